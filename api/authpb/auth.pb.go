@@ -54,6 +54,7 @@ func (Permission_Type) EnumDescriptor() ([]byte, []int) {
 
 type UserAddOptions struct {
 	NoPassword           bool     `protobuf:"varint,1,opt,name=no_password,json=noPassword,proto3" json:"no_password,omitempty"`
+	Immutable            bool     `protobuf:"varint,2,opt,name=immutable,proto3" json:"immutable,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -94,13 +95,15 @@ var xxx_messageInfo_UserAddOptions proto.InternalMessageInfo
 
 // User is a single entry in the bucket authUsers
 type User struct {
-	Name                 []byte          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Password             []byte          `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	Roles                []string        `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
-	Options              *UserAddOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Name     []byte          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Password []byte          `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Roles    []string        `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	Options  *UserAddOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	// role_grants holds expiry of roles that were granted to the user temporarily.
+	RoleGrants           []*RoleGrant `protobuf:"bytes,5,rep,name=role_grants,json=roleGrants,proto3" json:"role_grants,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *User) Reset()         { *m = User{} }
@@ -138,12 +141,15 @@ var xxx_messageInfo_User proto.InternalMessageInfo
 
 // Permission is a single entity
 type Permission struct {
-	PermType             Permission_Type `protobuf:"varint,1,opt,name=permType,proto3,enum=authpb.Permission_Type" json:"permType,omitempty"`
-	Key                  []byte          `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	RangeEnd             []byte          `protobuf:"bytes,3,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	PermType Permission_Type `protobuf:"varint,1,opt,name=permType,proto3,enum=authpb.Permission_Type" json:"permType,omitempty"`
+	Key      []byte          `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	RangeEnd []byte          `protobuf:"bytes,3,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+	// user_template makes key and range_end templates, in which {user} is replaced by name of the user the permission
+	// is checked for.
+	UserTemplate         bool     `protobuf:"varint,4,opt,name=user_template,json=userTemplate,proto3" json:"user_template,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Permission) Reset()         { *m = Permission{} }
@@ -181,11 +187,15 @@ var xxx_messageInfo_Permission proto.InternalMessageInfo
 
 // Role is a single entry in the bucket authRoles
 type Role struct {
-	Name                 []byte        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	KeyPermission        []*Permission `protobuf:"bytes,2,rep,name=keyPermission,proto3" json:"keyPermission,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	Name          []byte        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	KeyPermission []*Permission `protobuf:"bytes,2,rep,name=keyPermission,proto3" json:"keyPermission,omitempty"`
+	// quota_bytes limits total size of keys and values under ranges the role can write, zero means no limit.
+	QuotaBytes int64 `protobuf:"varint,3,opt,name=quota_bytes,json=quotaBytes,proto3" json:"quota_bytes,omitempty"`
+	// admin_prefix makes the role an admin scoped to keys with the prefix, empty if the role is not a scoped admin.
+	AdminPrefix          []byte   `protobuf:"bytes,4,opt,name=admin_prefix,json=adminPrefix,proto3" json:"admin_prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Role) Reset()         { *m = Role{} }
@@ -221,40 +231,92 @@ func (m *Role) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Role proto.InternalMessageInfo
 
+// RoleGrant is an expiry of a role granted to a user temporarily
+type RoleGrant struct {
+	Role string `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	// expire_time is unix time in seconds at which the role grant expires.
+	ExpireTime           int64    `protobuf:"varint,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoleGrant) Reset()         { *m = RoleGrant{} }
+func (m *RoleGrant) String() string { return proto.CompactTextString(m) }
+func (*RoleGrant) ProtoMessage()    {}
+func (*RoleGrant) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8bbd6f3875b0e874, []int{4}
+}
+func (m *RoleGrant) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RoleGrant) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RoleGrant.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RoleGrant) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoleGrant.Merge(m, src)
+}
+func (m *RoleGrant) XXX_Size() int {
+	return m.Size()
+}
+func (m *RoleGrant) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoleGrant.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoleGrant proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterEnum("authpb.Permission_Type", Permission_Type_name, Permission_Type_value)
 	proto.RegisterType((*UserAddOptions)(nil), "authpb.UserAddOptions")
 	proto.RegisterType((*User)(nil), "authpb.User")
 	proto.RegisterType((*Permission)(nil), "authpb.Permission")
 	proto.RegisterType((*Role)(nil), "authpb.Role")
+	proto.RegisterType((*RoleGrant)(nil), "authpb.RoleGrant")
 }
 
 func init() { proto.RegisterFile("auth.proto", fileDescriptor_8bbd6f3875b0e874) }
 
 var fileDescriptor_8bbd6f3875b0e874 = []byte{
-	// 338 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xcf, 0x4e, 0xea, 0x40,
-	0x14, 0xc6, 0x3b, 0xb4, 0x70, 0xdb, 0xc3, 0x85, 0x90, 0x13, 0x72, 0x6f, 0x83, 0x49, 0x6d, 0xba,
-	0x6a, 0x5c, 0x54, 0x85, 0x8d, 0x5b, 0x8c, 0x2c, 0x5c, 0x49, 0x26, 0x18, 0x97, 0xa4, 0xa4, 0x13,
-	0x24, 0xc0, 0x4c, 0x33, 0x83, 0x31, 0x6c, 0x7c, 0x0e, 0x17, 0x3e, 0x10, 0x4b, 0x1e, 0x41, 0xf0,
-	0x45, 0x4c, 0x67, 0xf8, 0x13, 0xa2, 0xbb, 0xef, 0x7c, 0xe7, 0xfb, 0x66, 0x7e, 0x99, 0x01, 0x48,
-	0x5f, 0x16, 0xcf, 0x49, 0x2e, 0xc5, 0x42, 0x60, 0xa5, 0xd0, 0xf9, 0xa8, 0xd5, 0x1c, 0x8b, 0xb1,
-	0xd0, 0xd6, 0x65, 0xa1, 0xcc, 0x36, 0xba, 0x86, 0xfa, 0xa3, 0x62, 0xb2, 0x9b, 0x65, 0x0f, 0xf9,
-	0x62, 0x22, 0xb8, 0xc2, 0x73, 0xa8, 0x72, 0x31, 0xcc, 0x53, 0xa5, 0x5e, 0x85, 0xcc, 0x7c, 0x12,
-	0x92, 0xd8, 0xa5, 0xc0, 0x45, 0x7f, 0xe7, 0x44, 0x6f, 0xe0, 0x14, 0x15, 0x44, 0x70, 0x78, 0x3a,
-	0x67, 0x3a, 0xf1, 0x97, 0x6a, 0x8d, 0x2d, 0x70, 0x0f, 0xcd, 0x92, 0xf6, 0x0f, 0x33, 0x36, 0xa1,
-	0x2c, 0xc5, 0x8c, 0x29, 0xdf, 0x0e, 0xed, 0xd8, 0xa3, 0x66, 0xc0, 0x2b, 0xf8, 0x23, 0xcc, 0xcd,
-	0xbe, 0x13, 0x92, 0xb8, 0xda, 0xfe, 0x97, 0x18, 0xe0, 0xe4, 0x94, 0x8b, 0xee, 0x63, 0xd1, 0x07,
-	0x01, 0xe8, 0x33, 0x39, 0x9f, 0x28, 0x35, 0x11, 0x1c, 0x3b, 0xe0, 0xe6, 0x4c, 0xce, 0x07, 0xcb,
-	0xdc, 0xa0, 0xd4, 0xdb, 0xff, 0xf7, 0x27, 0x1c, 0x53, 0x49, 0xb1, 0xa6, 0x87, 0x20, 0x36, 0xc0,
-	0x9e, 0xb2, 0xe5, 0x0e, 0xb1, 0x90, 0x78, 0x06, 0x9e, 0x4c, 0xf9, 0x98, 0x0d, 0x19, 0xcf, 0x7c,
-	0xdb, 0xa0, 0x6b, 0xa3, 0xc7, 0xb3, 0xe8, 0x02, 0x1c, 0x5d, 0x73, 0xc1, 0xa1, 0xbd, 0xee, 0x5d,
-	0xc3, 0x42, 0x0f, 0xca, 0x4f, 0xf4, 0x7e, 0xd0, 0x6b, 0x10, 0xac, 0x81, 0x57, 0x98, 0x66, 0x2c,
-	0x45, 0x03, 0x70, 0xa8, 0x98, 0xb1, 0x5f, 0x9f, 0xe7, 0x06, 0x6a, 0x53, 0xb6, 0x3c, 0x62, 0xf9,
-	0xa5, 0xd0, 0x8e, 0xab, 0x6d, 0xfc, 0x09, 0x4c, 0x4f, 0x83, 0xb7, 0xfe, 0x6a, 0x13, 0x58, 0xeb,
-	0x4d, 0x60, 0xad, 0xb6, 0x01, 0x59, 0x6f, 0x03, 0xf2, 0xb9, 0x0d, 0xc8, 0xfb, 0x57, 0x60, 0x8d,
-	0x2a, 0xfa, 0x23, 0x3b, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x61, 0x66, 0xc6, 0x9d, 0xf4, 0x01,
-	0x00, 0x00,
+	// 472 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0xdf, 0x6e, 0xd3, 0x30,
+	0x14, 0xc6, 0xeb, 0x26, 0x1d, 0xcd, 0x49, 0x3b, 0x15, 0x6b, 0x82, 0x68, 0xa0, 0x50, 0xc2, 0x4d,
+	0xc4, 0x45, 0x41, 0xdd, 0x0d, 0x97, 0x6c, 0xa2, 0x42, 0x5c, 0xad, 0xb2, 0x8a, 0xb8, 0x8c, 0x52,
+	0xe5, 0x50, 0xa2, 0x35, 0xb6, 0xb1, 0x5d, 0xb1, 0xbe, 0xc9, 0x9e, 0x83, 0x37, 0xe0, 0x6e, 0x97,
+	0x7b, 0x04, 0x56, 0x5e, 0x04, 0xd9, 0xee, 0x1f, 0x4d, 0x70, 0xf7, 0xf9, 0x77, 0x8e, 0x4f, 0xbe,
+	0xef, 0xc4, 0x00, 0xe5, 0xca, 0x7c, 0x1b, 0x49, 0x25, 0x8c, 0xa0, 0x47, 0x56, 0xcb, 0xf9, 0xe9,
+	0xc9, 0x42, 0x2c, 0x84, 0x43, 0x6f, 0xac, 0xf2, 0xd5, 0xec, 0x12, 0x8e, 0x3f, 0x6b, 0x54, 0xe7,
+	0x55, 0x75, 0x29, 0x4d, 0x2d, 0xb8, 0xa6, 0x2f, 0x20, 0xe6, 0xa2, 0x90, 0xa5, 0xd6, 0x3f, 0x84,
+	0xaa, 0x12, 0x32, 0x24, 0x79, 0x97, 0x01, 0x17, 0xd3, 0x2d, 0xa1, 0xcf, 0x21, 0xaa, 0x9b, 0x66,
+	0x65, 0xca, 0xf9, 0x12, 0x93, 0xb6, 0x2b, 0x1f, 0x40, 0xf6, 0x93, 0x40, 0x68, 0x27, 0x52, 0x0a,
+	0x21, 0x2f, 0x1b, 0x74, 0x03, 0x7a, 0xcc, 0x69, 0x7a, 0x0a, 0xdd, 0xfd, 0xe0, 0xb6, 0xe3, 0xfb,
+	0x33, 0x3d, 0x81, 0x8e, 0x12, 0x4b, 0xd4, 0x49, 0x30, 0x0c, 0xf2, 0x88, 0xf9, 0x03, 0x7d, 0x0b,
+	0x8f, 0x84, 0x37, 0x96, 0x84, 0x43, 0x92, 0xc7, 0xe3, 0x27, 0x23, 0x9f, 0x67, 0xf4, 0xd0, 0x36,
+	0xdb, 0xb5, 0xd1, 0x31, 0xc4, 0xf6, 0x6a, 0xb1, 0x50, 0x25, 0x37, 0x3a, 0xe9, 0x0c, 0x83, 0x3c,
+	0x1e, 0x3f, 0xde, 0xdd, 0x62, 0x62, 0x89, 0x1f, 0x6d, 0x85, 0x81, 0xda, 0x49, 0x9d, 0xfd, 0x22,
+	0x00, 0x53, 0x54, 0x4d, 0xad, 0x75, 0x2d, 0x38, 0x3d, 0x83, 0xae, 0x44, 0xd5, 0xcc, 0xd6, 0xd2,
+	0xdb, 0x3f, 0x1e, 0x3f, 0xdd, 0xdd, 0x3f, 0x74, 0x8d, 0x6c, 0x99, 0xed, 0x1b, 0xe9, 0x00, 0x82,
+	0x2b, 0x5c, 0x6f, 0x63, 0x59, 0x49, 0x9f, 0x41, 0xa4, 0x4a, 0xbe, 0xc0, 0x02, 0x79, 0x95, 0x04,
+	0x3e, 0xae, 0x03, 0x13, 0x5e, 0xd1, 0x57, 0xd0, 0x5f, 0x69, 0x54, 0x85, 0xc1, 0x46, 0x2e, 0x4b,
+	0x83, 0x2e, 0x5e, 0x97, 0xf5, 0x2c, 0x9c, 0x6d, 0x59, 0xf6, 0x1a, 0x42, 0x37, 0xbb, 0x0b, 0x21,
+	0x9b, 0x9c, 0x7f, 0x18, 0xb4, 0x68, 0x04, 0x9d, 0x2f, 0xec, 0xd3, 0x6c, 0x32, 0x20, 0xb4, 0x0f,
+	0x91, 0x85, 0xfe, 0xd8, 0xce, 0x6e, 0x08, 0x84, 0x36, 0xdd, 0x7f, 0x17, 0xff, 0x0e, 0xfa, 0x57,
+	0xb8, 0x3e, 0x98, 0x4f, 0xda, 0x6e, 0x2d, 0xf4, 0xdf, 0x58, 0xec, 0x61, 0xa3, 0x7d, 0x0e, 0xdf,
+	0x57, 0xc2, 0x94, 0xc5, 0x7c, 0x6d, 0xdc, 0xcf, 0x21, 0x79, 0xc0, 0xc0, 0xa1, 0x0b, 0x4b, 0xe8,
+	0x4b, 0xe8, 0x95, 0x55, 0x53, 0xf3, 0x42, 0x2a, 0xfc, 0x5a, 0x5f, 0xbb, 0x1c, 0x3d, 0x16, 0x3b,
+	0x36, 0x75, 0x28, 0x7b, 0x0f, 0xd1, 0x7e, 0xef, 0xd6, 0x9e, 0xdd, 0xbc, 0xb3, 0x17, 0x31, 0xa7,
+	0xed, 0x47, 0xf0, 0x5a, 0xd6, 0x0a, 0x0b, 0x53, 0x37, 0xfe, 0x51, 0x05, 0x0c, 0x3c, 0x9a, 0xd5,
+	0x0d, 0x5e, 0x24, 0xb7, 0xf7, 0x69, 0xeb, 0xee, 0x3e, 0x6d, 0xdd, 0x6e, 0x52, 0x72, 0xb7, 0x49,
+	0xc9, 0xef, 0x4d, 0x4a, 0x6e, 0xfe, 0xa4, 0xad, 0xf9, 0x91, 0x7b, 0xc7, 0x67, 0x7f, 0x07, 0x00,
+	0x5c, 0xe3, 0xfe, 0x88, 0xf3, 0x02, 0x00, 0x00,
 }
 
 func (m *UserAddOptions) Marshal() (dAtA []byte, err error) {
@@ -281,6 +343,16 @@ func (m *UserAddOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Immutable {
+		i--
+		if m.Immutable {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
 	if m.NoPassword {
 		i--
 		if m.NoPassword {
@@ -318,6 +390,20 @@ func (m *User) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.RoleGrants) > 0 {
+		for iNdEx := len(m.RoleGrants) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.RoleGrants[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAuth(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
 	if m.Options != nil {
 		{
 			size, err := m.Options.MarshalToSizedBuffer(dAtA[:i])
@@ -380,6 +466,16 @@ func (m *Permission) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.UserTemplate {
+		i--
+		if m.UserTemplate {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.RangeEnd) > 0 {
 		i -= len(m.RangeEnd)
 		copy(dAtA[i:], m.RangeEnd)
@@ -426,6 +522,18 @@ func (m *Role) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.AdminPrefix) > 0 {
+		i -= len(m.AdminPrefix)
+		copy(dAtA[i:], m.AdminPrefix)
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.AdminPrefix)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.QuotaBytes != 0 {
+		i = encodeVarintAuth(dAtA, i, uint64(m.QuotaBytes))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.KeyPermission) > 0 {
 		for iNdEx := len(m.KeyPermission) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -450,6 +558,45 @@ func (m *Role) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *RoleGrant) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RoleGrant) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RoleGrant) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ExpireTime != 0 {
+		i = encodeVarintAuth(dAtA, i, uint64(m.ExpireTime))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Role) > 0 {
+		i -= len(m.Role)
+		copy(dAtA[i:], m.Role)
+		i = encodeVarintAuth(dAtA, i, uint64(len(m.Role)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintAuth(dAtA []byte, offset int, v uint64) int {
 	offset -= sovAuth(v)
 	base := offset
@@ -470,6 +617,9 @@ func (m *UserAddOptions) Size() (n int) {
 	if m.NoPassword {
 		n += 2
 	}
+	if m.Immutable {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -500,6 +650,12 @@ func (m *User) Size() (n int) {
 		l = m.Options.Size()
 		n += 1 + l + sovAuth(uint64(l))
 	}
+	if len(m.RoleGrants) > 0 {
+		for _, e := range m.RoleGrants {
+			l = e.Size()
+			n += 1 + l + sovAuth(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -523,6 +679,9 @@ func (m *Permission) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovAuth(uint64(l))
 	}
+	if m.UserTemplate {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -545,6 +704,32 @@ func (m *Role) Size() (n int) {
 			n += 1 + l + sovAuth(uint64(l))
 		}
 	}
+	if m.QuotaBytes != 0 {
+		n += 1 + sovAuth(uint64(m.QuotaBytes))
+	}
+	l = len(m.AdminPrefix)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *RoleGrant) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Role)
+	if l > 0 {
+		n += 1 + l + sovAuth(uint64(l))
+	}
+	if m.ExpireTime != 0 {
+		n += 1 + sovAuth(uint64(m.ExpireTime))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -606,6 +791,26 @@ func (m *UserAddOptions) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.NoPassword = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Immutable", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Immutable = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -793,6 +998,40 @@ func (m *User) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RoleGrants", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RoleGrants = append(m.RoleGrants, &RoleGrant{})
+			if err := m.RoleGrants[len(m.RoleGrants)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -931,6 +1170,26 @@ func (m *Permission) Unmarshal(dAtA []byte) error {
 				m.RangeEnd = []byte{}
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserTemplate", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.UserTemplate = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
@@ -1050,6 +1309,161 @@ func (m *Role) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuotaBytes", wireType)
+			}
+			m.QuotaBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QuotaBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AdminPrefix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AdminPrefix = append(m.AdminPrefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.AdminPrefix == nil {
+				m.AdminPrefix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAuth(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RoleGrant) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAuth
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RoleGrant: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RoleGrant: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Role", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAuth
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAuth
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Role = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpireTime", wireType)
+			}
+			m.ExpireTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuth
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExpireTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuth(dAtA[iNdEx:])
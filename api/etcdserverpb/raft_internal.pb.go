@@ -31,7 +31,10 @@ type RequestHeader struct {
 	// username is a username that is associated with an auth token of gRPC connection
 	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
 	// auth_revision is a revision number of auth.authStore. It is not related to mvcc
-	AuthRevision         uint64   `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
+	AuthRevision uint64 `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
+	// time is a unix time in seconds at which the request was received, so that
+	// every member checks expiry of temporary role grants against the same time
+	Time                 int64    `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -73,41 +76,51 @@ var xxx_messageInfo_RequestHeader proto.InternalMessageInfo
 // An InternalRaftRequest is the union of all requests which can be
 // sent via raft.
 type InternalRaftRequest struct {
-	Header                   *RequestHeader                            `protobuf:"bytes,100,opt,name=header,proto3" json:"header,omitempty"`
-	ID                       uint64                                    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	V2                       *Request                                  `protobuf:"bytes,2,opt,name=v2,proto3" json:"v2,omitempty"`
-	Range                    *RangeRequest                             `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`
-	Put                      *PutRequest                               `protobuf:"bytes,4,opt,name=put,proto3" json:"put,omitempty"`
-	DeleteRange              *DeleteRangeRequest                       `protobuf:"bytes,5,opt,name=delete_range,json=deleteRange,proto3" json:"delete_range,omitempty"`
-	Txn                      *TxnRequest                               `protobuf:"bytes,6,opt,name=txn,proto3" json:"txn,omitempty"`
-	Compaction               *CompactionRequest                        `protobuf:"bytes,7,opt,name=compaction,proto3" json:"compaction,omitempty"`
-	LeaseGrant               *LeaseGrantRequest                        `protobuf:"bytes,8,opt,name=lease_grant,json=leaseGrant,proto3" json:"lease_grant,omitempty"`
-	LeaseRevoke              *LeaseRevokeRequest                       `protobuf:"bytes,9,opt,name=lease_revoke,json=leaseRevoke,proto3" json:"lease_revoke,omitempty"`
-	Alarm                    *AlarmRequest                             `protobuf:"bytes,10,opt,name=alarm,proto3" json:"alarm,omitempty"`
-	LeaseCheckpoint          *LeaseCheckpointRequest                   `protobuf:"bytes,11,opt,name=lease_checkpoint,json=leaseCheckpoint,proto3" json:"lease_checkpoint,omitempty"`
-	AuthEnable               *AuthEnableRequest                        `protobuf:"bytes,1000,opt,name=auth_enable,json=authEnable,proto3" json:"auth_enable,omitempty"`
-	AuthDisable              *AuthDisableRequest                       `protobuf:"bytes,1011,opt,name=auth_disable,json=authDisable,proto3" json:"auth_disable,omitempty"`
-	AuthStatus               *AuthStatusRequest                        `protobuf:"bytes,1013,opt,name=auth_status,json=authStatus,proto3" json:"auth_status,omitempty"`
-	Authenticate             *InternalAuthenticateRequest              `protobuf:"bytes,1012,opt,name=authenticate,proto3" json:"authenticate,omitempty"`
-	AuthUserAdd              *AuthUserAddRequest                       `protobuf:"bytes,1100,opt,name=auth_user_add,json=authUserAdd,proto3" json:"auth_user_add,omitempty"`
-	AuthUserDelete           *AuthUserDeleteRequest                    `protobuf:"bytes,1101,opt,name=auth_user_delete,json=authUserDelete,proto3" json:"auth_user_delete,omitempty"`
-	AuthUserGet              *AuthUserGetRequest                       `protobuf:"bytes,1102,opt,name=auth_user_get,json=authUserGet,proto3" json:"auth_user_get,omitempty"`
-	AuthUserChangePassword   *AuthUserChangePasswordRequest            `protobuf:"bytes,1103,opt,name=auth_user_change_password,json=authUserChangePassword,proto3" json:"auth_user_change_password,omitempty"`
-	AuthUserGrantRole        *AuthUserGrantRoleRequest                 `protobuf:"bytes,1104,opt,name=auth_user_grant_role,json=authUserGrantRole,proto3" json:"auth_user_grant_role,omitempty"`
-	AuthUserRevokeRole       *AuthUserRevokeRoleRequest                `protobuf:"bytes,1105,opt,name=auth_user_revoke_role,json=authUserRevokeRole,proto3" json:"auth_user_revoke_role,omitempty"`
-	AuthUserList             *AuthUserListRequest                      `protobuf:"bytes,1106,opt,name=auth_user_list,json=authUserList,proto3" json:"auth_user_list,omitempty"`
-	AuthRoleList             *AuthRoleListRequest                      `protobuf:"bytes,1107,opt,name=auth_role_list,json=authRoleList,proto3" json:"auth_role_list,omitempty"`
-	AuthRoleAdd              *AuthRoleAddRequest                       `protobuf:"bytes,1200,opt,name=auth_role_add,json=authRoleAdd,proto3" json:"auth_role_add,omitempty"`
-	AuthRoleDelete           *AuthRoleDeleteRequest                    `protobuf:"bytes,1201,opt,name=auth_role_delete,json=authRoleDelete,proto3" json:"auth_role_delete,omitempty"`
-	AuthRoleGet              *AuthRoleGetRequest                       `protobuf:"bytes,1202,opt,name=auth_role_get,json=authRoleGet,proto3" json:"auth_role_get,omitempty"`
-	AuthRoleGrantPermission  *AuthRoleGrantPermissionRequest           `protobuf:"bytes,1203,opt,name=auth_role_grant_permission,json=authRoleGrantPermission,proto3" json:"auth_role_grant_permission,omitempty"`
-	AuthRoleRevokePermission *AuthRoleRevokePermissionRequest          `protobuf:"bytes,1204,opt,name=auth_role_revoke_permission,json=authRoleRevokePermission,proto3" json:"auth_role_revoke_permission,omitempty"`
-	ClusterVersionSet        *membershippb.ClusterVersionSetRequest    `protobuf:"bytes,1300,opt,name=cluster_version_set,json=clusterVersionSet,proto3" json:"cluster_version_set,omitempty"`
-	ClusterMemberAttrSet     *membershippb.ClusterMemberAttrSetRequest `protobuf:"bytes,1301,opt,name=cluster_member_attr_set,json=clusterMemberAttrSet,proto3" json:"cluster_member_attr_set,omitempty"`
-	DowngradeInfoSet         *membershippb.DowngradeInfoSetRequest     `protobuf:"bytes,1302,opt,name=downgrade_info_set,json=downgradeInfoSet,proto3" json:"downgrade_info_set,omitempty"`
-	XXX_NoUnkeyedLiteral     struct{}                                  `json:"-"`
-	XXX_unrecognized         []byte                                    `json:"-"`
-	XXX_sizecache            int32                                     `json:"-"`
+	Header                        *RequestHeader                              `protobuf:"bytes,100,opt,name=header,proto3" json:"header,omitempty"`
+	ID                            uint64                                      `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	V2                            *Request                                    `protobuf:"bytes,2,opt,name=v2,proto3" json:"v2,omitempty"`
+	Range                         *RangeRequest                               `protobuf:"bytes,3,opt,name=range,proto3" json:"range,omitempty"`
+	Put                           *PutRequest                                 `protobuf:"bytes,4,opt,name=put,proto3" json:"put,omitempty"`
+	DeleteRange                   *DeleteRangeRequest                         `protobuf:"bytes,5,opt,name=delete_range,json=deleteRange,proto3" json:"delete_range,omitempty"`
+	Txn                           *TxnRequest                                 `protobuf:"bytes,6,opt,name=txn,proto3" json:"txn,omitempty"`
+	Compaction                    *CompactionRequest                          `protobuf:"bytes,7,opt,name=compaction,proto3" json:"compaction,omitempty"`
+	LeaseGrant                    *LeaseGrantRequest                          `protobuf:"bytes,8,opt,name=lease_grant,json=leaseGrant,proto3" json:"lease_grant,omitempty"`
+	LeaseRevoke                   *LeaseRevokeRequest                         `protobuf:"bytes,9,opt,name=lease_revoke,json=leaseRevoke,proto3" json:"lease_revoke,omitempty"`
+	Alarm                         *AlarmRequest                               `protobuf:"bytes,10,opt,name=alarm,proto3" json:"alarm,omitempty"`
+	LeaseCheckpoint               *LeaseCheckpointRequest                     `protobuf:"bytes,11,opt,name=lease_checkpoint,json=leaseCheckpoint,proto3" json:"lease_checkpoint,omitempty"`
+	AuthEnable                    *AuthEnableRequest                          `protobuf:"bytes,1000,opt,name=auth_enable,json=authEnable,proto3" json:"auth_enable,omitempty"`
+	AuthDisable                   *AuthDisableRequest                         `protobuf:"bytes,1011,opt,name=auth_disable,json=authDisable,proto3" json:"auth_disable,omitempty"`
+	AuthStatus                    *AuthStatusRequest                          `protobuf:"bytes,1013,opt,name=auth_status,json=authStatus,proto3" json:"auth_status,omitempty"`
+	AuthWhoAmI                    *AuthWhoAmIRequest                          `protobuf:"bytes,1014,opt,name=auth_who_am_i,json=authWhoAmI,proto3" json:"auth_who_am_i,omitempty"`
+	Authenticate                  *InternalAuthenticateRequest                `protobuf:"bytes,1012,opt,name=authenticate,proto3" json:"authenticate,omitempty"`
+	AuthUserAdd                   *AuthUserAddRequest                         `protobuf:"bytes,1100,opt,name=auth_user_add,json=authUserAdd,proto3" json:"auth_user_add,omitempty"`
+	AuthUserDelete                *AuthUserDeleteRequest                      `protobuf:"bytes,1101,opt,name=auth_user_delete,json=authUserDelete,proto3" json:"auth_user_delete,omitempty"`
+	AuthUserGet                   *AuthUserGetRequest                         `protobuf:"bytes,1102,opt,name=auth_user_get,json=authUserGet,proto3" json:"auth_user_get,omitempty"`
+	AuthUserChangePassword        *AuthUserChangePasswordRequest              `protobuf:"bytes,1103,opt,name=auth_user_change_password,json=authUserChangePassword,proto3" json:"auth_user_change_password,omitempty"`
+	AuthUserGrantRole             *AuthUserGrantRoleRequest                   `protobuf:"bytes,1104,opt,name=auth_user_grant_role,json=authUserGrantRole,proto3" json:"auth_user_grant_role,omitempty"`
+	AuthUserRevokeRole            *AuthUserRevokeRoleRequest                  `protobuf:"bytes,1105,opt,name=auth_user_revoke_role,json=authUserRevokeRole,proto3" json:"auth_user_revoke_role,omitempty"`
+	AuthUserList                  *AuthUserListRequest                        `protobuf:"bytes,1106,opt,name=auth_user_list,json=authUserList,proto3" json:"auth_user_list,omitempty"`
+	AuthRoleList                  *AuthRoleListRequest                        `protobuf:"bytes,1107,opt,name=auth_role_list,json=authRoleList,proto3" json:"auth_role_list,omitempty"`
+	AuthUserSetRoles              *AuthUserSetRolesRequest                    `protobuf:"bytes,1108,opt,name=auth_user_set_roles,json=authUserSetRoles,proto3" json:"auth_user_set_roles,omitempty"`
+	AuthUserPermissionsAtRevision *AuthUserPermissionsAtRevisionRequest       `protobuf:"bytes,1109,opt,name=auth_user_permissions_at_revision,json=authUserPermissionsAtRevision,proto3" json:"auth_user_permissions_at_revision,omitempty"`
+	AuthUserSetImmutable          *AuthUserSetImmutableRequest                `protobuf:"bytes,1110,opt,name=auth_user_set_immutable,json=authUserSetImmutable,proto3" json:"auth_user_set_immutable,omitempty"`
+	InternalAuthUserGrantRole     *InternalAuthUserGrantRoleRequest           `protobuf:"bytes,1111,opt,name=internal_auth_user_grant_role,json=internalAuthUserGrantRole,proto3" json:"internal_auth_user_grant_role,omitempty"`
+	InternalAuthUserSetRoles      *InternalAuthUserSetRolesRequest            `protobuf:"bytes,1112,opt,name=internal_auth_user_set_roles,json=internalAuthUserSetRoles,proto3" json:"internal_auth_user_set_roles,omitempty"`
+	AuthRoleAdd                   *AuthRoleAddRequest                         `protobuf:"bytes,1200,opt,name=auth_role_add,json=authRoleAdd,proto3" json:"auth_role_add,omitempty"`
+	AuthRoleDelete                *AuthRoleDeleteRequest                      `protobuf:"bytes,1201,opt,name=auth_role_delete,json=authRoleDelete,proto3" json:"auth_role_delete,omitempty"`
+	AuthRoleGet                   *AuthRoleGetRequest                         `protobuf:"bytes,1202,opt,name=auth_role_get,json=authRoleGet,proto3" json:"auth_role_get,omitempty"`
+	AuthRoleGrantPermission       *AuthRoleGrantPermissionRequest             `protobuf:"bytes,1203,opt,name=auth_role_grant_permission,json=authRoleGrantPermission,proto3" json:"auth_role_grant_permission,omitempty"`
+	AuthRoleRevokePermission      *AuthRoleRevokePermissionRequest            `protobuf:"bytes,1204,opt,name=auth_role_revoke_permission,json=authRoleRevokePermission,proto3" json:"auth_role_revoke_permission,omitempty"`
+	AuthUsersWithRole             *AuthUsersWithRoleRequest                   `protobuf:"bytes,1205,opt,name=auth_users_with_role,json=authUsersWithRole,proto3" json:"auth_users_with_role,omitempty"`
+	AuthRevokeExpiredRoleGrants   *InternalAuthRevokeExpiredRoleGrantsRequest `protobuf:"bytes,1206,opt,name=auth_revoke_expired_role_grants,json=authRevokeExpiredRoleGrants,proto3" json:"auth_revoke_expired_role_grants,omitempty"`
+	AuthRoleSetQuota              *AuthRoleSetQuotaRequest                    `protobuf:"bytes,1207,opt,name=auth_role_set_quota,json=authRoleSetQuota,proto3" json:"auth_role_set_quota,omitempty"`
+	AuthRoleSetAdminPrefix        *AuthRoleSetAdminPrefixRequest              `protobuf:"bytes,1208,opt,name=auth_role_set_admin_prefix,json=authRoleSetAdminPrefix,proto3" json:"auth_role_set_admin_prefix,omitempty"`
+	ClusterVersionSet             *membershippb.ClusterVersionSetRequest      `protobuf:"bytes,1300,opt,name=cluster_version_set,json=clusterVersionSet,proto3" json:"cluster_version_set,omitempty"`
+	ClusterMemberAttrSet          *membershippb.ClusterMemberAttrSetRequest   `protobuf:"bytes,1301,opt,name=cluster_member_attr_set,json=clusterMemberAttrSet,proto3" json:"cluster_member_attr_set,omitempty"`
+	DowngradeInfoSet              *membershippb.DowngradeInfoSetRequest       `protobuf:"bytes,1302,opt,name=downgrade_info_set,json=downgradeInfoSet,proto3" json:"downgrade_info_set,omitempty"`
+	XXX_NoUnkeyedLiteral          struct{}                                    `json:"-"`
+	XXX_unrecognized              []byte                                      `json:"-"`
+	XXX_sizecache                 int32                                       `json:"-"`
 }
 
 func (m *InternalRaftRequest) Reset()         { *m = InternalRaftRequest{} }
@@ -189,7 +202,10 @@ type InternalAuthenticateRequest struct {
 	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	// simple_token is generated in API layer (etcdserver/v3_server.go)
-	SimpleToken          string   `protobuf:"bytes,3,opt,name=simple_token,json=simpleToken,proto3" json:"simple_token,omitempty"`
+	SimpleToken string `protobuf:"bytes,3,opt,name=simple_token,json=simpleToken,proto3" json:"simple_token,omitempty"`
+	// assertion is set if user was authenticated by an assertion instead of password,
+	// so users without password can be authenticated.
+	Assertion            bool     `protobuf:"varint,4,opt,name=assertion,proto3" json:"assertion,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -228,83 +244,248 @@ func (m *InternalAuthenticateRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_InternalAuthenticateRequest proto.InternalMessageInfo
 
+// InternalAuthUserGrantRoleRequest carries values of a role grant that are computed by etcdserver when
+// the request is proposed, so all members apply the same grant regardless of their clocks and configuration.
+type InternalAuthUserGrantRoleRequest struct {
+	Grant *AuthUserGrantRoleRequest `protobuf:"bytes,1,opt,name=grant,proto3" json:"grant,omitempty"`
+	// expire_time is unix time in seconds at which the role grant expires, computed from TTL. Zero grants the role permanently.
+	ExpireTime int64 `protobuf:"varint,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// max_roles is the maximum number of roles the user may have after the grant. Zero means unlimited.
+	MaxRoles             int64    `protobuf:"varint,3,opt,name=max_roles,json=maxRoles,proto3" json:"max_roles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InternalAuthUserGrantRoleRequest) Reset()         { *m = InternalAuthUserGrantRoleRequest{} }
+func (m *InternalAuthUserGrantRoleRequest) String() string { return proto.CompactTextString(m) }
+func (*InternalAuthUserGrantRoleRequest) ProtoMessage()    {}
+func (*InternalAuthUserGrantRoleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4c9a9be0cfca103, []int{4}
+}
+func (m *InternalAuthUserGrantRoleRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InternalAuthUserGrantRoleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InternalAuthUserGrantRoleRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InternalAuthUserGrantRoleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InternalAuthUserGrantRoleRequest.Merge(m, src)
+}
+func (m *InternalAuthUserGrantRoleRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *InternalAuthUserGrantRoleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InternalAuthUserGrantRoleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InternalAuthUserGrantRoleRequest proto.InternalMessageInfo
+
+type InternalAuthUserSetRolesRequest struct {
+	SetRoles *AuthUserSetRolesRequest `protobuf:"bytes,1,opt,name=set_roles,json=setRoles,proto3" json:"set_roles,omitempty"`
+	// max_roles is the maximum number of roles the user may have after the request. Zero means unlimited.
+	MaxRoles             int64    `protobuf:"varint,2,opt,name=max_roles,json=maxRoles,proto3" json:"max_roles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InternalAuthUserSetRolesRequest) Reset()         { *m = InternalAuthUserSetRolesRequest{} }
+func (m *InternalAuthUserSetRolesRequest) String() string { return proto.CompactTextString(m) }
+func (*InternalAuthUserSetRolesRequest) ProtoMessage()    {}
+func (*InternalAuthUserSetRolesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4c9a9be0cfca103, []int{5}
+}
+func (m *InternalAuthUserSetRolesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InternalAuthUserSetRolesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InternalAuthUserSetRolesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InternalAuthUserSetRolesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InternalAuthUserSetRolesRequest.Merge(m, src)
+}
+func (m *InternalAuthUserSetRolesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *InternalAuthUserSetRolesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InternalAuthUserSetRolesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InternalAuthUserSetRolesRequest proto.InternalMessageInfo
+
+// InternalAuthRevokeExpiredRoleGrantsRequest is proposed by the leader to revoke role grants that have expired.
+// Expiry is checked against time observed by the leader, so all members revoke the same grants.
+type InternalAuthRevokeExpiredRoleGrantsRequest struct {
+	// now is unix time in seconds at which the leader proposed the request.
+	Now                  int64    `protobuf:"varint,1,opt,name=now,proto3" json:"now,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) Reset() {
+	*m = InternalAuthRevokeExpiredRoleGrantsRequest{}
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) String() string {
+	return proto.CompactTextString(m)
+}
+func (*InternalAuthRevokeExpiredRoleGrantsRequest) ProtoMessage() {}
+func (*InternalAuthRevokeExpiredRoleGrantsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4c9a9be0cfca103, []int{6}
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InternalAuthRevokeExpiredRoleGrantsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InternalAuthRevokeExpiredRoleGrantsRequest.Merge(m, src)
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InternalAuthRevokeExpiredRoleGrantsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InternalAuthRevokeExpiredRoleGrantsRequest proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*RequestHeader)(nil), "etcdserverpb.RequestHeader")
 	proto.RegisterType((*InternalRaftRequest)(nil), "etcdserverpb.InternalRaftRequest")
 	proto.RegisterType((*EmptyResponse)(nil), "etcdserverpb.EmptyResponse")
 	proto.RegisterType((*InternalAuthenticateRequest)(nil), "etcdserverpb.InternalAuthenticateRequest")
+	proto.RegisterType((*InternalAuthUserGrantRoleRequest)(nil), "etcdserverpb.InternalAuthUserGrantRoleRequest")
+	proto.RegisterType((*InternalAuthUserSetRolesRequest)(nil), "etcdserverpb.InternalAuthUserSetRolesRequest")
+	proto.RegisterType((*InternalAuthRevokeExpiredRoleGrantsRequest)(nil), "etcdserverpb.InternalAuthRevokeExpiredRoleGrantsRequest")
 }
 
 func init() { proto.RegisterFile("raft_internal.proto", fileDescriptor_b4c9a9be0cfca103) }
 
 var fileDescriptor_b4c9a9be0cfca103 = []byte{
-	// 1054 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0x5d, 0x6f, 0x1b, 0x45,
-	0x14, 0xad, 0xd3, 0x34, 0x89, 0xc7, 0x49, 0x9a, 0x4e, 0x52, 0x3a, 0x38, 0x92, 0x71, 0x03, 0x2d,
-	0x01, 0x8a, 0x53, 0x1c, 0x78, 0xe1, 0x05, 0x5c, 0x3b, 0x4a, 0x83, 0x4a, 0x15, 0x6d, 0x0b, 0xaa,
-	0x84, 0xd0, 0x32, 0xde, 0xbd, 0xb1, 0xb7, 0x59, 0xef, 0x2e, 0x33, 0x63, 0x37, 0x7d, 0xe5, 0x91,
-	0x67, 0x40, 0xfc, 0x0c, 0x3e, 0xff, 0x43, 0x85, 0xf8, 0x28, 0xf0, 0x07, 0x20, 0xbc, 0xf0, 0x0e,
-	0xbc, 0xa3, 0xf9, 0xd8, 0x5d, 0xaf, 0x3d, 0xce, 0xdb, 0xfa, 0xde, 0x73, 0xcf, 0x39, 0x33, 0x73,
-	0xef, 0x78, 0xd0, 0x3a, 0xa3, 0x47, 0xc2, 0x0d, 0x22, 0x01, 0x2c, 0xa2, 0x61, 0x23, 0x61, 0xb1,
-	0x88, 0xf1, 0x32, 0x08, 0xcf, 0xe7, 0xc0, 0x46, 0xc0, 0x92, 0x6e, 0x75, 0xa3, 0x17, 0xf7, 0x62,
-	0x95, 0xd8, 0x91, 0x5f, 0x1a, 0x53, 0x5d, 0xcb, 0x31, 0x26, 0x52, 0x66, 0x89, 0x67, 0x3e, 0xeb,
-	0x32, 0xb9, 0x43, 0x93, 0x60, 0x67, 0x04, 0x8c, 0x07, 0x71, 0x94, 0x74, 0xd3, 0x2f, 0x83, 0xb8,
-	0x9e, 0x21, 0x06, 0x30, 0xe8, 0x02, 0xe3, 0xfd, 0x20, 0x49, 0xba, 0x63, 0x3f, 0x34, 0x6e, 0x8b,
-	0xa1, 0x15, 0x07, 0x3e, 0x1e, 0x02, 0x17, 0xb7, 0x81, 0xfa, 0xc0, 0xf0, 0x2a, 0x9a, 0x3b, 0xe8,
-	0x90, 0x52, 0xbd, 0xb4, 0x3d, 0xef, 0xcc, 0x1d, 0x74, 0x70, 0x15, 0x2d, 0x0d, 0xb9, 0x34, 0x3f,
-	0x00, 0x32, 0x57, 0x2f, 0x6d, 0x97, 0x9d, 0xec, 0x37, 0xbe, 0x81, 0x56, 0xe8, 0x50, 0xf4, 0x5d,
-	0x06, 0xa3, 0x40, 0x6a, 0x93, 0xf3, 0xb2, 0xec, 0xd6, 0xe2, 0xa7, 0xdf, 0x93, 0xf3, 0xbb, 0x8d,
-	0xd7, 0x9c, 0x65, 0x99, 0x75, 0x4c, 0xf2, 0xcd, 0xc5, 0x4f, 0x54, 0xf8, 0xe6, 0xd6, 0x0f, 0x18,
-	0xad, 0x1f, 0x98, 0x1d, 0x71, 0xe8, 0x91, 0x30, 0x06, 0xf0, 0x2e, 0x5a, 0xe8, 0x2b, 0x13, 0xc4,
-	0xaf, 0x97, 0xb6, 0x2b, 0xcd, 0xcd, 0xc6, 0xf8, 0x3e, 0x35, 0x0a, 0x3e, 0x1d, 0x03, 0x9d, 0xf2,
-	0x7b, 0x0d, 0xcd, 0x8d, 0x9a, 0xca, 0x69, 0xa5, 0x79, 0xd9, 0x4a, 0xe0, 0xcc, 0x8d, 0x9a, 0xf8,
-	0x26, 0xba, 0xc0, 0x68, 0xd4, 0x03, 0x65, 0xb9, 0xd2, 0xac, 0x4e, 0x20, 0x65, 0x2a, 0x85, 0x6b,
-	0x20, 0x7e, 0x19, 0x9d, 0x4f, 0x86, 0x82, 0xcc, 0x2b, 0x3c, 0x29, 0xe2, 0x0f, 0x87, 0xe9, 0x22,
-	0x1c, 0x09, 0xc2, 0x6d, 0xb4, 0xec, 0x43, 0x08, 0x02, 0x5c, 0x2d, 0x72, 0x41, 0x15, 0xd5, 0x8b,
-	0x45, 0x1d, 0x85, 0x28, 0x48, 0x55, 0xfc, 0x3c, 0x26, 0x05, 0xc5, 0x49, 0x44, 0x16, 0x6c, 0x82,
-	0xf7, 0x4f, 0xa2, 0x4c, 0x50, 0x9c, 0x44, 0xf8, 0x2d, 0x84, 0xbc, 0x78, 0x90, 0x50, 0x4f, 0xc8,
-	0x63, 0x58, 0x54, 0x25, 0xcf, 0x15, 0x4b, 0xda, 0x59, 0x3e, 0xad, 0x1c, 0x2b, 0xc1, 0x6f, 0xa3,
-	0x4a, 0x08, 0x94, 0x83, 0xdb, 0x63, 0x34, 0x12, 0x64, 0xc9, 0xc6, 0x70, 0x47, 0x02, 0xf6, 0x65,
-	0x3e, 0x63, 0x08, 0xb3, 0x90, 0x5c, 0xb3, 0x66, 0x60, 0x30, 0x8a, 0x8f, 0x81, 0x94, 0x6d, 0x6b,
-	0x56, 0x14, 0x8e, 0x02, 0x64, 0x6b, 0x0e, 0xf3, 0x98, 0x3c, 0x16, 0x1a, 0x52, 0x36, 0x20, 0xc8,
-	0x76, 0x2c, 0x2d, 0x99, 0xca, 0x8e, 0x45, 0x01, 0xf1, 0x03, 0xb4, 0xa6, 0x65, 0xbd, 0x3e, 0x78,
-	0xc7, 0x49, 0x1c, 0x44, 0x82, 0x54, 0x54, 0xf1, 0x0b, 0x16, 0xe9, 0x76, 0x06, 0x32, 0x34, 0x69,
-	0xb3, 0xbe, 0xee, 0x5c, 0x0c, 0x8b, 0x00, 0xdc, 0x42, 0x15, 0xd5, 0xdd, 0x10, 0xd1, 0x6e, 0x08,
-	0xe4, 0x6f, 0xeb, 0xae, 0xb6, 0x86, 0xa2, 0xbf, 0xa7, 0x00, 0xd9, 0x9e, 0xd0, 0x2c, 0x84, 0x3b,
-	0x48, 0x8d, 0x80, 0xeb, 0x07, 0x5c, 0x71, 0xfc, 0xb3, 0x68, 0xdb, 0x14, 0xc9, 0xd1, 0xd1, 0x88,
-	0x6c, 0x53, 0x68, 0x1e, 0xc3, 0xef, 0x18, 0x23, 0x5c, 0x50, 0x31, 0xe4, 0xe4, 0xbf, 0x99, 0x46,
-	0xee, 0x29, 0xc0, 0xc4, 0xca, 0xde, 0xd0, 0x8e, 0x74, 0x0e, 0xdf, 0xd5, 0x8e, 0x20, 0x12, 0x81,
-	0x47, 0x05, 0x90, 0x7f, 0x35, 0xd9, 0x4b, 0x45, 0xb2, 0x74, 0x3a, 0x5b, 0x63, 0xd0, 0xd4, 0x5a,
-	0xa1, 0x1e, 0xef, 0x99, 0x2b, 0x40, 0xde, 0x09, 0x2e, 0xf5, 0x7d, 0xf2, 0xe3, 0xd2, 0xac, 0x25,
-	0xbe, 0xc7, 0x81, 0xb5, 0x7c, 0xbf, 0xb0, 0x44, 0x13, 0xc3, 0x77, 0xd1, 0x5a, 0x4e, 0xa3, 0x87,
-	0x80, 0xfc, 0xa4, 0x99, 0x9e, 0xb7, 0x33, 0x99, 0xe9, 0x31, 0x64, 0xab, 0xb4, 0x10, 0x2e, 0xda,
-	0xea, 0x81, 0x20, 0x3f, 0x9f, 0x69, 0x6b, 0x1f, 0xc4, 0x94, 0xad, 0x7d, 0x10, 0xb8, 0x87, 0x9e,
-	0xcd, 0x69, 0xbc, 0xbe, 0x1c, 0x4b, 0x37, 0xa1, 0x9c, 0x3f, 0x8a, 0x99, 0x4f, 0x7e, 0xd1, 0x94,
-	0xaf, 0xd8, 0x29, 0xdb, 0x0a, 0x7d, 0x68, 0xc0, 0x29, 0xfb, 0x33, 0xd4, 0x9a, 0xc6, 0x0f, 0xd0,
-	0xc6, 0x98, 0x5f, 0x39, 0x4f, 0x2e, 0x8b, 0x43, 0x20, 0x4f, 0xb5, 0xc6, 0xf5, 0x19, 0xb6, 0xd5,
-	0x2c, 0xc6, 0x79, 0xdb, 0x5c, 0xa2, 0x93, 0x19, 0xfc, 0x01, 0xba, 0x9c, 0x33, 0xeb, 0xd1, 0xd4,
-	0xd4, 0xbf, 0x6a, 0xea, 0x17, 0xed, 0xd4, 0x66, 0x46, 0xc7, 0xb8, 0x31, 0x9d, 0x4a, 0xe1, 0xdb,
-	0x68, 0x35, 0x27, 0x0f, 0x03, 0x2e, 0xc8, 0x6f, 0x9a, 0xf5, 0xaa, 0x9d, 0xf5, 0x4e, 0xc0, 0x45,
-	0xa1, 0x8f, 0xd2, 0x60, 0xc6, 0x24, 0xad, 0x69, 0xa6, 0xdf, 0x67, 0x32, 0x49, 0xe9, 0x29, 0xa6,
-	0x34, 0x98, 0x1d, 0xbd, 0x62, 0x92, 0x1d, 0xf9, 0x55, 0x79, 0xd6, 0xd1, 0xcb, 0x9a, 0xc9, 0x8e,
-	0x34, 0xb1, 0xac, 0x23, 0x15, 0x8d, 0xe9, 0xc8, 0xaf, 0xcb, 0xb3, 0x3a, 0x52, 0x56, 0x59, 0x3a,
-	0x32, 0x0f, 0x17, 0x6d, 0xc9, 0x8e, 0xfc, 0xe6, 0x4c, 0x5b, 0x93, 0x1d, 0x69, 0x62, 0xf8, 0x21,
-	0xaa, 0x8e, 0xd1, 0xa8, 0x46, 0x49, 0x80, 0x0d, 0x02, 0xae, 0xfe, 0x7f, 0xbf, 0xd5, 0x9c, 0x37,
-	0x66, 0x70, 0x4a, 0xf8, 0x61, 0x86, 0x4e, 0xf9, 0xaf, 0x50, 0x7b, 0x1e, 0x0f, 0xd0, 0x66, 0xae,
-	0x65, 0x5a, 0x67, 0x4c, 0xec, 0x3b, 0x2d, 0xf6, 0xaa, 0x5d, 0x4c, 0x77, 0xc9, 0xb4, 0x1a, 0xa1,
-	0x33, 0x00, 0xf8, 0x23, 0xb4, 0xee, 0x85, 0x43, 0x2e, 0x80, 0xb9, 0xe6, 0x2d, 0xe3, 0x72, 0x10,
-	0xe4, 0x33, 0x64, 0x46, 0x60, 0xfc, 0x21, 0xd3, 0x68, 0x6b, 0xe4, 0xfb, 0x1a, 0x78, 0x0f, 0xc4,
-	0xd4, 0xad, 0x77, 0xc9, 0x9b, 0x84, 0xe0, 0x87, 0xe8, 0x4a, 0xaa, 0xa0, 0xc9, 0x5c, 0x2a, 0x04,
-	0x53, 0x2a, 0x9f, 0x23, 0x73, 0x0f, 0xda, 0x54, 0xde, 0x55, 0xb1, 0x96, 0x10, 0xcc, 0x26, 0xb4,
-	0xe1, 0x59, 0x50, 0xf8, 0x43, 0x84, 0xfd, 0xf8, 0x51, 0xd4, 0x63, 0xd4, 0x07, 0x37, 0x88, 0x8e,
-	0x62, 0x25, 0xf3, 0x85, 0x96, 0xb9, 0x56, 0x94, 0xe9, 0xa4, 0xc0, 0x83, 0xe8, 0x28, 0xb6, 0x49,
-	0xac, 0xf9, 0x13, 0x88, 0xfc, 0x31, 0x75, 0x11, 0xad, 0xec, 0x0d, 0x12, 0xf1, 0xd8, 0x01, 0x9e,
-	0xc4, 0x11, 0x87, 0xad, 0xc7, 0x68, 0xf3, 0x8c, 0xeb, 0x1b, 0x63, 0x34, 0xaf, 0xde, 0x72, 0x25,
-	0xf5, 0x96, 0x53, 0xdf, 0xf2, 0x8d, 0x97, 0xdd, 0x6a, 0xe6, 0x8d, 0x97, 0xfe, 0xc6, 0x57, 0xd1,
-	0x32, 0x0f, 0x06, 0x49, 0x08, 0xae, 0x88, 0x8f, 0x41, 0x3f, 0xf1, 0xca, 0x4e, 0x45, 0xc7, 0xee,
-	0xcb, 0x50, 0xe6, 0xe5, 0xd6, 0xc6, 0x93, 0x3f, 0x6b, 0xe7, 0x9e, 0x9c, 0xd6, 0x4a, 0x4f, 0x4f,
-	0x6b, 0xa5, 0x3f, 0x4e, 0x6b, 0xa5, 0x2f, 0xff, 0xaa, 0x9d, 0xeb, 0x2e, 0xa8, 0x97, 0xe6, 0xee,
-	0xff, 0x01, 0x00, 0x00, 0xff, 0xff, 0x30, 0x36, 0x53, 0xc6, 0x0b, 0x0b, 0x00, 0x00,
+	// 1483 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0xc9, 0x73, 0x1b, 0xc5,
+	0x17, 0xce, 0x58, 0x8e, 0xad, 0x69, 0x39, 0x89, 0xd3, 0x76, 0x92, 0x8e, 0xf5, 0x8b, 0xad, 0xf8,
+	0x47, 0x82, 0x09, 0xc1, 0x09, 0x0a, 0xa4, 0x28, 0x8a, 0x2a, 0x50, 0x6c, 0x97, 0x63, 0x2a, 0xa4,
+	0xcc, 0x38, 0x90, 0x54, 0x51, 0x30, 0xb4, 0x35, 0x6d, 0x69, 0x12, 0xcd, 0x92, 0xe9, 0x96, 0x2d,
+	0x38, 0x72, 0xa0, 0x28, 0x38, 0x70, 0x01, 0x8a, 0x23, 0x55, 0x1c, 0xb8, 0xb2, 0x05, 0xf8, 0x13,
+	0x72, 0x60, 0x09, 0x3b, 0x47, 0x08, 0x17, 0xee, 0x2c, 0x67, 0xaa, 0x97, 0xd9, 0xa4, 0x1e, 0x85,
+	0xdc, 0x46, 0xef, 0x7d, 0xef, 0xfb, 0xde, 0xeb, 0xe5, 0x75, 0xb7, 0xc0, 0x54, 0x84, 0xb7, 0x98,
+	0xed, 0xfa, 0x8c, 0x44, 0x3e, 0xee, 0x2c, 0x86, 0x51, 0xc0, 0x02, 0x38, 0x41, 0x58, 0xd3, 0xa1,
+	0x24, 0xda, 0x26, 0x51, 0xb8, 0x39, 0x33, 0xdd, 0x0a, 0x5a, 0x81, 0x70, 0x9c, 0xe2, 0x5f, 0x12,
+	0x33, 0x33, 0x99, 0x62, 0x94, 0xc5, 0x8c, 0xc2, 0xa6, 0xfa, 0xac, 0x71, 0xe7, 0x29, 0x1c, 0xba,
+	0xa7, 0xb6, 0x49, 0x44, 0xdd, 0xc0, 0x0f, 0x37, 0xe3, 0x2f, 0x85, 0x38, 0x9e, 0x20, 0x3c, 0xe2,
+	0x6d, 0x92, 0x88, 0xb6, 0xdd, 0x30, 0xdc, 0xcc, 0xfc, 0x90, 0xb8, 0xf9, 0x37, 0x0d, 0xb0, 0xc7,
+	0x22, 0xd7, 0xbb, 0x84, 0xb2, 0xf3, 0x04, 0x3b, 0x24, 0x82, 0x7b, 0xc1, 0xc8, 0xda, 0x32, 0x32,
+	0x6a, 0xc6, 0xc2, 0xa8, 0x35, 0xb2, 0xb6, 0x0c, 0x67, 0x40, 0xb9, 0x4b, 0x79, 0xf6, 0x1e, 0x41,
+	0x23, 0x35, 0x63, 0xc1, 0xb4, 0x92, 0xdf, 0xf0, 0x24, 0xd8, 0x83, 0xbb, 0xac, 0x6d, 0x47, 0x64,
+	0xdb, 0xe5, 0xe2, 0xa8, 0xc4, 0xc3, 0xce, 0x8d, 0xbf, 0x7e, 0x03, 0x95, 0xce, 0x2c, 0x3e, 0x68,
+	0x4d, 0x70, 0xaf, 0xa5, 0x9c, 0xb0, 0x0a, 0x46, 0x99, 0xeb, 0x11, 0x34, 0x5a, 0x33, 0x16, 0x4a,
+	0x31, 0xe8, 0xac, 0x25, 0x8c, 0x8f, 0x8e, 0xbf, 0x22, 0x7e, 0x9e, 0x9e, 0x7f, 0xbf, 0x0a, 0xa6,
+	0xd6, 0xd4, 0x78, 0x59, 0x78, 0x8b, 0xa9, 0xec, 0xe0, 0x19, 0x30, 0xd6, 0x16, 0x19, 0x22, 0xa7,
+	0x66, 0x2c, 0x54, 0xea, 0xd5, 0xc5, 0xec, 0x28, 0x2e, 0xe6, 0x8a, 0xb0, 0xc6, 0xda, 0xfa, 0x62,
+	0x8e, 0x81, 0x91, 0xed, 0xba, 0x28, 0xa3, 0x52, 0x3f, 0xa0, 0x25, 0xb0, 0x46, 0xb6, 0xeb, 0xf0,
+	0x34, 0xd8, 0x1d, 0x61, 0xbf, 0x45, 0x44, 0x3d, 0x95, 0xfa, 0x4c, 0x1f, 0x92, 0xbb, 0x62, 0xb8,
+	0x04, 0xc2, 0x13, 0xa0, 0x14, 0x76, 0x99, 0x28, 0xad, 0x52, 0x47, 0x79, 0xfc, 0x7a, 0x37, 0x2e,
+	0xc2, 0xe2, 0x20, 0xb8, 0x04, 0x26, 0x1c, 0xd2, 0x21, 0x8c, 0xd8, 0x52, 0x64, 0xb7, 0x08, 0xaa,
+	0xe5, 0x83, 0x96, 0x05, 0x22, 0x27, 0x55, 0x71, 0x52, 0x1b, 0x17, 0x64, 0x3d, 0x1f, 0x8d, 0xe9,
+	0x04, 0x2f, 0xf5, 0xfc, 0x44, 0x90, 0xf5, 0x7c, 0xf8, 0x38, 0x00, 0xcd, 0xc0, 0x0b, 0x71, 0x93,
+	0xf1, 0x39, 0x1a, 0x17, 0x21, 0x73, 0xf9, 0x90, 0xa5, 0xc4, 0x1f, 0x47, 0x66, 0x42, 0xe0, 0x13,
+	0xa0, 0xd2, 0x21, 0x98, 0x12, 0xbb, 0x15, 0x61, 0x9f, 0xa1, 0xb2, 0x8e, 0xe1, 0x02, 0x07, 0xac,
+	0x72, 0x7f, 0xc2, 0xd0, 0x49, 0x4c, 0xbc, 0x66, 0xc9, 0x10, 0x91, 0xed, 0xe0, 0x1a, 0x41, 0xa6,
+	0xae, 0x66, 0x41, 0x61, 0x09, 0x40, 0x52, 0x73, 0x27, 0xb5, 0xf1, 0x69, 0xc1, 0x1d, 0x1c, 0x79,
+	0x08, 0xe8, 0xa6, 0xa5, 0xc1, 0x5d, 0xc9, 0xb4, 0x08, 0x20, 0xbc, 0x02, 0x26, 0xa5, 0x6c, 0xb3,
+	0x4d, 0x9a, 0xd7, 0xc2, 0xc0, 0xf5, 0x19, 0xaa, 0x88, 0xe0, 0x7b, 0x34, 0xd2, 0x4b, 0x09, 0x48,
+	0xd1, 0xc4, 0x8b, 0xf4, 0x21, 0x6b, 0x5f, 0x27, 0x0f, 0x80, 0x0d, 0x50, 0x11, 0x4b, 0x9f, 0xf8,
+	0x78, 0xb3, 0x43, 0xd0, 0x1f, 0xda, 0x51, 0x6d, 0x74, 0x59, 0x7b, 0x45, 0x00, 0x92, 0x31, 0xc1,
+	0x89, 0x09, 0x2e, 0x03, 0xb1, 0x3f, 0x6c, 0xc7, 0xa5, 0x82, 0xe3, 0xcf, 0x71, 0xdd, 0xa0, 0x70,
+	0x8e, 0x65, 0x97, 0x66, 0x49, 0x2a, 0x38, 0xb5, 0xc1, 0x27, 0x55, 0x22, 0x94, 0x61, 0xd6, 0xa5,
+	0xe8, 0xef, 0xc2, 0x44, 0x36, 0x04, 0xa0, 0xaf, 0xb2, 0x87, 0x65, 0x46, 0xd2, 0x07, 0x2f, 0xa8,
+	0xfd, 0xbc, 0xd3, 0x0e, 0x6c, 0xec, 0xd9, 0x2e, 0xfa, 0xa7, 0x90, 0xed, 0x72, 0x3b, 0x68, 0x78,
+	0x6b, 0x7d, 0x6c, 0x67, 0x25, 0x9b, 0xf4, 0xc1, 0x8b, 0xb2, 0x3e, 0xe2, 0x33, 0xb7, 0x89, 0x19,
+	0x41, 0x7f, 0x49, 0xb2, 0xfb, 0xf2, 0x64, 0xf1, 0x5e, 0x6f, 0x64, 0xa0, 0x71, 0xa1, 0xb9, 0x78,
+	0xb8, 0xa2, 0xb2, 0xeb, 0x52, 0x12, 0xd9, 0xd8, 0x71, 0xd0, 0x97, 0xe5, 0xa2, 0x01, 0x7b, 0x86,
+	0x92, 0xa8, 0xe1, 0x38, 0xb9, 0x01, 0x53, 0x36, 0x78, 0x11, 0x4c, 0xa6, 0x34, 0x72, 0x4b, 0xa1,
+	0xaf, 0x24, 0xd3, 0xff, 0xf5, 0x4c, 0x6a, 0x2f, 0x2a, 0xb2, 0xbd, 0x38, 0x67, 0xce, 0xa7, 0xd5,
+	0x22, 0x0c, 0x7d, 0x3d, 0x34, 0xad, 0x55, 0xc2, 0x06, 0xd2, 0x5a, 0x25, 0x0c, 0xb6, 0xc0, 0xe1,
+	0x94, 0xa6, 0xd9, 0xe6, 0x9b, 0xdc, 0x0e, 0x31, 0xa5, 0x3b, 0x41, 0xe4, 0xa0, 0x6f, 0x24, 0xe5,
+	0xfd, 0x7a, 0xca, 0x25, 0x81, 0x5e, 0x57, 0xe0, 0x98, 0xfd, 0x20, 0xd6, 0xba, 0xe1, 0x15, 0x30,
+	0x9d, 0xc9, 0x97, 0xef, 0x4e, 0x3b, 0x0a, 0x3a, 0x04, 0xdd, 0x92, 0x1a, 0xc7, 0x0b, 0xd2, 0x16,
+	0x3b, 0x3b, 0x48, 0x17, 0xe1, 0x7e, 0xdc, 0xef, 0x81, 0xcf, 0x81, 0x03, 0x29, 0xb3, 0xdc, 0xe8,
+	0x92, 0xfa, 0x5b, 0x49, 0x7d, 0xaf, 0x9e, 0x5a, 0xed, 0xf8, 0x0c, 0x37, 0xc4, 0x03, 0x2e, 0x78,
+	0x1e, 0xec, 0x4d, 0xc9, 0x3b, 0x2e, 0x65, 0xe8, 0x3b, 0xc9, 0x7a, 0x54, 0xcf, 0x7a, 0xc1, 0xa5,
+	0x2c, 0xb7, 0x8e, 0x62, 0x63, 0xc2, 0xc4, 0x53, 0x93, 0x4c, 0xdf, 0x17, 0x32, 0x71, 0xe9, 0x01,
+	0xa6, 0xd8, 0x08, 0x5f, 0x00, 0x53, 0x69, 0x4e, 0x94, 0xc8, 0x81, 0xa4, 0xe8, 0x07, 0x49, 0x77,
+	0x4c, 0x9f, 0xd8, 0x06, 0x11, 0xa3, 0x45, 0x07, 0xf6, 0xce, 0x24, 0xee, 0x43, 0xc0, 0x57, 0x0d,
+	0x70, 0x34, 0x15, 0x08, 0x49, 0xe4, 0xb9, 0x94, 0x1f, 0xa5, 0xd4, 0xc6, 0x2c, 0x3d, 0x74, 0x7f,
+	0x94, 0x72, 0x75, 0xbd, 0xdc, 0x7a, 0x1a, 0xd5, 0x60, 0xf1, 0x59, 0x3c, 0xa0, 0x7d, 0x04, 0x0f,
+	0x83, 0xc3, 0xab, 0xe0, 0x50, 0xbe, 0x50, 0xd7, 0xf3, 0xba, 0x4c, 0x74, 0xad, 0x9f, 0xca, 0xba,
+	0x5d, 0x9d, 0x29, 0x76, 0x2d, 0x86, 0x0e, 0x88, 0x4e, 0x63, 0x0d, 0x0a, 0xbe, 0x0c, 0x8e, 0xc4,
+	0xf7, 0x25, 0x5b, 0xbb, 0x50, 0x7f, 0x96, 0x8a, 0x8b, 0xc5, 0x7d, 0x44, 0xb7, 0x60, 0x53, 0xd9,
+	0xc3, 0x6e, 0x11, 0x14, 0xee, 0x80, 0xff, 0x69, 0xb4, 0xd3, 0x99, 0xfd, 0x45, 0x4a, 0x3f, 0x30,
+	0x5c, 0xba, 0x70, 0x86, 0x91, 0x5b, 0x80, 0x4c, 0x9a, 0x88, 0x58, 0x93, 0xbc, 0xb7, 0x7d, 0x68,
+	0x16, 0x35, 0x11, 0x1e, 0xd0, 0xdf, 0xdb, 0x94, 0x2d, 0xe9, 0x6d, 0x82, 0x46, 0xf5, 0xb6, 0x8f,
+	0xcc, 0xa2, 0xde, 0xc6, 0xa3, 0x34, 0xbd, 0x2d, 0x35, 0xe7, 0xd3, 0xe2, 0xbd, 0xed, 0xe3, 0xa1,
+	0x69, 0xf5, 0xf7, 0x36, 0x65, 0x83, 0x57, 0xc1, 0x4c, 0x86, 0x46, 0xcc, 0x64, 0xba, 0x98, 0xd1,
+	0x27, 0x92, 0xf3, 0x64, 0x01, 0x27, 0x87, 0xa7, 0xab, 0x32, 0xe6, 0x3f, 0x84, 0xf5, 0x7e, 0xe8,
+	0x81, 0x6a, 0xaa, 0xa5, 0x9a, 0x50, 0x46, 0xec, 0x53, 0x53, 0x37, 0x83, 0xb1, 0x98, 0xec, 0x37,
+	0x83, 0x6a, 0x08, 0x17, 0x00, 0x20, 0xce, 0x74, 0x53, 0x6a, 0xef, 0xb8, 0x4a, 0x19, 0xdd, 0x30,
+	0x87, 0x75, 0x53, 0x7a, 0xd9, 0x8d, 0xf9, 0xfa, 0x96, 0xc8, 0x7e, 0xdc, 0x0f, 0x81, 0xaf, 0x19,
+	0x60, 0x2e, 0xbe, 0x66, 0xf3, 0x6a, 0x48, 0x2f, 0x74, 0x23, 0xe2, 0x64, 0x46, 0x93, 0xa2, 0xcf,
+	0xa4, 0xdc, 0x23, 0xc5, 0x0b, 0x53, 0x66, 0xbe, 0x22, 0x63, 0x93, 0x81, 0x1b, 0x5c, 0xa3, 0x55,
+	0x5c, 0x0c, 0x4e, 0x1a, 0x9e, 0x90, 0xe6, 0xdb, 0xe2, 0x7a, 0x37, 0x60, 0x18, 0x7d, 0x6e, 0x16,
+	0x35, 0x3c, 0x1e, 0xbb, 0x41, 0xd8, 0xd3, 0x1c, 0xa6, 0x6f, 0x78, 0x59, 0x04, 0xbc, 0x0e, 0x66,
+	0xf2, 0xfc, 0xd8, 0xf1, 0x5c, 0xdf, 0x0e, 0x23, 0xb2, 0xe5, 0xf6, 0xd0, 0x17, 0x66, 0xd1, 0x29,
+	0xa8, 0x48, 0x1a, 0x1c, 0xbd, 0x2e, 0xc0, 0x03, 0x62, 0x07, 0xb1, 0x16, 0x07, 0x5f, 0x04, 0x53,
+	0xcd, 0x4e, 0x97, 0x32, 0x12, 0xd9, 0xea, 0x09, 0xc5, 0x85, 0xd1, 0x5b, 0x40, 0xcd, 0x5f, 0xf6,
+	0xfd, 0xb4, 0xb8, 0x24, 0x91, 0xcf, 0x4a, 0xe0, 0x06, 0x61, 0x03, 0xd7, 0xa9, 0xfd, 0xcd, 0x7e,
+	0x08, 0x6f, 0x9e, 0xb1, 0x82, 0x24, 0xb3, 0x31, 0x63, 0xa2, 0xab, 0xa0, 0xb7, 0x81, 0x6a, 0x9e,
+	0x3a, 0x95, 0xa7, 0x84, 0xad, 0xc1, 0x58, 0xa4, 0x13, 0x9a, 0x6e, 0x6a, 0x50, 0xf0, 0x79, 0x00,
+	0x9d, 0x60, 0xc7, 0x6f, 0x45, 0xd8, 0x21, 0xb6, 0xeb, 0x6f, 0x05, 0x42, 0xe6, 0x1d, 0xa0, 0xe6,
+	0x27, 0x27, 0xb3, 0x1c, 0x03, 0xd7, 0xfc, 0xad, 0x40, 0x27, 0x31, 0xe9, 0xf4, 0x21, 0xd2, 0x57,
+	0xda, 0x3e, 0xb0, 0x67, 0xc5, 0x0b, 0xd9, 0x4b, 0x16, 0xa1, 0x61, 0xe0, 0x53, 0x32, 0xff, 0x9e,
+	0x01, 0xaa, 0x43, 0xae, 0x72, 0x10, 0x82, 0x51, 0xf1, 0x84, 0x34, 0xc4, 0x13, 0x52, 0x7c, 0xf3,
+	0xa7, 0x65, 0x72, 0xc3, 0x51, 0x4f, 0xcb, 0xf8, 0x37, 0x3c, 0x0a, 0x26, 0xa8, 0xeb, 0x85, 0x1d,
+	0x62, 0xb3, 0xe0, 0x1a, 0x91, 0x2f, 0x4b, 0xd3, 0xaa, 0x48, 0xdb, 0x25, 0x6e, 0x82, 0xc7, 0x80,
+	0x89, 0x29, 0x25, 0x91, 0x78, 0xd5, 0xf0, 0x97, 0x57, 0x39, 0x9d, 0xed, 0xd4, 0x93, 0xe6, 0xfc,
+	0x81, 0x01, 0x6a, 0x77, 0x3a, 0x25, 0xe0, 0x63, 0x60, 0xb7, 0x7c, 0xe4, 0x18, 0x77, 0x75, 0x1b,
+	0x92, 0x41, 0x70, 0x0e, 0x54, 0xe4, 0xee, 0xb4, 0xc5, 0x4b, 0x97, 0x17, 0x55, 0xb2, 0x80, 0x34,
+	0x5d, 0x72, 0x3d, 0x02, 0xab, 0xc0, 0xf4, 0x70, 0x4f, 0x9d, 0x26, 0x25, 0xe1, 0x2e, 0x7b, 0xb8,
+	0xc7, 0xa9, 0x68, 0x9c, 0xe9, 0xd9, 0xf9, 0x37, 0x0c, 0x30, 0x77, 0x87, 0x43, 0x05, 0x9e, 0x03,
+	0x66, 0x7a, 0x2e, 0x19, 0x77, 0x71, 0xe1, 0xb0, 0xca, 0x54, 0x19, 0xf2, 0xd9, 0x8c, 0x14, 0x65,
+	0xb3, 0x0a, 0x4e, 0xfc, 0xf7, 0x46, 0x02, 0x27, 0x41, 0xc9, 0x0f, 0x76, 0x44, 0x46, 0x25, 0x8b,
+	0x7f, 0x26, 0x44, 0xe7, 0xa6, 0x6f, 0xfe, 0x36, 0xbb, 0xeb, 0xe6, 0xed, 0x59, 0xe3, 0xd6, 0xed,
+	0x59, 0xe3, 0xd7, 0xdb, 0xb3, 0xc6, 0xbb, 0xbf, 0xcf, 0xee, 0xda, 0x1c, 0x13, 0xff, 0x44, 0x9c,
+	0xf9, 0x77, 0x00, 0x5f, 0xea, 0xab, 0x69, 0x2b, 0x11, 0x00, 0x00,
 }
 
 func (m *RequestHeader) Marshal() (dAtA []byte, err error) {
@@ -331,6 +512,11 @@ func (m *RequestHeader) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Time != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.Time))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.AuthRevision != 0 {
 		i = encodeVarintRaftInternal(dAtA, i, uint64(m.AuthRevision))
 		i--
@@ -417,6 +603,62 @@ func (m *InternalRaftRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xa2
 	}
+	if m.AuthRoleSetAdminPrefix != nil {
+		{
+			size, err := m.AuthRoleSetAdminPrefix.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4b
+		i--
+		dAtA[i] = 0xc2
+	}
+	if m.AuthRoleSetQuota != nil {
+		{
+			size, err := m.AuthRoleSetQuota.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4b
+		i--
+		dAtA[i] = 0xba
+	}
+	if m.AuthRevokeExpiredRoleGrants != nil {
+		{
+			size, err := m.AuthRevokeExpiredRoleGrants.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4b
+		i--
+		dAtA[i] = 0xb2
+	}
+	if m.AuthUsersWithRole != nil {
+		{
+			size, err := m.AuthUsersWithRole.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4b
+		i--
+		dAtA[i] = 0xaa
+	}
 	if m.AuthRoleRevokePermission != nil {
 		{
 			size, err := m.AuthRoleRevokePermission.MarshalToSizedBuffer(dAtA[:i])
@@ -487,6 +729,76 @@ func (m *InternalRaftRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x82
 	}
+	if m.InternalAuthUserSetRoles != nil {
+		{
+			size, err := m.InternalAuthUserSetRoles.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x45
+		i--
+		dAtA[i] = 0xc2
+	}
+	if m.InternalAuthUserGrantRole != nil {
+		{
+			size, err := m.InternalAuthUserGrantRole.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x45
+		i--
+		dAtA[i] = 0xba
+	}
+	if m.AuthUserSetImmutable != nil {
+		{
+			size, err := m.AuthUserSetImmutable.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x45
+		i--
+		dAtA[i] = 0xb2
+	}
+	if m.AuthUserPermissionsAtRevision != nil {
+		{
+			size, err := m.AuthUserPermissionsAtRevision.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x45
+		i--
+		dAtA[i] = 0xaa
+	}
+	if m.AuthUserSetRoles != nil {
+		{
+			size, err := m.AuthUserSetRoles.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x45
+		i--
+		dAtA[i] = 0xa2
+	}
 	if m.AuthRoleList != nil {
 		{
 			size, err := m.AuthRoleList.MarshalToSizedBuffer(dAtA[:i])
@@ -599,6 +911,20 @@ func (m *InternalRaftRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xe2
 	}
+	if m.AuthWhoAmI != nil {
+		{
+			size, err := m.AuthWhoAmI.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3f
+		i--
+		dAtA[i] = 0xb2
+	}
 	if m.AuthStatus != nil {
 		{
 			size, err := m.AuthStatus.MarshalToSizedBuffer(dAtA[:i])
@@ -848,6 +1174,16 @@ func (m *InternalAuthenticateRequest) MarshalToSizedBuffer(dAtA []byte) (int, er
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Assertion {
+		i--
+		if m.Assertion {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.SimpleToken) > 0 {
 		i -= len(m.SimpleToken)
 		copy(dAtA[i:], m.SimpleToken)
@@ -872,45 +1208,173 @@ func (m *InternalAuthenticateRequest) MarshalToSizedBuffer(dAtA []byte) (int, er
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintRaftInternal(dAtA []byte, offset int, v uint64) int {
-	offset -= sovRaftInternal(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *InternalAuthUserGrantRoleRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *RequestHeader) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *InternalAuthUserGrantRoleRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InternalAuthUserGrantRoleRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.ID != 0 {
-		n += 1 + sovRaftInternal(uint64(m.ID))
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	l = len(m.Username)
-	if l > 0 {
-		n += 1 + l + sovRaftInternal(uint64(l))
+	if m.MaxRoles != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.MaxRoles))
+		i--
+		dAtA[i] = 0x18
 	}
-	if m.AuthRevision != 0 {
-		n += 1 + sovRaftInternal(uint64(m.AuthRevision))
+	if m.ExpireTime != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.ExpireTime))
+		i--
+		dAtA[i] = 0x10
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if m.Grant != nil {
+		{
+			size, err := m.Grant.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *InternalRaftRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
+func (m *InternalAuthUserSetRolesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InternalAuthUserSetRolesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InternalAuthUserSetRolesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.MaxRoles != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.MaxRoles))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.SetRoles != nil {
+		{
+			size, err := m.SetRoles.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRaftInternal(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Now != 0 {
+		i = encodeVarintRaftInternal(dAtA, i, uint64(m.Now))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintRaftInternal(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRaftInternal(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *RequestHeader) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovRaftInternal(uint64(m.ID))
+	}
+	l = len(m.Username)
+	if l > 0 {
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthRevision != 0 {
+		n += 1 + sovRaftInternal(uint64(m.AuthRevision))
+	}
+	if m.Time != 0 {
+		n += 1 + sovRaftInternal(uint64(m.Time))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *InternalRaftRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
 	if m.ID != 0 {
 		n += 1 + sovRaftInternal(uint64(m.ID))
 	}
@@ -974,6 +1438,10 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.AuthStatus.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
 	}
+	if m.AuthWhoAmI != nil {
+		l = m.AuthWhoAmI.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
 	if m.AuthUserAdd != nil {
 		l = m.AuthUserAdd.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
@@ -1006,6 +1474,26 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.AuthRoleList.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
 	}
+	if m.AuthUserSetRoles != nil {
+		l = m.AuthUserSetRoles.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthUserPermissionsAtRevision != nil {
+		l = m.AuthUserPermissionsAtRevision.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthUserSetImmutable != nil {
+		l = m.AuthUserSetImmutable.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.InternalAuthUserGrantRole != nil {
+		l = m.InternalAuthUserGrantRole.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.InternalAuthUserSetRoles != nil {
+		l = m.InternalAuthUserSetRoles.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
 	if m.AuthRoleAdd != nil {
 		l = m.AuthRoleAdd.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
@@ -1026,6 +1514,22 @@ func (m *InternalRaftRequest) Size() (n int) {
 		l = m.AuthRoleRevokePermission.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
 	}
+	if m.AuthUsersWithRole != nil {
+		l = m.AuthUsersWithRole.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthRevokeExpiredRoleGrants != nil {
+		l = m.AuthRevokeExpiredRoleGrants.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthRoleSetQuota != nil {
+		l = m.AuthRoleSetQuota.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
+	if m.AuthRoleSetAdminPrefix != nil {
+		l = m.AuthRoleSetAdminPrefix.Size()
+		n += 2 + l + sovRaftInternal(uint64(l))
+	}
 	if m.ClusterVersionSet != nil {
 		l = m.ClusterVersionSet.Size()
 		n += 2 + l + sovRaftInternal(uint64(l))
@@ -1074,6 +1578,65 @@ func (m *InternalAuthenticateRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovRaftInternal(uint64(l))
 	}
+	if m.Assertion {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *InternalAuthUserGrantRoleRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Grant != nil {
+		l = m.Grant.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.ExpireTime != 0 {
+		n += 1 + sovRaftInternal(uint64(m.ExpireTime))
+	}
+	if m.MaxRoles != 0 {
+		n += 1 + sovRaftInternal(uint64(m.MaxRoles))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *InternalAuthUserSetRolesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.SetRoles != nil {
+		l = m.SetRoles.Size()
+		n += 1 + l + sovRaftInternal(uint64(l))
+	}
+	if m.MaxRoles != 0 {
+		n += 1 + sovRaftInternal(uint64(m.MaxRoles))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Now != 0 {
+		n += 1 + sovRaftInternal(uint64(m.Now))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1185,6 +1748,25 @@ func (m *RequestHeader) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Time", wireType)
+			}
+			m.Time = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Time |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(dAtA[iNdEx:])
@@ -1795,6 +2377,42 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 1014:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthWhoAmI", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthWhoAmI == nil {
+				m.AuthWhoAmI = &AuthWhoAmIRequest{}
+			}
+			if err := m.AuthWhoAmI.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 1100:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field AuthUserAdd", wireType)
@@ -2083,9 +2701,9 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 1200:
+		case 1108:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleAdd", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthUserSetRoles", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2112,16 +2730,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AuthRoleAdd == nil {
-				m.AuthRoleAdd = &AuthRoleAddRequest{}
+			if m.AuthUserSetRoles == nil {
+				m.AuthUserSetRoles = &AuthUserSetRolesRequest{}
 			}
-			if err := m.AuthRoleAdd.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.AuthUserSetRoles.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1201:
+		case 1109:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleDelete", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthUserPermissionsAtRevision", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2148,16 +2766,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AuthRoleDelete == nil {
-				m.AuthRoleDelete = &AuthRoleDeleteRequest{}
+			if m.AuthUserPermissionsAtRevision == nil {
+				m.AuthUserPermissionsAtRevision = &AuthUserPermissionsAtRevisionRequest{}
 			}
-			if err := m.AuthRoleDelete.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.AuthUserPermissionsAtRevision.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1202:
+		case 1110:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleGet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthUserSetImmutable", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2184,16 +2802,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AuthRoleGet == nil {
-				m.AuthRoleGet = &AuthRoleGetRequest{}
+			if m.AuthUserSetImmutable == nil {
+				m.AuthUserSetImmutable = &AuthUserSetImmutableRequest{}
 			}
-			if err := m.AuthRoleGet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.AuthUserSetImmutable.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1203:
+		case 1111:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleGrantPermission", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field InternalAuthUserGrantRole", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2220,16 +2838,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AuthRoleGrantPermission == nil {
-				m.AuthRoleGrantPermission = &AuthRoleGrantPermissionRequest{}
+			if m.InternalAuthUserGrantRole == nil {
+				m.InternalAuthUserGrantRole = &InternalAuthUserGrantRoleRequest{}
 			}
-			if err := m.AuthRoleGrantPermission.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.InternalAuthUserGrantRole.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1204:
+		case 1112:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleRevokePermission", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field InternalAuthUserSetRoles", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2256,16 +2874,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AuthRoleRevokePermission == nil {
-				m.AuthRoleRevokePermission = &AuthRoleRevokePermissionRequest{}
+			if m.InternalAuthUserSetRoles == nil {
+				m.InternalAuthUserSetRoles = &InternalAuthUserSetRolesRequest{}
 			}
-			if err := m.AuthRoleRevokePermission.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.InternalAuthUserSetRoles.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1300:
+		case 1200:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ClusterVersionSet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleAdd", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2292,16 +2910,16 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.ClusterVersionSet == nil {
-				m.ClusterVersionSet = &membershippb.ClusterVersionSetRequest{}
+			if m.AuthRoleAdd == nil {
+				m.AuthRoleAdd = &AuthRoleAddRequest{}
 			}
-			if err := m.ClusterVersionSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.AuthRoleAdd.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1301:
+		case 1201:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ClusterMemberAttrSet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleDelete", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2328,18 +2946,512 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.ClusterMemberAttrSet == nil {
-				m.ClusterMemberAttrSet = &membershippb.ClusterMemberAttrSetRequest{}
+			if m.AuthRoleDelete == nil {
+				m.AuthRoleDelete = &AuthRoleDeleteRequest{}
 			}
-			if err := m.ClusterMemberAttrSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.AuthRoleDelete.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1202:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleGet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRoleGet == nil {
+				m.AuthRoleGet = &AuthRoleGetRequest{}
+			}
+			if err := m.AuthRoleGet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1203:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleGrantPermission", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRoleGrantPermission == nil {
+				m.AuthRoleGrantPermission = &AuthRoleGrantPermissionRequest{}
+			}
+			if err := m.AuthRoleGrantPermission.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1204:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleRevokePermission", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRoleRevokePermission == nil {
+				m.AuthRoleRevokePermission = &AuthRoleRevokePermissionRequest{}
+			}
+			if err := m.AuthRoleRevokePermission.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1205:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthUsersWithRole", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthUsersWithRole == nil {
+				m.AuthUsersWithRole = &AuthUsersWithRoleRequest{}
+			}
+			if err := m.AuthUsersWithRole.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 1302:
+		case 1206:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRevokeExpiredRoleGrants", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRevokeExpiredRoleGrants == nil {
+				m.AuthRevokeExpiredRoleGrants = &InternalAuthRevokeExpiredRoleGrantsRequest{}
+			}
+			if err := m.AuthRevokeExpiredRoleGrants.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1207:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleSetQuota", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRoleSetQuota == nil {
+				m.AuthRoleSetQuota = &AuthRoleSetQuotaRequest{}
+			}
+			if err := m.AuthRoleSetQuota.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1208:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthRoleSetAdminPrefix", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AuthRoleSetAdminPrefix == nil {
+				m.AuthRoleSetAdminPrefix = &AuthRoleSetAdminPrefixRequest{}
+			}
+			if err := m.AuthRoleSetAdminPrefix.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1300:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterVersionSet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ClusterVersionSet == nil {
+				m.ClusterVersionSet = &membershippb.ClusterVersionSetRequest{}
+			}
+			if err := m.ClusterVersionSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1301:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClusterMemberAttrSet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ClusterMemberAttrSet == nil {
+				m.ClusterMemberAttrSet = &membershippb.ClusterMemberAttrSetRequest{}
+			}
+			if err := m.ClusterMemberAttrSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 1302:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DowngradeInfoSet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.DowngradeInfoSet == nil {
+				m.DowngradeInfoSet = &membershippb.DowngradeInfoSetRequest{}
+			}
+			if err := m.DowngradeInfoSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRaftInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EmptyResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRaftInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EmptyResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EmptyResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRaftInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRaftInternal
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InternalAuthenticateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InternalAuthenticateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DowngradeInfoSet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Password", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRaftInternal
@@ -2349,28 +3461,76 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthRaftInternal
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthRaftInternal
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.DowngradeInfoSet == nil {
-				m.DowngradeInfoSet = &membershippb.DowngradeInfoSetRequest{}
+			m.Password = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SimpleToken", wireType)
 			}
-			if err := m.DowngradeInfoSet.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRaftInternal
 			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SimpleToken = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Assertion", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Assertion = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(dAtA[iNdEx:])
@@ -2393,7 +3553,7 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *EmptyResponse) Unmarshal(dAtA []byte) error {
+func (m *InternalAuthUserGrantRoleRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2416,12 +3576,86 @@ func (m *EmptyResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: EmptyResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: InternalAuthUserGrantRoleRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: EmptyResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: InternalAuthUserGrantRoleRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Grant", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRaftInternal
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Grant == nil {
+				m.Grant = &AuthUserGrantRoleRequest{}
+			}
+			if err := m.Grant.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpireTime", wireType)
+			}
+			m.ExpireTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExpireTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxRoles", wireType)
+			}
+			m.MaxRoles = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRaftInternal
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxRoles |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(dAtA[iNdEx:])
@@ -2444,7 +3678,7 @@ func (m *EmptyResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
+func (m *InternalAuthUserSetRolesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2467,17 +3701,17 @@ func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: InternalAuthenticateRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: InternalAuthUserSetRolesRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: InternalAuthenticateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: InternalAuthUserSetRolesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SetRoles", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRaftInternal
@@ -2487,29 +3721,33 @@ func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthRaftInternal
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthRaftInternal
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
+			if m.SetRoles == nil {
+				m.SetRoles = &AuthUserSetRolesRequest{}
+			}
+			if err := m.SetRoles.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Password", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxRoles", wireType)
 			}
-			var stringLen uint64
+			m.MaxRoles = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRaftInternal
@@ -2519,29 +3757,67 @@ func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.MaxRoles |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRaftInternal
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRaftInternal(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRaftInternal
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Password = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SimpleToken", wireType)
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *InternalAuthRevokeExpiredRoleGrantsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRaftInternal
 			}
-			var stringLen uint64
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InternalAuthRevokeExpiredRoleGrantsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InternalAuthRevokeExpiredRoleGrantsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Now", wireType)
+			}
+			m.Now = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRaftInternal
@@ -2551,24 +3827,11 @@ func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Now |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRaftInternal
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRaftInternal
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.SimpleToken = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRaftInternal(dAtA[iNdEx:])
@@ -51,23 +51,31 @@ var (
 	ErrGRPCRequestTooLarge        = status.Error(codes.InvalidArgument, "etcdserver: request is too large")
 	ErrGRPCRequestTooManyRequests = status.Error(codes.ResourceExhausted, "etcdserver: too many requests")
 
-	ErrGRPCRootUserNotExist     = status.Error(codes.FailedPrecondition, "etcdserver: root user does not exist")
-	ErrGRPCRootRoleNotExist     = status.Error(codes.FailedPrecondition, "etcdserver: root user does not have root role")
-	ErrGRPCUserAlreadyExist     = status.Error(codes.FailedPrecondition, "etcdserver: user name already exists")
-	ErrGRPCUserEmpty            = status.Error(codes.InvalidArgument, "etcdserver: user name is empty")
-	ErrGRPCUserNotFound         = status.Error(codes.FailedPrecondition, "etcdserver: user name not found")
-	ErrGRPCRoleAlreadyExist     = status.Error(codes.FailedPrecondition, "etcdserver: role name already exists")
-	ErrGRPCRoleNotFound         = status.Error(codes.FailedPrecondition, "etcdserver: role name not found")
-	ErrGRPCRoleEmpty            = status.Error(codes.InvalidArgument, "etcdserver: role name is empty")
-	ErrGRPCAuthFailed           = status.Error(codes.InvalidArgument, "etcdserver: authentication failed, invalid user ID or password")
-	ErrGRPCPermissionNotGiven   = status.Error(codes.InvalidArgument, "etcdserver: permission not given")
-	ErrGRPCPermissionDenied     = status.Error(codes.PermissionDenied, "etcdserver: permission denied")
-	ErrGRPCRoleNotGranted       = status.Error(codes.FailedPrecondition, "etcdserver: role is not granted to the user")
-	ErrGRPCPermissionNotGranted = status.Error(codes.FailedPrecondition, "etcdserver: permission is not granted to the role")
-	ErrGRPCAuthNotEnabled       = status.Error(codes.FailedPrecondition, "etcdserver: authentication is not enabled")
-	ErrGRPCInvalidAuthToken     = status.Error(codes.Unauthenticated, "etcdserver: invalid auth token")
-	ErrGRPCInvalidAuthMgmt      = status.Error(codes.InvalidArgument, "etcdserver: invalid auth management")
-	ErrGRPCAuthOldRevision      = status.Error(codes.InvalidArgument, "etcdserver: revision of auth store is old")
+	ErrGRPCRootUserNotExist        = status.Error(codes.FailedPrecondition, "etcdserver: root user does not exist")
+	ErrGRPCRootRoleNotExist        = status.Error(codes.FailedPrecondition, "etcdserver: root user does not have root role")
+	ErrGRPCUserAlreadyExist        = status.Error(codes.FailedPrecondition, "etcdserver: user name already exists")
+	ErrGRPCUserEmpty               = status.Error(codes.InvalidArgument, "etcdserver: user name is empty")
+	ErrGRPCUserNotFound            = status.Error(codes.FailedPrecondition, "etcdserver: user name not found")
+	ErrGRPCRoleAlreadyExist        = status.Error(codes.FailedPrecondition, "etcdserver: role name already exists")
+	ErrGRPCRoleNotFound            = status.Error(codes.FailedPrecondition, "etcdserver: role name not found")
+	ErrGRPCRoleEmpty               = status.Error(codes.InvalidArgument, "etcdserver: role name is empty")
+	ErrGRPCAuthFailed              = status.Error(codes.InvalidArgument, "etcdserver: authentication failed, invalid user ID or password")
+	ErrGRPCPermissionNotGiven      = status.Error(codes.InvalidArgument, "etcdserver: permission not given")
+	ErrGRPCPermissionDenied        = status.Error(codes.PermissionDenied, "etcdserver: permission denied")
+	ErrGRPCRoleNotGranted          = status.Error(codes.FailedPrecondition, "etcdserver: role is not granted to the user")
+	ErrGRPCTooManyRoles            = status.Error(codes.FailedPrecondition, "etcdserver: user has too many roles")
+	ErrGRPCUserImmutable           = status.Error(codes.FailedPrecondition, "etcdserver: user is immutable")
+	ErrGRPCPermissionNotGranted    = status.Error(codes.FailedPrecondition, "etcdserver: permission is not granted to the role")
+	ErrGRPCAuthNotEnabled          = status.Error(codes.FailedPrecondition, "etcdserver: authentication is not enabled")
+	ErrGRPCInvalidAuthToken        = status.Error(codes.Unauthenticated, "etcdserver: invalid auth token")
+	ErrGRPCInvalidAuthMgmt         = status.Error(codes.InvalidArgument, "etcdserver: invalid auth management")
+	ErrGRPCAuthOldRevision         = status.Error(codes.InvalidArgument, "etcdserver: revision of auth store is old")
+	ErrGRPCAuthRateLimited         = status.Error(codes.ResourceExhausted, "etcdserver: too many failed authentication attempts, retry later")
+	ErrGRPCRoleQuotaExceeded       = status.Error(codes.ResourceExhausted, "etcdserver: role storage quota exceeded")
+	ErrGRPCAuthRevisionNotRetained = status.Error(codes.OutOfRange, "etcdserver: auth revision is older than retained auth history")
+	ErrGRPCAuthFutureRevision      = status.Error(codes.OutOfRange, "etcdserver: auth revision is a future revision")
+	ErrGRPCInvalidAssertion        = status.Error(codes.InvalidArgument, "etcdserver: authentication failed, invalid assertion")
+	ErrGRPCAssertionNotConfigured  = status.Error(codes.FailedPrecondition, "etcdserver: authentication by assertion is not configured")
 
 	ErrGRPCNoLeader                   = status.Error(codes.Unavailable, "etcdserver: no leader")
 	ErrGRPCNotLeader                  = status.Error(codes.FailedPrecondition, "etcdserver: not leader")
@@ -82,6 +90,7 @@ var (
 	ErrGRPCCorrupt                    = status.Error(codes.DataLoss, "etcdserver: corrupt cluster")
 	ErrGRPCNotSupportedForLearner     = status.Error(codes.FailedPrecondition, "etcdserver: rpc not supported for learner")
 	ErrGRPCBadLeaderTransferee        = status.Error(codes.FailedPrecondition, "etcdserver: bad leader transferee")
+	ErrGRPCUnsupportedClusterVersion  = status.Error(codes.FailedPrecondition, "etcdserver: request is not supported by cluster version")
 
 	ErrGRPCWrongDowngradeVersionFormat   = status.Error(codes.InvalidArgument, "etcdserver: wrong downgrade target version format")
 	ErrGRPCInvalidDowngradeTargetVersion = status.Error(codes.InvalidArgument, "etcdserver: invalid downgrade target version")
@@ -121,22 +130,30 @@ var (
 		ErrorDesc(ErrGRPCRequestTooLarge):        ErrGRPCRequestTooLarge,
 		ErrorDesc(ErrGRPCRequestTooManyRequests): ErrGRPCRequestTooManyRequests,
 
-		ErrorDesc(ErrGRPCRootUserNotExist):     ErrGRPCRootUserNotExist,
-		ErrorDesc(ErrGRPCRootRoleNotExist):     ErrGRPCRootRoleNotExist,
-		ErrorDesc(ErrGRPCUserAlreadyExist):     ErrGRPCUserAlreadyExist,
-		ErrorDesc(ErrGRPCUserEmpty):            ErrGRPCUserEmpty,
-		ErrorDesc(ErrGRPCUserNotFound):         ErrGRPCUserNotFound,
-		ErrorDesc(ErrGRPCRoleAlreadyExist):     ErrGRPCRoleAlreadyExist,
-		ErrorDesc(ErrGRPCRoleNotFound):         ErrGRPCRoleNotFound,
-		ErrorDesc(ErrGRPCRoleEmpty):            ErrGRPCRoleEmpty,
-		ErrorDesc(ErrGRPCAuthFailed):           ErrGRPCAuthFailed,
-		ErrorDesc(ErrGRPCPermissionDenied):     ErrGRPCPermissionDenied,
-		ErrorDesc(ErrGRPCRoleNotGranted):       ErrGRPCRoleNotGranted,
-		ErrorDesc(ErrGRPCPermissionNotGranted): ErrGRPCPermissionNotGranted,
-		ErrorDesc(ErrGRPCAuthNotEnabled):       ErrGRPCAuthNotEnabled,
-		ErrorDesc(ErrGRPCInvalidAuthToken):     ErrGRPCInvalidAuthToken,
-		ErrorDesc(ErrGRPCInvalidAuthMgmt):      ErrGRPCInvalidAuthMgmt,
-		ErrorDesc(ErrGRPCAuthOldRevision):      ErrGRPCAuthOldRevision,
+		ErrorDesc(ErrGRPCRootUserNotExist):        ErrGRPCRootUserNotExist,
+		ErrorDesc(ErrGRPCRootRoleNotExist):        ErrGRPCRootRoleNotExist,
+		ErrorDesc(ErrGRPCUserAlreadyExist):        ErrGRPCUserAlreadyExist,
+		ErrorDesc(ErrGRPCUserEmpty):               ErrGRPCUserEmpty,
+		ErrorDesc(ErrGRPCUserNotFound):            ErrGRPCUserNotFound,
+		ErrorDesc(ErrGRPCRoleAlreadyExist):        ErrGRPCRoleAlreadyExist,
+		ErrorDesc(ErrGRPCRoleNotFound):            ErrGRPCRoleNotFound,
+		ErrorDesc(ErrGRPCRoleEmpty):               ErrGRPCRoleEmpty,
+		ErrorDesc(ErrGRPCAuthFailed):              ErrGRPCAuthFailed,
+		ErrorDesc(ErrGRPCPermissionDenied):        ErrGRPCPermissionDenied,
+		ErrorDesc(ErrGRPCRoleNotGranted):          ErrGRPCRoleNotGranted,
+		ErrorDesc(ErrGRPCTooManyRoles):            ErrGRPCTooManyRoles,
+		ErrorDesc(ErrGRPCUserImmutable):           ErrGRPCUserImmutable,
+		ErrorDesc(ErrGRPCPermissionNotGranted):    ErrGRPCPermissionNotGranted,
+		ErrorDesc(ErrGRPCAuthNotEnabled):          ErrGRPCAuthNotEnabled,
+		ErrorDesc(ErrGRPCInvalidAuthToken):        ErrGRPCInvalidAuthToken,
+		ErrorDesc(ErrGRPCInvalidAuthMgmt):         ErrGRPCInvalidAuthMgmt,
+		ErrorDesc(ErrGRPCAuthOldRevision):         ErrGRPCAuthOldRevision,
+		ErrorDesc(ErrGRPCAuthRateLimited):         ErrGRPCAuthRateLimited,
+		ErrorDesc(ErrGRPCRoleQuotaExceeded):       ErrGRPCRoleQuotaExceeded,
+		ErrorDesc(ErrGRPCAuthRevisionNotRetained): ErrGRPCAuthRevisionNotRetained,
+		ErrorDesc(ErrGRPCAuthFutureRevision):      ErrGRPCAuthFutureRevision,
+		ErrorDesc(ErrGRPCInvalidAssertion):        ErrGRPCInvalidAssertion,
+		ErrorDesc(ErrGRPCAssertionNotConfigured):  ErrGRPCAssertionNotConfigured,
 
 		ErrorDesc(ErrGRPCNoLeader):                   ErrGRPCNoLeader,
 		ErrorDesc(ErrGRPCNotLeader):                  ErrGRPCNotLeader,
@@ -150,6 +167,7 @@ var (
 		ErrorDesc(ErrGRPCCorrupt):                    ErrGRPCCorrupt,
 		ErrorDesc(ErrGRPCNotSupportedForLearner):     ErrGRPCNotSupportedForLearner,
 		ErrorDesc(ErrGRPCBadLeaderTransferee):        ErrGRPCBadLeaderTransferee,
+		ErrorDesc(ErrGRPCUnsupportedClusterVersion):  ErrGRPCUnsupportedClusterVersion,
 
 		ErrorDesc(ErrGRPCClusterVersionUnavailable):     ErrGRPCClusterVersionUnavailable,
 		ErrorDesc(ErrGRPCWrongDowngradeVersionFormat):   ErrGRPCWrongDowngradeVersionFormat,
@@ -188,22 +206,30 @@ var (
 	ErrRequestTooLarge = Error(ErrGRPCRequestTooLarge)
 	ErrTooManyRequests = Error(ErrGRPCRequestTooManyRequests)
 
-	ErrRootUserNotExist     = Error(ErrGRPCRootUserNotExist)
-	ErrRootRoleNotExist     = Error(ErrGRPCRootRoleNotExist)
-	ErrUserAlreadyExist     = Error(ErrGRPCUserAlreadyExist)
-	ErrUserEmpty            = Error(ErrGRPCUserEmpty)
-	ErrUserNotFound         = Error(ErrGRPCUserNotFound)
-	ErrRoleAlreadyExist     = Error(ErrGRPCRoleAlreadyExist)
-	ErrRoleNotFound         = Error(ErrGRPCRoleNotFound)
-	ErrRoleEmpty            = Error(ErrGRPCRoleEmpty)
-	ErrAuthFailed           = Error(ErrGRPCAuthFailed)
-	ErrPermissionDenied     = Error(ErrGRPCPermissionDenied)
-	ErrRoleNotGranted       = Error(ErrGRPCRoleNotGranted)
-	ErrPermissionNotGranted = Error(ErrGRPCPermissionNotGranted)
-	ErrAuthNotEnabled       = Error(ErrGRPCAuthNotEnabled)
-	ErrInvalidAuthToken     = Error(ErrGRPCInvalidAuthToken)
-	ErrAuthOldRevision      = Error(ErrGRPCAuthOldRevision)
-	ErrInvalidAuthMgmt      = Error(ErrGRPCInvalidAuthMgmt)
+	ErrRootUserNotExist        = Error(ErrGRPCRootUserNotExist)
+	ErrRootRoleNotExist        = Error(ErrGRPCRootRoleNotExist)
+	ErrUserAlreadyExist        = Error(ErrGRPCUserAlreadyExist)
+	ErrUserEmpty               = Error(ErrGRPCUserEmpty)
+	ErrUserNotFound            = Error(ErrGRPCUserNotFound)
+	ErrRoleAlreadyExist        = Error(ErrGRPCRoleAlreadyExist)
+	ErrRoleNotFound            = Error(ErrGRPCRoleNotFound)
+	ErrRoleEmpty               = Error(ErrGRPCRoleEmpty)
+	ErrAuthFailed              = Error(ErrGRPCAuthFailed)
+	ErrPermissionDenied        = Error(ErrGRPCPermissionDenied)
+	ErrRoleNotGranted          = Error(ErrGRPCRoleNotGranted)
+	ErrTooManyRoles            = Error(ErrGRPCTooManyRoles)
+	ErrUserImmutable           = Error(ErrGRPCUserImmutable)
+	ErrPermissionNotGranted    = Error(ErrGRPCPermissionNotGranted)
+	ErrAuthNotEnabled          = Error(ErrGRPCAuthNotEnabled)
+	ErrInvalidAuthToken        = Error(ErrGRPCInvalidAuthToken)
+	ErrAuthOldRevision         = Error(ErrGRPCAuthOldRevision)
+	ErrAuthRateLimited         = Error(ErrGRPCAuthRateLimited)
+	ErrRoleQuotaExceeded       = Error(ErrGRPCRoleQuotaExceeded)
+	ErrAuthRevisionNotRetained = Error(ErrGRPCAuthRevisionNotRetained)
+	ErrAuthFutureRevision      = Error(ErrGRPCAuthFutureRevision)
+	ErrInvalidAssertion        = Error(ErrGRPCInvalidAssertion)
+	ErrAssertionNotConfigured  = Error(ErrGRPCAssertionNotConfigured)
+	ErrInvalidAuthMgmt         = Error(ErrGRPCInvalidAuthMgmt)
 
 	ErrNoLeader                   = Error(ErrGRPCNoLeader)
 	ErrNotLeader                  = Error(ErrGRPCNotLeader)
@@ -217,6 +243,7 @@ var (
 	ErrUnhealthy                  = Error(ErrGRPCUnhealthy)
 	ErrCorrupt                    = Error(ErrGRPCCorrupt)
 	ErrBadLeaderTransferee        = Error(ErrGRPCBadLeaderTransferee)
+	ErrUnsupportedClusterVersion  = Error(ErrGRPCUnsupportedClusterVersion)
 
 	ErrClusterVersionUnavailable     = Error(ErrGRPCClusterVersionUnavailable)
 	ErrWrongDowngradeVersionFormat   = Error(ErrGRPCWrongDowngradeVersionFormat)
@@ -19,4 +19,8 @@ var (
 	MetadataHasLeader        = "true"
 
 	MetadataClientAPIVersionKey = "client-api-version"
+
+	// MetadataConsistentIndexKey is trailer of unary responses carrying consistent index of the member serving them,
+	// sent only by member with debug consistent index trailer enabled.
+	MetadataConsistentIndexKey = "consistent-index"
 )
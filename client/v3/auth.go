@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -26,23 +27,31 @@ import (
 )
 
 type (
-	AuthEnableResponse               pb.AuthEnableResponse
-	AuthDisableResponse              pb.AuthDisableResponse
-	AuthStatusResponse               pb.AuthStatusResponse
-	AuthenticateResponse             pb.AuthenticateResponse
-	AuthUserAddResponse              pb.AuthUserAddResponse
-	AuthUserDeleteResponse           pb.AuthUserDeleteResponse
-	AuthUserChangePasswordResponse   pb.AuthUserChangePasswordResponse
-	AuthUserGrantRoleResponse        pb.AuthUserGrantRoleResponse
-	AuthUserGetResponse              pb.AuthUserGetResponse
-	AuthUserRevokeRoleResponse       pb.AuthUserRevokeRoleResponse
-	AuthRoleAddResponse              pb.AuthRoleAddResponse
-	AuthRoleGrantPermissionResponse  pb.AuthRoleGrantPermissionResponse
-	AuthRoleGetResponse              pb.AuthRoleGetResponse
-	AuthRoleRevokePermissionResponse pb.AuthRoleRevokePermissionResponse
-	AuthRoleDeleteResponse           pb.AuthRoleDeleteResponse
-	AuthUserListResponse             pb.AuthUserListResponse
-	AuthRoleListResponse             pb.AuthRoleListResponse
+	AuthEnableResponse                    pb.AuthEnableResponse
+	AuthDisableResponse                   pb.AuthDisableResponse
+	AuthStatusResponse                    pb.AuthStatusResponse
+	AuthWhoAmIResponse                    pb.AuthWhoAmIResponse
+	AuthenticateResponse                  pb.AuthenticateResponse
+	AuthUserAddResponse                   pb.AuthUserAddResponse
+	AuthUserDeleteResponse                pb.AuthUserDeleteResponse
+	AuthUserChangePasswordResponse        pb.AuthUserChangePasswordResponse
+	AuthUserVerifyPasswordResponse        pb.AuthUserVerifyPasswordResponse
+	AuthUserGrantRoleResponse             pb.AuthUserGrantRoleResponse
+	AuthUserGetResponse                   pb.AuthUserGetResponse
+	AuthUserPermissionsAtRevisionResponse pb.AuthUserPermissionsAtRevisionResponse
+	AuthUserRevokeRoleResponse            pb.AuthUserRevokeRoleResponse
+	AuthUserSetRolesResponse              pb.AuthUserSetRolesResponse
+	AuthUserSetImmutableResponse          pb.AuthUserSetImmutableResponse
+	AuthRoleAddResponse                   pb.AuthRoleAddResponse
+	AuthRoleGrantPermissionResponse       pb.AuthRoleGrantPermissionResponse
+	AuthRoleGetResponse                   pb.AuthRoleGetResponse
+	AuthRoleRevokePermissionResponse      pb.AuthRoleRevokePermissionResponse
+	AuthRoleDeleteResponse                pb.AuthRoleDeleteResponse
+	AuthUserListResponse                  pb.AuthUserListResponse
+	AuthRoleListResponse                  pb.AuthRoleListResponse
+	AuthUsersWithRoleResponse             pb.AuthUsersWithRoleResponse
+	AuthRoleSetQuotaResponse              pb.AuthRoleSetQuotaResponse
+	AuthRoleSetAdminPrefixResponse        pb.AuthRoleSetAdminPrefixResponse
 
 	PermissionType authpb.Permission_Type
 	Permission     authpb.Permission
@@ -60,7 +69,13 @@ type Auth interface {
 	// Authenticate login and get token
 	Authenticate(ctx context.Context, name string, password string) (*AuthenticateResponse, error)
 
-	// AuthEnable enables auth of an etcd cluster.
+	// AuthenticateWithAssertion gets token of the user named by the assertion, a JWT signed by an issuer
+	// trusted by the server. Server rejects it with rpctypes.ErrAssertionNotConfigured unless issuer is configured.
+	AuthenticateWithAssertion(ctx context.Context, assertion string) (*AuthenticateResponse, error)
+
+	// AuthEnable enables auth of an etcd cluster. The request is rejected with
+	// rpctypes.ErrRootUserNotExist or rpctypes.ErrRootRoleNotExist unless a root
+	// user with the root role exists at the time auth is enabled.
 	AuthEnable(ctx context.Context) (*AuthEnableResponse, error)
 
 	// AuthDisable disables auth of an etcd cluster.
@@ -69,6 +84,15 @@ type Auth interface {
 	// AuthStatus returns the status of auth of an etcd cluster.
 	AuthStatus(ctx context.Context) (*AuthStatusResponse, error)
 
+	// WatchAuthStatus streams the status of auth of an etcd cluster, starting with the current one and followed by
+	// each transition between enabled and disabled. It works for authenticated and unauthenticated clients alike.
+	// The channel is closed when ctx is canceled.
+	WatchAuthStatus(ctx context.Context, opts ...AuthStatusWatchOption) AuthStatusChan
+
+	// WhoAmI returns the identity of the client, the name of the authenticated user with its roles and permissions.
+	// Clients of a cluster without auth get an anonymous identity without name.
+	WhoAmI(ctx context.Context) (*AuthWhoAmIResponse, error)
+
 	// UserAdd adds a new user to an etcd cluster.
 	UserAdd(ctx context.Context, name string, password string) (*AuthUserAddResponse, error)
 
@@ -81,23 +105,42 @@ type Auth interface {
 	// UserChangePassword changes a password of a user.
 	UserChangePassword(ctx context.Context, name string, password string) (*AuthUserChangePasswordResponse, error)
 
-	// UserGrantRole grants a role to a user.
-	UserGrantRole(ctx context.Context, user string, role string) (*AuthUserGrantRoleResponse, error)
+	// UserVerifyPassword checks whether a password of a user is correct, without issuing a token.
+	// It requires root privilege.
+	UserVerifyPassword(ctx context.Context, name string, password string) (*AuthUserVerifyPasswordResponse, error)
+
+	// UserGrantRole grants a role to a user. Use WithGrantTTL to grant the role only temporarily.
+	UserGrantRole(ctx context.Context, user string, role string, opts ...UserGrantRoleOption) (*AuthUserGrantRoleResponse, error)
 
 	// UserGet gets a detailed information of a user.
 	UserGet(ctx context.Context, name string) (*AuthUserGetResponse, error)
 
+	// UserPermissionsAtRevision gets roles and permissions the user had at the given auth revision, zero for
+	// the current one. Past auth revisions are available only within auth revision retention configured on the server,
+	// querying revision older than that fails with rpctypes.ErrAuthRevisionNotRetained.
+	UserPermissionsAtRevision(ctx context.Context, name string, authRevision uint64) (*AuthUserPermissionsAtRevisionResponse, error)
+
 	// UserList gets a list of all users.
 	UserList(ctx context.Context) (*AuthUserListResponse, error)
 
 	// UserRevokeRole revokes a role of a user.
 	UserRevokeRole(ctx context.Context, name string, role string) (*AuthUserRevokeRoleResponse, error)
 
+	// UserSetRoles replaces all roles of a user with the given roles in a single request, granting missing
+	// and revoking extra ones. If any of the roles doesn't exist, the user is left unchanged.
+	UserSetRoles(ctx context.Context, user string, roles []string) (*AuthUserSetRolesResponse, error)
+
+	// UserSetImmutable protects a user from being deleted and from having roles revoked, including by deleting
+	// the roles, until it is cleared by setting immutable to false. Blocked requests fail with
+	// rpctypes.ErrUserImmutable. Only users with root role can set or clear the protection.
+	UserSetImmutable(ctx context.Context, name string, immutable bool) (*AuthUserSetImmutableResponse, error)
+
 	// RoleAdd adds a new role to an etcd cluster.
 	RoleAdd(ctx context.Context, name string) (*AuthRoleAddResponse, error)
 
-	// RoleGrantPermission grants a permission to a role.
-	RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType PermissionType) (*AuthRoleGrantPermissionResponse, error)
+	// RoleGrantPermission grants a permission to a role. Use WithUserTemplate to grant each user
+	// the permission on keys containing its own name.
+	RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType PermissionType, opts ...RoleGrantPermissionOption) (*AuthRoleGrantPermissionResponse, error)
 
 	// RoleGet gets a detailed information of a role.
 	RoleGet(ctx context.Context, role string) (*AuthRoleGetResponse, error)
@@ -105,11 +148,29 @@ type Auth interface {
 	// RoleList gets a list of all roles.
 	RoleList(ctx context.Context) (*AuthRoleListResponse, error)
 
+	// UsersWithRole gets a list of all users that have been granted a role.
+	UsersWithRole(ctx context.Context, role string) (*AuthUsersWithRoleResponse, error)
+
 	// RoleRevokePermission revokes a permission from a role.
 	RoleRevokePermission(ctx context.Context, role string, key, rangeEnd string) (*AuthRoleRevokePermissionResponse, error)
 
+	// RoleSetQuota sets the total size of keys and values a role can store under
+	// the ranges it can write. Zero quotaBytes removes the quota.
+	RoleSetQuota(ctx context.Context, role string, quotaBytes int64) (*AuthRoleSetQuotaResponse, error)
+
+	// RoleSetAdminPrefix makes users granted the role admins scoped to keys with the prefix. Scoped admins can
+	// grant and revoke permissions within the prefix, and grant and revoke roles permitting nothing outside of it.
+	// Empty prefix makes the role no longer a scoped admin. Only users with root role can set the prefix.
+	RoleSetAdminPrefix(ctx context.Context, role string, prefix string) (*AuthRoleSetAdminPrefixResponse, error)
+
 	// RoleDelete deletes a role.
 	RoleDelete(ctx context.Context, role string) (*AuthRoleDeleteResponse, error)
+
+	// ImportRBAC creates roles, permissions, users and role grants declared by config, skipping the ones that
+	// already exist and updating permissions of different type. Nothing missing from config is removed, so the import
+	// can be safely repeated. Config referencing undefined roles is rejected before any change is made. Changes are
+	// applied one by one, so failed import can leave some of them applied, repeating it completes the rest.
+	ImportRBAC(ctx context.Context, config RBACConfig) (*ImportRBACResponse, error)
 }
 
 type authClient struct {
@@ -138,6 +199,11 @@ func (auth *authClient) Authenticate(ctx context.Context, name string, password
 	return (*AuthenticateResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) AuthenticateWithAssertion(ctx context.Context, assertion string) (*AuthenticateResponse, error) {
+	resp, err := auth.remote.Authenticate(ctx, &pb.AuthenticateRequest{Assertion: assertion}, auth.callOpts...)
+	return (*AuthenticateResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) AuthEnable(ctx context.Context) (*AuthEnableResponse, error) {
 	resp, err := auth.remote.AuthEnable(ctx, &pb.AuthEnableRequest{}, auth.callOpts...)
 	return (*AuthEnableResponse)(resp), toErr(ctx, err)
@@ -153,6 +219,11 @@ func (auth *authClient) AuthStatus(ctx context.Context) (*AuthStatusResponse, er
 	return (*AuthStatusResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) WhoAmI(ctx context.Context) (*AuthWhoAmIResponse, error) {
+	resp, err := auth.remote.WhoAmI(ctx, &pb.AuthWhoAmIRequest{}, auth.callOpts...)
+	return (*AuthWhoAmIResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) UserAdd(ctx context.Context, name string, password string) (*AuthUserAddResponse, error) {
 	resp, err := auth.remote.UserAdd(ctx, &pb.AuthUserAddRequest{Name: name, Password: password, Options: &authpb.UserAddOptions{NoPassword: false}}, auth.callOpts...)
 	return (*AuthUserAddResponse)(resp), toErr(ctx, err)
@@ -173,16 +244,41 @@ func (auth *authClient) UserChangePassword(ctx context.Context, name string, pas
 	return (*AuthUserChangePasswordResponse)(resp), toErr(ctx, err)
 }
 
-func (auth *authClient) UserGrantRole(ctx context.Context, user string, role string) (*AuthUserGrantRoleResponse, error) {
-	resp, err := auth.remote.UserGrantRole(ctx, &pb.AuthUserGrantRoleRequest{User: user, Role: role}, auth.callOpts...)
+func (auth *authClient) UserVerifyPassword(ctx context.Context, name string, password string) (*AuthUserVerifyPasswordResponse, error) {
+	resp, err := auth.remote.UserVerifyPassword(ctx, &pb.AuthUserVerifyPasswordRequest{Name: name, Password: password}, auth.callOpts...)
+	return (*AuthUserVerifyPasswordResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) UserGrantRole(ctx context.Context, user string, role string, opts ...UserGrantRoleOption) (*AuthUserGrantRoleResponse, error) {
+	r := &pb.AuthUserGrantRoleRequest{User: user, Role: role}
+	for _, opt := range opts {
+		opt(r)
+	}
+	resp, err := auth.remote.UserGrantRole(ctx, r, auth.callOpts...)
 	return (*AuthUserGrantRoleResponse)(resp), toErr(ctx, err)
 }
 
+// UserGrantRoleOption configures a role grant.
+type UserGrantRoleOption func(*pb.AuthUserGrantRoleRequest)
+
+// WithGrantTTL makes the granted role expire after the given duration, rounded up to whole seconds.
+// Once the role expires, the leader revokes it from the user and it no longer grants any permission.
+func WithGrantTTL(ttl time.Duration) UserGrantRoleOption {
+	return func(r *pb.AuthUserGrantRoleRequest) {
+		r.TTL = int64((ttl + time.Second - 1) / time.Second)
+	}
+}
+
 func (auth *authClient) UserGet(ctx context.Context, name string) (*AuthUserGetResponse, error) {
 	resp, err := auth.remote.UserGet(ctx, &pb.AuthUserGetRequest{Name: name}, auth.callOpts...)
 	return (*AuthUserGetResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) UserPermissionsAtRevision(ctx context.Context, name string, authRevision uint64) (*AuthUserPermissionsAtRevisionResponse, error) {
+	resp, err := auth.remote.UserPermissionsAtRevision(ctx, &pb.AuthUserPermissionsAtRevisionRequest{Name: name, AuthRevision: authRevision}, auth.callOpts...)
+	return (*AuthUserPermissionsAtRevisionResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) UserList(ctx context.Context) (*AuthUserListResponse, error) {
 	resp, err := auth.remote.UserList(ctx, &pb.AuthUserListRequest{}, auth.callOpts...)
 	return (*AuthUserListResponse)(resp), toErr(ctx, err)
@@ -193,21 +289,51 @@ func (auth *authClient) UserRevokeRole(ctx context.Context, name string, role st
 	return (*AuthUserRevokeRoleResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) UserSetRoles(ctx context.Context, user string, roles []string) (*AuthUserSetRolesResponse, error) {
+	resp, err := auth.remote.UserSetRoles(ctx, &pb.AuthUserSetRolesRequest{User: user, Roles: roles}, auth.callOpts...)
+	return (*AuthUserSetRolesResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) UserSetImmutable(ctx context.Context, name string, immutable bool) (*AuthUserSetImmutableResponse, error) {
+	resp, err := auth.remote.UserSetImmutable(ctx, &pb.AuthUserSetImmutableRequest{Name: name, Immutable: immutable}, auth.callOpts...)
+	return (*AuthUserSetImmutableResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) RoleAdd(ctx context.Context, name string) (*AuthRoleAddResponse, error) {
 	resp, err := auth.remote.RoleAdd(ctx, &pb.AuthRoleAddRequest{Name: name}, auth.callOpts...)
 	return (*AuthRoleAddResponse)(resp), toErr(ctx, err)
 }
 
-func (auth *authClient) RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType PermissionType) (*AuthRoleGrantPermissionResponse, error) {
+func (auth *authClient) RoleGrantPermission(ctx context.Context, name string, key, rangeEnd string, permType PermissionType, opts ...RoleGrantPermissionOption) (*AuthRoleGrantPermissionResponse, error) {
 	perm := &authpb.Permission{
 		Key:      []byte(key),
 		RangeEnd: []byte(rangeEnd),
 		PermType: authpb.Permission_Type(permType),
 	}
+	for _, opt := range opts {
+		opt(perm)
+	}
 	resp, err := auth.remote.RoleGrantPermission(ctx, &pb.AuthRoleGrantPermissionRequest{Name: name, Perm: perm}, auth.callOpts...)
 	return (*AuthRoleGrantPermissionResponse)(resp), toErr(ctx, err)
 }
 
+// UserTemplatePlaceholder is replaced by the user name in key and range end of permissions granted WithUserTemplate.
+const UserTemplatePlaceholder = "{user}"
+
+// RoleGrantPermissionOption configures a granted permission.
+type RoleGrantPermissionOption func(*authpb.Permission)
+
+// WithUserTemplate makes key and rangeEnd templates, in which UserTemplatePlaceholder is replaced by name of
+// the user the permission is checked for. For example, prefix "/users/{user}/" lets each user granted the role
+// access only keys under its own "/users/<name>/". The placeholder must appear exactly once in key and, if rangeEnd
+// is set, once in rangeEnd, after the same bytes as in key, followed by a separator in key. Users whose name
+// contains the separator are not granted the permission, so they can't reach into keys of other users.
+func WithUserTemplate() RoleGrantPermissionOption {
+	return func(p *authpb.Permission) {
+		p.UserTemplate = true
+	}
+}
+
 func (auth *authClient) RoleGet(ctx context.Context, role string) (*AuthRoleGetResponse, error) {
 	resp, err := auth.remote.RoleGet(ctx, &pb.AuthRoleGetRequest{Role: role}, auth.callOpts...)
 	return (*AuthRoleGetResponse)(resp), toErr(ctx, err)
@@ -218,11 +344,26 @@ func (auth *authClient) RoleList(ctx context.Context) (*AuthRoleListResponse, er
 	return (*AuthRoleListResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) UsersWithRole(ctx context.Context, role string) (*AuthUsersWithRoleResponse, error) {
+	resp, err := auth.remote.UsersWithRole(ctx, &pb.AuthUsersWithRoleRequest{Role: role}, auth.callOpts...)
+	return (*AuthUsersWithRoleResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) RoleRevokePermission(ctx context.Context, role string, key, rangeEnd string) (*AuthRoleRevokePermissionResponse, error) {
 	resp, err := auth.remote.RoleRevokePermission(ctx, &pb.AuthRoleRevokePermissionRequest{Role: role, Key: []byte(key), RangeEnd: []byte(rangeEnd)}, auth.callOpts...)
 	return (*AuthRoleRevokePermissionResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) RoleSetQuota(ctx context.Context, role string, quotaBytes int64) (*AuthRoleSetQuotaResponse, error) {
+	resp, err := auth.remote.RoleSetQuota(ctx, &pb.AuthRoleSetQuotaRequest{Role: role, QuotaBytes: quotaBytes}, auth.callOpts...)
+	return (*AuthRoleSetQuotaResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) RoleSetAdminPrefix(ctx context.Context, role string, prefix string) (*AuthRoleSetAdminPrefixResponse, error) {
+	resp, err := auth.remote.RoleSetAdminPrefix(ctx, &pb.AuthRoleSetAdminPrefixRequest{Role: role, Prefix: []byte(prefix)}, auth.callOpts...)
+	return (*AuthRoleSetAdminPrefixResponse)(resp), toErr(ctx, err)
+}
+
 func (auth *authClient) RoleDelete(ctx context.Context, role string) (*AuthRoleDeleteResponse, error) {
 	resp, err := auth.remote.RoleDelete(ctx, &pb.AuthRoleDeleteRequest{Role: role}, auth.callOpts...)
 	return (*AuthRoleDeleteResponse)(resp), toErr(ctx, err)
@@ -0,0 +1,195 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// RBACConfig declares roles and users imported by ImportRBAC.
+type RBACConfig struct {
+	Roles []RBACRole
+	Users []RBACUser
+}
+
+// RBACRole declares a role together with permissions granted to it.
+type RBACRole struct {
+	Name        string
+	Permissions []RBACPermission
+}
+
+// RBACPermission declares permission on key, or on range [Key, RangeEnd) if RangeEnd is set.
+// With UserTemplate set, Key and RangeEnd are user templates as granted WithUserTemplate.
+type RBACPermission struct {
+	Key          string
+	RangeEnd     string
+	PermType     PermissionType
+	UserTemplate bool
+}
+
+// RBACUser declares a user together with roles granted to it.
+// Password is used only when user is created, passwords of existing users are not changed.
+type RBACUser struct {
+	Name       string
+	Password   string
+	NoPassword bool
+	Roles      []string
+}
+
+// ImportRBACResponse describes roles, permissions, users and role grants processed by ImportRBAC.
+type ImportRBACResponse struct {
+	Created []string
+	Updated []string
+	Skipped []string
+}
+
+func (auth *authClient) ImportRBAC(ctx context.Context, config RBACConfig) (*ImportRBACResponse, error) {
+	if err := auth.validateRBACConfig(ctx, config); err != nil {
+		return nil, err
+	}
+	resp := &ImportRBACResponse{}
+	for _, role := range config.Roles {
+		if err := auth.importRole(ctx, role, resp); err != nil {
+			return resp, err
+		}
+	}
+	for _, user := range config.Users {
+		if err := auth.importUser(ctx, user, resp); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// validateRBACConfig rejects duplicated names and users referencing roles that are neither declared nor already exist,
+// so invalid config is reported before any change is made.
+func (auth *authClient) validateRBACConfig(ctx context.Context, config RBACConfig) error {
+	roles := map[string]bool{}
+	for _, role := range config.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("role name is empty")
+		}
+		if roles[role.Name] {
+			return fmt.Errorf("role %q is declared more than once", role.Name)
+		}
+		roles[role.Name] = true
+	}
+	users := map[string]bool{}
+	for _, user := range config.Users {
+		if user.Name == "" {
+			return fmt.Errorf("user name is empty")
+		}
+		if users[user.Name] {
+			return fmt.Errorf("user %q is declared more than once", user.Name)
+		}
+		users[user.Name] = true
+		for _, role := range user.Roles {
+			if roles[role] {
+				continue
+			}
+			_, err := auth.RoleGet(ctx, role)
+			if err == rpctypes.ErrRoleNotFound {
+				return fmt.Errorf("user %q references undefined role %q", user.Name, role)
+			}
+			if err != nil {
+				return err
+			}
+			roles[role] = true
+		}
+	}
+	return nil
+}
+
+func (auth *authClient) importRole(ctx context.Context, role RBACRole, resp *ImportRBACResponse) error {
+	var existing []*authpb.Permission
+	roleResp, err := auth.RoleGet(ctx, role.Name)
+	switch err {
+	case nil:
+		existing = roleResp.Perm
+		resp.Skipped = append(resp.Skipped, fmt.Sprintf("role %q", role.Name))
+	case rpctypes.ErrRoleNotFound:
+		if _, err = auth.RoleAdd(ctx, role.Name); err != nil {
+			return err
+		}
+		resp.Created = append(resp.Created, fmt.Sprintf("role %q", role.Name))
+	default:
+		return err
+	}
+	for _, perm := range role.Permissions {
+		description := fmt.Sprintf("permission %s [%q, %q) of role %q", authpb.Permission_Type(perm.PermType), perm.Key, perm.RangeEnd, role.Name)
+		current := findPermission(existing, perm)
+		if current != nil && current.PermType == authpb.Permission_Type(perm.PermType) && current.UserTemplate == perm.UserTemplate {
+			resp.Skipped = append(resp.Skipped, description)
+			continue
+		}
+		var opts []RoleGrantPermissionOption
+		if perm.UserTemplate {
+			opts = append(opts, WithUserTemplate())
+		}
+		if _, err = auth.RoleGrantPermission(ctx, role.Name, perm.Key, perm.RangeEnd, perm.PermType, opts...); err != nil {
+			return err
+		}
+		if current != nil {
+			resp.Updated = append(resp.Updated, description)
+		} else {
+			resp.Created = append(resp.Created, description)
+		}
+	}
+	return nil
+}
+
+func findPermission(perms []*authpb.Permission, perm RBACPermission) *authpb.Permission {
+	for _, p := range perms {
+		if string(p.Key) == perm.Key && string(p.RangeEnd) == perm.RangeEnd {
+			return p
+		}
+	}
+	return nil
+}
+
+func (auth *authClient) importUser(ctx context.Context, user RBACUser, resp *ImportRBACResponse) error {
+	granted := map[string]bool{}
+	userResp, err := auth.UserGet(ctx, user.Name)
+	switch err {
+	case nil:
+		for _, role := range userResp.Roles {
+			granted[role] = true
+		}
+		resp.Skipped = append(resp.Skipped, fmt.Sprintf("user %q", user.Name))
+	case rpctypes.ErrUserNotFound:
+		if _, err = auth.UserAddWithOptions(ctx, user.Name, user.Password, &UserAddOptions{NoPassword: user.NoPassword}); err != nil {
+			return err
+		}
+		resp.Created = append(resp.Created, fmt.Sprintf("user %q", user.Name))
+	default:
+		return err
+	}
+	for _, role := range user.Roles {
+		description := fmt.Sprintf("role %q of user %q", role, user.Name)
+		if granted[role] {
+			resp.Skipped = append(resp.Skipped, description)
+			continue
+		}
+		if _, err = auth.UserGrantRole(ctx, user.Name, role); err != nil {
+			return err
+		}
+		resp.Created = append(resp.Created, description)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"time"
+)
+
+const defaultAuthStatusWatchInterval = time.Second
+
+// AuthStatusEvent describes status of auth observed by WatchAuthStatus.
+type AuthStatusEvent struct {
+	Enabled bool
+	// AuthRevision is the revision of auth store at which the status was observed.
+	AuthRevision uint64
+	// Revision is the revision of key-value store at which the status was observed.
+	Revision int64
+}
+
+type AuthStatusChan <-chan AuthStatusEvent
+
+type authStatusWatchConfig struct {
+	interval time.Duration
+}
+
+// AuthStatusWatchOption configures WatchAuthStatus.
+type AuthStatusWatchOption func(*authStatusWatchConfig)
+
+// WithAuthStatusWatchInterval sets how often WatchAuthStatus checks the status of auth.
+// Transitions reverted within the interval are not reported.
+func WithAuthStatusWatchInterval(interval time.Duration) AuthStatusWatchOption {
+	return func(cfg *authStatusWatchConfig) {
+		cfg.interval = interval
+	}
+}
+
+func (auth *authClient) WatchAuthStatus(ctx context.Context, opts ...AuthStatusWatchOption) AuthStatusChan {
+	cfg := authStatusWatchConfig{interval: defaultAuthStatusWatchInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ch := make(chan AuthStatusEvent)
+	go auth.watchAuthStatus(ctx, cfg, ch)
+	return ch
+}
+
+func (auth *authClient) watchAuthStatus(ctx context.Context, cfg authStatusWatchConfig, ch chan<- AuthStatusEvent) {
+	defer close(ch)
+	var last *AuthStatusEvent
+	for {
+		// Failed checks are retried after interval, status is requested through raft so it's never stale.
+		if resp, err := auth.AuthStatus(ctx); err == nil {
+			ev := AuthStatusEvent{Enabled: resp.Enabled, AuthRevision: resp.AuthRevision, Revision: resp.Header.Revision}
+			if last == nil || (ev.Enabled != last.Enabled && ev.Revision >= last.Revision) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+				last = &ev
+			}
+		}
+		select {
+		case <-time.After(cfg.interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
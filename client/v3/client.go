@@ -61,13 +61,20 @@ type Client struct {
 	epMu      *sync.RWMutex
 	endpoints []string
 
+	// memberRolesc requests refresh of member roles, nil if learner-aware endpoint selection is disabled.
+	memberRolesc chan struct{}
+	// endpointMembers caches IDs of members serving endpoints, owned by member roles refresh.
+	endpointMembers map[string]uint64
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	// Username is a user name for authentication.
 	Username string
 	// Password is a password for authentication.
-	Password        string
+	Password string
+	// authAssertion returns assertion to authenticate with instead of password.
+	authAssertion   func(ctx context.Context) (string, error)
 	authTokenBundle credentials.Bundle
 
 	callOpts []grpc.CallOption
@@ -176,9 +183,14 @@ func (c *Client) Endpoints() []string {
 func (c *Client) SetEndpoints(eps ...string) {
 	c.epMu.Lock()
 	defer c.epMu.Unlock()
+	if c.cfg.PinEndpoint && len(c.endpoints) != 0 {
+		c.lg.Warn("ignored setting endpoints of client pinned to endpoint", zap.Strings("endpoints", eps), zap.Strings("pinned", c.endpoints))
+		return
+	}
 	c.endpoints = eps
 
 	c.resolver.SetEndpoints(eps)
+	c.requestMemberRolesRefresh()
 }
 
 // Sync synchronizes client's endpoints with the known endpoints from the etcd membership.
@@ -240,11 +252,15 @@ func (c *Client) dialSetupOpts(creds grpccredentials.TransportCredentials, dopts
 	// TODO: Replace all of clientv3/retry.go with RetryPolicy:
 	// https://github.com/grpc/grpc-proto/blob/cdd9ed5c3d3f87aef62f373b93361cf7bddc620d/grpc/service_config/service_config.proto#L130
 	rrBackoff := withBackoff(c.roundRobinQuorumBackoff(defaultBackoffWaitBetween, defaultBackoffJitterFraction))
+	unaryMaxRetries := defaultUnaryMaxRetries
+	if c.cfg.PinEndpoint {
+		unaryMaxRetries = 0
+	}
 	opts = append(opts,
 		// Disable stream retry by default since go-grpc-middleware/retry does not support client streams.
 		// Streams that are safe to retry are enabled individually.
 		grpc.WithStreamInterceptor(c.streamClientInterceptor(withMax(0), rrBackoff)),
-		grpc.WithUnaryInterceptor(c.unaryClientInterceptor(withMax(defaultUnaryMaxRetries), rrBackoff)),
+		grpc.WithUnaryInterceptor(c.unaryClientInterceptor(withMax(unaryMaxRetries), rrBackoff)),
 	)
 
 	return opts, nil
@@ -262,11 +278,20 @@ func (c *Client) Dial(ep string) (*grpc.ClientConn, error) {
 func (c *Client) getToken(ctx context.Context) error {
 	var err error // return last error in a case of fail
 
-	if c.Username == "" || c.Password == "" {
+	var resp *AuthenticateResponse
+	switch {
+	case c.authAssertion != nil:
+		var assertion string
+		assertion, err = c.authAssertion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get auth assertion: %w", err)
+		}
+		resp, err = c.Auth.AuthenticateWithAssertion(ctx, assertion)
+	case c.Username != "" && c.Password != "":
+		resp, err = c.Auth.Authenticate(ctx, c.Username, c.Password)
+	default:
 		return nil
 	}
-
-	resp, err := c.Auth.Authenticate(ctx, c.Username, c.Password)
 	if err != nil {
 		if err == rpctypes.ErrAuthNotEnabled {
 			c.authTokenBundle.UpdateAuthToken("")
@@ -390,6 +415,10 @@ func newClient(cfg *Config) (*Client, error) {
 		client.Password = cfg.Password
 		client.authTokenBundle = credentials.NewBundle(credentials.Config{})
 	}
+	if cfg.AuthAssertion != nil {
+		client.authAssertion = cfg.AuthAssertion
+		client.authTokenBundle = credentials.NewBundle(credentials.Config{})
+	}
 	if cfg.MaxCallSendMsgSize > 0 || cfg.MaxCallRecvMsgSize > 0 {
 		if cfg.MaxCallRecvMsgSize > 0 && cfg.MaxCallSendMsgSize > cfg.MaxCallRecvMsgSize {
 			return nil, fmt.Errorf("gRPC message recv limit (%d bytes) must be greater than send limit (%d bytes)", cfg.MaxCallRecvMsgSize, cfg.MaxCallSendMsgSize)
@@ -407,13 +436,28 @@ func newClient(cfg *Config) (*Client, error) {
 		}
 		client.callOpts = callOpts
 	}
+	if cfg.PinEndpoint {
+		// fail fast with connection error instead of waiting for pinned endpoint to become ready,
+		// call options are applied in order so the appended option overrides any earlier one
+		callOpts := make([]grpc.CallOption, 0, len(client.callOpts)+1)
+		callOpts = append(callOpts, client.callOpts...)
+		client.callOpts = append(callOpts, grpc.WaitForReady(false))
+	}
 
 	client.resolver = resolver.New(cfg.Endpoints...)
+	if cfg.MemberRolesRefreshInterval > 0 {
+		client.memberRolesc = make(chan struct{}, 1)
+		client.endpointMembers = make(map[string]uint64)
+	}
 
 	if len(cfg.Endpoints) < 1 {
 		client.cancel()
 		return nil, errors.New("at least one Endpoint is required in client config")
 	}
+	if cfg.PinEndpoint && len(cfg.Endpoints) != 1 {
+		client.cancel()
+		return nil, errors.New("exactly one Endpoint is required in client config to pin client to it")
+	}
 	client.SetEndpoints(cfg.Endpoints...)
 
 	// Use a provided endpoint target so that for https:// without any tls config given, then
@@ -455,6 +499,7 @@ func newClient(cfg *Config) (*Client, error) {
 		}
 	}
 
+	client.initMemberRoles()
 	go client.autoSync()
 	return client, nil
 }
@@ -312,6 +312,44 @@ func TestSyncFiltersMembers(t *testing.T) {
 	}
 }
 
+func TestPinEndpoint(t *testing.T) {
+	_, err := NewClient(t, Config{Endpoints: []string{"127.0.0.1:12345", "127.0.0.1:12346"}, PinEndpoint: true})
+	if err == nil {
+		t.Fatal("expected pinning client to multiple endpoints to fail")
+	}
+
+	// endpoint that refuses connections
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep := lis.Addr().String()
+	lis.Close()
+
+	c, err := NewClient(t, Config{Endpoints: []string{ep}, PinEndpoint: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Cluster = &mockCluster{
+		[]*etcdserverpb.Member{
+			{ID: 1, Name: "other", ClientURLs: []string{"http://254.0.0.1:12345"}},
+		},
+	}
+	c.Sync(context.Background())
+	assert.Equal(t, []string{ep}, c.Endpoints())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = c.Get(ctx, "foo")
+	if err == nil || ctx.Err() != nil {
+		t.Fatalf("expected request to fail with connection error before timeout, got %v", err)
+	}
+	if !isUnavailableErr(ctx, err) {
+		t.Errorf("expected unavailable error, got %v", err)
+	}
+}
+
 func TestClientRejectOldCluster(t *testing.T) {
 	testutil.BeforeTest(t)
 	var tests = []struct {
@@ -382,6 +420,14 @@ func (mm mockMaintenance) Status(ctx context.Context, endpoint string) (*StatusR
 	return &StatusResponse{Version: mm.Version[endpoint]}, nil
 }
 
+func (mm mockMaintenance) ClusterStatus(ctx context.Context) (*ClusterStatusResponse, error) {
+	return nil, nil
+}
+
+func (mm mockMaintenance) MoveLeaderToMostUpToDate(ctx context.Context) (*MoveLeaderToMostUpToDateResponse, error) {
+	return nil, nil
+}
+
 func (mm mockMaintenance) AlarmList(ctx context.Context) (*AlarmResponse, error) {
 	return nil, nil
 }
@@ -402,6 +448,10 @@ func (mm mockMaintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotRes
 	return nil, nil
 }
 
+func (mm mockMaintenance) SnapshotWithProgress(ctx context.Context, progress func(SnapshotProgress)) (*SnapshotResponse, error) {
+	return nil, nil
+}
+
 func (mm mockMaintenance) Snapshot(ctx context.Context) (io.ReadCloser, error) {
 	return nil, nil
 }
@@ -64,3 +64,42 @@ func ExampleKeyExists() {
 		log.Fatal(err)
 	}
 }
+
+func ExampleCompareValueAndDelete() {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:2379"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cli.Close()
+
+	// release a lock only if it is still held by us
+	resp, err := clientv3util.CompareValueAndDelete(context.Background(), cli, "lock", "owner-1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !resp.Succeeded {
+		log.Println("lock is not held by owner-1")
+	}
+}
+
+func ExampleCompareMultiAndSwap() {
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{"127.0.0.1:2379"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cli.Close()
+
+	// publish config only if both of its dependencies are ready
+	expected := map[string]string{"schema": "v2", "migration": "done"}
+	resp, err := clientv3util.CompareMultiAndSwap(context.Background(), cli, expected, "config", "v2")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !resp.Succeeded {
+		log.Printf("dependencies %q are not ready", resp.FailedKeys)
+	}
+}
@@ -16,6 +16,10 @@
 package clientv3util
 
 import (
+	"context"
+	"sort"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -31,3 +35,71 @@ func KeyExists(key string) clientv3.Cmp {
 func KeyMissing(key string) clientv3.Cmp {
 	return clientv3.Compare(clientv3.Version(key), "=", 0)
 }
+
+// CompareValueAndDelete atomically deletes the key iff its current value is
+// equal to expectedValue. Response Succeeded field reports whether the value
+// matched; missing key never matches, so nothing is deleted in that case.
+func CompareValueAndDelete(ctx context.Context, kv clientv3.KV, key, expectedValue string) (*clientv3.TxnResponse, error) {
+	return kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", expectedValue)).
+		Then(clientv3.OpDelete(key)).
+		Commit()
+}
+
+// CompareAndSwapResult is the result of CompareMultiAndSwap.
+type CompareAndSwapResult struct {
+	// Succeeded reports whether all compared keys had expected values, so the value was put.
+	Succeeded bool
+	// FailedKeys are sorted compared keys that were missing or had value other than expected. Empty if Succeeded.
+	FailedKeys []string
+	// Current holds compared keys and the swapped key, as read when comparison failed. Missing keys are omitted.
+	// Nil if Succeeded.
+	Current map[string]*mvccpb.KeyValue
+	// Response is the transaction response the result was parsed from.
+	Response *clientv3.TxnResponse
+}
+
+// CompareMultiAndSwap atomically puts value to key iff each key of expected has
+// its expected value. Otherwise it atomically reads all compared keys and the key,
+// returning their current values and which comparisons failed. Missing key never
+// matches expected value, even an empty one.
+func CompareMultiAndSwap(ctx context.Context, kv clientv3.KV, expected map[string]string, key, value string) (*CompareAndSwapResult, error) {
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	cmps := make([]clientv3.Cmp, 0, len(keys))
+	reads := make([]clientv3.Op, 0, len(keys)+1)
+	for _, k := range keys {
+		cmps = append(cmps, clientv3.Compare(clientv3.Value(k), "=", expected[k]))
+		reads = append(reads, clientv3.OpGet(k))
+	}
+	if _, ok := expected[key]; !ok {
+		reads = append(reads, clientv3.OpGet(key))
+	}
+	resp, err := kv.Txn(ctx).
+		If(cmps...).
+		Then(clientv3.OpPut(key, value)).
+		Else(reads...).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+	result := &CompareAndSwapResult{Succeeded: resp.Succeeded, Response: resp}
+	if resp.Succeeded {
+		return result, nil
+	}
+	result.Current = map[string]*mvccpb.KeyValue{}
+	for _, r := range resp.Responses {
+		for _, kv := range r.GetResponseRange().Kvs {
+			result.Current[string(kv.Key)] = kv
+		}
+	}
+	for _, k := range keys {
+		if current, ok := result.Current[k]; !ok || string(current.Value) != expected[k] {
+			result.FailedKeys = append(result.FailedKeys, k)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	v3 "go.etcd.io/etcd/client/v3"
+)
+
+const defaultReregisterInterval = 500 * time.Millisecond
+
+// Registration represents an ephemeral key bound to a lease kept alive in
+// background, as commonly used for service discovery. The key disappears
+// once the lease expires or is revoked.
+type Registration struct {
+	client *v3.Client
+	opts   *registrationOptions
+	key    string
+	val    string
+
+	mu sync.Mutex
+	id v3.LeaseID
+
+	cancel context.CancelFunc
+	lostc  chan struct{}
+	donec  chan struct{}
+}
+
+// Register grants a lease, puts key with val bound to it and keeps the lease
+// alive until Deregister is called. If the lease is lost, Lost is signaled
+// and, if enabled by WithReregister, the key is registered again under a new
+// lease.
+func Register(client *v3.Client, key, val string, opts ...RegistrationOption) (*Registration, error) {
+	lg := client.GetLogger()
+	ops := &registrationOptions{ttl: defaultSessionTTL, ctx: client.Ctx(), reregisterInterval: defaultReregisterInterval}
+	for _, opt := range opts {
+		opt(ops, lg)
+	}
+
+	ctx, cancel := context.WithCancel(ops.ctx)
+	r := &Registration{
+		client: client,
+		opts:   ops,
+		key:    key,
+		val:    val,
+		cancel: cancel,
+		lostc:  make(chan struct{}, 1),
+		donec:  make(chan struct{}),
+	}
+	keepAlive, err := r.register(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go r.run(ctx, keepAlive)
+	return r, nil
+}
+
+// register grants a new lease, puts key bound to it and starts keeping it alive.
+func (r *Registration) register(ctx context.Context) (<-chan *v3.LeaseKeepAliveResponse, error) {
+	resp, err := r.client.Grant(ctx, int64(r.opts.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.client.Put(ctx, r.key, r.val, v3.WithLease(resp.ID)); err != nil {
+		r.revoke(resp.ID)
+		return nil, err
+	}
+	keepAlive, err := r.client.KeepAlive(ctx, resp.ID)
+	if err != nil || keepAlive == nil {
+		r.revoke(resp.ID)
+		return nil, err
+	}
+	r.mu.Lock()
+	r.id = resp.ID
+	r.mu.Unlock()
+	return keepAlive, nil
+}
+
+// run keeps the lease alive, registering the key again on lease loss if
+// re-registration is enabled, until the registration is canceled.
+func (r *Registration) run(ctx context.Context, keepAlive <-chan *v3.LeaseKeepAliveResponse) {
+	defer close(r.donec)
+	for {
+		for range keepAlive {
+			// eat messages until keep alive channel closes
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case r.lostc <- struct{}{}:
+		default:
+		}
+		if !r.opts.reregister {
+			return
+		}
+		for {
+			var err error
+			if keepAlive, err = r.register(ctx); err == nil {
+				break
+			}
+			r.client.GetLogger().Warn("failed to re-register key", zap.String("key", r.key), zap.Error(err))
+			select {
+			case <-time.After(r.opts.reregisterInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// revoke revokes lease, giving up if revoke takes longer than the ttl, as
+// lease is expired anyway.
+func (r *Registration) revoke(id v3.LeaseID) error {
+	ctx, cancel := context.WithTimeout(r.opts.ctx, time.Duration(r.opts.ttl)*time.Second)
+	_, err := r.client.Revoke(ctx, id)
+	cancel()
+	return err
+}
+
+// Key is the registered key.
+func (r *Registration) Key() string { return r.key }
+
+// Lease is the lease ID the key is currently registered under.
+func (r *Registration) Lease() v3.LeaseID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.id
+}
+
+// Lost returns a channel that is signaled when the lease expires or is
+// revoked, before the key is registered again. Loss is noticed by the next
+// keep alive, sent every third of the TTL. A single pending signal is
+// kept, so losses that are not consumed in time are coalesced.
+func (r *Registration) Lost() <-chan struct{} { return r.lostc }
+
+// Done returns a channel that closes when the lease is no longer being
+// refreshed, either because it was lost without re-registration or the
+// registration was deregistered.
+func (r *Registration) Done() <-chan struct{} { return r.donec }
+
+// Deregister stops keeping the lease alive and revokes it, removing the key.
+func (r *Registration) Deregister() error {
+	r.cancel()
+	<-r.donec
+	return r.revoke(r.Lease())
+}
+
+type registrationOptions struct {
+	ttl                int
+	ctx                context.Context
+	reregister         bool
+	reregisterInterval time.Duration
+}
+
+// RegistrationOption configures Registration.
+type RegistrationOption func(*registrationOptions, *zap.Logger)
+
+// WithRegistrationTTL configures the registration lease TTL in seconds.
+// If TTL is <= 0, the default 60 seconds TTL will be used.
+func WithRegistrationTTL(ttl int) RegistrationOption {
+	return func(ro *registrationOptions, lg *zap.Logger) {
+		if ttl > 0 {
+			ro.ttl = ttl
+		} else {
+			lg.Warn("WithRegistrationTTL(): TTL should be > 0, preserving current TTL", zap.Int64("current-registration-ttl", int64(ro.ttl)))
+		}
+	}
+}
+
+// WithRegistrationContext assigns a context to the registration instead of
+// defaulting to using the client context. Canceling it stops keeping the
+// lease alive, leaving it to expire.
+func WithRegistrationContext(ctx context.Context) RegistrationOption {
+	return func(ro *registrationOptions, _ *zap.Logger) {
+		ro.ctx = ctx
+	}
+}
+
+// WithReregister enables registering the key again under a new lease when
+// the lease is lost, retrying every interval until it succeeds. If interval
+// is <= 0, the default 500 milliseconds interval will be used.
+func WithReregister(interval time.Duration) RegistrationOption {
+	return func(ro *registrationOptions, _ *zap.Logger) {
+		ro.reregister = true
+		if interval > 0 {
+			ro.reregisterInterval = interval
+		}
+	}
+}
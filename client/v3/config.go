@@ -33,6 +33,16 @@ type Config struct {
 	// 0 disables auto-sync. By default auto-sync is disabled.
 	AutoSyncInterval time.Duration `json:"auto-sync-interval"`
 
+	// MemberRolesRefreshInterval is the interval to refresh roles of members of endpoints, so that requests
+	// are not balanced to endpoints of learners, unless all endpoints are learners. Roles are also refreshed
+	// when endpoints change or a learner rejects a request. 0 disables learner-aware endpoint selection.
+	MemberRolesRefreshInterval time.Duration `json:"member-roles-refresh-interval"`
+
+	// PinEndpoint pins client to its single endpoint without failover, for observing what exactly the member
+	// behind it returns. Requests fail right away with error of connection to the endpoint instead of waiting
+	// for it to become ready, and are not retried. Endpoints cannot be changed by SetEndpoints or auto-sync.
+	PinEndpoint bool `json:"pin-endpoint"`
+
 	// DialTimeout is the timeout for failing to establish a connection.
 	DialTimeout time.Duration `json:"dial-timeout"`
 
@@ -66,6 +76,11 @@ type Config struct {
 	// Password is a password for authentication.
 	Password string `json:"password"`
 
+	// AuthAssertion returns assertion, a JWT signed by an issuer trusted by the server, to authenticate with
+	// instead of Username and Password, which are ignored when it is set. It's called each time token is obtained,
+	// so short-lived assertions can be refreshed.
+	AuthAssertion func(ctx context.Context) (string, error) `json:"-"`
+
 	// RejectOldCluster when set will refuse to create a client against an outdated cluster.
 	RejectOldCluster bool `json:"reject-old-cluster"`
 
@@ -32,6 +32,8 @@ type EtcdManualResolver struct {
 	*manual.Resolver
 	endpoints     []string
 	serviceConfig *serviceconfig.ParseResult
+	// learners are endpoints of learner members, excluded from addresses unless all endpoints are learners.
+	learners map[string]struct{}
 }
 
 func New(endpoints ...string) *EtcdManualResolver {
@@ -59,10 +61,34 @@ func (r *EtcdManualResolver) SetEndpoints(endpoints []string) {
 	r.updateState()
 }
 
+// SetLearnerEndpoints excludes given endpoints from balancing, as learners don't serve most requests.
+func (r *EtcdManualResolver) SetLearnerEndpoints(learners []string) {
+	r.learners = make(map[string]struct{}, len(learners))
+	for _, ep := range learners {
+		r.learners[ep] = struct{}{}
+	}
+	r.updateState()
+}
+
+// balancedEndpoints returns endpoints excluding learners, all endpoints if all of them are learners.
+func (r EtcdManualResolver) balancedEndpoints() []string {
+	eps := make([]string, 0, len(r.endpoints))
+	for _, ep := range r.endpoints {
+		if _, ok := r.learners[ep]; !ok {
+			eps = append(eps, ep)
+		}
+	}
+	if len(eps) == 0 {
+		return r.endpoints
+	}
+	return eps
+}
+
 func (r EtcdManualResolver) updateState() {
 	if r.CC != nil {
-		addresses := make([]resolver.Address, len(r.endpoints))
-		for i, ep := range r.endpoints {
+		eps := r.balancedEndpoints()
+		addresses := make([]resolver.Address, len(eps))
+		for i, ep := range eps {
 			addr, serverName := endpoint.Interpret(ep)
 			addresses[i] = resolver.Address{Addr: addr, ServerName: serverName}
 		}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -38,6 +39,9 @@ type (
 	DowngradeAction pb.DowngradeRequest_DowngradeAction
 )
 
+// ErrNoCaughtUpFollower is returned by MoveLeaderToMostUpToDate if no follower is caught up with the leader.
+var ErrNoCaughtUpFollower = errors.New("etcdclient: no follower is caught up with the leader")
+
 const (
 	DowngradeValidate = DowngradeAction(pb.DowngradeRequest_VALIDATE)
 	DowngradeEnable   = DowngradeAction(pb.DowngradeRequest_ENABLE)
@@ -63,6 +67,12 @@ type Maintenance interface {
 	// Status gets the status of the endpoint.
 	Status(ctx context.Context, endpoint string) (*StatusResponse, error)
 
+	// ClusterStatus gets the status of all cluster members, including db size and db size in use,
+	// which tell whether member needs defragmentation. Members are requested concurrently.
+	// Failing to get status of a member is reported in its MemberStatus, so partial results
+	// are returned if some members are unreachable. Error is returned only if members couldn't be listed.
+	ClusterStatus(ctx context.Context) (*ClusterStatusResponse, error)
+
 	// HashKV returns a hash of the KV state at the time of the RPC.
 	// If revision is zero, the hash is computed on all keys. If the revision
 	// is non-zero, the hash is computed on all keys at or below the given revision.
@@ -73,6 +83,11 @@ type Maintenance interface {
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
 	SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error)
 
+	// SnapshotWithProgress is like SnapshotWithVersion, additionally calling progress after each chunk of snapshot
+	// was read from returned "io.ReadCloser". Progress is reported from the goroutine receiving the snapshot stream,
+	// so progress should return quickly. Long time since last progress report indicates stalled snapshot.
+	SnapshotWithProgress(ctx context.Context, progress func(SnapshotProgress)) (*SnapshotResponse, error)
+
 	// Snapshot provides a reader for a point-in-time snapshot of etcd.
 	// If the context "ctx" is canceled or timed out, reading from returned
 	// "io.ReadCloser" would error out (e.g. context.Canceled, context.DeadlineExceeded).
@@ -83,6 +98,14 @@ type Maintenance interface {
 	// Request must be made to the leader.
 	MoveLeader(ctx context.Context, transfereeID uint64) (*MoveLeaderResponse, error)
 
+	// MoveLeaderToMostUpToDate moves leadership from the current leader to its most up-to-date follower,
+	// for example before the leader is restarted during rolling upgrade. Request is made to the leader
+	// found by ClusterStatus, so it doesn't depend on client endpoints. Followers are compared by raft index
+	// of their log, as match index tracked by leader isn't exposed by status. Learners and followers that
+	// didn't reach the index applied by the leader are not caught up, ErrNoCaughtUpFollower is returned
+	// without transferring leadership if there is none.
+	MoveLeaderToMostUpToDate(ctx context.Context) (*MoveLeaderToMostUpToDateResponse, error)
+
 	// Downgrade requests downgrades, verifies feasibility or cancels downgrade
 	// on the cluster version.
 	// Supported since etcd 3.5.
@@ -104,11 +127,50 @@ type SnapshotResponse struct {
 	Version string
 }
 
+// SnapshotProgress describes how much of the snapshot was received.
+// It is derived from remaining bytes reported with each chunk of the snapshot stream, so it doesn't alter the snapshot data.
+type SnapshotProgress struct {
+	// ReceivedBytes is number of snapshot bytes received, excluding sha256 checksum sent after the snapshot.
+	ReceivedBytes int64
+	// TotalBytes is size of the snapshot as estimated by server when the stream started,
+	// ReceivedBytes equals TotalBytes once whole snapshot was received.
+	TotalBytes int64
+}
+
+// ClusterStatusResponse is the status of all cluster members returned by ClusterStatus.
+type ClusterStatusResponse struct {
+	// Header is the header of member list response the members were taken from.
+	Header *pb.ResponseHeader
+	// Members are in the order returned by member list.
+	Members []MemberStatus
+}
+
+// MoveLeaderToMostUpToDateResponse is returned by MoveLeaderToMostUpToDate.
+type MoveLeaderToMostUpToDateResponse struct {
+	// Header is the header of move leader response of the former leader.
+	Header *pb.ResponseHeader
+	// LeaderID is the ID of the member leadership was moved from.
+	LeaderID uint64
+	// Transferee is the status of the follower leadership was moved to, taken before the transfer.
+	Transferee MemberStatus
+}
+
+// MemberStatus is the status of a single cluster member.
+type MemberStatus struct {
+	Member *pb.Member
+	// Endpoint is the client URL of the member that served the status, or the last one tried if all failed.
+	Endpoint string
+	// Status is nil if Err is set.
+	Status *StatusResponse
+	Err    error
+}
+
 type maintenance struct {
-	lg       *zap.Logger
-	dial     func(endpoint string) (pb.MaintenanceClient, func(), error)
-	remote   pb.MaintenanceClient
-	callOpts []grpc.CallOption
+	lg         *zap.Logger
+	dial       func(endpoint string) (pb.MaintenanceClient, func(), error)
+	memberList func(ctx context.Context) (*MemberListResponse, error)
+	remote     pb.MaintenanceClient
+	callOpts   []grpc.CallOption
 }
 
 func NewMaintenance(c *Client) Maintenance {
@@ -123,6 +185,9 @@ func NewMaintenance(c *Client) Maintenance {
 			cancel := func() { conn.Close() }
 			return RetryMaintenanceClient(c, conn), cancel, nil
 		},
+		memberList: func(ctx context.Context) (*MemberListResponse, error) {
+			return c.Cluster.MemberList(ctx)
+		},
 		remote: RetryMaintenanceClient(c, c.conn),
 	}
 	if c != nil {
@@ -137,6 +202,9 @@ func NewMaintenanceFromMaintenanceClient(remote pb.MaintenanceClient, c *Client)
 		dial: func(string) (pb.MaintenanceClient, func(), error) {
 			return remote, func() {}, nil
 		},
+		memberList: func(ctx context.Context) (*MemberListResponse, error) {
+			return c.Cluster.MemberList(ctx)
+		},
 		remote: remote,
 	}
 	if c != nil {
@@ -214,6 +282,52 @@ func (m *maintenance) Status(ctx context.Context, endpoint string) (*StatusRespo
 	return (*StatusResponse)(resp), nil
 }
 
+func (m *maintenance) ClusterStatus(ctx context.Context) (*ClusterStatusResponse, error) {
+	members, err := m.memberList(ctx)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	resp := &ClusterStatusResponse{
+		Header:  members.Header,
+		Members: make([]MemberStatus, len(members.Members)),
+	}
+	var wg sync.WaitGroup
+	for i, member := range members.Members {
+		wg.Add(1)
+		go func(i int, member *pb.Member) {
+			defer wg.Done()
+			resp.Members[i] = m.memberStatus(ctx, member)
+		}(i, member)
+	}
+	wg.Wait()
+	return resp, nil
+}
+
+// memberStatus requests status from member client URLs until one succeeds.
+func (m *maintenance) memberStatus(ctx context.Context, member *pb.Member) MemberStatus {
+	status := MemberStatus{Member: member}
+	if len(member.ClientURLs) == 0 {
+		status.Err = fmt.Errorf("etcdclient: member %x has no client URLs", member.ID)
+		return status
+	}
+	for _, endpoint := range member.ClientURLs {
+		status.Endpoint = endpoint
+		resp, err := m.Status(ctx, endpoint)
+		if err != nil {
+			status.Err = err
+			continue
+		}
+		if resp.Header != nil && resp.Header.MemberId != member.ID {
+			status.Err = fmt.Errorf("etcdclient: endpoint %s is served by member %x instead of %x", endpoint, resp.Header.MemberId, member.ID)
+			continue
+		}
+		status.Status = resp
+		status.Err = nil
+		return status
+	}
+	return status
+}
+
 func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error) {
 	remote, cancel, err := m.dial(endpoint)
 	if err != nil {
@@ -229,6 +343,10 @@ func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*
 }
 
 func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotResponse, error) {
+	return m.SnapshotWithProgress(ctx, nil)
+}
+
+func (m *maintenance) SnapshotWithProgress(ctx context.Context, progress func(SnapshotProgress)) (*SnapshotResponse, error) {
 	ss, err := m.remote.Snapshot(ctx, &pb.SnapshotRequest{}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
 	if err != nil {
 		return nil, toErr(ctx, err)
@@ -243,12 +361,14 @@ func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotRespons
 		return nil, err
 	}
 	go func() {
+		tracker := snapshotProgressTracker{progress: progress}
 		// Saving response is blocking
 		err = m.save(resp, pw)
 		if err != nil {
 			m.logAndCloseWithError(err, pw)
 			return
 		}
+		tracker.received(resp)
 		for {
 			resp, err := ss.Recv()
 			if err != nil {
@@ -260,6 +380,7 @@ func (m *maintenance) SnapshotWithVersion(ctx context.Context) (*SnapshotRespons
 				m.logAndCloseWithError(err, pw)
 				return
 			}
+			tracker.received(resp)
 		}
 	}()
 
@@ -318,6 +439,28 @@ func (m *maintenance) save(resp *pb.SnapshotResponse, pw *io.PipeWriter) error {
 	return nil
 }
 
+// snapshotProgressTracker reports progress of snapshot stream. Server sends snapshot in chunks with number of bytes
+// remaining after each of them, followed by a chunk with sha256 checksum, which is not counted as snapshot data.
+type snapshotProgressTracker struct {
+	progress func(SnapshotProgress)
+	started  bool
+	done     bool
+	SnapshotProgress
+}
+
+func (t *snapshotProgressTracker) received(resp *pb.SnapshotResponse) {
+	if t.progress == nil || t.done {
+		return
+	}
+	t.ReceivedBytes += int64(len(resp.Blob))
+	if !t.started {
+		t.started = true
+		t.TotalBytes = t.ReceivedBytes + int64(resp.RemainingBytes)
+	}
+	t.done = resp.RemainingBytes == 0
+	t.progress(t.SnapshotProgress)
+}
+
 type snapshotReadCloser struct {
 	ctx context.Context
 	io.ReadCloser
@@ -333,6 +476,57 @@ func (m *maintenance) MoveLeader(ctx context.Context, transfereeID uint64) (*Mov
 	return (*MoveLeaderResponse)(resp), toErr(ctx, err)
 }
 
+func (m *maintenance) MoveLeaderToMostUpToDate(ctx context.Context) (*MoveLeaderToMostUpToDateResponse, error) {
+	cluster, err := m.ClusterStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	leader, transferee, err := mostUpToDateFollower(cluster.Members)
+	if err != nil {
+		return nil, err
+	}
+	remote, cancel, err := m.dial(leader.Endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.MoveLeader(ctx, &pb.MoveLeaderRequest{TargetID: transferee.Member.ID}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return &MoveLeaderToMostUpToDateResponse{Header: resp.Header, LeaderID: leader.Member.ID, Transferee: transferee}, nil
+}
+
+// mostUpToDateFollower returns the leader and its follower with the highest raft index among followers of the
+// leader's term that reached the index applied by the leader.
+func mostUpToDateFollower(members []MemberStatus) (leader, transferee MemberStatus, err error) {
+	var found bool
+	for _, member := range members {
+		if member.Status != nil && member.Status.Leader == member.Member.ID {
+			leader, found = member, true
+			break
+		}
+	}
+	if !found {
+		return leader, transferee, errors.New("etcdclient: no member reported itself as leader")
+	}
+	found = false
+	for _, member := range members {
+		status := member.Status
+		if status == nil || member.Member.ID == leader.Member.ID || status.IsLearner || status.Leader != leader.Member.ID ||
+			status.RaftTerm != leader.Status.RaftTerm || status.RaftIndex < leader.Status.RaftAppliedIndex {
+			continue
+		}
+		if !found || status.RaftIndex > transferee.Status.RaftIndex {
+			transferee, found = member, true
+		}
+	}
+	if !found {
+		return leader, transferee, ErrNoCaughtUpFollower
+	}
+	return leader, transferee, nil
+}
+
 func (m *maintenance) Downgrade(ctx context.Context, action DowngradeAction, version string) (*DowngradeResponse, error) {
 	var actionType pb.DowngradeRequest_DowngradeAction
 	switch action {
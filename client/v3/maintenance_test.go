@@ -0,0 +1,211 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+type statusMaintenanceClient struct {
+	pb.MaintenanceClient
+	status *pb.StatusResponse
+}
+
+func (c statusMaintenanceClient) Status(ctx context.Context, in *pb.StatusRequest, opts ...grpc.CallOption) (*pb.StatusResponse, error) {
+	return c.status, nil
+}
+
+func TestMaintenanceClusterStatus(t *testing.T) {
+	members := []*pb.Member{
+		{ID: 1, ClientURLs: []string{"http://member1"}},
+		{ID: 2, ClientURLs: []string{"http://unreachable", "http://member2"}},
+		{ID: 3, ClientURLs: []string{"http://unreachable"}},
+		{ID: 4, ClientURLs: []string{"http://member1"}},
+		{ID: 5},
+	}
+	statuses := map[string]*pb.StatusResponse{
+		"http://member1": {Header: &pb.ResponseHeader{MemberId: 1}, Leader: 2, DbSize: 100, DbSizeInUse: 40, RaftIndex: 10},
+		"http://member2": {Header: &pb.ResponseHeader{MemberId: 2}, Leader: 2, DbSize: 200, DbSizeInUse: 150, RaftIndex: 11},
+	}
+	m := &maintenance{
+		lg: zaptest.NewLogger(t),
+		dial: func(endpoint string) (pb.MaintenanceClient, func(), error) {
+			status, ok := statuses[endpoint]
+			if !ok {
+				return nil, nil, errors.New("unreachable")
+			}
+			return statusMaintenanceClient{status: status}, func() {}, nil
+		},
+		memberList: func(ctx context.Context) (*MemberListResponse, error) {
+			return &MemberListResponse{Header: &pb.ResponseHeader{Revision: 5}, Members: members}, nil
+		},
+	}
+
+	resp, err := m.ClusterStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), resp.Header.Revision)
+	require.Len(t, resp.Members, len(members))
+	for i, member := range resp.Members {
+		assert.Equal(t, members[i], member.Member)
+	}
+
+	assert.NoError(t, resp.Members[0].Err)
+	assert.Equal(t, "http://member1", resp.Members[0].Endpoint)
+	assert.Equal(t, int64(100), resp.Members[0].Status.DbSize)
+	assert.Equal(t, int64(40), resp.Members[0].Status.DbSizeInUse)
+	assert.Equal(t, uint64(2), resp.Members[0].Status.Leader)
+	assert.Equal(t, uint64(10), resp.Members[0].Status.RaftIndex)
+
+	assert.NoError(t, resp.Members[1].Err, "should fall back to next client URL")
+	assert.Equal(t, "http://member2", resp.Members[1].Endpoint)
+	assert.Equal(t, int64(200), resp.Members[1].Status.DbSize)
+
+	assert.Error(t, resp.Members[2].Err)
+	assert.Nil(t, resp.Members[2].Status)
+	assert.Equal(t, "http://unreachable", resp.Members[2].Endpoint)
+
+	assert.ErrorContains(t, resp.Members[3].Err, "served by member 1 instead of 4")
+	assert.Nil(t, resp.Members[3].Status)
+
+	assert.ErrorContains(t, resp.Members[4].Err, "no client URLs")
+	assert.Nil(t, resp.Members[4].Status)
+}
+
+func TestMaintenanceClusterStatusMemberListError(t *testing.T) {
+	m := &maintenance{
+		lg: zaptest.NewLogger(t),
+		memberList: func(ctx context.Context) (*MemberListResponse, error) {
+			return nil, errors.New("member list failed")
+		},
+	}
+	_, err := m.ClusterStatus(context.Background())
+	assert.ErrorContains(t, err, "member list failed")
+}
+
+type moveLeaderMaintenanceClient struct {
+	statusMaintenanceClient
+	moved *[]uint64
+}
+
+func (c moveLeaderMaintenanceClient) MoveLeader(ctx context.Context, in *pb.MoveLeaderRequest, opts ...grpc.CallOption) (*pb.MoveLeaderResponse, error) {
+	*c.moved = append(*c.moved, c.status.Header.MemberId, in.TargetID)
+	return &pb.MoveLeaderResponse{Header: &pb.ResponseHeader{MemberId: c.status.Header.MemberId}}, nil
+}
+
+func TestMaintenanceMoveLeaderToMostUpToDate(t *testing.T) {
+	leader := &pb.StatusResponse{Header: &pb.ResponseHeader{MemberId: 1}, Leader: 1, RaftTerm: 2, RaftIndex: 20, RaftAppliedIndex: 18}
+	follower := func(id, term, index uint64) *pb.StatusResponse {
+		return &pb.StatusResponse{Header: &pb.ResponseHeader{MemberId: id}, Leader: 1, RaftTerm: term, RaftIndex: index}
+	}
+	tcs := []struct {
+		name             string
+		statuses         []*pb.StatusResponse
+		expectTransferee uint64
+		expectErr        error
+	}{
+		{
+			name:             "Follower with highest raft index is chosen",
+			statuses:         []*pb.StatusResponse{leader, follower(2, 2, 19), follower(3, 2, 20)},
+			expectTransferee: 3,
+		},
+		{
+			name:             "Unreachable follower is skipped",
+			statuses:         []*pb.StatusResponse{leader, nil, follower(3, 2, 18)},
+			expectTransferee: 3,
+		},
+		{
+			name:             "Learner is skipped",
+			statuses:         []*pb.StatusResponse{leader, {Header: &pb.ResponseHeader{MemberId: 2}, Leader: 1, RaftTerm: 2, RaftIndex: 20, IsLearner: true}, follower(3, 2, 19)},
+			expectTransferee: 3,
+		},
+		{
+			name:      "Follower behind applied index of leader is not caught up",
+			statuses:  []*pb.StatusResponse{leader, follower(2, 2, 17), follower(3, 2, 10)},
+			expectErr: ErrNoCaughtUpFollower,
+		},
+		{
+			name:      "Follower of other term is not caught up",
+			statuses:  []*pb.StatusResponse{leader, follower(2, 1, 20)},
+			expectErr: ErrNoCaughtUpFollower,
+		},
+		{
+			name:      "Single member has no follower",
+			statuses:  []*pb.StatusResponse{leader},
+			expectErr: ErrNoCaughtUpFollower,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var members []*pb.Member
+			statuses := map[string]*pb.StatusResponse{}
+			for i, status := range tc.statuses {
+				endpoint := fmt.Sprintf("http://member%d", i+1)
+				members = append(members, &pb.Member{ID: uint64(i + 1), ClientURLs: []string{endpoint}})
+				if status != nil {
+					statuses[endpoint] = status
+				}
+			}
+			var moved []uint64
+			m := &maintenance{
+				lg: zaptest.NewLogger(t),
+				dial: func(endpoint string) (pb.MaintenanceClient, func(), error) {
+					status, ok := statuses[endpoint]
+					if !ok {
+						return nil, nil, errors.New("unreachable")
+					}
+					return moveLeaderMaintenanceClient{statusMaintenanceClient: statusMaintenanceClient{status: status}, moved: &moved}, func() {}, nil
+				},
+				memberList: func(ctx context.Context) (*MemberListResponse, error) {
+					return &MemberListResponse{Header: &pb.ResponseHeader{}, Members: members}, nil
+				},
+			}
+
+			resp, err := m.MoveLeaderToMostUpToDate(context.Background())
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				assert.Empty(t, moved, "leadership shouldn't be transferred")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), resp.LeaderID)
+			assert.Equal(t, tc.expectTransferee, resp.Transferee.Member.ID)
+			assert.Equal(t, []uint64{1, tc.expectTransferee}, moved, "request should be made to the leader")
+		})
+	}
+}
+
+func TestMaintenanceMoveLeaderToMostUpToDateNoLeader(t *testing.T) {
+	m := &maintenance{
+		lg: zaptest.NewLogger(t),
+		dial: func(endpoint string) (pb.MaintenanceClient, func(), error) {
+			return statusMaintenanceClient{status: &pb.StatusResponse{Header: &pb.ResponseHeader{MemberId: 1}}}, func() {}, nil
+		},
+		memberList: func(ctx context.Context) (*MemberListResponse, error) {
+			return &MemberListResponse{Header: &pb.ResponseHeader{}, Members: []*pb.Member{{ID: 1, ClientURLs: []string{"http://member1"}}}}, nil
+		},
+	}
+	_, err := m.MoveLeaderToMostUpToDate(context.Background())
+	assert.ErrorContains(t, err, "no member reported itself as leader")
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// memberRolesRefreshTimeout limits a single refresh of member roles.
+const memberRolesRefreshTimeout = 5 * time.Second
+
+// initMemberRoles refreshes member roles before client is returned, so its first requests already avoid learners,
+// and starts refreshing them periodically.
+func (c *Client) initMemberRoles() {
+	if c.memberRolesc == nil {
+		return
+	}
+	c.refreshMemberRolesWithTimeout()
+	// drop refresh requested by setting initial endpoints or by learners rejecting the refresh
+	select {
+	case <-c.memberRolesc:
+	default:
+	}
+	go c.memberRolesLoop()
+}
+
+func (c *Client) memberRolesLoop() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.cfg.MemberRolesRefreshInterval):
+		case <-c.memberRolesc:
+		}
+		c.refreshMemberRolesWithTimeout()
+		// Refresh itself can be rejected by learner, don't let it request another refresh right away.
+		select {
+		case <-c.memberRolesc:
+		default:
+		}
+	}
+}
+
+func (c *Client) refreshMemberRolesWithTimeout() {
+	ctx, cancel := context.WithTimeout(c.ctx, memberRolesRefreshTimeout)
+	err := c.refreshMemberRoles(ctx)
+	cancel()
+	if err != nil && err != c.ctx.Err() {
+		c.lg.Info("Refreshing member roles failed.", zap.Error(err))
+	}
+}
+
+// refreshMemberRoles lists members to learn which of them are learners and excludes their endpoints from balancing.
+// Endpoints don't need to match client URLs advertised by members, member serving endpoint is identified by
+// status request, which learners also serve. Endpoint whose member is not known is balanced.
+func (c *Client) refreshMemberRoles(ctx context.Context) error {
+	resp, err := c.MemberList(ctx)
+	if err != nil {
+		return err
+	}
+	members := make(map[uint64]bool, len(resp.Members))
+	for _, m := range resp.Members {
+		members[m.ID] = m.IsLearner
+	}
+	var learners []string
+	for _, ep := range c.Endpoints() {
+		id, ok := c.endpointMembers[ep]
+		if _, isMember := members[id]; !ok || !isMember {
+			status, err := c.Status(ctx, ep)
+			if err != nil {
+				c.lg.Debug("failed to identify member of endpoint", zap.String("endpoint", ep), zap.Error(err))
+				continue
+			}
+			id = status.Header.MemberId
+			c.endpointMembers[ep] = id
+		}
+		if members[id] {
+			learners = append(learners, ep)
+		}
+	}
+
+	c.epMu.Lock()
+	defer c.epMu.Unlock()
+	c.resolver.SetLearnerEndpoints(learners)
+	c.lg.Debug("set learner endpoints by member roles refresh", zap.Strings("learners", learners))
+	return nil
+}
+
+// requestMemberRolesRefresh requests refresh of member roles without waiting for it.
+func (c *Client) requestMemberRolesRefresh() {
+	if c.memberRolesc == nil {
+		return
+	}
+	select {
+	case c.memberRolesc <- struct{}{}:
+	default:
+	}
+}
+
+// checkLearnerError requests refresh of member roles if request was rejected by learner, as member
+// was added as learner or roles were not refreshed yet.
+func (c *Client) checkLearnerError(err error) {
+	if err != nil && errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) {
+		c.requestMemberRolesRefresh()
+	}
+}
@@ -38,17 +38,37 @@ func NewWatcher(w clientv3.Watcher, prefix string) clientv3.Watcher {
 }
 
 func (w *watcherPrefix) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	spec := w.prefixSpec(clientv3.WatchSpec{Key: key, Opts: opts})
+	return w.unprefix(ctx, w.Watcher.Watch(ctx, spec.Key, spec.Opts...))
+}
+
+func (w *watcherPrefix) WatchBatch(ctx context.Context, specs ...clientv3.WatchSpec) []clientv3.WatchChan {
+	pfxSpecs := make([]clientv3.WatchSpec, len(specs))
+	for i, spec := range specs {
+		pfxSpecs[i] = w.prefixSpec(spec)
+	}
+	wchs := clientv3.WatchBatch(ctx, w.Watcher, pfxSpecs...)
+	for i := range wchs {
+		wchs[i] = w.unprefix(ctx, wchs[i])
+	}
+	return wchs
+}
+
+// prefixSpec translates watched range of spec from unprefixed to prefixed.
+func (w *watcherPrefix) prefixSpec(spec clientv3.WatchSpec) clientv3.WatchSpec {
 	// since OpOption is opaque, determine range for prefixing through an OpGet
-	op := clientv3.OpGet(key, opts...)
+	op := clientv3.OpGet(spec.Key, spec.Opts...)
 	end := op.RangeBytes()
-	pfxBegin, pfxEnd := prefixInterval(w.pfx, []byte(key), end)
+	pfxBegin, pfxEnd := prefixInterval(w.pfx, []byte(spec.Key), end)
+	opts := spec.Opts
 	if pfxEnd != nil {
 		opts = append(opts, clientv3.WithRange(string(pfxEnd)))
 	}
+	return clientv3.WatchSpec{Key: string(pfxBegin), Opts: opts}
+}
 
-	wch := w.Watcher.Watch(ctx, string(pfxBegin), opts...)
-
-	// translate watch events from prefixed to unprefixed
+// unprefix translates watch events from prefixed to unprefixed.
+func (w *watcherPrefix) unprefix(ctx context.Context, wch clientv3.WatchChan) clientv3.WatchChan {
 	pfxWch := make(chan clientv3.WatchResponse)
 	w.wg.Add(1)
 	go func() {
@@ -142,6 +142,9 @@ func (op *Op) WithRangeBytes(end []byte) { op.end = end }
 // ValueBytes returns the byte slice holding the Op's value, if any.
 func (op Op) ValueBytes() []byte { return op.val }
 
+// LeaseID returns the lease the Op's key is attached to, if any.
+func (op Op) LeaseID() LeaseID { return op.leaseID }
+
 // WithValueBytes sets the byte slice for the Op's value.
 func (op *Op) WithValueBytes(v []byte) { op.val = v }
 
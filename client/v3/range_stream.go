@@ -0,0 +1,107 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+const defaultRangeStreamPageSize = 1000
+
+// RangeStreamResponse is a page of keys read by RangeStream.
+type RangeStreamResponse struct {
+	// Kvs are keys of the page, sorted by key. Pages don't overlap and follow each other in key order.
+	Kvs []*mvccpb.KeyValue
+	// Revision is the revision all pages are read at, so together they form a consistent snapshot.
+	Revision int64
+	// Err is set if reading next page failed, it's the last response on the channel.
+	// Err wraps ErrCompacted if Revision was compacted before all pages were read.
+	Err error
+}
+
+type RangeStreamChan <-chan RangeStreamResponse
+
+type rangeStreamConfig struct {
+	pageSize int64
+}
+
+// RangeStreamOption configures RangeStream.
+type RangeStreamOption func(*rangeStreamConfig)
+
+// WithRangeStreamPageSize sets maximal number of keys read by single request of RangeStream.
+func WithRangeStreamPageSize(pageSize int64) RangeStreamOption {
+	return func(cfg *rangeStreamConfig) {
+		cfg.pageSize = pageSize
+	}
+}
+
+// RangeStream reads all keys with prefix in pages, so ranges too large for a single response can be read.
+// The first page is read at the current revision, following pages at the same revision, so all pages
+// together are equal to a single range of the prefix at that revision. At least one page is returned,
+// even if no key has the prefix. Channel is closed after the last page, after response with error or when ctx
+// is canceled, in which case pages might be missing without an error, so ctx.Err() has to be checked.
+func RangeStream(ctx context.Context, kv KV, prefix string, opts ...RangeStreamOption) RangeStreamChan {
+	cfg := rangeStreamConfig{pageSize: defaultRangeStreamPageSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ch := make(chan RangeStreamResponse)
+	go rangeStream(ctx, kv, prefix, cfg, ch)
+	return ch
+}
+
+func rangeStream(ctx context.Context, kv KV, prefix string, cfg rangeStreamConfig, ch chan<- RangeStreamResponse) {
+	defer close(ch)
+	key, end := prefix, GetPrefixRangeEnd(prefix)
+	if key == "" {
+		// Empty key is invalid, so like WithPrefix start from the smallest key.
+		key = "\x00"
+	}
+	var revision int64
+	for {
+		opts := []OpOption{WithRange(end), WithLimit(cfg.pageSize)}
+		if revision != 0 {
+			opts = append(opts, WithRev(revision))
+		}
+		resp, err := kv.Get(ctx, key, opts...)
+		if err != nil {
+			if errors.Is(err, rpctypes.ErrCompacted) {
+				err = fmt.Errorf("range stream revision %d was compacted before all pages were read: %w", revision, err)
+			}
+			select {
+			case ch <- RangeStreamResponse{Revision: revision, Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		if revision == 0 {
+			revision = resp.Header.Revision
+		}
+		select {
+		case ch <- RangeStreamResponse{Kvs: resp.Kvs, Revision: revision}:
+		case <-ctx.Done():
+			return
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return
+		}
+		key = string(append(resp.Kvs[len(resp.Kvs)-1].Key, 0))
+	}
+}
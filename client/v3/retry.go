@@ -245,6 +245,14 @@ func (rac *retryAuthClient) UserGet(ctx context.Context, in *pb.AuthUserGetReque
 	return rac.ac.UserGet(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rac *retryAuthClient) UserPermissionsAtRevision(ctx context.Context, in *pb.AuthUserPermissionsAtRevisionRequest, opts ...grpc.CallOption) (resp *pb.AuthUserPermissionsAtRevisionResponse, err error) {
+	return rac.ac.UserPermissionsAtRevision(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rac *retryAuthClient) UserVerifyPassword(ctx context.Context, in *pb.AuthUserVerifyPasswordRequest, opts ...grpc.CallOption) (resp *pb.AuthUserVerifyPasswordResponse, err error) {
+	return rac.ac.UserVerifyPassword(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rac *retryAuthClient) RoleGet(ctx context.Context, in *pb.AuthRoleGetRequest, opts ...grpc.CallOption) (resp *pb.AuthRoleGetResponse, err error) {
 	return rac.ac.RoleGet(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
@@ -253,6 +261,14 @@ func (rac *retryAuthClient) RoleList(ctx context.Context, in *pb.AuthRoleListReq
 	return rac.ac.RoleList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rac *retryAuthClient) UsersWithRole(ctx context.Context, in *pb.AuthUsersWithRoleRequest, opts ...grpc.CallOption) (resp *pb.AuthUsersWithRoleResponse, err error) {
+	return rac.ac.UsersWithRole(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rac *retryAuthClient) WhoAmI(ctx context.Context, in *pb.AuthWhoAmIRequest, opts ...grpc.CallOption) (resp *pb.AuthWhoAmIResponse, err error) {
+	return rac.ac.WhoAmI(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rac *retryAuthClient) AuthEnable(ctx context.Context, in *pb.AuthEnableRequest, opts ...grpc.CallOption) (resp *pb.AuthEnableResponse, err error) {
 	return rac.ac.AuthEnable(ctx, in, opts...)
 }
@@ -285,6 +301,14 @@ func (rac *retryAuthClient) UserRevokeRole(ctx context.Context, in *pb.AuthUserR
 	return rac.ac.UserRevokeRole(ctx, in, opts...)
 }
 
+func (rac *retryAuthClient) UserSetRoles(ctx context.Context, in *pb.AuthUserSetRolesRequest, opts ...grpc.CallOption) (resp *pb.AuthUserSetRolesResponse, err error) {
+	return rac.ac.UserSetRoles(ctx, in, opts...)
+}
+
+func (rac *retryAuthClient) UserSetImmutable(ctx context.Context, in *pb.AuthUserSetImmutableRequest, opts ...grpc.CallOption) (resp *pb.AuthUserSetImmutableResponse, err error) {
+	return rac.ac.UserSetImmutable(ctx, in, opts...)
+}
+
 func (rac *retryAuthClient) RoleAdd(ctx context.Context, in *pb.AuthRoleAddRequest, opts ...grpc.CallOption) (resp *pb.AuthRoleAddResponse, err error) {
 	return rac.ac.RoleAdd(ctx, in, opts...)
 }
@@ -301,6 +325,14 @@ func (rac *retryAuthClient) RoleRevokePermission(ctx context.Context, in *pb.Aut
 	return rac.ac.RoleRevokePermission(ctx, in, opts...)
 }
 
+func (rac *retryAuthClient) RoleSetQuota(ctx context.Context, in *pb.AuthRoleSetQuotaRequest, opts ...grpc.CallOption) (resp *pb.AuthRoleSetQuotaResponse, err error) {
+	return rac.ac.RoleSetQuota(ctx, in, opts...)
+}
+
+func (rac *retryAuthClient) RoleSetAdminPrefix(ctx context.Context, in *pb.AuthRoleSetAdminPrefixRequest, opts ...grpc.CallOption) (resp *pb.AuthRoleSetAdminPrefixResponse, err error) {
+	return rac.ac.RoleSetAdminPrefix(ctx, in, opts...)
+}
+
 func (rac *retryAuthClient) Authenticate(ctx context.Context, in *pb.AuthenticateRequest, opts ...grpc.CallOption) (resp *pb.AuthenticateResponse, err error) {
 	return rac.ac.Authenticate(ctx, in, opts...)
 }
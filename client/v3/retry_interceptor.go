@@ -45,7 +45,9 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 		callOpts := reuseOrNewWithCallOptions(intOpts, retryOpts)
 		// short circuit for simplicity, and avoiding allocations.
 		if callOpts.max == 0 {
-			return invoker(ctx, method, req, reply, cc, grpcOpts...)
+			err := invoker(ctx, method, req, reply, cc, grpcOpts...)
+			c.checkLearnerError(err)
+			return err
 		}
 		var lastErr error
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
@@ -62,6 +64,7 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 			if lastErr == nil {
 				return nil
 			}
+			c.checkLearnerError(lastErr)
 			c.GetLogger().Warn(
 				"retrying of unary invoker failed",
 				zap.String("target", cc.Target()),
@@ -149,7 +152,7 @@ func (c *Client) shouldRefreshToken(err error, callOpts *options) bool {
 	if rpctypes.Error(err) == rpctypes.ErrUserEmpty {
 		// refresh the token when username, password is present but the server returns ErrUserEmpty
 		// which is possible when the client token is cleared somehow
-		return c.authTokenBundle != nil // equal to c.Username != "" && c.Password != ""
+		return c.authTokenBundle != nil // equal to c.Username != "" && c.Password != "" or c.authAssertion != nil
 	}
 
 	return callOpts.retryAuth &&
@@ -159,7 +162,7 @@ func (c *Client) shouldRefreshToken(err error, callOpts *options) bool {
 func (c *Client) refreshToken(ctx context.Context) error {
 	if c.authTokenBundle == nil {
 		// c.authTokenBundle will be initialized only when
-		// c.Username != "" && c.Password != "".
+		// c.Username != "" && c.Password != "" or c.authAssertion != nil.
 		//
 		// When users use the TLS CommonName based authentication, the
 		// authTokenBundle is always nil. But it's possible for the clients
@@ -329,6 +332,7 @@ func isSafeRetry(c *Client, err error, callOpts *options) bool {
 	// But for backward-compatibility reasons we need  to support situation that
 	// customer provides mix of learners (not yet voters) and voters with an
 	// expectation to pick voter in the next attempt.
+	// Client with Config.MemberRolesRefreshInterval set is aware which endpoints represent learners and avoids them.
 	// TODO: Ideally client should be 'aware' which endpoint represents: leader/voter/learner with high probability.
 	if errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) && len(c.Endpoints()) > 1 {
 		return true
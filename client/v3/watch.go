@@ -93,6 +93,39 @@ type Watcher interface {
 	Close() error
 }
 
+// WatchBatcher is implemented by watchers able to create several watches at once.
+type WatchBatcher interface {
+	// WatchBatch creates a watch for each spec, like Watch, returning their
+	// channels in order of specs. Create requests of all watches are sent
+	// together on the watch stream of the context, instead of waiting for each
+	// watch to be created before requesting the next one. Watches are created
+	// independently, so watch failing to be created closes only its own channel.
+	WatchBatch(ctx context.Context, specs ...WatchSpec) []WatchChan
+}
+
+// WatchSpec describes a watch created by WatchBatch, with the same arguments as Watch.
+type WatchSpec struct {
+	Key  string
+	Opts []OpOption
+}
+
+// WatchBatch creates a watch for each spec with the watcher, returning their channels
+// in order of specs. Watches are created together if the watcher implements WatchBatcher,
+// and one by one otherwise.
+func WatchBatch(ctx context.Context, w Watcher, specs ...WatchSpec) []WatchChan {
+	if c, ok := w.(*Client); ok {
+		w = c.Watcher
+	}
+	if wb, ok := w.(WatchBatcher); ok {
+		return wb.WatchBatch(ctx, specs...)
+	}
+	var wchs []WatchChan
+	for _, spec := range specs {
+		wchs = append(wchs, w.Watch(ctx, spec.Key, spec.Opts...))
+	}
+	return wchs
+}
+
 type WatchResponse struct {
 	Header pb.ResponseHeader
 	Events []*Event
@@ -174,6 +207,11 @@ type watchGrpcStream struct {
 	substreams map[int64]*watcherStream
 	// resuming holds all resuming watchers on this grpc stream
 	resuming []*watcherStream
+	// inflight is the number of watchers at the head of resuming with create
+	// request sent, waiting for created response
+	inflight int
+	// batches counts watch batches, to tell watchers created together
+	batches uint64
 
 	// reqc sends a watch request from Watch() to the main goroutine
 	reqc chan watchStreamRequest
@@ -198,7 +236,7 @@ type watchGrpcStream struct {
 
 // watchStreamRequest is a union of the supported watch request operation types
 type watchStreamRequest interface {
-	toPB() *pb.WatchRequest
+	isWatchStreamRequest()
 }
 
 // watchRequest is issued by the subscriber to start a new watcher
@@ -225,6 +263,11 @@ type watchRequest struct {
 	retc chan chan WatchResponse
 }
 
+// watchBatchRequest is issued by the subscriber to start several watchers at once
+type watchBatchRequest struct {
+	reqs []*watchRequest
+}
+
 // progressRequest is issued by the subscriber to request watch progress
 type progressRequest struct {
 }
@@ -244,6 +287,12 @@ type watcherStream struct {
 	closing bool
 	// id is the registered watch id on the grpc stream
 	id int64
+	// batch is the id of batch the watcher was created with, zero if it was
+	// created alone. Create requests of watchers of the same batch are sent
+	// together.
+	batch uint64
+	// closeErr is the error that failed creation of the watcher
+	closeErr error
 
 	// buf holds all events received from etcd but not yet consumed by the client
 	buf []*WatchResponse
@@ -265,6 +314,23 @@ func NewWatchFromWatchClient(wc pb.WatchClient, c *Client) Watcher {
 	return w
 }
 
+// WatchWithInitial gets the current value of the key and starts watching it from the revision right after
+// the one the value was read at, so no event is missed or duplicated between the initial value and the watch.
+// Options apply to both the get and the watch, e.g. WithPrefix returns all initial keys in the range.
+// If revision following the initial read is compacted before the watch starts, the watch is canceled
+// with ErrCompacted, and the caller should start over.
+func WatchWithInitial(ctx context.Context, c *Client, key string, opts ...OpOption) (*GetResponse, WatchChan, error) {
+	resp, err := c.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	rev := resp.Header.Revision
+	if op := OpGet(key, opts...); op.Rev() != 0 {
+		rev = op.Rev()
+	}
+	return resp, c.Watch(ctx, key, append(opts, WithRev(rev+1))...), nil
+}
+
 // never closes
 var valCtxCh = make(chan struct{})
 var zeroTime = time.Unix(0, 0)
@@ -300,6 +366,23 @@ func (w *watcher) newWatcherGrpcStream(inctx context.Context) *watchGrpcStream {
 
 // Watch posts a watch request to run() and waits for a new watcher channel
 func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	wr := newWatchRequest(ctx, key, opts...)
+	return w.submit(ctx, wr, []*watchRequest{wr})[0]
+}
+
+// WatchBatch posts a batch of watch requests to run() and waits for their watcher channels
+func (w *watcher) WatchBatch(ctx context.Context, specs ...WatchSpec) []WatchChan {
+	if len(specs) == 0 {
+		return nil
+	}
+	reqs := make([]*watchRequest, len(specs))
+	for i, spec := range specs {
+		reqs[i] = newWatchRequest(ctx, spec.Key, spec.Opts...)
+	}
+	return w.submit(ctx, &watchBatchRequest{reqs: reqs}, reqs)
+}
+
+func newWatchRequest(ctx context.Context, key string, opts ...OpOption) *watchRequest {
 	ow := opWatch(key, opts...)
 
 	var filters []pb.WatchCreateRequest_FilterType
@@ -310,7 +393,7 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		filters = append(filters, pb.WatchCreateRequest_NODELETE)
 	}
 
-	wr := &watchRequest{
+	return &watchRequest{
 		ctx:            ctx,
 		createdNotify:  ow.createdNotify,
 		key:            string(ow.key),
@@ -322,10 +405,15 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		prevKV:         ow.prevKV,
 		retc:           make(chan chan WatchResponse, 1),
 	}
+}
 
+// submit posts request creating watchers of reqs to run() and waits for their channels. Channels of watchers
+// that couldn't be created are closed.
+func (w *watcher) submit(ctx context.Context, req watchStreamRequest, reqs []*watchRequest) []WatchChan {
 	ok := false
 	ctxKey := streamKeyFromCtx(ctx)
 
+	chans := make([]WatchChan, 0, len(reqs))
 	var closeCh chan WatchResponse
 	for {
 		// find or allocate appropriate grpc watch stream
@@ -335,7 +423,10 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 			w.mu.Unlock()
 			ch := make(chan WatchResponse)
 			close(ch)
-			return ch
+			for len(chans) < len(reqs) {
+				chans = append(chans, ch)
+			}
+			return chans
 		}
 		wgs := w.streams[ctxKey]
 		if wgs == nil {
@@ -353,9 +444,9 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 
 		// submit request
 		select {
-		case reqc <- wr:
+		case reqc <- req:
 			ok = true
-		case <-wr.ctx.Done():
+		case <-ctx.Done():
 			ok = false
 		case <-donec:
 			ok = false
@@ -367,26 +458,34 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 			continue
 		}
 
-		// receive channel
-		if ok {
+		// receive channels
+		for ok && len(chans) < len(reqs) {
 			select {
-			case ret := <-wr.retc:
-				return ret
+			case ret := <-reqs[len(chans)].retc:
+				chans = append(chans, ret)
+				continue
 			case <-ctx.Done():
 			case <-donec:
 				if wgs.closeErr != nil {
 					closeCh <- WatchResponse{Canceled: true, closeErr: wgs.closeErr}
-					break
 				}
-				// retry; may have dropped stream from no ctxs
-				continue
 			}
+			ok = false
+		}
+		if len(chans) == len(reqs) {
+			return chans
+		}
+		if len(chans) != 0 || wgs.closeErr != nil || ctx.Err() != nil {
+			break
 		}
-		break
+		// retry; may have dropped stream from no ctxs
 	}
 
 	close(closeCh)
-	return closeCh
+	for len(chans) < len(reqs) {
+		chans = append(chans, closeCh)
+	}
+	return chans
 }
 
 func (w *watcher) Close() (err error) {
@@ -463,7 +562,7 @@ func (w *watcher) closeStream(wgs *watchGrpcStream) {
 func (w *watchGrpcStream) addSubstream(resp *pb.WatchResponse, ws *watcherStream) {
 	// check watch ID for backward compatibility (<= v3.3)
 	if resp.WatchId == InvalidWatchID || (resp.Canceled && resp.CancelReason != "") {
-		w.closeErr = v3rpc.Error(errors.New(resp.CancelReason))
+		ws.closeErr = v3rpc.Error(errors.New(resp.CancelReason))
 		// failed; no channel
 		close(ws.recvc)
 		return
@@ -487,9 +586,13 @@ func (w *watchGrpcStream) closeSubstream(ws *watcherStream) {
 	case ws.initReq.retc <- ws.outc:
 	default:
 	}
-	// close subscriber's channel
-	if closeErr := w.closeErr; closeErr != nil && ws.initReq.ctx.Err() == nil {
-		go w.sendCloseSubstream(ws, &WatchResponse{Canceled: true, closeErr: w.closeErr})
+	// close subscriber's channel, with error that failed its creation if any
+	closeErr := ws.closeErr
+	if closeErr == nil {
+		closeErr = w.closeErr
+	}
+	if closeErr != nil && ws.initReq.ctx.Err() == nil {
+		go w.sendCloseSubstream(ws, &WatchResponse{Canceled: true, closeErr: closeErr})
 	} else if ws.outc != nil {
 		close(ws.outc)
 	}
@@ -552,28 +655,14 @@ func (w *watchGrpcStream) run() {
 		case req := <-w.reqc:
 			switch wreq := req.(type) {
 			case *watchRequest:
-				outc := make(chan WatchResponse, 1)
-				// TODO: pass custom watch ID?
-				ws := &watcherStream{
-					initReq: *wreq,
-					id:      InvalidWatchID,
-					outc:    outc,
-					// unbuffered so resumes won't cause repeat events
-					recvc: make(chan *WatchResponse),
-				}
-
-				ws.donec = make(chan struct{})
-				w.wg.Add(1)
-				go w.serveSubstream(ws, w.resumec)
-
-				// queue up for watcher creation/resume
-				w.resuming = append(w.resuming, ws)
-				if len(w.resuming) == 1 {
-					// head of resume queue, can register a new watcher
-					if err := wc.Send(ws.initReq.toPB()); err != nil {
-						w.lg.Debug("error when sending request", zap.Error(err))
-					}
+				w.queueWatcher(wreq, 0)
+				w.sendResuming(wc)
+			case *watchBatchRequest:
+				w.batches++
+				for _, r := range wreq.reqs {
+					w.queueWatcher(r, w.batches)
 				}
+				w.sendResuming(wc)
 			case *progressRequest:
 				if err := wc.Send(wreq.toPB()); err != nil {
 					w.lg.Debug("error when sending request", zap.Error(err))
@@ -600,33 +689,26 @@ func (w *watchGrpcStream) run() {
 						return
 					}
 
-					if len(w.resuming) != 0 {
-						if ws := w.resuming[0]; ws != nil {
-							if err := wc.Send(ws.initReq.toPB()); err != nil {
-								w.lg.Debug("error when sending request", zap.Error(err))
-							}
-						}
-					}
+					w.sendResuming(wc)
 
 					cur = nil
 					continue
 				}
 
-				// response to head of queue creation
+				// response to head of queue creation, creations are responded in order they were sent
 				if len(w.resuming) != 0 {
 					if ws := w.resuming[0]; ws != nil {
 						w.addSubstream(pbresp, ws)
 						w.dispatchEvent(pbresp)
-						w.resuming[0] = nil
 					}
-				}
-
-				if ws := w.nextResume(); ws != nil {
-					if err := wc.Send(ws.initReq.toPB()); err != nil {
-						w.lg.Debug("error when sending request", zap.Error(err))
+					w.resuming = w.resuming[1:]
+					if w.inflight > 0 {
+						w.inflight--
 					}
 				}
 
+				w.sendResuming(wc)
+
 				// reset for next iteration
 				cur = nil
 
@@ -685,11 +767,7 @@ func (w *watchGrpcStream) run() {
 			if wc, closeErr = w.newWatchClient(); closeErr != nil {
 				return
 			}
-			if ws := w.nextResume(); ws != nil {
-				if err := wc.Send(ws.initReq.toPB()); err != nil {
-					w.lg.Debug("error when sending request", zap.Error(err))
-				}
-			}
+			w.sendResuming(wc)
 			cancelSet = make(map[int64]struct{})
 
 		case <-w.ctx.Done():
@@ -729,16 +807,51 @@ func shouldRetryWatch(cancelReason string) bool {
 		(cancelReason == errMsgGRPCAuthOldRevision)
 }
 
-// nextResume chooses the next resuming to register with the grpc stream. Abandoned
-// streams are marked as nil in the queue since the head must wait for its inflight registration.
-func (w *watchGrpcStream) nextResume() *watcherStream {
-	for len(w.resuming) != 0 {
-		if w.resuming[0] != nil {
-			return w.resuming[0]
+// queueWatcher starts serving a new watcher of the request and queues it up for creation.
+func (w *watchGrpcStream) queueWatcher(wreq *watchRequest, batch uint64) {
+	outc := make(chan WatchResponse, 1)
+	// TODO: pass custom watch ID?
+	ws := &watcherStream{
+		initReq: *wreq,
+		id:      InvalidWatchID,
+		outc:    outc,
+		// unbuffered so resumes won't cause repeat events
+		recvc: make(chan *WatchResponse),
+		batch: batch,
+	}
+
+	ws.donec = make(chan struct{})
+	w.wg.Add(1)
+	go w.serveSubstream(ws, w.resumec)
+
+	// queue up for watcher creation/resume
+	w.resuming = append(w.resuming, ws)
+}
+
+// sendResuming registers the next resuming with the grpc stream, unless registrations are already inflight.
+// Resuming watchers of the same batch are registered together, other watchers one by one. Abandoned streams
+// are marked as nil in the queue since the head must wait for its inflight registration, so they are dropped
+// only once no registration is inflight.
+func (w *watchGrpcStream) sendResuming(wc pb.Watch_WatchClient) {
+	if w.inflight != 0 {
+		return
+	}
+	resuming := w.resuming[:0]
+	for _, ws := range w.resuming {
+		if ws != nil {
+			resuming = append(resuming, ws)
 		}
-		w.resuming = w.resuming[1:len(w.resuming)]
 	}
-	return nil
+	w.resuming = resuming
+	for i, ws := range w.resuming {
+		if i != 0 && (ws.batch == 0 || ws.batch != w.resuming[0].batch) {
+			break
+		}
+		if err := wc.Send(ws.initReq.toPB()); err != nil {
+			w.lg.Debug("error when sending request", zap.Error(err))
+		}
+		w.inflight++
+	}
 }
 
 // dispatchEvent sends a WatchResponse to the appropriate watcher stream
@@ -926,6 +1039,7 @@ func (w *watchGrpcStream) newWatchClient() (pb.Watch_WatchClient, error) {
 		}
 	}
 	w.resuming = resuming
+	w.inflight = 0
 	w.substreams = make(map[int64]*watcherStream)
 
 	// connect to grpc stream while accepting watcher cancelation
@@ -1045,6 +1159,10 @@ func (w *watchGrpcStream) openWatchClient() (ws pb.Watch_WatchClient, err error)
 	return ws, nil
 }
 
+func (wr *watchRequest) isWatchStreamRequest()      {}
+func (wr *watchBatchRequest) isWatchStreamRequest() {}
+func (pr *progressRequest) isWatchStreamRequest()   {}
+
 // toPB converts an internal watch request structure to its protobuf WatchRequest structure.
 func (wr *watchRequest) toPB() *pb.WatchRequest {
 	req := &pb.WatchCreateRequest{
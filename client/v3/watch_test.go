@@ -15,6 +15,7 @@
 package clientv3
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -92,3 +93,20 @@ func TestShouldRetryWatch(t *testing.T) {
 		})
 	}
 }
+
+type keyWatcher struct {
+	Watcher
+	keys []string
+}
+
+func (w *keyWatcher) Watch(ctx context.Context, key string, opts ...OpOption) WatchChan {
+	w.keys = append(w.keys, key)
+	return make(chan WatchResponse)
+}
+
+func TestWatchBatchWithoutWatchBatcher(t *testing.T) {
+	w := &keyWatcher{}
+	wchs := WatchBatch(context.Background(), w, WatchSpec{Key: "a"}, WatchSpec{Key: "b"})
+	assert.Len(t, wchs, 2)
+	assert.Equal(t, []string{"a", "b"}, w.keys)
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+)
+
+// A role with admin prefix makes users it is granted to admins scoped to keys with the prefix.
+// Scoped admins can grant and revoke permissions within the prefix to any role except root,
+// and grant and revoke roles that permit nothing outside of the prefix. All other auth
+// management, including setting admin prefixes, remains restricted to root.
+//
+// Prefixes are compared as raw bytes, so prefix "/a" also covers keys like "/ab".
+// Prefixes meant to scope a directory-like namespace should end with a separator.
+
+// adminPrefixes returns admin prefixes of roles of the user.
+func adminPrefixes(tx AuthReadTx, u *authpb.User) [][]byte {
+	var prefixes [][]byte
+	for _, roleName := range u.Roles {
+		role := tx.UnsafeGetRole(roleName)
+		if role == nil || len(role.AdminPrefix) == 0 {
+			continue
+		}
+		prefixes = append(prefixes, role.AdminPrefix)
+	}
+	return prefixes
+}
+
+// isRoleWithinAdminPrefixes returns whether everything the role permits, including its own
+// admin scope, is within one of the prefixes.
+func isRoleWithinAdminPrefixes(prefixes [][]byte, role *authpb.Role) bool {
+	if string(role.Name) == rootRole {
+		return false
+	}
+	for _, perm := range role.KeyPermission {
+		key, rangeEnd := perm.Key, perm.RangeEnd
+		if perm.UserTemplate {
+			key, rangeEnd = userTemplateBounds(perm.Key)
+		}
+		if !isRangeWithinAdminPrefixes(prefixes, key, rangeEnd) {
+			return false
+		}
+	}
+	return len(role.AdminPrefix) == 0 || isRangeWithinAdminPrefixes(prefixes, role.AdminPrefix, prefixRangeEnd(role.AdminPrefix))
+}
+
+func isRangeWithinAdminPrefixes(prefixes [][]byte, key, rangeEnd []byte) bool {
+	for _, prefix := range prefixes {
+		if isRangeWithinPrefix(prefix, key, rangeEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRangeWithinPrefix returns whether all keys of the permission range have the prefix.
+// Empty rangeEnd means a single key and rangeEnd []byte{0x00} means all keys >= key.
+func isRangeWithinPrefix(prefix, key, rangeEnd []byte) bool {
+	if len(prefix) == 0 || !bytes.HasPrefix(key, prefix) {
+		return false
+	}
+	if len(rangeEnd) == 0 {
+		return true
+	}
+	end := prefixRangeEnd(prefix)
+	if isOpenEnded(end) {
+		// all keys >= prefix have the prefix
+		return true
+	}
+	return !isOpenEnded(rangeEnd) && bytes.Compare(rangeEnd, end) <= 0
+}
+
+// prefixRangeEnd returns the end of range of keys with the prefix, []byte{0x00} if the
+// prefix consists only of 0xff bytes, as then all keys >= prefix have it.
+func prefixRangeEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestIsRangeWithinPrefix(t *testing.T) {
+	tcs := []struct {
+		name     string
+		prefix   string
+		key      string
+		rangeEnd string
+		expect   bool
+	}{
+		{name: "single key with prefix", prefix: "/a/", key: "/a/x", expect: true},
+		{name: "single key without prefix", prefix: "/a/", key: "/b/x", expect: false},
+		{name: "whole prefix", prefix: "/a/", key: "/a/", rangeEnd: "/a0", expect: true},
+		{name: "range within prefix", prefix: "/a/", key: "/a/x", rangeEnd: "/a/y", expect: true},
+		{name: "range ending after prefix", prefix: "/a/", key: "/a/x", rangeEnd: "/b", expect: false},
+		{name: "range starting before prefix", prefix: "/a/", key: "/a", rangeEnd: "/a/y", expect: false},
+		{name: "open ended range", prefix: "/a/", key: "/a/x", rangeEnd: "\x00", expect: false},
+		{name: "all keys", prefix: "\x00", key: "\x00", rangeEnd: "\x00", expect: false},
+		{name: "open ended range within prefix without end", prefix: "\xff", key: "\xff/x", rangeEnd: "\x00", expect: true},
+		{name: "empty prefix", prefix: "", key: "/a/x", expect: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, isRangeWithinPrefix([]byte(tc.prefix), []byte(tc.key), []byte(tc.rangeEnd)))
+		})
+	}
+}
+
+func TestIsRoleWithinAdminPrefixes(t *testing.T) {
+	prefixes := [][]byte{[]byte("/a/")}
+	tcs := []struct {
+		name   string
+		role   *authpb.Role
+		expect bool
+	}{
+		{
+			name:   "role without permissions",
+			role:   &authpb.Role{Name: []byte("r")},
+			expect: true,
+		},
+		{
+			name:   "permissions within prefix",
+			role:   &authpb.Role{Name: []byte("r"), KeyPermission: []*authpb.Permission{{Key: []byte("/a/x")}, {Key: []byte("/a/y"), RangeEnd: []byte("/a/z")}}},
+			expect: true,
+		},
+		{
+			name:   "permission outside of prefix",
+			role:   &authpb.Role{Name: []byte("r"), KeyPermission: []*authpb.Permission{{Key: []byte("/a/x")}, {Key: []byte("/b/x")}}},
+			expect: false,
+		},
+		{
+			name:   "user template within prefix",
+			role:   &authpb.Role{Name: []byte("r"), KeyPermission: []*authpb.Permission{{Key: []byte("/a/{user}/"), RangeEnd: []byte("/a/{user}0"), UserTemplate: true}}},
+			expect: true,
+		},
+		{
+			name:   "user template expanding outside of prefix",
+			role:   &authpb.Role{Name: []byte("r"), KeyPermission: []*authpb.Permission{{Key: []byte("/a{user}/"), RangeEnd: []byte("/a{user}0"), UserTemplate: true}}},
+			expect: false,
+		},
+		{
+			name:   "admin of nested prefix",
+			role:   &authpb.Role{Name: []byte("r"), AdminPrefix: []byte("/a/b/")},
+			expect: true,
+		},
+		{
+			name:   "admin of wider prefix",
+			role:   &authpb.Role{Name: []byte("r"), AdminPrefix: []byte("/")},
+			expect: false,
+		},
+		{
+			name:   "root role",
+			role:   &authpb.Role{Name: []byte(rootRole)},
+			expect: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, isRoleWithinAdminPrefixes(prefixes, tc.role))
+		})
+	}
+}
+
+func TestScopedAdminPermitted(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	for _, role := range []string{"admin-a", "tenant-a", "tenant-b"} {
+		_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: role})
+		require.NoError(t, err)
+	}
+	_, err := as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: "admin-a", Prefix: []byte("/a/")})
+	require.NoError(t, err)
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "tenant-a", Perm: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/a/"), RangeEnd: []byte("/a0")}})
+	require.NoError(t, err)
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "tenant-b", Perm: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/b/"), RangeEnd: []byte("/b0")}})
+	require.NoError(t, err)
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "admin-a"})
+	require.NoError(t, err)
+
+	resp, err := as.RoleGet(&pb.AuthRoleGetRequest{Role: "admin-a"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("/a/"), resp.AdminPrefix)
+
+	foo := &AuthInfo{Username: "foo", Revision: as.Revision()}
+	assert.Equal(t, ErrPermissionDenied, as.IsAdminPermitted(foo))
+	assert.NoError(t, as.IsPermissionAdminPermitted(foo, "tenant-b", []byte("/a/x"), nil))
+	assert.NoError(t, as.IsPermissionAdminPermitted(foo, "tenant-a", []byte("/a/"), []byte("/a0")))
+	assert.Equal(t, ErrPermissionDenied, as.IsPermissionAdminPermitted(foo, "tenant-a", []byte("/b/x"), nil))
+	assert.Equal(t, ErrPermissionDenied, as.IsPermissionAdminPermitted(foo, "tenant-a", []byte("/a/"), []byte("\x00")))
+	assert.Equal(t, ErrPermissionDenied, as.IsPermissionAdminPermitted(foo, rootRole, []byte("/a/x"), nil))
+	assert.NoError(t, as.IsRoleAdminPermitted(foo, "tenant-a"))
+	assert.NoError(t, as.IsRoleAdminPermitted(foo, "admin-a"))
+	assert.Equal(t, ErrPermissionDenied, as.IsRoleAdminPermitted(foo, "tenant-b"))
+	assert.Equal(t, ErrPermissionDenied, as.IsRoleAdminPermitted(foo, rootRole))
+	assert.Equal(t, ErrPermissionDenied, as.IsRoleAdminPermitted(foo, "no-such-role"))
+
+	// root is permitted everything, users without admin prefix nothing
+	root := &AuthInfo{Username: "root", Revision: as.Revision()}
+	assert.NoError(t, as.IsPermissionAdminPermitted(root, rootRole, []byte("/b/x"), nil))
+	assert.NoError(t, as.IsRoleAdminPermitted(root, "tenant-b"))
+	noOptions := &AuthInfo{Username: "foo-no-user-options", Revision: as.Revision()}
+	assert.Equal(t, ErrPermissionDenied, as.IsPermissionAdminPermitted(noOptions, "tenant-a", []byte("/a/x"), nil))
+	assert.Equal(t, ErrPermissionDenied, as.IsRoleAdminPermitted(noOptions, "tenant-a"))
+
+	// removing admin prefix ends admin scope
+	_, err = as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: "admin-a"})
+	require.NoError(t, err)
+	assert.Equal(t, ErrPermissionDenied, as.IsPermissionAdminPermitted(foo, "tenant-a", []byte("/a/x"), nil))
+}
+
+func TestRoleSetAdminPrefix(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: "no-such-role", Prefix: []byte("/a/")})
+	assert.Equal(t, ErrRoleNotFound, err)
+	_, err = as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: rootRole, Prefix: []byte("/a/")})
+	assert.Equal(t, ErrInvalidAuthMgmt, err)
+
+	revision := as.Revision()
+	_, err = as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: "role-test", Prefix: []byte("/a/")})
+	require.NoError(t, err)
+	assert.Greater(t, as.Revision(), revision)
+}
+
+func TestRoleRevokePermissionKeepsAdminPrefix(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.RoleSetAdminPrefix(&pb.AuthRoleSetAdminPrefixRequest{Role: "role-test", Prefix: []byte("/a/")})
+	require.NoError(t, err)
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.READ, Key: []byte("/b/x")},
+	})
+	require.NoError(t, err)
+
+	_, err = as.RoleRevokePermission(&pb.AuthRoleRevokePermissionRequest{Role: "role-test", Key: []byte("/b/x")})
+	require.NoError(t, err)
+	r, err := as.RoleGet(&pb.AuthRoleGetRequest{Role: "role-test"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("/a/"), r.AdminPrefix)
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// DefaultAssertionUserClaim is the claim of assertion mapped to etcd user when no other claim is configured.
+const DefaultAssertionUserClaim = "sub"
+
+// AssertionVerifier verifies assertions, JWTs signed by an identity provider trusted by the server, and maps
+// them to etcd users. This allows machine identities to authenticate without passwords stored in etcd.
+type AssertionVerifier struct {
+	lg *zap.Logger
+	// issuer is required value of "iss" claim of assertions.
+	issuer string
+	// audience is required value of "aud" claim of assertions, so assertions the issuer issued for
+	// other services cannot be used to authenticate to etcd.
+	audience string
+	// userClaim is the claim whose value is name of the authenticated etcd user.
+	userClaim string
+	// keys are public keys of the issuer, assertion has to be signed by any of them.
+	keys []interface{}
+	now  func() time.Time
+}
+
+// NewAssertionVerifier creates verifier of assertions issued by issuer for audience and signed by any of RSA, ECDSA
+// or Ed25519 public keys read from PEM files at keyFiles.
+func NewAssertionVerifier(lg *zap.Logger, issuer, audience string, keyFiles []string, userClaim string) (*AssertionVerifier, error) {
+	if lg == nil {
+		lg = zap.NewNop()
+	}
+	if issuer == "" {
+		return nil, errors.New("auth: assertion issuer is empty")
+	}
+	if audience == "" {
+		return nil, errors.New("auth: assertion audience is empty")
+	}
+	if len(keyFiles) == 0 {
+		return nil, ErrMissingKey
+	}
+	if userClaim == "" {
+		userClaim = DefaultAssertionUserClaim
+	}
+	v := &AssertionVerifier{lg: lg, issuer: issuer, audience: audience, userClaim: userClaim, now: time.Now}
+	for _, file := range keyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("auth: failed to parse assertion key %q: %w", file, err)
+		}
+		v.keys = append(v.keys, key)
+	}
+	return v, nil
+}
+
+func parsePublicKey(data []byte) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	key, err := jwt.ParseEdPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, errors.New("key is not a PEM encoded RSA, ECDSA or Ed25519 public key")
+	}
+	return key, nil
+}
+
+// Verify checks signature, issuer, audience and validity period of the assertion, and returns name of the user it was issued for.
+// Assertion is required to expire, so a leaked one cannot be used indefinitely.
+func (v *AssertionVerifier) Verify(assertion string) (string, error) {
+	claims, err := v.parse(assertion)
+	if err != nil {
+		v.lg.Warn("failed to verify assertion signature", zap.Error(err))
+		return "", ErrInvalidAssertion
+	}
+	now := v.now().Unix()
+	if !claims.VerifyIssuer(v.issuer, true) {
+		v.lg.Warn("assertion was issued by untrusted issuer", zap.Any("issuer", claims["iss"]))
+		return "", ErrInvalidAssertion
+	}
+	if !claims.VerifyAudience(v.audience, true) {
+		v.lg.Warn("assertion was issued for other audience", zap.Any("audience", claims["aud"]))
+		return "", ErrInvalidAssertion
+	}
+	if !claims.VerifyExpiresAt(now, true) {
+		v.lg.Warn("assertion is expired or has no expiration time", zap.Any("expiration-time", claims["exp"]))
+		return "", ErrInvalidAssertion
+	}
+	if !claims.VerifyNotBefore(now, false) || !claims.VerifyIssuedAt(now, false) {
+		v.lg.Warn("assertion is not valid yet", zap.Any("not-before", claims["nbf"]), zap.Any("issued-at", claims["iat"]))
+		return "", ErrInvalidAssertion
+	}
+	username, ok := claims[v.userClaim].(string)
+	if !ok || username == "" {
+		v.lg.Warn("assertion has no user claim", zap.String("claim", v.userClaim))
+		return "", ErrInvalidAssertion
+	}
+	return username, nil
+}
+
+// parse returns claims of the assertion signed by any of the keys. Claims are validated by the caller,
+// so they are checked against time of the verifier.
+func (v *AssertionVerifier) parse(assertion string) (jwt.MapClaims, error) {
+	var err error
+	for _, key := range v.keys {
+		key := key
+		var token *jwt.Token
+		token, err = jwt.Parse(assertion, func(token *jwt.Token) (interface{}, error) {
+			if !signingMethodMatchesKey(token.Method, key) {
+				return nil, errors.New("signing method doesn't match key")
+			}
+			return key, nil
+		}, jwt.WithoutClaimsValidation())
+		if err != nil {
+			continue
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return nil, errors.New("failed to obtain claims")
+		}
+		return claims, nil
+	}
+	return nil, err
+}
+
+// signingMethodMatchesKey prevents verifying assertion with a method other than the one of the key type,
+// for example HMAC keyed with public key.
+func signingMethodMatchesKey(method jwt.SigningMethod, key interface{}) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
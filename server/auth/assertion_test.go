@@ -0,0 +1,276 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+const (
+	testAssertionIssuer   = "https://issuer.example.com"
+	testAssertionAudience = "etcd.example.com"
+)
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return path
+}
+
+func signAssertion(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	assertion, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return assertion
+}
+
+func TestAssertionVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keyFiles := []string{writePublicKey(t, &rsaKey.PublicKey), writePublicKey(t, &ecKey.PublicKey), writePublicKey(t, edPublicKey)}
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{"iss": testAssertionIssuer, "aud": testAssertionAudience, "sub": "foo", "exp": now.Add(time.Minute).Unix()}
+	}
+
+	tcs := []struct {
+		name       string
+		userClaim  string
+		assertion  func(claims jwt.MapClaims) string
+		claims     func(claims jwt.MapClaims)
+		expectUser string
+		expectErr  error
+	}{
+		{
+			name:       "RSA signed",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			expectUser: "foo",
+		},
+		{
+			name:       "RSA-PSS signed",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodPS256, rsaKey, c) },
+			expectUser: "foo",
+		},
+		{
+			name:       "ECDSA signed",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodES256, ecKey, c) },
+			expectUser: "foo",
+		},
+		{
+			name:       "Ed25519 signed",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodEdDSA, edKey, c) },
+			expectUser: "foo",
+		},
+		{
+			name:       "Custom user claim",
+			userClaim:  "email",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:     func(c jwt.MapClaims) { c["email"] = "foo@example.com" },
+			expectUser: "foo@example.com",
+		},
+		{
+			name:      "Signed by untrusted key",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, otherKey, c) },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name: "HMAC keyed with public key",
+			assertion: func(c jwt.MapClaims) string {
+				data, err := os.ReadFile(keyFiles[0])
+				require.NoError(t, err)
+				return signAssertion(t, jwt.SigningMethodHS256, data, c)
+			},
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name: "Unsigned",
+			assertion: func(c jwt.MapClaims) string {
+				return signAssertion(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, c)
+			},
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Malformed",
+			assertion: func(c jwt.MapClaims) string { return "not-a-jwt" },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Expired",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Without expiration",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { delete(c, "exp") },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Not valid yet",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Minute).Unix() },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Other issuer",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Without issuer",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { delete(c, "iss") },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:       "Audience in list",
+			assertion:  func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:     func(c jwt.MapClaims) { c["aud"] = []string{"other", testAssertionAudience} },
+			expectUser: "foo",
+		},
+		{
+			name:      "Other audience",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { c["aud"] = "other" },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Without audience",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			claims:    func(c jwt.MapClaims) { delete(c, "aud") },
+			expectErr: ErrInvalidAssertion,
+		},
+		{
+			name:      "Without user claim",
+			userClaim: "email",
+			assertion: func(c jwt.MapClaims) string { return signAssertion(t, jwt.SigningMethodRS256, rsaKey, c) },
+			expectErr: ErrInvalidAssertion,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, testAssertionAudience, keyFiles, tc.userClaim)
+			require.NoError(t, err)
+			v.now = func() time.Time { return now }
+			claims := validClaims()
+			if tc.claims != nil {
+				tc.claims(claims)
+			}
+			user, err := v.Verify(tc.assertion(claims))
+			assert.Equal(t, tc.expectErr, err)
+			assert.Equal(t, tc.expectUser, user)
+		})
+	}
+}
+
+func TestNewAssertionVerifierInvalidConfig(t *testing.T) {
+	notKey := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(notKey, []byte("not a key"), 0600))
+
+	_, err := NewAssertionVerifier(zaptest.NewLogger(t), "", testAssertionAudience, []string{notKey}, "")
+	assert.Error(t, err)
+	_, err = NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, "", []string{notKey}, "")
+	assert.Error(t, err)
+	_, err = NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, testAssertionAudience, nil, "")
+	assert.Equal(t, ErrMissingKey, err)
+	_, err = NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, testAssertionAudience, []string{notKey}, "")
+	assert.Error(t, err)
+	_, err = NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, testAssertionAudience, []string{filepath.Join(t.TempDir(), "missing.pem")}, "")
+	assert.Error(t, err)
+}
+
+func TestCheckAssertion(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	assertionFor := func(user string) string {
+		return signAssertion(t, jwt.SigningMethodES256, key, jwt.MapClaims{"iss": testAssertionIssuer, "aud": testAssertionAudience, "sub": user, "exp": time.Now().Add(time.Minute).Unix()})
+	}
+
+	_, _, err = as.CheckAssertion("", assertionFor("foo"))
+	assert.Equal(t, ErrAssertionNotConfigured, err)
+
+	v, err := NewAssertionVerifier(zaptest.NewLogger(t), testAssertionIssuer, testAssertionAudience, []string{writePublicKey(t, &key.PublicKey)}, "")
+	require.NoError(t, err)
+	as.SetAssertionVerifier(v)
+
+	user, revision, err := as.CheckAssertion("", assertionFor("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", user)
+	assert.Equal(t, as.Revision(), revision)
+
+	user, _, err = as.CheckAssertion("foo", assertionFor("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", user)
+
+	// failures of verified assertions are attributed to the user they were issued for
+	user, _, err = as.CheckAssertion("root", assertionFor("foo"))
+	assert.Equal(t, ErrInvalidAssertion, err)
+	assert.Equal(t, "foo", user)
+
+	user, _, err = as.CheckAssertion("", assertionFor("foo-test"))
+	assert.Equal(t, ErrAuthFailed, err)
+	assert.Equal(t, "foo-test", user)
+
+	user, _, err = as.CheckAssertion("foo", "not-a-jwt")
+	assert.Equal(t, ErrInvalidAssertion, err)
+	assert.Empty(t, user)
+
+	// users without password can authenticate by assertion
+	_, err = as.UserAdd(&pb.AuthUserAddRequest{Name: "machine", Options: &authpb.UserAddOptions{NoPassword: true}})
+	require.NoError(t, err)
+	user, _, err = as.CheckAssertion("", assertionFor("machine"))
+	require.NoError(t, err)
+	assert.Equal(t, "machine", user)
+
+	ctx := context.WithValue(context.WithValue(context.TODO(), AuthenticateParamIndex{}, uint64(1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
+	_, err = as.Authenticate(ctx, "machine", "")
+	assert.Equal(t, ErrAuthFailed, err)
+	resp, err := as.Authenticate(context.WithValue(ctx, AuthenticateParamAssertion{}, true), "machine", "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTrackedUsers bounds number of users with failed attempts kept in memory.
+// When reached, entries that have fully decayed are swept, and if none did, the
+// user with the oldest failure is evicted.
+const maxTrackedUsers = 4096
+
+// AttemptLimiter rate limits failed authentication attempts per user name.
+// After each consecutive failure the user must wait exponentially longer before
+// the next attempt is checked, starting at backoff and capped at maxBackoff.
+// Recorded failures decay by one for every maxBackoff elapsed since the last
+// failure, so users mistyping their password are never locked out permanently.
+//
+// A nil *AttemptLimiter allows all attempts.
+type AttemptLimiter struct {
+	backoff    time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	users map[string]*failedAttempts
+}
+
+type failedAttempts struct {
+	count int
+	last  time.Time
+}
+
+// NewAttemptLimiter returns limiter with given backoff, or nil if backoff is not positive.
+func NewAttemptLimiter(backoff, maxBackoff time.Duration) *AttemptLimiter {
+	if backoff <= 0 {
+		return nil
+	}
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+	return &AttemptLimiter{
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		now:        time.Now,
+		users:      make(map[string]*failedAttempts),
+	}
+}
+
+// Allow returns ErrAuthRateLimited if user has to wait before attempting to authenticate again.
+func (l *AttemptLimiter) Allow(username string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	a, ok := l.users[username]
+	if !ok {
+		return nil
+	}
+	count := l.decayedCount(a, now)
+	if count == 0 {
+		delete(l.users, username)
+		return nil
+	}
+	if now.Before(a.last.Add(l.delay(count))) {
+		return ErrAuthRateLimited
+	}
+	return nil
+}
+
+// Failed records a failed authentication attempt of user.
+func (l *AttemptLimiter) Failed(username string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	a, ok := l.users[username]
+	if !ok {
+		if len(l.users) >= maxTrackedUsers {
+			l.sweep(now)
+		}
+		if len(l.users) >= maxTrackedUsers {
+			l.evictOldest()
+		}
+		a = &failedAttempts{}
+		l.users[username] = a
+	}
+	a.count = l.decayedCount(a, now) + 1
+	a.last = now
+}
+
+// Succeeded resets failed attempts of user.
+func (l *AttemptLimiter) Succeeded(username string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.users, username)
+}
+
+func (l *AttemptLimiter) decayedCount(a *failedAttempts, now time.Time) int {
+	count := a.count - int(now.Sub(a.last)/l.maxBackoff)
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+func (l *AttemptLimiter) delay(count int) time.Duration {
+	delay := l.backoff
+	for i := 1; i < count && delay < l.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > l.maxBackoff {
+		return l.maxBackoff
+	}
+	return delay
+}
+
+func (l *AttemptLimiter) sweep(now time.Time) {
+	for username, a := range l.users {
+		if l.decayedCount(a, now) == 0 {
+			delete(l.users, username)
+		}
+	}
+}
+
+func (l *AttemptLimiter) evictOldest() {
+	var oldest string
+	var oldestLast time.Time
+	for username, a := range l.users {
+		if oldestLast.IsZero() || a.last.Before(oldestLast) {
+			oldest, oldestLast = username, a.last
+		}
+	}
+	delete(l.users, oldest)
+}
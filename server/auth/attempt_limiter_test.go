@@ -0,0 +1,98 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewAttemptLimiter(time.Second, 4*time.Second)
+	l.now = func() time.Time { return now }
+
+	assert.NoError(t, l.Allow("foo"))
+	l.Failed("foo")
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("foo"))
+	assert.NoError(t, l.Allow("bar"), "other users should not be limited")
+
+	now = now.Add(time.Second)
+	assert.NoError(t, l.Allow("foo"))
+	l.Failed("foo")
+	now = now.Add(time.Second)
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("foo"), "backoff should double after consecutive failure")
+	now = now.Add(time.Second)
+	assert.NoError(t, l.Allow("foo"))
+
+	for i := 0; i < 5; i++ {
+		l.Failed("foo")
+	}
+	now = now.Add(3 * time.Second)
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("foo"))
+	now = now.Add(time.Second)
+	assert.NoError(t, l.Allow("foo"), "backoff should be capped at max backoff")
+
+	l.Succeeded("foo")
+	l.Failed("foo")
+	now = now.Add(time.Second)
+	assert.NoError(t, l.Allow("foo"), "success should reset backoff")
+}
+
+func TestAttemptLimiterDecay(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewAttemptLimiter(time.Second, 4*time.Second)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		l.Failed("foo")
+	}
+	now = now.Add(4 * time.Second)
+	l.Failed("foo")
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("foo"))
+	now = now.Add(2 * time.Second)
+	assert.NoError(t, l.Allow("foo"), "one failure should have decayed")
+
+	now = now.Add(16 * time.Second)
+	assert.NoError(t, l.Allow("foo"))
+	assert.Empty(t, l.users, "fully decayed user should be forgotten")
+}
+
+func TestAttemptLimiterMaxTrackedUsers(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewAttemptLimiter(time.Hour, time.Hour)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < maxTrackedUsers; i++ {
+		l.Failed(fmt.Sprintf("user%d", i))
+		now = now.Add(time.Millisecond)
+	}
+	l.Failed("foo")
+	assert.Len(t, l.users, maxTrackedUsers)
+	assert.NoError(t, l.Allow("user0"), "user with oldest failure should be evicted")
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("user1"))
+	assert.Equal(t, ErrAuthRateLimited, l.Allow("foo"))
+}
+
+func TestAttemptLimiterDisabled(t *testing.T) {
+	l := NewAttemptLimiter(0, time.Second)
+	l.Failed("foo")
+	assert.NoError(t, l.Allow("foo"))
+	l.Succeeded("foo")
+}
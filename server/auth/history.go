@@ -0,0 +1,115 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+)
+
+// authSnapshot is the state of users and roles of the auth store at an auth revision.
+// Only roles of users and permissions of roles are kept, so password hashes are not
+// retained in memory.
+type authSnapshot struct {
+	revision  uint64
+	userRoles map[string][]string             // user name -> roles granted to the user
+	rolePerms map[string][]*authpb.Permission // role name -> key permissions of the role
+}
+
+func newAuthSnapshot(revision uint64, users []*authpb.User, roles []*authpb.Role) authSnapshot {
+	s := authSnapshot{
+		revision:  revision,
+		userRoles: make(map[string][]string),
+		rolePerms: make(map[string][]*authpb.Permission),
+	}
+	// copy the state, as backend is free to reuse returned users and roles
+	for _, user := range users {
+		s.userRoles[string(user.Name)] = append([]string{}, user.Roles...)
+	}
+	for _, role := range roles {
+		perms := make([]*authpb.Permission, 0, len(role.KeyPermission))
+		for _, perm := range role.KeyPermission {
+			perms = append(perms, &authpb.Permission{PermType: perm.PermType, Key: perm.Key, RangeEnd: perm.RangeEnd, UserTemplate: perm.UserTemplate})
+		}
+		s.rolePerms[string(role.Name)] = perms
+	}
+	return s
+}
+
+// authHistory retains snapshots of users and roles at the most recent auth revisions,
+// so permissions of users can be queried as they were in the past. A snapshot is
+// recorded on every commit of auth revision, so the state at any retained revision
+// is the latest snapshot not newer than it. History is kept only in memory and
+// starts over when the member restarts or recovers from a snapshot.
+type authHistory struct {
+	mu        sync.RWMutex
+	retention int
+	snapshots []authSnapshot // sorted by revision
+}
+
+func newAuthHistory() *authHistory {
+	return &authHistory{}
+}
+
+// setRetention sets the number of retained auth revisions and starts the history
+// with the current state. Zero retention disables the history.
+func (h *authHistory) setRetention(retention int, revision uint64, be AuthBackend) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retention = retention
+	h.snapshots = nil
+	if retention > 0 {
+		h.snapshots = append(h.snapshots, newAuthSnapshot(revision, be.GetAllUsers(), be.GetAllRoles()))
+	}
+}
+
+// record appends state of the auth store at the revision, dropping snapshots over retention.
+func (h *authHistory) record(revision uint64, tx AuthBatchTx) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.retention <= 0 {
+		return
+	}
+	h.snapshots = append(h.snapshots, newAuthSnapshot(revision, tx.UnsafeGetAllUsers(), tx.UnsafeGetAllRoles()))
+	if len(h.snapshots) > h.retention {
+		h.snapshots = append(h.snapshots[:0:0], h.snapshots[len(h.snapshots)-h.retention:]...)
+	}
+}
+
+// reset starts the history over from the state at the revision, used when
+// the auth store is recovered from a different backend.
+func (h *authHistory) reset(revision uint64, users []*authpb.User, roles []*authpb.Role) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.retention <= 0 {
+		return
+	}
+	h.snapshots = []authSnapshot{newAuthSnapshot(revision, users, roles)}
+}
+
+// at returns the state of the auth store at the revision.
+func (h *authHistory) at(revision uint64) (authSnapshot, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	i := sort.Search(len(h.snapshots), func(i int) bool {
+		return h.snapshots[i].revision > revision
+	})
+	if i == 0 {
+		return authSnapshot{}, ErrAuthRevisionNotRetained
+	}
+	return h.snapshots[i-1], nil
+}
@@ -15,7 +15,9 @@
 package auth
 
 import (
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -36,8 +38,117 @@ var (
 	// overridden by auth store initialization
 	reportCurrentAuthRevMu sync.RWMutex
 	reportCurrentAuthRev   = func() float64 { return 0 }
+
+	authenticateTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "authenticate_total",
+		Help:      "The total number of authentication attempts by result.",
+	},
+		[]string{"result"})
+	permissionCheckSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "permission_check_duration_seconds",
+		Help:      "The latency distributions of permission checks by rpc.",
+
+		// lowest bucket start of upper bound 0.00001 sec (10 us) with factor 2
+		// highest bucket start of 0.00001 sec * 2^15 == 0.32768 sec
+		Buckets: prometheus.ExponentialBuckets(0.00001, 2, 16),
+	},
+		[]string{"rpc"})
+	permissionDenied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "permission_denied_total",
+		Help:      "The total number of requests denied by permission check by rpc.",
+	},
+		[]string{"rpc"})
+
+	simpleTokenCacheSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "simple_token_cache_size",
+		Help:      "The number of simple tokens kept by the member.",
+	})
+	simpleTokenCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "simple_token_cache_evictions_total",
+		Help:      "The total number of simple tokens evicted before their expiration because the token cache was full.",
+	})
+	simpleTokenCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "auth",
+		Name:      "simple_token_cache_lookups_total",
+		Help:      "The total number of simple token lookups by result, hit when token was found.",
+	},
+		[]string{"result"})
+
+	authenticateSucceeded = authenticateTotal.WithLabelValues("success")
+	authenticateFailed    = authenticateTotal.WithLabelValues("failure")
+
+	simpleTokenCacheHits   = simpleTokenCacheLookups.WithLabelValues("hit")
+	simpleTokenCacheMisses = simpleTokenCacheLookups.WithLabelValues("miss")
+
+	putPermissionCheck         = newPermissionCheckMetrics("put")
+	rangePermissionCheck       = newPermissionCheckMetrics("range")
+	deleteRangePermissionCheck = newPermissionCheckMetrics("delete_range")
+	adminPermissionCheck       = newPermissionCheckMetrics("admin")
+
+	registerAuthMetricsOnce sync.Once
 )
 
+// permissionCheckMetrics are metrics of permission checks of a single rpc. They are resolved
+// once, so reporting them on the permission check path doesn't require label lookups.
+type permissionCheckMetrics struct {
+	duration prometheus.Observer
+	denied   prometheus.Counter
+}
+
+func newPermissionCheckMetrics(rpc string) permissionCheckMetrics {
+	return permissionCheckMetrics{
+		duration: permissionCheckSec.WithLabelValues(rpc),
+		denied:   permissionDenied.WithLabelValues(rpc),
+	}
+}
+
+func (m permissionCheckMetrics) observe(start time.Time, err error) {
+	m.duration.Observe(time.Since(start).Seconds())
+	if errors.Is(err, ErrPermissionDenied) {
+		m.denied.Inc()
+	}
+}
+
+func reportAuthenticate(err error) {
+	if err != nil {
+		authenticateFailed.Inc()
+		return
+	}
+	authenticateSucceeded.Inc()
+}
+
+func reportSimpleTokenLookup(found bool) {
+	if found {
+		simpleTokenCacheHits.Inc()
+		return
+	}
+	simpleTokenCacheMisses.Inc()
+}
+
+// registerAuthMetrics registers metrics of auth operations. They are registered only once auth is enabled,
+// so members that don't use auth don't export them.
+func registerAuthMetrics() {
+	registerAuthMetricsOnce.Do(func() {
+		prometheus.MustRegister(authenticateTotal)
+		prometheus.MustRegister(permissionCheckSec)
+		prometheus.MustRegister(permissionDenied)
+		prometheus.MustRegister(simpleTokenCacheSize)
+		prometheus.MustRegister(simpleTokenCacheEvictions)
+		prometheus.MustRegister(simpleTokenCacheLookups)
+	})
+}
+
 func init() {
 	prometheus.MustRegister(currentAuthRevision)
 }
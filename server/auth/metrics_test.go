@@ -0,0 +1,58 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMetrics(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	succeeded := testutil.ToFloat64(authenticateSucceeded)
+	failed := testutil.ToFloat64(authenticateFailed)
+	_, err := as.CheckPassword("foo", "bar")
+	require.NoError(t, err)
+	_, err = as.CheckPassword("foo", "baz")
+	require.ErrorIs(t, err, ErrAuthFailed)
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(authenticateSucceeded))
+	assert.Equal(t, failed+1, testutil.ToFloat64(authenticateFailed))
+
+	putDenied := testutil.ToFloat64(putPermissionCheck.denied)
+	adminDenied := testutil.ToFloat64(adminPermissionCheck.denied)
+	authInfo := &AuthInfo{Username: "foo", Revision: as.Revision()}
+	require.ErrorIs(t, as.IsPutPermitted(authInfo, []byte("foo")), ErrPermissionDenied)
+	require.ErrorIs(t, as.IsAdminPermitted(authInfo), ErrPermissionDenied)
+	require.NoError(t, as.IsAdminPermitted(&AuthInfo{Username: "root", Revision: as.Revision()}))
+	assert.Equal(t, putDenied+1, testutil.ToFloat64(putPermissionCheck.denied))
+	assert.Equal(t, adminDenied+1, testutil.ToFloat64(adminPermissionCheck.denied))
+
+	// metrics are registered once auth is enabled
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	registered := map[string]bool{}
+	for _, family := range families {
+		registered[family.GetName()] = true
+	}
+	for _, name := range []string{"etcd_auth_authenticate_total", "etcd_auth_permission_check_duration_seconds", "etcd_auth_permission_denied_total"} {
+		assert.True(t, registered[name], "metric %q not registered", name)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/pkg/v3/adt"
+)
+
+// KeySizeRanger returns sizes of all keys currently stored in the range [key, rangeEnd),
+// indexed by key. Size of a key is the length of the key plus the length of its value.
+// Empty rangeEnd means a single key and rangeEnd []byte{0x00} means all keys >= key.
+type KeySizeRanger func(key, rangeEnd []byte) map[string]int64
+
+// keySize returns the storage size of the key, counted against role quota.
+func keySize(key, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
+
+// roleQuota tracks storage used by keys under the ranges that a role with quota can write.
+type roleQuota struct {
+	quota      int64
+	writePerms adt.IntervalTree
+	sizes      map[string]int64
+	used       int64
+}
+
+func (q *roleQuota) covers(key string) bool {
+	return q.writePerms.Intersects(adt.NewBytesAffinePoint([]byte(key)))
+}
+
+// quotaTracker keeps storage usage of roles with quota up to date. Usage of a role
+// is rebuilt from the keys it can write whenever the role changes and updated
+// incrementally with events of every write, so usage is always computed at the
+// same applied revision on every member.
+type quotaTracker struct {
+	mu     sync.RWMutex
+	ranger KeySizeRanger
+	roles  map[string]*roleQuota // role name -> roleQuota
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{roles: make(map[string]*roleQuota)}
+}
+
+func (t *quotaTracker) setRanger(ranger KeySizeRanger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ranger = ranger
+}
+
+// refresh rebuilds usage of all roles with quota, ranging over all keys the roles can write.
+// It is called when the whole auth state changes, e.g. on recovery from a snapshot.
+func (t *quotaTracker) refresh(roles []*authpb.Role) {
+	t.mu.RLock()
+	ranger := t.ranger
+	t.mu.RUnlock()
+
+	quotas := make(map[string]*roleQuota)
+	for _, role := range roles {
+		if q := newRoleQuota(role, ranger); q != nil {
+			quotas[string(role.Name)] = q
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.roles = quotas
+}
+
+// refreshRole rebuilds usage of a single role after it changed, ranging only over
+// keys that role can write. Usage of the role is dropped if it has no quota.
+func (t *quotaTracker) refreshRole(role *authpb.Role) {
+	t.mu.RLock()
+	ranger := t.ranger
+	t.mu.RUnlock()
+
+	q := newRoleQuota(role, ranger)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if q == nil {
+		delete(t.roles, string(role.Name))
+		return
+	}
+	t.roles[string(role.Name)] = q
+}
+
+// remove drops usage of a deleted role.
+func (t *quotaTracker) remove(role string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.roles, role)
+}
+
+// newRoleQuota computes usage of the role from the key space, nil if the role has no quota.
+func newRoleQuota(role *authpb.Role, ranger KeySizeRanger) *roleQuota {
+	if role.QuotaBytes <= 0 {
+		return nil
+	}
+	q := &roleQuota{
+		quota:      role.QuotaBytes,
+		writePerms: adt.NewIntervalTree(),
+		sizes:      make(map[string]int64),
+	}
+	for _, perm := range role.KeyPermission {
+		if perm.PermType != authpb.WRITE && perm.PermType != authpb.READWRITE {
+			continue
+		}
+		if perm.UserTemplate {
+			// the role permits writes to keys of every user granted it, so quota covers keys of all of them
+			key, rangeEnd := userTemplateBounds(perm.Key)
+			perm = &authpb.Permission{PermType: perm.PermType, Key: key, RangeEnd: rangeEnd}
+		}
+		q.writePerms.Insert(permInterval(perm), struct{}{})
+		if ranger == nil {
+			continue
+		}
+		for key, size := range ranger(perm.Key, perm.RangeEnd) {
+			if _, ok := q.sizes[key]; !ok {
+				q.sizes[key] = size
+				q.used += size
+			}
+		}
+	}
+	return q
+}
+
+// observe updates usage of roles with quota by events of a write transaction.
+func (t *quotaTracker) observe(evs []mvccpb.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, ev := range evs {
+		key := string(ev.Kv.Key)
+		for _, q := range t.roles {
+			if !q.covers(key) {
+				continue
+			}
+			q.used -= q.sizes[key]
+			switch ev.Type {
+			case mvccpb.PUT:
+				q.sizes[key] = keySize(ev.Kv.Key, ev.Kv.Value)
+				q.used += q.sizes[key]
+			case mvccpb.DELETE:
+				delete(q.sizes, key)
+			}
+		}
+	}
+}
+
+// usage returns storage currently used by the role.
+func (t *quotaTracker) usage(role string) int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if q, ok := t.roles[role]; ok {
+		return q.used
+	}
+	return 0
+}
+
+// exceeded checks whether putting keys of the given sizes would exceed quota of the role.
+// Writes that don't increase usage are always allowed, so a role over its quota can still
+// shrink or overwrite its keys.
+func (t *quotaTracker) exceeded(role string, puts map[string]int64) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	q, ok := t.roles[role]
+	if !ok {
+		return false
+	}
+	used := q.used
+	for key, size := range puts {
+		if q.covers(key) {
+			used += size - q.sizes[key]
+		}
+	}
+	return used > q.quota && used > q.used
+}
+
+func permInterval(perm *authpb.Permission) adt.Interval {
+	if len(perm.RangeEnd) == 0 {
+		return adt.NewBytesAffinePoint(perm.Key)
+	}
+	if isOpenEnded(perm.RangeEnd) {
+		return adt.NewBytesAffineInterval(perm.Key, nil)
+	}
+	return adt.NewBytesAffineInterval(perm.Key, perm.RangeEnd)
+}
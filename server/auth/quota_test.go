@@ -0,0 +1,199 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// mapRanger returns KeySizeRanger over the given keys and values.
+func mapRanger(kvs map[string]string) KeySizeRanger {
+	return func(key, rangeEnd []byte) map[string]int64 {
+		sizes := make(map[string]int64)
+		for k, v := range kvs {
+			inRange := bytes.Equal([]byte(k), key)
+			if len(rangeEnd) != 0 {
+				inRange = bytes.Compare([]byte(k), key) >= 0 && (isOpenEnded(rangeEnd) || bytes.Compare([]byte(k), rangeEnd) < 0)
+			}
+			if inRange {
+				sizes[k] = keySize([]byte(k), []byte(v))
+			}
+		}
+		return sizes
+	}
+}
+
+func TestQuotaTracker(t *testing.T) {
+	tracker := newQuotaTracker()
+	tracker.setRanger(mapRanger(map[string]string{"a1": "123", "a2": "1", "b1": "12345", "c": "1"}))
+	tracker.refresh([]*authpb.Role{
+		{
+			Name:       []byte("role-a"),
+			QuotaBytes: 10,
+			KeyPermission: []*authpb.Permission{
+				{PermType: authpb.READWRITE, Key: []byte("a"), RangeEnd: []byte("b")},
+				// overlapping permission shouldn't count keys twice
+				{PermType: authpb.WRITE, Key: []byte("a1")},
+				// keys that can only be read don't count
+				{PermType: authpb.READ, Key: []byte("b"), RangeEnd: []byte("c")},
+			},
+		},
+		{
+			Name:          []byte("role-all"),
+			QuotaBytes:    100,
+			KeyPermission: []*authpb.Permission{{PermType: authpb.WRITE, Key: []byte("a"), RangeEnd: []byte{0}}},
+		},
+		{
+			Name:          []byte("role-no-quota"),
+			KeyPermission: []*authpb.Permission{{PermType: authpb.WRITE, Key: []byte("a"), RangeEnd: []byte{0}}},
+		},
+	})
+	assert.Equal(t, int64(8), tracker.usage("role-a"))
+	assert.Equal(t, int64(17), tracker.usage("role-all"))
+	assert.Equal(t, int64(0), tracker.usage("role-no-quota"))
+
+	assert.False(t, tracker.exceeded("role-a", map[string]int64{"a3": 2}))
+	assert.True(t, tracker.exceeded("role-a", map[string]int64{"a3": 3}))
+	assert.False(t, tracker.exceeded("role-a", map[string]int64{"a1": 7, "b2": 100}), "keys outside of write permissions shouldn't count")
+	assert.False(t, tracker.exceeded("role-no-quota", map[string]int64{"a3": 1000}))
+
+	tracker.observe([]mvccpb.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("a1"), Value: []byte("1")}},
+		{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("a2")}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("a3"), Value: []byte("123456")}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("d"), Value: []byte("1")}},
+	})
+	assert.Equal(t, int64(11), tracker.usage("role-a"))
+	assert.Equal(t, int64(22), tracker.usage("role-all"))
+
+	// role over its quota can still write keys that don't increase usage
+	assert.False(t, tracker.exceeded("role-a", map[string]int64{"a3": 3}))
+	assert.True(t, tracker.exceeded("role-a", map[string]int64{"a4": 2}))
+}
+
+func TestQuotaTrackerRefreshRole(t *testing.T) {
+	tracker := newQuotaTracker()
+	ranger := mapRanger(map[string]string{"a1": "123", "b1": "12345"})
+	var ranged []string
+	tracker.setRanger(func(key, rangeEnd []byte) map[string]int64 {
+		ranged = append(ranged, string(key))
+		return ranger(key, rangeEnd)
+	})
+	roleA := &authpb.Role{
+		Name:          []byte("role-a"),
+		QuotaBytes:    10,
+		KeyPermission: []*authpb.Permission{{PermType: authpb.WRITE, Key: []byte("a"), RangeEnd: []byte("b")}},
+	}
+	roleB := &authpb.Role{
+		Name:          []byte("role-b"),
+		QuotaBytes:    10,
+		KeyPermission: []*authpb.Permission{{PermType: authpb.WRITE, Key: []byte("b"), RangeEnd: []byte("c")}},
+	}
+	tracker.refresh([]*authpb.Role{roleA, roleB})
+	assert.Equal(t, []string{"a", "b"}, ranged)
+
+	// only keys of the changed role are ranged over, usage of other roles is kept
+	ranged = nil
+	roleA.KeyPermission = append(roleA.KeyPermission, &authpb.Permission{PermType: authpb.WRITE, Key: []byte("b1")})
+	tracker.refreshRole(roleA)
+	assert.Equal(t, []string{"a", "b1"}, ranged)
+	assert.Equal(t, int64(12), tracker.usage("role-a"))
+	assert.Equal(t, int64(7), tracker.usage("role-b"))
+
+	roleA.QuotaBytes = 0
+	tracker.refreshRole(roleA)
+	assert.Equal(t, int64(0), tracker.usage("role-a"))
+	assert.False(t, tracker.exceeded("role-a", map[string]int64{"a2": 100}))
+
+	tracker.remove("role-b")
+	assert.Equal(t, int64(0), tracker.usage("role-b"))
+	assert.False(t, tracker.exceeded("role-b", map[string]int64{"b2": 100}))
+}
+
+func TestRoleSetQuota(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	as.SetKeySizeRanger(mapRanger(map[string]string{"foo": "12345"}))
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.WRITE, Key: []byte("foo"), RangeEnd: []byte("fop")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = as.RoleSetQuota(&pb.AuthRoleSetQuotaRequest{Role: "role-test", QuotaBytes: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := as.RoleGet(&pb.AuthRoleGetRequest{Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(16), r.QuotaBytes)
+	assert.Equal(t, int64(8), r.UsedBytes)
+
+	authInfo := &AuthInfo{Username: "foo", Revision: as.Revision()}
+	assert.NoError(t, as.IsPutWithinQuota(authInfo, map[string]int64{"foo1": 8}))
+	assert.ErrorIs(t, as.IsPutWithinQuota(authInfo, map[string]int64{"foo1": 9}), ErrRoleQuotaExceeded)
+	assert.NoError(t, as.IsPutWithinQuota(&AuthInfo{Username: "root", Revision: as.Revision()}, map[string]int64{"foo1": 9}))
+
+	as.ObserveWrites([]mvccpb.Event{{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("foo1"), Value: []byte("1234")}}})
+	r, err = as.RoleGet(&pb.AuthRoleGetRequest{Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(16), r.UsedBytes)
+
+	// revoking other permission keeps the quota
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{
+		Name: "role-test",
+		Perm: &authpb.Permission{PermType: authpb.READ, Key: []byte("bar")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = as.RoleRevokePermission(&pb.AuthRoleRevokePermissionRequest{Role: "role-test", Key: []byte("bar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = as.RoleGet(&pb.AuthRoleGetRequest{Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(16), r.QuotaBytes)
+
+	_, err = as.RoleSetQuota(&pb.AuthRoleSetQuotaRequest{Role: "role-test", QuotaBytes: -1})
+	if !errors.Is(err, ErrInvalidAuthMgmt) {
+		t.Errorf("expected %v, got %v", ErrInvalidAuthMgmt, err)
+	}
+	_, err = as.RoleSetQuota(&pb.AuthRoleSetQuotaRequest{Role: "norole", QuotaBytes: 1})
+	if !errors.Is(err, ErrRoleNotFound) {
+		t.Errorf("expected %v, got %v", ErrRoleNotFound, err)
+	}
+}
@@ -26,7 +26,12 @@ func getMergedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 	if user == nil {
 		return nil
 	}
+	return mergePerms(tx, user)
+}
 
+// mergePerms merges permissions of all roles of the user.
+func mergePerms(tx AuthReadTx, user *authpb.User) *unifiedRangePermissions {
+	userName := string(user.Name)
 	readPerms := adt.NewIntervalTree()
 	writePerms := adt.NewIntervalTree()
 
@@ -40,6 +45,11 @@ func getMergedPerms(tx AuthReadTx, userName string) *unifiedRangePermissions {
 			var ivl adt.Interval
 			var rangeEnd []byte
 
+			perm, ok := expandUserTemplate(perm, userName)
+			if !ok {
+				continue
+			}
+
 			if len(perm.RangeEnd) != 1 || perm.RangeEnd[0] != 0 {
 				rangeEnd = perm.RangeEnd
 			}
@@ -120,11 +130,15 @@ func (as *authStore) isRangeOpPermitted(userName string, key, rangeEnd []byte, p
 		return false
 	}
 
+	return checkRangePerm(as.lg, rangePerm, key, rangeEnd, permtyp)
+}
+
+func checkRangePerm(lg *zap.Logger, perms *unifiedRangePermissions, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
 	if len(rangeEnd) == 0 {
-		return checkKeyPoint(as.lg, rangePerm, key, permtyp)
+		return checkKeyPoint(lg, perms, key, permtyp)
 	}
 
-	return checkKeyInterval(as.lg, rangePerm, key, rangeEnd, permtyp)
+	return checkKeyInterval(lg, perms, key, rangeEnd, permtyp)
 }
 
 func (as *authStore) refreshRangePermCache(tx AuthReadTx) {
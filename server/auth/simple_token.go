@@ -18,6 +18,7 @@ package auth
 // JWT based mechanism will be added in the near future.
 
 import (
+	"container/list"
 	"context"
 	"crypto/rand"
 	"errors"
@@ -44,7 +45,11 @@ var (
 )
 
 type simpleTokenTTLKeeper struct {
-	tokens          map[string]time.Time
+	tokens map[string]*list.Element
+	// lru holds tokens ordered by their last use, the least recently used one at the back.
+	// As TTL is reset on each use, it's also the order of their expiration.
+	lru             *list.List
+	maxTokens       int
 	donec           chan struct{}
 	stopc           chan struct{}
 	deleteTokenFunc func(string)
@@ -52,6 +57,11 @@ type simpleTokenTTLKeeper struct {
 	simpleTokenTTL  time.Duration
 }
 
+type simpleTokenEntry struct {
+	token   string
+	expires time.Time
+}
+
 func (tm *simpleTokenTTLKeeper) stop() {
 	select {
 	case tm.stopc <- struct{}{}:
@@ -61,16 +71,34 @@ func (tm *simpleTokenTTLKeeper) stop() {
 }
 
 func (tm *simpleTokenTTLKeeper) addSimpleToken(token string) {
-	tm.tokens[token] = time.Now().Add(tm.simpleTokenTTL)
+	tm.tokens[token] = tm.lru.PushFront(&simpleTokenEntry{token: token, expires: time.Now().Add(tm.simpleTokenTTL)})
+	for tm.maxTokens > 0 && tm.lru.Len() > tm.maxTokens {
+		tm.removeSimpleToken(tm.lru.Back())
+		simpleTokenCacheEvictions.Inc()
+	}
+	simpleTokenCacheSize.Set(float64(tm.lru.Len()))
 }
 
 func (tm *simpleTokenTTLKeeper) resetSimpleToken(token string) {
-	if _, ok := tm.tokens[token]; ok {
-		tm.tokens[token] = time.Now().Add(tm.simpleTokenTTL)
+	if e, ok := tm.tokens[token]; ok {
+		e.Value.(*simpleTokenEntry).expires = time.Now().Add(tm.simpleTokenTTL)
+		tm.lru.MoveToFront(e)
 	}
 }
 
 func (tm *simpleTokenTTLKeeper) deleteSimpleToken(token string) {
+	if e, ok := tm.tokens[token]; ok {
+		tm.lru.Remove(e)
+		delete(tm.tokens, token)
+		simpleTokenCacheSize.Set(float64(tm.lru.Len()))
+	}
+}
+
+// removeSimpleToken removes token both from the keeper and from the token provider.
+func (tm *simpleTokenTTLKeeper) removeSimpleToken(e *list.Element) {
+	token := e.Value.(*simpleTokenEntry).token
+	tm.deleteTokenFunc(token)
+	tm.lru.Remove(e)
 	delete(tm.tokens, token)
 }
 
@@ -85,12 +113,10 @@ func (tm *simpleTokenTTLKeeper) run() {
 		case <-tokenTicker.C:
 			nowtime := time.Now()
 			tm.mu.Lock()
-			for t, tokenendtime := range tm.tokens {
-				if nowtime.After(tokenendtime) {
-					tm.deleteTokenFunc(t)
-					delete(tm.tokens, t)
-				}
+			for e := tm.lru.Back(); e != nil && nowtime.After(e.Value.(*simpleTokenEntry).expires); e = tm.lru.Back() {
+				tm.removeSimpleToken(e)
 			}
+			simpleTokenCacheSize.Set(float64(tm.lru.Len()))
 			tm.mu.Unlock()
 		case <-tm.stopc:
 			return
@@ -105,6 +131,7 @@ type tokenSimple struct {
 	simpleTokensMu    sync.Mutex
 	simpleTokens      map[string]string // token -> username
 	simpleTokenTTL    time.Duration
+	maxSimpleTokens   int
 }
 
 func (t *tokenSimple) genTokenPrefix() (string, error) {
@@ -177,7 +204,9 @@ func (t *tokenSimple) enable() {
 		}
 	}
 	t.simpleTokenKeeper = &simpleTokenTTLKeeper{
-		tokens:          make(map[string]time.Time),
+		tokens:          make(map[string]*list.Element),
+		lru:             list.New(),
+		maxTokens:       t.maxSimpleTokens,
 		donec:           make(chan struct{}),
 		stopc:           make(chan struct{}),
 		deleteTokenFunc: delf,
@@ -195,6 +224,7 @@ func (t *tokenSimple) disable() {
 	t.simpleTokensMu.Unlock()
 	if tk != nil {
 		tk.stop()
+		simpleTokenCacheSize.Set(0)
 	}
 }
 
@@ -208,6 +238,7 @@ func (t *tokenSimple) info(ctx context.Context, token string, revision uint64) (
 		t.simpleTokenKeeper.resetSimpleToken(token)
 	}
 	t.simpleTokensMu.Unlock()
+	reportSimpleTokenLookup(ok)
 	return &AuthInfo{Username: username, Revision: revision}, ok
 }
 
@@ -244,14 +275,17 @@ func (t *tokenSimple) isValidSimpleToken(ctx context.Context, token string) bool
 	return false
 }
 
-func newTokenProviderSimple(lg *zap.Logger, indexWaiter func(uint64) <-chan struct{}, TokenTTL time.Duration) *tokenSimple {
+// newTokenProviderSimple creates simple token provider. If maxSimpleTokens is positive, at most that many tokens
+// are kept, assigning a new one evicts the least recently used token, which is reported as invalid afterwards.
+func newTokenProviderSimple(lg *zap.Logger, indexWaiter func(uint64) <-chan struct{}, TokenTTL time.Duration, maxSimpleTokens int) *tokenSimple {
 	if lg == nil {
 		lg = zap.NewNop()
 	}
 	return &tokenSimple{
-		lg:             lg,
-		simpleTokens:   make(map[string]string),
-		indexWaiter:    indexWaiter,
-		simpleTokenTTL: TokenTTL,
+		lg:              lg,
+		simpleTokens:    make(map[string]string),
+		indexWaiter:     indexWaiter,
+		simpleTokenTTL:  TokenTTL,
+		maxSimpleTokens: maxSimpleTokens,
 	}
 }
@@ -18,15 +18,16 @@ import (
 	"context"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/zap/zaptest"
 )
 
 // TestSimpleTokenDisabled ensures that TokenProviderSimple behaves correctly when
 // disabled.
 func TestSimpleTokenDisabled(t *testing.T) {
-	initialState := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault)
+	initialState := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault, 0)
 
-	explicitlyDisabled := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault)
+	explicitlyDisabled := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	explicitlyDisabled.enable()
 	explicitlyDisabled.disable()
 
@@ -48,7 +49,7 @@ func TestSimpleTokenDisabled(t *testing.T) {
 // TestSimpleTokenAssign ensures that TokenProviderSimple can correctly assign a
 // token, look it up with info, and invalidate it by user.
 func TestSimpleTokenAssign(t *testing.T) {
-	tp := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault)
+	tp := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	tp.enable()
 	defer tp.disable()
 	ctx := context.WithValue(context.WithValue(context.TODO(), AuthenticateParamIndex{}, uint64(1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
@@ -68,3 +69,41 @@ func TestSimpleTokenAssign(t *testing.T) {
 		t.Errorf("expected ok == false after user is invalidated")
 	}
 }
+
+// TestSimpleTokenEviction ensures that TokenProviderSimple keeps at most configured number
+// of tokens, evicting the least recently used one.
+func TestSimpleTokenEviction(t *testing.T) {
+	tp := newTokenProviderSimple(zaptest.NewLogger(t), dummyIndexWaiter, simpleTokenTTLDefault, 2)
+	tp.enable()
+	defer tp.disable()
+	evictions := testutil.ToFloat64(simpleTokenCacheEvictions)
+
+	var tokens []string
+	for i, user := range []string{"user1", "user2", "user3"} {
+		ctx := context.WithValue(context.WithValue(context.TODO(), AuthenticateParamIndex{}, uint64(i+1)), AuthenticateParamSimpleTokenPrefix{}, "dummy")
+		token, err := tp.assign(ctx, user, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, token)
+		if i == 1 {
+			// use the first token, so the second one becomes the least recently used
+			if _, ok := tp.info(ctx, tokens[0], 0); !ok {
+				t.Fatalf("expected token of user1 to be valid")
+			}
+		}
+	}
+
+	for i, expectValid := range []bool{true, false, true} {
+		_, ok := tp.info(context.TODO(), tokens[i], 0)
+		if ok != expectValid {
+			t.Errorf("expected token %q valid: %t, got %t", tokens[i], expectValid, ok)
+		}
+	}
+	if got := testutil.ToFloat64(simpleTokenCacheEvictions) - evictions; got != 1 {
+		t.Errorf("expected 1 eviction, got %v", got)
+	}
+	if got := testutil.ToFloat64(simpleTokenCacheSize); got != 2 {
+		t.Errorf("expected cache size 2, got %v", got)
+	}
+}
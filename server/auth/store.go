@@ -27,6 +27,7 @@ import (
 
 	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 
 	"go.uber.org/zap"
@@ -42,29 +43,37 @@ var (
 
 	rootPerm = authpb.Permission{PermType: authpb.READWRITE, Key: []byte{}, RangeEnd: []byte{0}}
 
-	ErrRootUserNotExist     = errors.New("auth: root user does not exist")
-	ErrRootRoleNotExist     = errors.New("auth: root user does not have root role")
-	ErrUserAlreadyExist     = errors.New("auth: user already exists")
-	ErrUserEmpty            = errors.New("auth: user name is empty")
-	ErrUserNotFound         = errors.New("auth: user not found")
-	ErrRoleAlreadyExist     = errors.New("auth: role already exists")
-	ErrRoleNotFound         = errors.New("auth: role not found")
-	ErrRoleEmpty            = errors.New("auth: role name is empty")
-	ErrPermissionNotGiven   = errors.New("auth: permission not given")
-	ErrAuthFailed           = errors.New("auth: authentication failed, invalid user ID or password")
-	ErrNoPasswordUser       = errors.New("auth: authentication failed, password was given for no password user")
-	ErrPermissionDenied     = errors.New("auth: permission denied")
-	ErrRoleNotGranted       = errors.New("auth: role is not granted to the user")
-	ErrPermissionNotGranted = errors.New("auth: permission is not granted to the role")
-	ErrAuthNotEnabled       = errors.New("auth: authentication is not enabled")
-	ErrAuthOldRevision      = errors.New("auth: revision in header is old")
-	ErrInvalidAuthToken     = errors.New("auth: invalid auth token")
-	ErrInvalidAuthOpts      = errors.New("auth: invalid auth options")
-	ErrInvalidAuthMgmt      = errors.New("auth: invalid auth management")
-	ErrInvalidAuthMethod    = errors.New("auth: invalid auth signature method")
-	ErrMissingKey           = errors.New("auth: missing key data")
-	ErrKeyMismatch          = errors.New("auth: public and private keys don't match")
-	ErrVerifyOnly           = errors.New("auth: token signing attempted with verify-only key")
+	ErrRootUserNotExist        = errors.New("auth: root user does not exist")
+	ErrRootRoleNotExist        = errors.New("auth: root user does not have root role")
+	ErrUserAlreadyExist        = errors.New("auth: user already exists")
+	ErrUserEmpty               = errors.New("auth: user name is empty")
+	ErrUserNotFound            = errors.New("auth: user not found")
+	ErrRoleAlreadyExist        = errors.New("auth: role already exists")
+	ErrRoleNotFound            = errors.New("auth: role not found")
+	ErrRoleEmpty               = errors.New("auth: role name is empty")
+	ErrPermissionNotGiven      = errors.New("auth: permission not given")
+	ErrAuthFailed              = errors.New("auth: authentication failed, invalid user ID or password")
+	ErrNoPasswordUser          = errors.New("auth: authentication failed, password was given for no password user")
+	ErrInvalidAssertion        = errors.New("auth: authentication failed, invalid assertion")
+	ErrAssertionNotConfigured  = errors.New("auth: authentication by assertion is not configured")
+	ErrPermissionDenied        = errors.New("auth: permission denied")
+	ErrRoleNotGranted          = errors.New("auth: role is not granted to the user")
+	ErrTooManyRoles            = errors.New("auth: user has too many roles")
+	ErrUserImmutable           = errors.New("auth: user is immutable")
+	ErrPermissionNotGranted    = errors.New("auth: permission is not granted to the role")
+	ErrAuthNotEnabled          = errors.New("auth: authentication is not enabled")
+	ErrAuthOldRevision         = errors.New("auth: revision in header is old")
+	ErrAuthRateLimited         = errors.New("auth: too many failed authentication attempts, retry later")
+	ErrRoleQuotaExceeded       = errors.New("auth: role storage quota exceeded")
+	ErrAuthRevisionNotRetained = errors.New("auth: required auth revision is older than retained auth history")
+	ErrAuthFutureRevision      = errors.New("auth: required auth revision is a future revision")
+	ErrInvalidAuthToken        = errors.New("auth: invalid auth token")
+	ErrInvalidAuthOpts         = errors.New("auth: invalid auth options")
+	ErrInvalidAuthMgmt         = errors.New("auth: invalid auth management")
+	ErrInvalidAuthMethod       = errors.New("auth: invalid auth signature method")
+	ErrMissingKey              = errors.New("auth: missing key data")
+	ErrKeyMismatch             = errors.New("auth: public and private keys don't match")
+	ErrVerifyOnly              = errors.New("auth: token signing attempted with verify-only key")
 )
 
 const (
@@ -78,6 +87,9 @@ const (
 type AuthInfo struct {
 	Username string
 	Revision uint64
+	// Time is unix time in seconds at which permissions are checked, temporary role grants expired
+	// by then are ignored. Zero ignores expiration, such grants are then permitted until revoked.
+	Time int64
 }
 
 // AuthenticateParamIndex is used for a key of context in the parameters of Authenticate()
@@ -86,6 +98,10 @@ type AuthenticateParamIndex struct{}
 // AuthenticateParamSimpleTokenPrefix is used for a key of context in the parameters of Authenticate()
 type AuthenticateParamSimpleTokenPrefix struct{}
 
+// AuthenticateParamAssertion is used for a key of context in the parameters of Authenticate(), set if user
+// was authenticated by an assertion instead of password
+type AuthenticateParamAssertion struct{}
+
 // AuthStore defines auth storage interface.
 type AuthStore interface {
 	// AuthEnable turns on the authentication feature
@@ -115,12 +131,24 @@ type AuthStore interface {
 	// UserGrantRole grants a role to the user
 	UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
 
+	// InternalUserGrantRole grants a role to the user, with expiry and limit of roles computed when the request was proposed
+	InternalUserGrantRole(r *pb.InternalAuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
+
 	// UserGet gets the detailed information of a users
 	UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error)
 
 	// UserRevokeRole revokes a role of a user
 	UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error)
 
+	// UserSetRoles replaces all roles of the user
+	UserSetRoles(r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error)
+
+	// InternalUserSetRoles replaces all roles of the user, with limit of roles computed when the request was proposed
+	InternalUserSetRoles(r *pb.InternalAuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error)
+
+	// UserSetImmutable sets or clears protection of the user from deletion and role revocation
+	UserSetImmutable(r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error)
+
 	// RoleAdd adds a new role
 	RoleAdd(r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error)
 
@@ -136,12 +164,27 @@ type AuthStore interface {
 	// RoleDelete gets the detailed information of a role
 	RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 
+	// RoleSetQuota sets storage quota of a role
+	RoleSetQuota(r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error)
+
+	// RoleSetAdminPrefix makes a role an admin scoped to a key prefix
+	RoleSetAdminPrefix(r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error)
+
 	// UserList gets a list of all users
 	UserList(r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 
 	// RoleList gets a list of all roles
 	RoleList(r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
 
+	// UsersWithRole gets a list of all users that have been granted the role
+	UsersWithRole(r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error)
+
+	// WhoAmI gets the identity of the authenticated user with its roles and permissions
+	WhoAmI(authInfo *AuthInfo) (*pb.AuthWhoAmIResponse, error)
+
+	// UserPermissionsAtRevision gets roles and permissions the user had at a past auth revision
+	UserPermissionsAtRevision(r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error)
+
 	// IsPutPermitted checks put permission of the user
 	IsPutPermitted(authInfo *AuthInfo, key []byte) error
 
@@ -154,6 +197,26 @@ type AuthStore interface {
 	// IsAdminPermitted checks admin permission of the user
 	IsAdminPermitted(authInfo *AuthInfo) error
 
+	// IsPermissionAdminPermitted checks that the user can grant or revoke a permission of the range to the role,
+	// either as an admin or as an admin scoped to a prefix including the range
+	IsPermissionAdminPermitted(authInfo *AuthInfo, role string, key, rangeEnd []byte) error
+
+	// IsRoleAdminPermitted checks that the user can grant or revoke the role to users,
+	// either as an admin or as an admin scoped to a prefix including everything the role permits
+	IsRoleAdminPermitted(authInfo *AuthInfo, role string) error
+
+	// IsPutWithinQuota checks that putting keys of the given sizes doesn't exceed storage quota of any role of the user
+	IsPutWithinQuota(authInfo *AuthInfo, puts map[string]int64) error
+
+	// SetKeySizeRanger sets the ranger used to compute storage usage of roles with quota
+	SetKeySizeRanger(ranger KeySizeRanger)
+
+	// SetHistoryRetention sets the number of recent auth revisions whose users and roles are retained in memory
+	SetHistoryRetention(retention int)
+
+	// ObserveWrites updates storage usage of roles with quota by events of a write transaction
+	ObserveWrites(evs []mvccpb.Event)
+
 	// GenTokenPrefix produces a random string in a case of simple token
 	// in a case of JWT, it produces an empty string
 	GenTokenPrefix() (string, error)
@@ -164,6 +227,15 @@ type AuthStore interface {
 	// CheckPassword checks a given pair of username and password is correct
 	CheckPassword(username, password string) (uint64, error)
 
+	// VerifyPassword checks whether password of the user is correct, without authenticating
+	VerifyPassword(username, password string) (bool, error)
+
+	// CheckAssertion checks a given assertion is valid and returns the user it was issued for
+	CheckAssertion(username, assertion string) (string, uint64, error)
+
+	// SetAssertionVerifier sets the verifier of assertions used instead of passwords
+	SetAssertionVerifier(verifier *AssertionVerifier)
+
 	// Close does cleanup of AuthStore
 	Close() error
 
@@ -179,6 +251,15 @@ type AuthStore interface {
 	// HasRole checks that user has role
 	HasRole(user, role string) bool
 
+	// IsRoleGranted checks that user of authInfo has role, ignoring grants expired at the time of authInfo
+	IsRoleGranted(authInfo *AuthInfo, role string) bool
+
+	// HasExpiredRoleGrants checks whether any user has a temporary role grant expired at the given time
+	HasExpiredRoleGrants(now time.Time) bool
+
+	// RevokeExpiredRoleGrants revokes temporary role grants expired at the time carried by the request
+	RevokeExpiredRoleGrants(r *pb.InternalAuthRevokeExpiredRoleGrantsRequest)
+
 	// BcryptCost gets strength of hashing bcrypted auth password
 	BcryptCost() int
 }
@@ -244,8 +325,22 @@ type authStore struct {
 	rangePermCache   map[string]*unifiedRangePermissions // username -> unifiedRangePermissions
 	rangePermCacheMu sync.RWMutex
 
+	// quotas tracks storage usage of roles with quota
+	quotas *quotaTracker
+
+	// history retains users and roles at recent auth revisions
+	history *authHistory
+
 	tokenProvider TokenProvider
 	bcryptCost    int // the algorithm cost / strength for hashing auth passwords
+
+	// dummyPassword is compared with passwords of users that cannot be authenticated with password,
+	// so VerifyPassword takes the same time whether the user exists or not
+	dummyPassword     []byte
+	dummyPasswordOnce sync.Once
+
+	// assertionVerifier verifies assertions used instead of passwords, nil if authentication by assertion is not configured
+	assertionVerifier *AssertionVerifier
 }
 
 func (as *authStore) AuthEnable() error {
@@ -274,6 +369,7 @@ func (as *authStore) AuthEnable() error {
 	tx.UnsafeSaveAuthEnabled(true)
 	as.enabled = true
 	as.tokenProvider.enable()
+	registerAuthMetrics()
 
 	as.refreshRangePermCache(tx)
 
@@ -324,11 +420,13 @@ func (as *authStore) Authenticate(ctx context.Context, username, password string
 		return nil, ErrAuthFailed
 	}
 
-	if user.Options != nil && user.Options.NoPassword {
+	// Users without password can be authenticated only by assertion, which doesn't use password.
+	assertion, _ := ctx.Value(AuthenticateParamAssertion{}).(bool)
+	if !assertion && user.Options != nil && user.Options.NoPassword {
 		return nil, ErrAuthFailed
 	}
 
-	// Password checking is already performed in the API layer, so we don't need to check for now.
+	// Password or assertion checking is already performed in the API layer, so we don't need to check for now.
 	// Staleness of password can be detected with OCC in the API layer, too.
 
 	token, err := as.tokenProvider.assign(ctx, username, as.Revision())
@@ -344,15 +442,16 @@ func (as *authStore) Authenticate(ctx context.Context, username, password string
 	return &pb.AuthenticateResponse{Token: token}, nil
 }
 
-func (as *authStore) CheckPassword(username, password string) (uint64, error) {
+func (as *authStore) CheckPassword(username, password string) (revision uint64, err error) {
 	if !as.IsAuthEnabled() {
 		return 0, ErrAuthNotEnabled
 	}
+	defer func() { reportAuthenticate(err) }()
 
 	var user *authpb.User
 	// CompareHashAndPassword is very expensive, so we use closures
 	// to avoid putting it in the critical section of the tx lock.
-	revision, err := func() (uint64, error) {
+	revision, err = func() (uint64, error) {
 		tx := as.be.ReadTx()
 		tx.Lock()
 		defer tx.Unlock()
@@ -379,6 +478,82 @@ func (as *authStore) CheckPassword(username, password string) (uint64, error) {
 	return revision, nil
 }
 
+// VerifyPassword checks password of the user using the same bcrypt comparison as CheckPassword.
+// Unlike CheckPassword, password is compared even if the user doesn't exist or has no password,
+// so that response time doesn't reveal whether the user exists.
+func (as *authStore) VerifyPassword(username, password string) (bool, error) {
+	if !as.IsAuthEnabled() {
+		return false, ErrAuthNotEnabled
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	user := tx.UnsafeGetUser(username)
+	tx.Unlock()
+
+	var err error
+	hashedPassword := as.dummyPasswordHash()
+	switch {
+	case user == nil:
+		err = ErrUserNotFound
+	case user.Options != nil && user.Options.NoPassword:
+		err = ErrNoPasswordUser
+	default:
+		hashedPassword = user.Password
+	}
+	valid := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password)) == nil
+	if err != nil {
+		return false, err
+	}
+	return valid, nil
+}
+
+// CheckAssertion verifies the assertion and returns the user it was issued for, together with auth revision
+// at which the user was found. If username is given, it has to match the user of the assertion.
+// The user is returned also on failure once the assertion is verified, so the failure can be attributed to it.
+func (as *authStore) CheckAssertion(username, assertion string) (user string, revision uint64, err error) {
+	if !as.IsAuthEnabled() {
+		return "", 0, ErrAuthNotEnabled
+	}
+	defer func() { reportAuthenticate(err) }()
+
+	if as.assertionVerifier == nil {
+		return "", 0, ErrAssertionNotConfigured
+	}
+	user, err = as.assertionVerifier.Verify(assertion)
+	if err != nil {
+		return "", 0, err
+	}
+	if username != "" && username != user {
+		as.lg.Info("assertion was issued for other user", zap.String("user-name", username), zap.String("assertion-user-name", user))
+		return user, 0, ErrInvalidAssertion
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+	if tx.UnsafeGetUser(user) == nil {
+		as.lg.Info("assertion was issued for user that doesn't exist", zap.String("user-name", user))
+		return user, 0, ErrAuthFailed
+	}
+	return user, tx.UnsafeReadAuthRevision(), nil
+}
+
+func (as *authStore) SetAssertionVerifier(verifier *AssertionVerifier) {
+	as.assertionVerifier = verifier
+}
+
+func (as *authStore) dummyPasswordHash() []byte {
+	as.dummyPasswordOnce.Do(func() {
+		hashed, err := bcrypt.GenerateFromPassword([]byte("dummy password"), as.bcryptCost)
+		if err != nil {
+			as.lg.Panic("failed to hash dummy password", zap.Error(err))
+		}
+		as.dummyPassword = hashed
+	})
+	return as.dummyPassword
+}
+
 func (as *authStore) Recover(be AuthBackend) {
 	as.be = be
 	tx := be.ReadTx()
@@ -387,13 +562,19 @@ func (as *authStore) Recover(be AuthBackend) {
 	enabled := tx.UnsafeReadAuthEnabled()
 	as.setRevision(tx.UnsafeReadAuthRevision())
 	as.refreshRangePermCache(tx)
+	// Recover is called outside of apply, where backend batch tx can't be used.
+	users, roles := tx.UnsafeGetAllUsers(), tx.UnsafeGetAllRoles()
 
 	tx.Unlock()
 
+	as.quotas.refresh(roles)
+	as.history.reset(as.Revision(), users, roles)
+
 	as.enabledMu.Lock()
 	as.enabled = enabled
 	if enabled {
 		as.tokenProvider.enable()
+		registerAuthMetrics()
 	}
 	as.enabledMu.Unlock()
 }
@@ -465,6 +646,10 @@ func (as *authStore) UserDelete(r *pb.AuthUserDeleteRequest) (*pb.AuthUserDelete
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
+	if isImmutable(user) {
+		as.lg.Error("cannot delete immutable user", zap.String("user-name", r.Name))
+		return nil, ErrUserImmutable
+	}
 	tx.UnsafeDeleteUser(r.Name)
 
 	as.commitRevision(tx)
@@ -502,10 +687,11 @@ func (as *authStore) UserChangePassword(r *pb.AuthUserChangePasswordRequest) (*p
 	}
 
 	updatedUser := &authpb.User{
-		Name:     []byte(r.Name),
-		Roles:    user.Roles,
-		Password: password,
-		Options:  user.Options,
+		Name:       []byte(r.Name),
+		Roles:      user.Roles,
+		Password:   password,
+		Options:    user.Options,
+		RoleGrants: user.RoleGrants,
 	}
 	tx.UnsafePutUser(updatedUser)
 
@@ -523,6 +709,11 @@ func (as *authStore) UserChangePassword(r *pb.AuthUserChangePasswordRequest) (*p
 }
 
 func (as *authStore) UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error) {
+	return as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: r})
+}
+
+func (as *authStore) InternalUserGrantRole(ir *pb.InternalAuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error) {
+	r := ir.Grant
 	tx := as.be.BatchTx()
 	tx.Lock()
 	defer tx.Unlock()
@@ -537,10 +728,18 @@ func (as *authStore) UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUser
 		if role == nil {
 			return nil, ErrRoleNotFound
 		}
+	} else if r.User == rootUser && ir.ExpireTime != 0 {
+		as.lg.Error(
+			"'root' user cannot be granted 'root' role temporarily",
+			zap.String("user-name", r.User),
+			zap.String("role-name", r.Role),
+		)
+		return nil, ErrInvalidAuthMgmt
 	}
 
 	idx := sort.SearchStrings(user.Roles, r.Role)
-	if idx < len(user.Roles) && user.Roles[idx] == r.Role {
+	granted := idx < len(user.Roles) && user.Roles[idx] == r.Role
+	if granted && roleGrantExpireTime(user, r.Role) == 0 {
 		as.lg.Warn(
 			"ignored grant role request to a user",
 			zap.String("user-name", r.User),
@@ -550,8 +749,26 @@ func (as *authStore) UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUser
 		return &pb.AuthUserGrantRoleResponse{}, nil
 	}
 
-	user.Roles = append(user.Roles, r.Role)
-	sort.Strings(user.Roles)
+	if !granted {
+		if ir.MaxRoles > 0 && int64(len(user.Roles)) >= ir.MaxRoles {
+			as.lg.Warn(
+				"rejected grant role request to a user having maximum number of roles",
+				zap.String("user-name", r.User),
+				zap.Strings("user-roles", user.Roles),
+				zap.String("role-name", r.Role),
+				zap.Int64("max-roles", ir.MaxRoles),
+			)
+			return nil, ErrTooManyRoles
+		}
+		user.Roles = append(user.Roles, r.Role)
+		sort.Strings(user.Roles)
+	}
+
+	// Granting a temporary role again extends it, granting it permanently drops its expiration.
+	user.RoleGrants = removeRoleGrant(user.RoleGrants, r.Role)
+	if ir.ExpireTime != 0 {
+		user.RoleGrants = append(user.RoleGrants, &authpb.RoleGrant{Role: r.Role, ExpireTime: ir.ExpireTime})
+	}
 
 	tx.UnsafePutUser(user)
 
@@ -563,6 +780,7 @@ func (as *authStore) UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUser
 		zap.String("user-name", r.User),
 		zap.Strings("user-roles", user.Roles),
 		zap.String("added-role-name", r.Role),
+		zap.Int64("expire-time", ir.ExpireTime),
 	)
 	return &pb.AuthUserGrantRoleResponse{}, nil
 }
@@ -607,6 +825,14 @@ func (as *authStore) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUs
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
+	if isImmutable(user) {
+		as.lg.Error(
+			"cannot revoke a role from immutable user",
+			zap.String("user-name", r.Name),
+			zap.String("role-name", r.Role),
+		)
+		return nil, ErrUserImmutable
+	}
 
 	updatedUser := &authpb.User{
 		Name:     user.Name,
@@ -624,6 +850,8 @@ func (as *authStore) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUs
 		return nil, ErrRoleNotGranted
 	}
 
+	updatedUser.RoleGrants = removeRoleGrant(user.RoleGrants, r.Role)
+
 	tx.UnsafePutUser(updatedUser)
 
 	as.commitRevision(tx)
@@ -639,6 +867,123 @@ func (as *authStore) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUs
 	return &pb.AuthUserRevokeRoleResponse{}, nil
 }
 
+func (as *authStore) UserSetRoles(r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	return as.InternalUserSetRoles(&pb.InternalAuthUserSetRolesRequest{SetRoles: r})
+}
+
+func (as *authStore) InternalUserSetRoles(ir *pb.InternalAuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	r := ir.SetRoles
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := tx.UnsafeGetUser(r.User)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	var roles []string
+	for _, role := range r.Roles {
+		if role != rootRole && tx.UnsafeGetRole(role) == nil {
+			return nil, ErrRoleNotFound
+		}
+		if !containsRole(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	if as.enabled && r.User == rootUser && !containsRole(roles, rootRole) {
+		as.lg.Error(
+			"'root' user cannot revoke 'root' role",
+			zap.String("user-name", r.User),
+			zap.Strings("role-names", roles),
+		)
+		return nil, ErrInvalidAuthMgmt
+	}
+
+	if isImmutable(user) {
+		for _, role := range user.Roles {
+			if !containsRole(roles, role) {
+				as.lg.Error(
+					"cannot revoke a role from immutable user",
+					zap.String("user-name", r.User),
+					zap.String("role-name", role),
+				)
+				return nil, ErrUserImmutable
+			}
+		}
+	}
+
+	if ir.MaxRoles > 0 && int64(len(roles)) > ir.MaxRoles {
+		as.lg.Warn(
+			"rejected set roles request to a user exceeding maximum number of roles",
+			zap.String("user-name", r.User),
+			zap.Strings("role-names", roles),
+			zap.Int64("max-roles", ir.MaxRoles),
+		)
+		return nil, ErrTooManyRoles
+	}
+
+	updatedUser := &authpb.User{
+		Name:     user.Name,
+		Password: user.Password,
+		Options:  user.Options,
+		Roles:    roles,
+	}
+	// Temporary grants of roles the user keeps stay temporary, newly granted roles are permanent.
+	for _, grant := range user.RoleGrants {
+		if containsRole(roles, grant.Role) {
+			updatedUser.RoleGrants = append(updatedUser.RoleGrants, grant)
+		}
+	}
+
+	tx.UnsafePutUser(updatedUser)
+
+	as.commitRevision(tx)
+	as.refreshRangePermCache(tx)
+
+	as.lg.Info(
+		"set roles of a user",
+		zap.String("user-name", r.User),
+		zap.Strings("old-user-roles", user.Roles),
+		zap.Strings("new-user-roles", updatedUser.Roles),
+	)
+	return &pb.AuthUserSetRolesResponse{Roles: updatedUser.Roles, AuthRevision: as.Revision()}, nil
+}
+
+func (as *authStore) UserSetImmutable(r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := tx.UnsafeGetUser(r.Name)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	options := &authpb.UserAddOptions{Immutable: r.Immutable}
+	if user.Options != nil {
+		options.NoPassword = user.Options.NoPassword
+	}
+	user.Options = options
+	tx.UnsafePutUser(user)
+
+	as.commitRevision(tx)
+
+	as.lg.Info(
+		"set immutability of a user",
+		zap.String("user-name", r.Name),
+		zap.Bool("immutable", r.Immutable),
+	)
+	return &pb.AuthUserSetImmutableResponse{}, nil
+}
+
+// isImmutable returns true if the user is protected from deletion and role revocation.
+func isImmutable(user *authpb.User) bool {
+	return user.Options != nil && user.Options.Immutable
+}
+
 func (as *authStore) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse, error) {
 	var resp pb.AuthRoleGetResponse
 
@@ -651,6 +996,9 @@ func (as *authStore) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse,
 	} else {
 		resp.Perm = append(resp.Perm, role.KeyPermission...)
 	}
+	resp.QuotaBytes = role.QuotaBytes
+	resp.UsedBytes = as.quotas.usage(r.Role)
+	resp.AdminPrefix = role.AdminPrefix
 	return &resp, nil
 }
 
@@ -664,6 +1012,99 @@ func (as *authStore) RoleList(r *pb.AuthRoleListRequest) (*pb.AuthRoleListRespon
 	return resp, nil
 }
 
+func (as *authStore) UsersWithRole(r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error) {
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	if tx.UnsafeGetRole(r.Role) == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	resp := &pb.AuthUsersWithRoleResponse{AuthRevision: tx.UnsafeReadAuthRevision()}
+	for _, user := range tx.UnsafeGetAllUsers() {
+		for _, role := range user.Roles {
+			if role == r.Role {
+				resp.Users = append(resp.Users, string(user.Name))
+				break
+			}
+		}
+	}
+	return resp, nil
+}
+
+func (as *authStore) WhoAmI(authInfo *AuthInfo) (*pb.AuthWhoAmIResponse, error) {
+	if !as.IsAuthEnabled() {
+		// requests to cluster without auth are not authenticated, they get an anonymous identity
+		return &pb.AuthWhoAmIResponse{AuthRevision: as.Revision()}, nil
+	}
+	if authInfo == nil || authInfo.Username == "" {
+		return nil, ErrUserEmpty
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	resp := &pb.AuthWhoAmIResponse{AuthRevision: tx.UnsafeReadAuthRevision()}
+	user := tx.UnsafeGetUser(authInfo.Username)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	resp.Name = string(user.Name)
+	for _, roleName := range user.Roles {
+		resp.Roles = append(resp.Roles, roleName)
+		if roleName == rootRole {
+			continue
+		}
+		if role := tx.UnsafeGetRole(roleName); role != nil {
+			resp.Perms = append(resp.Perms, role.KeyPermission...)
+		}
+	}
+	return resp, nil
+}
+
+func (as *authStore) UserPermissionsAtRevision(r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	if len(r.Name) == 0 {
+		return nil, ErrUserEmpty
+	}
+
+	revision := r.AuthRevision
+	if revision == 0 {
+		revision = as.Revision()
+	}
+	if revision > as.Revision() {
+		return nil, ErrAuthFutureRevision
+	}
+
+	var snapshot authSnapshot
+	if revision == as.Revision() {
+		// current state is always available, even without retained history
+		snapshot = newAuthSnapshot(revision, as.be.GetAllUsers(), as.be.GetAllRoles())
+	} else {
+		var err error
+		if snapshot, err = as.history.at(revision); err != nil {
+			return nil, err
+		}
+	}
+
+	roles, ok := snapshot.userRoles[r.Name]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	// expired temporary role grants are revoked with a new auth revision,
+	// so roles of the user at the revision are exactly the ones stored
+	resp := &pb.AuthUserPermissionsAtRevisionResponse{AuthRevision: revision, Roles: roles}
+	for _, roleName := range roles {
+		if roleName == rootRole {
+			continue
+		}
+		resp.Perms = append(resp.Perms, snapshot.rolePerms[roleName]...)
+	}
+	return resp, nil
+}
+
 func (as *authStore) RoleRevokePermission(r *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error) {
 	tx := as.be.BatchTx()
 	tx.Lock()
@@ -674,24 +1115,24 @@ func (as *authStore) RoleRevokePermission(r *pb.AuthRoleRevokePermissionRequest)
 		return nil, ErrRoleNotFound
 	}
 
-	updatedRole := &authpb.Role{
-		Name: role.Name,
-	}
-
+	// only key permissions are filtered, so other fields of the role are kept as they are
+	var keyPermissions []*authpb.Permission
 	for _, perm := range role.KeyPermission {
 		if !bytes.Equal(perm.Key, r.Key) || !bytes.Equal(perm.RangeEnd, r.RangeEnd) {
-			updatedRole.KeyPermission = append(updatedRole.KeyPermission, perm)
+			keyPermissions = append(keyPermissions, perm)
 		}
 	}
 
-	if len(role.KeyPermission) == len(updatedRole.KeyPermission) {
+	if len(role.KeyPermission) == len(keyPermissions) {
 		return nil, ErrPermissionNotGranted
 	}
+	role.KeyPermission = keyPermissions
 
-	tx.UnsafePutRole(updatedRole)
+	tx.UnsafePutRole(role)
 
 	as.commitRevision(tx)
 	as.refreshRangePermCache(tx)
+	as.quotas.refreshRole(role)
 
 	as.lg.Info(
 		"revoked a permission on range",
@@ -717,9 +1158,20 @@ func (as *authStore) RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDelete
 		return nil, ErrRoleNotFound
 	}
 
+	users := tx.UnsafeGetAllUsers()
+	for _, user := range users {
+		if isImmutable(user) && containsRole(user.Roles, r.Role) {
+			as.lg.Error(
+				"cannot delete a role granted to immutable user",
+				zap.String("role-name", r.Role),
+				zap.ByteString("user-name", user.Name),
+			)
+			return nil, ErrUserImmutable
+		}
+	}
+
 	tx.UnsafeDeleteRole(r.Role)
 
-	users := tx.UnsafeGetAllUsers()
 	for _, user := range users {
 		updatedUser := &authpb.User{
 			Name:     user.Name,
@@ -737,17 +1189,76 @@ func (as *authStore) RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDelete
 			continue
 		}
 
+		updatedUser.RoleGrants = removeRoleGrant(user.RoleGrants, r.Role)
+
 		tx.UnsafePutUser(updatedUser)
 
 	}
 
 	as.commitRevision(tx)
 	as.refreshRangePermCache(tx)
+	as.quotas.remove(r.Role)
 
 	as.lg.Info("deleted a role", zap.String("role-name", r.Role))
 	return &pb.AuthRoleDeleteResponse{}, nil
 }
 
+func (as *authStore) RoleSetQuota(r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error) {
+	if r.QuotaBytes < 0 {
+		return nil, ErrInvalidAuthMgmt
+	}
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	role := tx.UnsafeGetRole(r.Role)
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	role.QuotaBytes = r.QuotaBytes
+	tx.UnsafePutRole(role)
+
+	as.commitRevision(tx)
+	as.quotas.refreshRole(role)
+
+	as.lg.Info(
+		"set storage quota of a role",
+		zap.String("role-name", r.Role),
+		zap.Int64("quota-bytes", r.QuotaBytes),
+	)
+	return &pb.AuthRoleSetQuotaResponse{}, nil
+}
+
+func (as *authStore) RoleSetAdminPrefix(r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	// root role is already an admin of all keys
+	if r.Role == rootRole {
+		return nil, ErrInvalidAuthMgmt
+	}
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	role := tx.UnsafeGetRole(r.Role)
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	role.AdminPrefix = r.Prefix
+	tx.UnsafePutRole(role)
+
+	as.commitRevision(tx)
+
+	as.lg.Info(
+		"set admin prefix of a role",
+		zap.String("role-name", r.Role),
+		zap.ByteString("admin-prefix", r.Prefix),
+	)
+	return &pb.AuthRoleSetAdminPrefixResponse{}, nil
+}
+
 func (as *authStore) RoleAdd(r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error) {
 	if len(r.Name) == 0 {
 		return nil, ErrRoleEmpty
@@ -799,6 +1310,9 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 	if !isValidPermissionRange(r.Perm.Key, r.Perm.RangeEnd) {
 		return nil, ErrInvalidAuthMgmt
 	}
+	if r.Perm.UserTemplate && !isValidPermissionTemplate(r.Perm.Key, r.Perm.RangeEnd) {
+		return nil, ErrInvalidAuthMgmt
+	}
 
 	tx := as.be.BatchTx()
 	tx.Lock()
@@ -816,12 +1330,14 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 	if idx < len(role.KeyPermission) && bytes.Equal(role.KeyPermission[idx].Key, r.Perm.Key) && bytes.Equal(role.KeyPermission[idx].RangeEnd, r.Perm.RangeEnd) {
 		// update existing permission
 		role.KeyPermission[idx].PermType = r.Perm.PermType
+		role.KeyPermission[idx].UserTemplate = r.Perm.UserTemplate
 	} else {
 		// append new permission to the role
 		newPerm := &authpb.Permission{
-			Key:      r.Perm.Key,
-			RangeEnd: r.Perm.RangeEnd,
-			PermType: r.Perm.PermType,
+			Key:          r.Perm.Key,
+			RangeEnd:     r.Perm.RangeEnd,
+			PermType:     r.Perm.PermType,
+			UserTemplate: r.Perm.UserTemplate,
 		}
 
 		role.KeyPermission = append(role.KeyPermission, newPerm)
@@ -832,6 +1348,7 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 
 	as.commitRevision(tx)
 	as.refreshRangePermCache(tx)
+	as.quotas.refreshRole(role)
 
 	as.lg.Info(
 		"granted/updated a permission to a user",
@@ -839,16 +1356,20 @@ func (as *authStore) RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (
 		zap.String("permission-name", authpb.Permission_Type_name[int32(r.Perm.PermType)]),
 		zap.ByteString("key", r.Perm.Key),
 		zap.ByteString("range-end", r.Perm.RangeEnd),
+		zap.Bool("user-template", r.Perm.UserTemplate),
 	)
 	return &pb.AuthRoleGrantPermissionResponse{}, nil
 }
 
-func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+func (as *authStore) isOpPermitted(metrics permissionCheckMetrics, authInfo *AuthInfo, key, rangeEnd []byte, permTyp authpb.Permission_Type) (err error) {
 	// TODO(mitake): this function would be costly so we need a caching mechanism
 	if !as.IsAuthEnabled() {
 		return nil
 	}
+	start := time.Now()
+	defer func() { metrics.observe(start, err) }()
 
+	userName, revision := authInfo.Username, authInfo.Revision
 	// only gets rev == 0 when passed AuthInfo{}; no user given
 	if revision == 0 {
 		return ErrUserEmpty
@@ -872,35 +1393,45 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 		as.lg.Error("cannot find a user for permission check", zap.String("user-name", userName))
 		return ErrPermissionDenied
 	}
+	active := withoutExpiredRoles(user, authInfo.Time)
 
 	// root role should have permission on all ranges
-	if hasRootRole(user) {
+	if hasRootRole(active) {
 		return nil
 	}
 
-	if as.isRangeOpPermitted(userName, key, rangeEnd, permTyp) {
-		return nil
+	if active == user {
+		if as.isRangeOpPermitted(userName, key, rangeEnd, permTyp) {
+			return nil
+		}
+		return ErrPermissionDenied
 	}
 
+	// cached permissions include roles whose grants expired, but weren't revoked yet
+	if checkRangePerm(as.lg, mergePerms(tx, active), key, rangeEnd, permTyp) {
+		return nil
+	}
 	return ErrPermissionDenied
 }
 
 func (as *authStore) IsPutPermitted(authInfo *AuthInfo, key []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, nil, authpb.WRITE)
+	return as.isOpPermitted(putPermissionCheck, authInfo, key, nil, authpb.WRITE)
 }
 
 func (as *authStore) IsRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.READ)
+	return as.isOpPermitted(rangePermissionCheck, authInfo, key, rangeEnd, authpb.READ)
 }
 
 func (as *authStore) IsDeleteRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.WRITE)
+	return as.isOpPermitted(deleteRangePermissionCheck, authInfo, key, rangeEnd, authpb.WRITE)
 }
 
-func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
+func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) (err error) {
 	if !as.IsAuthEnabled() {
 		return nil
 	}
+	start := time.Now()
+	defer func() { adminPermissionCheck.observe(start, err) }()
 	if authInfo == nil || authInfo.Username == "" {
 		return ErrUserEmpty
 	}
@@ -914,13 +1445,113 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 		return ErrUserNotFound
 	}
 
-	if !hasRootRole(u) {
+	if !hasRootRole(withoutExpiredRoles(u, authInfo.Time)) {
+		return ErrPermissionDenied
+	}
+
+	return nil
+}
+
+func (as *authStore) IsPermissionAdminPermitted(authInfo *AuthInfo, role string, key, rangeEnd []byte) error {
+	if bytes.Contains(key, userTemplatePlaceholder) {
+		// permission might be a user template, permitting keys of any user
+		key, rangeEnd = userTemplateBounds(key)
+	}
+	return as.isScopedAdminPermitted(authInfo, role, func(prefixes [][]byte, _ AuthReadTx) bool {
+		return isRangeWithinAdminPrefixes(prefixes, key, rangeEnd)
+	})
+}
+
+func (as *authStore) IsRoleAdminPermitted(authInfo *AuthInfo, role string) error {
+	return as.isScopedAdminPermitted(authInfo, role, func(prefixes [][]byte, tx AuthReadTx) bool {
+		r := tx.UnsafeGetRole(role)
+		return r != nil && isRoleWithinAdminPrefixes(prefixes, r)
+	})
+}
+
+// isScopedAdminPermitted permits admins, and admins scoped to prefixes for which withinScope returns true.
+// root role is never within scope, so scoped admins cannot grant themselves or anyone else admin of all keys.
+func (as *authStore) isScopedAdminPermitted(authInfo *AuthInfo, role string, withinScope func(prefixes [][]byte, tx AuthReadTx) bool) (err error) {
+	if !as.IsAuthEnabled() {
+		return nil
+	}
+	start := time.Now()
+	defer func() { adminPermissionCheck.observe(start, err) }()
+	if authInfo == nil || authInfo.Username == "" {
+		return ErrUserEmpty
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+	u := tx.UnsafeGetUser(authInfo.Username)
+
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u = withoutExpiredRoles(u, authInfo.Time)
+
+	if hasRootRole(u) {
+		return nil
+	}
+	if role == rootRole {
+		return ErrPermissionDenied
+	}
+
+	prefixes := adminPrefixes(tx, u)
+	if len(prefixes) == 0 || !withinScope(prefixes, tx) {
 		return ErrPermissionDenied
 	}
 
 	return nil
 }
 
+func (as *authStore) IsPutWithinQuota(authInfo *AuthInfo, puts map[string]int64) error {
+	if !as.IsAuthEnabled() || len(puts) == 0 {
+		return nil
+	}
+
+	tx := as.be.ReadTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := tx.UnsafeGetUser(authInfo.Username)
+	if user == nil {
+		return nil
+	}
+	user = withoutExpiredRoles(user, authInfo.Time)
+
+	if hasRootRole(user) {
+		return nil
+	}
+
+	for _, role := range user.Roles {
+		if as.quotas.exceeded(role, puts) {
+			as.lg.Warn(
+				"put exceeds storage quota of a role",
+				zap.String("user-name", authInfo.Username),
+				zap.String("role-name", role),
+			)
+			return ErrRoleQuotaExceeded
+		}
+	}
+	return nil
+}
+
+func (as *authStore) SetKeySizeRanger(ranger KeySizeRanger) {
+	as.quotas.setRanger(ranger)
+
+	as.quotas.refresh(as.be.GetAllRoles())
+}
+
+func (as *authStore) SetHistoryRetention(retention int) {
+	as.history.setRetention(retention, as.Revision(), as.be)
+}
+
+func (as *authStore) ObserveWrites(evs []mvccpb.Event) {
+	as.quotas.observe(evs)
+}
+
 func (as *authStore) IsAuthEnabled() bool {
 	as.enabledMu.RLock()
 	defer as.enabledMu.RUnlock()
@@ -956,12 +1587,15 @@ func NewAuthStore(lg *zap.Logger, be AuthBackend, tp TokenProvider, bcryptCost i
 		be:             be,
 		enabled:        enabled,
 		rangePermCache: make(map[string]*unifiedRangePermissions),
+		quotas:         newQuotaTracker(),
+		history:        newAuthHistory(),
 		tokenProvider:  tp,
 		bcryptCost:     bcryptCost,
 	}
 
 	if enabled {
 		as.tokenProvider.enable()
+		registerAuthMetrics()
 	}
 
 	if as.Revision() == 0 {
@@ -984,9 +1618,69 @@ func hasRootRole(u *authpb.User) bool {
 	return idx != len(u.Roles) && u.Roles[idx] == rootRole
 }
 
+// roleGrantExpireTime returns unix time at which the role grant of the user expires, zero if it doesn't expire.
+func roleGrantExpireTime(u *authpb.User, role string) int64 {
+	for _, grant := range u.RoleGrants {
+		if grant.Role == role {
+			return grant.ExpireTime
+		}
+	}
+	return 0
+}
+
+func roleGrantExpired(u *authpb.User, role string, now time.Time) bool {
+	expireTime := roleGrantExpireTime(u, role)
+	return expireTime != 0 && expireTime <= now.Unix()
+}
+
+func hasExpiredRoleGrant(u *authpb.User, now time.Time) bool {
+	for _, grant := range u.RoleGrants {
+		if grant.ExpireTime <= now.Unix() {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExpiredRoles returns a copy of the user without roles whose grants expired at unix time now,
+// or the user itself if none did. Expired grants are ignored this way until the leader revokes them.
+func withoutExpiredRoles(u *authpb.User, now int64) *authpb.User {
+	if now == 0 || !hasExpiredRoleGrant(u, time.Unix(now, 0)) {
+		return u
+	}
+	active := *u
+	active.Roles = nil
+	for _, role := range u.Roles {
+		if !roleGrantExpired(u, role, time.Unix(now, 0)) {
+			active.Roles = append(active.Roles, role)
+		}
+	}
+	return &active
+}
+
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func removeRoleGrant(grants []*authpb.RoleGrant, role string) []*authpb.RoleGrant {
+	var updated []*authpb.RoleGrant
+	for _, grant := range grants {
+		if grant.Role != role {
+			updated = append(updated, grant)
+		}
+	}
+	return updated
+}
+
 func (as *authStore) commitRevision(tx AuthBatchTx) {
 	atomic.AddUint64(&as.revision, 1)
 	tx.UnsafeSaveAuthRevision(as.Revision())
+	as.history.record(as.Revision(), tx)
 }
 
 func (as *authStore) setRevision(rev uint64) {
@@ -1111,7 +1805,8 @@ func NewTokenProvider(
 	lg *zap.Logger,
 	tokenOpts string,
 	indexWaiter func(uint64) <-chan struct{},
-	TokenTTL time.Duration) (TokenProvider, error) {
+	TokenTTL time.Duration,
+	maxSimpleTokens int) (TokenProvider, error) {
 	tokenType, typeSpecificOpts, err := decomposeOpts(lg, tokenOpts)
 	if err != nil {
 		return nil, ErrInvalidAuthOpts
@@ -1122,7 +1817,7 @@ func NewTokenProvider(
 		if lg != nil {
 			lg.Warn("simple token is not cryptographically signed")
 		}
-		return newTokenProviderSimple(lg, indexWaiter, TokenTTL), nil
+		return newTokenProviderSimple(lg, indexWaiter, TokenTTL, maxSimpleTokens), nil
 
 	case tokenTypeJWT:
 		return newTokenProviderJWT(lg, typeSpecificOpts)
@@ -1205,6 +1900,80 @@ func (as *authStore) HasRole(user, role string) bool {
 	return false
 }
 
+func (as *authStore) IsRoleGranted(authInfo *AuthInfo, role string) bool {
+	tx := as.be.ReadTx()
+	tx.Lock()
+	u := tx.UnsafeGetUser(authInfo.Username)
+	tx.Unlock()
+
+	if u == nil {
+		return false
+	}
+	return containsRole(withoutExpiredRoles(u, authInfo.Time).Roles, role)
+}
+
+func (as *authStore) HasExpiredRoleGrants(now time.Time) bool {
+	tx := as.be.ReadTx()
+	tx.Lock()
+	users := tx.UnsafeGetAllUsers()
+	tx.Unlock()
+
+	for _, u := range users {
+		if hasExpiredRoleGrant(u, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (as *authStore) RevokeExpiredRoleGrants(r *pb.InternalAuthRevokeExpiredRoleGrantsRequest) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	now := time.Unix(r.Now, 0)
+	revoked := false
+	for _, user := range tx.UnsafeGetAllUsers() {
+		if !hasExpiredRoleGrant(user, now) {
+			continue
+		}
+		updatedUser := &authpb.User{
+			Name:     user.Name,
+			Password: user.Password,
+			Options:  user.Options,
+		}
+		var expiredRoles []string
+		for _, role := range user.Roles {
+			if roleGrantExpired(user, role, now) {
+				expiredRoles = append(expiredRoles, role)
+				continue
+			}
+			updatedUser.Roles = append(updatedUser.Roles, role)
+		}
+		for _, grant := range user.RoleGrants {
+			if grant.ExpireTime > r.Now {
+				updatedUser.RoleGrants = append(updatedUser.RoleGrants, grant)
+			}
+		}
+		tx.UnsafePutUser(updatedUser)
+		revoked = true
+
+		as.lg.Info(
+			"revoked expired roles from a user",
+			zap.ByteString("user-name", user.Name),
+			zap.Strings("old-user-roles", user.Roles),
+			zap.Strings("new-user-roles", updatedUser.Roles),
+			zap.Strings("revoked-role-names", expiredRoles),
+		)
+	}
+
+	if !revoked {
+		return
+	}
+	as.commitRevision(tx)
+	as.refreshRangePermCache(tx)
+}
+
 func (as *authStore) BcryptCost() int {
 	return as.bcryptCost
 }
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 
 	"golang.org/x/crypto/bcrypt"
@@ -47,7 +48,7 @@ func dummyIndexWaiter(index uint64) <-chan struct{} {
 // TestNewAuthStoreRevision ensures newly auth store
 // keeps the old revision when there are no changes.
 func TestNewAuthStoreRevision(t *testing.T) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -72,7 +73,7 @@ func TestNewAuthStoreRevision(t *testing.T) {
 
 // TestNewAuthStoreBcryptCost ensures that NewAuthStore uses default when given bcrypt-cost is invalid
 func TestNewAuthStoreBcryptCost(t *testing.T) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -93,7 +94,7 @@ func encodePassword(s string) string {
 }
 
 func setupAuthStore(t *testing.T) (store *authStore, teardownfunc func(t *testing.T)) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -248,6 +249,45 @@ func TestCheckPassword(t *testing.T) {
 	}
 }
 
+func TestVerifyPassword(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.UserAdd(&pb.AuthUserAddRequest{Name: "no-password", Options: &authpb.UserAddOptions{NoPassword: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name      string
+		user      string
+		password  string
+		wantValid bool
+		wantErr   error
+	}{
+		{name: "correct password", user: "foo", password: "bar", wantValid: true},
+		{name: "wrong password", user: "foo", password: ""},
+		{name: "non-existing user", user: "foo-test", password: "bar", wantErr: ErrUserNotFound},
+		{name: "user without password", user: "no-password", password: "", wantErr: ErrNoPasswordUser},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := as.VerifyPassword(tc.user, tc.password)
+			if err != tc.wantErr {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if valid != tc.wantValid {
+				t.Errorf("expected valid %v, got %v", tc.wantValid, valid)
+			}
+		})
+	}
+
+	as.AuthDisable()
+	if _, err = as.VerifyPassword("foo", "bar"); err != ErrAuthNotEnabled {
+		t.Fatalf("expected %v, got %v", ErrAuthNotEnabled, err)
+	}
+}
+
 func TestUserDelete(t *testing.T) {
 	as, tearDown := setupAuthStore(t)
 	defer tearDown(t)
@@ -446,7 +486,7 @@ func TestIsOpPermitted(t *testing.T) {
 
 	// check permission reflected to user
 
-	err = as.isOpPermitted("foo", as.Revision(), perm.Key, perm.RangeEnd, perm.PermType)
+	err = as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, perm.RangeEnd, perm.PermType)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -456,12 +496,188 @@ func TestIsOpPermitted(t *testing.T) {
 	as.rangePermCacheMu.Lock()
 	delete(as.rangePermCache, "foo")
 	as.rangePermCacheMu.Unlock()
-	if err := as.isOpPermitted("foo", as.Revision(), perm.Key, perm.RangeEnd, perm.PermType); err != ErrPermissionDenied {
+	if err := as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, perm.RangeEnd, perm.PermType); err != ErrPermissionDenied {
 		t.Fatal(err)
 	}
 
 }
 
+func TestUserGrantRoleWithExpireTime(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	perm := &authpb.Permission{PermType: authpb.WRITE, Key: []byte("foo")}
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "role-test", Perm: perm})
+	require.NoError(t, err)
+
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}, ExpireTime: 1010})
+	require.NoError(t, err)
+	assert.True(t, as.HasRole("foo", "role-test"))
+	assert.NoError(t, as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, nil, perm.PermType))
+	assert.False(t, as.HasExpiredRoleGrants(time.Unix(1000, 0)))
+
+	// expired grant is ignored by checks at the time of the request, before it is revoked
+	now := time.Unix(1010, 0)
+	assert.True(t, as.HasExpiredRoleGrants(now))
+	assert.True(t, as.HasRole("foo", "role-test"))
+	assert.NoError(t, as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision(), Time: 1009}, perm.Key, nil, perm.PermType))
+	assert.Equal(t, ErrPermissionDenied, as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision(), Time: now.Unix()}, perm.Key, nil, perm.PermType))
+	assert.True(t, as.IsRoleGranted(&AuthInfo{Username: "foo", Time: 1009}, "role-test"))
+	assert.False(t, as.IsRoleGranted(&AuthInfo{Username: "foo", Time: now.Unix()}, "role-test"))
+	// requests proposed without time keep being permitted until the grant is revoked
+	assert.NoError(t, as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, nil, perm.PermType))
+
+	as.RevokeExpiredRoleGrants(&pb.InternalAuthRevokeExpiredRoleGrantsRequest{Now: now.Unix()})
+	assert.False(t, as.HasExpiredRoleGrants(now))
+	resp, err := as.UserGet(&pb.AuthUserGetRequest{Name: "foo"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Roles)
+	assert.Equal(t, ErrPermissionDenied, as.isOpPermitted(rangePermissionCheck, &AuthInfo{Username: "foo", Revision: as.Revision()}, perm.Key, nil, perm.PermType))
+
+	// granting the role permanently drops its expiration
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}, ExpireTime: 1020})
+	require.NoError(t, err)
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	require.NoError(t, err)
+	now = time.Unix(1030, 0)
+	assert.False(t, as.HasExpiredRoleGrants(now))
+	as.RevokeExpiredRoleGrants(&pb.InternalAuthRevokeExpiredRoleGrantsRequest{Now: now.Unix()})
+	assert.True(t, as.HasRole("foo", "role-test"))
+
+	// root role cannot be granted to root user temporarily
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "root", Role: "root"}, ExpireTime: 1040})
+	assert.Equal(t, ErrInvalidAuthMgmt, err)
+
+	// expired root role no longer permits admin requests
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "root"}, ExpireTime: 1040})
+	require.NoError(t, err)
+	assert.NoError(t, as.IsAdminPermitted(&AuthInfo{Username: "foo", Revision: as.Revision(), Time: 1039}))
+	assert.Equal(t, ErrPermissionDenied, as.IsAdminPermitted(&AuthInfo{Username: "foo", Revision: as.Revision(), Time: 1040}))
+}
+
+func TestUserGrantRoleWithMaxRoles(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: "role-test-1"})
+	require.NoError(t, err)
+
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}, MaxRoles: 1})
+	require.NoError(t, err)
+	// granting already granted role doesn't count towards the limit
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}, MaxRoles: 1})
+	require.NoError(t, err)
+	_, err = as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test-1"}, MaxRoles: 1})
+	assert.Equal(t, ErrTooManyRoles, err)
+	assert.False(t, as.HasRole("foo", "role-test-1"))
+
+	// zero means unlimited
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test-1"})
+	require.NoError(t, err)
+	assert.True(t, as.HasRole("foo", "role-test-1"))
+}
+
+func TestUserSetRoles(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	for _, role := range []string{"role-test-1", "role-test-2"} {
+		_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: role})
+		require.NoError(t, err)
+	}
+	_, err := as.InternalUserGrantRole(&pb.InternalAuthUserGrantRoleRequest{Grant: &pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"}, ExpireTime: 1010})
+	require.NoError(t, err)
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test-1"})
+	require.NoError(t, err)
+
+	// granting missing and revoking extra roles is a single auth revision
+	rev := as.Revision()
+	resp, err := as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "foo", Roles: []string{"role-test-2", "role-test", "role-test-2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role-test", "role-test-2"}, resp.Roles)
+	assert.Equal(t, rev+1, resp.AuthRevision)
+	assert.Equal(t, as.Revision(), resp.AuthRevision)
+	assert.False(t, as.HasRole("foo", "role-test-1"))
+	assert.True(t, as.HasRole("foo", "role-test-2"))
+
+	// kept temporary grant still expires
+	assert.True(t, as.HasExpiredRoleGrants(time.Unix(1010, 0)))
+
+	// any missing role leaves the user unchanged
+	_, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "foo", Roles: []string{"role-test-1", "norole"}})
+	assert.Equal(t, ErrRoleNotFound, err)
+	u, err := as.UserGet(&pb.AuthUserGetRequest{Name: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role-test", "role-test-2"}, u.Roles)
+
+	_, err = as.InternalUserSetRoles(&pb.InternalAuthUserSetRolesRequest{SetRoles: &pb.AuthUserSetRolesRequest{User: "foo", Roles: []string{"role-test", "role-test-1", "role-test-2"}}, MaxRoles: 2})
+	assert.Equal(t, ErrTooManyRoles, err)
+
+	_, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "nouser", Roles: []string{"role-test"}})
+	assert.Equal(t, ErrUserNotFound, err)
+
+	// 'root' user cannot lose 'root' role while auth is enabled
+	_, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "root", Roles: []string{"role-test"}})
+	assert.Equal(t, ErrInvalidAuthMgmt, err)
+	assert.True(t, as.HasRole("root", "root"))
+
+	resp, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "foo"})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Roles)
+	assert.False(t, as.HasRole("foo", "role-test-2"))
+}
+
+func TestUserSetImmutable(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	for _, role := range []string{"role-test-1", "role-test-2"} {
+		_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: role})
+		require.NoError(t, err)
+	}
+	_, err := as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test-1"})
+	require.NoError(t, err)
+
+	rev := as.Revision()
+	_, err = as.UserSetImmutable(&pb.AuthUserSetImmutableRequest{Name: "foo", Immutable: true})
+	require.NoError(t, err)
+	assert.Equal(t, rev+1, as.Revision())
+
+	_, err = as.UserDelete(&pb.AuthUserDeleteRequest{Name: "foo"})
+	assert.Equal(t, ErrUserImmutable, err)
+	_, err = as.UserRevokeRole(&pb.AuthUserRevokeRoleRequest{Name: "foo", Role: "role-test-1"})
+	assert.Equal(t, ErrUserImmutable, err)
+	_, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "foo", Roles: []string{"role-test-2"}})
+	assert.Equal(t, ErrUserImmutable, err)
+	_, err = as.RoleDelete(&pb.AuthRoleDeleteRequest{Role: "role-test-1"})
+	assert.Equal(t, ErrUserImmutable, err)
+	assert.True(t, as.HasRole("foo", "role-test-1"))
+	assert.False(t, as.HasRole("foo", "role-test-2"))
+
+	// granting roles and changing password stay permitted
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test-2"})
+	require.NoError(t, err)
+	_, err = as.UserSetRoles(&pb.AuthUserSetRolesRequest{User: "foo", Roles: []string{"role-test", "role-test-1", "role-test-2"}})
+	require.NoError(t, err)
+	assert.True(t, as.HasRole("foo", "role-test"))
+	_, err = as.UserChangePassword(&pb.AuthUserChangePasswordRequest{Name: "foo", HashedPassword: encodePassword("baz")})
+	require.NoError(t, err)
+	_, err = as.UserDelete(&pb.AuthUserDeleteRequest{Name: "foo"})
+	assert.Equal(t, ErrUserImmutable, err)
+
+	_, err = as.UserSetImmutable(&pb.AuthUserSetImmutableRequest{Name: "nouser", Immutable: true})
+	assert.Equal(t, ErrUserNotFound, err)
+
+	_, err = as.UserSetImmutable(&pb.AuthUserSetImmutableRequest{Name: "foo"})
+	require.NoError(t, err)
+	_, err = as.UserRevokeRole(&pb.AuthUserRevokeRoleRequest{Name: "foo", Role: "role-test"})
+	require.NoError(t, err)
+	_, err = as.RoleDelete(&pb.AuthRoleDeleteRequest{Role: "role-test-1"})
+	require.NoError(t, err)
+	_, err = as.UserDelete(&pb.AuthUserDeleteRequest{Name: "foo"})
+	require.NoError(t, err)
+}
+
 func TestGetUser(t *testing.T) {
 	as, tearDown := setupAuthStore(t)
 	defer tearDown(t)
@@ -511,6 +727,183 @@ func TestListUsers(t *testing.T) {
 	}
 }
 
+func TestUsersWithRole(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := as.UsersWithRole(&pb.AuthUsersWithRoleRequest{Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"foo"}, resp.Users)
+	assert.Equal(t, as.Revision(), resp.AuthRevision)
+
+	// check non existent role
+	_, err = as.UsersWithRole(&pb.AuthUsersWithRoleRequest{Role: "norole"})
+	if !errors.Is(err, ErrRoleNotFound) {
+		t.Errorf("expected %v, got %v", ErrRoleNotFound, err)
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	perm := &authpb.Permission{PermType: authpb.READ, Key: []byte("foo"), RangeEnd: []byte("fop")}
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "role-test", Perm: perm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := as.WhoAmI(&AuthInfo{Username: "foo", Revision: as.Revision()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "foo", resp.Name)
+	assert.Equal(t, []string{"role-test"}, resp.Roles)
+	assert.Equal(t, []*authpb.Permission{perm}, resp.Perms)
+	assert.Equal(t, as.Revision(), resp.AuthRevision)
+
+	// root role grants all permissions without listing them
+	resp, err = as.WhoAmI(&AuthInfo{Username: "root", Revision: as.Revision()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"root"}, resp.Roles)
+	assert.Empty(t, resp.Perms)
+
+	_, err = as.WhoAmI(&AuthInfo{})
+	if !errors.Is(err, ErrUserEmpty) {
+		t.Errorf("expected %v, got %v", ErrUserEmpty, err)
+	}
+	_, err = as.WhoAmI(&AuthInfo{Username: "nouser", Revision: as.Revision()})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected %v, got %v", ErrUserNotFound, err)
+	}
+
+	// requests to cluster without auth get an anonymous identity
+	as.AuthDisable()
+	resp, err = as.WhoAmI(&AuthInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, resp.Name)
+	assert.Empty(t, resp.Roles)
+	assert.Equal(t, as.Revision(), resp.AuthRevision)
+}
+
+func TestUserPermissionsAtRevision(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+	as.SetHistoryRetention(3)
+
+	perm := &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("foo"), RangeEnd: []byte("fop")}
+	_, err := as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "role-test", Perm: perm})
+	if err != nil {
+		t.Fatal(err)
+	}
+	beforeGrant := as.Revision()
+	_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted := as.Revision()
+	_, err = as.UserRevokeRole(&pb.AuthUserRevokeRoleRequest{Name: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: granted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"role-test"}, resp.Roles)
+	assert.Equal(t, []*authpb.Permission{perm}, resp.Perms)
+	assert.Equal(t, granted, resp.AuthRevision)
+
+	resp, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: beforeGrant})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, resp.Roles)
+	assert.Empty(t, resp.Perms)
+
+	// zero revision queries the current one
+	resp, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, resp.Roles)
+	assert.Equal(t, as.Revision(), resp.AuthRevision)
+
+	// permissions changed after the grant are not visible at its revision
+	_, err = as.RoleRevokePermission(&pb.AuthRoleRevokePermissionRequest{Role: "role-test", Key: []byte("foo"), RangeEnd: []byte("fop")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: granted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*authpb.Permission{perm}, resp.Perms)
+
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: as.Revision() + 1})
+	if !errors.Is(err, ErrAuthFutureRevision) {
+		t.Errorf("expected %v, got %v", ErrAuthFutureRevision, err)
+	}
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "nouser", AuthRevision: granted})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected %v, got %v", ErrUserNotFound, err)
+	}
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{AuthRevision: granted})
+	if !errors.Is(err, ErrUserEmpty) {
+		t.Errorf("expected %v, got %v", ErrUserEmpty, err)
+	}
+
+	// only the last 3 auth revisions are retained
+	_, err = as.RoleAdd(&pb.AuthRoleAddRequest{Name: "role-test-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: granted})
+	if !errors.Is(err, ErrAuthRevisionNotRetained) {
+		t.Errorf("expected %v, got %v", ErrAuthRevisionNotRetained, err)
+	}
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: as.Revision() - 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserPermissionsAtRevisionWithoutRetention(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	_, err := as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: "foo", Role: "role-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: as.Revision()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"role-test"}, resp.Roles)
+
+	_, err = as.UserPermissionsAtRevision(&pb.AuthUserPermissionsAtRevisionRequest{Name: "foo", AuthRevision: as.Revision() - 1})
+	if !errors.Is(err, ErrAuthRevisionNotRetained) {
+		t.Errorf("expected %v, got %v", ErrAuthRevisionNotRetained, err)
+	}
+}
+
 func TestRoleGrantPermission(t *testing.T) {
 	as, tearDown := setupAuthStore(t)
 	defer tearDown(t)
@@ -927,6 +1320,34 @@ func TestAuthDisable(t *testing.T) {
 	}
 }
 
+func TestAuthEnableRequiresRoot(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	as.AuthDisable()
+	_, err := as.UserRevokeRole(&pb.AuthUserRevokeRoleRequest{Name: "root", Role: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = as.AuthEnable(); err != ErrRootRoleNotExist {
+		t.Errorf("expected %v, got %v", ErrRootRoleNotExist, err)
+	}
+	if as.IsAuthEnabled() {
+		t.Error("expected auth to stay disabled when root user does not have root role")
+	}
+
+	_, err = as.UserDelete(&pb.AuthUserDeleteRequest{Name: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = as.AuthEnable(); err != ErrRootUserNotExist {
+		t.Errorf("expected %v, got %v", ErrRootUserNotExist, err)
+	}
+	if as.IsAuthEnabled() {
+		t.Error("expected auth to stay disabled when root user does not exist")
+	}
+}
+
 func TestIsAuthEnabled(t *testing.T) {
 	as, tearDown := setupAuthStore(t)
 	defer tearDown(t)
@@ -953,7 +1374,7 @@ func TestIsAuthEnabled(t *testing.T) {
 
 // TestAuthInfoFromCtxRace ensures that access to authStore.revision is thread-safe.
 func TestAuthInfoFromCtxRace(t *testing.T) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1026,7 +1447,7 @@ func TestRecoverFromSnapshot(t *testing.T) {
 
 	as.Close()
 
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1103,7 +1524,7 @@ func TestHammerSimpleAuthenticate(t *testing.T) {
 
 // TestRolesOrder tests authpb.User.Roles is sorted
 func TestRolesOrder(t *testing.T) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	defer tp.disable()
 	if err != nil {
 		t.Fatal(err)
@@ -1157,7 +1578,7 @@ func TestAuthInfoFromCtxWithRootJWT(t *testing.T) {
 
 // testAuthInfoFromCtxWithRoot ensures "WithRoot" properly embeds token in the context.
 func testAuthInfoFromCtxWithRoot(t *testing.T, opts string) {
-	tp, err := NewTokenProvider(zaptest.NewLogger(t), opts, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, err := NewTokenProvider(zaptest.NewLogger(t), opts, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
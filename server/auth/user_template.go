@@ -0,0 +1,93 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+)
+
+// A permission with user template has key and range end in which the placeholder is replaced
+// by name of the user the permission is checked for, so a single role can grant each user
+// access to its own keys, for example prefix "/users/{user}/" permits user "alice" keys with
+// prefix "/users/alice/".
+//
+// The placeholder appears exactly once in the key and, for a range, exactly once in the range
+// end after the same bytes as in the key. Substitution is done once, so a user name containing
+// the placeholder is not expanded again. If the placeholder is followed by other bytes, the
+// first of them separates the user name from the rest of the key and users whose name contains
+// it are not granted the permission. Otherwise user "alice/x" would be granted keys of prefix
+// "/users/alice/x/", which is within keys of user "alice".
+
+// userTemplatePlaceholder is replaced by the user name in permissions with user template.
+var userTemplatePlaceholder = []byte("{user}")
+
+// isValidPermissionTemplate returns whether key and rangeEnd form a valid user template.
+func isValidPermissionTemplate(key, rangeEnd []byte) bool {
+	if bytes.Count(key, userTemplatePlaceholder) != 1 {
+		return false
+	}
+	if len(rangeEnd) == 0 {
+		return true
+	}
+	if bytes.Count(rangeEnd, userTemplatePlaceholder) != 1 {
+		return false
+	}
+	idx := bytes.Index(key, userTemplatePlaceholder)
+	// range must stay within keys of a single user, so placeholder is followed by a separator
+	// and range end shares everything before the placeholder with the key
+	return idx+len(userTemplatePlaceholder) < len(key) && bytes.HasPrefix(rangeEnd, key[:idx+len(userTemplatePlaceholder)])
+}
+
+// expandUserTemplate returns the permission with user template resolved for the user, or false
+// if the user is not granted the permission. Permissions without user template are returned unchanged.
+func expandUserTemplate(perm *authpb.Permission, userName string) (*authpb.Permission, bool) {
+	if !perm.UserTemplate {
+		return perm, true
+	}
+	idx := bytes.Index(perm.Key, userTemplatePlaceholder)
+	if idx < 0 || len(userName) == 0 || (len(perm.RangeEnd) != 0 && !bytes.Contains(perm.RangeEnd, userTemplatePlaceholder)) {
+		return nil, false
+	}
+	if separator := idx + len(userTemplatePlaceholder); separator < len(perm.Key) && strings.IndexByte(userName, perm.Key[separator]) >= 0 {
+		return nil, false
+	}
+	expanded := &authpb.Permission{PermType: perm.PermType, Key: substituteUser(perm.Key, userName)}
+	if len(perm.RangeEnd) != 0 {
+		expanded.RangeEnd = substituteUser(perm.RangeEnd, userName)
+	}
+	return expanded, true
+}
+
+// substituteUser replaces the first placeholder in template by the user name.
+func substituteUser(template []byte, userName string) []byte {
+	idx := bytes.Index(template, userTemplatePlaceholder)
+	expanded := make([]byte, 0, len(template)-len(userTemplatePlaceholder)+len(userName))
+	expanded = append(expanded, template[:idx]...)
+	expanded = append(expanded, userName...)
+	return append(expanded, template[idx+len(userTemplatePlaceholder):]...)
+}
+
+// userTemplateBounds returns the range covering keys the template permits to any user, that is
+// all keys sharing the part of the key before the placeholder.
+func userTemplateBounds(key []byte) (begin, end []byte) {
+	begin = key
+	if idx := bytes.Index(key, userTemplatePlaceholder); idx >= 0 {
+		begin = key[:idx]
+	}
+	return begin, prefixRangeEnd(begin)
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/etcd/api/v3/authpb"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestIsValidPermissionTemplate(t *testing.T) {
+	tcs := []struct {
+		name     string
+		key      string
+		rangeEnd string
+		expect   bool
+	}{
+		{name: "single key", key: "/config/{user}", expect: true},
+		{name: "prefix", key: "/users/{user}/", rangeEnd: "/users/{user}0", expect: true},
+		{name: "no placeholder", key: "/users/", rangeEnd: "/users0", expect: false},
+		{name: "placeholder repeated", key: "/{user}/{user}", expect: false},
+		{name: "range end without placeholder", key: "/users/{user}/", rangeEnd: "/users0", expect: false},
+		{name: "open ended range", key: "/users/{user}/", rangeEnd: "\x00", expect: false},
+		{name: "range without separator", key: "/users/{user}", rangeEnd: "/users/{user~", expect: false},
+		{name: "range end leaving user keys", key: "/users/{user}/", rangeEnd: "/v/{user}0", expect: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, isValidPermissionTemplate([]byte(tc.key), []byte(tc.rangeEnd)))
+		})
+	}
+}
+
+func TestExpandUserTemplate(t *testing.T) {
+	prefix := &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/users/{user}/"), RangeEnd: []byte("/users/{user}0"), UserTemplate: true}
+	tcs := []struct {
+		name     string
+		perm     *authpb.Permission
+		user     string
+		expect   *authpb.Permission
+		excluded bool
+	}{
+		{
+			name:   "permission without template",
+			perm:   &authpb.Permission{Key: []byte("/users/{user}/")},
+			user:   "alice",
+			expect: &authpb.Permission{Key: []byte("/users/{user}/")},
+		},
+		{
+			name:   "prefix",
+			perm:   prefix,
+			user:   "alice",
+			expect: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/users/alice/"), RangeEnd: []byte("/users/alice0")},
+		},
+		{
+			name:   "single key without separator",
+			perm:   &authpb.Permission{Key: []byte("/config/{user}"), UserTemplate: true},
+			user:   "alice/x",
+			expect: &authpb.Permission{Key: []byte("/config/alice/x")},
+		},
+		{
+			name:   "user name containing placeholder is not expanded again",
+			perm:   prefix,
+			user:   "{user}",
+			expect: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/users/{user}/"), RangeEnd: []byte("/users/{user}0")},
+		},
+		{
+			name:     "user name containing separator",
+			perm:     prefix,
+			user:     "alice/x",
+			excluded: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			perm, ok := expandUserTemplate(tc.perm, tc.user)
+			assert.Equal(t, !tc.excluded, ok)
+			assert.Equal(t, tc.expect, perm)
+		})
+	}
+}
+
+func TestUserTemplatePermission(t *testing.T) {
+	as, tearDown := setupAuthStore(t)
+	defer tearDown(t)
+
+	for _, user := range []string{"alice", "alice/x"} {
+		_, err := as.UserAdd(&pb.AuthUserAddRequest{Name: user, HashedPassword: encodePassword("pwd"), Options: &authpb.UserAddOptions{NoPassword: false}})
+		require.NoError(t, err)
+	}
+	_, err := as.RoleAdd(&pb.AuthRoleAddRequest{Name: "users"})
+	require.NoError(t, err)
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "users", Perm: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/users/"), RangeEnd: []byte("/users0"), UserTemplate: true}})
+	assert.Equal(t, ErrInvalidAuthMgmt, err)
+	_, err = as.RoleGrantPermission(&pb.AuthRoleGrantPermissionRequest{Name: "users", Perm: &authpb.Permission{PermType: authpb.READWRITE, Key: []byte("/users/{user}/"), RangeEnd: []byte("/users/{user}0"), UserTemplate: true}})
+	require.NoError(t, err)
+	for _, user := range []string{"foo", "alice", "alice/x"} {
+		_, err = as.UserGrantRole(&pb.AuthUserGrantRoleRequest{User: user, Role: "users"})
+		require.NoError(t, err)
+	}
+
+	foo := &AuthInfo{Username: "foo", Revision: as.Revision()}
+	assert.NoError(t, as.IsPutPermitted(foo, []byte("/users/foo/a")))
+	assert.NoError(t, as.IsRangePermitted(foo, []byte("/users/foo/"), []byte("/users/foo0")))
+	assert.Equal(t, ErrPermissionDenied, as.IsPutPermitted(foo, []byte("/users/alice/a")))
+	assert.Equal(t, ErrPermissionDenied, as.IsPutPermitted(foo, []byte("/users/{user}/a")))
+	assert.Equal(t, ErrPermissionDenied, as.IsRangePermitted(foo, []byte("/users/"), []byte("/users0")))
+
+	alice := &AuthInfo{Username: "alice", Revision: as.Revision()}
+	assert.NoError(t, as.IsPutPermitted(alice, []byte("/users/alice/a")))
+	assert.Equal(t, ErrPermissionDenied, as.IsPutPermitted(alice, []byte("/users/foo/a")))
+
+	// user name containing separator would reach into keys of user "alice"
+	aliceX := &AuthInfo{Username: "alice/x", Revision: as.Revision()}
+	assert.Equal(t, ErrPermissionDenied, as.IsPutPermitted(aliceX, []byte("/users/alice/x/a")))
+
+	resp, err := as.RoleGet(&pb.AuthRoleGetRequest{Role: "users"})
+	require.NoError(t, err)
+	require.Len(t, resp.Perm, 1)
+	assert.True(t, resp.Perm[0].UserTemplate)
+}
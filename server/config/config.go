@@ -144,6 +144,32 @@ type ServerConfig struct {
 	AuthToken  string
 	BcryptCost uint
 	TokenTTL   uint
+	// TokenCacheSize is the maximum number of simple tokens kept in memory, least recently used
+	// tokens are evicted once it's reached. Zero means unlimited.
+	TokenCacheSize uint
+
+	// AuthFailureBackoff is the initial time a user has to wait after failed authentication
+	// attempt before the next one is checked. Zero disables rate limiting of authentication.
+	AuthFailureBackoff time.Duration
+	// AuthFailureMaxBackoff caps the exponential backoff of failed authentication attempts.
+	AuthFailureMaxBackoff time.Duration
+	// AuthVerifyPasswordUserNotFound makes UserVerifyPassword return an error for users that don't exist
+	// or have no password, instead of reporting the password as not valid.
+	AuthVerifyPasswordUserNotFound bool
+	// MaxRolesPerUser is the maximum number of roles that can be granted to a user. Zero means unlimited.
+	MaxRolesPerUser uint
+	// AuthRevisionRetention is the number of recent auth revisions whose users and roles are retained
+	// in memory to query past permissions of users. Zero disables retaining auth history.
+	AuthRevisionRetention uint
+	// AuthAssertionIssuer is the issuer of assertions users can authenticate with instead of password.
+	// Empty disables authentication by assertion.
+	AuthAssertionIssuer string
+	// AuthAssertionAudience is the audience assertions have to be issued for, required with AuthAssertionIssuer.
+	AuthAssertionAudience string
+	// AuthAssertionPublicKeys are paths of PEM encoded public keys assertions are verified with.
+	AuthAssertionPublicKeys []string
+	// AuthAssertionUserClaim is the claim of assertion holding name of the authenticated user.
+	AuthAssertionUserClaim string
 
 	// InitialCorruptCheck is true to check data corruption on boot
 	// before serving any peer/client traffic.
@@ -204,6 +230,10 @@ type ServerConfig struct {
 	// ExperimentalMaxLearners sets a limit to the number of learner members that can exist in the cluster membership.
 	ExperimentalMaxLearners int `json:"experimental-max-learners"`
 
+	// ExperimentalDebugConsistentIndexTrailer enables returning consistent index of the member in trailer of
+	// unary responses. It's meant for debugging, as it exposes internal apply progress of the member.
+	ExperimentalDebugConsistentIndexTrailer bool `json:"experimental-debug-consistent-index-trailer"`
+
 	// V2Deprecation defines a phase of v2store deprecation process.
 	V2Deprecation V2DeprecationEnum `json:"v2-deprecation"`
 }
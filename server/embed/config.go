@@ -60,6 +60,7 @@ const (
 	DefaultMaxWALs                     = 5
 	DefaultMaxTxnOps                   = uint(128)
 	DefaultWarningApplyDuration        = 100 * time.Millisecond
+	DefaultAuthFailureMaxBackoff       = time.Minute
 	DefaultWarningUnaryRequestDuration = 300 * time.Millisecond
 	DefaultMaxRequestBytes             = 1.5 * 1024 * 1024
 	DefaultMaxConcurrentStreams        = math.MaxUint32
@@ -324,6 +325,37 @@ type Config struct {
 
 	// AuthTokenTTL in seconds of the simple token
 	AuthTokenTTL uint `json:"auth-token-ttl"`
+	// ExperimentalAuthTokenCacheSize is the maximum number of simple tokens kept by a member. Once reached, the least
+	// recently used token is evicted and its client has to authenticate again. Zero means unlimited.
+	ExperimentalAuthTokenCacheSize uint `json:"experimental-auth-token-cache-size"`
+
+	// ExperimentalAuthFailureBackoff is the initial backoff applied to a user after failed authentication attempt,
+	// doubled on each consecutive failure. Zero disables rate limiting of authentication.
+	ExperimentalAuthFailureBackoff time.Duration `json:"experimental-auth-failure-backoff"`
+	// ExperimentalAuthFailureMaxBackoff caps the backoff of failed authentication attempts.
+	ExperimentalAuthFailureMaxBackoff time.Duration `json:"experimental-auth-failure-max-backoff"`
+	// ExperimentalAuthVerifyPasswordUserNotFound makes UserVerifyPassword return an error for users that don't exist,
+	// which reveals whether a user exists to its callers. By default such password is reported as not valid.
+	ExperimentalAuthVerifyPasswordUserNotFound bool `json:"experimental-auth-verify-password-user-not-found"`
+	// ExperimentalMaxRolesPerUser is the maximum number of roles that can be granted to a user. Zero means unlimited.
+	// Limit is enforced with the value configured on the member that receives the grant request.
+	ExperimentalMaxRolesPerUser uint `json:"experimental-max-roles-per-user"`
+	// ExperimentalAuthRevisionRetention is the number of recent auth revisions whose users and roles are retained
+	// in memory, so permissions users had at these revisions can be queried. Zero disables retaining auth history.
+	// History is kept by each member since it started, it is not persisted.
+	ExperimentalAuthRevisionRetention uint `json:"experimental-auth-revision-retention"`
+	// ExperimentalAuthAssertionIssuer is the issuer of JWT assertions, that users can authenticate with instead of
+	// password, for example machine identities issued by workload identity provider. Empty disables it.
+	ExperimentalAuthAssertionIssuer string `json:"experimental-auth-assertion-issuer"`
+	// ExperimentalAuthAssertionAudience is the audience assertions have to be issued for, usually identifying the
+	// etcd cluster. Required with ExperimentalAuthAssertionIssuer.
+	ExperimentalAuthAssertionAudience string `json:"experimental-auth-assertion-audience"`
+	// ExperimentalAuthAssertionPublicKeys are paths of PEM encoded RSA, ECDSA or Ed25519 public keys of the issuer.
+	// Assertion has to be signed by any of them.
+	ExperimentalAuthAssertionPublicKeys []string `json:"experimental-auth-assertion-public-keys"`
+	// ExperimentalAuthAssertionUserClaim is the claim of assertion holding name of the authenticated user.
+	// Empty means "sub" claim.
+	ExperimentalAuthAssertionUserClaim string `json:"experimental-auth-assertion-user-claim"`
 
 	ExperimentalInitialCorruptCheck     bool          `json:"experimental-initial-corrupt-check"`
 	ExperimentalCorruptCheckTime        time.Duration `json:"experimental-corrupt-check-time"`
@@ -427,6 +459,10 @@ type Config struct {
 	// ExperimentalTxnModeWriteWithSharedBuffer enables write transaction to use a shared buffer in its readonly check operations.
 	ExperimentalTxnModeWriteWithSharedBuffer bool `json:"experimental-txn-mode-write-with-shared-buffer"`
 
+	// ExperimentalDebugConsistentIndexTrailer enables returning consistent index of the member in trailer of unary responses,
+	// to correlate revisions observed by clients with apply progress of the member. It should not be used in production.
+	ExperimentalDebugConsistentIndexTrailer bool `json:"experimental-debug-consistent-index-trailer"`
+
 	// V2Deprecation describes phase of API & Storage V2 support
 	V2Deprecation config.V2DeprecationEnum `json:"v2-deprecation"`
 }
@@ -514,6 +550,8 @@ func NewConfig() *Config {
 		BcryptCost:   uint(bcrypt.DefaultCost),
 		AuthTokenTTL: 300,
 
+		ExperimentalAuthFailureMaxBackoff: DefaultAuthFailureMaxBackoff,
+
 		PreVote: true,
 
 		loggerMu:              new(sync.RWMutex),
@@ -793,6 +831,10 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("setting experimental-enable-lease-checkpoint-persist requires experimental-enable-lease-checkpoint")
 	}
 
+	if cfg.ExperimentalAuthFailureBackoff < 0 || cfg.ExperimentalAuthFailureMaxBackoff < cfg.ExperimentalAuthFailureBackoff {
+		return fmt.Errorf("--experimental-auth-failure-max-backoff (%v) must be not lower than --experimental-auth-failure-backoff (%v), which must be >=0", cfg.ExperimentalAuthFailureMaxBackoff, cfg.ExperimentalAuthFailureBackoff)
+	}
+
 	if cfg.ExperimentalCompactHashCheckTime <= 0 {
 		return fmt.Errorf("--experimental-compact-hash-check-time must be >0 (set to %v)", cfg.ExperimentalCompactHashCheckTime)
 	}
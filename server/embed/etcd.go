@@ -201,6 +201,16 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		AuthToken:                                cfg.AuthToken,
 		BcryptCost:                               cfg.BcryptCost,
 		TokenTTL:                                 cfg.AuthTokenTTL,
+		TokenCacheSize:                           cfg.ExperimentalAuthTokenCacheSize,
+		AuthFailureBackoff:                       cfg.ExperimentalAuthFailureBackoff,
+		AuthFailureMaxBackoff:                    cfg.ExperimentalAuthFailureMaxBackoff,
+		AuthVerifyPasswordUserNotFound:           cfg.ExperimentalAuthVerifyPasswordUserNotFound,
+		MaxRolesPerUser:                          cfg.ExperimentalMaxRolesPerUser,
+		AuthRevisionRetention:                    cfg.ExperimentalAuthRevisionRetention,
+		AuthAssertionIssuer:                      cfg.ExperimentalAuthAssertionIssuer,
+		AuthAssertionAudience:                    cfg.ExperimentalAuthAssertionAudience,
+		AuthAssertionPublicKeys:                  cfg.ExperimentalAuthAssertionPublicKeys,
+		AuthAssertionUserClaim:                   cfg.ExperimentalAuthAssertionUserClaim,
 		CORS:                                     cfg.CORS,
 		HostWhitelist:                            cfg.HostWhitelist,
 		InitialCorruptCheck:                      cfg.ExperimentalInitialCorruptCheck,
@@ -225,6 +235,7 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		ExperimentalTxnModeWriteWithSharedBuffer: cfg.ExperimentalTxnModeWriteWithSharedBuffer,
 		ExperimentalBootstrapDefragThresholdMegabytes: cfg.ExperimentalBootstrapDefragThresholdMegabytes,
 		ExperimentalMaxLearners:                       cfg.ExperimentalMaxLearners,
+		ExperimentalDebugConsistentIndexTrailer:       cfg.ExperimentalDebugConsistentIndexTrailer,
 		V2Deprecation:                                 cfg.V2DeprecationEffective(),
 	}
 
@@ -373,6 +384,7 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 
 		zap.String("downgrade-check-interval", sc.DowngradeCheckTime.String()),
 		zap.Int("max-learners", sc.ExperimentalMaxLearners),
+		zap.Bool("debug-consistent-index-trailer", sc.ExperimentalDebugConsistentIndexTrailer),
 	)
 }
 
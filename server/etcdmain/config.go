@@ -260,6 +260,16 @@ func newConfig() *config {
 	fs.StringVar(&cfg.ec.AuthToken, "auth-token", cfg.ec.AuthToken, "Specify auth token specific options.")
 	fs.UintVar(&cfg.ec.BcryptCost, "bcrypt-cost", cfg.ec.BcryptCost, "Specify bcrypt algorithm cost factor for auth password hashing.")
 	fs.UintVar(&cfg.ec.AuthTokenTTL, "auth-token-ttl", cfg.ec.AuthTokenTTL, "The lifetime in seconds of the auth token.")
+	fs.UintVar(&cfg.ec.ExperimentalAuthTokenCacheSize, "experimental-auth-token-cache-size", cfg.ec.ExperimentalAuthTokenCacheSize, "Maximum number of simple tokens kept by the member, least recently used tokens are evicted once reached. 0 means unlimited.")
+	fs.DurationVar(&cfg.ec.ExperimentalAuthFailureBackoff, "experimental-auth-failure-backoff", cfg.ec.ExperimentalAuthFailureBackoff, "Initial backoff of a user after failed authentication attempt, doubled on each consecutive failure. 0 disables it.")
+	fs.DurationVar(&cfg.ec.ExperimentalAuthFailureMaxBackoff, "experimental-auth-failure-max-backoff", cfg.ec.ExperimentalAuthFailureMaxBackoff, "Maximum backoff of a user after failed authentication attempts.")
+	fs.BoolVar(&cfg.ec.ExperimentalAuthVerifyPasswordUserNotFound, "experimental-auth-verify-password-user-not-found", cfg.ec.ExperimentalAuthVerifyPasswordUserNotFound, "Return an error from UserVerifyPassword for users that don't exist, instead of reporting the password as not valid.")
+	fs.UintVar(&cfg.ec.ExperimentalMaxRolesPerUser, "experimental-max-roles-per-user", cfg.ec.ExperimentalMaxRolesPerUser, "Maximum number of roles that can be granted to a user. 0 means unlimited.")
+	fs.UintVar(&cfg.ec.ExperimentalAuthRevisionRetention, "experimental-auth-revision-retention", cfg.ec.ExperimentalAuthRevisionRetention, "Number of recent auth revisions whose users and roles are retained in memory to query past permissions of users. 0 disables it.")
+	fs.StringVar(&cfg.ec.ExperimentalAuthAssertionIssuer, "experimental-auth-assertion-issuer", cfg.ec.ExperimentalAuthAssertionIssuer, "Issuer of JWT assertions users can authenticate with instead of password. Empty disables it.")
+	fs.StringVar(&cfg.ec.ExperimentalAuthAssertionAudience, "experimental-auth-assertion-audience", cfg.ec.ExperimentalAuthAssertionAudience, "Audience JWT assertions have to be issued for. Required with --experimental-auth-assertion-issuer.")
+	fs.Var(flags.NewStringsValue(""), "experimental-auth-assertion-public-keys", "Comma-separated list of paths to PEM encoded public keys of the assertion issuer.")
+	fs.StringVar(&cfg.ec.ExperimentalAuthAssertionUserClaim, "experimental-auth-assertion-user-claim", cfg.ec.ExperimentalAuthAssertionUserClaim, "Claim of assertion holding name of the authenticated user. Empty means \"sub\".")
 
 	// gateway
 	fs.BoolVar(&cfg.ec.EnableGRPCGateway, "enable-grpc-gateway", cfg.ec.EnableGRPCGateway, "Enable GRPC gateway.")
@@ -282,6 +292,7 @@ func newConfig() *config {
 	fs.DurationVar(&cfg.ec.ExperimentalWarningUnaryRequestDuration, "experimental-warning-unary-request-duration", cfg.ec.ExperimentalWarningUnaryRequestDuration, "Time duration after which a warning is generated if a unary request takes more time. It's deprecated, and will be decommissioned in v3.7. Use --warning-unary-request-duration instead.")
 	fs.BoolVar(&cfg.ec.ExperimentalMemoryMlock, "experimental-memory-mlock", cfg.ec.ExperimentalMemoryMlock, "Enable to enforce etcd pages (in particular bbolt) to stay in RAM.")
 	fs.BoolVar(&cfg.ec.ExperimentalTxnModeWriteWithSharedBuffer, "experimental-txn-mode-write-with-shared-buffer", true, "Enable the write transaction to use a shared buffer in its readonly check operations.")
+	fs.BoolVar(&cfg.ec.ExperimentalDebugConsistentIndexTrailer, "experimental-debug-consistent-index-trailer", false, "Enable returning consistent index of the member in trailer of unary responses. For debugging only, should not be used in production.")
 	fs.UintVar(&cfg.ec.ExperimentalBootstrapDefragThresholdMegabytes, "experimental-bootstrap-defrag-threshold-megabytes", 0, "Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.")
 	fs.IntVar(&cfg.ec.ExperimentalMaxLearners, "experimental-max-learners", membership.DefaultMaxLearners, "Sets the maximum number of learners that can be available in the cluster membership.")
 	fs.DurationVar(&cfg.ec.ExperimentalWaitClusterReadyTimeout, "experimental-wait-cluster-ready-timeout", cfg.ec.ExperimentalWaitClusterReadyTimeout, "Maximum duration to wait for the cluster to be ready.")
@@ -399,6 +410,7 @@ func (cfg *config) configFromCmdLine() error {
 	cfg.ec.HostWhitelist = flags.UniqueStringsMapFromFlag(cfg.cf.flagSet, "host-whitelist")
 
 	cfg.ec.CipherSuites = flags.StringsFromFlag(cfg.cf.flagSet, "cipher-suites")
+	cfg.ec.ExperimentalAuthAssertionPublicKeys = flags.StringsFromFlag(cfg.cf.flagSet, "experimental-auth-assertion-public-keys")
 
 	cfg.ec.MaxConcurrentStreams = flags.Uint32FromFlag(cfg.cf.flagSet, "max-concurrent-streams")
 
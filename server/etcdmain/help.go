@@ -214,6 +214,26 @@ Auth:
     Specify the cost / strength of the bcrypt algorithm for hashing auth passwords. Valid values are between ` + fmt.Sprintf("%d", bcrypt.MinCost) + ` and ` + fmt.Sprintf("%d", bcrypt.MaxCost) + `.
   --auth-token-ttl 300
     Time (in seconds) of the auth-token-ttl.
+  --experimental-auth-token-cache-size '0'
+    Maximum number of simple tokens kept by the member, least recently used tokens are evicted once reached and their clients have to authenticate again. 0 means unlimited.
+  --experimental-auth-failure-backoff '0s'
+    Initial backoff of a user after failed authentication attempt, doubled on each consecutive failure. 0 disables it.
+  --experimental-auth-failure-max-backoff '1m0s'
+    Maximum backoff of a user after failed authentication attempts. Failed attempts are forgotten one by one after each such period without failures.
+  --experimental-auth-verify-password-user-not-found 'false'
+    Return an error from UserVerifyPassword for users that don't exist or have no password, instead of reporting the password as not valid.
+  --experimental-max-roles-per-user '0'
+    Maximum number of roles that can be granted to a user, enforced with the limit of the member receiving the grant request. 0 means unlimited.
+  --experimental-auth-revision-retention '0'
+    Number of recent auth revisions whose users and roles are retained in memory to query past permissions of users. History is not persisted and starts over on restart. 0 disables it.
+  --experimental-auth-assertion-issuer ''
+    Issuer of JWT assertions users can authenticate with instead of password, for example identities of workloads. Empty disables it.
+  --experimental-auth-assertion-audience ''
+    Audience JWT assertions have to be issued for, usually identifying the etcd cluster. Required with --experimental-auth-assertion-issuer.
+  --experimental-auth-assertion-public-keys ''
+    Comma-separated list of paths to PEM encoded RSA, ECDSA or Ed25519 public keys of the assertion issuer. Assertion has to be signed by any of them.
+  --experimental-auth-assertion-user-claim ''
+    Claim of assertion holding name of the authenticated user, which has to exist. Empty means "sub".
 
 Profiling and Monitoring:
   --enable-pprof 'false'
@@ -268,6 +288,8 @@ Experimental feature:
     Warning is generated if requests take more than this duration.
   --experimental-txn-mode-write-with-shared-buffer 'true'
     Enable the write transaction to use a shared buffer in its readonly check operations.
+  --experimental-debug-consistent-index-trailer 'false'
+    Enable returning consistent index of the member in trailer of unary responses. For debugging only, should not be used in production.
   --experimental-bootstrap-defrag-threshold-megabytes
     Enable the defrag during etcd server bootstrap on condition that it will free at least the provided threshold of disk space. Needs to be set to non-zero value to take effect.
   --experimental-warning-unary-request-duration '300ms'
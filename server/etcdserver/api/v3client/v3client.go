@@ -65,3 +65,7 @@ type watchWrapper struct{ clientv3.Watcher }
 func (ww *watchWrapper) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
 	return ww.Watcher.Watch(&blankContext{ctx}, key, opts...)
 }
+
+func (ww *watchWrapper) WatchBatch(ctx context.Context, specs ...clientv3.WatchSpec) []clientv3.WatchChan {
+	return clientv3.WatchBatch(&blankContext{ctx}, ww.Watcher, specs...)
+}
@@ -54,6 +54,14 @@ func (as *AuthServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (
 	return resp, nil
 }
 
+func (as *AuthServer) WhoAmI(ctx context.Context, r *pb.AuthWhoAmIRequest) (*pb.AuthWhoAmIResponse, error) {
+	resp, err := as.authenticator.WhoAmI(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	resp, err := as.authenticator.Authenticate(ctx, r)
 	if err != nil {
@@ -94,6 +102,14 @@ func (as *AuthServer) RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (
 	return resp, nil
 }
 
+func (as *AuthServer) UsersWithRole(ctx context.Context, r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error) {
+	resp, err := as.authenticator.UsersWithRole(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error) {
 	resp, err := as.authenticator.RoleRevokePermission(ctx, r)
 	if err != nil {
@@ -102,6 +118,22 @@ func (as *AuthServer) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRe
 	return resp, nil
 }
 
+func (as *AuthServer) RoleSetQuota(ctx context.Context, r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error) {
+	resp, err := as.authenticator.RoleSetQuota(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) RoleSetAdminPrefix(ctx context.Context, r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	resp, err := as.authenticator.RoleSetAdminPrefix(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
 	resp, err := as.authenticator.RoleGrantPermission(ctx, r)
 	if err != nil {
@@ -134,6 +166,14 @@ func (as *AuthServer) UserGet(ctx context.Context, r *pb.AuthUserGetRequest) (*p
 	return resp, nil
 }
 
+func (as *AuthServer) UserPermissionsAtRevision(ctx context.Context, r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	resp, err := as.authenticator.UserPermissionsAtRevision(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error) {
 	resp, err := as.authenticator.UserList(ctx, r)
 	if err != nil {
@@ -158,6 +198,22 @@ func (as *AuthServer) UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRo
 	return resp, nil
 }
 
+func (as *AuthServer) UserSetRoles(ctx context.Context, r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	resp, err := as.authenticator.UserSetRoles(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) UserSetImmutable(ctx context.Context, r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error) {
+	resp, err := as.authenticator.UserSetImmutable(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 func (as *AuthServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error) {
 	resp, err := as.authenticator.UserChangePassword(ctx, r)
 	if err != nil {
@@ -166,6 +222,14 @@ func (as *AuthServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChan
 	return resp, nil
 }
 
+func (as *AuthServer) UserVerifyPassword(ctx context.Context, r *pb.AuthUserVerifyPasswordRequest) (*pb.AuthUserVerifyPasswordResponse, error) {
+	resp, err := as.authenticator.UserVerifyPassword(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
 type AuthGetter interface {
 	AuthInfoFromCtx(ctx context.Context) (*auth.AuthInfo, error)
 	AuthStore() auth.AuthStore
@@ -50,6 +50,9 @@ func Server(s *etcdserver.EtcdServer, tls *tls.Config, interceptor grpc.UnarySer
 	if interceptor != nil {
 		chainUnaryInterceptors = append(chainUnaryInterceptors, interceptor)
 	}
+	if s.Cfg.ExperimentalDebugConsistentIndexTrailer {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, newConsistentIndexUnaryInterceptor(s))
+	}
 
 	chainStreamInterceptors := []grpc.StreamServerInterceptor{
 		newStreamInterceptor(s),
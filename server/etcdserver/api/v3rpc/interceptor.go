@@ -16,6 +16,7 @@ package v3rpc
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -88,6 +89,16 @@ func newLogUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerIntercepto
 	}
 }
 
+// newConsistentIndexUnaryInterceptor returns interceptor setting trailer of unary responses to consistent index
+// of the member after request was handled, so clients can correlate responses with apply progress of the member.
+func newConsistentIndexUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		grpc.SetTrailer(ctx, metadata.Pairs(rpctypes.MetadataConsistentIndexKey, strconv.FormatUint(s.ConsistentIndex(), 10)))
+		return resp, err
+	}
+}
+
 func logUnaryRequestStats(ctx context.Context, lg *zap.Logger, warnLatency time.Duration, info *grpc.UnaryServerInfo, startTime time.Time, req interface{}, resp interface{}) {
 	duration := time.Since(startTime)
 	var enabledDebugLevel, expensiveRequest bool
@@ -59,6 +59,7 @@ var toGRPCErrorMap = map[error]error{
 	errors.ErrKeyNotFound:                rpctypes.ErrGRPCKeyNotFound,
 	errors.ErrCorrupt:                    rpctypes.ErrGRPCCorrupt,
 	errors.ErrBadLeaderTransferee:        rpctypes.ErrGRPCBadLeaderTransferee,
+	errors.ErrUnsupportedClusterVersion:  rpctypes.ErrGRPCUnsupportedClusterVersion,
 
 	errors.ErrClusterVersionUnavailable:      rpctypes.ErrGRPCClusterVersionUnavailable,
 	errors.ErrWrongDowngradeVersionFormat:    rpctypes.ErrGRPCWrongDowngradeVersionFormat,
@@ -70,23 +71,31 @@ var toGRPCErrorMap = map[error]error{
 	lease.ErrLeaseExists:      rpctypes.ErrGRPCLeaseExist,
 	lease.ErrLeaseTTLTooLarge: rpctypes.ErrGRPCLeaseTTLTooLarge,
 
-	auth.ErrRootUserNotExist:     rpctypes.ErrGRPCRootUserNotExist,
-	auth.ErrRootRoleNotExist:     rpctypes.ErrGRPCRootRoleNotExist,
-	auth.ErrUserAlreadyExist:     rpctypes.ErrGRPCUserAlreadyExist,
-	auth.ErrUserEmpty:            rpctypes.ErrGRPCUserEmpty,
-	auth.ErrUserNotFound:         rpctypes.ErrGRPCUserNotFound,
-	auth.ErrRoleAlreadyExist:     rpctypes.ErrGRPCRoleAlreadyExist,
-	auth.ErrRoleNotFound:         rpctypes.ErrGRPCRoleNotFound,
-	auth.ErrRoleEmpty:            rpctypes.ErrGRPCRoleEmpty,
-	auth.ErrAuthFailed:           rpctypes.ErrGRPCAuthFailed,
-	auth.ErrPermissionNotGiven:   rpctypes.ErrGRPCPermissionNotGiven,
-	auth.ErrPermissionDenied:     rpctypes.ErrGRPCPermissionDenied,
-	auth.ErrRoleNotGranted:       rpctypes.ErrGRPCRoleNotGranted,
-	auth.ErrPermissionNotGranted: rpctypes.ErrGRPCPermissionNotGranted,
-	auth.ErrAuthNotEnabled:       rpctypes.ErrGRPCAuthNotEnabled,
-	auth.ErrInvalidAuthToken:     rpctypes.ErrGRPCInvalidAuthToken,
-	auth.ErrInvalidAuthMgmt:      rpctypes.ErrGRPCInvalidAuthMgmt,
-	auth.ErrAuthOldRevision:      rpctypes.ErrGRPCAuthOldRevision,
+	auth.ErrRootUserNotExist:        rpctypes.ErrGRPCRootUserNotExist,
+	auth.ErrRootRoleNotExist:        rpctypes.ErrGRPCRootRoleNotExist,
+	auth.ErrUserAlreadyExist:        rpctypes.ErrGRPCUserAlreadyExist,
+	auth.ErrUserEmpty:               rpctypes.ErrGRPCUserEmpty,
+	auth.ErrUserNotFound:            rpctypes.ErrGRPCUserNotFound,
+	auth.ErrRoleAlreadyExist:        rpctypes.ErrGRPCRoleAlreadyExist,
+	auth.ErrRoleNotFound:            rpctypes.ErrGRPCRoleNotFound,
+	auth.ErrRoleEmpty:               rpctypes.ErrGRPCRoleEmpty,
+	auth.ErrAuthFailed:              rpctypes.ErrGRPCAuthFailed,
+	auth.ErrPermissionNotGiven:      rpctypes.ErrGRPCPermissionNotGiven,
+	auth.ErrPermissionDenied:        rpctypes.ErrGRPCPermissionDenied,
+	auth.ErrRoleNotGranted:          rpctypes.ErrGRPCRoleNotGranted,
+	auth.ErrTooManyRoles:            rpctypes.ErrGRPCTooManyRoles,
+	auth.ErrUserImmutable:           rpctypes.ErrGRPCUserImmutable,
+	auth.ErrPermissionNotGranted:    rpctypes.ErrGRPCPermissionNotGranted,
+	auth.ErrAuthNotEnabled:          rpctypes.ErrGRPCAuthNotEnabled,
+	auth.ErrInvalidAuthToken:        rpctypes.ErrGRPCInvalidAuthToken,
+	auth.ErrInvalidAuthMgmt:         rpctypes.ErrGRPCInvalidAuthMgmt,
+	auth.ErrAuthOldRevision:         rpctypes.ErrGRPCAuthOldRevision,
+	auth.ErrAuthRateLimited:         rpctypes.ErrGRPCAuthRateLimited,
+	auth.ErrRoleQuotaExceeded:       rpctypes.ErrGRPCRoleQuotaExceeded,
+	auth.ErrAuthRevisionNotRetained: rpctypes.ErrGRPCAuthRevisionNotRetained,
+	auth.ErrAuthFutureRevision:      rpctypes.ErrGRPCAuthFutureRevision,
+	auth.ErrInvalidAssertion:        rpctypes.ErrGRPCInvalidAssertion,
+	auth.ErrAssertionNotConfigured:  rpctypes.ErrGRPCAssertionNotConfigured,
 
 	// In sync with status.FromContextError
 	context.Canceled:         rpctypes.ErrGRPCCanceled,
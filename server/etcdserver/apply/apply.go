@@ -89,26 +89,36 @@ type applierV3 interface {
 	AuthEnable() (*pb.AuthEnableResponse, error)
 	AuthDisable() (*pb.AuthDisableResponse, error)
 	AuthStatus() (*pb.AuthStatusResponse, error)
+	WhoAmI(authInfo *auth.AuthInfo) (*pb.AuthWhoAmIResponse, error)
 
 	UserAdd(ua *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error)
 	UserDelete(ua *pb.AuthUserDeleteRequest) (*pb.AuthUserDeleteResponse, error)
 	UserChangePassword(ua *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error)
 	UserGrantRole(ua *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
+	InternalUserGrantRole(ua *pb.InternalAuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
 	UserGet(ua *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error)
+	UserPermissionsAtRevision(ua *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error)
 	UserRevokeRole(ua *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error)
+	UserSetRoles(ua *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error)
+	InternalUserSetRoles(ua *pb.InternalAuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error)
+	UserSetImmutable(ua *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error)
 	RoleAdd(ua *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error)
 	RoleGrantPermission(ua *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error)
 	RoleGet(ua *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse, error)
 	RoleRevokePermission(ua *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error)
+	RoleSetQuota(ua *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error)
+	RoleSetAdminPrefix(ua *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error)
 	RoleDelete(ua *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ua *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ua *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	UsersWithRole(ua *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error)
 
 	// processing internal V3 raft request
 
 	ClusterVersionSet(r *membershippb.ClusterVersionSetRequest, shouldApplyV3 membership.ShouldApplyV3)
 	ClusterMemberAttrSet(r *membershippb.ClusterMemberAttrSetRequest, shouldApplyV3 membership.ShouldApplyV3)
 	DowngradeInfoSet(r *membershippb.DowngradeInfoSetRequest, shouldApplyV3 membership.ShouldApplyV3)
+	RevokeExpiredRoleGrants(r *pb.InternalAuthRevokeExpiredRoleGrantsRequest)
 }
 
 type SnapshotServer interface {
@@ -289,8 +299,17 @@ func (a *applierV3backend) AuthStatus() (*pb.AuthStatusResponse, error) {
 	return &pb.AuthStatusResponse{Header: a.newHeader(), Enabled: enabled, AuthRevision: authRevision}, nil
 }
 
+func (a *applierV3backend) WhoAmI(authInfo *auth.AuthInfo) (*pb.AuthWhoAmIResponse, error) {
+	resp, err := a.authStore.WhoAmI(authInfo)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) Authenticate(r *pb.InternalAuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	ctx := context.WithValue(context.WithValue(context.Background(), auth.AuthenticateParamIndex{}, a.consistentIndex.ConsistentIndex()), auth.AuthenticateParamSimpleTokenPrefix{}, r.SimpleToken)
+	ctx = context.WithValue(ctx, auth.AuthenticateParamAssertion{}, r.Assertion)
 	resp, err := a.authStore.Authenticate(ctx, r.Name, r.Password)
 	if resp != nil {
 		resp.Header = a.newHeader()
@@ -330,6 +349,14 @@ func (a *applierV3backend) UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.Au
 	return resp, err
 }
 
+func (a *applierV3backend) InternalUserGrantRole(r *pb.InternalAuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error) {
+	resp, err := a.authStore.InternalUserGrantRole(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error) {
 	resp, err := a.authStore.UserGet(r)
 	if resp != nil {
@@ -338,6 +365,14 @@ func (a *applierV3backend) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetRes
 	return resp, err
 }
 
+func (a *applierV3backend) UserPermissionsAtRevision(r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	resp, err := a.authStore.UserPermissionsAtRevision(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error) {
 	resp, err := a.authStore.UserRevokeRole(r)
 	if resp != nil {
@@ -346,6 +381,30 @@ func (a *applierV3backend) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.
 	return resp, err
 }
 
+func (a *applierV3backend) UserSetRoles(r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	resp, err := a.authStore.UserSetRoles(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) InternalUserSetRoles(r *pb.InternalAuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	resp, err := a.authStore.InternalUserSetRoles(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) UserSetImmutable(r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error) {
+	resp, err := a.authStore.UserSetImmutable(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) RoleAdd(r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error) {
 	resp, err := a.authStore.RoleAdd(r)
 	if resp != nil {
@@ -378,6 +437,22 @@ func (a *applierV3backend) RoleRevokePermission(r *pb.AuthRoleRevokePermissionRe
 	return resp, err
 }
 
+func (a *applierV3backend) RoleSetQuota(r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error) {
+	resp, err := a.authStore.RoleSetQuota(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) RoleSetAdminPrefix(r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	resp, err := a.authStore.RoleSetAdminPrefix(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error) {
 	resp, err := a.authStore.RoleDelete(r)
 	if resp != nil {
@@ -402,6 +477,18 @@ func (a *applierV3backend) RoleList(r *pb.AuthRoleListRequest) (*pb.AuthRoleList
 	return resp, err
 }
 
+func (a *applierV3backend) UsersWithRole(r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error) {
+	resp, err := a.authStore.UsersWithRole(r)
+	if resp != nil {
+		resp.Header = a.newHeader()
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) RevokeExpiredRoleGrants(r *pb.InternalAuthRevokeExpiredRoleGrantsRequest) {
+	a.authStore.RevokeExpiredRoleGrants(r)
+}
+
 func (a *applierV3backend) ClusterVersionSet(r *membershippb.ClusterVersionSetRequest, shouldApplyV3 membership.ShouldApplyV3) {
 	prevVersion := a.cluster.Version()
 	newVersion := semver.Must(semver.NewVersion(r.Ver))
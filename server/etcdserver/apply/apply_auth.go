@@ -31,6 +31,7 @@ type authApplierV3 struct {
 	applierV3
 	as     auth.AuthStore
 	lessor lease.Lessor
+	kv     mvcc.KV
 
 	// mu serializes Apply so that user isn't corrupted and so that
 	// serialized requests don't leak data from TOCTOU errors
@@ -39,8 +40,8 @@ type authApplierV3 struct {
 	authInfo auth.AuthInfo
 }
 
-func newAuthApplierV3(as auth.AuthStore, base applierV3, lessor lease.Lessor) *authApplierV3 {
-	return &authApplierV3{applierV3: base, as: as, lessor: lessor}
+func newAuthApplierV3(as auth.AuthStore, base applierV3, lessor lease.Lessor, kv mvcc.KV) *authApplierV3 {
+	return &authApplierV3{applierV3: base, as: as, lessor: lessor, kv: kv}
 }
 
 func (aa *authApplierV3) Apply(ctx context.Context, r *pb.InternalRaftRequest, shouldApplyV3 membership.ShouldApplyV3, applyFunc applyFunc) *Result {
@@ -51,9 +52,10 @@ func (aa *authApplierV3) Apply(ctx context.Context, r *pb.InternalRaftRequest, s
 		// does not have header field
 		aa.authInfo.Username = r.Header.Username
 		aa.authInfo.Revision = r.Header.AuthRevision
+		aa.authInfo.Time = r.Header.Time
 	}
 	if needAdminPermission(r) {
-		if err := aa.as.IsAdminPermitted(&aa.authInfo); err != nil {
+		if err := aa.isAdminPermitted(r); err != nil {
 			aa.authInfo.Username = ""
 			aa.authInfo.Revision = 0
 			return &Result{Err: err}
@@ -84,6 +86,10 @@ func (aa *authApplierV3) Put(ctx context.Context, txn mvcc.TxnWrite, r *pb.PutRe
 			return nil, nil, err
 		}
 	}
+
+	if err := aa.as.IsPutWithinQuota(&aa.authInfo, putSizes([]*pb.PutRequest{r})); err != nil {
+		return nil, nil, err
+	}
 	return aa.applierV3.Put(ctx, txn, r)
 }
 
@@ -112,6 +118,13 @@ func (aa *authApplierV3) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnRes
 	if err := txn.CheckTxnAuth(aa.as, &aa.authInfo, rt); err != nil {
 		return nil, nil, err
 	}
+	if aa.as.IsAuthEnabled() {
+		// Only puts of executed branches must fit into the quota. Compares are evaluated against
+		// the same state the transaction is applied to, so all members choose the same branches.
+		if err := aa.as.IsPutWithinQuota(&aa.authInfo, putSizes(txn.ExecutedPuts(aa.kv, rt))); err != nil {
+			return nil, nil, err
+		}
+	}
 	return aa.applierV3.Txn(ctx, rt)
 }
 
@@ -122,6 +135,19 @@ func (aa *authApplierV3) LeaseRevoke(lc *pb.LeaseRevokeRequest) (*pb.LeaseRevoke
 	return aa.applierV3.LeaseRevoke(lc)
 }
 
+// putSizes returns sizes of keys written by the puts, indexed by key.
+// Puts ignoring value keep size of the key unchanged so they are skipped.
+func putSizes(puts []*pb.PutRequest) map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, put := range puts {
+		if put.IgnoreValue {
+			continue
+		}
+		sizes[string(put.Key)] = int64(len(put.Key) + len(put.Value))
+	}
+	return sizes
+}
+
 func (aa *authApplierV3) checkLeasePuts(leaseID lease.LeaseID) error {
 	l := aa.lessor.Lookup(leaseID)
 	if l != nil {
@@ -146,9 +172,20 @@ func (aa *authApplierV3) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetRespo
 	return aa.applierV3.UserGet(r)
 }
 
+func (aa *authApplierV3) UserPermissionsAtRevision(r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	err := aa.as.IsAdminPermitted(&aa.authInfo)
+	if err != nil && r.Name != aa.authInfo.Username {
+		aa.authInfo.Username = ""
+		aa.authInfo.Revision = 0
+		return &pb.AuthUserPermissionsAtRevisionResponse{}, err
+	}
+
+	return aa.applierV3.UserPermissionsAtRevision(r)
+}
+
 func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse, error) {
 	err := aa.as.IsAdminPermitted(&aa.authInfo)
-	if err != nil && !aa.as.HasRole(aa.authInfo.Username, r.Role) {
+	if err != nil && !aa.as.IsRoleGranted(&aa.authInfo, r.Role) {
 		aa.authInfo.Username = ""
 		aa.authInfo.Revision = 0
 		return &pb.AuthRoleGetResponse{}, err
@@ -157,14 +194,33 @@ func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetRespo
 	return aa.applierV3.RoleGet(r)
 }
 
+// isAdminPermitted checks that the user is permitted to apply the admin request. Granting and revoking
+// permissions and roles is also permitted to admins scoped to a key prefix, as long as it stays within the prefix.
+func (aa *authApplierV3) isAdminPermitted(r *pb.InternalRaftRequest) error {
+	switch {
+	case r.AuthRoleGrantPermission != nil && r.AuthRoleGrantPermission.Perm != nil:
+		perm := r.AuthRoleGrantPermission.Perm
+		return aa.as.IsPermissionAdminPermitted(&aa.authInfo, r.AuthRoleGrantPermission.Name, perm.Key, perm.RangeEnd)
+	case r.AuthRoleRevokePermission != nil:
+		rp := r.AuthRoleRevokePermission
+		return aa.as.IsPermissionAdminPermitted(&aa.authInfo, rp.Role, rp.Key, rp.RangeEnd)
+	case r.AuthUserGrantRole != nil:
+		return aa.as.IsRoleAdminPermitted(&aa.authInfo, r.AuthUserGrantRole.Role)
+	case r.InternalAuthUserGrantRole != nil:
+		return aa.as.IsRoleAdminPermitted(&aa.authInfo, r.InternalAuthUserGrantRole.Grant.Role)
+	case r.AuthUserRevokeRole != nil:
+		return aa.as.IsRoleAdminPermitted(&aa.authInfo, r.AuthUserRevokeRole.Role)
+	default:
+		return aa.as.IsAdminPermitted(&aa.authInfo)
+	}
+}
+
 func needAdminPermission(r *pb.InternalRaftRequest) bool {
 	switch {
 	case r.AuthEnable != nil:
 		return true
 	case r.AuthDisable != nil:
 		return true
-	case r.AuthStatus != nil:
-		return true
 	case r.AuthUserAdd != nil:
 		return true
 	case r.AuthUserDelete != nil:
@@ -173,20 +229,34 @@ func needAdminPermission(r *pb.InternalRaftRequest) bool {
 		return true
 	case r.AuthUserGrantRole != nil:
 		return true
+	case r.InternalAuthUserGrantRole != nil:
+		return true
 	case r.AuthUserRevokeRole != nil:
 		return true
+	case r.AuthUserSetRoles != nil:
+		return true
+	case r.InternalAuthUserSetRoles != nil:
+		return true
 	case r.AuthRoleAdd != nil:
 		return true
 	case r.AuthRoleGrantPermission != nil:
 		return true
 	case r.AuthRoleRevokePermission != nil:
 		return true
+	case r.AuthRoleSetQuota != nil:
+		return true
+	case r.AuthRoleSetAdminPrefix != nil:
+		return true
+	case r.AuthUserSetImmutable != nil:
+		return true
 	case r.AuthRoleDelete != nil:
 		return true
 	case r.AuthUserList != nil:
 		return true
 	case r.AuthRoleList != nil:
 		return true
+	case r.AuthUsersWithRole != nil:
+		return true
 	default:
 		return false
 	}
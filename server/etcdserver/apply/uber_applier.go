@@ -93,6 +93,7 @@ func newApplierV3(
 		authStore,
 		newQuotaApplierV3(lg, quotaBackendBytesCfg, be, applierBackend),
 		lessor,
+		kv,
 	)
 }
 
@@ -189,6 +190,14 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest, s
 		ar.Resp, ar.Err = a.applyV3.AuthDisable()
 	case r.AuthStatus != nil:
 		ar.Resp, ar.Err = a.applyV3.AuthStatus()
+	case r.AuthWhoAmI != nil:
+		op = "AuthWhoAmI"
+		var authInfo auth.AuthInfo
+		if r.Header != nil {
+			authInfo.Username = r.Header.Username
+			authInfo.Revision = r.Header.AuthRevision
+		}
+		ar.Resp, ar.Err = a.applyV3.WhoAmI(&authInfo)
 	case r.AuthUserAdd != nil:
 		op = "AuthUserAdd"
 		ar.Resp, ar.Err = a.applyV3.UserAdd(r.AuthUserAdd)
@@ -201,12 +210,27 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest, s
 	case r.AuthUserGrantRole != nil:
 		op = "AuthUserGrantRole"
 		ar.Resp, ar.Err = a.applyV3.UserGrantRole(r.AuthUserGrantRole)
+	case r.InternalAuthUserGrantRole != nil:
+		op = "InternalAuthUserGrantRole"
+		ar.Resp, ar.Err = a.applyV3.InternalUserGrantRole(r.InternalAuthUserGrantRole)
 	case r.AuthUserGet != nil:
 		op = "AuthUserGet"
 		ar.Resp, ar.Err = a.applyV3.UserGet(r.AuthUserGet)
+	case r.AuthUserPermissionsAtRevision != nil:
+		op = "AuthUserPermissionsAtRevision"
+		ar.Resp, ar.Err = a.applyV3.UserPermissionsAtRevision(r.AuthUserPermissionsAtRevision)
 	case r.AuthUserRevokeRole != nil:
 		op = "AuthUserRevokeRole"
 		ar.Resp, ar.Err = a.applyV3.UserRevokeRole(r.AuthUserRevokeRole)
+	case r.AuthUserSetRoles != nil:
+		op = "AuthUserSetRoles"
+		ar.Resp, ar.Err = a.applyV3.UserSetRoles(r.AuthUserSetRoles)
+	case r.InternalAuthUserSetRoles != nil:
+		op = "InternalAuthUserSetRoles"
+		ar.Resp, ar.Err = a.applyV3.InternalUserSetRoles(r.InternalAuthUserSetRoles)
+	case r.AuthUserSetImmutable != nil:
+		op = "AuthUserSetImmutable"
+		ar.Resp, ar.Err = a.applyV3.UserSetImmutable(r.AuthUserSetImmutable)
 	case r.AuthRoleAdd != nil:
 		op = "AuthRoleAdd"
 		ar.Resp, ar.Err = a.applyV3.RoleAdd(r.AuthRoleAdd)
@@ -219,6 +243,12 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest, s
 	case r.AuthRoleRevokePermission != nil:
 		op = "AuthRoleRevokePermission"
 		ar.Resp, ar.Err = a.applyV3.RoleRevokePermission(r.AuthRoleRevokePermission)
+	case r.AuthRoleSetQuota != nil:
+		op = "AuthRoleSetQuota"
+		ar.Resp, ar.Err = a.applyV3.RoleSetQuota(r.AuthRoleSetQuota)
+	case r.AuthRoleSetAdminPrefix != nil:
+		op = "AuthRoleSetAdminPrefix"
+		ar.Resp, ar.Err = a.applyV3.RoleSetAdminPrefix(r.AuthRoleSetAdminPrefix)
 	case r.AuthRoleDelete != nil:
 		op = "AuthRoleDelete"
 		ar.Resp, ar.Err = a.applyV3.RoleDelete(r.AuthRoleDelete)
@@ -228,6 +258,12 @@ func (a *uberApplier) dispatch(ctx context.Context, r *pb.InternalRaftRequest, s
 	case r.AuthRoleList != nil:
 		op = "AuthRoleList"
 		ar.Resp, ar.Err = a.applyV3.RoleList(r.AuthRoleList)
+	case r.AuthUsersWithRole != nil:
+		op = "AuthUsersWithRole"
+		ar.Resp, ar.Err = a.applyV3.UsersWithRole(r.AuthUsersWithRole)
+	case r.AuthRevokeExpiredRoleGrants != nil:
+		op = "AuthRevokeExpiredRoleGrants"
+		a.applyV3.RevokeExpiredRoleGrants(r.AuthRevokeExpiredRoleGrants)
 	default:
 		a.lg.Panic("not implemented apply", zap.Stringer("raft-request", r))
 	}
@@ -39,6 +39,7 @@ var (
 	ErrUnhealthy                   = errors.New("etcdserver: unhealthy cluster")
 	ErrCorrupt                     = errors.New("etcdserver: corrupt cluster")
 	ErrBadLeaderTransferee         = errors.New("etcdserver: bad leader transferee")
+	ErrUnsupportedClusterVersion   = errors.New("etcdserver: request is not supported by cluster version")
 	ErrClusterVersionUnavailable   = errors.New("etcdserver: cluster version not found during downgrade")
 	ErrWrongDowngradeVersionFormat = errors.New("etcdserver: wrong downgrade target version format")
 	ErrKeyNotFound                 = errors.New("etcdserver: key not found")
@@ -107,6 +107,9 @@ const (
 	readyPercent = 0.9
 
 	DowngradeEnabledPath = "/downgrade/enabled"
+
+	// monitorRoleGrantsInterval is the interval in which leader checks for expired temporary role grants.
+	monitorRoleGrantsInterval = time.Second
 )
 
 var (
@@ -266,6 +269,9 @@ type EtcdServer struct {
 	authStore  auth.AuthStore
 	alarmStore *v3alarm.AlarmStore
 
+	// authLimiter rate limits failed authentication attempts served by this member.
+	authLimiter *auth.AttemptLimiter
+
 	stats  *stats.ServerStats
 	lstats *stats.LeaderStats
 
@@ -363,11 +369,20 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 			return srv.applyWait.Wait(index)
 		},
 		time.Duration(cfg.TokenTTL)*time.Second,
+		int(cfg.TokenCacheSize),
 	)
 	if err != nil {
 		cfg.Logger.Warn("failed to create token provider", zap.Error(err))
 		return nil, err
 	}
+	var assertionVerifier *auth.AssertionVerifier
+	if cfg.AuthAssertionIssuer != "" {
+		assertionVerifier, err = auth.NewAssertionVerifier(cfg.Logger, cfg.AuthAssertionIssuer, cfg.AuthAssertionAudience, cfg.AuthAssertionPublicKeys, cfg.AuthAssertionUserClaim)
+		if err != nil {
+			cfg.Logger.Warn("failed to create assertion verifier", zap.Error(err))
+			return nil, err
+		}
+	}
 
 	mvccStoreConfig := mvcc.StoreConfig{
 		CompactionBatchLimit:    cfg.CompactionBatchLimit,
@@ -377,6 +392,11 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 	srv.corruptionChecker = newCorruptionChecker(cfg.Logger, srv, srv.kv.HashStorage())
 
 	srv.authStore = auth.NewAuthStore(srv.Logger(), schema.NewAuthBackend(srv.Logger(), srv.be), tp, int(cfg.BcryptCost))
+	srv.authStore.SetKeySizeRanger(srv.keySizes)
+	srv.authStore.SetHistoryRetention(int(cfg.AuthRevisionRetention))
+	srv.authStore.SetAssertionVerifier(assertionVerifier)
+	srv.kv.ObserveWrites(srv.authStore.ObserveWrites)
+	srv.authLimiter = auth.NewAttemptLimiter(cfg.AuthFailureBackoff, cfg.AuthFailureMaxBackoff)
 
 	newSrv := srv // since srv == nil in defer if srv is returned as nil
 	defer func() {
@@ -536,6 +556,7 @@ func (s *EtcdServer) Start() {
 	s.GoAttach(s.monitorKVHash)
 	s.GoAttach(s.monitorCompactHash)
 	s.GoAttach(s.monitorDowngrade)
+	s.GoAttach(s.monitorRoleGrants)
 }
 
 // start prepares and starts server in a new goroutine. It is no longer safe to
@@ -1638,6 +1659,8 @@ func (s *EtcdServer) CommittedIndex() uint64 { return s.getCommittedIndex() }
 
 func (s *EtcdServer) AppliedIndex() uint64 { return s.getAppliedIndex() }
 
+func (s *EtcdServer) ConsistentIndex() uint64 { return s.consistIndex.ConsistentIndex() }
+
 func (s *EtcdServer) Term() uint64 { return s.getTerm() }
 
 type confChangeResponse struct {
@@ -1950,7 +1973,7 @@ func (s *EtcdServer) applyEntryNormal(e *raftpb.Entry) {
 }
 
 func noSideEffect(r *pb.InternalRaftRequest) bool {
-	return r.Range != nil || r.AuthUserGet != nil || r.AuthRoleGet != nil || r.AuthStatus != nil
+	return r.Range != nil || r.AuthUserGet != nil || r.AuthRoleGet != nil || r.AuthUsersWithRole != nil || r.AuthStatus != nil || r.AuthWhoAmI != nil || r.AuthUserPermissionsAtRevision != nil
 }
 
 func removeNeedlessRangeReqs(txn *pb.TxnRequest) {
@@ -2353,6 +2376,33 @@ func (s *EtcdServer) monitorDowngrade() {
 	}
 }
 
+// monitorRoleGrants every monitorRoleGrantsInterval checks if it's the leader and revokes expired temporary role grants.
+func (s *EtcdServer) monitorRoleGrants() {
+	lg := s.Logger()
+	for {
+		select {
+		case <-time.After(monitorRoleGrantsInterval):
+		case <-s.stopping:
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		now := time.Now()
+		if !s.AuthStore().HasExpiredRoleGrants(now) {
+			continue
+		}
+		// Leader's time is proposed, so all members revoke the same grants.
+		ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+		_, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRevokeExpiredRoleGrants: &pb.InternalAuthRevokeExpiredRoleGrantsRequest{Now: now.Unix()}})
+		cancel()
+		if err != nil {
+			lg.Warn("failed to revoke expired role grants", zap.Error(err))
+		}
+	}
+}
+
 func (s *EtcdServer) parseProposeCtxErr(err error, start time.Time) error {
 	switch err {
 	case context.Canceled:
@@ -2387,6 +2437,24 @@ func (s *EtcdServer) parseProposeCtxErr(err error, start time.Time) error {
 }
 
 func (s *EtcdServer) KV() mvcc.WatchableKV { return s.kv }
+
+// keySizes returns sizes of keys stored in the range, used to compute storage usage of roles with quota.
+func (s *EtcdServer) keySizes(key, rangeEnd []byte) map[string]int64 {
+	if len(rangeEnd) == 1 && rangeEnd[0] == 0 {
+		rangeEnd = []byte{}
+	}
+	txn := s.kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+	defer txn.End()
+	res, err := txn.Range(context.TODO(), key, rangeEnd, mvcc.RangeOptions{})
+	if err != nil {
+		s.Logger().Panic("failed to range keys for role quota", zap.Error(err))
+	}
+	sizes := make(map[string]int64, len(res.KVs))
+	for _, kv := range res.KVs {
+		sizes[string(kv.Key)] = int64(len(kv.Key) + len(kv.Value))
+	}
+	return sizes
+}
 func (s *EtcdServer) Backend() backend.Backend {
 	s.bemu.RLock()
 	defer s.bemu.RUnlock()
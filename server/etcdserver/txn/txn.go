@@ -534,6 +534,31 @@ func compareToPath(rv mvcc.ReadView, rt *pb.TxnRequest) []bool {
 	return txnPath
 }
 
+// ExecutedPuts returns puts the transaction executes, including puts of nested transactions, with branches
+// chosen by evaluating compares against the current state of kv.
+func ExecutedPuts(kv mvcc.KV, rt *pb.TxnRequest) []*pb.PutRequest {
+	rv := kv.Read(mvcc.ConcurrentReadTxMode, traceutil.TODO())
+	defer rv.End()
+	return executedPuts(rv, rt)
+}
+
+func executedPuts(rv mvcc.ReadView, rt *pb.TxnRequest) []*pb.PutRequest {
+	ops := rt.Success
+	if !applyCompares(rv, rt.Compare) {
+		ops = rt.Failure
+	}
+	var puts []*pb.PutRequest
+	for _, op := range ops {
+		switch tv := op.Request.(type) {
+		case *pb.RequestOp_RequestPut:
+			puts = append(puts, tv.RequestPut)
+		case *pb.RequestOp_RequestTxn:
+			puts = append(puts, executedPuts(rv, tv.RequestTxn)...)
+		}
+	}
+	return puts
+}
+
 func applyCompares(rv mvcc.ReadView, cmps []*pb.Compare) bool {
 	for _, c := range cmps {
 		if !applyCompare(rv, c) {
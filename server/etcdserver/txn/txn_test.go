@@ -258,7 +258,7 @@ func setupAuth(t *testing.T, be backend.Backend) auth.AuthStore {
 		return ch
 	}
 
-	tp, _ := auth.NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault)
+	tp, _ := auth.NewTokenProvider(zaptest.NewLogger(t), tokenTypeSimple, dummyIndexWaiter, simpleTokenTTLDefault, 0)
 
 	as := auth.NewAuthStore(lg, schema.NewAuthBackend(lg, be), tp, 4)
 
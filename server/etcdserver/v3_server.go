@@ -35,6 +35,7 @@ import (
 	"go.etcd.io/etcd/server/v3/storage/mvcc"
 	"go.etcd.io/raft/v3"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
@@ -83,20 +84,28 @@ type Authenticator interface {
 	AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error)
 	AuthDisable(ctx context.Context, r *pb.AuthDisableRequest) (*pb.AuthDisableResponse, error)
 	AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*pb.AuthStatusResponse, error)
+	WhoAmI(ctx context.Context, r *pb.AuthWhoAmIRequest) (*pb.AuthWhoAmIResponse, error)
 	Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error)
 	UserAdd(ctx context.Context, r *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error)
 	UserDelete(ctx context.Context, r *pb.AuthUserDeleteRequest) (*pb.AuthUserDeleteResponse, error)
 	UserChangePassword(ctx context.Context, r *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error)
+	UserVerifyPassword(ctx context.Context, r *pb.AuthUserVerifyPasswordRequest) (*pb.AuthUserVerifyPasswordResponse, error)
 	UserGrantRole(ctx context.Context, r *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
 	UserGet(ctx context.Context, r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error)
+	UserPermissionsAtRevision(ctx context.Context, r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error)
 	UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error)
+	UserSetRoles(ctx context.Context, r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error)
+	UserSetImmutable(ctx context.Context, r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error)
 	RoleAdd(ctx context.Context, r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error)
 	RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error)
 	RoleGet(ctx context.Context, r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse, error)
 	RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error)
+	RoleSetQuota(ctx context.Context, r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error)
+	RoleSetAdminPrefix(ctx context.Context, r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error)
 	RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	UsersWithRole(ctx context.Context, r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error)
 }
 
 func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeResponse, error) {
@@ -187,6 +196,9 @@ func (s *EtcdServer) Txn(ctx context.Context, r *pb.TxnRequest) (*pb.TxnResponse
 		if serr := s.doSerialize(ctx, chk, get); serr != nil {
 			return nil, serr
 		}
+		if err == nil {
+			readOnlyTxns.Inc()
+		}
 		return resp, err
 	}
 
@@ -487,6 +499,14 @@ func (s *EtcdServer) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*
 	return resp.(*pb.AuthStatusResponse), nil
 }
 
+func (s *EtcdServer) WhoAmI(ctx context.Context, r *pb.AuthWhoAmIRequest) (*pb.AuthWhoAmIResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthWhoAmI: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthWhoAmIResponse), nil
+}
+
 func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	if err := s.linearizableReadNotify(ctx); err != nil {
 		return nil, err
@@ -498,13 +518,46 @@ func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest
 	defer func() {
 		if r != nil {
 			r.Password = ""
+			r.Assertion = ""
 		}
 	}()
 
+	// Check limit before CheckPassword to avoid spending CPU on bcrypt comparison for rate limited users.
+	// Assertion is limited by the user it was issued for, which is known only once it is verified.
+	if r.Assertion == "" {
+		if err := s.authLimiter.Allow(r.Name); err != nil {
+			lg.Warn(
+				"authentication was rate limited",
+				zap.String("user", r.Name),
+			)
+			return nil, err
+		}
+	}
+
 	var resp proto.Message
+	var name string
 	for {
-		checkedRevision, err := s.AuthStore().CheckPassword(r.Name, r.Password)
+		name = r.Name
+		var checkedRevision uint64
+		var err error
+		if r.Assertion != "" {
+			// Assertion names the user, so name is only checked to match it if given. Attempts with
+			// assertions that cannot be verified name no user and are limited together under empty name.
+			name, checkedRevision, err = s.AuthStore().CheckAssertion(r.Name, r.Assertion)
+			if lerr := s.authLimiter.Allow(name); lerr != nil {
+				lg.Warn(
+					"authentication was rate limited",
+					zap.String("user", name),
+				)
+				return nil, lerr
+			}
+		} else {
+			checkedRevision, err = s.AuthStore().CheckPassword(r.Name, r.Password)
+		}
 		if err != nil {
+			if err == auth.ErrAuthFailed || err == auth.ErrInvalidAssertion {
+				s.authLimiter.Failed(name)
+			}
 			if err != auth.ErrAuthNotEnabled {
 				lg.Warn(
 					"invalid authentication was requested",
@@ -523,8 +576,9 @@ func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest
 		// internalReq doesn't need to have Password because the above s.AuthStore().CheckPassword() already did it.
 		// In addition, it will let a WAL entry not record password as a plain text.
 		internalReq := &pb.InternalAuthenticateRequest{
-			Name:        r.Name,
+			Name:        name,
 			SimpleToken: st,
+			Assertion:   r.Assertion != "",
 		}
 
 		resp, err = s.raftRequestOnce(ctx, pb.InternalRaftRequest{Authenticate: internalReq})
@@ -535,8 +589,9 @@ func (s *EtcdServer) Authenticate(ctx context.Context, r *pb.AuthenticateRequest
 			break
 		}
 
-		lg.Info("revision when password checked became stale; retrying")
+		lg.Info("revision when password or assertion checked became stale; retrying")
 	}
+	s.authLimiter.Succeeded(name)
 
 	return resp.(*pb.AuthenticateResponse), nil
 }
@@ -583,8 +638,56 @@ func (s *EtcdServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChang
 	return resp.(*pb.AuthUserChangePasswordResponse), nil
 }
 
+// UserVerifyPassword checks the password with the same bcrypt comparison as Authenticate, but doesn't
+// issue a token nor affect rate limiting of authentication. Only root is allowed to verify passwords.
+func (s *EtcdServer) UserVerifyPassword(ctx context.Context, r *pb.AuthUserVerifyPasswordRequest) (*pb.AuthUserVerifyPasswordResponse, error) {
+	defer func() {
+		if r != nil {
+			r.Password = ""
+		}
+	}()
+
+	if err := s.linearizableReadNotify(ctx); err != nil {
+		return nil, err
+	}
+
+	authInfo, err := s.AuthInfoFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.AuthStore().IsAdminPermitted(authInfo); err != nil {
+		return nil, err
+	}
+
+	valid, err := s.AuthStore().VerifyPassword(r.Name, r.Password)
+	if err != nil {
+		if (err != auth.ErrUserNotFound && err != auth.ErrNoPasswordUser) || s.Cfg.AuthVerifyPasswordUserNotFound {
+			return nil, err
+		}
+		valid = false
+	}
+	return &pb.AuthUserVerifyPasswordResponse{Header: s.newHeader(), Valid: valid}, nil
+}
+
 func (s *EtcdServer) UserGrantRole(ctx context.Context, r *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error) {
-	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserGrantRole: r})
+	if r.TTL == 0 && s.Cfg.MaxRolesPerUser == 0 {
+		resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserGrantRole: r})
+		if err != nil {
+			return nil, err
+		}
+		return resp.(*pb.AuthUserGrantRoleResponse), nil
+	}
+
+	// Temporary grants and the limit of roles are only applied by members of 3.6 and later.
+	if !s.clusterVersionAtLeast(version.V3_6) {
+		return nil, errors.ErrUnsupportedClusterVersion
+	}
+	// Expiration and limit are computed once when the request is proposed, so all members apply the same ones.
+	ir := &pb.InternalAuthUserGrantRoleRequest{Grant: r, MaxRoles: int64(s.Cfg.MaxRolesPerUser)}
+	if r.TTL > 0 {
+		ir.ExpireTime = time.Now().Add(time.Duration(r.TTL) * time.Second).Unix()
+	}
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{InternalAuthUserGrantRole: ir})
 	if err != nil {
 		return nil, err
 	}
@@ -599,6 +702,14 @@ func (s *EtcdServer) UserGet(ctx context.Context, r *pb.AuthUserGetRequest) (*pb
 	return resp.(*pb.AuthUserGetResponse), nil
 }
 
+func (s *EtcdServer) UserPermissionsAtRevision(ctx context.Context, r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserPermissionsAtRevision: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserPermissionsAtRevisionResponse), nil
+}
+
 func (s *EtcdServer) UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error) {
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserList: r})
 	if err != nil {
@@ -615,6 +726,27 @@ func (s *EtcdServer) UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRol
 	return resp.(*pb.AuthUserRevokeRoleResponse), nil
 }
 
+func (s *EtcdServer) UserSetRoles(ctx context.Context, r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	req := pb.InternalRaftRequest{AuthUserSetRoles: r}
+	if s.Cfg.MaxRolesPerUser > 0 {
+		// Limit is set once when the request is proposed, so all members apply the same one.
+		req = pb.InternalRaftRequest{InternalAuthUserSetRoles: &pb.InternalAuthUserSetRolesRequest{SetRoles: r, MaxRoles: int64(s.Cfg.MaxRolesPerUser)}}
+	}
+	resp, err := s.raftRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserSetRolesResponse), nil
+}
+
+func (s *EtcdServer) UserSetImmutable(ctx context.Context, r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserSetImmutable: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserSetImmutableResponse), nil
+}
+
 func (s *EtcdServer) RoleAdd(ctx context.Context, r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error) {
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleAdd: r})
 	if err != nil {
@@ -647,6 +779,14 @@ func (s *EtcdServer) RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*
 	return resp.(*pb.AuthRoleListResponse), nil
 }
 
+func (s *EtcdServer) UsersWithRole(ctx context.Context, r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUsersWithRole: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUsersWithRoleResponse), nil
+}
+
 func (s *EtcdServer) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error) {
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleRevokePermission: r})
 	if err != nil {
@@ -655,6 +795,22 @@ func (s *EtcdServer) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRev
 	return resp.(*pb.AuthRoleRevokePermissionResponse), nil
 }
 
+func (s *EtcdServer) RoleSetQuota(ctx context.Context, r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleSetQuota: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthRoleSetQuotaResponse), nil
+}
+
+func (s *EtcdServer) RoleSetAdminPrefix(ctx context.Context, r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleSetAdminPrefix: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthRoleSetAdminPrefixResponse), nil
+}
+
 func (s *EtcdServer) RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error) {
 	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleDelete: r})
 	if err != nil {
@@ -732,6 +888,9 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		if authInfo != nil {
 			r.Header.Username = authInfo.Username
 			r.Header.AuthRevision = authInfo.Revision
+			if s.clusterVersionAtLeast(version.V3_6) {
+				r.Header.Time = authInfo.Time
+			}
 		}
 	}
 
@@ -960,16 +1119,24 @@ func (s *EtcdServer) linearizableReadNotify(ctx context.Context) error {
 
 func (s *EtcdServer) AuthInfoFromCtx(ctx context.Context) (*auth.AuthInfo, error) {
 	authInfo, err := s.AuthStore().AuthInfoFromCtx(ctx)
-	if authInfo != nil || err != nil {
-		return authInfo, err
+	if err != nil {
+		return nil, err
+	}
+	if authInfo == nil && s.Cfg.ClientCertAuthEnabled {
+		authInfo = s.AuthStore().AuthInfoFromTLS(ctx)
 	}
-	if !s.Cfg.ClientCertAuthEnabled {
-		return nil, nil
+	if authInfo != nil {
+		// permissions of the request are checked at the time it was received
+		authInfo.Time = time.Now().Unix()
 	}
-	authInfo = s.AuthStore().AuthInfoFromTLS(ctx)
 	return authInfo, nil
 }
 
+func (s *EtcdServer) clusterVersionAtLeast(v semver.Version) bool {
+	cv := s.ClusterVersion()
+	return cv != nil && !cv.LessThan(v)
+}
+
 func (s *EtcdServer) Downgrade(ctx context.Context, r *pb.DowngradeRequest) (*pb.DowngradeResponse, error) {
 	switch r.Action {
 	case pb.DowngradeRequest_VALIDATE:
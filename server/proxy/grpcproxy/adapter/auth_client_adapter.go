@@ -40,6 +40,10 @@ func (s *as2ac) AuthStatus(ctx context.Context, in *pb.AuthStatusRequest, opts .
 	return s.as.AuthStatus(ctx, in)
 }
 
+func (s *as2ac) WhoAmI(ctx context.Context, in *pb.AuthWhoAmIRequest, opts ...grpc.CallOption) (*pb.AuthWhoAmIResponse, error) {
+	return s.as.WhoAmI(ctx, in)
+}
+
 func (s *as2ac) Authenticate(ctx context.Context, in *pb.AuthenticateRequest, opts ...grpc.CallOption) (*pb.AuthenticateResponse, error) {
 	return s.as.Authenticate(ctx, in)
 }
@@ -60,10 +64,22 @@ func (s *as2ac) RoleList(ctx context.Context, in *pb.AuthRoleListRequest, opts .
 	return s.as.RoleList(ctx, in)
 }
 
+func (s *as2ac) UsersWithRole(ctx context.Context, in *pb.AuthUsersWithRoleRequest, opts ...grpc.CallOption) (*pb.AuthUsersWithRoleResponse, error) {
+	return s.as.UsersWithRole(ctx, in)
+}
+
 func (s *as2ac) RoleRevokePermission(ctx context.Context, in *pb.AuthRoleRevokePermissionRequest, opts ...grpc.CallOption) (*pb.AuthRoleRevokePermissionResponse, error) {
 	return s.as.RoleRevokePermission(ctx, in)
 }
 
+func (s *as2ac) RoleSetQuota(ctx context.Context, in *pb.AuthRoleSetQuotaRequest, opts ...grpc.CallOption) (*pb.AuthRoleSetQuotaResponse, error) {
+	return s.as.RoleSetQuota(ctx, in)
+}
+
+func (s *as2ac) RoleSetAdminPrefix(ctx context.Context, in *pb.AuthRoleSetAdminPrefixRequest, opts ...grpc.CallOption) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	return s.as.RoleSetAdminPrefix(ctx, in)
+}
+
 func (s *as2ac) RoleGrantPermission(ctx context.Context, in *pb.AuthRoleGrantPermissionRequest, opts ...grpc.CallOption) (*pb.AuthRoleGrantPermissionResponse, error) {
 	return s.as.RoleGrantPermission(ctx, in)
 }
@@ -80,10 +96,18 @@ func (s *as2ac) UserGet(ctx context.Context, in *pb.AuthUserGetRequest, opts ...
 	return s.as.UserGet(ctx, in)
 }
 
+func (s *as2ac) UserPermissionsAtRevision(ctx context.Context, in *pb.AuthUserPermissionsAtRevisionRequest, opts ...grpc.CallOption) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	return s.as.UserPermissionsAtRevision(ctx, in)
+}
+
 func (s *as2ac) UserList(ctx context.Context, in *pb.AuthUserListRequest, opts ...grpc.CallOption) (*pb.AuthUserListResponse, error) {
 	return s.as.UserList(ctx, in)
 }
 
+func (s *as2ac) UserVerifyPassword(ctx context.Context, in *pb.AuthUserVerifyPasswordRequest, opts ...grpc.CallOption) (*pb.AuthUserVerifyPasswordResponse, error) {
+	return s.as.UserVerifyPassword(ctx, in)
+}
+
 func (s *as2ac) UserGrantRole(ctx context.Context, in *pb.AuthUserGrantRoleRequest, opts ...grpc.CallOption) (*pb.AuthUserGrantRoleResponse, error) {
 	return s.as.UserGrantRole(ctx, in)
 }
@@ -92,6 +116,14 @@ func (s *as2ac) UserRevokeRole(ctx context.Context, in *pb.AuthUserRevokeRoleReq
 	return s.as.UserRevokeRole(ctx, in)
 }
 
+func (s *as2ac) UserSetRoles(ctx context.Context, in *pb.AuthUserSetRolesRequest, opts ...grpc.CallOption) (*pb.AuthUserSetRolesResponse, error) {
+	return s.as.UserSetRoles(ctx, in)
+}
+
+func (s *as2ac) UserSetImmutable(ctx context.Context, in *pb.AuthUserSetImmutableRequest, opts ...grpc.CallOption) (*pb.AuthUserSetImmutableResponse, error) {
+	return s.as.UserSetImmutable(ctx, in)
+}
+
 func (s *as2ac) UserChangePassword(ctx context.Context, in *pb.AuthUserChangePasswordRequest, opts ...grpc.CallOption) (*pb.AuthUserChangePasswordResponse, error) {
 	return s.as.UserChangePassword(ctx, in)
 }
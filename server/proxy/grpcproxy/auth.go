@@ -41,6 +41,10 @@ func (ap *AuthProxy) AuthStatus(ctx context.Context, r *pb.AuthStatusRequest) (*
 	return ap.authClient.AuthStatus(ctx, r)
 }
 
+func (ap *AuthProxy) WhoAmI(ctx context.Context, r *pb.AuthWhoAmIRequest) (*pb.AuthWhoAmIResponse, error) {
+	return ap.authClient.WhoAmI(ctx, r)
+}
+
 func (ap *AuthProxy) Authenticate(ctx context.Context, r *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
 	return ap.authClient.Authenticate(ctx, r)
 }
@@ -61,10 +65,22 @@ func (ap *AuthProxy) RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*
 	return ap.authClient.RoleList(ctx, r)
 }
 
+func (ap *AuthProxy) UsersWithRole(ctx context.Context, r *pb.AuthUsersWithRoleRequest) (*pb.AuthUsersWithRoleResponse, error) {
+	return ap.authClient.UsersWithRole(ctx, r)
+}
+
 func (ap *AuthProxy) RoleRevokePermission(ctx context.Context, r *pb.AuthRoleRevokePermissionRequest) (*pb.AuthRoleRevokePermissionResponse, error) {
 	return ap.authClient.RoleRevokePermission(ctx, r)
 }
 
+func (ap *AuthProxy) RoleSetQuota(ctx context.Context, r *pb.AuthRoleSetQuotaRequest) (*pb.AuthRoleSetQuotaResponse, error) {
+	return ap.authClient.RoleSetQuota(ctx, r)
+}
+
+func (ap *AuthProxy) RoleSetAdminPrefix(ctx context.Context, r *pb.AuthRoleSetAdminPrefixRequest) (*pb.AuthRoleSetAdminPrefixResponse, error) {
+	return ap.authClient.RoleSetAdminPrefix(ctx, r)
+}
+
 func (ap *AuthProxy) RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
 	return ap.authClient.RoleGrantPermission(ctx, r)
 }
@@ -81,6 +97,10 @@ func (ap *AuthProxy) UserGet(ctx context.Context, r *pb.AuthUserGetRequest) (*pb
 	return ap.authClient.UserGet(ctx, r)
 }
 
+func (ap *AuthProxy) UserPermissionsAtRevision(ctx context.Context, r *pb.AuthUserPermissionsAtRevisionRequest) (*pb.AuthUserPermissionsAtRevisionResponse, error) {
+	return ap.authClient.UserPermissionsAtRevision(ctx, r)
+}
+
 func (ap *AuthProxy) UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error) {
 	return ap.authClient.UserList(ctx, r)
 }
@@ -93,6 +113,18 @@ func (ap *AuthProxy) UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRol
 	return ap.authClient.UserRevokeRole(ctx, r)
 }
 
+func (ap *AuthProxy) UserSetRoles(ctx context.Context, r *pb.AuthUserSetRolesRequest) (*pb.AuthUserSetRolesResponse, error) {
+	return ap.authClient.UserSetRoles(ctx, r)
+}
+
+func (ap *AuthProxy) UserSetImmutable(ctx context.Context, r *pb.AuthUserSetImmutableRequest) (*pb.AuthUserSetImmutableResponse, error) {
+	return ap.authClient.UserSetImmutable(ctx, r)
+}
+
 func (ap *AuthProxy) UserChangePassword(ctx context.Context, r *pb.AuthUserChangePasswordRequest) (*pb.AuthUserChangePasswordResponse, error) {
 	return ap.authClient.UserChangePassword(ctx, r)
 }
+
+func (ap *AuthProxy) UserVerifyPassword(ctx context.Context, r *pb.AuthUserVerifyPasswordRequest) (*pb.AuthUserVerifyPasswordResponse, error) {
+	return ap.authClient.UserVerifyPassword(ctx, r)
+}
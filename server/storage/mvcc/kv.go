@@ -137,6 +137,14 @@ type KV interface {
 type WatchableKV interface {
 	KV
 	Watchable
+	WriteObservable
+}
+
+// WriteObservable is the interface that wraps the ObserveWrites function.
+type WriteObservable interface {
+	// ObserveWrites registers f to be called synchronously with the events of
+	// every write transaction when it ends. f must not block or access the KV.
+	ObserveWrites(f func(evs []mvccpb.Event))
 }
 
 // Watchable is the interface that wraps the NewWatchStream function.
@@ -64,6 +64,9 @@ type watchableStore struct {
 	// The key of the map is the key that the watcher watches on.
 	synced watcherGroup
 
+	// writeObserver is called with events of every write transaction, see ObserveWrites.
+	writeObserver func(evs []mvccpb.Event)
+
 	stopc chan struct{}
 	wg    sync.WaitGroup
 }
@@ -115,6 +118,12 @@ func (s *watchableStore) NewWatchStream() WatchStream {
 	}
 }
 
+func (s *watchableStore) ObserveWrites(f func(evs []mvccpb.Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeObserver = f
+}
+
 func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc) {
 	wa := &watcher{
 		key:    key,
@@ -42,6 +42,9 @@ func (tw *watchableStoreTxnWrite) End() {
 	// when asynchronous event posting checks the current store revision
 	tw.s.mu.Lock()
 	tw.s.notify(rev, evs)
+	if tw.s.writeObserver != nil {
+		tw.s.writeObserver(evs)
+	}
 	tw.TxnWrite.End()
 	tw.s.mu.Unlock()
 }
@@ -182,11 +182,15 @@ type EtcdProcessClusterConfig struct {
 	CompactHashCheckTime    time.Duration
 	GoFailEnabled           bool
 	CompactionBatchLimit    int
+	BackendBatchInterval    time.Duration
+	BackendBatchLimit       int
 
 	WarningUnaryRequestDuration             time.Duration
 	ExperimentalWarningUnaryRequestDuration time.Duration
 	PeerProxy                               bool
 	WatchProcessNotifyInterval              time.Duration
+	// DebugConsistentIndexTrailer is only passed to members running current version, as older ones don't support it.
+	DebugConsistentIndexTrailer bool
 }
 
 func DefaultConfig() *EtcdProcessClusterConfig {
@@ -341,10 +345,25 @@ func WithCompactionBatchLimit(limit int) EPClusterOption {
 	return func(c *EtcdProcessClusterConfig) { c.CompactionBatchLimit = limit }
 }
 
+// WithBackendBatchInterval sets the maximum time before the backend transaction is committed.
+func WithBackendBatchInterval(interval time.Duration) EPClusterOption {
+	return func(c *EtcdProcessClusterConfig) { c.BackendBatchInterval = interval }
+}
+
+// WithBackendBatchLimit sets the maximum number of operations before the backend transaction is committed.
+func WithBackendBatchLimit(limit int) EPClusterOption {
+	return func(c *EtcdProcessClusterConfig) { c.BackendBatchLimit = limit }
+}
+
 func WithWatchProcessNotifyInterval(interval time.Duration) EPClusterOption {
 	return func(c *EtcdProcessClusterConfig) { c.WatchProcessNotifyInterval = interval }
 }
 
+// WithDebugConsistentIndexTrailer enables returning consistent index of the member in trailer of unary responses.
+func WithDebugConsistentIndexTrailer(enabled bool) EPClusterOption {
+	return func(c *EtcdProcessClusterConfig) { c.DebugConsistentIndexTrailer = enabled }
+}
+
 func WithPeerProxy(enabled bool) EPClusterOption {
 	return func(c *EtcdProcessClusterConfig) { c.PeerProxy = enabled }
 }
@@ -582,6 +601,12 @@ func (cfg *EtcdProcessClusterConfig) EtcdServerProcessConfig(tb testing.TB, i in
 	if cfg.CompactionBatchLimit != 0 {
 		args = append(args, "--experimental-compaction-batch-limit", fmt.Sprintf("%d", cfg.CompactionBatchLimit))
 	}
+	if cfg.BackendBatchInterval != 0 {
+		args = append(args, "--backend-batch-interval", cfg.BackendBatchInterval.String())
+	}
+	if cfg.BackendBatchLimit != 0 {
+		args = append(args, "--backend-batch-limit", fmt.Sprintf("%d", cfg.BackendBatchLimit))
+	}
 	if cfg.WarningUnaryRequestDuration != 0 {
 		args = append(args, "--warning-unary-request-duration", cfg.WarningUnaryRequestDuration.String())
 	}
@@ -591,6 +616,11 @@ func (cfg *EtcdProcessClusterConfig) EtcdServerProcessConfig(tb testing.TB, i in
 	if cfg.WatchProcessNotifyInterval != 0 {
 		args = append(args, "--experimental-watch-progress-notify-interval", cfg.WatchProcessNotifyInterval.String())
 	}
+	if cfg.DebugConsistentIndexTrailer {
+		if cfg.Version == CurrentVersion || (cfg.Version == MinorityLastVersion && i <= cfg.ClusterSize/2) || (cfg.Version == QuorumLastVersion && i > cfg.ClusterSize/2) {
+			args = append(args, "--experimental-debug-consistent-index-trailer")
+		}
+	}
 	if cfg.SnapshotCatchUpEntries != etcdserver.DefaultSnapshotCatchUpEntries {
 		if cfg.Version == CurrentVersion || (cfg.Version == MinorityLastVersion && i <= cfg.ClusterSize/2) || (cfg.Version == QuorumLastVersion && i > cfg.ClusterSize/2) {
 			args = append(args, "--experimental-snapshot-catchup-entries", fmt.Sprintf("%d", cfg.SnapshotCatchUpEntries))
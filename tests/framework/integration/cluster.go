@@ -141,8 +141,13 @@ type ClusterConfig struct {
 
 	DiscoveryURL string
 
-	AuthToken    string
-	AuthTokenTTL uint
+	AuthToken             string
+	AuthTokenTTL          uint
+	AuthRevisionRetention uint
+	// AuthAssertionIssuer, AuthAssertionAudience and AuthAssertionPublicKeys configure authentication by assertion.
+	AuthAssertionIssuer     string
+	AuthAssertionAudience   string
+	AuthAssertionPublicKeys []string
 
 	QuotaBackendBytes int64
 
@@ -174,6 +179,8 @@ type ClusterConfig struct {
 	ExperimentalMaxLearners     int
 	DisableStrictReconfigCheck  bool
 	CorruptCheckTime            time.Duration
+
+	DebugConsistentIndexTrailer bool
 }
 
 type Cluster struct {
@@ -267,6 +274,10 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			MemberNumber:                memberNumber,
 			AuthToken:                   c.Cfg.AuthToken,
 			AuthTokenTTL:                c.Cfg.AuthTokenTTL,
+			AuthRevisionRetention:       c.Cfg.AuthRevisionRetention,
+			AuthAssertionIssuer:         c.Cfg.AuthAssertionIssuer,
+			AuthAssertionAudience:       c.Cfg.AuthAssertionAudience,
+			AuthAssertionPublicKeys:     c.Cfg.AuthAssertionPublicKeys,
 			PeerTLS:                     c.Cfg.PeerTLS,
 			ClientTLS:                   c.Cfg.ClientTLS,
 			QuotaBackendBytes:           c.Cfg.QuotaBackendBytes,
@@ -289,6 +300,7 @@ func (c *Cluster) mustNewMember(t testutil.TB) *Member {
 			ExperimentalMaxLearners:     c.Cfg.ExperimentalMaxLearners,
 			DisableStrictReconfigCheck:  c.Cfg.DisableStrictReconfigCheck,
 			CorruptCheckTime:            c.Cfg.CorruptCheckTime,
+			DebugConsistentIndexTrailer: c.Cfg.DebugConsistentIndexTrailer,
 		})
 	m.DiscoveryURL = c.Cfg.DiscoveryURL
 	return m
@@ -595,6 +607,10 @@ type MemberConfig struct {
 	ClientTLS                   *transport.TLSInfo
 	AuthToken                   string
 	AuthTokenTTL                uint
+	AuthRevisionRetention       uint
+	AuthAssertionIssuer         string
+	AuthAssertionAudience       string
+	AuthAssertionPublicKeys     []string
 	QuotaBackendBytes           int64
 	MaxTxnOps                   uint
 	MaxRequestBytes             uint
@@ -615,6 +631,7 @@ type MemberConfig struct {
 	ExperimentalMaxLearners     int
 	DisableStrictReconfigCheck  bool
 	CorruptCheckTime            time.Duration
+	DebugConsistentIndexTrailer bool
 }
 
 // MustNewMember return an inited member with the given name. If peerTLS is
@@ -692,6 +709,10 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	if mcfg.AuthTokenTTL != 0 {
 		m.TokenTTL = mcfg.AuthTokenTTL
 	}
+	m.AuthRevisionRetention = mcfg.AuthRevisionRetention
+	m.AuthAssertionIssuer = mcfg.AuthAssertionIssuer
+	m.AuthAssertionAudience = mcfg.AuthAssertionAudience
+	m.AuthAssertionPublicKeys = mcfg.AuthAssertionPublicKeys
 
 	m.BcryptCost = uint(bcrypt.MinCost) // use min bcrypt cost to speedy up integration testing
 
@@ -719,6 +740,7 @@ func MustNewMember(t testutil.TB, mcfg MemberConfig) *Member {
 	m.LeaseCheckpointPersist = mcfg.LeaseCheckpointPersist
 
 	m.WatchProgressNotifyInterval = mcfg.WatchProgressNotifyInterval
+	m.ExperimentalDebugConsistentIndexTrailer = mcfg.DebugConsistentIndexTrailer
 
 	m.InitialCorruptCheck = true
 	if mcfg.CorruptCheckTime > time.Duration(0) {
@@ -0,0 +1,101 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
+)
+
+func TestRegistration(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	r, err := concurrency.Register(cli, "/registration/a", "addr", concurrency.WithRegistrationTTL(30))
+	require.NoError(t, err)
+	resp, err := cli.Get(context.Background(), "/registration/a")
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "addr", string(resp.Kvs[0].Value))
+	assert.Equal(t, int64(r.Lease()), resp.Kvs[0].Lease)
+
+	require.NoError(t, r.Deregister())
+	resp, err = cli.Get(context.Background(), "/registration/a")
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+}
+
+func TestRegistrationLeaseLost(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	r, err := concurrency.Register(cli, "/registration/b", "addr", concurrency.WithRegistrationTTL(3))
+	require.NoError(t, err)
+	defer r.Deregister()
+	_, err = cli.Revoke(context.Background(), r.Lease())
+	require.NoError(t, err)
+
+	select {
+	case <-r.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatal("lease loss was not signaled")
+	}
+	select {
+	case <-r.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("registration is not done after lease loss")
+	}
+}
+
+func TestRegistrationReregister(t *testing.T) {
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: exampleEndpoints()})
+	require.NoError(t, err)
+	defer cli.Close()
+
+	r, err := concurrency.Register(cli, "/registration/c", "addr", concurrency.WithRegistrationTTL(3), concurrency.WithReregister(10*time.Millisecond))
+	require.NoError(t, err)
+	lost := r.Lease()
+	_, err = cli.Revoke(context.Background(), lost)
+	require.NoError(t, err)
+
+	select {
+	case <-r.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatal("lease loss was not signaled")
+	}
+	require.Eventually(t, func() bool {
+		resp, err := cli.Get(context.Background(), "/registration/c")
+		return err == nil && len(resp.Kvs) == 1 && resp.Kvs[0].Lease != int64(lost) && resp.Kvs[0].Lease == int64(r.Lease())
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case <-r.Done():
+		t.Fatal("registration is done despite re-registration")
+	default:
+	}
+
+	require.NoError(t, r.Deregister())
+	resp, err := cli.Get(context.Background(), "/registration/c")
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+}
@@ -17,11 +17,13 @@ package clientv3test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -33,6 +35,7 @@ import (
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/api/v3/version"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/clientv3util"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
 )
 
@@ -288,6 +291,87 @@ func TestKVRange(t *testing.T) {
 	}
 }
 
+func TestKVRangeStream(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	for _, key := range []string{"fo", "foo/0", "foo/1", "foo/2", "foo/3", "foo/4", "foo/5", "foo/6", "fop"} {
+		if _, err := kv.Put(ctx, key, "v1"); err != nil {
+			t.Fatalf("couldn't put %q (%v)", key, err)
+		}
+	}
+
+	var kvs []*mvccpb.KeyValue
+	var revision int64
+	pages := 0
+	for resp := range clientv3.RangeStream(ctx, kv, "foo/", clientv3.WithRangeStreamPageSize(3)) {
+		if resp.Err != nil {
+			t.Fatalf("couldn't read page %d (%v)", pages, resp.Err)
+		}
+		if pages == 0 {
+			revision = resp.Revision
+			// writes after the first page are not visible in following pages
+			if _, err := kv.Put(ctx, "foo/5", "v2"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := kv.Put(ctx, "foo/7", "v2"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if resp.Revision != revision {
+			t.Errorf("page %d revision = %d, want %d", pages, resp.Revision, revision)
+		}
+		kvs = append(kvs, resp.Kvs...)
+		pages++
+	}
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	wresp, err := kv.Get(ctx, "foo/", clientv3.WithPrefix(), clientv3.WithRev(revision))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wresp.Kvs, kvs) {
+		t.Errorf("kvs = %+v, want %+v", kvs, wresp.Kvs)
+	}
+
+	var empty []clientv3.RangeStreamResponse
+	for resp := range clientv3.RangeStream(ctx, kv, "bar/") {
+		empty = append(empty, resp)
+	}
+	if len(empty) != 1 || empty[0].Err != nil || len(empty[0].Kvs) != 0 || empty[0].Revision == 0 {
+		t.Errorf("responses for missing prefix = %+v, want single empty page", empty)
+	}
+
+	// Next page might be already read when the first one is received, so only one of the following ones fails.
+	var lastErr error
+	pages = 0
+	for resp := range clientv3.RangeStream(ctx, kv, "foo/", clientv3.WithRangeStreamPageSize(1)) {
+		if pages == 0 {
+			putResp, err := kv.Put(ctx, "foo/0", "v3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = kv.Compact(ctx, putResp.Header.Revision); err != nil {
+				t.Fatal(err)
+			}
+		}
+		lastErr = resp.Err
+		pages++
+	}
+	if !errors.Is(lastErr, rpctypes.ErrCompacted) {
+		t.Errorf("last page err = %v, want %v", lastErr, rpctypes.ErrCompacted)
+	}
+	if pages > 3 {
+		t.Errorf("pages = %d, want at most 3", pages)
+	}
+}
+
 func TestKVGetErrConnClosed(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -393,6 +477,140 @@ func TestKVDeleteRange(t *testing.T) {
 	}
 }
 
+func TestKVCompareValueAndDelete(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	tests := []struct {
+		name          string
+		put           bool
+		expectedValue string
+		wSucceeded    bool
+	}{
+		{name: "not found", expectedValue: "foo"},
+		{name: "not found with empty value", expectedValue: ""},
+		{name: "mismatch", put: true, expectedValue: "bar"},
+		{name: "match", put: true, expectedValue: "foo", wSucceeded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := kv.Delete(ctx, "key"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.put {
+				if _, err := kv.Put(ctx, "key", "foo"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resp, err := clientv3util.CompareValueAndDelete(ctx, kv, "key", tt.expectedValue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Succeeded != tt.wSucceeded {
+				t.Errorf("succeeded = %v, want %v", resp.Succeeded, tt.wSucceeded)
+			}
+			getResp, err := kv.Get(ctx, "key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wExists := tt.put && !tt.wSucceeded; (getResp.Count == 1) != wExists {
+				t.Errorf("key exists = %v, want %v", getResp.Count == 1, wExists)
+			}
+		})
+	}
+}
+
+func TestKVCompareMultiAndSwap(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kv := clus.RandClient()
+	ctx := context.TODO()
+
+	tests := []struct {
+		name        string
+		put         map[string]string
+		expected    map[string]string
+		wSucceeded  bool
+		wFailedKeys []string
+		wCurrent    map[string]string
+	}{
+		{
+			name:       "all match",
+			put:        map[string]string{"a": "x", "b": "y"},
+			expected:   map[string]string{"a": "x", "b": "y"},
+			wSucceeded: true,
+		},
+		{
+			name:        "partial match",
+			put:         map[string]string{"a": "x", "b": "other", "c": "old"},
+			expected:    map[string]string{"a": "x", "b": "y"},
+			wFailedKeys: []string{"b"},
+			wCurrent:    map[string]string{"a": "x", "b": "other", "c": "old"},
+		},
+		{
+			name:        "missing key",
+			put:         map[string]string{"a": "x"},
+			expected:    map[string]string{"a": "x", "b": ""},
+			wFailedKeys: []string{"b"},
+			wCurrent:    map[string]string{"a": "x"},
+		},
+		{
+			name:        "none match",
+			put:         map[string]string{"a": "other", "b": "other"},
+			expected:    map[string]string{"a": "x", "b": "y"},
+			wFailedKeys: []string{"a", "b"},
+			wCurrent:    map[string]string{"a": "other", "b": "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := kv.Delete(ctx, "", clientv3.WithPrefix()); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.put {
+				if _, err := kv.Put(ctx, k, v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resp, err := clientv3util.CompareMultiAndSwap(ctx, kv, tt.expected, "c", "z")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Succeeded != tt.wSucceeded {
+				t.Errorf("succeeded = %v, want %v", resp.Succeeded, tt.wSucceeded)
+			}
+			if !reflect.DeepEqual(resp.FailedKeys, tt.wFailedKeys) {
+				t.Errorf("failed keys = %v, want %v", resp.FailedKeys, tt.wFailedKeys)
+			}
+			var current map[string]string
+			if resp.Current != nil {
+				current = map[string]string{}
+				for k, kv := range resp.Current {
+					current[k] = string(kv.Value)
+				}
+			}
+			if !reflect.DeepEqual(current, tt.wCurrent) {
+				t.Errorf("current = %v, want %v", current, tt.wCurrent)
+			}
+			getResp, err := kv.Get(ctx, "c")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if swapped := getResp.Count == 1 && string(getResp.Kvs[0].Value) == "z"; swapped != tt.wSucceeded {
+				t.Errorf("swapped = %v, want %v", swapped, tt.wSucceeded)
+			}
+		})
+	}
+}
+
 func TestKVCompactError(t *testing.T) {
 	integration2.BeforeTest(t)
 
@@ -896,3 +1114,63 @@ func TestBalancerSupportLearner(t *testing.T) {
 		t.Errorf("expect no error (balancer should retry when request to learner fails), got error: %v", err)
 	}
 }
+
+// TestBalancerAvoidsLearner verifies that client with learner-aware endpoint selection doesn't send requests to learner.
+func TestBalancerAvoidsLearner(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3, DisableStrictReconfigCheck: true})
+	defer clus.Terminate(t)
+
+	// we have to add and launch learner member after initial cluster was created, because
+	// bootstrapping a cluster with learner member is not supported.
+	clus.AddAndLaunchLearnerMember(t)
+	<-clus.Members[3].ReadyNotify()
+
+	var learnerRejections atomic.Int32
+	countLearnerRejections := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if errors.Is(err, rpctypes.ErrGRPCNotSupportedForLearner) {
+			learnerRejections.Add(1)
+		}
+		return err
+	}
+	cfg := clientv3.Config{
+		Endpoints:                  []string{clus.Members[3].GRPCURL(), clus.Members[0].GRPCURL()},
+		DialTimeout:                5 * time.Second,
+		DialOptions:                []grpc.DialOption{grpc.WithBlock(), grpc.WithChainUnaryInterceptor(countLearnerRejections)},
+		MemberRolesRefreshInterval: time.Hour,
+	}
+	cli, err := integration2.NewClient(t, cfg)
+	if err != nil {
+		t.Fatalf("failed to create clientv3: %v", err)
+	}
+	defer cli.Close()
+
+	// client learns roles before it's returned, rejection of member list sent to learner doesn't count
+	learnerRejections.Store(0)
+	for i := 0; i < 10; i++ {
+		if _, err := cli.Put(context.Background(), "foo", strconv.Itoa(i)); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if _, err := cli.Get(context.Background(), "foo"); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	if n := learnerRejections.Load(); n != 0 {
+		t.Errorf("expect no request sent to learner, got %d rejected by it", n)
+	}
+
+	// endpoints changed, so roles are refreshed for new endpoints too
+	cli.SetEndpoints(clus.Members[1].GRPCURL(), clus.Members[3].GRPCURL())
+	time.Sleep(time.Second)
+	learnerRejections.Store(0)
+	for i := 0; i < 10; i++ {
+		if _, err := cli.Get(context.Background(), "foo"); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+	}
+	if n := learnerRejections.Load(); n != 0 {
+		t.Errorf("expect no request sent to learner after endpoints change, got %d rejected by it", n)
+	}
+}
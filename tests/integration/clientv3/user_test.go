@@ -16,11 +16,13 @@ package clientv3test
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
 
+	"go.etcd.io/etcd/api/v3/authpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	integration2 "go.etcd.io/etcd/tests/v3/framework/integration"
@@ -151,3 +153,57 @@ func TestGetTokenWithoutAuth(t *testing.T) {
 		t.Errorf("other errors:%v", err)
 	}
 }
+
+func TestImportRBAC(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	authapi := clus.RandClient()
+	ctx := context.TODO()
+
+	_, err := authapi.ImportRBAC(ctx, clientv3.RBACConfig{
+		Users: []clientv3.RBACUser{{Name: "foo", Password: "bar", Roles: []string{"undefined-role"}}},
+	})
+	if err == nil || err.Error() != `user "foo" references undefined role "undefined-role"` {
+		t.Fatalf("expected undefined role error, got %v", err)
+	}
+	if _, err = authapi.UserGet(ctx, "foo"); err != rpctypes.ErrUserNotFound {
+		t.Fatalf("expected %v, got %v", rpctypes.ErrUserNotFound, err)
+	}
+
+	config := clientv3.RBACConfig{
+		Roles: []clientv3.RBACRole{{Name: "reader", Permissions: []clientv3.RBACPermission{
+			{Key: "a", RangeEnd: "b", PermType: clientv3.PermissionType(clientv3.PermRead)},
+		}}},
+		Users: []clientv3.RBACUser{{Name: "foo", Password: "bar", Roles: []string{"reader"}}},
+	}
+	resp, err := authapi.ImportRBAC(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCreated := []string{`role "reader"`, `permission READ ["a", "b") of role "reader"`, `user "foo"`, `role "reader" of user "foo"`}
+	if !reflect.DeepEqual(resp.Created, expectCreated) || len(resp.Updated) != 0 || len(resp.Skipped) != 0 {
+		t.Fatalf("unexpected first import result %+v", resp)
+	}
+
+	config.Roles[0].Permissions[0].PermType = clientv3.PermissionType(clientv3.PermReadWrite)
+	resp, err = authapi.ImportRBAC(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectUpdated := []string{`permission READWRITE ["a", "b") of role "reader"`}
+	expectSkipped := []string{`role "reader"`, `user "foo"`, `role "reader" of user "foo"`}
+	if len(resp.Created) != 0 || !reflect.DeepEqual(resp.Updated, expectUpdated) || !reflect.DeepEqual(resp.Skipped, expectSkipped) {
+		t.Fatalf("unexpected second import result %+v", resp)
+	}
+
+	roleResp, err := authapi.RoleGet(ctx, "reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roleResp.Perm) != 1 || roleResp.Perm[0].PermType != authpb.READWRITE {
+		t.Fatalf("unexpected permissions of imported role %v", roleResp.Perm)
+	}
+}
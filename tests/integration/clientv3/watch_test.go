@@ -1215,3 +1215,113 @@ func testWatchClose(t *testing.T, wctx *watchctx) {
 		t.Fatalf("read wch got %v; expected closed channel", wresp)
 	}
 }
+
+// TestWatchWithInitial ensures that WatchWithInitial neither misses nor duplicates
+// events between the initial value and the watch, while the key is being written.
+func TestWatchWithInitial(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const writes = 100
+	writer := clus.Client(0)
+	donec := make(chan error, 1)
+	go func() {
+		for i := 0; i < writes; i++ {
+			if _, err := writer.Put(ctx, "a", strconv.Itoa(i)); err != nil {
+				donec <- err
+				return
+			}
+		}
+		donec <- nil
+	}()
+
+	cli := clus.Client(1)
+	// wait for some writes to happen, so the initial value is read in the middle of them
+	wch := cli.Watch(ctx, "a", clientv3.WithRev(1))
+	for i := 0; i < writes/2; {
+		wresp := <-wch
+		i += len(wresp.Events)
+	}
+
+	resp, ich, err := clientv3.WatchWithInitial(ctx, cli, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatalf("expected initial value, got %v", resp.Kvs)
+	}
+	last, err := strconv.Atoi(string(resp.Kvs[0].Value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastRev := resp.Kvs[0].ModRevision
+	for last < writes-1 {
+		wresp, ok := <-ich
+		if !ok {
+			t.Fatalf("watch closed after value %d, err: %v", last, ctx.Err())
+		}
+		if wresp.Err() != nil {
+			t.Fatal(wresp.Err())
+		}
+		for _, ev := range wresp.Events {
+			v, err := strconv.Atoi(string(ev.Kv.Value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != last+1 || ev.Kv.ModRevision <= lastRev {
+				t.Fatalf("expected value %d after revision %d, got value %d at revision %d", last+1, lastRev, v, ev.Kv.ModRevision)
+			}
+			last, lastRev = v, ev.Kv.ModRevision
+		}
+	}
+	if err := <-donec; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWatchBatch ensures watches created in a batch each receive events of their own keys.
+func TestWatchBatch(t *testing.T) {
+	integration2.BeforeTest(t)
+
+	clus := integration2.NewCluster(t, &integration2.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keys := []string{"a", "b", "c"}
+	specs := make([]clientv3.WatchSpec, len(keys))
+	for i, key := range keys {
+		specs[i] = clientv3.WatchSpec{Key: key, Opts: []clientv3.OpOption{clientv3.WithCreatedNotify()}}
+	}
+	wchs := clientv3.WatchBatch(ctx, cli, specs...)
+	if len(wchs) != len(keys) {
+		t.Fatalf("expected %d watch channels, got %d", len(keys), len(wchs))
+	}
+	for i, wch := range wchs {
+		if wresp := <-wch; !wresp.Created {
+			t.Fatalf("watch of %q: expected created response, got %+v", keys[i], wresp)
+		}
+	}
+
+	for _, key := range keys {
+		if _, err := cli.Put(ctx, key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, wch := range wchs {
+		wresp := <-wch
+		if err := wresp.Err(); err != nil {
+			t.Fatalf("watch of %q: unexpected error %v", keys[i], err)
+		}
+		if len(wresp.Events) != 1 || string(wresp.Events[0].Kv.Key) != keys[i] {
+			t.Fatalf("watch of %q: unexpected events %v", keys[i], wresp.Events)
+		}
+	}
+}
@@ -210,3 +210,47 @@ func TestMetricsHealth(t *testing.T) {
 		t.Fatalf("expected '0' from etcd_server_health_failures, got %q", hv)
 	}
 }
+
+// TestMetricReadOnlyTxns checks that only transactions without writes are served without proposal.
+func TestMetricReadOnlyTxns(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	kvc := integration.ToGRPC(clus.Client(0)).KV
+	readOnlyTxns := func() int {
+		v, err := clus.Members[0].Metric("etcd_server_read_only_txns_total")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	rangeOp := &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("foo")}}}
+	putOp := &pb.RequestOp{Request: &pb.RequestOp_RequestPut{RequestPut: &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}}}
+	before := readOnlyTxns()
+	if _, err := kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{rangeOp}, Failure: []*pb.RequestOp{putOp}}); err != nil {
+		t.Fatal(err)
+	}
+	if after := readOnlyTxns(); after != before {
+		t.Fatalf("expected txn with write to go through proposal, read-only txns changed from %d to %d", before, after)
+	}
+	if _, err := kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{rangeOp}, Failure: []*pb.RequestOp{rangeOp}}); err != nil {
+		t.Fatal(err)
+	}
+	if after := readOnlyTxns(); after != before+1 {
+		t.Fatalf("expected read-only txn to be served without proposal, read-only txns changed from %d to %d", before, after)
+	}
+
+	futureRangeOp := &pb.RequestOp{Request: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: []byte("foo"), Revision: 100}}}
+	if _, err := kvc.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{futureRangeOp}}); err == nil {
+		t.Fatal("expected txn ranging over future revision to fail")
+	}
+	if after := readOnlyTxns(); after != before+1 {
+		t.Fatalf("expected failed read-only txn not to be counted, read-only txns changed from %d to %d", before, after)
+	}
+}
@@ -16,7 +16,9 @@ package snapshot_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -137,6 +139,67 @@ func TestSnapshotV3RestoreMulti(t *testing.T) {
 	}
 }
 
+// TestSnapshotV3RestoreWithProgress ensures that snapshot taken with progress
+// reporting restores correctly, and progress reaches size of the snapshot.
+func TestSnapshotV3RestoreWithProgress(t *testing.T) {
+	integration2.BeforeTest(t)
+	kvs := []kv{{"foo1", "bar1"}, {"foo2", "bar2"}, {"foo3", strings.Repeat("a", 1024*1024)}}
+	var progress []clientv3.SnapshotProgress
+	dbPath := createSnapshotFileWith(t, kvs, func(cli *clientv3.Client, ccfg clientv3.Config, dbPath string) error {
+		resp, err := cli.SnapshotWithProgress(context.Background(), func(p clientv3.SnapshotProgress) {
+			progress = append(progress, p)
+		})
+		if err != nil {
+			return err
+		}
+		defer resp.Snapshot.Close()
+		f, err := os.Create(dbPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, resp.Snapshot)
+		return err
+	})
+
+	if len(progress) < 2 {
+		t.Fatalf("expected progress reported for each snapshot chunk, got %v", progress)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i].ReceivedBytes <= progress[i-1].ReceivedBytes || progress[i].TotalBytes != progress[0].TotalBytes {
+			t.Fatalf("expected increasing received bytes of the same total, got %v after %v", progress[i], progress[i-1])
+		}
+	}
+	last := progress[len(progress)-1]
+	if last.ReceivedBytes != last.TotalBytes {
+		t.Fatalf("expected whole snapshot received, got %v", last)
+	}
+	fi, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != last.TotalBytes+sha256.Size {
+		t.Fatalf("expected snapshot file of %d bytes with checksum, got %d", last.TotalBytes+sha256.Size, fi.Size())
+	}
+
+	cURLs, _, srvs := restoreCluster(t, 1, dbPath)
+	defer srvs[0].Close()
+	cli, err := integration2.NewClient(t, clientv3.Config{Endpoints: []string{cURLs[0].String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	for i := range kvs {
+		gresp, err := cli.Get(context.Background(), kvs[i].k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(gresp.Kvs) != 1 || string(gresp.Kvs[0].Value) != kvs[i].v {
+			t.Fatalf("#%d: expected value of key %q restored, got %v", i, kvs[i].k, gresp.Kvs)
+		}
+	}
+}
+
 // TestCorruptedBackupFileCheck tests if we can correctly identify a corrupted backup file.
 func TestCorruptedBackupFileCheck(t *testing.T) {
 	dbPath := testutils.MustAbsPath("testdata/corrupted_backup.db")
@@ -169,6 +232,15 @@ type kv struct {
 
 // creates a snapshot file and returns the file path.
 func createSnapshotFile(t *testing.T, kvs []kv) string {
+	return createSnapshotFileWith(t, kvs, func(cli *clientv3.Client, ccfg clientv3.Config, dbPath string) error {
+		sp := snapshot.NewV3(zaptest.NewLogger(t))
+		_, err := sp.Save(context.Background(), ccfg, dbPath)
+		return err
+	})
+}
+
+// creates a snapshot file by calling save and returns the file path.
+func createSnapshotFileWith(t *testing.T, kvs []kv, save func(cli *clientv3.Client, ccfg clientv3.Config, dbPath string) error) string {
 	testutil.SkipTestIfShortMode(t,
 		"Snapshot creation tests are depending on embedded etcd server so are integration-level tests.")
 	clusterN := 1
@@ -208,10 +280,8 @@ func createSnapshotFile(t *testing.T, kvs []kv) string {
 		}
 	}
 
-	sp := snapshot.NewV3(zaptest.NewLogger(t))
 	dpPath := filepath.Join(t.TempDir(), fmt.Sprintf("snapshot%d.db", time.Now().Nanosecond()))
-	_, err = sp.Save(context.Background(), ccfg, dpPath)
-	if err != nil {
+	if err = save(cli, ccfg, dpPath); err != nil {
 		t.Fatal(err)
 	}
 	return dpPath
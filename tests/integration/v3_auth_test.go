@@ -16,11 +16,23 @@ package integration
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
 	"go.etcd.io/etcd/api/v3/authpb"
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
@@ -319,30 +331,49 @@ func TestV3AuthWithLeaseAttach(t *testing.T) {
 }
 
 func authSetupUsers(t *testing.T, auth pb.AuthClient, users []user) {
-	for _, user := range users {
-		if _, err := auth.UserAdd(context.TODO(), &pb.AuthUserAddRequest{Name: user.name, Password: user.password, Options: &authpb.UserAddOptions{NoPassword: false}}); err != nil {
-			t.Fatal(err)
-		}
-		if _, err := auth.RoleAdd(context.TODO(), &pb.AuthRoleAddRequest{Name: user.role}); err != nil {
-			t.Fatal(err)
-		}
-		if _, err := auth.UserGrantRole(context.TODO(), &pb.AuthUserGrantRoleRequest{User: user.name, Role: user.role}); err != nil {
-			t.Fatal(err)
-		}
+	authSetupUsersConcurrently(t, auth, users, 1)
+}
 
-		if len(user.key) == 0 {
-			continue
-		}
+// authSetupUsersConcurrently sets up users with at most concurrency of them at the same time.
+// Each user has its own role, so users can be set up independently of each other.
+func authSetupUsersConcurrently(t *testing.T, auth pb.AuthClient, users []user, concurrency int) {
+	g := errgroup.Group{}
+	g.SetLimit(concurrency)
+	for _, u := range users {
+		u := u
+		g.Go(func() error {
+			return authSetupUser(auth, u)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
 
-		perm := &authpb.Permission{
-			PermType: authpb.READWRITE,
-			Key:      []byte(user.key),
-			RangeEnd: []byte(user.end),
-		}
-		if _, err := auth.RoleGrantPermission(context.TODO(), &pb.AuthRoleGrantPermissionRequest{Name: user.role, Perm: perm}); err != nil {
-			t.Fatal(err)
-		}
+func authSetupUser(auth pb.AuthClient, user user) error {
+	if _, err := auth.UserAdd(context.TODO(), &pb.AuthUserAddRequest{Name: user.name, Password: user.password, Options: &authpb.UserAddOptions{NoPassword: false}}); err != nil {
+		return fmt.Errorf("UserAdd %q failed: %w", user.name, err)
+	}
+	if _, err := auth.RoleAdd(context.TODO(), &pb.AuthRoleAddRequest{Name: user.role}); err != nil {
+		return fmt.Errorf("RoleAdd %q failed: %w", user.role, err)
+	}
+	if _, err := auth.UserGrantRole(context.TODO(), &pb.AuthUserGrantRoleRequest{User: user.name, Role: user.role}); err != nil {
+		return fmt.Errorf("UserGrantRole %q to %q failed: %w", user.role, user.name, err)
+	}
+
+	if len(user.key) == 0 {
+		return nil
 	}
+
+	perm := &authpb.Permission{
+		PermType: authpb.READWRITE,
+		Key:      []byte(user.key),
+		RangeEnd: []byte(user.end),
+	}
+	if _, err := auth.RoleGrantPermission(context.TODO(), &pb.AuthRoleGrantPermissionRequest{Name: user.role, Perm: perm}); err != nil {
+		return fmt.Errorf("RoleGrantPermission to %q failed: %w", user.role, err)
+	}
+	return nil
 }
 
 func authSetupRoot(t *testing.T, auth pb.AuthClient) {
@@ -360,6 +391,40 @@ func authSetupRoot(t *testing.T, auth pb.AuthClient) {
 	}
 }
 
+func TestV3AuthSetupUsersConcurrently(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	auth := integration.ToGRPC(clus.Client(0)).Auth
+	var users []user
+	for i := 0; i < 40; i++ {
+		users = append(users, user{
+			name:     fmt.Sprintf("user%d", i),
+			password: fmt.Sprintf("user%d-123", i),
+			role:     fmt.Sprintf("role%d", i),
+			key:      fmt.Sprintf("k%d", i),
+		})
+	}
+	authSetupUsersConcurrently(t, auth, users, 8)
+
+	resp, err := auth.UserList(context.TODO(), &pb.AuthUserListRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.Users, len(users))
+	for _, u := range users {
+		roleResp, err := auth.RoleGet(context.TODO(), &pb.AuthRoleGetRequest{Role: u.role})
+		require.NoError(t, err)
+		require.Len(t, roleResp.Perm, 1)
+		assert.Equal(t, u.key, string(roleResp.Perm[0].Key))
+	}
+
+	authSetupRoot(t, auth)
+	for _, u := range users {
+		_, err := auth.Authenticate(context.TODO(), &pb.AuthenticateRequest{Name: u.name, Password: u.password})
+		require.NoError(t, err)
+	}
+}
+
 func TestV3AuthNonAuthorizedRPCs(t *testing.T) {
 	integration.BeforeTest(t)
 	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
@@ -584,6 +649,59 @@ func TestV3AuthWatchErrorAndWatchId0(t *testing.T) {
 	<-watchEndCh
 }
 
+// TestV3AuthWatchBatchPermissionDenied ensures watch of batch denied by permissions doesn't break other watches of
+// the batch.
+func TestV3AuthWatchBatchPermissionDenied(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k3",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	c, cerr := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	defer c.Close()
+
+	wChans := clientv3.WatchBatch(ctx, c,
+		clientv3.WatchSpec{Key: "k1"},
+		clientv3.WatchSpec{Key: "non-allowed-key"},
+		clientv3.WatchSpec{Key: "k2"},
+	)
+	watchResponse := <-wChans[1]
+	testutil.AssertNotNil(t, watchResponse.Err()) // permission denied
+
+	for _, key := range []string{"k1", "k2"} {
+		if _, err := c.Put(ctx, key, "val"); err != nil {
+			t.Fatalf("Unexpected error from Put: %v", err)
+		}
+	}
+	for i, key := range map[int]string{0: "k1", 2: "k2"} {
+		watchResponse := <-wChans[i]
+		if err := watchResponse.Err(); err != nil {
+			t.Fatalf("Unexpected error from watch of %q: %v", key, err)
+		}
+		if len(watchResponse.Events) != 1 || string(watchResponse.Events[0].Kv.Key) != key {
+			t.Fatalf("Unexpected events from watch of %q: %v", key, watchResponse.Events)
+		}
+	}
+}
+
 func TestV3AuthWithLeaseTimeToLive(t *testing.T) {
 	integration.BeforeTest(t)
 	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
@@ -666,3 +784,672 @@ func TestV3AuthWithLeaseTimeToLive(t *testing.T) {
 		t.Fatal("timetolive from user2 should be failed with permission denied")
 	}
 }
+
+func TestV3AuthRoleQuota(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// keys stored before the quota is set count against it, keys outside of the granted range don't
+	_, err = rootc.Put(ctx, "k1a", "12345")
+	require.NoError(t, err)
+	_, err = rootc.Put(ctx, "k3", "12345")
+	require.NoError(t, err)
+	_, err = rootc.RoleSetQuota(ctx, "role1", 20)
+	require.NoError(t, err)
+	assertRoleQuota(ctx, t, rootc, "role1", 20, 8)
+
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+
+	_, err = userc.Put(ctx, "k1b", "1234567")
+	require.NoError(t, err)
+	assertRoleQuota(ctx, t, rootc, "role1", 20, 18)
+
+	_, err = userc.Put(ctx, "k1c", "12345")
+	require.ErrorIs(t, err, rpctypes.ErrRoleQuotaExceeded)
+	_, err = userc.Txn(ctx).If(clientv3.Compare(clientv3.Version("k1c"), "=", 0)).Then(clientv3.OpPut("k1c", "12345")).Commit()
+	require.ErrorIs(t, err, rpctypes.ErrRoleQuotaExceeded)
+	// puts of the branch which is not executed don't count against the quota
+	_, err = userc.Txn(ctx).If(clientv3.Compare(clientv3.Version("k1b"), ">", 0)).
+		Then(clientv3.OpPut("k1b", "1234567")).
+		Else(clientv3.OpPut("k1c", "12345")).Commit()
+	require.NoError(t, err)
+	assertRoleQuota(ctx, t, rootc, "role1", 20, 18)
+	// overwriting a key with a value of the same size doesn't increase usage
+	_, err = userc.Put(ctx, "k1b", "7654321")
+	require.NoError(t, err)
+
+	_, err = userc.Delete(ctx, "k1a")
+	require.NoError(t, err)
+	assertRoleQuota(ctx, t, rootc, "role1", 20, 10)
+	_, err = userc.Put(ctx, "k1c", "12345")
+	require.NoError(t, err)
+
+	// usage is recomputed from the stored keys on restart
+	clus.Members[0].Stop(t)
+	require.NoError(t, clus.Members[0].Restart(t))
+	integration.WaitClientV3WithKey(t, rootc.KV, "k1b")
+	assertRoleQuota(ctx, t, rootc, "role1", 20, 18)
+
+	_, err = rootc.RoleSetQuota(ctx, "role1", 0)
+	require.NoError(t, err)
+	_, err = userc.Put(ctx, "k1d", "12345")
+	require.NoError(t, err)
+	assertRoleQuota(ctx, t, rootc, "role1", 0, 0)
+}
+
+func assertRoleQuota(ctx context.Context, t *testing.T, c *clientv3.Client, role string, quotaBytes, usedBytes int64) {
+	resp, err := c.RoleGet(ctx, role)
+	require.NoError(t, err)
+	assert.Equal(t, quotaBytes, resp.QuotaBytes)
+	assert.Equal(t, usedBytes, resp.UsedBytes)
+}
+
+func TestV3AuthUserVerifyPassword(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := rootc.UserVerifyPassword(ctx, "user1", "user1-123")
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	resp, err = rootc.UserVerifyPassword(ctx, "user1", "wrong")
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+
+	// non-existing user is indistinguishable from a wrong password
+	resp, err = rootc.UserVerifyPassword(ctx, "user2", "user1-123")
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+
+	_, err = userc.UserVerifyPassword(ctx, "user1", "user1-123")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+}
+
+func TestV3AuthUserGrantRoleWithTTL(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(1).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = rootc.UserRevokeRole(ctx, "user1", "role1")
+	require.NoError(t, err)
+	_, err = rootc.UserGrantRole(ctx, "user1", "role1", clientv3.WithGrantTTL(time.Second))
+	require.NoError(t, err)
+	_, err = userc.Put(ctx, "k1", "v")
+	require.NoError(t, err)
+
+	// expired grant is revoked by the leader
+	for i := 0; ; i++ {
+		resp, err := rootc.UserGet(ctx, "user1")
+		require.NoError(t, err)
+		if len(resp.Roles) == 0 {
+			break
+		}
+		require.Less(t, i, 100, "expired role grant was not revoked")
+		time.Sleep(100 * time.Millisecond)
+	}
+	_, err = userc.Put(ctx, "k1", "v")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+}
+
+func TestV3AuthUserSetRoles(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+		{
+			name:     "user2",
+			password: "user2-123",
+			role:     "role2",
+			key:      "k2",
+			end:      "k3",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	status, err := rootc.AuthStatus(ctx)
+	require.NoError(t, err)
+	resp, err := rootc.UserSetRoles(ctx, "user1", []string{"role2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role2"}, resp.Roles)
+	assert.Equal(t, status.AuthRevision+1, resp.AuthRevision)
+
+	_, err = userc.Put(ctx, "k1", "v")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = userc.Put(ctx, "k2", "v")
+	require.NoError(t, err)
+
+	// a single missing role leaves the user unchanged
+	_, err = rootc.UserSetRoles(ctx, "user1", []string{"role1", "role3"})
+	require.ErrorIs(t, err, rpctypes.ErrRoleNotFound)
+	userResp, err := rootc.UserGet(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"role2"}, userResp.Roles)
+
+	_, err = rootc.UserSetRoles(ctx, "root", nil)
+	require.ErrorIs(t, err, rpctypes.ErrInvalidAuthMgmt)
+
+	_, err = userc.UserSetRoles(ctx, "user1", []string{"role1", "role2"})
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+}
+
+func TestV3AuthUserSetImmutable(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = rootc.UserSetImmutable(ctx, "user1", true)
+	require.NoError(t, err)
+	_, err = rootc.UserSetImmutable(ctx, "root", true)
+	require.NoError(t, err)
+
+	_, err = rootc.UserDelete(ctx, "user1")
+	require.ErrorIs(t, err, rpctypes.ErrUserImmutable)
+	_, err = rootc.UserRevokeRole(ctx, "user1", "role1")
+	require.ErrorIs(t, err, rpctypes.ErrUserImmutable)
+	_, err = rootc.RoleDelete(ctx, "role1")
+	require.ErrorIs(t, err, rpctypes.ErrUserImmutable)
+	_, err = rootc.UserDelete(ctx, "root")
+	require.ErrorIs(t, err, rpctypes.ErrInvalidAuthMgmt)
+	_, err = userc.Put(ctx, "k1", "v")
+	require.NoError(t, err)
+
+	_, err = userc.UserSetImmutable(ctx, "user1", false)
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+
+	_, err = rootc.UserSetImmutable(ctx, "user1", false)
+	require.NoError(t, err)
+	_, err = rootc.UserRevokeRole(ctx, "user1", "role1")
+	require.NoError(t, err)
+	_, err = rootc.UserDelete(ctx, "user1")
+	require.NoError(t, err)
+}
+
+func TestV3AuthWhoAmI(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	anonc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints()})
+	require.NoError(t, err)
+	defer anonc.Close()
+
+	// client of a cluster without auth gets an anonymous identity
+	resp, err := anonc.WhoAmI(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Name)
+	assert.Empty(t, resp.Roles)
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	status, err := rootc.AuthStatus(ctx)
+	require.NoError(t, err)
+	resp, err = userc.WhoAmI(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", resp.Name)
+	assert.Equal(t, []string{"role1"}, resp.Roles)
+	assert.Equal(t, []*authpb.Permission{{PermType: authpb.READWRITE, Key: []byte("k1"), RangeEnd: []byte("k2")}}, resp.Perms)
+	assert.Equal(t, status.AuthRevision, resp.AuthRevision)
+
+	resp, err = rootc.WhoAmI(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "root", resp.Name)
+	assert.Equal(t, []string{"root"}, resp.Roles)
+
+	_, err = anonc.WhoAmI(ctx)
+	require.ErrorIs(t, err, rpctypes.ErrUserEmpty)
+}
+
+func TestV3AuthUserPermissionsAtRevision(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 3, AuthRevisionRetention: 3})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	users := []user{
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+			key:      "k1",
+			end:      "k2",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	status, err := rootc.AuthStatus(ctx)
+	require.NoError(t, err)
+	granted := status.AuthRevision
+	_, err = rootc.UserRevokeRole(ctx, "user1", "role1")
+	require.NoError(t, err)
+
+	// every member retains the history, as it is built by applying auth changes
+	for i := range clus.Members {
+		c, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(i).Endpoints(), Username: "root", Password: "123"})
+		require.NoError(t, err)
+		resp, err := c.UserPermissionsAtRevision(ctx, "user1", granted)
+		c.Close()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"role1"}, resp.Roles)
+		assert.Equal(t, []*authpb.Permission{{PermType: authpb.READWRITE, Key: []byte("k1"), RangeEnd: []byte("k2")}}, resp.Perms)
+		assert.Equal(t, granted, resp.AuthRevision)
+	}
+
+	resp, err := rootc.UserPermissionsAtRevision(ctx, "user1", 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Roles)
+	assert.Equal(t, granted+1, resp.AuthRevision)
+
+	// users can query their own permissions only
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+	_, err = userc.UserPermissionsAtRevision(ctx, "user1", granted)
+	require.NoError(t, err)
+	_, err = userc.UserPermissionsAtRevision(ctx, "root", granted)
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+
+	_, err = rootc.UserPermissionsAtRevision(ctx, "user1", granted+2)
+	require.ErrorIs(t, err, rpctypes.ErrAuthFutureRevision)
+	_, err = rootc.UserPermissionsAtRevision(ctx, "user1", 1)
+	require.ErrorIs(t, err, rpctypes.ErrAuthRevisionNotRetained)
+}
+
+// TestV3AuthWatchAuthStatus ensures auth transitions are reported exactly once and in order,
+// to both authenticated and unauthenticated clients.
+func TestV3AuthWatchAuthStatus(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+	anonc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints()})
+	require.NoError(t, err)
+	defer anonc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	watches := map[string]clientv3.AuthStatusChan{
+		"root":            rootc.WatchAuthStatus(ctx, clientv3.WithAuthStatusWatchInterval(10*time.Millisecond)),
+		"unauthenticated": anonc.WatchAuthStatus(ctx, clientv3.WithAuthStatusWatchInterval(10*time.Millisecond)),
+	}
+	lastEvents := map[string]clientv3.AuthStatusEvent{}
+	observe := func(enabled bool) {
+		for name, watch := range watches {
+			ev, ok := <-watch
+			require.Truef(t, ok, "watch of %s client closed", name)
+			require.Equalf(t, enabled, ev.Enabled, "unexpected status observed by %s client", name)
+			if last, ok := lastEvents[name]; ok {
+				assert.GreaterOrEqualf(t, ev.AuthRevision, last.AuthRevision, "auth revision went back observed by %s client", name)
+				assert.GreaterOrEqualf(t, ev.Revision, last.Revision, "revision went back observed by %s client", name)
+			}
+			lastEvents[name] = ev
+		}
+	}
+
+	observe(true)
+	for i := 0; i < 3; i++ {
+		_, err = rootc.AuthDisable(ctx)
+		require.NoError(t, err)
+		observe(false)
+		_, err = rootc.AuthEnable(ctx)
+		require.NoError(t, err)
+		observe(true)
+	}
+
+	// Status didn't change, so no more transitions are reported.
+	time.Sleep(100 * time.Millisecond)
+	for name, watch := range watches {
+		select {
+		case ev := <-watch:
+			t.Errorf("unexpected transition observed by %s client: %+v", name, ev)
+		default:
+		}
+	}
+	// Watches are closed once context is canceled.
+	cancel()
+	for _, watch := range watches {
+		for range watch {
+		}
+	}
+}
+
+func TestV3AuthScopedAdmin(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "tenant-admin",
+			password: "admin-123",
+			role:     "admin-a",
+		},
+		{
+			name:     "user1",
+			password: "user1-123",
+			role:     "role1",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, role := range []string{"tenant-a", "tenant-b"} {
+		_, err = rootc.RoleAdd(ctx, role)
+		require.NoError(t, err)
+	}
+	_, err = rootc.RoleGrantPermission(ctx, "tenant-b", "/b/", "/b0", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.NoError(t, err)
+	_, err = rootc.RoleSetAdminPrefix(ctx, "admin-a", "/a/")
+	require.NoError(t, err)
+	resp, err := rootc.RoleGet(ctx, "admin-a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("/a/"), resp.AdminPrefix)
+
+	adminc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "tenant-admin", Password: "admin-123"})
+	require.NoError(t, err)
+	defer adminc.Close()
+
+	// permissions and roles within the prefix can be managed
+	_, err = adminc.RoleGrantPermission(ctx, "tenant-a", "/a/", "/a0", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.NoError(t, err)
+	_, err = adminc.UserGrantRole(ctx, "user1", "tenant-a")
+	require.NoError(t, err)
+
+	userc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "user1", Password: "user1-123"})
+	require.NoError(t, err)
+	defer userc.Close()
+	_, err = userc.Put(ctx, "/a/k", "v")
+	require.NoError(t, err)
+
+	_, err = adminc.UserRevokeRole(ctx, "user1", "tenant-a")
+	require.NoError(t, err)
+	_, err = userc.Put(ctx, "/a/k", "v")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+
+	// scoped admin cannot escalate beyond its prefix
+	_, err = adminc.RoleGrantPermission(ctx, "admin-a", "/b/", "/b0", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.RoleGrantPermission(ctx, "admin-a", "/a/", "\x00", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.RoleGrantPermission(ctx, "root", "/a/", "/a0", clientv3.PermissionType(clientv3.PermReadWrite))
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.UserGrantRole(ctx, "tenant-admin", "root")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.UserGrantRole(ctx, "tenant-admin", "tenant-b")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.UserRevokeRole(ctx, "root", "root")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.RoleSetAdminPrefix(ctx, "admin-a", "/")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.UserAdd(ctx, "user2", "user2-123")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = adminc.AuthDisable(ctx)
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+}
+
+func TestV3AuthUserTemplatePermission(t *testing.T) {
+	integration.BeforeTest(t)
+	clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	users := []user{
+		{
+			name:     "alice",
+			password: "alice-123",
+			role:     "role-alice",
+		},
+		{
+			name:     "bob",
+			password: "bob-123",
+			role:     "role-bob",
+		},
+	}
+	authSetupUsers(t, integration.ToGRPC(clus.Client(0)).Auth, users)
+	authSetupRoot(t, integration.ToGRPC(clus.Client(0)).Auth)
+
+	rootc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "root", Password: "123"})
+	require.NoError(t, err)
+	defer rootc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err = rootc.RoleAdd(ctx, "users")
+	require.NoError(t, err)
+	// template without placeholder in range end would permit keys of all users
+	_, err = rootc.RoleGrantPermission(ctx, "users", "/users/{user}/", "/users0", clientv3.PermissionType(clientv3.PermReadWrite), clientv3.WithUserTemplate())
+	require.ErrorIs(t, err, rpctypes.ErrInvalidAuthMgmt)
+	prefix := "/users/" + clientv3.UserTemplatePlaceholder + "/"
+	_, err = rootc.RoleGrantPermission(ctx, "users", prefix, clientv3.GetPrefixRangeEnd(prefix), clientv3.PermissionType(clientv3.PermReadWrite), clientv3.WithUserTemplate())
+	require.NoError(t, err)
+	for _, u := range users {
+		_, err = rootc.UserGrantRole(ctx, u.name, "users")
+		require.NoError(t, err)
+	}
+	resp, err := rootc.RoleGet(ctx, "users")
+	require.NoError(t, err)
+	require.Len(t, resp.Perm, 1)
+	assert.True(t, resp.Perm[0].UserTemplate)
+
+	alicec, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "alice", Password: "alice-123"})
+	require.NoError(t, err)
+	defer alicec.Close()
+	bobc, err := integration.NewClient(t, clientv3.Config{Endpoints: clus.Client(0).Endpoints(), Username: "bob", Password: "bob-123"})
+	require.NoError(t, err)
+	defer bobc.Close()
+
+	_, err = alicec.Put(ctx, "/users/alice/x", "1")
+	require.NoError(t, err)
+	_, err = bobc.Put(ctx, "/users/bob/x", "2")
+	require.NoError(t, err)
+	_, err = alicec.Put(ctx, "/users/bob/x", "3")
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = bobc.Get(ctx, "/users/alice/", clientv3.WithPrefix())
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+	_, err = alicec.Get(ctx, "/users/", clientv3.WithPrefix())
+	require.ErrorIs(t, err, rpctypes.ErrPermissionDenied)
+
+	getResp, err := bobc.Get(ctx, "/users/bob/", clientv3.WithPrefix())
+	require.NoError(t, err)
+	require.Len(t, getResp.Kvs, 1)
+	assert.Equal(t, "2", string(getResp.Kvs[0].Value))
+}
+
+func TestV3AuthenticateWithAssertion(t *testing.T) {
+	integration.BeforeTest(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "issuer.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	issuer, audience := "https://issuer.example.com", "etcd.example.com"
+
+	clus := integration.NewCluster(t, &integration.ClusterConfig{
+		Size:                    1,
+		AuthAssertionIssuer:     issuer,
+		AuthAssertionAudience:   audience,
+		AuthAssertionPublicKeys: []string{keyFile},
+	})
+	defer clus.Terminate(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	authc := integration.ToGRPC(clus.Client(0)).Auth
+	_, err = authc.UserAdd(ctx, &pb.AuthUserAddRequest{Name: "machine", Options: &authpb.UserAddOptions{NoPassword: true}})
+	require.NoError(t, err)
+	authSetupRoot(t, authc)
+
+	assertionWithAudience := func(user, audience string) string {
+		claims := jwt.MapClaims{"iss": issuer, "aud": audience, "sub": user, "exp": time.Now().Add(time.Minute).Unix()}
+		assertion, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
+		require.NoError(t, err)
+		return assertion
+	}
+	assertionFor := func(user string) string { return assertionWithAudience(user, audience) }
+
+	c, err := integration.NewClient(t, clientv3.Config{
+		Endpoints:     clus.Client(0).Endpoints(),
+		AuthAssertion: func(ctx context.Context) (string, error) { return assertionFor("machine"), nil },
+	})
+	require.NoError(t, err)
+	defer c.Close()
+	resp, err := c.WhoAmI(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "machine", resp.Name)
+
+	_, err = clus.Client(0).AuthenticateWithAssertion(ctx, assertionFor("missing"))
+	require.ErrorIs(t, err, rpctypes.ErrAuthFailed)
+	_, err = clus.Client(0).AuthenticateWithAssertion(ctx, assertionWithAudience("machine", "other.example.com"))
+	require.ErrorIs(t, err, rpctypes.ErrInvalidAssertion)
+	_, err = clus.Client(0).AuthenticateWithAssertion(ctx, "not-a-jwt")
+	require.ErrorIs(t, err, rpctypes.ErrInvalidAssertion)
+	// user without password can only authenticate by assertion
+	_, err = clus.Client(0).Authenticate(ctx, "machine", "")
+	require.Error(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -119,6 +120,52 @@ func TestV3PutRestart(t *testing.T) {
 	}
 }
 
+// TestV3ConsistentIndexTrailer ensures that member with debug consistent index trailer enabled returns
+// its consistent index in trailer of unary responses, growing with each applied put.
+func TestV3ConsistentIndexTrailer(t *testing.T) {
+	integration.BeforeTest(t)
+	tcs := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "Disabled", enabled: false},
+		{name: "Enabled", enabled: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			clus := integration.NewCluster(t, &integration.ClusterConfig{Size: 1, DebugConsistentIndexTrailer: tc.enabled})
+			defer clus.Terminate(t)
+
+			kvc := integration.ToGRPC(clus.RandClient()).KV
+			var lastIndex uint64
+			for i := 0; i < 3; i++ {
+				var trailer metadata.MD
+				if _, err := kvc.Put(context.TODO(), &pb.PutRequest{Key: []byte("foo"), Value: []byte("bar")}, grpc.Trailer(&trailer)); err != nil {
+					t.Fatalf("couldn't put key (%v)", err)
+				}
+				values := trailer.Get(rpctypes.MetadataConsistentIndexKey)
+				if !tc.enabled {
+					if len(values) != 0 {
+						t.Fatalf("expected no consistent index trailer, got %v", values)
+					}
+					continue
+				}
+				if len(values) != 1 {
+					t.Fatalf("expected single consistent index trailer, got %v", values)
+				}
+				index, err := strconv.ParseUint(values[0], 10, 64)
+				if err != nil {
+					t.Fatalf("couldn't parse consistent index %q (%v)", values[0], err)
+				}
+				if index <= lastIndex {
+					t.Fatalf("expected consistent index to grow with each put, got %d <= %d", index, lastIndex)
+				}
+				lastIndex = index
+			}
+		})
+	}
+}
+
 // TestV3CompactCurrentRev ensures keys are present when compacting on current revision.
 func TestV3CompactCurrentRev(t *testing.T) {
 	integration.BeforeTest(t)
@@ -0,0 +1,376 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+const (
+	// authRequestTimeout is longer than RequestTimeout, as adding users and authenticating requires hashing passwords.
+	authRequestTimeout = time.Second
+	// grantTimeout limits time in which authenticated request using committed grant needs to succeed.
+	// It needs to cover member restart caused by failpoint.
+	grantTimeout = 10 * time.Second
+)
+
+// rootCredentials are used by all clients of traffic with authentication enabled.
+var rootCredentials = clientCredentials{username: "root", password: "root"}
+
+// enableAuth creates root user with root role and enables authentication.
+func enableAuth(ctx context.Context, endpoints []string) error {
+	c, err := newEtcdClient(endpoints, clientCredentials{}, DefaultKeepAlive)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(ctx, 5*authRequestTimeout)
+	defer cancel()
+	if _, err = c.UserAdd(ctx, rootCredentials.username, rootCredentials.password); err != nil {
+		return err
+	}
+	if _, err = c.RoleAdd(ctx, "root"); err != nil {
+		return err
+	}
+	if _, err = c.UserGrantRole(ctx, rootCredentials.username, "root"); err != nil {
+		return err
+	}
+	_, err = c.AuthEnable(ctx)
+	return err
+}
+
+// authTraffic concurrently creates roles and users, grants them permission to a key and immediately puts the key
+// authenticated as the new user. Each grant bumps auth revision, so requests of users authenticated just before it
+// are rejected as old revision and need to be retried, like in TestV3AuthOldRevConcurrent.
+// Once grant is committed, put using it needs to eventually succeed.
+type authTraffic struct {
+	keyCount int
+	model    *rbacModel
+	report   *authTrafficReport
+}
+
+func newAuthTraffic(keyCount int) authTraffic {
+	return authTraffic{
+		keyCount: keyCount,
+		model:    newRBACModel(),
+	}
+}
+
+func (t authTraffic) ForRun() Traffic {
+	t.report = &authTrafficReport{}
+	return t
+}
+
+func (t authTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateAuthTraffic(tt, lg, t)
+}
+
+func (t authTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		id := ids.RequestId()
+		role, user := fmt.Sprintf("auth-role-%d", id), fmt.Sprintf("auth-user-%d", id)
+		key := fmt.Sprintf("/auth/%d", rand.Intn(t.keyCount))
+		if t.grant(ctx, c, limiter, role, user, key) {
+			t.putEventually(ctx, clientId, c, limiter, ids, user, key, finish)
+		}
+		t.cleanup(ctx, c, limiter, role, user)
+	}
+}
+
+// grant creates role with read-write permission to the key and user having the role, returns false if any step failed.
+func (t authTraffic) grant(ctx context.Context, c *recordingClient, limiter *trafficLimiter, role, user, key string) bool {
+	steps := []func(ctx context.Context) error{
+		func(ctx context.Context) error {
+			_, err := c.client.RoleAdd(ctx, role)
+			if err == nil {
+				t.model.RoleAdd(role)
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.RoleGrantPermission(ctx, role, key, "", clientv3.PermissionType(clientv3.PermReadWrite))
+			if err == nil {
+				t.model.RoleGrantPermission(role, key)
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.UserAdd(ctx, user, user)
+			if err == nil {
+				t.model.UserAdd(user)
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.UserGrantRole(ctx, user, role)
+			if err == nil {
+				t.model.UserGrantRole(user, role)
+			}
+			return err
+		},
+	}
+	for _, step := range steps {
+		limiter.Wait(ctx)
+		stepCtx, cancel := context.WithTimeout(ctx, authRequestTimeout)
+		err := step(stepCtx)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			return false
+		}
+	}
+	t.report.Granted()
+	return true
+}
+
+// putEventually puts the key authenticated as the user until it succeeds, reporting failure if model permits it,
+// but it didn't succeed within grantTimeout.
+func (t authTraffic) putEventually(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, user, key string, finish <-chan struct{}) {
+	if !t.model.Permitted(user, key) {
+		return
+	}
+	startRevision := t.authRevision(ctx, c)
+	deadline := time.Now().Add(grantTimeout)
+	var uc *recordingClient
+	defer func() {
+		if uc != nil {
+			c.history.History = c.history.Merge(uc.history.History)
+			uc.Close()
+		}
+	}()
+	var err error
+	attempts := 0
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		attempts++
+		limiter.Wait(ctx)
+		if uc == nil {
+			// Creating client authenticates the user.
+			uc, err = NewClient(c.lg, c.client.Endpoints(), clientCredentials{username: user, password: user}, c.keepAlive, ids, c.baseTime, c.dialOptions...)
+			if err != nil {
+				uc = nil
+				t.report.Failed(err)
+				continue
+			}
+		}
+		putCtx, cancel := context.WithTimeout(ctx, authRequestTimeout)
+		err = uc.Put(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err == nil {
+			var gap uint64
+			if endRevision := t.authRevision(ctx, c); startRevision != 0 && endRevision > startRevision {
+				gap = endRevision - startRevision
+			}
+			t.report.Put(gap)
+			return
+		}
+		t.report.Failed(err)
+	}
+	t.report.Violated(fmt.Sprintf("client: %d, user: %q, key: %q, attempts: %d, last error: %v", clientId, user, key, attempts, err))
+}
+
+// authRevision returns current auth revision, or zero if it couldn't be read.
+func (t authTraffic) authRevision(ctx context.Context, c *recordingClient) uint64 {
+	statusCtx, cancel := context.WithTimeout(ctx, authRequestTimeout)
+	defer cancel()
+	resp, err := c.client.AuthStatus(statusCtx)
+	if err != nil {
+		return 0
+	}
+	return resp.AuthRevision
+}
+
+// cleanup deletes the user and the role, so they don't accumulate. Model is updated before the request is sent,
+// as failed delete might still be committed.
+func (t authTraffic) cleanup(ctx context.Context, c *recordingClient, limiter *trafficLimiter, role, user string) {
+	t.model.UserDelete(user)
+	limiter.Wait(ctx)
+	deleteCtx, cancel := context.WithTimeout(ctx, authRequestTimeout)
+	_, err := c.client.UserDelete(deleteCtx, user)
+	cancel()
+	limiter.Adapt(ctx, err)
+
+	t.model.RoleDelete(role)
+	limiter.Wait(ctx)
+	deleteCtx, cancel = context.WithTimeout(ctx, authRequestTimeout)
+	_, err = c.client.RoleDelete(deleteCtx, role)
+	cancel()
+	limiter.Adapt(ctx, err)
+}
+
+// rbacModel is a simplified RBAC state machine, updated with auth requests acknowledged by etcd.
+// Requests that failed might have been committed too, so model skips them and only permits access that etcd has to permit.
+type rbacModel struct {
+	mux sync.Mutex
+	// roles maps role to keys it has read-write permission to.
+	roles map[string]map[string]bool
+	// users maps user to roles granted to it.
+	users map[string]map[string]bool
+}
+
+func newRBACModel() *rbacModel {
+	return &rbacModel{
+		roles: map[string]map[string]bool{},
+		users: map[string]map[string]bool{},
+	}
+}
+
+func (m *rbacModel) RoleAdd(role string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.roles[role] = map[string]bool{}
+}
+
+func (m *rbacModel) RoleGrantPermission(role, key string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if keys, ok := m.roles[role]; ok {
+		keys[key] = true
+	}
+}
+
+func (m *rbacModel) RoleDelete(role string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.roles, role)
+	for _, roles := range m.users {
+		delete(roles, role)
+	}
+}
+
+func (m *rbacModel) UserAdd(user string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.users[user] = map[string]bool{}
+}
+
+func (m *rbacModel) UserGrantRole(user, role string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if _, ok := m.roles[role]; !ok {
+		return
+	}
+	if roles, ok := m.users[user]; ok {
+		roles[role] = true
+	}
+}
+
+func (m *rbacModel) UserDelete(user string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.users, user)
+}
+
+// Permitted returns true if user has a role with read-write permission to the key.
+func (m *rbacModel) Permitted(user, key string) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for role := range m.users[user] {
+		if m.roles[role][key] {
+			return true
+		}
+	}
+	return false
+}
+
+// authTrafficReport collects results of authTraffic validation from all clients.
+type authTrafficReport struct {
+	mux    sync.Mutex
+	grants int
+	puts   int
+	// retries counts failed attempts of authenticated puts that eventually succeeded or were abandoned.
+	retries                int
+	oldRevisionErrors      int
+	permissionDeniedErrors int
+	// maxAuthRevisionGap is the largest number of auth revisions committed between grant and successful put using it.
+	maxAuthRevisionGap uint64
+	violations         []string
+}
+
+func (r *authTrafficReport) Granted() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.grants++
+}
+
+func (r *authTrafficReport) Put(authRevisionGap uint64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.puts++
+	if authRevisionGap > r.maxAuthRevisionGap {
+		r.maxAuthRevisionGap = authRevisionGap
+	}
+}
+
+func (r *authTrafficReport) Failed(err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.retries++
+	switch {
+	case errors.Is(err, rpctypes.ErrAuthOldRevision):
+		r.oldRevisionErrors++
+	case errors.Is(err, rpctypes.ErrPermissionDenied):
+		r.permissionDeniedErrors++
+	}
+}
+
+func (r *authTrafficReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateAuthTraffic(t *testing.T, lg *zap.Logger, traffic authTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Auth traffic", zap.Int("grants", r.grants), zap.Int("puts", r.puts), zap.Int("retries", r.retries),
+		zap.Int("old-revision-errors", r.oldRevisionErrors), zap.Int("permission-denied-errors", r.permissionDeniedErrors),
+		zap.Uint64("max-auth-revision-gap", r.maxAuthRevisionGap))
+	for _, violation := range r.violations {
+		t.Errorf("Broke auth guarantee: Authenticated request using committed grant didn't succeed, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.puts == 0 {
+		t.Errorf("No authenticated put succeeded, grants: %d, retries: %d", r.grants, r.retries)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// bulkScanTraffic models ETL and backup workloads, that bulk-load many keys and then read them with full range scan.
+// Each client repeatedly loads keyCount keys under its own part of prefix, then reads whole prefix in pages of pageSize
+// keys. Other clients keep loading their keys during the scan, so pages must be read at revision pinned by the first
+// page. Loads and scans are recorded in history, which is validated to check each scan observed exactly the keys
+// loaded up to its revision.
+type bulkScanTraffic struct {
+	prefix   string
+	keyCount int
+	pageSize int64
+	report   *bulkScanReport
+}
+
+func newBulkScanTraffic(prefix string, keyCount int, pageSize int64) bulkScanTraffic {
+	return bulkScanTraffic{
+		prefix:   prefix,
+		keyCount: keyCount,
+		pageSize: pageSize,
+	}
+}
+
+func (t bulkScanTraffic) ForRun() Traffic {
+	t.report = &bulkScanReport{}
+	return t
+}
+
+func (t bulkScanTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateBulkScans(tt, lg, t, operations)
+}
+
+func (t bulkScanTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		if !t.load(ctx, clientId, c, limiter, ids, finish) {
+			continue
+		}
+		limiter.Wait(ctx)
+		scanCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		kvs, _, err := c.PaginatedRange(scanCtx, t.prefix, t.pageSize)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err == nil {
+			t.report.Scanned(len(kvs))
+		}
+	}
+}
+
+// load puts keyCount keys of client with new values, returns whether all of them were put.
+func (t bulkScanTraffic) load(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, finish <-chan struct{}) bool {
+	for i := 0; i < t.keyCount; i++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-finish:
+			return false
+		default:
+		}
+		limiter.Wait(ctx)
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err := c.Put(putCtx, fmt.Sprintf("%s%d/%d", t.prefix, clientId, i), fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			return false
+		}
+	}
+	t.report.Loaded()
+	return true
+}
+
+// bulkScanReport counts loads and scans of bulkScanTraffic from all clients.
+type bulkScanReport struct {
+	mux         sync.Mutex
+	loads       int
+	scans       int
+	scannedKeys int
+	largestScan int
+}
+
+func (r *bulkScanReport) Loaded() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.loads++
+}
+
+func (r *bulkScanReport) Scanned(keys int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.scans++
+	r.scannedKeys += keys
+	if keys > r.largestScan {
+		r.largestScan = keys
+	}
+}
+
+func validateBulkScans(t *testing.T, lg *zap.Logger, traffic bulkScanTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Bulk scan traffic", zap.Int("loads", r.loads), zap.Int("scans", r.scans), zap.Int("scanned-keys", r.scannedKeys))
+	for _, violation := range model.ValidateBulkScans(operations, traffic.prefix) {
+		t.Errorf("Broke range guarantee: Atomic - paginated range pinned to revision observes exactly keys put up to it, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.largestScan <= int(traffic.pageSize) {
+		t.Errorf("No scan read more than a single page, largest scan: %d, pageSize: %d", r.largestScan, traffic.pageSize)
+	}
+}
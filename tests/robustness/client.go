@@ -16,44 +16,283 @@ package robustness
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/clientv3util"
 	"go.etcd.io/etcd/tests/v3/robustness/identity"
 	"go.etcd.io/etcd/tests/v3/robustness/model"
 )
 
 type recordingClient struct {
-	client   clientv3.Client
-	history  *model.AppendableHistory
-	baseTime time.Time
+	lg          *zap.Logger
+	client      clientv3.Client
+	credentials clientCredentials
+	keepAlive   keepAliveConfig
+	history     *model.AppendableHistory
+	baseTime    time.Time
+	readRetry   readRetryConfig
+	// physicalCompactions are compactions done by the client that waited for physical completion.
+	physicalCompactions []physicalCompaction
+	// endpoints are endpoints of the current connection.
+	endpoints []string
+	// reconnections are connections of client replaced by Reconnect, in order they happened.
+	reconnections []reconnection
+	// dialOptions are additional options of client connections, kept by reconnections and clients derived from it.
+	dialOptions []grpc.DialOption
+	// membershipChanges are membership operations done by the client, in order they were called.
+	membershipChanges []membershipChange
+	// consistentIndexes are consistent indexes returned by members in trailers of responses, only sent by members
+	// with debug consistent index trailer enabled.
+	consistentIndexMux sync.Mutex
+	consistentIndexes  []model.ConsistentIndexObservation
 }
 
-func NewClient(endpoints []string, ids identity.Provider, baseTime time.Time) (*recordingClient, error) {
-	cc, err := clientv3.New(clientv3.Config{
+// reconnection records client replacing its connection, so it can be correlated with operations recorded around it.
+// Time is relative to the client base time, the same as times of operations in history.
+type reconnection struct {
+	Time time.Duration
+	From []string
+	To   []string
+	// LastRevision is the newest revision observed by client before reconnecting, it's preserved by reconnection.
+	LastRevision int64
+	// Failed is set if new connection couldn't be established, client stays connected to previous endpoints.
+	Failed bool
+}
+
+// membershipChange records membership operation done by the client, so reconfiguration can be correlated with operations
+// recorded around it. They are not part of history, as model doesn't validate membership. Times are relative to
+// the client base time, the same as times of operations in history.
+type membershipChange struct {
+	Type  membershipChangeType
+	Start time.Duration
+	End   time.Duration
+	// MemberID is ID of the member added, removed or promoted, zero for listing members or failed add.
+	MemberID uint64
+	// Members are IDs of cluster members returned by the response, reflecting the change. Empty if operation failed.
+	Members []uint64
+	// Learners are IDs of learners among Members.
+	Learners []uint64
+	Err      error
+}
+
+type membershipChangeType string
+
+const (
+	MemberList         membershipChangeType = "memberList"
+	MemberAdd          membershipChangeType = "memberAdd"
+	MemberAddAsLearner membershipChangeType = "memberAddAsLearner"
+	MemberRemove       membershipChangeType = "memberRemove"
+	MemberPromote      membershipChangeType = "memberPromote"
+)
+
+// physicalCompaction records time between physical compaction request and confirmation that compacted revisions
+// were removed from the backend. Times are relative to the client base time.
+type physicalCompaction struct {
+	Revision int64
+	Start    time.Duration
+	End      time.Duration
+	// Interrupted is set if completion wasn't confirmed, for example because member restarted during compaction.
+	// Compaction might have still been applied, with its physical part resumed by member after restart,
+	// so its duration is not known.
+	Interrupted bool
+}
+
+// Duration returns time physical compaction took, only known if it wasn't interrupted.
+func (p physicalCompaction) Duration() time.Duration {
+	return p.End - p.Start
+}
+
+// DefaultReadRetry retries reads failed during fault injection a few times, recording only their final outcome.
+var DefaultReadRetry = readRetryConfig{
+	attempts: 3,
+	backoff:  50 * time.Millisecond,
+}
+
+// readRetryConfig configures retries of reads failed with retriable errors. Reads don't change etcd state, so retrying
+// them is safe, while writes are never retried as model expects each of them to be executed at most once.
+type readRetryConfig struct {
+	// attempts is the maximal number of attempts of each read, reads are not retried if it's below 2.
+	attempts int
+	// backoff is delay before the first retry, doubled on each next one.
+	backoff time.Duration
+	// recordAttempts records each failed attempt in history, otherwise only successful attempt is recorded.
+	recordAttempts bool
+}
+
+// clientCredentials are used by client to authenticate, zero value is used when authentication is not enabled.
+type clientCredentials struct {
+	username string
+	password string
+}
+
+// DefaultKeepAlive pings server rarely, while detecting connection that doesn't respond to a ping quickly.
+var DefaultKeepAlive = keepAliveConfig{
+	interval: 10 * time.Second,
+	timeout:  100 * time.Millisecond,
+}
+
+// keepAliveConfig configures gRPC keepalive pings of client. They determine how fast client detects dead connection,
+// for example to member isolated by failpoint, and fails requests sent over it, shaping the window in which
+// operations end with unknown result.
+type keepAliveConfig struct {
+	// interval is time without activity after which client pings server. gRPC raises intervals below 10s to 10s.
+	interval time.Duration
+	// timeout is time client waits for response to ping before closing the connection.
+	timeout time.Duration
+}
+
+func NewClient(lg *zap.Logger, endpoints []string, credentials clientCredentials, keepAlive keepAliveConfig, ids identity.Provider, baseTime time.Time, opts ...grpc.DialOption) (*recordingClient, error) {
+	c := &recordingClient{
+		lg:          lg,
+		credentials: credentials,
+		keepAlive:   keepAlive,
+		history:     model.NewAppendableHistory(ids),
+		baseTime:    baseTime,
+		endpoints:   endpoints,
+		dialOptions: opts,
+	}
+	cc, err := newEtcdClient(endpoints, credentials, keepAlive, c.connectionDialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	c.client = *cc
+	return c, nil
+}
+
+// connectionDialOptions returns dialOptions extended with recording of consistent indexes by the client.
+// It's not part of dialOptions, as clients derived from the client record their own consistent indexes.
+func (c *recordingClient) connectionDialOptions() []grpc.DialOption {
+	opts := append([]grpc.DialOption{}, c.dialOptions...)
+	return append(opts, grpc.WithChainUnaryInterceptor(c.recordConsistentIndex))
+}
+
+// recordConsistentIndex records consistent index returned in trailer of unary response, together with member that
+// returned it, taken from response header. Responses without trailer or header are skipped.
+func (c *recordingClient) recordConsistentIndex(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var trailer metadata.MD
+	start := time.Since(c.baseTime)
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Trailer(&trailer))...)
+	end := time.Since(c.baseTime)
+	values := trailer.Get(rpctypes.MetadataConsistentIndexKey)
+	if len(values) == 0 {
+		return err
+	}
+	index, parseErr := strconv.ParseUint(values[0], 10, 64)
+	resp, ok := reply.(interface {
+		GetHeader() *etcdserverpb.ResponseHeader
+	})
+	if parseErr != nil || !ok || resp.GetHeader() == nil || resp.GetHeader().MemberId == 0 {
+		return err
+	}
+	c.consistentIndexMux.Lock()
+	defer c.consistentIndexMux.Unlock()
+	c.consistentIndexes = append(c.consistentIndexes, model.ConsistentIndexObservation{
+		MemberId: resp.GetHeader().MemberId,
+		Index:    index,
+		Start:    start,
+		End:      end,
+	})
+	return err
+}
+
+func newEtcdClient(endpoints []string, credentials clientCredentials, keepAlive keepAliveConfig, opts ...grpc.DialOption) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
 		Endpoints:            endpoints,
+		Username:             credentials.username,
+		Password:             credentials.password,
 		Logger:               zap.NewNop(),
-		DialKeepAliveTime:    10 * time.Second,
-		DialKeepAliveTimeout: 100 * time.Millisecond,
+		DialKeepAliveTime:    keepAlive.interval,
+		DialKeepAliveTimeout: keepAlive.timeout,
+		DialOptions:          opts,
 	})
-	if err != nil {
-		return nil, err
+}
+
+// newMemberProbeClient creates client pinned to a single member endpoint, for probing what exactly the member returns.
+// Requests are neither retried nor failed over to other members, they return error of connection to the member.
+func newMemberProbeClient(endpoint string, credentials clientCredentials, keepAlive keepAliveConfig) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:            []string{endpoint},
+		PinEndpoint:          true,
+		Username:             credentials.username,
+		Password:             credentials.password,
+		Logger:               zap.NewNop(),
+		DialKeepAliveTime:    keepAlive.interval,
+		DialKeepAliveTimeout: keepAlive.timeout,
+	})
+}
+
+// Tag tags operations recorded from now on with type of traffic request they are done for, empty tag stops tagging.
+func (c *recordingClient) Tag(requestType string) {
+	c.history.SetTag(requestType)
+}
+
+// RetryReads configures client to retry reads failed with retriable errors.
+func (c *recordingClient) RetryReads(config readRetryConfig) {
+	c.readRetry = config
+}
+
+// isRetriableReadError returns true for errors returned when member is temporarily unable to serve requests,
+// for example during leader election or while isolated by failpoint.
+func isRetriableReadError(err error) bool {
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
+		return etcdErr.Code() == codes.Unavailable
 	}
-	return &recordingClient{
-		client:   *cc,
-		history:  model.NewAppendableHistory(ids),
-		baseTime: baseTime,
-	}, nil
+	return status.Code(err) == codes.Unavailable
 }
 
 func (c *recordingClient) Close() error {
 	return c.client.Close()
 }
 
+// Reconnect replaces connection of the client with a new one to the given endpoints. History recorded so far and
+// the last observed revision are kept, so operations before and after reconnection form a single timeline of
+// the client. Reconnection is recorded together with the time it happened.
+func (c *recordingClient) Reconnect(endpoints []string) error {
+	r := reconnection{
+		Time:         time.Since(c.baseTime),
+		From:         c.endpoints,
+		To:           endpoints,
+		LastRevision: c.history.LastRevision(),
+	}
+	cc, err := newEtcdClient(endpoints, c.credentials, c.keepAlive, c.connectionDialOptions()...)
+	r.Failed = err != nil
+	c.reconnections = append(c.reconnections, r)
+	c.lg.Debug("Client reconnected", zap.Duration("time", r.Time), zap.Strings("from", r.From), zap.Strings("to", r.To),
+		zap.Int64("last-revision", r.LastRevision), zap.Error(err))
+	if err != nil {
+		return err
+	}
+	c.client.Close()
+	c.client = *cc
+	c.endpoints = endpoints
+	return nil
+}
+
+// LastRevision returns the newest revision observed by client, including operations before reconnections.
+func (c *recordingClient) LastRevision() int64 {
+	return c.history.LastRevision()
+}
+
+// Reconnections returns reconnections of the client, in order they happened.
+func (c *recordingClient) Reconnections() []reconnection {
+	return c.reconnections
+}
+
 func (c *recordingClient) Get(ctx context.Context, key string) (*mvccpb.KeyValue, error) {
 	resp, err := c.Range(ctx, key, false)
 	if err != nil || len(resp) == 0 {
@@ -65,21 +304,161 @@ func (c *recordingClient) Get(ctx context.Context, key string) (*mvccpb.KeyValue
 	panic(fmt.Sprintf("Unexpected response size: %d", len(resp)))
 }
 
+// GetWithRevision reads key like Get, additionally returning revision of the response.
+func (c *recordingClient) GetWithRevision(ctx context.Context, key string) (*mvccpb.KeyValue, int64, error) {
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendRange(key, false, callTime, returnTime, resp, err)
+	}, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, resp.Header.Revision, nil
+	}
+	return resp.Kvs[0], resp.Header.Revision, nil
+}
+
+// SerializableGet reads key with serializable read served locally by the member client is connected to.
+// Read is not recorded in history, as it can observe stale state that cannot be linearized.
+func (c *recordingClient) SerializableGet(ctx context.Context, key string) (*clientv3.GetResponse, error) {
+	return c.client.Get(ctx, key, clientv3.WithSerializable())
+}
+
+// SerializablePrefixRange reads all keys with prefix with serializable read, not recorded in history, see SerializableGet.
+func (c *recordingClient) SerializablePrefixRange(ctx context.Context, prefix string) (*clientv3.GetResponse, error) {
+	return c.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSerializable())
+}
+
+// GetAtRevision reads key at historical revision. Read is not recorded in history, as model tracks only the latest state.
+func (c *recordingClient) GetAtRevision(ctx context.Context, key string, revision int64) (*clientv3.GetResponse, error) {
+	return c.client.Get(ctx, key, clientv3.WithRev(revision))
+}
+
 func (c *recordingClient) Range(ctx context.Context, key string, withPrefix bool) ([]*mvccpb.KeyValue, error) {
-	callTime := time.Since(c.baseTime)
 	ops := []clientv3.OpOption{}
 	if withPrefix {
 		ops = append(ops, clientv3.WithPrefix())
 	}
-	resp, err := c.client.Get(ctx, key, ops...)
-	returnTime := time.Since(c.baseTime)
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendRange(key, withPrefix, callTime, returnTime, resp, err)
+	}, key, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Kvs, nil
+}
+
+// SortedRange reads keys with prefix key, or just key, sorted by target in given order and truncated to limit.
+func (c *recordingClient) SortedRange(ctx context.Context, key string, withPrefix bool, target clientv3.SortTarget, order clientv3.SortOrder, limit int64) ([]*mvccpb.KeyValue, error) {
+	ops := []clientv3.OpOption{clientv3.WithSort(target, order), clientv3.WithLimit(limit)}
+	if withPrefix {
+		ops = append(ops, clientv3.WithPrefix())
+	}
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendSortedRange(key, withPrefix, target, order, limit, callTime, returnTime, resp, err)
+	}, key, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Kvs, nil
+}
+
+// ModRevisionRange reads keys with prefix key, or just key, filtered to ones with mod revision in inclusive
+// [minModRev, maxModRev] window, like incremental sync does. Zero bound is not applied.
+func (c *recordingClient) ModRevisionRange(ctx context.Context, key string, withPrefix bool, minModRev, maxModRev int64) ([]*mvccpb.KeyValue, error) {
+	ops := []clientv3.OpOption{clientv3.WithMinModRev(minModRev), clientv3.WithMaxModRev(maxModRev)}
+	if withPrefix {
+		ops = append(ops, clientv3.WithPrefix())
+	}
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendModRevisionRange(key, withPrefix, minModRev, maxModRev, callTime, returnTime, resp, err)
+	}, key, ops...)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Kvs, nil
+}
+
+// KeysOnlyRange reads keys with prefix key, or just key, returning them with their metadata, but without values.
+func (c *recordingClient) KeysOnlyRange(ctx context.Context, key string, withPrefix bool) ([]*mvccpb.KeyValue, error) {
+	ops := []clientv3.OpOption{clientv3.WithKeysOnly()}
+	if withPrefix {
+		ops = append(ops, clientv3.WithPrefix())
+	}
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendKeysOnlyRange(key, withPrefix, callTime, returnTime, resp, err)
+	}, key, ops...)
 	if err != nil {
 		return nil, err
 	}
-	c.history.AppendRange(key, withPrefix, callTime, returnTime, resp)
 	return resp.Kvs, nil
 }
 
+// RangeSnapshot reads all keys in [key, end), or all keys with prefix key if withPrefix is set, and returns them
+// together with response revision. Whole result is recorded as one operation so it can be validated as a consistent snapshot.
+func (c *recordingClient) RangeSnapshot(ctx context.Context, key, end string, withPrefix bool) ([]*mvccpb.KeyValue, int64, error) {
+	ops := []clientv3.OpOption{}
+	if withPrefix {
+		ops = append(ops, clientv3.WithPrefix())
+	} else {
+		ops = append(ops, clientv3.WithRange(end))
+	}
+	resp, err := c.get(ctx, func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error) {
+		c.history.AppendRangeSnapshot(key, end, withPrefix, callTime, returnTime, resp, err)
+	}, key, ops...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Kvs, resp.Header.Revision, nil
+}
+
+// PaginatedRange reads all keys with prefix in pages of pageSize keys, all read at revision of the first page, and
+// returns them together with that revision. Whole result is recorded as one range of prefix, so model validates
+// that pages together are equal to a single atomic range.
+func (c *recordingClient) PaginatedRange(ctx context.Context, prefix string, pageSize int64) ([]*mvccpb.KeyValue, int64, error) {
+	callTime := time.Since(c.baseTime)
+	var kvs []*mvccpb.KeyValue
+	var revision int64
+	var err error
+	for resp := range clientv3.RangeStream(ctx, c.client, prefix, clientv3.WithRangeStreamPageSize(pageSize)) {
+		kvs = append(kvs, resp.Kvs...)
+		revision, err = resp.Revision, resp.Err
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	returnTime := time.Since(c.baseTime)
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: revision}, Kvs: kvs, Count: int64(len(kvs))}
+	c.history.AppendRangeSnapshot(prefix, "", true, callTime, returnTime, resp, err)
+	if err != nil {
+		return nil, 0, err
+	}
+	return kvs, revision, nil
+}
+
+// get reads key, retrying read failed with retriable error as configured by RetryReads. Successful attempt is passed
+// to record, failed attempts only if recording of each attempt was configured.
+func (c *recordingClient) get(ctx context.Context, record func(callTime, returnTime time.Duration, resp *clientv3.GetResponse, err error), key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	delay := c.readRetry.backoff
+	for attempt := 1; ; attempt++ {
+		callTime := time.Since(c.baseTime)
+		resp, err := c.client.Get(ctx, key, opts...)
+		returnTime := time.Since(c.baseTime)
+		if err == nil || c.readRetry.recordAttempts {
+			record(callTime, returnTime, resp, err)
+		}
+		if err == nil || attempt >= c.readRetry.attempts || !isRetriableReadError(err) {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
 func (c *recordingClient) Put(ctx context.Context, key, value string) error {
 	callTime := time.Since(c.baseTime)
 	resp, err := c.client.Put(ctx, key, value)
@@ -88,6 +467,18 @@ func (c *recordingClient) Put(ctx context.Context, key, value string) error {
 	return err
 }
 
+// PutWithRevision puts key like Put, additionally returning revision of the write.
+func (c *recordingClient) PutWithRevision(ctx context.Context, key, value string) (int64, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Put(ctx, key, value)
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendPut(key, value, callTime, returnTime, resp, err)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
 func (c *recordingClient) Delete(ctx context.Context, key string) error {
 	callTime := time.Since(c.baseTime)
 	resp, err := c.client.Delete(ctx, key)
@@ -96,6 +487,30 @@ func (c *recordingClient) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// DeleteWithRevision deletes key like Delete, additionally returning revision of the delete.
+func (c *recordingClient) DeleteWithRevision(ctx context.Context, key string) (int64, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Delete(ctx, key)
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendDelete(key, callTime, returnTime, resp, err)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
+// DeletePrefix deletes all keys with prefix, returns number of deleted keys and revision of the delete.
+func (c *recordingClient) DeletePrefix(ctx context.Context, prefix string) (int64, int64, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Delete(ctx, prefix, clientv3.WithPrefix())
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendDeletePrefix(prefix, callTime, returnTime, resp, err)
+	if err != nil {
+		return 0, 0, err
+	}
+	return resp.Deleted, resp.Header.Revision, nil
+}
+
 func (c *recordingClient) CompareRevisionAndDelete(ctx context.Context, key string, expectedRevision int64) error {
 	callTime := time.Since(c.baseTime)
 	resp, err := c.compareRevisionTxn(ctx, key, expectedRevision, clientv3.OpDelete(key)).Commit()
@@ -104,6 +519,15 @@ func (c *recordingClient) CompareRevisionAndDelete(ctx context.Context, key stri
 	return err
 }
 
+// CompareValueAndDelete deletes key only if its current value is equal to expectedValue.
+func (c *recordingClient) CompareValueAndDelete(ctx context.Context, key, expectedValue string) error {
+	callTime := time.Since(c.baseTime)
+	resp, err := clientv3util.CompareValueAndDelete(ctx, c.client.KV, key, expectedValue)
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendCompareValueAndDelete(key, expectedValue, callTime, returnTime, resp, err)
+	return err
+}
+
 func (c *recordingClient) CompareRevisionAndPut(ctx context.Context, key, value string, expectedRevision int64) error {
 	callTime := time.Since(c.baseTime)
 	resp, err := c.compareRevisionTxn(ctx, key, expectedRevision, clientv3.OpPut(key, value)).Commit()
@@ -112,44 +536,113 @@ func (c *recordingClient) CompareRevisionAndPut(ctx context.Context, key, value
 	return err
 }
 
+// CompareMultiAndSwap puts key only if all compared keys have expected values, see clientv3util.CompareMultiAndSwap.
+func (c *recordingClient) CompareMultiAndSwap(ctx context.Context, expected map[string]string, key, value string) (*clientv3util.CompareAndSwapResult, error) {
+	callTime := time.Since(c.baseTime)
+	result, err := clientv3util.CompareMultiAndSwap(ctx, c.client.KV, expected, key, value)
+	returnTime := time.Since(c.baseTime)
+	var resp *clientv3.TxnResponse
+	if result != nil {
+		resp = result.Response
+	}
+	c.history.AppendCompareMultiAndSwap(expected, key, value, callTime, returnTime, resp, err)
+	return result, err
+}
+
+// CreateIfAbsent puts key only if it doesn't exist, returning whether it was created.
+func (c *recordingClient) CreateIfAbsent(ctx context.Context, key, value string) (bool, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, value),
+	).Commit()
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendCreateIfAbsent(key, value, callTime, returnTime, resp, err)
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// CompareRevisionPutOrGet puts key only if its mod revision is equal to expectedRevision, reading it otherwise, so
+// the failure branch doesn't write. Returns whether key was put.
+func (c *recordingClient) CompareRevisionPutOrGet(ctx context.Context, key, value string, expectedRevision int64) (bool, error) {
+	cmp := []clientv3.Cmp{compareRevision(key, expectedRevision)}
+	onSuccess := []clientv3.Op{clientv3.OpPut(key, value)}
+	onFailure := []clientv3.Op{clientv3.OpGet(key)}
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Txn(ctx).If(cmp...).Then(onSuccess...).Else(onFailure...).Commit()
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendTxn(cmp, onSuccess, onFailure, callTime, returnTime, resp, err)
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// GetAndPut atomically reads key and overwrites it with value, returning previous key value or nil if key didn't exist.
+func (c *recordingClient) GetAndPut(ctx context.Context, key, value string) (*mvccpb.KeyValue, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Txn(ctx).Then(clientv3.OpGet(key), clientv3.OpPut(key, value)).Commit()
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendGetAndPut(key, value, callTime, returnTime, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return nil, nil
+	}
+	return kvs[0], nil
+}
+
 func (c *recordingClient) compareRevisionTxn(ctx context.Context, key string, expectedRevision int64, op clientv3.Op) clientv3.Txn {
 	txn := c.client.Txn(ctx)
-	var cmp clientv3.Cmp
-	if expectedRevision == 0 {
-		cmp = clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
-	} else {
-		cmp = clientv3.Compare(clientv3.ModRevision(key), "=", expectedRevision)
-	}
 	return txn.If(
-		cmp,
+		compareRevision(key, expectedRevision),
 	).Then(
 		op,
 	)
 }
 
-func (c *recordingClient) Txn(ctx context.Context, cmp []clientv3.Cmp, ops []clientv3.Op) error {
+// compareRevision compares mod revision of key with expectedRevision, zero expects key not to exist.
+func compareRevision(key string, expectedRevision int64) clientv3.Cmp {
+	if expectedRevision == 0 {
+		return clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+	}
+	return clientv3.Compare(clientv3.ModRevision(key), "=", expectedRevision)
+}
+
+func (c *recordingClient) Txn(ctx context.Context, cmp []clientv3.Cmp, onSuccess []clientv3.Op, onFailure []clientv3.Op) error {
 	callTime := time.Since(c.baseTime)
 	txn := c.client.Txn(ctx)
 	resp, err := txn.If(
 		cmp...,
 	).Then(
-		ops...,
+		onSuccess...,
+	).Else(
+		onFailure...,
 	).Commit()
 	returnTime := time.Since(c.baseTime)
-	c.history.AppendTxn(cmp, ops, callTime, returnTime, resp, err)
+	c.history.AppendTxn(cmp, onSuccess, onFailure, callTime, returnTime, resp, err)
 	return err
 }
 
-func (c *recordingClient) LeaseGrant(ctx context.Context, ttl int64) (int64, error) {
+// LeaseGrant grants lease with the given TTL, returning its id and TTL granted by server. Server enforces minimal
+// lease TTL, so granted TTL can be higher than requested one and should be used to reason about lease expiration.
+func (c *recordingClient) LeaseGrant(ctx context.Context, ttl int64) (leaseId int64, grantedTTL int64, err error) {
 	callTime := time.Since(c.baseTime)
 	resp, err := c.client.Lease.Grant(ctx, ttl)
 	returnTime := time.Since(c.baseTime)
-	c.history.AppendLeaseGrant(callTime, returnTime, resp, err)
-	var leaseId int64
-	if resp != nil {
-		leaseId = int64(resp.ID)
+	c.history.AppendLeaseGrant(ttl, callTime, returnTime, resp, err)
+	if err != nil || resp == nil {
+		return 0, 0, err
+	}
+	if resp.TTL != ttl {
+		c.lg.Warn("Granted lease TTL differs from requested one", zap.Int64("lease-id", int64(resp.ID)), zap.Int64("requested-ttl", ttl), zap.Int64("granted-ttl", resp.TTL))
 	}
-	return leaseId, err
+	return int64(resp.ID), resp.TTL, nil
 }
 
 func (c *recordingClient) LeaseRevoke(ctx context.Context, leaseId int64) error {
@@ -160,6 +653,12 @@ func (c *recordingClient) LeaseRevoke(ctx context.Context, leaseId int64) error
 	return err
 }
 
+// LeaseMayExpire records that lease could have expired at any moment since the given time, as it was not kept alive since then.
+// Expiration deletes attached keys the same way revoke does, so it's recorded as revoke with unknown result.
+func (c *recordingClient) LeaseMayExpire(leaseId int64, since time.Time) {
+	c.history.AppendLeaseRevoke(leaseId, since.Sub(c.baseTime), time.Since(c.baseTime), nil, errLeaseMayExpire)
+}
+
 func (c *recordingClient) PutWithLease(ctx context.Context, key string, value string, leaseId int64) error {
 	callTime := time.Since(c.baseTime)
 	opts := clientv3.WithLease(clientv3.LeaseID(leaseId))
@@ -170,9 +669,129 @@ func (c *recordingClient) PutWithLease(ctx context.Context, key string, value st
 }
 
 func (c *recordingClient) Defragment(ctx context.Context) error {
+	return c.DefragmentMember(ctx, c.client.Endpoints()[0])
+}
+
+// DefragmentMember defragments member serving given endpoint, recording the endpoint together with the operation.
+// Unavailable member is recorded as failed operation once ctx is done, so ctx should have a deadline.
+func (c *recordingClient) DefragmentMember(ctx context.Context, endpoint string) error {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.Defragment(ctx, endpoint)
+	returnTime := time.Since(c.baseTime)
+	c.history.AppendDefragment(endpoint, callTime, returnTime, resp, err)
+	return err
+}
+
+// Compact compacts revisions below rev. With physical set it waits until compacted revisions are removed
+// from the backend, so operation returns only after compaction has completed.
+func (c *recordingClient) Compact(ctx context.Context, rev int64, physical bool) error {
+	var opts []clientv3.CompactOption
+	if physical {
+		opts = append(opts, clientv3.WithCompactPhysical())
+	}
 	callTime := time.Since(c.baseTime)
-	resp, err := c.client.Defragment(ctx, c.client.Endpoints()[0])
+	resp, err := c.client.Compact(ctx, rev, opts...)
 	returnTime := time.Since(c.baseTime)
-	c.history.AppendDefragment(callTime, returnTime, resp, err)
+	c.history.AppendCompact(rev, physical, callTime, returnTime, resp, err)
+	if physical && !errors.Is(err, rpctypes.ErrCompacted) && !errors.Is(err, rpctypes.ErrFutureRev) {
+		c.physicalCompactions = append(c.physicalCompactions, physicalCompaction{Revision: rev, Start: callTime, End: returnTime, Interrupted: err != nil})
+		if err != nil {
+			c.lg.Info("Physical compaction interrupted", zap.Int64("revision", rev), zap.Duration("after", returnTime-callTime), zap.Error(err))
+		}
+	}
 	return err
 }
+
+// PhysicalCompactions returns compactions done by the client that waited for physical completion.
+func (c *recordingClient) PhysicalCompactions() []physicalCompaction {
+	return c.physicalCompactions
+}
+
+// MemberList lists cluster members, recording the membership seen by the client.
+func (c *recordingClient) MemberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.MemberList(ctx)
+	var members []*etcdserverpb.Member
+	if resp != nil {
+		members = resp.Members
+	}
+	c.recordMembershipChange(MemberList, 0, callTime, members, err)
+	return resp, err
+}
+
+// MemberAdd adds a voting member with peerURLs, recording ID of the new member and the resulting membership.
+func (c *recordingClient) MemberAdd(ctx context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.MemberAdd(ctx, peerURLs)
+	c.recordMemberAdd(MemberAdd, callTime, resp, err)
+	return resp, err
+}
+
+// MemberAddAsLearner adds a learner with peerURLs, recording ID of the new learner and the resulting membership.
+func (c *recordingClient) MemberAddAsLearner(ctx context.Context, peerURLs []string) (*clientv3.MemberAddResponse, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.MemberAddAsLearner(ctx, peerURLs)
+	c.recordMemberAdd(MemberAddAsLearner, callTime, resp, err)
+	return resp, err
+}
+
+// MemberRemove removes member with id, recording the resulting membership.
+func (c *recordingClient) MemberRemove(ctx context.Context, id uint64) (*clientv3.MemberRemoveResponse, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.MemberRemove(ctx, id)
+	var members []*etcdserverpb.Member
+	if resp != nil {
+		members = resp.Members
+	}
+	c.recordMembershipChange(MemberRemove, id, callTime, members, err)
+	return resp, err
+}
+
+// MemberPromote promotes learner with id to voting member, recording the resulting membership.
+func (c *recordingClient) MemberPromote(ctx context.Context, id uint64) (*clientv3.MemberPromoteResponse, error) {
+	callTime := time.Since(c.baseTime)
+	resp, err := c.client.MemberPromote(ctx, id)
+	var members []*etcdserverpb.Member
+	if resp != nil {
+		members = resp.Members
+	}
+	c.recordMembershipChange(MemberPromote, id, callTime, members, err)
+	return resp, err
+}
+
+func (c *recordingClient) recordMemberAdd(changeType membershipChangeType, callTime time.Duration, resp *clientv3.MemberAddResponse, err error) {
+	var id uint64
+	var members []*etcdserverpb.Member
+	if resp != nil {
+		if resp.Member != nil {
+			id = resp.Member.ID
+		}
+		members = resp.Members
+	}
+	c.recordMembershipChange(changeType, id, callTime, members, err)
+}
+
+func (c *recordingClient) recordMembershipChange(changeType membershipChangeType, id uint64, callTime time.Duration, members []*etcdserverpb.Member, err error) {
+	change := membershipChange{Type: changeType, Start: callTime, End: time.Since(c.baseTime), MemberID: id, Err: err}
+	if err == nil {
+		for _, m := range members {
+			change.Members = append(change.Members, m.ID)
+			if m.IsLearner {
+				change.Learners = append(change.Learners, m.ID)
+			}
+		}
+	}
+	c.membershipChanges = append(c.membershipChanges, change)
+}
+
+// MembershipChanges returns membership operations done by the client, in order they were called.
+func (c *recordingClient) MembershipChanges() []membershipChange {
+	return c.membershipChanges
+}
+
+// ConsistentIndexes returns consistent indexes returned to the client, in order responses were received.
+func (c *recordingClient) ConsistentIndexes() []model.ConsistentIndexObservation {
+	c.consistentIndexMux.Lock()
+	defer c.consistentIndexMux.Unlock()
+	return c.consistentIndexes
+}
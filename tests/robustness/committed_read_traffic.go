@@ -0,0 +1,145 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// committedReadTraffic pins each client to a single member and mixes serializable reads with transactions putting
+// key only if it wasn't modified since it was read, and reading it otherwise, so failure branch doesn't write.
+// Serializable reads are often stale, making many transactions execute the failure branch, leaving values of the
+// success branch uncommitted. Reads are not recorded in history, but validated to return only committed values,
+// possibly stale ones, see model.ValidateCommittedReads.
+type committedReadTraffic struct {
+	prefix   string
+	keyCount int
+	report   *committedReadReport
+}
+
+func newCommittedReadTraffic(prefix string, keyCount int) committedReadTraffic {
+	return committedReadTraffic{
+		prefix:   prefix,
+		keyCount: keyCount,
+	}
+}
+
+func (t committedReadTraffic) ForRun() Traffic {
+	t.report = &committedReadReport{}
+	return t
+}
+
+func (t committedReadTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateCommittedReads(tt, lg, t, operations)
+}
+
+func (t committedReadTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	var memberID uint64
+	for memberID == 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		memberID = pinToMember(ctx, clientId, c)
+		limiter.Wait(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := fmt.Sprintf("%s%d", t.prefix, rand.Int()%t.keyCount)
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := c.SerializableGet(getCtx, key)
+		returnTime := time.Since(c.baseTime)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			continue
+		}
+		read := model.CommittedRead{ClientId: clientId, Key: key, Revision: resp.Header.Revision, Return: returnTime.Nanoseconds()}
+		if len(resp.Kvs) != 0 {
+			read.Value = model.ToValueOrHash(string(resp.Kvs[0].Value))
+			read.ModRevision = resp.Kvs[0].ModRevision
+		}
+		t.report.Read(read)
+
+		txnCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		put, err := c.CompareRevisionPutOrGet(txnCtx, key, fmt.Sprintf("%d", ids.RequestId()), read.ModRevision)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err == nil {
+			t.report.Txn(put)
+		}
+	}
+}
+
+// committedReadReport collects serializable reads of committedReadTraffic from all clients.
+type committedReadReport struct {
+	mux   sync.Mutex
+	reads []model.CommittedRead
+	// puts and gets count transactions executing success and failure branch.
+	puts int
+	gets int
+}
+
+func (r *committedReadReport) Read(read model.CommittedRead) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads = append(r.reads, read)
+}
+
+func (r *committedReadReport) Txn(put bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if put {
+		r.puts++
+	} else {
+		r.gets++
+	}
+}
+
+func validateCommittedReads(t *testing.T, lg *zap.Logger, traffic committedReadTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Committed read traffic", zap.Int("reads", len(r.reads)), zap.Int("puts", r.puts), zap.Int("gets", r.gets))
+	for _, violation := range model.ValidateCommittedReads(operations, r.reads) {
+		t.Errorf("Broke serializable reads guarantee: Committed - read can be stale, but returns only committed values, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.puts == 0 || r.gets == 0 {
+		t.Errorf("Transactions didn't execute both branches, puts: %d, gets: %d", r.puts, r.gets)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// compactionRaceTraffic orchestrates race of watch, compaction and historical read in fixed order. The first client
+// coordinates rounds: it watches prefix, puts key at revision R and compacts past R once watch observed the put.
+// Only then it passes R over control channel to one of the other clients, which reads the key at R and has to get
+// ErrCompacted. Watch established before compaction has to keep its event and deliver the put done afterwards.
+type compactionRaceTraffic struct {
+	prefix           string
+	compactionPolicy CompactionPolicy
+	// control passes historical reads from coordinator to other clients, ordering them after compaction.
+	control chan compactedRevisionRead
+	report  *compactionRaceReport
+}
+
+func newCompactionRaceTraffic(prefix string, policy CompactionPolicy) compactionRaceTraffic {
+	return compactionRaceTraffic{
+		prefix:           prefix,
+		compactionPolicy: policy,
+		control:          make(chan compactedRevisionRead),
+	}
+}
+
+func (t compactionRaceTraffic) ForRun() Traffic {
+	t.report = &compactionRaceReport{}
+	return t
+}
+
+func (t compactionRaceTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateCompactionRace(tt, lg, t)
+}
+
+// compactedRevisionRead requests read of key at compacted revision, result is sent back over buffered result channel.
+type compactedRevisionRead struct {
+	key      string
+	revision int64
+	result   chan compactedRevisionReadResult
+}
+
+type compactedRevisionReadResult struct {
+	kv  *mvccpb.KeyValue
+	err error
+}
+
+func (t compactionRaceTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	if clientId != 0 {
+		t.runReader(ctx, c, finish)
+		return
+	}
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runRound(ctx, c, limiter, ids, prefix, finish)
+	}
+}
+
+// runReader reads keys at revisions requested by coordinator.
+func (t compactionRaceTraffic) runReader(ctx context.Context, c *recordingClient, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		case read := <-t.control:
+			getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+			resp, err := c.GetAtRevision(getCtx, read.key, read.revision)
+			cancel()
+			result := compactedRevisionReadResult{err: err}
+			if err == nil && len(resp.Kvs) > 0 {
+				result.kv = resp.Kvs[0]
+			}
+			read.result <- result
+		}
+	}
+}
+
+// runRound watches prefix, puts key and compacts past its revision after watch observed it, then has other client read
+// the key at compacted revision and puts another key that watch has to deliver. Round is validated only if all steps
+// completed, steps interrupted by failpoint or timeout are not.
+func (t compactionRaceTraffic) runRound(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string, finish <-chan struct{}) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch := newCompactionRaceWatch(watchCtx, c, prefix, resp.Header.Revision+1)
+	if !watch.waitCreated() {
+		return
+	}
+
+	read := model.CompactedRevisionRead{Key: fmt.Sprintf("%s%d", prefix, ids.RequestId()), Value: fmt.Sprintf("%d", ids.RequestId())}
+	read.PutRevision, err = t.put(ctx, c, limiter, read.Key, read.Value)
+	if err != nil {
+		return
+	}
+	// Compaction has to go past revision of the put, so it is done at revision of the next put.
+	read.CompactRevision, err = t.put(ctx, c, limiter, fmt.Sprintf("%s%d", prefix, ids.RequestId()), fmt.Sprintf("%d", ids.RequestId()))
+	if err != nil {
+		return
+	}
+	events, ok := watch.waitRevision(read.CompactRevision)
+	if !ok {
+		return
+	}
+	read.WatchedKv = events[read.Key]
+	read.WatchedRevision = watch.nextRevision - 1
+
+	physical := t.compactionPolicy.Physical()
+	compactCtx, compactCancel := context.WithTimeout(ctx, CompactTimeout)
+	err = c.Compact(compactCtx, read.CompactRevision, physical)
+	compactCancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	t.report.Compacted(physical)
+
+	request := compactedRevisionRead{key: read.Key, revision: read.PutRevision, result: make(chan compactedRevisionReadResult, 1)}
+	select {
+	case t.control <- request:
+	case <-ctx.Done():
+		return
+	case <-finish:
+		return
+	}
+	result := <-request.result
+	if result.err != nil && !errors.Is(result.err, rpctypes.ErrCompacted) {
+		// Read could have failed due to failpoint or timeout.
+		return
+	}
+	read.ReadKv, read.ReadErr = result.kv, result.err
+
+	revision, err := t.put(ctx, c, limiter, fmt.Sprintf("%s%d", prefix, ids.RequestId()), fmt.Sprintf("%d", ids.RequestId()))
+	if err != nil {
+		return
+	}
+	if _, ok := watch.waitRevision(revision); !ok && !watch.compacted {
+		// Watch could have been broken by failpoint or timeout.
+		return
+	}
+	read.WatchCompacted = watch.compacted
+	t.report.Read(read)
+}
+
+func (t compactionRaceTraffic) put(ctx context.Context, c *recordingClient, limiter *trafficLimiter, key, value string) (int64, error) {
+	limiter.Wait(ctx)
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	revision, err := c.PutWithRevision(putCtx, key, value)
+	cancel()
+	limiter.Adapt(ctx, err)
+	return revision, err
+}
+
+// compactionRaceWatch tracks next revision needed by watch, like compactionWatch, also recording whether it was compacted.
+type compactionRaceWatch struct {
+	*compactionWatch
+	compacted bool
+}
+
+func newCompactionRaceWatch(ctx context.Context, c *recordingClient, prefix string, revision int64) *compactionRaceWatch {
+	return &compactionRaceWatch{compactionWatch: newCompactionWatch(ctx, c, prefix, revision)}
+}
+
+// waitRevision reads watch until it observes event of revision, returning the last event of each key delivered meanwhile.
+// Returns false if watch stopped before that.
+func (w *compactionRaceWatch) waitRevision(revision int64) (map[string]*mvccpb.KeyValue, bool) {
+	events := map[string]*mvccpb.KeyValue{}
+	timeout := time.After(watchBatchTimeout)
+	for w.nextRevision <= revision {
+		var resp clientv3.WatchResponse
+		var ok bool
+		select {
+		case resp, ok = <-w.watch:
+		case <-timeout:
+			return events, false
+		}
+		if !ok {
+			return events, false
+		}
+		if resp.CompactRevision != 0 {
+			w.compacted = true
+			return events, false
+		}
+		if resp.Err() != nil {
+			return events, false
+		}
+		for _, event := range resp.Events {
+			events[string(event.Kv.Key)] = event.Kv
+			w.nextRevision = event.Kv.ModRevision + 1
+		}
+	}
+	return events, true
+}
+
+// compactionRaceReport collects results of compactionRaceTraffic validation.
+type compactionRaceReport struct {
+	mux                 sync.Mutex
+	compactions         int
+	physicalCompactions int
+	reads               int
+	violations          []string
+}
+
+func (r *compactionRaceReport) Compacted(physical bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.compactions++
+	if physical {
+		r.physicalCompactions++
+	}
+}
+
+func (r *compactionRaceReport) Read(read model.CompactedRevisionRead) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if err != nil {
+		r.violations = append(r.violations, err.Error())
+	}
+}
+
+func validateCompactionRace(t *testing.T, lg *zap.Logger, traffic compactionRaceTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Compaction race traffic", zap.Int("compactions", r.compactions), zap.Int("physical-compactions", r.physicalCompactions), zap.Int("reads", r.reads))
+	for _, violation := range r.violations {
+		t.Errorf("Broke compaction guarantee: Watch keeps events observed before compaction, while reads of compacted revisions fail, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if traffic.compactionPolicy != CompactionLogical && r.physicalCompactions == 0 {
+		t.Errorf("No physical compaction was done, compactionPolicy: %q", traffic.compactionPolicy)
+	}
+	if r.reads == 0 {
+		t.Errorf("No round of compaction race traffic was completed")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// compactionReadTraffic reads latest values of keys while the first client compacts right behind the latest revision.
+// Keys are never deleted, so once client has written or read a key, its later latest reads must return the key with
+// the same or newer mod revision, even if all revisions up to the one known by the client were compacted.
+// Compactions and reads are recorded in the same operation history, so they are also validated by the model.
+// Reads must not fail during physical compaction, except when member is unavailable or read times out.
+type compactionReadTraffic struct {
+	prefix   string
+	keyCount int
+	// compactLag is the number of revisions compaction stays behind the latest revision.
+	compactLag       int64
+	compactionPolicy CompactionPolicy
+	report           *compactionReadReport
+}
+
+func newCompactionReadTraffic(prefix string, keyCount int, compactLag int64, policy CompactionPolicy) compactionReadTraffic {
+	return compactionReadTraffic{
+		prefix:           prefix,
+		keyCount:         keyCount,
+		compactLag:       compactLag,
+		compactionPolicy: policy,
+	}
+}
+
+func (t compactionReadTraffic) ForRun() Traffic {
+	t.report = &compactionReadReport{}
+	return t
+}
+
+func (t compactionReadTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateCompactionReads(tt, lg, t)
+}
+
+func (t compactionReadTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	if clientId == 0 {
+		t.runCompactor(ctx, c, limiter, finish)
+		return
+	}
+	// known mod revision of each key, written or read by this client.
+	known := map[string]int64{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := t.pickKey()
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.PutWithRevision(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err == nil && revision > known[key] {
+			known[key] = revision
+		}
+		limiter.Wait(ctx)
+
+		key = t.pickKey()
+		compactRevision := t.report.CompactRevision()
+		readStart := time.Since(c.baseTime)
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		kv, revision, err := c.GetWithRevision(getCtx, key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			t.report.ReadFailed(failedRead{clientId: clientId, key: key, start: readStart, end: time.Since(c.baseTime), err: err})
+			continue
+		}
+		knownRevision, found := known[key]
+		switch {
+		case !found:
+		case kv == nil:
+			t.report.Violated(fmt.Sprintf("client: %d, key: %q, revision: %d, key not found, known mod revision: %d, compact revision: %d",
+				clientId, key, revision, knownRevision, compactRevision))
+		case kv.ModRevision < knownRevision:
+			t.report.Violated(fmt.Sprintf("client: %d, key: %q, revision: %d, mod revision: %d, known mod revision: %d, compact revision: %d",
+				clientId, key, revision, kv.ModRevision, knownRevision, compactRevision))
+		}
+		t.report.Read(found && knownRevision <= compactRevision)
+		if kv != nil && kv.ModRevision > knownRevision {
+			known[key] = kv.ModRevision
+		}
+		limiter.Wait(ctx)
+	}
+}
+
+// runCompactor repeatedly compacts revisions older than compactLag revisions behind the latest one.
+func (t compactionReadTraffic) runCompactor(ctx context.Context, c *recordingClient, limiter *trafficLimiter, finish <-chan struct{}) {
+	var lastCompactRevision int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		limiter.Wait(ctx)
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := c.client.Get(getCtx, t.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			continue
+		}
+		compactRevision := resp.Header.Revision - t.compactLag
+		if compactRevision <= lastCompactRevision {
+			continue
+		}
+		physical := t.compactionPolicy.Physical()
+		compactCtx, cancel := context.WithTimeout(ctx, CompactTimeout)
+		err = c.Compact(compactCtx, compactRevision, physical)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			continue
+		}
+		if physical {
+			compactions := c.PhysicalCompactions()
+			t.report.PhysicallyCompacted(compactions[len(compactions)-1])
+		}
+		lastCompactRevision = compactRevision
+		t.report.Compacted(compactRevision)
+	}
+}
+
+func (t compactionReadTraffic) pickKey() string {
+	return fmt.Sprintf("%s%d", t.prefix, rand.Intn(t.keyCount))
+}
+
+// compactionReadReport collects results of compactionReadTraffic validation from all clients.
+type compactionReadReport struct {
+	mux             sync.Mutex
+	compactions     int
+	compactRevision int64
+	reads           int
+	// compactedReads counts reads of keys whose known mod revision was already compacted when read started.
+	compactedReads int
+	// physicalCompactions are physical compactions confirmed to complete.
+	physicalCompactions []physicalCompaction
+	failedReads         []failedRead
+	violations          []string
+}
+
+// failedRead is a read that failed between start and end, relative to traffic start.
+type failedRead struct {
+	clientId   int
+	key        string
+	start, end time.Duration
+	err        error
+}
+
+func (r *compactionReadReport) Compacted(revision int64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.compactions++
+	if revision > r.compactRevision {
+		r.compactRevision = revision
+	}
+}
+
+// CompactRevision returns the highest revision compacted so far.
+func (r *compactionReadReport) CompactRevision() int64 {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.compactRevision
+}
+
+func (r *compactionReadReport) Read(compacted bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if compacted {
+		r.compactedReads++
+	}
+}
+
+func (r *compactionReadReport) PhysicallyCompacted(compaction physicalCompaction) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.physicalCompactions = append(r.physicalCompactions, compaction)
+}
+
+func (r *compactionReadReport) ReadFailed(read failedRead) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.failedReads = append(r.failedReads, read)
+}
+
+func (r *compactionReadReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+// overlappingPhysicalCompaction returns physical compaction that was in progress when read was.
+func overlappingPhysicalCompaction(compactions []physicalCompaction, read failedRead) (physicalCompaction, bool) {
+	for _, compaction := range compactions {
+		if read.start < compaction.End && compaction.Start < read.end {
+			return compaction, true
+		}
+	}
+	return physicalCompaction{}, false
+}
+
+func validateCompactionReads(t *testing.T, lg *zap.Logger, traffic compactionReadTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Compaction read traffic", zap.Int("compactions", r.compactions), zap.Int64("compact-revision", r.compactRevision), zap.Int("reads", r.reads), zap.Int("compacted-reads", r.compactedReads))
+	for _, violation := range r.violations {
+		t.Errorf("Broke compaction guarantee: Compaction doesn't change latest revision, read returned key older than previously known one, %s", violation)
+	}
+	var timedOut int
+	for _, read := range r.failedReads {
+		compaction, found := overlappingPhysicalCompaction(r.physicalCompactions, read)
+		switch {
+		case !found || isRetriableReadError(read.err):
+		case errors.Is(read.err, context.DeadlineExceeded):
+			timedOut++
+		default:
+			t.Errorf("Broke compaction guarantee: Reads don't fail during physical compaction, client: %d, key: %q, compact revision: %d, err: %v", read.clientId, read.key, compaction.Revision, read.err)
+		}
+	}
+	lg.Info("Reads during physical compaction", zap.Int("physical-compactions", len(r.physicalCompactions)), zap.Int("failed-reads", len(r.failedReads)), zap.Int("timed-out", timedOut))
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.compactions == 0 {
+		t.Errorf("No compaction was done, compactLag: %d", traffic.compactLag)
+	}
+	if r.compactedReads == 0 {
+		t.Errorf("No read was done of key with known revision compacted, compactLag: %d", traffic.compactLag)
+	}
+}
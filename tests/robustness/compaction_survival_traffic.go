@@ -0,0 +1,202 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// compactionSurvivalTraffic validates that compaction preserves the latest version of each key. In each round client
+// puts versionCount versions of keyCount new keys, deletes every third of them, and compacts at revision following
+// all the writes. Then it puts a new version of another third of keys and reads all keys of the round, which must
+// be read with value of their last put, no matter if it was before or after compaction, or not be found if deleted.
+// Keys are unique to client and round, so round can be abandoned on any failure without affecting the next ones.
+// Operations are recorded in history, so they are also validated by the model.
+type compactionSurvivalTraffic struct {
+	prefix           string
+	keyCount         int
+	versionCount     int
+	compactionPolicy CompactionPolicy
+	report           *compactionSurvivalReport
+}
+
+func newCompactionSurvivalTraffic(prefix string, keyCount, versionCount int, policy CompactionPolicy) compactionSurvivalTraffic {
+	return compactionSurvivalTraffic{
+		prefix:           prefix,
+		keyCount:         keyCount,
+		versionCount:     versionCount,
+		compactionPolicy: policy,
+	}
+}
+
+func (t compactionSurvivalTraffic) ForRun() Traffic {
+	t.report = &compactionSurvivalReport{}
+	return t
+}
+
+func (t compactionSurvivalTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateCompactionSurvival(tt, lg, t)
+}
+
+func (t compactionSurvivalTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for round := 0; ; round++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runRound(ctx, clientId, round, c, limiter, ids)
+	}
+}
+
+// runRound writes, compacts and reads keys of a single round, returns early if any request fails.
+func (t compactionSurvivalTraffic) runRound(ctx context.Context, clientId, round int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider) {
+	reads := make([]model.CompactedKeyRead, t.keyCount)
+	for i := range reads {
+		reads[i].Key = fmt.Sprintf("%s%d/%d/%d", t.prefix, clientId, round, i)
+		for v := 0; v < t.versionCount; v++ {
+			if !t.put(ctx, c, limiter, ids, &reads[i]) {
+				return
+			}
+		}
+	}
+	for i := 1; i < len(reads); i += 3 {
+		deleteCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err := c.Delete(deleteCtx, reads[i].Key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			return
+		}
+		reads[i].Deleted = true
+	}
+
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	_, compactRevision, err := c.GetWithRevision(getCtx, reads[0].Key)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+	compactCtx, cancel := context.WithTimeout(ctx, CompactTimeout)
+	err = c.Compact(compactCtx, compactRevision, t.compactionPolicy.Physical())
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	// Compaction of a newer revision by other client also compacts all versions of the round overwritten before it.
+	if err != nil && !errors.Is(err, rpctypes.ErrCompacted) {
+		return
+	}
+
+	for i := 2; i < len(reads); i += 3 {
+		if !t.put(ctx, c, limiter, ids, &reads[i]) {
+			return
+		}
+	}
+	for i := range reads {
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		kv, revision, err := c.GetWithRevision(getCtx, reads[i].Key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			return
+		}
+		reads[i].CompactRevision = compactRevision
+		reads[i].ReadKv = kv
+		reads[i].ReadRevision = revision
+		t.report.Read(clientId, reads[i])
+	}
+	t.report.Completed()
+}
+
+// put writes a new version of the key, recording it as the last put of the key.
+func (t compactionSurvivalTraffic) put(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, read *model.CompactedKeyRead) bool {
+	value := fmt.Sprintf("%d", ids.RequestId())
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	revision, err := c.PutWithRevision(putCtx, read.Key, value)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return false
+	}
+	read.Value = value
+	read.PutRevision = revision
+	return true
+}
+
+// compactionSurvivalReport collects results of compactionSurvivalTraffic validation from all clients.
+type compactionSurvivalReport struct {
+	mux    sync.Mutex
+	rounds int
+	reads  int
+	// deleted counts reads of keys deleted before compaction.
+	deleted int
+	// putAfterCompaction counts reads of keys put again after compaction.
+	putAfterCompaction int
+	violations         []string
+}
+
+func (r *compactionSurvivalReport) Read(clientId int, read model.CompactedKeyRead) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if read.Deleted {
+		r.deleted++
+	}
+	if read.PutAfterCompaction() {
+		r.putAfterCompaction++
+	}
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, err))
+	}
+}
+
+func (r *compactionSurvivalReport) Completed() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.rounds++
+}
+
+func validateCompactionSurvival(t *testing.T, lg *zap.Logger, traffic compactionSurvivalTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Compaction survival traffic", zap.Int("rounds", r.rounds), zap.Int("reads", r.reads), zap.Int("deleted", r.deleted), zap.Int("put-after-compaction", r.putAfterCompaction))
+	for _, violation := range r.violations {
+		t.Errorf("Broke compaction guarantee: Compaction preserves the latest version of each key, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.rounds == 0 {
+		t.Errorf("No round of compaction survival traffic was completed, keyCount: %d, versionCount: %d", traffic.keyCount, traffic.versionCount)
+	}
+}
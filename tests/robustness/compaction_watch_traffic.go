@@ -0,0 +1,287 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// CompactTimeout limits time for compaction, physical compaction returns only after compacted revisions are removed.
+var CompactTimeout = 500 * time.Millisecond
+
+// compactionWatchTraffic compacts revisions while watches are active, racing removal of compacted revisions with watchers.
+// Each client establishes watch, puts batch of keys, compacts at revision of the last put and puts another batch.
+// Watch established before compaction has already observed all compacted revisions, so it has to receive events of
+// the second batch. Watch opened afterwards from random revision can be compacted only if it starts below compaction.
+type compactionWatchTraffic struct {
+	prefix           string
+	batchSize        int
+	compactionPolicy CompactionPolicy
+	report           *compactionWatchReport
+}
+
+func newCompactionWatchTraffic(prefix string, batchSize int, policy CompactionPolicy) compactionWatchTraffic {
+	return compactionWatchTraffic{
+		prefix:           prefix,
+		batchSize:        batchSize,
+		compactionPolicy: policy,
+	}
+}
+
+func (t compactionWatchTraffic) ForRun() Traffic {
+	t.report = &compactionWatchReport{}
+	return t
+}
+
+func (t compactionWatchTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateCompactionWatch(tt, lg, t)
+}
+
+func (t compactionWatchTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runCompaction(ctx, clientId, c, limiter, ids, prefix)
+	}
+}
+
+// runCompaction compacts revisions of batch put under prefix, validating watches established before and after compaction.
+func (t compactionWatchTraffic) runCompaction(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	startRevision := resp.Header.Revision + 1
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	established := newCompactionWatch(watchCtx, c, prefix, startRevision)
+	if !established.waitCreated() {
+		return
+	}
+	batch := newCompactionBatch()
+	t.putBatch(ctx, c, limiter, ids, prefix, batch)
+	compactRevision := batch.lastRevision
+	if compactRevision == 0 {
+		return
+	}
+	if ok, violation := established.observe(batch, compactRevision); !ok {
+		t.report.Violated(clientId, violation)
+		return
+	}
+
+	physical := t.compactionPolicy.Physical()
+	compactCtx, compactCancel := context.WithTimeout(ctx, CompactTimeout)
+	err = c.Compact(compactCtx, compactRevision, physical)
+	compactCancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	t.report.Compacted(physical)
+
+	t.putBatch(ctx, c, limiter, ids, prefix, batch)
+	if batch.lastRevision == compactRevision {
+		return
+	}
+	ok, violation := established.observe(batch, batch.lastRevision)
+	t.report.Violated(clientId, violation)
+	if ok {
+		t.report.Survived()
+	}
+
+	watchRevision := startRevision + rand.Int63n(batch.lastRevision-startRevision+1)
+	late := newCompactionWatch(watchCtx, c, prefix, watchRevision)
+	_, violation = late.observe(batch, batch.lastRevision)
+	t.report.Violated(clientId, violation)
+}
+
+// putBatch puts batchSize keys under prefix, recording their values and revisions in batch.
+func (t compactionWatchTraffic) putBatch(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string, batch *compactionBatch) {
+	for i := 0; i < t.batchSize; i++ {
+		limiter.Wait(ctx)
+		key := fmt.Sprintf("%s%d", prefix, ids.RequestId())
+		value := fmt.Sprintf("%d", ids.RequestId())
+		// Failed put might have been persisted too, its value will still be validated if watch observes it.
+		batch.values[key] = value
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.PutWithRevision(putCtx, key, value)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			continue
+		}
+		batch.revisions[key] = revision
+		batch.lastRevision = revision
+	}
+}
+
+// compactionBatch records keys put by single compaction iteration.
+type compactionBatch struct {
+	// values of all keys put, including ones that failed.
+	values map[string]string
+	// revisions of keys put successfully.
+	revisions    map[string]int64
+	lastRevision int64
+}
+
+func newCompactionBatch() *compactionBatch {
+	return &compactionBatch{
+		values:    map[string]string{},
+		revisions: map[string]int64{},
+	}
+}
+
+// compactionWatch tracks revision the watch needs next, which is the revision it resumes from after stream breaks.
+type compactionWatch struct {
+	watch        clientv3.WatchChan
+	nextRevision int64
+}
+
+func newCompactionWatch(ctx context.Context, c *recordingClient, prefix string, revision int64) *compactionWatch {
+	return &compactionWatch{
+		watch:        c.client.Watch(ctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(revision), clientv3.WithCreatedNotify()),
+		nextRevision: revision,
+	}
+}
+
+// waitCreated returns whether watch was created.
+func (w *compactionWatch) waitCreated() bool {
+	select {
+	case resp, ok := <-w.watch:
+		return ok && resp.Created && resp.Err() == nil
+	case <-time.After(watchBatchTimeout):
+		return false
+	}
+}
+
+// observe reads watch until it receives events of all keys put successfully up to revision.
+// Returns false if watch stopped before that, together with violation if it was compacted despite not
+// needing any compacted revision. Watch broken by failpoint or timeout is not validated.
+func (w *compactionWatch) observe(batch *compactionBatch, revision int64) (bool, string) {
+	pending := map[string]bool{}
+	for key, rev := range batch.revisions {
+		if rev >= w.nextRevision && rev <= revision {
+			pending[key] = true
+		}
+	}
+	timeout := time.After(watchBatchTimeout)
+	for len(pending) > 0 {
+		var resp clientv3.WatchResponse
+		var ok bool
+		select {
+		case resp, ok = <-w.watch:
+		case <-timeout:
+			return false, ""
+		}
+		if !ok {
+			return false, ""
+		}
+		if resp.CompactRevision != 0 {
+			if !model.WatchMayBeCompacted(w.nextRevision, resp.CompactRevision) {
+				return false, fmt.Sprintf("watch needing revision %d was compacted at revision %d", w.nextRevision, resp.CompactRevision)
+			}
+			return false, ""
+		}
+		if resp.Err() != nil {
+			return false, ""
+		}
+		for _, event := range resp.Events {
+			key := string(event.Kv.Key)
+			w.nextRevision = event.Kv.ModRevision + 1
+			delete(pending, key)
+			if event.Type != mvccpb.PUT {
+				continue
+			}
+			if expect, found := batch.values[key]; found && string(event.Kv.Value) != expect {
+				return false, fmt.Sprintf("key: %q, revision: %d, value: %q, expected: %q", key, event.Kv.ModRevision, event.Kv.Value, expect)
+			}
+		}
+	}
+	return true, ""
+}
+
+// compactionWatchReport collects results of compactionWatchTraffic validation from all clients.
+type compactionWatchReport struct {
+	mux                 sync.Mutex
+	compactions         int
+	physicalCompactions int
+	survivedWatches     int
+	violations          []string
+}
+
+func (r *compactionWatchReport) Compacted(physical bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.compactions++
+	if physical {
+		r.physicalCompactions++
+	}
+}
+
+func (r *compactionWatchReport) Survived() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.survivedWatches++
+}
+
+// Violated records violation, empty violation is ignored.
+func (r *compactionWatchReport) Violated(clientId int, violation string) {
+	if violation == "" {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, violation))
+}
+
+func validateCompactionWatch(t *testing.T, lg *zap.Logger, traffic compactionWatchTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Compaction watch traffic", zap.Int("compactions", r.compactions), zap.Int("physical-compactions", r.physicalCompactions), zap.Int("survived-watches", r.survivedWatches))
+	for _, violation := range r.violations {
+		t.Errorf("Broke watch guarantee: Compaction - only watch starting below compaction revision can be compacted, others deliver values that were put, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if traffic.compactionPolicy != CompactionLogical && r.physicalCompactions == 0 {
+		t.Errorf("No physical compaction was done, compactionPolicy: %q", traffic.compactionPolicy)
+	}
+	if r.survivedWatches == 0 {
+		t.Errorf("No watch established before compaction received events after it")
+	}
+}
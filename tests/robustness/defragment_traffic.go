@@ -0,0 +1,162 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// defragmentTimeout is longer than RequestTimeout, as defragmentation copies the whole backend.
+const defragmentTimeout = time.Second
+
+// defragmentTransparencyTraffic writes keys under client prefix and defragments member the client is connected to,
+// reading the prefix from the member right before and after each defragmentation. Read after defragmentation is
+// pinned to revision of the read before it, so both have to return identical keys.
+type defragmentTransparencyTraffic struct {
+	prefix   string
+	keyCount int
+	report   *defragmentTransparencyReport
+}
+
+func newDefragmentTransparencyTraffic(prefix string, keyCount int) defragmentTransparencyTraffic {
+	return defragmentTransparencyTraffic{
+		prefix:   prefix,
+		keyCount: keyCount,
+	}
+}
+
+func (t defragmentTransparencyTraffic) ForRun() Traffic {
+	t.report = &defragmentTransparencyReport{}
+	return t
+}
+
+func (t defragmentTransparencyTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateDefragmentTransparency(tt, lg, t)
+}
+
+func (t defragmentTransparencyTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runRound(ctx, clientId, c, limiter, ids, prefix)
+	}
+}
+
+// runRound overwrites keys, so defragmentation has space to reclaim, and defragments member between two reads of them.
+func (t defragmentTransparencyTraffic) runRound(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string) {
+	for i := 0; i < t.keyCount; i++ {
+		limiter.Wait(ctx)
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err := c.Put(putCtx, fmt.Sprintf("%s%d", prefix, rand.Intn(t.keyCount)), fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+	}
+
+	read := model.DefragmentedRead{Endpoint: c.client.Endpoints()[0], Prefix: prefix}
+	// Reads are serializable, so they are served by the defragmented member itself.
+	limiter.Wait(ctx)
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithSerializable())
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	read.Before, read.Revision = resp.Kvs, resp.Header.Revision
+
+	limiter.Wait(ctx)
+	defragCtx, cancel := context.WithTimeout(ctx, defragmentTimeout)
+	read.DefragmentErr = c.DefragmentMember(defragCtx, read.Endpoint)
+	cancel()
+	limiter.Adapt(ctx, read.DefragmentErr)
+
+	limiter.Wait(ctx)
+	getCtx, cancel = context.WithTimeout(ctx, RequestTimeout)
+	resp, err = c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithSerializable(), clientv3.WithRev(read.Revision))
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		// Revision might have been compacted by failpoint, which is not a failure of the traffic.
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			t.report.Compacted()
+		}
+		return
+	}
+	read.After, read.AfterRevision = resp.Kvs, resp.Header.Revision
+	t.report.Read(clientId, read)
+}
+
+// defragmentTransparencyReport collects results of defragmentTransparencyTraffic validation from all clients.
+type defragmentTransparencyReport struct {
+	mux   sync.Mutex
+	reads int
+	// defragmented counts reads around successful defragmentation.
+	defragmented int
+	compacted    int
+	violations   []string
+}
+
+func (r *defragmentTransparencyReport) Read(clientId int, read model.DefragmentedRead) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if read.DefragmentErr == nil {
+		r.defragmented++
+	}
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, err))
+	}
+}
+
+func (r *defragmentTransparencyReport) Compacted() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.compacted++
+}
+
+func validateDefragmentTransparency(t *testing.T, lg *zap.Logger, traffic defragmentTransparencyTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Defragment transparency traffic", zap.Int("reads", r.reads), zap.Int("defragmented", r.defragmented), zap.Int("compacted", r.compacted))
+	for _, violation := range r.violations {
+		t.Errorf("Broke defragment guarantee: Transparent - defragmentation doesn't change data or revision, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.defragmented == 0 {
+		t.Errorf("No read around successful defragmentation, keyCount: %d", traffic.keyCount)
+	}
+}
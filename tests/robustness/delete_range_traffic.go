@@ -0,0 +1,120 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// deleteRangeTraffic races deletes of whole prefix with inserts of keys under it. Out of each inserters+deleters
+// clients, deleters repeatedly delete the prefix, while inserters put random keys under it. Deletes are recorded
+// together with number of keys they reported as deleted, so model validates that each delete atomically removed
+// exactly the keys present at its revision, and none inserted after it.
+type deleteRangeTraffic struct {
+	prefix    string
+	keyCount  int
+	inserters int
+	deleters  int
+	report    *deleteRangeReport
+}
+
+func newDeleteRangeTraffic(prefix string, keyCount, inserters, deleters int) deleteRangeTraffic {
+	return deleteRangeTraffic{
+		prefix:    prefix,
+		keyCount:  keyCount,
+		inserters: inserters,
+		deleters:  deleters,
+	}
+}
+
+func (t deleteRangeTraffic) ForRun() Traffic {
+	t.report = &deleteRangeReport{}
+	return t
+}
+
+func (t deleteRangeTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateDeleteRange(tt, lg, t)
+}
+
+func (t deleteRangeTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	deleter := clientId%(t.inserters+t.deleters) < t.deleters
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		limiter.Wait(ctx)
+		opCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		var err error
+		if deleter {
+			var deleted int64
+			deleted, _, err = c.DeletePrefix(opCtx, t.prefix)
+			if err == nil {
+				t.report.Deleted(deleted)
+			}
+		} else {
+			key := fmt.Sprintf("%s%d", t.prefix, rand.Intn(t.keyCount))
+			err = c.Put(opCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		}
+		cancel()
+		limiter.Adapt(ctx, err)
+	}
+}
+
+// deleteRangeReport collects results of deletes done by deleteRangeTraffic from all clients.
+type deleteRangeReport struct {
+	mux         sync.Mutex
+	deletes     int
+	deletedKeys int64
+	maxDeleted  int64
+}
+
+func (r *deleteRangeReport) Deleted(keys int64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.deletes++
+	r.deletedKeys += keys
+	if keys > r.maxDeleted {
+		r.maxDeleted = keys
+	}
+}
+
+// validateDeleteRange validates that traffic exercised deletes of multiple keys, exact number of deleted keys
+// is validated by the model against the state at revision of each delete.
+func validateDeleteRange(t *testing.T, lg *zap.Logger, traffic deleteRangeTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Delete range traffic", zap.Int("deletes", r.deletes), zap.Int64("deleted-keys", r.deletedKeys), zap.Int64("max-deleted", r.maxDeleted))
+	if r.maxDeleted > int64(traffic.keyCount) {
+		t.Errorf("Broke delete range guarantee: Atomic - delete removes only keys present at its revision, deleted %d keys, while only %d keys can exist under prefix %q", r.maxDeleted, traffic.keyCount, traffic.prefix)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.maxDeleted < 2 {
+		t.Errorf("No delete removed multiple keys, keyCount: %d, inserters: %d, deleters: %d, max deleted: %d", traffic.keyCount, traffic.inserters, traffic.deleters, r.maxDeleted)
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+var errLeaseMayExpire = errors.New("lease may expire")
+
+// electionTraffic runs leader election the same way as concurrency.Election, but using only recorded requests.
+// Each candidate campaigns by putting a key under prefix attached to its own lease, leader is the candidate
+// whose key has the lowest create revision. Leader holds leadership for holdTime and then either resigns by
+// revoking its lease, or stops keeping lease alive and waits for it to expire.
+// Clients above candidateCount only observe the election.
+type electionTraffic struct {
+	prefix         string
+	candidateCount int
+	leaseTTL       int64
+	holdTime       time.Duration
+	// expireChance is the probability that leader lets its lease expire instead of resigning.
+	expireChance float64
+	report       *electionReport
+}
+
+func newElectionTraffic(candidateCount int) electionTraffic {
+	return electionTraffic{
+		prefix:         "/election",
+		candidateCount: candidateCount,
+		leaseTTL:       3,
+		holdTime:       500 * time.Millisecond,
+		expireChance:   0.2,
+	}
+}
+
+func (t electionTraffic) ForRun() Traffic {
+	t.report = &electionReport{}
+	return t
+}
+
+func (t electionTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateElection(tt, lg, t)
+}
+
+func (t electionTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		if clientId < t.candidateCount {
+			t.campaign(ctx, clientId, c, limiter, finish)
+		} else {
+			t.observe(ctx, clientId, c, limiter, "")
+			limiter.Wait(ctx)
+		}
+	}
+}
+
+// campaign runs a single election term of candidate, from granting the lease to resigning or lease expiration.
+func (t electionTraffic) campaign(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, finish <-chan struct{}) {
+	grantTime := time.Now()
+	grantCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	leaseId, grantedTTL, err := c.LeaseGrant(grantCtx, t.leaseTTL)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+	lease := &candidateLease{id: leaseId, ttl: time.Duration(grantedTTL) * time.Second, lastKeepAlive: grantTime}
+	key := t.candidateKey(leaseId)
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err = c.PutWithLease(putCtx, key, fmt.Sprintf("%d", clientId), leaseId)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		t.resign(ctx, c, lease)
+		return
+	}
+	t.report.Campaigned()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			t.resign(ctx, c, lease)
+			return
+		default:
+		}
+		if !t.keepAlive(ctx, c, lease) {
+			t.report.Lost()
+			return
+		}
+		leader, present := t.observe(ctx, clientId, c, limiter, key)
+		limiter.Wait(ctx)
+		if leader {
+			break
+		}
+		if present == keyMissing {
+			t.report.Lost()
+			t.resign(ctx, c, lease)
+			return
+		}
+	}
+	t.report.Elected()
+
+	holdUntil := time.Now().Add(t.holdTime)
+	for time.Now().Before(holdUntil) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			t.resign(ctx, c, lease)
+			return
+		default:
+		}
+		if !t.keepAlive(ctx, c, lease) {
+			t.report.Lost()
+			return
+		}
+		leader, _ := t.observe(ctx, clientId, c, limiter, key)
+		limiter.Wait(ctx)
+		if !leader {
+			t.report.Lost()
+			t.resign(ctx, c, lease)
+			return
+		}
+	}
+	if rand.Float64() < t.expireChance {
+		t.expire(ctx, c, lease, key, finish)
+		return
+	}
+	t.resign(ctx, c, lease)
+}
+
+type keyPresence int
+
+const (
+	keyUnknown keyPresence = iota
+	keyPresent
+	keyMissing
+)
+
+// observe reads all candidates and records the current leader, returns whether the leader is the given key
+// and whether the key was present in the read.
+func (t electionTraffic) observe(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, key string) (bool, keyPresence) {
+	readCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	kvs, revision, err := c.RangeSnapshot(readCtx, t.prefix+"/", "", true)
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return false, keyUnknown
+	}
+	presence := keyMissing
+	var leader *mvccpb.KeyValue
+	for _, kv := range kvs {
+		if string(kv.Key) == key {
+			presence = keyPresent
+		}
+		if leader == nil || kv.CreateRevision < leader.CreateRevision {
+			leader = kv
+		}
+	}
+	if leader == nil {
+		return false, presence
+	}
+	t.report.Observed(leaderObservation{
+		clientId:       clientId,
+		key:            string(leader.Key),
+		createRevision: leader.CreateRevision,
+		revision:       revision,
+	})
+	return string(leader.Key) == key, presence
+}
+
+// keepAlive refreshes lease every third of its TTL. If lease couldn't be refreshed for half of its TTL,
+// it records that lease may expire and returns false, as candidate can no longer be sure it holds the lease.
+func (t electionTraffic) keepAlive(ctx context.Context, c *recordingClient, lease *candidateLease) bool {
+	if time.Since(lease.lastKeepAlive) < lease.ttl/3 {
+		return true
+	}
+	callTime := time.Now()
+	keepAliveCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	_, err := c.client.KeepAliveOnce(keepAliveCtx, clientv3.LeaseID(lease.id))
+	cancel()
+	if err == nil {
+		lease.lastKeepAlive = callTime
+		return true
+	}
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) || time.Since(lease.lastKeepAlive) >= lease.ttl/2 {
+		c.LeaseMayExpire(lease.id, lease.lastKeepAlive)
+		return false
+	}
+	return true
+}
+
+// resign revokes candidate lease, deleting its key. Failed revoke is recorded with unknown result,
+// and as lease is no longer kept alive, it will eventually expire.
+func (t electionTraffic) resign(ctx context.Context, c *recordingClient, lease *candidateLease) {
+	revokeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err := c.LeaseRevoke(revokeCtx, lease.id)
+	cancel()
+	if err == nil {
+		t.report.Resigned()
+	}
+}
+
+// expire stops keeping lease alive and waits until it expires, then validates that candidate key was deleted with it.
+func (t electionTraffic) expire(ctx context.Context, c *recordingClient, lease *candidateLease, key string, finish <-chan struct{}) {
+	c.LeaseMayExpire(lease.id, lease.lastKeepAlive)
+	// Lease TTL is extended on etcd leader change, so wait for a few TTLs.
+	deadline := time.Now().Add(3 * lease.ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+		ttlCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := c.client.TimeToLive(ttlCtx, clientv3.LeaseID(lease.id))
+		cancel()
+		if err == nil && resp.TTL == -1 {
+			break
+		}
+	}
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	kv, revision, err := c.GetWithRevision(getCtx, key)
+	cancel()
+	if err != nil {
+		return
+	}
+	if kv != nil {
+		t.report.Violated(fmt.Sprintf("key: %q, lease: %x, revision: %d", key, lease.id, revision))
+		return
+	}
+	t.report.Expired()
+}
+
+func (t electionTraffic) candidateKey(leaseId int64) string {
+	return fmt.Sprintf("%s/%x", t.prefix, leaseId)
+}
+
+type candidateLease struct {
+	id int64
+	// ttl is the TTL granted by server, which can be higher than requested one.
+	ttl time.Duration
+	// lastKeepAlive is the time when the last successful keep alive was sent, lease cannot expire before lastKeepAlive + TTL.
+	lastKeepAlive time.Time
+}
+
+// leaderObservation describes leader observed by client in read done at revision.
+type leaderObservation struct {
+	clientId       int
+	key            string
+	createRevision int64
+	revision       int64
+}
+
+// electionReport collects results of electionTraffic validation from all clients.
+type electionReport struct {
+	mux          sync.Mutex
+	observations []leaderObservation
+	campaigns    int
+	elections    int
+	resigns      int
+	expirations  int
+	losses       int
+	violations   []string
+}
+
+func (r *electionReport) Observed(observation leaderObservation) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.observations = append(r.observations, observation)
+}
+
+func (r *electionReport) Campaigned() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.campaigns++
+}
+
+func (r *electionReport) Elected() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.elections++
+}
+
+func (r *electionReport) Resigned() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.resigns++
+}
+
+func (r *electionReport) Expired() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.expirations++
+}
+
+func (r *electionReport) Lost() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.losses++
+}
+
+func (r *electionReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateElection(t *testing.T, lg *zap.Logger, traffic electionTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	transitions := leaderTransitions(r.observations)
+	lg.Info("Election traffic",
+		zap.Int("campaigns", r.campaigns),
+		zap.Int("elections", r.elections),
+		zap.Int("resigns", r.resigns),
+		zap.Int("expirations", r.expirations),
+		zap.Int("losses", r.losses),
+		zap.Int("observations", len(r.observations)),
+		zap.Int("leader-transitions", transitions),
+	)
+	for _, violation := range validateLeaderObservations(r.observations) {
+		t.Errorf("Broke election guarantee: %s", violation)
+	}
+	for _, violation := range r.violations {
+		t.Errorf("Broke election guarantee: Leader key was not deleted after its lease expired, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.elections == 0 {
+		t.Errorf("No candidate was elected, campaigns: %d", r.campaigns)
+	}
+	if transitions < 2 {
+		t.Errorf("Not enough leader transitions observed, got: %d", transitions)
+	}
+}
+
+// validateLeaderObservations checks that there was at most one leader at any revision. Candidate can become leader
+// only after all candidates created before it are gone, so leaders observed in revision order must have non-decreasing
+// create revisions, meaning that leader is never observed again once a newer leader was observed.
+func validateLeaderObservations(observations []leaderObservation) []string {
+	ordered := make([]leaderObservation, len(observations))
+	copy(ordered, observations)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].revision < ordered[j].revision
+	})
+	violations := []string{}
+	for i := 1; i < len(ordered); i++ {
+		previous, current := ordered[i-1], ordered[i]
+		if current.createRevision < previous.createRevision {
+			violations = append(violations, fmt.Sprintf("leader %q (create revision %d) observed by client %d at revision %d after leader %q (create revision %d) observed by client %d at revision %d",
+				current.key, current.createRevision, current.clientId, current.revision,
+				previous.key, previous.createRevision, previous.clientId, previous.revision))
+		}
+		if current.revision == previous.revision && current.key != previous.key {
+			violations = append(violations, fmt.Sprintf("two leaders %q and %q observed at revision %d", previous.key, current.key, current.revision))
+		}
+	}
+	return violations
+}
+
+// leaderTransitions counts leader changes, as each candidate key can lead only once, it's the number of observed leaders minus one.
+func leaderTransitions(observations []leaderObservation) int {
+	leaders := map[string]struct{}{}
+	for _, o := range observations {
+		leaders[o.key] = struct{}{}
+	}
+	if len(leaders) == 0 {
+		return 0
+	}
+	return len(leaders) - 1
+}
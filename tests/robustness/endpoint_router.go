@@ -0,0 +1,130 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"math/rand"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// endpointRouter picks client used for each request according to KubernetesRouting.
+// With routing other than SingleEndpointRouting each member is served by a separate recording client,
+// so operations in history are recorded under client ids that identify endpoint that served them.
+type endpointRouter struct {
+	routing KubernetesRouting
+	c       *recordingClient
+	ids     identity.Provider
+
+	members []*recordingClient
+	// memberClients maps member ID to client connected to its endpoint.
+	memberClients map[uint64]*recordingClient
+	// leader is ID of member that served as leader for last write, zero if it needs to be looked up.
+	leader uint64
+}
+
+func newEndpointRouter(routing KubernetesRouting, c *recordingClient, ids identity.Provider) *endpointRouter {
+	return &endpointRouter{
+		routing: routing,
+		c:       c,
+		ids:     ids,
+	}
+}
+
+// Reader returns client for the next read request.
+func (r *endpointRouter) Reader(ctx context.Context) *recordingClient {
+	if r.routing == SingleEndpointRouting || !r.connect(ctx) {
+		return r.c
+	}
+	return r.members[rand.Intn(len(r.members))]
+}
+
+// Writer returns client for the next write request.
+func (r *endpointRouter) Writer(ctx context.Context) *recordingClient {
+	if r.routing == SingleEndpointRouting || !r.connect(ctx) {
+		return r.c
+	}
+	if r.leader == 0 {
+		r.leader = r.findLeader(ctx)
+	}
+	if mc, ok := r.memberClients[r.leader]; ok {
+		return mc
+	}
+	return r.c
+}
+
+// WriteFailed should be called when write fails, as leader might have changed.
+func (r *endpointRouter) WriteFailed() {
+	r.leader = 0
+}
+
+// Close closes member clients and merges their history into the history of the router client.
+func (r *endpointRouter) Close() {
+	for _, mc := range r.members {
+		r.c.history.History = r.c.history.Merge(mc.history.History)
+		mc.Close()
+	}
+	r.members = nil
+	r.memberClients = nil
+}
+
+// connect creates client for each cluster member, returns false if members could not be listed.
+func (r *endpointRouter) connect(ctx context.Context) bool {
+	if r.members != nil {
+		return true
+	}
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := r.c.client.MemberList(listCtx)
+	cancel()
+	if err != nil {
+		return false
+	}
+	members := []*recordingClient{}
+	memberClients := map[uint64]*recordingClient{}
+	for _, m := range resp.Members {
+		if len(m.ClientURLs) == 0 {
+			continue
+		}
+		mc, err := NewClient(r.c.lg, m.ClientURLs, r.c.credentials, r.c.keepAlive, r.ids, r.c.baseTime, r.c.dialOptions...)
+		if err != nil {
+			for _, mc := range members {
+				mc.Close()
+			}
+			return false
+		}
+		members = append(members, mc)
+		memberClients[m.ID] = mc
+	}
+	if len(members) == 0 {
+		return false
+	}
+	r.members = members
+	r.memberClients = memberClients
+	return true
+}
+
+// findLeader asks members for the current leader, returns zero if no member knows it.
+func (r *endpointRouter) findLeader(ctx context.Context) uint64 {
+	for _, mc := range r.members {
+		statusCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := mc.client.Status(statusCtx, mc.client.Endpoints()[0])
+		cancel()
+		if err == nil && resp.Leader != 0 {
+			return resp.Leader
+		}
+	}
+	return 0
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -26,7 +28,9 @@ import (
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/client/pkg/v3/fileutil"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/snapshot"
 	"go.etcd.io/etcd/tests/v3/framework/e2e"
 )
 
@@ -36,6 +40,8 @@ const (
 
 var (
 	KillFailpoint                            Failpoint = killFailpoint{}
+	RestartFailpoint                         Failpoint = restartFailpoint{}
+	RestoreDefragFailpoint                   Failpoint = restoreDefragFailpoint{}
 	DefragBeforeCopyPanic                    Failpoint = goPanicFailpoint{"defragBeforeCopy", triggerDefrag{}, AnyMember}
 	DefragBeforeRenamePanic                  Failpoint = goPanicFailpoint{"defragBeforeRename", triggerDefrag{}, AnyMember}
 	BeforeCommitPanic                        Failpoint = goPanicFailpoint{"beforeCommit", nil, AnyMember}
@@ -76,7 +82,8 @@ var (
 	}}
 )
 
-func injectFailpoints(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, config FailpointConfig) {
+// injectFailpoints triggers failpoints, recording each of them in manifest fault schedule.
+func injectFailpoints(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, config FailpointConfig, manifest *runManifest) {
 	ctx, cancel := context.WithTimeout(ctx, triggerTimeout)
 	defer cancel()
 
@@ -98,8 +105,27 @@ func injectFailpoints(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e
 			return
 		}
 
-		lg.Info("Triggering failpoint", zap.String("failpoint", config.failpoint.Name()))
-		err = config.failpoint.Inject(ctx, t, lg, clus)
+		// Random failpoint is resolved here, so the fault schedule records failpoint that was actually triggered.
+		failpoint := config.failpoint
+		if random, ok := failpoint.(randomFailpoint); ok {
+			if failpoint = random.pick(t, clus); failpoint == nil {
+				return
+			}
+		}
+		lg.Info("Triggering failpoint", zap.String("failpoint", failpoint.Name()))
+		start := time.Now()
+		if stepped, ok := failpoint.(steppedFailpoint); ok {
+			err = stepped.InjectSteps(ctx, t, lg, clus, func(step string, stepStart time.Time, stepErr error) {
+				if persistErr := manifest.Injected(failpoint.Name()+"/"+step, stepStart, time.Now(), stepErr); persistErr != nil {
+					t.Errorf("Failed to save run manifest: %v", persistErr)
+				}
+			})
+		} else {
+			err = failpoint.Inject(ctx, t, lg, clus)
+		}
+		if persistErr := manifest.Injected(failpoint.Name(), start, time.Now(), err); persistErr != nil {
+			t.Errorf("Failed to save run manifest: %v", persistErr)
+		}
 		if err != nil {
 			select {
 			case <-ctx.Done():
@@ -129,12 +155,7 @@ func injectFailpoints(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e
 
 func verifyClusterHealth(ctx context.Context, t *testing.T, clus *e2e.EtcdProcessCluster) error {
 	for i := 0; i < len(clus.Procs); i++ {
-		clusterClient, err := clientv3.New(clientv3.Config{
-			Endpoints:            clus.Procs[i].EndpointsGRPC(),
-			Logger:               zap.NewNop(),
-			DialKeepAliveTime:    10 * time.Second,
-			DialKeepAliveTimeout: 100 * time.Millisecond,
-		})
+		clusterClient, err := newMemberProbeClient(clus.Procs[i].EndpointsGRPC()[0], clientCredentials{}, keepAliveConfig{interval: 10 * time.Second, timeout: 100 * time.Millisecond})
 		if err != nil {
 			return fmt.Errorf("Error creating client for cluster %s: %v", clus.Procs[i].Config().Name, err)
 		}
@@ -172,6 +193,16 @@ type AvailabilityChecker interface {
 	Available(e2e.EtcdProcessClusterConfig, e2e.EtcdProcess) bool
 }
 
+// steppedFailpoint is failpoint made of several steps, each of them recorded in fault schedule as they finish,
+// before the whole failpoint is recorded.
+type steppedFailpoint interface {
+	Failpoint
+	InjectSteps(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, record stepRecorder) error
+}
+
+// stepRecorder records step of failpoint started at start, that ended now with err.
+type stepRecorder func(step string, start time.Time, err error)
+
 type killFailpoint struct{}
 
 func (f killFailpoint) Inject(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster) error {
@@ -204,6 +235,174 @@ func (f killFailpoint) Available(e2e.EtcdProcessClusterConfig, e2e.EtcdProcess)
 	return true
 }
 
+// restartFailpoint gracefully stops a random member and starts it again, so it replays its WAL on start.
+type restartFailpoint struct{}
+
+func (f restartFailpoint) Inject(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster) error {
+	member := clus.Procs[rand.Int()%len(clus.Procs)]
+	lg.Info("Restarting member", zap.String("member", member.Config().Name))
+	return member.Restart(ctx)
+}
+
+func (f restartFailpoint) Name() string {
+	return "Restart"
+}
+
+func (f restartFailpoint) Available(e2e.EtcdProcessClusterConfig, e2e.EtcdProcess) bool {
+	return true
+}
+
+// restoreDefragFailpoint restores a random member from snapshot of other member and defragments it right after
+// start, while traffic continues against other members. Restored member starts with raft log of a new cluster,
+// so it can only catch up by receiving snapshot from the leader, which is why leader log has to be compacted
+// before restore. Restored member is validated to converge to the same state as other members.
+type restoreDefragFailpoint struct{}
+
+func (f restoreDefragFailpoint) Inject(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster) error {
+	return f.InjectSteps(ctx, t, lg, clus, func(string, time.Time, error) {})
+}
+
+func (f restoreDefragFailpoint) InjectSteps(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, record stepRecorder) error {
+	i := rand.Int() % len(clus.Procs)
+	member := clus.Procs[i]
+	source := clus.Procs[(i+1)%len(clus.Procs)]
+	step := func(name string, fn func() error) error {
+		start := time.Now()
+		lg.Info("Restore defrag failpoint step", zap.String("step", name), zap.String("member", member.Config().Name))
+		err := fn()
+		record(name, start, err)
+		return err
+	}
+	snapshotPath := filepath.Join(t.TempDir(), "restore.snapshot")
+	if err := step("snapshot", func() error {
+		if err := waitTillLogCompacted(ctx, clus, source); err != nil {
+			return err
+		}
+		_, err := snapshot.SaveWithVersion(ctx, zap.NewNop(), clientv3.Config{
+			Endpoints:            source.EndpointsGRPC(),
+			DialKeepAliveTime:    10 * time.Second,
+			DialKeepAliveTimeout: 100 * time.Millisecond,
+		}, snapshotPath)
+		return err
+	}); err != nil {
+		return err
+	}
+	if err := step("restore", func() error {
+		return restoreMember(ctx, member, snapshotPath)
+	}); err != nil {
+		return err
+	}
+	if err := step("defrag", func() error {
+		return triggerDefrag{}.Trigger(t, ctx, member, clus)
+	}); err != nil {
+		return err
+	}
+	return step("catch-up", func() error {
+		return verifyMemberConverged(ctx, t, clus, member)
+	})
+}
+
+// waitTillLogCompacted waits until raft log was compacted past entries of a new cluster, so member restored from
+// snapshot is sent snapshot by leader, instead of replaying entries already included in the restored snapshot.
+func waitTillLogCompacted(ctx context.Context, clus *e2e.EtcdProcessCluster, member e2e.EtcdProcess) error {
+	c, err := newMemberProbeClient(member.EndpointsGRPC()[0], clientCredentials{}, DefaultKeepAlive)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for {
+		resp, err := c.Status(ctx, member.EndpointsGRPC()[0])
+		if err != nil {
+			return err
+		}
+		// Snapshot is taken every SnapshotCount entries, keeping SnapshotCatchUpEntries entries before it in log.
+		if resp.RaftIndex > uint64(len(clus.Procs))+clus.Cfg.SnapshotCount+clus.Cfg.SnapshotCatchUpEntries {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// restoreMember stops member and replaces its data with the snapshot, keeping member and cluster ids.
+func restoreMember(ctx context.Context, member e2e.EtcdProcess, snapshotPath string) error {
+	if err := member.Stop(); err != nil {
+		return err
+	}
+	cfg := member.Config()
+	if err := os.RemoveAll(cfg.DataDirPath); err != nil {
+		return err
+	}
+	err := e2e.SpawnWithExpect([]string{e2e.BinPath.Etcdutl, "snapshot", "restore", snapshotPath,
+		"--name", cfg.Name,
+		"--initial-cluster", cfg.InitialCluster,
+		"--initial-cluster-token", cfg.InitialToken,
+		"--initial-advertise-peer-urls", cfg.PeerURL.String(),
+		"--data-dir", cfg.DataDirPath,
+	}, "added member")
+	if err != nil {
+		return err
+	}
+	return member.Start(ctx)
+}
+
+// verifyMemberConverged waits for member to catch up with revision of the cluster and checks that hash of its key
+// value store at that revision matches other members.
+func verifyMemberConverged(ctx context.Context, t *testing.T, clus *e2e.EtcdProcessCluster, member e2e.EtcdProcess) error {
+	c, err := clientv3.New(clientv3.Config{
+		Endpoints:            clus.EndpointsGRPC(),
+		Logger:               zap.NewNop(),
+		DialKeepAliveTime:    10 * time.Second,
+		DialKeepAliveTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+		resp, err := c.Get(ctx, "/")
+		if err != nil {
+			continue
+		}
+		revision := resp.Header.Revision
+		hashes := map[string]uint32{}
+		for _, proc := range clus.Procs {
+			hash, err := c.HashKV(ctx, proc.EndpointsGRPC()[0], revision)
+			if err != nil {
+				// Member might not have applied the revision yet, or it was compacted in meantime.
+				break
+			}
+			hashes[proc.Config().Name] = hash.Hash
+		}
+		if len(hashes) != len(clus.Procs) {
+			continue
+		}
+		for name, hash := range hashes {
+			if hash != hashes[member.Config().Name] {
+				t.Errorf("Restored member %q diverged from member %q, revision: %d, hashes: %v", member.Config().Name, name, revision, hashes)
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func (f restoreDefragFailpoint) Name() string {
+	return "RestoreDefrag"
+}
+
+func (f restoreDefragFailpoint) Available(config e2e.EtcdProcessClusterConfig, _ e2e.EtcdProcess) bool {
+	return config.ClusterSize > 1 && fileutil.Exist(e2e.BinPath.Etcdutl)
+}
+
 type goPanicFailpoint struct {
 	failpoint string
 	trigger   trigger
@@ -349,6 +548,16 @@ type randomFailpoint struct {
 }
 
 func (f randomFailpoint) Inject(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster) error {
+	failpoint := f.pick(t, clus)
+	if failpoint == nil {
+		return nil
+	}
+	lg.Info("Triggering failpoint\n", zap.String("failpoint", failpoint.Name()))
+	return failpoint.Inject(ctx, t, lg, clus)
+}
+
+// pick returns random failpoint available on all members, or nil if there is none.
+func (f randomFailpoint) pick(t *testing.T, clus *e2e.EtcdProcessCluster) Failpoint {
 	availableFailpoints := make([]Failpoint, 0, len(f.failpoints))
 	for _, failpoint := range f.failpoints {
 		count := 0
@@ -365,9 +574,7 @@ func (f randomFailpoint) Inject(ctx context.Context, t *testing.T, lg *zap.Logge
 		t.Errorf("No available failpoints")
 		return nil
 	}
-	failpoint := availableFailpoints[rand.Int()%len(availableFailpoints)]
-	lg.Info("Triggering failpoint\n", zap.String("failpoint", failpoint.Name()))
-	return failpoint.Inject(ctx, t, lg, clus)
+	return availableFailpoints[rand.Int()%len(availableFailpoints)]
 }
 
 func (f randomFailpoint) Name() string {
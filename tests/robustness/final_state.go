@@ -0,0 +1,53 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+const finalStateTimeout = 10 * time.Second
+
+// readFinalState reads all keys once traffic ended and failpoints were cleared, so the cluster is healthy.
+// Final state is used to resolve operations with unknown result, see model.ResolveUnknownOperations.
+func readFinalState(ctx context.Context, clus *e2e.EtcdProcessCluster, credentials clientCredentials) ([]model.KeyValue, error) {
+	c, err := newEtcdClient(clus.EndpointsGRPC(), credentials, DefaultKeepAlive)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(ctx, finalStateTimeout)
+	defer cancel()
+	resp, err := c.Get(ctx, "\x00", clientv3.WithFromKey())
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]model.KeyValue, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs = append(kvs, model.KeyValue{
+			Key: string(kv.Key),
+			ValueRevision: model.ValueRevision{
+				Value:       model.ToValueOrHash(string(kv.Value)),
+				ModRevision: kv.ModRevision,
+			},
+		})
+	}
+	return kvs, nil
+}
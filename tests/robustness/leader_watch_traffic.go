@@ -0,0 +1,310 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// leaderWatchTraffic validates that watches survive leader changes without gaps or duplicates in event stream.
+// The first client moves leadership to a random follower every moveInterval, recording each transition.
+// Other clients put keys under their own prefix, while following them with a single watch. As only the client writes
+// under its prefix, watch must deliver event for each successful put exactly once, in the order of revisions.
+// Watch broken by failpoint is resumed from the revision after the last observed event.
+type leaderWatchTraffic struct {
+	prefix string
+	// keyCount is the number of keys each client overwrites under its prefix.
+	keyCount     int
+	moveInterval time.Duration
+	report       *leaderWatchReport
+}
+
+func newLeaderWatchTraffic(prefix string, keyCount int, moveInterval time.Duration) leaderWatchTraffic {
+	return leaderWatchTraffic{
+		prefix:       prefix,
+		keyCount:     keyCount,
+		moveInterval: moveInterval,
+	}
+}
+
+func (t leaderWatchTraffic) ForRun() Traffic {
+	t.report = &leaderWatchReport{}
+	return t
+}
+
+func (t leaderWatchTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateLeaderWatch(tt, lg, t)
+}
+
+func (t leaderWatchTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	if clientId == 0 {
+		t.runLeaderMover(ctx, c, finish)
+		return
+	}
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	if err != nil {
+		return
+	}
+	w := newLeaderWatch(ctx, c, prefix, resp.Header.Revision+1)
+	defer w.cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		limiter.Wait(ctx)
+		key := fmt.Sprintf("%s%d", prefix, rand.Intn(t.keyCount))
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.PutWithRevision(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		// Failed put might have been persisted too, watch only validates its event is in order.
+		if err != nil {
+			continue
+		}
+		if violation := w.observe(t.report, revision); violation != "" {
+			t.report.Violated(fmt.Sprintf("client: %d, prefix: %q, %s", clientId, prefix, violation))
+			return
+		}
+	}
+}
+
+// runLeaderMover periodically moves leadership from the current leader to a random follower.
+func (t leaderWatchTraffic) runLeaderMover(ctx context.Context, c *recordingClient, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		case <-time.After(t.moveInterval):
+		}
+		t.moveLeader(ctx, c)
+	}
+}
+
+func (t leaderWatchTraffic) moveLeader(ctx context.Context, c *recordingClient) {
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	members, err := c.client.MemberList(listCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+	statusCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	status, err := c.client.Status(statusCtx, c.client.Endpoints()[0])
+	cancel()
+	if err != nil || status.Leader == 0 {
+		return
+	}
+	var leaderURLs []string
+	var followers []uint64
+	for _, m := range members.Members {
+		if len(m.ClientURLs) == 0 {
+			continue
+		}
+		if m.ID == status.Leader {
+			leaderURLs = m.ClientURLs
+			continue
+		}
+		followers = append(followers, m.ID)
+	}
+	if len(leaderURLs) == 0 || len(followers) == 0 {
+		return
+	}
+	// Leadership can only be moved by request sent to the leader.
+	lc, err := newEtcdClient(leaderURLs, c.credentials, c.keepAlive)
+	if err != nil {
+		return
+	}
+	defer lc.Close()
+	target := followers[rand.Intn(len(followers))]
+	moveCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	_, err = lc.MoveLeader(moveCtx, target)
+	cancel()
+	if err != nil {
+		return
+	}
+	t.report.Transitioned(leaderTransition{
+		time: time.Now(),
+		from: status.Leader,
+		to:   target,
+	})
+}
+
+// leaderWatch follows events under prefix, resuming the watch from revision after the last observed event if it breaks.
+type leaderWatch struct {
+	ctx          context.Context
+	c            *recordingClient
+	prefix       string
+	watch        clientv3.WatchChan
+	cancel       context.CancelFunc
+	lastRevision int64
+	// lastEventTime is the time when the last event was observed, used to correlate events with leader transitions.
+	lastEventTime time.Time
+}
+
+func newLeaderWatch(ctx context.Context, c *recordingClient, prefix string, revision int64) *leaderWatch {
+	w := &leaderWatch{ctx: ctx, c: c, prefix: prefix, lastRevision: revision - 1, lastEventTime: time.Now()}
+	w.resume()
+	return w
+}
+
+func (w *leaderWatch) resume() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	watchCtx, cancel := context.WithCancel(w.ctx)
+	w.cancel = cancel
+	w.watch = w.c.client.Watch(clientv3.WithRequireLeader(watchCtx), w.prefix, clientv3.WithPrefix(), clientv3.WithRev(w.lastRevision+1))
+}
+
+// observe reads watch events until it observes event of put done at revision. Returns description of a violation
+// if event is duplicated, out of order or event of the put is missing.
+func (w *leaderWatch) observe(report *leaderWatchReport, revision int64) string {
+	deadline := time.After(watchBatchTimeout)
+	for w.lastRevision < revision {
+		select {
+		case <-w.ctx.Done():
+			return ""
+		case <-deadline:
+			// Watch could have stalled on member isolated by failpoint, resume it to be served by other stream.
+			report.Resumed()
+			w.resume()
+			deadline = time.After(watchBatchTimeout)
+		case resp, ok := <-w.watch:
+			if !ok || resp.Err() != nil {
+				if resp.CompactRevision != 0 {
+					// Revisions were compacted before watch was resumed, so there is nothing to validate up to them.
+					w.lastRevision = resp.CompactRevision - 1
+				}
+				report.Resumed()
+				w.resume()
+				continue
+			}
+			for _, event := range resp.Events {
+				eventRevision := event.Kv.ModRevision
+				transitions := report.TransitionsBetween(w.lastEventTime, time.Now())
+				switch {
+				case eventRevision <= w.lastRevision:
+					return fmt.Sprintf("duplicated or out of order event, key: %q, revision: %d, last revision: %d, leader transitions: %s",
+						event.Kv.Key, eventRevision, w.lastRevision, transitions)
+				case revision < eventRevision:
+					return fmt.Sprintf("missing event of put, revision: %d, got event, key: %q, revision: %d, leader transitions: %s",
+						revision, event.Kv.Key, eventRevision, transitions)
+				}
+				w.lastRevision = eventRevision
+				w.lastEventTime = time.Now()
+				report.Observed(len(transitions) != 0)
+			}
+		}
+	}
+	return ""
+}
+
+// leaderTransition describes leadership moved from one member to another, completed at time.
+type leaderTransition struct {
+	time     time.Time
+	from, to uint64
+}
+
+func (l leaderTransition) String() string {
+	return fmt.Sprintf("{from: %x, to: %x, time: %s}", l.from, l.to, l.time.Format(time.StampMicro))
+}
+
+// leaderWatchReport collects results of leaderWatchTraffic validation from all clients.
+type leaderWatchReport struct {
+	mux         sync.Mutex
+	transitions []leaderTransition
+	events      int
+	// eventsAcrossTransition counts events observed with leader transition since the previous event of the same watch.
+	eventsAcrossTransition int
+	resumptions            int
+	violations             []string
+}
+
+func (r *leaderWatchReport) Transitioned(transition leaderTransition) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.transitions = append(r.transitions, transition)
+}
+
+// TransitionsBetween returns leader transitions completed in the given time range.
+func (r *leaderWatchReport) TransitionsBetween(start, end time.Time) []leaderTransition {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var transitions []leaderTransition
+	for _, transition := range r.transitions {
+		if transition.time.After(start) && !transition.time.After(end) {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions
+}
+
+func (r *leaderWatchReport) Observed(acrossTransition bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.events++
+	if acrossTransition {
+		r.eventsAcrossTransition++
+	}
+}
+
+func (r *leaderWatchReport) Resumed() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.resumptions++
+}
+
+func (r *leaderWatchReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateLeaderWatch(t *testing.T, lg *zap.Logger, traffic leaderWatchTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Leader watch traffic", zap.Int("leader-transitions", len(r.transitions)), zap.Int("events", r.events),
+		zap.Int("events-across-transition", r.eventsAcrossTransition), zap.Int("resumptions", r.resumptions))
+	for _, violation := range r.violations {
+		t.Errorf("Broke watch guarantee: Watch delivers each event exactly once and in order, also across leader changes, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if len(r.transitions) == 0 {
+		t.Errorf("No leader transition was done, moveInterval: %s", traffic.moveInterval)
+	}
+	if r.eventsAcrossTransition == 0 {
+		t.Errorf("No watch event was observed across leader transition, moveInterval: %s, events: %d", traffic.moveInterval, r.events)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// leaseDetachTraffic validates that key attached to a lease is detached from it when overwritten by put without lease.
+// Each client grants a lease, puts two keys attached to it and overwrites one of them without lease. Lease is then
+// left to expire, which must delete only the key that is still attached. Reading the deleted key also tells the model
+// that lease has expired, as otherwise it couldn't tell the expired lease without keys from the existing one.
+type leaseDetachTraffic struct {
+	prefix   string
+	leaseTTL int64
+	report   *leaseDetachReport
+}
+
+func newLeaseDetachTraffic(prefix string, leaseTTL int64) leaseDetachTraffic {
+	return leaseDetachTraffic{
+		prefix:   prefix,
+		leaseTTL: leaseTTL,
+	}
+}
+
+func (t leaseDetachTraffic) ForRun() Traffic {
+	t.report = &leaseDetachReport{}
+	return t
+}
+
+func (t leaseDetachTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateLeaseDetach(tt, lg, t)
+}
+
+func (t leaseDetachTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.detach(ctx, clientId, c, limiter, ids, finish)
+	}
+}
+
+// detach runs a single lease lifetime, from granting it to validating keys after its expiration.
+func (t leaseDetachTraffic) detach(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, finish <-chan struct{}) {
+	grantTime := time.Now()
+	grantCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	leaseId, grantedTTL, err := c.LeaseGrant(grantCtx, t.leaseTTL)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+
+	attachedKey := fmt.Sprintf("%s%d/%x/attached", t.prefix, clientId, leaseId)
+	detachedKey := fmt.Sprintf("%s%d/%x/detached", t.prefix, clientId, leaseId)
+	for _, key := range []string{attachedKey, detachedKey} {
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err = c.PutWithLease(putCtx, key, fmt.Sprintf("%d", ids.RequestId()), leaseId)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			t.revoke(ctx, c, limiter, leaseId)
+			return
+		}
+	}
+	value := fmt.Sprintf("%d", ids.RequestId())
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err = c.Put(putCtx, detachedKey, value)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		t.revoke(ctx, c, limiter, leaseId)
+		return
+	}
+	t.report.Detached()
+
+	ttl := time.Duration(grantedTTL) * time.Second
+	if !t.waitExpired(ctx, c, leaseId, ttl, finish) {
+		t.revoke(ctx, c, limiter, leaseId)
+		return
+	}
+	// Lease was never kept alive, but it cannot expire before its TTL passes, as TTL is only extended on leader change.
+	// Narrowing the time of expiration keeps the number of states considered by the model low, while reading
+	// the attached key below tells the model that it has already expired.
+	c.LeaseMayExpire(leaseId, grantTime.Add(ttl))
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	kv, revision, err := c.GetWithRevision(getCtx, detachedKey)
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	if kv == nil || string(kv.Value) != value {
+		t.report.Violated(fmt.Sprintf("client: %d, key: %q, lease: %x, revision: %d, got: %v, expected value: %q", clientId, detachedKey, leaseId, revision, kv, value))
+		return
+	}
+	getCtx, cancel = context.WithTimeout(ctx, RequestTimeout)
+	kv, _, err = c.GetWithRevision(getCtx, attachedKey)
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	if kv != nil {
+		t.report.Violated(fmt.Sprintf("client: %d, key: %q, lease: %x, revision: %d, key attached to expired lease was not deleted", clientId, attachedKey, leaseId, kv.ModRevision))
+		return
+	}
+	t.report.Survived()
+}
+
+// revoke revokes lease that wasn't confirmed to expire, so the model doesn't have to consider its expiration
+// for the rest of the history.
+func (t leaseDetachTraffic) revoke(ctx context.Context, c *recordingClient, limiter *trafficLimiter, leaseId int64) {
+	revokeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err := c.LeaseRevoke(revokeCtx, leaseId)
+	cancel()
+	limiter.Adapt(ctx, err)
+}
+
+// waitExpired waits until lease expires, returns false if it didn't expire in time. Lease TTL is extended
+// on etcd leader change, so it waits for a few TTLs.
+func (t leaseDetachTraffic) waitExpired(ctx context.Context, c *recordingClient, leaseId int64, ttl time.Duration, finish <-chan struct{}) bool {
+	deadline := time.Now().Add(3 * ttl)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-finish:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+		ttlCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := c.client.TimeToLive(ttlCtx, clientv3.LeaseID(leaseId))
+		cancel()
+		if err == nil && resp.TTL == -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// leaseDetachReport collects results of leaseDetachTraffic validation from all clients.
+type leaseDetachReport struct {
+	mux        sync.Mutex
+	detached   int
+	survived   int
+	violations []string
+}
+
+func (r *leaseDetachReport) Detached() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.detached++
+}
+
+func (r *leaseDetachReport) Survived() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.survived++
+}
+
+func (r *leaseDetachReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateLeaseDetach(t *testing.T, lg *zap.Logger, traffic leaseDetachTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Lease detach traffic", zap.Int("detached", r.detached), zap.Int("survived", r.survived))
+	for _, violation := range r.violations {
+		t.Errorf("Broke lease guarantee: Put without lease detaches key from its lease, so only keys still attached are deleted on lease expiration, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.survived == 0 {
+		t.Errorf("No overwritten key was validated after lease expiration, leaseTTL: %d, detached: %d", traffic.leaseTTL, r.detached)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// leaseClockToleranceEnv overrides clock tolerance of lease renewal traffic, see model.DefaultLeaseClockTolerance.
+// Value is a duration, for example "500ms".
+const leaseClockToleranceEnv = "ROBUSTNESS_LEASE_CLOCK_TOLERANCE"
+
+// leaseRenewalTraffic validates that lease expires if and only if it's not kept alive for longer than its TTL.
+// Each client grants a lease and keeps it alive a few times, then either keeps it alive once more and checks it
+// persists, or stops keeping it alive and waits for it to expire. Recorded keepalives are validated by the model.
+type leaseRenewalTraffic struct {
+	leaseTTL   int64
+	keepAlives int
+	// clockTolerance covers difference between client and server measuring lease TTL, including TTL granularity.
+	clockTolerance time.Duration
+	// revokeDelay is the time server can take to revoke lease that was not kept alive for its TTL. It includes
+	// lease TTL extension on leader change, as new leader resets remaining TTL of all leases.
+	revokeDelay time.Duration
+	report      *leaseRenewalReport
+}
+
+func newLeaseRenewalTraffic(leaseTTL int64, keepAlives int, clockTolerance, revokeDelay time.Duration) leaseRenewalTraffic {
+	return leaseRenewalTraffic{
+		leaseTTL:       leaseTTL,
+		keepAlives:     keepAlives,
+		clockTolerance: clockTolerance,
+		revokeDelay:    revokeDelay,
+	}
+}
+
+func (t leaseRenewalTraffic) ForRun() Traffic {
+	t.report = &leaseRenewalReport{}
+	return t
+}
+
+func (t leaseRenewalTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateLeaseRenewal(tt, lg, t)
+}
+
+// withLeaseClockTolerance returns traffic config with clock tolerance of lease renewal traffic overridden
+// by leaseClockToleranceEnv, config of other traffics is returned unchanged.
+func withLeaseClockTolerance(t *testing.T, config trafficConfig) trafficConfig {
+	leaseRenewal, ok := config.traffic.(leaseRenewalTraffic)
+	if !ok {
+		return config
+	}
+	value, ok := os.LookupEnv(leaseClockToleranceEnv)
+	if !ok {
+		return config
+	}
+	tolerance, err := time.ParseDuration(value)
+	if err != nil || tolerance < 0 {
+		t.Fatalf("Failed to parse %s=%q as non-negative duration: %v", leaseClockToleranceEnv, value, err)
+	}
+	leaseRenewal.clockTolerance = tolerance
+	config.traffic = leaseRenewal
+	return config
+}
+
+func (t leaseRenewalTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.renew(ctx, clientId, c, limiter, finish)
+	}
+}
+
+// renew runs a single lease lifetime, from granting it to checking whether it expired.
+func (t leaseRenewalTraffic) renew(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, finish <-chan struct{}) {
+	limiter.Wait(ctx)
+	expire := rand.Intn(2) == 0
+	grantCall := time.Now()
+	grantCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	leaseId, grantedTTL, err := c.LeaseGrant(grantCtx, t.leaseTTL)
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	renewal := model.LeaseRenewal{
+		LeaseID:  leaseId,
+		TTL:      time.Duration(grantedTTL) * time.Second,
+		Renewals: []model.LeaseRenewalCall{{Call: grantCall, Return: time.Now()}},
+	}
+	keepAlives := t.keepAlives
+	if !expire {
+		// Lease that should persist is kept alive once more before the check.
+		keepAlives++
+	}
+	for i := 0; i < keepAlives; i++ {
+		// Keepalives are not rate limited, as backoff of limiter could delay them beyond lease TTL.
+		if !waitFor(ctx, finish, renewal.TTL/3) {
+			t.revoke(ctx, c, limiter, leaseId)
+			return
+		}
+		keepAliveCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		call := time.Now()
+		_, err = c.client.KeepAliveOnce(keepAliveCtx, clientv3.LeaseID(leaseId))
+		cancel()
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			renewal.Check = model.LeaseCheck{Call: call, Return: time.Now(), Expired: true}
+			t.validate(clientId, c, renewal, expire)
+			return
+		}
+		renewal.Renewals = append(renewal.Renewals, model.LeaseRenewalCall{Call: call, Return: time.Now(), Unknown: err != nil})
+	}
+
+	last := renewal.Renewals[len(renewal.Renewals)-1]
+	// Lease that should expire is checked until it does, or until it was not kept alive for longer than allowed.
+	deadline := last.Return.Add(renewal.TTL + t.clockTolerance + t.revokeDelay + time.Second)
+	for {
+		ttlCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		call := time.Now()
+		resp, err := c.client.TimeToLive(ttlCtx, clientv3.LeaseID(leaseId))
+		cancel()
+		if err == nil {
+			renewal.Check = model.LeaseCheck{Call: call, Return: time.Now(), Expired: resp.TTL == -1}
+			if !expire || renewal.Check.Expired {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		if !waitFor(ctx, finish, 100*time.Millisecond) {
+			t.revoke(ctx, c, limiter, leaseId)
+			return
+		}
+	}
+	if renewal.Check.Call.IsZero() {
+		t.revoke(ctx, c, limiter, leaseId)
+		return
+	}
+	t.validate(clientId, c, renewal, expire)
+	if !renewal.Check.Expired {
+		t.revoke(ctx, c, limiter, leaseId)
+	}
+}
+
+// validate validates renewal and informs model that lease could have expired since it was last kept alive.
+func (t leaseRenewalTraffic) validate(clientId int, c *recordingClient, renewal model.LeaseRenewal, expire bool) {
+	if renewal.Check.Expired {
+		c.LeaseMayExpire(renewal.LeaseID, renewal.Renewals[len(renewal.Renewals)-1].Call)
+	}
+	t.report.Checked(renewal.Check.Expired, expire)
+	if err := model.ValidateLeaseRenewal(renewal, t.clockTolerance, t.revokeDelay); err != nil {
+		t.report.Violated(fmt.Sprintf("client: %d, %s", clientId, err))
+	}
+}
+
+// revoke revokes lease that wasn't confirmed to expire, so the model doesn't have to consider its expiration
+// for the rest of the history.
+func (t leaseRenewalTraffic) revoke(ctx context.Context, c *recordingClient, limiter *trafficLimiter, leaseId int64) {
+	revokeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err := c.LeaseRevoke(revokeCtx, leaseId)
+	cancel()
+	limiter.Adapt(ctx, err)
+}
+
+// waitFor waits for duration, returns false if traffic finished before that.
+func waitFor(ctx context.Context, finish <-chan struct{}, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-finish:
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// leaseRenewalReport collects results of leaseRenewalTraffic validation from all clients.
+type leaseRenewalReport struct {
+	mux        sync.Mutex
+	persisted  int
+	expired    int
+	unexpected int
+	violations []string
+}
+
+// Checked records result of lease check, together with whether lease was expected to expire by traffic.
+func (r *leaseRenewalReport) Checked(expired, expectExpired bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if expired {
+		r.expired++
+	} else {
+		r.persisted++
+	}
+	if expired != expectExpired {
+		r.unexpected++
+	}
+}
+
+func (r *leaseRenewalReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateLeaseRenewal(t *testing.T, lg *zap.Logger, traffic leaseRenewalTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Lease renewal traffic", zap.Int("persisted", r.persisted), zap.Int("expired", r.expired), zap.Int("unexpected", r.unexpected), zap.Duration("clock-tolerance", traffic.clockTolerance))
+	for _, violation := range r.violations {
+		t.Errorf("Broke lease guarantee: Renewal - lease expires if and only if it's not kept alive for longer than its TTL, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.persisted == 0 || r.expired == 0 {
+		t.Errorf("Traffic didn't check both persisting and expiring leases, persisted: %d, expired: %d", r.persisted, r.expired)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+var DefaultBackoff = backoffConfig{
+	initialDelay: 10 * time.Millisecond,
+	maxDelay:     time.Second,
+	rateDecrease: 0.5,
+	rateIncrease: 1,
+}
+
+type backoffConfig struct {
+	// initialDelay is how long client pauses after first backpressure error, doubled on consecutive errors up to maxDelay.
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	// rateDecrease multiplies traffic rate on each backpressure error.
+	rateDecrease float64
+	// rateIncrease is added to traffic rate (in qps) on each successful request, until maximal qps is restored.
+	rateIncrease float64
+}
+
+// trafficLimiter limits rate of traffic shared by all clients and adapts it when etcd applies backpressure.
+type trafficLimiter struct {
+	*rate.Limiter
+	lg       *zap.Logger
+	config   backoffConfig
+	maxLimit rate.Limit
+
+	mux   sync.Mutex
+	delay time.Duration
+	// backpressureCount counts requests rejected by etcd with ErrTooManyRequests.
+	backpressureCount int
+}
+
+func newTrafficLimiter(lg *zap.Logger, maximalQPS float64, config backoffConfig) *trafficLimiter {
+	return &trafficLimiter{
+		Limiter:  rate.NewLimiter(rate.Limit(maximalQPS), 200),
+		lg:       lg,
+		config:   config,
+		maxLimit: rate.Limit(maximalQPS),
+	}
+}
+
+// Adapt should be called with result of each request. Backpressure errors reduce traffic rate and pause client before returning,
+// successful requests slowly restore rate, other errors are ignored.
+func (l *trafficLimiter) Adapt(ctx context.Context, err error) {
+	if err == nil {
+		l.recover()
+		return
+	}
+	if !errors.Is(err, rpctypes.ErrTooManyRequests) {
+		return
+	}
+	delay := l.backoff()
+	select {
+	case <-ctx.Done():
+	case <-time.After(delay):
+	}
+}
+
+func (l *trafficLimiter) backoff() time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.backpressureCount++
+	l.delay *= 2
+	if l.delay < l.config.initialDelay {
+		l.delay = l.config.initialDelay
+	}
+	if l.delay > l.config.maxDelay {
+		l.delay = l.config.maxDelay
+	}
+	limit := l.Limit() * rate.Limit(l.config.rateDecrease)
+	if limit < 1 {
+		limit = 1
+	}
+	l.SetLimit(limit)
+	l.lg.Info("Backpressure applied by etcd, slowing down traffic", zap.Duration("delay", l.delay), zap.Float64("qps", float64(limit)))
+	return l.delay
+}
+
+func (l *trafficLimiter) recover() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.delay = 0
+	if l.Limit() >= l.maxLimit {
+		return
+	}
+	limit := l.Limit() + rate.Limit(l.config.rateIncrease)
+	if limit >= l.maxLimit {
+		limit = l.maxLimit
+		l.lg.Info("Traffic rate restored after backpressure", zap.Float64("qps", float64(limit)))
+	}
+	l.SetLimit(limit)
+}
+
+func (l *trafficLimiter) BackpressureCount() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.backpressureCount
+}
@@ -16,6 +16,10 @@ package robustness
 
 import (
 	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,6 +29,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"go.etcd.io/etcd/api/v3/version"
+	"go.etcd.io/etcd/server/v3/embed"
 	"go.etcd.io/etcd/tests/v3/framework/e2e"
 	"go.etcd.io/etcd/tests/v3/robustness/model"
 )
@@ -41,6 +46,7 @@ var (
 		maximalQPS:      200,
 		clientCount:     8,
 		requestProgress: false,
+		backoff:         DefaultBackoff,
 		traffic: etcdTraffic{
 			keyCount:     10,
 			leaseTTL:     DefaultLeaseTTL,
@@ -55,6 +61,11 @@ var (
 				{choice: string(CompareAndSet), weight: 10},
 			},
 		},
+		minimalRequestCounts: map[string]int{
+			string(PutWithLease): 10,
+			string(LeaseRevoke):  10,
+			string(LargePut):     1,
+		},
 	}
 	HighTraffic = trafficConfig{
 		name:            "HighTraffic",
@@ -62,6 +73,8 @@ var (
 		maximalQPS:      1000,
 		clientCount:     12,
 		requestProgress: false,
+		backoff:         DefaultBackoff,
+		readRetry:       DefaultReadRetry,
 		traffic: etcdTraffic{
 			keyCount:     10,
 			largePutSize: 32769,
@@ -73,11 +86,33 @@ var (
 			},
 		},
 	}
+	RecentKeyTraffic = trafficConfig{
+		name:            "RecentKeyTraffic",
+		minimalQPS:      200,
+		maximalQPS:      1000,
+		clientCount:     12,
+		requestProgress: false,
+		backoff:         DefaultBackoff,
+		readRetry:       DefaultReadRetry,
+		traffic: etcdTraffic{
+			keyCount:        10,
+			largePutSize:    32769,
+			leaseTTL:        DefaultLeaseTTL,
+			recentKeyChance: 30,
+			recentKeyCount:  3,
+			writeChoices: []choiceWeight{
+				{choice: string(Put), weight: 85},
+				{choice: string(MultiOpTxn), weight: 10},
+				{choice: string(LargePut), weight: 5},
+			},
+		},
+	}
 	KubernetesTraffic = trafficConfig{
 		name:        "Kubernetes",
 		minimalQPS:  200,
 		maximalQPS:  1000,
 		clientCount: 12,
+		backoff:     DefaultBackoff,
 		traffic: kubernetesTraffic{
 			averageKeyCount: 5,
 			resource:        "pods",
@@ -87,14 +122,270 @@ var (
 				{choice: string(KubernetesDelete), weight: 15},
 				{choice: string(KubernetesCreate), weight: 10},
 			},
+			deleteRetryChance: 20,
 		},
 	}
+	JobQueueTraffic = trafficConfig{
+		name:        "JobQueue",
+		minimalQPS:  200,
+		maximalQPS:  1000,
+		clientCount: 12,
+		backoff:     DefaultBackoff,
+		traffic: jobQueueTraffic{
+			prefix:        "/jobs/",
+			producerCount: 4,
+			consumerCount: 8,
+		},
+	}
+	KeyRecreateTraffic = trafficConfig{
+		name:        "KeyRecreate",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount:     3,
+			largePutSize: 32769,
+			leaseTTL:     DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(Recreate), weight: 50},
+				{choice: string(Put), weight: 30},
+				{choice: string(Delete), weight: 20},
+				{choice: string(CreateIfAbsent), weight: 20},
+			},
+		},
+	}
+	LeaseTxnTraffic = trafficConfig{
+		name:        "LeaseTxn",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount:              10,
+			leaseTTL:              DefaultLeaseTTL,
+			leaseTxnAttachChance:  70,
+			leaseTxnRevokedChance: 10,
+			writeChoices: []choiceWeight{
+				{choice: string(LeaseTxn), weight: 50},
+				{choice: string(Put), weight: 20},
+				{choice: string(Delete), weight: 10},
+				{choice: string(PutWithLease), weight: 10},
+				{choice: string(LeaseRevoke), weight: 10},
+			},
+		},
+	}
+	WatchFragmentTraffic = trafficConfig{
+		name:        "WatchFragment",
+		minimalQPS:  10,
+		maximalQPS:  50,
+		clientCount: 4,
+		backoff:     DefaultBackoff,
+		traffic:     newWatchTraffic("/watch/", 128*1024, 20, DefaultWatchFragmentThreshold, 50, 20),
+	}
+	AuthTraffic = trafficConfig{
+		name:        "Auth",
+		minimalQPS:  10,
+		maximalQPS:  200,
+		clientCount: 12,
+		backoff:     DefaultBackoff,
+		authEnabled: true,
+		traffic:     newAuthTraffic(10),
+	}
+	MonotonicReadTraffic = trafficConfig{
+		name:        "MonotonicRead",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newMonotonicReadTraffic(10, time.Second),
+	}
+	ElectionTraffic = trafficConfig{
+		name:        "Election",
+		minimalQPS:  50,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newElectionTraffic(6),
+	}
+	ReadAfterWriteTraffic = trafficConfig{
+		name:        "ReadAfterWrite",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newReadAfterWriteTraffic(5),
+	}
+	CompactionWatchTraffic = trafficConfig{
+		name:        "CompactionWatch",
+		minimalQPS:  50,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newCompactionWatchTraffic("/compaction/", 10, CompactionRandom),
+	}
+	WatchContiguityTraffic = trafficConfig{
+		name:        "WatchContiguity",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newWatchContiguityTraffic("/watch-contiguity/", 5, 20, 5, CompactionRandom),
+	}
+	LeaderWatchTraffic = trafficConfig{
+		name:        "LeaderWatch",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newLeaderWatchTraffic("/leader-watch/", 10, time.Second),
+	}
+	WatchIdTraffic = trafficConfig{
+		name:        "WatchId",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newWatchIdTraffic("/watch-id/", false),
+	}
+	WatchIdAuthTraffic = trafficConfig{
+		name:        "WatchIdAuth",
+		minimalQPS:  50,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		authEnabled: true,
+		traffic:     newWatchIdTraffic("/watch-id-auth/", true),
+	}
+	DefragmentTransparencyTraffic = trafficConfig{
+		name:        "DefragmentTransparency",
+		minimalQPS:  20,
+		maximalQPS:  100,
+		clientCount: 4,
+		backoff:     DefaultBackoff,
+		traffic:     newDefragmentTransparencyTraffic("/defragment/", 5),
+	}
+	WatchCoalescingTraffic = trafficConfig{
+		name:        "WatchCoalescing",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 4,
+		backoff:     DefaultBackoff,
+		traffic:     newWatchCoalescingTraffic("/watch-coalescing/", 50),
+	}
+	QuotaTraffic = trafficConfig{
+		name:        "Quota",
+		minimalQPS:  10,
+		maximalQPS:  100,
+		clientCount: 4,
+		backoff:     DefaultBackoff,
+		traffic:     newQuotaTraffic("/quota/", 10, 64*1024, quotaBackendBytes),
+	}
+	LeaseDetachTraffic = trafficConfig{
+		name:        "LeaseDetach",
+		minimalQPS:  10,
+		maximalQPS:  100,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newLeaseDetachTraffic("/lease-detach/", 2),
+	}
+	LeaseRenewalTraffic = trafficConfig{
+		name:        "LeaseRenewal",
+		minimalQPS:  5,
+		maximalQPS:  100,
+		clientCount: 20,
+		backoff:     DefaultBackoff,
+		// Revoke delay covers single leader change extending lease by its TTL.
+		traffic: newLeaseRenewalTraffic(2, 2, model.DefaultLeaseClockTolerance, 3*time.Second),
+	}
+	BulkScanTraffic = trafficConfig{
+		name:        "BulkScan",
+		minimalQPS:  100,
+		maximalQPS:  300,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newBulkScanTraffic("/bulk-scan/", 50, 20),
+	}
+	DeleteRangeTraffic = trafficConfig{
+		name:        "DeleteRange",
+		minimalQPS:  100,
+		maximalQPS:  300,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newDeleteRangeTraffic("/delete-range/", 20, 3, 1),
+	}
+	CompactionReadTraffic = trafficConfig{
+		name:        "CompactionRead",
+		minimalQPS:  100,
+		maximalQPS:  500,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newCompactionReadTraffic("/compaction-read/", 10, 5, CompactionRandom),
+	}
+	CompactionSurvivalTraffic = trafficConfig{
+		name:        "CompactionSurvival",
+		minimalQPS:  100,
+		maximalQPS:  500,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newCompactionSurvivalTraffic("/compaction-survival/", 9, 3, CompactionRandom),
+	}
+	CompactionRaceTraffic = trafficConfig{
+		name:        "CompactionRace",
+		minimalQPS:  50,
+		maximalQPS:  200,
+		clientCount: 4,
+		backoff:     DefaultBackoff,
+		traffic:     newCompactionRaceTraffic("/compaction-race/", CompactionRandom),
+	}
+	SerializableReadTraffic = trafficConfig{
+		name:        "SerializableRead",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		// Backend commits applied entries every 100ms by default, interval includes margin for slow disk.
+		traffic: newSerializableReadTraffic(10, time.Second),
+	}
+	CommittedReadTraffic = trafficConfig{
+		name:        "CommittedRead",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newCommittedReadTraffic("/committed-read/", 5),
+	}
+	MemberRestartTraffic = trafficConfig{
+		name:        "MemberRestart",
+		minimalQPS:  100,
+		maximalQPS:  300,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newMemberRestartTraffic("/member-restart/"),
+	}
+	TxnLimitTraffic = trafficConfig{
+		name:        "TxnLimit",
+		minimalQPS:  50,
+		maximalQPS:  200,
+		clientCount: 6,
+		backoff:     DefaultBackoff,
+		traffic:     newTxnLimitTraffic("/txn-limit/", int(embed.DefaultMaxTxnOps), []int{-1, 0, 1, 2}, 10),
+	}
+	SecretRotationTraffic = trafficConfig{
+		name:        "SecretRotation",
+		minimalQPS:  100,
+		maximalQPS:  300,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic:     newSecretRotationTraffic("/secret-rotation/", 0.2),
+	}
 	ReqProgTraffic = trafficConfig{
 		name:            "RequestProgressTraffic",
 		minimalQPS:      200,
 		maximalQPS:      1000,
 		clientCount:     12,
 		requestProgress: true,
+		backoff:         DefaultBackoff,
 		traffic: etcdTraffic{
 			keyCount:     10,
 			largePutSize: 8196,
@@ -105,12 +396,225 @@ var (
 			},
 		},
 	}
+	NestedTxnTraffic = trafficConfig{
+		name:        "NestedTxn",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount:       10,
+			leaseTTL:       DefaultLeaseTTL,
+			nestedTxnDepth: 2,
+			writeChoices: []choiceWeight{
+				{choice: string(NestedTxn), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(NestedTxn): 1,
+		},
+	}
+	GetAndPutTraffic = trafficConfig{
+		name:        "GetAndPut",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(GetAndPut), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(GetAndPut): 1,
+		},
+	}
+	SortedRangeTraffic = trafficConfig{
+		name:        "SortedRange",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(SortedRange), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(SortedRange): 1,
+		},
+	}
+	CompareAndDeleteTraffic = trafficConfig{
+		name:        "CompareAndDelete",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(CompareAndDelete), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(CompareAndDelete): 1,
+		},
+	}
+	ModRevisionRangeTraffic = trafficConfig{
+		name:        "ModRevisionRange",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(ModRevisionRange), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(ModRevisionRange): 1,
+		},
+	}
+	GuardedTxnTraffic = trafficConfig{
+		name:        "GuardedTxn",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount:   10,
+			leaseTTL:   DefaultLeaseTTL,
+			guardCount: 3,
+			writeChoices: []choiceWeight{
+				{choice: string(GuardedTxn), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(GuardedTxn): 1,
+		},
+	}
+	KeysOnlyRangeTraffic = trafficConfig{
+		name:        "KeysOnlyRange",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(KeysOnlyRange), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(KeysOnlyRange): 1,
+		},
+	}
+	PaginatedRangeTraffic = trafficConfig{
+		name:        "PaginatedRange",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(PaginatedRange), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(PaginatedRange): 1,
+		},
+	}
+	CompareMultiAndSwapTraffic = trafficConfig{
+		name:        "CompareMultiAndSwap",
+		minimalQPS:  100,
+		maximalQPS:  200,
+		clientCount: 8,
+		backoff:     DefaultBackoff,
+		traffic: etcdTraffic{
+			keyCount: 10,
+			leaseTTL: DefaultLeaseTTL,
+			writeChoices: []choiceWeight{
+				{choice: string(CompareMultiAndSwap), weight: 50},
+				{choice: string(Put), weight: 40},
+				{choice: string(Delete), weight: 10},
+			},
+		},
+		minimalRequestCounts: map[string]int{
+			string(CompareMultiAndSwap): 1,
+		},
+	}
 	defaultTraffic = LowTraffic
 	trafficList    = []trafficConfig{
 		LowTraffic, HighTraffic, KubernetesTraffic,
 	}
+	// extraTrafficList are traffics run only when opted in by extraTrafficEnv, so they don't change what runs by default.
+	extraTrafficList = []trafficConfig{
+		RecentKeyTraffic, JobQueueTraffic, KeyRecreateTraffic, WatchFragmentTraffic,
+		MonotonicReadTraffic, ElectionTraffic, ReadAfterWriteTraffic, CompactionWatchTraffic,
+		CompactionReadTraffic, LeaseDetachTraffic, TxnLimitTraffic,
+		SerializableReadTraffic, LeaseTxnTraffic, WatchContiguityTraffic, LeaseRenewalTraffic,
+		BulkScanTraffic, DeleteRangeTraffic, SecretRotationTraffic, CompactionSurvivalTraffic, MemberRestartTraffic,
+		CompactionRaceTraffic, CommittedReadTraffic, WatchIdTraffic, DefragmentTransparencyTraffic, WatchCoalescingTraffic,
+		NestedTxnTraffic, GetAndPutTraffic, SortedRangeTraffic, CompareAndDeleteTraffic, ModRevisionRangeTraffic,
+		GuardedTxnTraffic, KeysOnlyRangeTraffic, PaginatedRangeTraffic, CompareMultiAndSwapTraffic,
+	}
 )
 
+// extraTrafficEnv lists comma separated names of traffics from extraTrafficList to run in addition to trafficList,
+// "all" runs all of them.
+const extraTrafficEnv = "ROBUSTNESS_EXTRA_TRAFFIC"
+
+// extraTraffics returns traffics of extraTrafficList opted in by extraTrafficEnv.
+func extraTraffics(t *testing.T) []trafficConfig {
+	value, ok := os.LookupEnv(extraTrafficEnv)
+	if !ok || value == "" {
+		return nil
+	}
+	if value == "all" {
+		return extraTrafficList
+	}
+	var traffics []trafficConfig
+	for _, name := range strings.Split(value, ",") {
+		found := false
+		for _, traffic := range extraTrafficList {
+			if traffic.name == name {
+				traffics = append(traffics, traffic)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("Unknown traffic %q in %s", name, extraTrafficEnv)
+		}
+	}
+	return traffics
+}
+
 func TestRobustness(t *testing.T) {
 	testRunner.BeforeTest(t)
 	v, err := e2e.GetVersionFromBinary(e2e.BinPath.Etcd)
@@ -124,19 +628,15 @@ func TestRobustness(t *testing.T) {
 		traffic   *trafficConfig
 	}
 	scenarios := []scenario{}
-	for _, traffic := range trafficList {
-		scenarios = append(scenarios, scenario{
-			name:      "ClusterOfSize1/" + traffic.name,
-			failpoint: RandomFailpoint,
-			traffic:   &traffic,
-			config: *e2e.NewConfig(
-				e2e.WithClusterSize(1),
-				e2e.WithSnapshotCount(100),
-				e2e.WithGoFailEnabled(true),
-				e2e.WithCompactionBatchLimit(100), // required for compactBeforeCommitBatch and compactAfterCommitBatch failpoints
-				e2e.WithWatchProcessNotifyInterval(100*time.Millisecond),
-			),
-		})
+	traffics := append(append([]trafficConfig{}, trafficList...), extraTraffics(t)...)
+	for _, traffic := range traffics {
+		clusterOfSize1Options := []e2e.EPClusterOption{
+			e2e.WithClusterSize(1),
+			e2e.WithSnapshotCount(100),
+			e2e.WithGoFailEnabled(true),
+			e2e.WithCompactionBatchLimit(100), // required for compactBeforeCommitBatch and compactAfterCommitBatch failpoints
+			e2e.WithWatchProcessNotifyInterval(100 * time.Millisecond),
+		}
 		clusterOfSize3Options := []e2e.EPClusterOption{
 			e2e.WithIsPeerTLS(true),
 			e2e.WithSnapshotCount(100),
@@ -146,8 +646,16 @@ func TestRobustness(t *testing.T) {
 			e2e.WithWatchProcessNotifyInterval(100 * time.Millisecond),
 		}
 		if !v.LessThan(version.V3_6) {
-			clusterOfSize3Options = append(clusterOfSize3Options, e2e.WithSnapshotCatchUpEntries(100))
+			// Consistent index returned by members lets clients validate apply progress of each member.
+			clusterOfSize1Options = append(clusterOfSize1Options, e2e.WithDebugConsistentIndexTrailer(true))
+			clusterOfSize3Options = append(clusterOfSize3Options, e2e.WithSnapshotCatchUpEntries(100), e2e.WithDebugConsistentIndexTrailer(true))
 		}
+		scenarios = append(scenarios, scenario{
+			name:      "ClusterOfSize1/" + traffic.name,
+			failpoint: RandomFailpoint,
+			traffic:   &traffic,
+			config:    *e2e.NewConfig(clusterOfSize1Options...),
+		})
 		scenarios = append(scenarios, scenario{
 			name:      "ClusterOfSize3/" + traffic.name,
 			failpoint: RandomFailpoint,
@@ -155,6 +663,79 @@ func TestRobustness(t *testing.T) {
 			config:    *e2e.NewConfig(clusterOfSize3Options...),
 		})
 	}
+	// Failpoints triggered by defragment or compaction use unauthenticated clients, so they cannot be used with auth traffic.
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/" + AuthTraffic.name,
+		failpoint: KillFailpoint,
+		traffic:   &AuthTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+		),
+	})
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/" + WatchIdAuthTraffic.name,
+		failpoint: KillFailpoint,
+		traffic:   &WatchIdAuthTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+		),
+	})
+	// Compaction and defragmentation done by failpoints would reclaim space, hiding whether traffic recovered from
+	// exceeded quota by itself.
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/" + QuotaTraffic.name,
+		failpoint: KillFailpoint,
+		traffic:   &QuotaTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+			e2e.WithQuotaBackendBytes(quotaBackendBytes),
+		),
+	})
+	// Leadership can only be moved in cluster with multiple members.
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/" + LeaderWatchTraffic.name,
+		failpoint: RandomFailpoint,
+		traffic:   &LeaderWatchTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+			e2e.WithPeerProxy(true),
+			e2e.WithGoFailEnabled(true),
+			e2e.WithCompactionBatchLimit(100), // required for compactBeforeCommitBatch and compactAfterCommitBatch failpoints
+		),
+	})
+	// Graceful restart makes member replay its WAL, while clients pinned to it keep reading through it.
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/" + MemberRestartTraffic.name + "/Restart",
+		failpoint: RestartFailpoint,
+		traffic:   &MemberRestartTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+		),
+	})
+	// Restored member catches up by snapshot from leader, which requires leader to compact its log quickly.
+	if !v.LessThan(version.V3_6) {
+		scenarios = append(scenarios, scenario{
+			name:      "ClusterOfSize3/RestoreDefrag",
+			failpoint: RestoreDefragFailpoint,
+			traffic:   &HighTraffic,
+			config: *e2e.NewConfig(
+				e2e.WithSnapshotCount(100),
+				e2e.WithSnapshotCatchUpEntries(100),
+			),
+		})
+	}
+	// Committing backend transaction on almost every write shrinks window of writes applied, but not yet persisted,
+	// that are lost when member crashes and must be recovered by replaying WAL.
+	scenarios = append(scenarios, scenario{
+		name:      "ClusterOfSize3/AggressiveBatchCommit",
+		failpoint: KillFailpoint,
+		traffic:   &HighTraffic,
+		config: *e2e.NewConfig(
+			e2e.WithSnapshotCount(100),
+			e2e.WithBackendBatchInterval(time.Millisecond),
+			e2e.WithBackendBatchLimit(1),
+		),
+	})
 	scenarios = append(scenarios, scenario{
 		name:      "Issue14370",
 		failpoint: RaftBeforeSavePanic,
@@ -219,13 +800,32 @@ func TestRobustness(t *testing.T) {
 }
 
 func testRobustness(ctx context.Context, t *testing.T, lg *zap.Logger, config e2e.EtcdProcessClusterConfig, traffic *trafficConfig, failpoint FailpointConfig) {
-	r := report{lg: lg}
-	var err error
+	overridden := withLeaseClockTolerance(t, *traffic)
+	overridden.traffic = overridden.traffic.ForRun()
+	traffic = &overridden
+	r := report{lg: lg, path: testResultsDirectory(t), timelineKeys: timelineKeys()}
+	v, err := e2e.GetVersionFromBinary(e2e.BinPath.Etcd)
+	if err != nil {
+		t.Fatalf("Failed checking etcd version binary, binary: %q, err: %v", e2e.BinPath.Etcd, err)
+	}
+	seed := runSeed(t)
+	lg.Info("Seeding random", zap.Int64("seed", seed))
+	rand.Seed(seed)
+	manifest := newRunManifest(lg, filepath.Join(r.path, "manifest.json"), seed, v.String(), config, *traffic, failpoint)
+	if err = manifest.Persist(); err != nil {
+		t.Fatal(err)
+	}
+
 	r.clus, err = e2e.NewEtcdProcessCluster(ctx, t, e2e.WithConfig(&config))
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer r.clus.Close()
+	if traffic.authEnabled {
+		if err = enableAuth(ctx, r.clus.EndpointsGRPC()); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	// t.Failed() returns false during panicking. We need to forcibly
 	// save data on panicking.
@@ -234,44 +834,58 @@ func testRobustness(ctx context.Context, t *testing.T, lg *zap.Logger, config e2
 	defer func() {
 		r.Report(t, panicked)
 	}()
-	r.operations, r.responses = runScenario(ctx, t, lg, r.clus, *traffic, failpoint)
+	var cancellations [][]*watchCancellation
+	r.baseTime = time.Now()
+	r.operations, r.responses, cancellations = runScenario(ctx, t, lg, r.clus, *traffic, failpoint, r.baseTime, manifest)
+	finalState, err := readFinalState(ctx, r.clus, traffic.credentials())
+	if err != nil {
+		lg.Warn("Failed to read final state, operations with unknown result will not be resolved", zap.Error(err))
+	}
 	forcestopCluster(r.clus)
 
 	watchProgressNotifyEnabled := r.clus.Cfg.WatchProcessNotifyInterval != 0
 	validateWatchResponses(t, r.clus, r.responses, traffic.requestProgress || watchProgressNotifyEnabled)
+	validateWatchCancellations(t, r.clus, cancellations)
+	traffic.traffic.Validate(t, lg, r.operations)
 
 	r.events = watchEvents(r.responses)
 	validateEventsMatch(t, r.events)
 
 	r.patchedOperations = patchOperationBasedOnWatchEvents(r.operations, longestHistory(r.events))
-	r.visualizeHistory = model.ValidateOperationHistoryAndReturnVisualize(t, lg, r.patchedOperations)
+	r.patchedOperations, r.resolutions = model.ResolveUnknownOperations(r.patchedOperations, finalState)
+	lg.Info("Resolved operations with unknown result based on final state", zap.Int("count", len(r.resolutions)))
+	r.visualizeHistory = model.ValidateOperationHistoryAndReturnVisualize(t, lg, r.patchedOperations, traffic.checkWindow)
 
 	panicked = false
 }
 
-func runScenario(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, traffic trafficConfig, failpoint FailpointConfig) (operations []porcupine.Operation, responses [][]watchResponse) {
+func runScenario(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, traffic trafficConfig, failpoint FailpointConfig, baseTime time.Time, manifest *runManifest) (operations []porcupine.Operation, responses [][]watchResponse, cancellations [][]*watchCancellation) {
 	g := errgroup.Group{}
 	finishTraffic := make(chan struct{})
 
 	g.Go(func() error {
 		defer close(finishTraffic)
-		injectFailpoints(ctx, t, lg, clus, failpoint)
+		injectFailpoints(ctx, t, lg, clus, failpoint, manifest)
 		time.Sleep(time.Second)
 		return nil
 	})
 	maxRevisionChan := make(chan int64, 1)
 	g.Go(func() error {
 		defer close(maxRevisionChan)
-		operations = simulateTraffic(ctx, t, lg, clus, traffic, finishTraffic)
+		operations = simulateTraffic(ctx, t, lg, clus, traffic, baseTime, finishTraffic)
 		maxRevisionChan <- operationsMaxRevision(operations)
 		return nil
 	})
 	g.Go(func() error {
-		responses = collectClusterWatchEvents(ctx, t, clus, maxRevisionChan, traffic.requestProgress)
+		responses = collectClusterWatchEvents(ctx, t, clus, maxRevisionChan, traffic.requestProgress, traffic.credentials())
+		return nil
+	})
+	g.Go(func() error {
+		cancellations = collectClusterWatchCancellations(ctx, t, clus, finishTraffic, traffic.credentials())
 		return nil
 	})
 	g.Wait()
-	return operations, responses
+	return operations, responses, cancellations
 }
 
 func operationsMaxRevision(operations []porcupine.Operation) int64 {
@@ -8,6 +8,12 @@ test-robustness-release-3.5: /tmp/etcd-release-3.5-failpoints/bin
 test-robustness-release-3.4: /tmp/etcd-release-3.4-failpoints/bin
 	GO_TEST_FLAGS="$${GO_TEST_FLAGS} --bin-dir=/tmp/etcd-release-3.4-failpoints/bin" make test-robustness
 
+# Run additional traffics, not run by default
+
+.PHONY: test-robustness-extra-traffic
+test-robustness-extra-traffic:
+	ROBUSTNESS_EXTRA_TRAFFIC=all make test-robustness
+
 # Reproduce historical issues
 
 .PHONY: test-robustness-issue14370
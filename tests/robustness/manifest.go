@@ -0,0 +1,127 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+// seedEnv overrides seed of the run, allowing to repeat random choices of a run recorded in its manifest.
+const seedEnv = "ROBUSTNESS_SEED"
+
+// runManifest records everything needed to reproduce a robustness test run, together with its operation history.
+// It's persisted in the results directory before the cluster is started and updated after each failpoint injection,
+// so it's available even if the run panics or is killed mid-way.
+type runManifest struct {
+	Seed        int64
+	EtcdVersion string
+	Traffic     string
+	Cluster     e2e.EtcdProcessClusterConfig
+	Failpoint   failpointManifest
+	// FaultSchedule lists failpoints in order they were triggered.
+	FaultSchedule []injectedFailpoint
+
+	mux  sync.Mutex
+	lg   *zap.Logger
+	path string
+}
+
+type failpointManifest struct {
+	Name                string
+	Count               int
+	Retries             int
+	WaitBetweenTriggers time.Duration
+}
+
+type injectedFailpoint struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	// Error is set if failpoint failed to trigger.
+	Error string `json:",omitempty"`
+}
+
+// runSeed returns seed set by seedEnv, or a new one if it's not set.
+func runSeed(t *testing.T) int64 {
+	value, ok := os.LookupEnv(seedEnv)
+	if !ok {
+		return time.Now().UnixNano()
+	}
+	seed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		t.Fatalf("Failed to parse %s=%q: %v", seedEnv, value, err)
+	}
+	return seed
+}
+
+func newRunManifest(lg *zap.Logger, path string, seed int64, etcdVersion string, config e2e.EtcdProcessClusterConfig, traffic trafficConfig, failpoint FailpointConfig) *runManifest {
+	config.Logger = nil
+	return &runManifest{
+		Seed:        seed,
+		EtcdVersion: etcdVersion,
+		Traffic:     fmt.Sprintf("%+v", traffic),
+		Cluster:     config,
+		Failpoint: failpointManifest{
+			Name:                failpoint.failpoint.Name(),
+			Count:               failpoint.count,
+			Retries:             failpoint.retries,
+			WaitBetweenTriggers: failpoint.waitBetweenTriggers,
+		},
+		lg:   lg,
+		path: path,
+	}
+}
+
+// Injected records failpoint triggered between start and end and persists the manifest.
+func (m *runManifest) Injected(name string, start, end time.Time, err error) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	injected := injectedFailpoint{Name: name, Start: start, End: end}
+	if err != nil {
+		injected.Error = err.Error()
+	}
+	m.FaultSchedule = append(m.FaultSchedule, injected)
+	return m.persist()
+}
+
+// Persist writes the manifest to its path, replacing the previous one.
+func (m *runManifest) Persist() error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.persist()
+}
+
+func (m *runManifest) persist() error {
+	m.lg.Info("Saving run manifest", zap.String("path", m.path))
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := m.path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, m.path)
+}
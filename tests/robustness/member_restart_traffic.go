@@ -0,0 +1,187 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// memberRestartTraffic validates that member keeps all committed data when it's restarted, for example by
+// RestartFailpoint, which records the restart in fault schedule of run manifest. Each client is pinned to a single
+// member and puts new keys, each of them only once, reading them back through the member with serializable reads,
+// which are served from the member's local state. Usually a random committed key is read, but after any failure, as
+// member might have restarted, all keys committed by the client are read at once. Restarted member can still be
+// replaying its WAL, so keys put at revision higher than the read revision are counted as catching up instead
+// of being validated, see model.DurableKeyRead. Puts are recorded in history, so they are also validated by the model.
+type memberRestartTraffic struct {
+	prefix string
+	report *memberRestartReport
+}
+
+func newMemberRestartTraffic(prefix string) memberRestartTraffic {
+	return memberRestartTraffic{
+		prefix: prefix,
+	}
+}
+
+func (t memberRestartTraffic) ForRun() Traffic {
+	t.report = &memberRestartReport{members: map[uint64]int{}}
+	return t
+}
+
+func (t memberRestartTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateMemberRestart(tt, lg, t)
+}
+
+func (t memberRestartTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	var memberID uint64
+	for memberID == 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		memberID = pinToMember(ctx, clientId, c)
+		limiter.Wait(ctx)
+	}
+
+	var puts []model.DurableKeyRead
+	failed := false
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		put := model.DurableKeyRead{Key: fmt.Sprintf("%s%d/%d", t.prefix, clientId, i), Value: fmt.Sprintf("%d", ids.RequestId())}
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.PutWithRevision(putCtx, put.Key, put.Value)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			failed = true
+		} else {
+			put.PutRevision = revision
+			puts = append(puts, put)
+		}
+		if len(puts) == 0 {
+			continue
+		}
+		if failed {
+			err = t.readAll(ctx, clientId, c, memberID, puts)
+		} else {
+			err = t.readOne(ctx, clientId, c, memberID, puts[rand.Intn(len(puts))])
+		}
+		limiter.Adapt(ctx, err)
+		failed = err != nil
+		limiter.Wait(ctx)
+	}
+}
+
+// readOne reads a single committed key through the member client is pinned to.
+func (t memberRestartTraffic) readOne(ctx context.Context, clientId int, c *recordingClient, memberID uint64, read model.DurableKeyRead) error {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.SerializableGet(getCtx, read.Key)
+	cancel()
+	if err != nil {
+		return err
+	}
+	read.ReadRevision = resp.Header.Revision
+	if len(resp.Kvs) != 0 {
+		read.ReadKv = resp.Kvs[0]
+	}
+	t.report.Read(clientId, memberID, read, false)
+	return nil
+}
+
+// readAll reads all keys of the client at once through the member client is pinned to, validating all committed puts.
+func (t memberRestartTraffic) readAll(ctx context.Context, clientId int, c *recordingClient, memberID uint64, puts []model.DurableKeyRead) error {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.SerializablePrefixRange(getCtx, fmt.Sprintf("%s%d/", t.prefix, clientId))
+	cancel()
+	if err != nil {
+		return err
+	}
+	kvs := make(map[string]*mvccpb.KeyValue, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs[string(kv.Key)] = kv
+	}
+	for _, read := range puts {
+		read.ReadRevision = resp.Header.Revision
+		read.ReadKv = kvs[read.Key]
+		t.report.Read(clientId, memberID, read, true)
+	}
+	return nil
+}
+
+// memberRestartReport collects results of memberRestartTraffic validation from all clients.
+type memberRestartReport struct {
+	mux sync.Mutex
+	// members counts reads served by each member.
+	members map[uint64]int
+	reads   int
+	// readsAfterFailure counts reads of all keys done after failure, when member might have restarted.
+	readsAfterFailure int
+	// catchingUp counts reads served by member that didn't apply the put yet.
+	catchingUp int
+	violations []string
+}
+
+func (r *memberRestartReport) Read(clientId int, memberID uint64, read model.DurableKeyRead, afterFailure bool) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	r.members[memberID]++
+	if afterFailure {
+		r.readsAfterFailure++
+	}
+	if read.CatchingUp() {
+		r.catchingUp++
+	}
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, member: %x, %s", clientId, memberID, err))
+	}
+}
+
+func validateMemberRestart(t *testing.T, lg *zap.Logger, traffic memberRestartTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Member restart traffic", zap.Int("reads", r.reads), zap.Int("reads-after-failure", r.readsAfterFailure), zap.Int("catching-up", r.catchingUp), zap.Int("members", len(r.members)))
+	for _, violation := range r.violations {
+		t.Errorf("Broke durability guarantee: Member keeps all committed data after restart, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.reads == 0 {
+		t.Errorf("No committed key was read back")
+	}
+}
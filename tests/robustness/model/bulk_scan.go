@@ -0,0 +1,146 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateBulkScans checks that each successful range of prefix observed exactly the keys loaded under prefix up
+// to its revision, as if the whole range was read at that single revision. Keys under prefix are expected to be
+// only put, never deleted. Each returned key must have value of the latest put with known revision not newer than
+// range revision, or of a failed put that might have been persisted after it. Each key put up to range revision must
+// be returned, and keys must be returned in order without duplicates, as expected from pages of the same revision.
+// Returns description of each violation.
+func ValidateBulkScans(operations []porcupine.Operation, prefix string) []string {
+	known := map[string][]ValueRevision{}
+	unknown := map[string]map[ValueOrHash]bool{}
+	var scans []porcupine.Operation
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn {
+			continue
+		}
+		if isPrefixScan(request.Txn, prefix) {
+			if resp.Err == nil && !resp.ResultUnknown && resp.Txn != nil {
+				scans = append(scans, op)
+			}
+			continue
+		}
+		if resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			for _, ops := range [][]EtcdOperation{request.Txn.Ops, request.Txn.OpsOnFailure} {
+				for _, put := range prefixPuts(ops, prefix) {
+					if unknown[put.Key] == nil {
+						unknown[put.Key] = map[ValueOrHash]bool{}
+					}
+					unknown[put.Key][put.Value] = true
+				}
+			}
+			continue
+		}
+		ops := request.Txn.Ops
+		if resp.Txn.TxnResult {
+			ops = request.Txn.OpsOnFailure
+		}
+		for _, put := range prefixPuts(ops, prefix) {
+			known[put.Key] = append(known[put.Key], ValueRevision{Value: put.Value, ModRevision: resp.Revision})
+		}
+	}
+	for key := range known {
+		puts := known[key]
+		sort.Slice(puts, func(i, j int) bool {
+			return puts[i].ModRevision < puts[j].ModRevision
+		})
+	}
+
+	violations := []string{}
+	for _, scan := range scans {
+		resp := scan.Output.(EtcdNonDeterministicResponse)
+		revision := resp.Revision
+		describe := func(format string, args ...any) {
+			violations = append(violations, fmt.Sprintf("range of prefix %q at revision %d by client: %d %s", prefix, revision, scan.ClientId, fmt.Sprintf(format, args...)))
+		}
+		returned := map[string]bool{}
+		var lastKey string
+		for _, kv := range resp.Txn.OpsResult[0].KVs {
+			if returned[kv.Key] || kv.Key < lastKey {
+				describe("returned key %q out of order after key %q", kv.Key, lastKey)
+			}
+			returned[kv.Key] = true
+			lastKey = kv.Key
+			if kv.ModRevision > revision {
+				describe("returned key %q modified at later revision %d", kv.Key, kv.ModRevision)
+				continue
+			}
+			latest, ok := latestPut(known[kv.Key], revision)
+			if ok && kv.ValueRevision == latest {
+				continue
+			}
+			if unknown[kv.Key][kv.Value] && (!ok || kv.ModRevision > latest.ModRevision) {
+				continue
+			}
+			if !ok {
+				describe("returned key %q at revision %d, which was not put until range revision", kv.Key, kv.ModRevision)
+				continue
+			}
+			describe("returned key %q at revision %d, while it was put at revision %d", kv.Key, kv.ModRevision, latest.ModRevision)
+		}
+		var missed []string
+		for key, puts := range known {
+			if _, ok := latestPut(puts, revision); ok && !returned[key] {
+				missed = append(missed, key)
+			}
+		}
+		sort.Strings(missed)
+		for _, key := range missed {
+			latest, _ := latestPut(known[key], revision)
+			describe("missed key %q put at revision %d", key, latest.ModRevision)
+		}
+	}
+	return violations
+}
+
+// isPrefixScan returns whether transaction is a single range of all keys with prefix.
+func isPrefixScan(txn *TxnRequest, prefix string) bool {
+	return len(txn.Conds) == 0 && len(txn.Ops) == 1 && len(txn.OpsOnFailure) == 0 &&
+		txn.Ops[0].Type == Range && txn.Ops[0].Key == prefix && txn.Ops[0].WithPrefix
+}
+
+// prefixPuts returns put operations of keys with prefix.
+func prefixPuts(ops []EtcdOperation, prefix string) []EtcdOperation {
+	puts := []EtcdOperation{}
+	for _, op := range ops {
+		if op.Type == Put && strings.HasPrefix(op.Key, prefix) {
+			puts = append(puts, op)
+		}
+	}
+	return puts
+}
+
+// latestPut returns the latest of puts ordered by revision, that is not newer than revision.
+func latestPut(puts []ValueRevision, revision int64) (ValueRevision, bool) {
+	i := sort.Search(len(puts), func(i int) bool {
+		return puts[i].ModRevision > revision
+	})
+	if i == 0 {
+		return ValueRevision{}, false
+	}
+	return puts[i-1], true
+}
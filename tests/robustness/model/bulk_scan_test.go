@@ -0,0 +1,90 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestValidateBulkScans(t *testing.T) {
+	loads := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("/etl/a", "1"), Output: putResponse(2)},
+		{ClientId: 1, Input: putRequest("/etl/b", "2"), Output: putResponse(3)},
+		{ClientId: 2, Input: putRequest("/etl/c", "3"), Output: failedResponse(errors.New("failed"))},
+		{ClientId: 1, Input: putRequest("/etl/a", "4"), Output: putResponse(5)},
+		{ClientId: 1, Input: putRequest("/other", "5"), Output: putResponse(6)},
+	}
+	kv := func(key, value string, modRevision int64) *mvccpb.KeyValue {
+		return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: modRevision}
+	}
+	tcs := []struct {
+		name             string
+		kvs              []*mvccpb.KeyValue
+		revision         int64
+		expectViolations int
+	}{
+		{
+			name:     "Scan observes loaded keys at its revision",
+			kvs:      []*mvccpb.KeyValue{kv("/etl/a", "1", 2), kv("/etl/b", "2", 3)},
+			revision: 3,
+		},
+		{
+			name:     "Scan observes latest put of key",
+			kvs:      []*mvccpb.KeyValue{kv("/etl/a", "4", 5), kv("/etl/b", "2", 3)},
+			revision: 6,
+		},
+		{
+			name:     "Failed put might have been persisted",
+			kvs:      []*mvccpb.KeyValue{kv("/etl/a", "1", 2), kv("/etl/b", "2", 3), kv("/etl/c", "3", 4)},
+			revision: 4,
+		},
+		{
+			name:             "Loaded key missed",
+			kvs:              []*mvccpb.KeyValue{kv("/etl/a", "1", 2)},
+			revision:         3,
+			expectViolations: 1,
+		},
+		{
+			name:             "Key put after scan revision returned",
+			kvs:              []*mvccpb.KeyValue{kv("/etl/a", "4", 5), kv("/etl/b", "2", 3)},
+			revision:         3,
+			expectViolations: 1,
+		},
+		{
+			name:             "Stale value of key returned",
+			kvs:              []*mvccpb.KeyValue{kv("/etl/a", "1", 2), kv("/etl/b", "2", 3)},
+			revision:         5,
+			expectViolations: 1,
+		},
+		{
+			name:             "Key duplicated by pagination",
+			kvs:              []*mvccpb.KeyValue{kv("/etl/a", "1", 2), kv("/etl/b", "2", 3), kv("/etl/b", "2", 3)},
+			revision:         3,
+			expectViolations: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			scan := porcupine.Operation{ClientId: 3, Input: rangeSnapshotRequest("/etl/", "", true), Output: rangeResponse(tc.kvs, int64(len(tc.kvs)), tc.revision)}
+			assert.Len(t, ValidateBulkScans(append(loads, scan), "/etl/"), tc.expectViolations)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// CommittedRead describes serializable read of a key. It's not recorded in history, as it can observe stale state.
+type CommittedRead struct {
+	ClientId int
+	Key      string
+	// Value is the value returned by read, empty if key was not found.
+	Value       ValueOrHash
+	ModRevision int64
+	// Revision is the header revision of the read response.
+	Revision int64
+	// Return is when the read returned, relative to the same base time as operations in history.
+	Return int64
+}
+
+// committedWrite is operation that might have committed value, with revision it committed at, zero if unknown.
+type committedWrite struct {
+	op       porcupine.Operation
+	revision int64
+}
+
+// ValidateCommittedReads checks that serializable reads return only values that were committed, possibly stale ones.
+// It's stricter version of ValidateNoSpuriousValues for reads not recorded in history, as it doesn't require reads to
+// be linearizable.
+// Value is committed by successful transaction only if it was written by the branch that was executed, while
+// transaction with unknown result might have committed value written by any of its branches. Returns description of
+// each read of value that was not committed, was committed only after the read returned, or was committed at
+// revision different than mod revision returned by read.
+func ValidateCommittedReads(operations []porcupine.Operation, reads []CommittedRead) []string {
+	committed := map[KeyValue]committedWrite{}
+	written := map[KeyValue]porcupine.Operation{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn {
+			continue
+		}
+		for put := range txnPuts(request.Txn) {
+			written[put] = op
+		}
+		if resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			for put := range txnPuts(request.Txn) {
+				committed[put] = committedWrite{op: op}
+			}
+			continue
+		}
+		for put := range committedTxnPuts(request.Txn, resp.Txn) {
+			committed[put] = committedWrite{op: op, revision: resp.Revision}
+		}
+	}
+	violations := []string{}
+	for _, read := range reads {
+		if read.Value == (ValueOrHash{}) {
+			continue
+		}
+		kv := KeyValue{Key: read.Key, ValueRevision: ValueRevision{Value: read.Value}}
+		write, found := committed[kv]
+		if !found {
+			if op, found := written[kv]; found {
+				violations = append(violations, fmt.Sprintf("value %s of key %q read at revision %d with mod revision %d was written only by branch not executed, read: client: %d, write: client: %d, %s",
+					describeValueOrHash(read.Value), read.Key, read.Revision, read.ModRevision, read.ClientId,
+					op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output)))
+			} else {
+				violations = append(violations, fmt.Sprintf("value %s of key %q read at revision %d with mod revision %d was never written, read: client: %d",
+					describeValueOrHash(read.Value), read.Key, read.Revision, read.ModRevision, read.ClientId))
+			}
+			continue
+		}
+		switch {
+		case write.op.Call > read.Return:
+			violations = append(violations, fmt.Sprintf("value %s of key %q read at revision %d with mod revision %d was written only after it was read, read: client: %d, write: client: %d, %s",
+				describeValueOrHash(read.Value), read.Key, read.Revision, read.ModRevision, read.ClientId,
+				write.op.ClientId, NonDeterministicModel.DescribeOperation(write.op.Input, write.op.Output)))
+		case write.revision != 0 && write.revision != read.ModRevision:
+			violations = append(violations, fmt.Sprintf("value %s of key %q read at revision %d with mod revision %d was committed at revision %d, read: client: %d, write: client: %d, %s",
+				describeValueOrHash(read.Value), read.Key, read.Revision, read.ModRevision, write.revision, read.ClientId,
+				write.op.ClientId, NonDeterministicModel.DescribeOperation(write.op.Input, write.op.Output)))
+		}
+	}
+	return violations
+}
+
+// committedTxnPuts returns keys and values put by branches executed by successful transaction, including nested
+// transactions. Mod revision of returned values is not set, like in txnPuts.
+func committedTxnPuts(txn *TxnRequest, resp *TxnResponse) map[KeyValue]struct{} {
+	ops := txn.Ops
+	if resp.TxnResult {
+		ops = txn.OpsOnFailure
+	}
+	puts := map[KeyValue]struct{}{}
+	for i, op := range ops {
+		switch op.Type {
+		case Put:
+			puts[KeyValue{Key: op.Key, ValueRevision: ValueRevision{Value: op.Value}}] = struct{}{}
+		case NestedTxn:
+			nested := txnPuts(op.Txn)
+			if i < len(resp.OpsResult) && resp.OpsResult[i].Txn != nil {
+				nested = committedTxnPuts(op.Txn, resp.OpsResult[i].Txn)
+			}
+			for kv := range nested {
+				puts[kv] = struct{}{}
+			}
+		}
+	}
+	return puts
+}
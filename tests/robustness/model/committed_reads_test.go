@@ -0,0 +1,83 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommittedReads(t *testing.T) {
+	putIfRevision := func(key string, revision int64, value string) EtcdRequest {
+		return conditionalTxnRequest([]EtcdCondition{{Key: key, ExpectedRevision: revision}},
+			[]EtcdOperation{{Type: Put, Key: key, Value: ToValueOrHash(value)}}, []EtcdOperation{{Type: Range, Key: key}})
+	}
+	operations := []porcupine.Operation{
+		{ClientId: 1, Input: putIfRevision("key", 0, "1"), Output: compareRevisionAndPutResponse(true, 2), Call: 0, Return: 1},
+		{ClientId: 2, Input: putIfRevision("key", 0, "2"), Output: txnResponse([]EtcdOperationResult{{}}, false, 2), Call: 2, Return: 3},
+		{ClientId: 1, Input: putIfRevision("key", 2, "3"), Output: compareRevisionAndPutResponse(true, 3), Call: 4, Return: 5},
+		{ClientId: 2, Input: putIfRevision("key", 3, "4"), Output: failedResponse(errors.New("timeout")), Call: 6},
+	}
+	tcs := []struct {
+		name             string
+		read             CommittedRead
+		expectViolations int
+	}{
+		{
+			name: "Read latest committed value",
+			read: CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("3"), ModRevision: 3, Revision: 3, Return: 6},
+		},
+		{
+			name: "Read stale committed value",
+			read: CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("1"), ModRevision: 2, Revision: 2, Return: 6},
+		},
+		{
+			name: "Read key not found",
+			read: CommittedRead{ClientId: 3, Key: "key", Revision: 1, Return: 6},
+		},
+		{
+			name: "Read value of write with unknown result",
+			read: CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("4"), ModRevision: 4, Revision: 4, Return: 7},
+		},
+		{
+			name:             "Read value of branch not executed",
+			read:             CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("2"), ModRevision: 3, Revision: 3, Return: 6},
+			expectViolations: 1,
+		},
+		{
+			name:             "Read value never written",
+			read:             CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("5"), ModRevision: 3, Revision: 3, Return: 6},
+			expectViolations: 1,
+		},
+		{
+			name:             "Read value before it was written",
+			read:             CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("3"), ModRevision: 3, Revision: 3, Return: 3},
+			expectViolations: 1,
+		},
+		{
+			name:             "Read value with mod revision different than committed",
+			read:             CommittedRead{ClientId: 3, Key: "key", Value: ToValueOrHash("1"), ModRevision: 3, Revision: 3, Return: 6},
+			expectViolations: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, ValidateCommittedReads(operations, []CommittedRead{tc.read}), tc.expectViolations)
+		})
+	}
+}
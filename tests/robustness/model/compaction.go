@@ -0,0 +1,115 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+// WatchMayBeCompacted returns whether watch that needs events starting from watchRevision can be
+// canceled with ErrCompacted by compaction of compactRevision. Compaction keeps the compacted revision itself,
+// so only watches starting below it are allowed to be compacted. Watches that already observed all events
+// before compactRevision, like ones established before the compaction, have to keep receiving events.
+func WatchMayBeCompacted(watchRevision, compactRevision int64) bool {
+	return watchRevision < compactRevision
+}
+
+// CompactedKeyRead describes read of the latest value of a key after compaction of CompactRevision. Compaction
+// removes only versions overwritten before the compacted revision, so the key must be read with value of its
+// last put, whether it was put before or after compaction. Key deleted by its last write must not be found.
+type CompactedKeyRead struct {
+	Key string
+	// Value and PutRevision are of the last put of the key.
+	Value       string
+	PutRevision int64
+	// Deleted is set if key was deleted after its last put.
+	Deleted         bool
+	CompactRevision int64
+	// ReadKv is the key returned by read at ReadRevision, nil if key was not found.
+	ReadKv       *mvccpb.KeyValue
+	ReadRevision int64
+}
+
+// Validate returns error describing how read didn't observe the last write of the key, nil if it did.
+func (r CompactedKeyRead) Validate() error {
+	if r.Deleted {
+		if r.ReadKv != nil {
+			return fmt.Errorf("key %q deleted before compaction at revision %d found by read at revision %d with mod revision %d", r.Key, r.CompactRevision, r.ReadRevision, r.ReadKv.ModRevision)
+		}
+		return nil
+	}
+	if r.ReadKv == nil {
+		return fmt.Errorf("key %q put at revision %d not found by read at revision %d after compaction at revision %d", r.Key, r.PutRevision, r.ReadRevision, r.CompactRevision)
+	}
+	if r.ReadKv.ModRevision != r.PutRevision {
+		return fmt.Errorf("key %q put at revision %d read with mod revision %d at revision %d after compaction at revision %d", r.Key, r.PutRevision, r.ReadKv.ModRevision, r.ReadRevision, r.CompactRevision)
+	}
+	if string(r.ReadKv.Value) != r.Value {
+		return fmt.Errorf("key %q put at revision %d with value %q read with value %q after compaction at revision %d", r.Key, r.PutRevision, r.Value, string(r.ReadKv.Value), r.CompactRevision)
+	}
+	return nil
+}
+
+// PutAfterCompaction returns whether the last put of the key happened after compaction.
+func (r CompactedKeyRead) PutAfterCompaction() bool {
+	return r.PutRevision > r.CompactRevision
+}
+
+// CompactedRevisionRead describes race of watch observing put of Key at PutRevision, compaction of CompactRevision
+// past it, and read of the key at PutRevision by other client after compaction. Watch has to keep the event it was
+// delivered before compaction and keep delivering events after it, while read of compacted revision has to fail.
+type CompactedRevisionRead struct {
+	Key string
+	// Value and PutRevision are of the put observed by watch.
+	Value       string
+	PutRevision int64
+	// WatchedKv is the event of the put delivered to watch, WatchedRevision the last revision watch observed before compaction.
+	WatchedKv       *mvccpb.KeyValue
+	WatchedRevision int64
+	// CompactRevision is higher than PutRevision.
+	CompactRevision int64
+	// WatchCompacted is set if watch was canceled as compacted after compaction.
+	WatchCompacted bool
+	// ReadKv and ReadErr are result of read of the key at PutRevision, which has to fail with ErrCompacted.
+	ReadKv  *mvccpb.KeyValue
+	ReadErr error
+}
+
+// Validate returns error describing how watch lost its event or read observed compacted revision, nil if none did.
+func (r CompactedRevisionRead) Validate() error {
+	if r.WatchedKv == nil {
+		return fmt.Errorf("key %q put at revision %d not delivered to watch before compaction at revision %d", r.Key, r.PutRevision, r.CompactRevision)
+	}
+	if r.WatchedKv.ModRevision != r.PutRevision || string(r.WatchedKv.Value) != r.Value {
+		return fmt.Errorf("key %q put at revision %d with value %q delivered to watch at revision %d with value %q", r.Key, r.PutRevision, r.Value, r.WatchedKv.ModRevision, string(r.WatchedKv.Value))
+	}
+	if r.WatchCompacted && !WatchMayBeCompacted(r.WatchedRevision+1, r.CompactRevision) {
+		return fmt.Errorf("watch that observed revision %d was compacted by compaction at revision %d", r.WatchedRevision, r.CompactRevision)
+	}
+	if r.ReadErr == nil {
+		if r.ReadKv == nil {
+			return fmt.Errorf("read of key %q at revision %d succeeded after compaction at revision %d without finding it", r.Key, r.PutRevision, r.CompactRevision)
+		}
+		return fmt.Errorf("read of key %q at revision %d succeeded after compaction at revision %d, returning mod revision %d", r.Key, r.PutRevision, r.CompactRevision, r.ReadKv.ModRevision)
+	}
+	if !errors.Is(r.ReadErr, rpctypes.ErrCompacted) {
+		return fmt.Errorf("read of key %q at revision %d after compaction at revision %d failed with %q, expected %q", r.Key, r.PutRevision, r.CompactRevision, r.ReadErr, rpctypes.ErrCompacted)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+)
+
+func TestWatchMayBeCompacted(t *testing.T) {
+	tcs := []struct {
+		name            string
+		watchRevision   int64
+		compactRevision int64
+		expect          bool
+	}{
+		{name: "Watch starting below compaction", watchRevision: 5, compactRevision: 10, expect: true},
+		{name: "Watch starting at compacted revision", watchRevision: 10, compactRevision: 10, expect: false},
+		{name: "Watch starting above compaction", watchRevision: 11, compactRevision: 10, expect: false},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := WatchMayBeCompacted(tc.watchRevision, tc.compactRevision); got != tc.expect {
+				t.Errorf("WatchMayBeCompacted(%d, %d) = %v, expect %v", tc.watchRevision, tc.compactRevision, got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestCompactedKeyRead(t *testing.T) {
+	tcs := []struct {
+		name                     string
+		read                     CompactedKeyRead
+		expectPutAfterCompaction bool
+		expectError              string
+	}{
+		{
+			name: "Key put before compaction read with its last value",
+			read: CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, CompactRevision: 5, ReadRevision: 6,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 3}},
+		},
+		{
+			name: "Key put after compaction read with its last value",
+			read: CompactedKeyRead{Key: "key", Value: "3", PutRevision: 6, CompactRevision: 5, ReadRevision: 6,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("3"), ModRevision: 6}},
+			expectPutAfterCompaction: true,
+		},
+		{
+			name: "Key deleted before compaction not found",
+			read: CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, Deleted: true, CompactRevision: 5, ReadRevision: 6},
+		},
+		{
+			name: "Key deleted before compaction found",
+			read: CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, Deleted: true, CompactRevision: 5, ReadRevision: 6,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 3}},
+			expectError: `key "key" deleted before compaction at revision 5 found by read at revision 6 with mod revision 3`,
+		},
+		{
+			name:        "Key put before compaction not found",
+			read:        CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, CompactRevision: 5, ReadRevision: 6},
+			expectError: `key "key" put at revision 3 not found by read at revision 6 after compaction at revision 5`,
+		},
+		{
+			name: "Key read with compacted version",
+			read: CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, CompactRevision: 5, ReadRevision: 6,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}},
+			expectError: `key "key" put at revision 3 read with mod revision 2 at revision 6 after compaction at revision 5`,
+		},
+		{
+			name: "Key read with different value",
+			read: CompactedKeyRead{Key: "key", Value: "2", PutRevision: 3, CompactRevision: 5, ReadRevision: 6,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 3}},
+			expectError: `key "key" put at revision 3 with value "2" read with value "1" after compaction at revision 5`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.read.Validate()
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectPutAfterCompaction, tc.read.PutAfterCompaction())
+		})
+	}
+}
+
+func TestCompactedRevisionRead(t *testing.T) {
+	watched := &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 3}
+	tcs := []struct {
+		name        string
+		read        CompactedRevisionRead
+		expectError string
+	}{
+		{
+			name: "Watch kept event and read of compacted revision failed",
+			read: CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 5, CompactRevision: 5, ReadErr: rpctypes.ErrCompacted},
+		},
+		{
+			name: "Watch needing compacted revision compacted",
+			read: CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 3, CompactRevision: 5, WatchCompacted: true, ReadErr: rpctypes.ErrCompacted},
+		},
+		{
+			name:        "Watch lost event",
+			read:        CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedRevision: 5, CompactRevision: 5, ReadErr: rpctypes.ErrCompacted},
+			expectError: `key "key" put at revision 3 not delivered to watch before compaction at revision 5`,
+		},
+		{
+			name: "Watch delivered different event",
+			read: CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedRevision: 5, CompactRevision: 5, ReadErr: rpctypes.ErrCompacted,
+				WatchedKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 4}},
+			expectError: `key "key" put at revision 3 with value "1" delivered to watch at revision 4 with value "2"`,
+		},
+		{
+			name:        "Watch that observed compacted revisions compacted",
+			read:        CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 5, CompactRevision: 5, WatchCompacted: true, ReadErr: rpctypes.ErrCompacted},
+			expectError: `watch that observed revision 5 was compacted by compaction at revision 5`,
+		},
+		{
+			name: "Read of compacted revision succeeded",
+			read: CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 5, CompactRevision: 5,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 3}},
+			expectError: `read of key "key" at revision 3 succeeded after compaction at revision 5, returning mod revision 3`,
+		},
+		{
+			name:        "Read of compacted revision found nothing",
+			read:        CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 5, CompactRevision: 5},
+			expectError: `read of key "key" at revision 3 succeeded after compaction at revision 5 without finding it`,
+		},
+		{
+			name:        "Read of compacted revision failed with other error",
+			read:        CompactedRevisionRead{Key: "key", Value: "1", PutRevision: 3, WatchedKv: watched, WatchedRevision: 5, CompactRevision: 5, ReadErr: errors.New("failed")},
+			expectError: `read of key "key" at revision 3 after compaction at revision 5 failed with "failed", expected "etcdserver: mvcc: required revision has been compacted"`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.read.Validate()
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ConsistentIndexObservation records consistent index returned by member in trailer of response.
+// Start and End are times request was sent and response received, relative to the client base time.
+type ConsistentIndexObservation struct {
+	MemberId uint64
+	Index    uint64
+	Start    time.Duration
+	End      time.Duration
+}
+
+// ValidateConsistentIndexes checks that consistent index of each member never goes backwards. Member sets trailer
+// after handling request, so response received before other request to the same member was sent has to carry
+// consistent index not greater than that request. It holds also across member restarts, as member starts serving
+// only after applying entries it applied before. Concurrent requests are not compared.
+func ValidateConsistentIndexes(observations []ConsistentIndexObservation) []string {
+	members := map[uint64][]ConsistentIndexObservation{}
+	for _, o := range observations {
+		members[o.MemberId] = append(members[o.MemberId], o)
+	}
+	var memberIds []uint64
+	for id := range members {
+		memberIds = append(memberIds, id)
+	}
+	sort.Slice(memberIds, func(i, j int) bool { return memberIds[i] < memberIds[j] })
+
+	violations := []string{}
+	for _, id := range memberIds {
+		byEnd := members[id]
+		byStart := make([]ConsistentIndexObservation, len(byEnd))
+		copy(byStart, byEnd)
+		sort.Slice(byEnd, func(i, j int) bool { return byEnd[i].End < byEnd[j].End })
+		sort.Slice(byStart, func(i, j int) bool { return byStart[i].Start < byStart[j].Start })
+		// highest is observation with the highest index among responses received before currently checked request was sent.
+		var highest *ConsistentIndexObservation
+		next := 0
+		for _, o := range byStart {
+			for ; next < len(byEnd) && byEnd[next].End < o.Start; next++ {
+				if highest == nil || byEnd[next].Index > highest.Index {
+					highest = &byEnd[next]
+				}
+			}
+			if highest != nil && o.Index < highest.Index {
+				violations = append(violations, fmt.Sprintf("member %x returned consistent index %d to request sent at %s, after returning %d to request finished at %s",
+					id, o.Index, o.Start, highest.Index, highest.End))
+			}
+		}
+	}
+	return violations
+}
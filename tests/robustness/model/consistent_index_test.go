@@ -0,0 +1,76 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConsistentIndexes(t *testing.T) {
+	tcs := []struct {
+		name             string
+		observations     []ConsistentIndexObservation
+		expectViolations int
+	}{
+		{
+			name: "Growing index",
+			observations: []ConsistentIndexObservation{
+				{MemberId: 1, Index: 10, Start: 1, End: 2},
+				{MemberId: 1, Index: 10, Start: 3, End: 4},
+				{MemberId: 1, Index: 12, Start: 5, End: 6},
+			},
+		},
+		{
+			name: "Index going backwards",
+			observations: []ConsistentIndexObservation{
+				{MemberId: 1, Index: 12, Start: 1, End: 2},
+				{MemberId: 1, Index: 10, Start: 3, End: 4},
+			},
+			expectViolations: 1,
+		},
+		{
+			name: "Concurrent requests are not compared",
+			observations: []ConsistentIndexObservation{
+				{MemberId: 1, Index: 12, Start: 1, End: 4},
+				{MemberId: 1, Index: 10, Start: 2, End: 3},
+			},
+		},
+		{
+			name: "Members are validated separately",
+			observations: []ConsistentIndexObservation{
+				{MemberId: 1, Index: 12, Start: 1, End: 2},
+				{MemberId: 2, Index: 10, Start: 3, End: 4},
+			},
+		},
+		{
+			name: "Index lower than any previous response",
+			observations: []ConsistentIndexObservation{
+				{MemberId: 1, Index: 12, Start: 1, End: 2},
+				{MemberId: 1, Index: 11, Start: 3, End: 4},
+				{MemberId: 1, Index: 13, Start: 5, End: 6},
+				{MemberId: 1, Index: 12, Start: 7, End: 8},
+			},
+			expectViolations: 2,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateConsistentIndexes(tc.observations)
+			assert.Len(t, violations, tc.expectViolations, "%v", violations)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// RequestTypeCounts returns number of operations tagged with each traffic request type, see AppendableHistory.SetTag.
+// Untagged operations, like reads done by traffic before writes, are not counted.
+func RequestTypeCounts(operations []porcupine.Operation) map[string]int {
+	counts := map[string]int{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		if request.Tag != "" {
+			counts[request.Tag]++
+		}
+	}
+	return counts
+}
+
+// ValidateRequestTypeCounts checks that each request type was done by at least minimal number of operations.
+// Returns description of each request type below its minimum, in request type order.
+func ValidateRequestTypeCounts(counts, minimal map[string]int) []string {
+	requestTypes := make([]string, 0, len(minimal))
+	for requestType := range minimal {
+		requestTypes = append(requestTypes, requestType)
+	}
+	sort.Strings(requestTypes)
+	violations := []string{}
+	for _, requestType := range requestTypes {
+		if counts[requestType] < minimal[requestType] {
+			violations = append(violations, fmt.Sprintf("request type %q done by %d operations, expected at least %d", requestType, counts[requestType], minimal[requestType]))
+		}
+	}
+	return violations
+}
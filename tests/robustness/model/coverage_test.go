@@ -0,0 +1,74 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+func TestRequestTypeCounts(t *testing.T) {
+	h := NewAppendableHistory(identity.NewIdProvider())
+	h.AppendRange("key", false, 0, time.Second, nil, errors.New("failed"))
+	h.SetTag("putWithLease")
+	h.AppendLeaseGrant(10, 0, time.Second, nil, errors.New("failed"))
+	h.AppendPut("key", "1", 0, time.Second, nil, errors.New("failed"))
+	h.SetTag("delete")
+	h.AppendDelete("key", 0, time.Second, nil, errors.New("failed"))
+	h.SetTag("")
+	h.AppendPut("key", "2", 0, time.Second, nil, errors.New("failed"))
+
+	assert.Equal(t, map[string]int{"putWithLease": 2, "delete": 1}, RequestTypeCounts(h.Operations()))
+}
+
+func TestValidateRequestTypeCounts(t *testing.T) {
+	tcs := []struct {
+		name             string
+		counts           map[string]int
+		minimal          map[string]int
+		expectViolations []string
+	}{
+		{
+			name:             "No minimal counts",
+			counts:           map[string]int{"put": 10},
+			expectViolations: []string{},
+		},
+		{
+			name:             "All request types reached minimal count",
+			counts:           map[string]int{"put": 10, "defragment": 1},
+			minimal:          map[string]int{"put": 10, "defragment": 1},
+			expectViolations: []string{},
+		},
+		{
+			name:    "Request types below minimal count",
+			counts:  map[string]int{"put": 10, "putWithLease": 2},
+			minimal: map[string]int{"put": 1, "putWithLease": 5, "defragment": 1},
+			expectViolations: []string{
+				`request type "defragment" done by 0 operations, expected at least 1`,
+				`request type "putWithLease" done by 2 operations, expected at least 5`,
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectViolations, ValidateRequestTypeCounts(tc.counts, tc.minimal))
+		})
+	}
+}
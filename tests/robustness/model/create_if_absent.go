@@ -0,0 +1,122 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateCreateIfAbsent checks exactly-once creation semantics of create-if-absent transactions, which put key
+// only if its create revision is zero. Once such transaction created a key, no other one can create it again,
+// until key is removed by a delete or by a lease revoke. Removal with known result has to happen at revision
+// between both creations, removal with unknown result has to be called before the second creation returned.
+// Returns description of each violation. Creations that failed or have unknown result are skipped.
+func ValidateCreateIfAbsent(operations []porcupine.Operation) []string {
+	creations := map[string][]porcupine.Operation{}
+	removals := map[string][]porcupine.Operation{}
+	leaseRevokes := []porcupine.Operation{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type == LeaseRevoke {
+			leaseRevokes = append(leaseRevokes, op)
+			continue
+		}
+		if request.Type != Txn {
+			continue
+		}
+		for _, key := range txnDeletedKeys(request.Txn) {
+			removals[key] = append(removals[key], op)
+		}
+		if key, ok := createIfAbsentKey(request); ok && resp.Err == nil && !resp.ResultUnknown && resp.Txn != nil && !resp.Txn.TxnResult {
+			creations[key] = append(creations[key], op)
+		}
+	}
+	keys := make([]string, 0, len(creations))
+	for key := range creations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	violations := []string{}
+	for _, key := range keys {
+		ops := creations[key]
+		sort.SliceStable(ops, func(i, j int) bool {
+			return ops[i].Output.(EtcdNonDeterministicResponse).Revision < ops[j].Output.(EtcdNonDeterministicResponse).Revision
+		})
+		for i := 1; i < len(ops); i++ {
+			if removedBetween(ops[i-1], ops[i], removals[key]) || removedBetween(ops[i-1], ops[i], leaseRevokes) {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("key %q created again without being removed, first: client: %d, %s, then: client: %d, %s",
+				key,
+				ops[i-1].ClientId, NonDeterministicModel.DescribeOperation(ops[i-1].Input, ops[i-1].Output),
+				ops[i].ClientId, NonDeterministicModel.DescribeOperation(ops[i].Input, ops[i].Output),
+			))
+		}
+	}
+	return violations
+}
+
+// createIfAbsentKey returns key put by request if it's a create-if-absent transaction.
+func createIfAbsentKey(request EtcdRequest) (string, bool) {
+	txn := request.Txn
+	if len(txn.Conds) != 1 || len(txn.Ops) != 1 || len(txn.OpsOnFailure) != 0 {
+		return "", false
+	}
+	cond, op := txn.Conds[0], txn.Ops[0]
+	if cond.ExpectedRevision != 0 || cond.ExpectedValue != nil || op.Type != Put || op.Key != cond.Key {
+		return "", false
+	}
+	return op.Key, true
+}
+
+// txnDeletedKeys returns keys deleted by any branch of transaction, including nested transactions.
+func txnDeletedKeys(txn *TxnRequest) []string {
+	keys := []string{}
+	for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Delete:
+				keys = append(keys, op.Key)
+			case NestedTxn:
+				keys = append(keys, txnDeletedKeys(op.Txn)...)
+			}
+		}
+	}
+	return keys
+}
+
+// removedBetween returns whether any of removals could have been applied between first and second creation.
+func removedBetween(first, second porcupine.Operation, removals []porcupine.Operation) bool {
+	firstRevision := first.Output.(EtcdNonDeterministicResponse).Revision
+	secondRevision := second.Output.(EtcdNonDeterministicResponse).Revision
+	for _, op := range removals {
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if resp.Err != nil || resp.ResultUnknown {
+			if op.Call < second.Return {
+				return true
+			}
+			continue
+		}
+		if resp.Revision > firstRevision && resp.Revision < secondRevision {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCreateIfAbsent(t *testing.T) {
+	tcs := []struct {
+		name           string
+		operations     []porcupine.Operation
+		expectViolated int
+	}{
+		{
+			name: "Only one of concurrent creations succeeds",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 2},
+				{ClientId: 2, Input: createIfAbsentRequest("key", "2"), Call: 1, Output: createIfAbsentResponse(false, 2), Return: 3},
+				{ClientId: 3, Input: createIfAbsentRequest("key", "3"), Call: 1, Output: failedResponse(errors.New("failed")), Return: 3},
+			},
+		},
+		{
+			name: "Key created twice",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 2},
+				{ClientId: 2, Input: createIfAbsentRequest("key", "2"), Call: 1, Output: createIfAbsentResponse(true, 3), Return: 3},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Key created again after other key was deleted",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 1, Input: deleteRequest("other"), Call: 2, Output: deleteResponse(1, 3), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 4), Return: 5},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Key created again after being deleted",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 1, Input: deleteRequest("key"), Call: 2, Output: deleteResponse(1, 3), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 4), Return: 5},
+			},
+		},
+		{
+			name: "Key created again after being deleted by nested transaction",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 1, Input: txnRequest(nil, []EtcdOperation{{Type: NestedTxn, Txn: txnRequest(nil, []EtcdOperation{{Type: Delete, Key: "key"}}).Txn}}), Call: 2, Output: txnResponse([]EtcdOperationResult{{Txn: &TxnResponse{OpsResult: []EtcdOperationResult{{Deleted: 1}}}}}, true, 3), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 4), Return: 5},
+			},
+		},
+		{
+			name: "Key deleted before first creation",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: deleteRequest("key"), Call: 0, Output: deleteResponse(0, 1), Return: 1},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 2, Output: createIfAbsentResponse(true, 2), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 3), Return: 5},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Key created again after failed delete",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 2, Input: deleteRequest("key"), Call: 2, Output: failedResponse(errors.New("failed")), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 4), Return: 5},
+			},
+		},
+		{
+			name: "Failed delete called after second creation",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 2, Output: createIfAbsentResponse(true, 3), Return: 3},
+				{ClientId: 2, Input: deleteRequest("key"), Call: 4, Output: failedResponse(errors.New("failed")), Return: 5},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Key created again after lease revoke",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: createIfAbsentRequest("key", "1"), Call: 0, Output: createIfAbsentResponse(true, 2), Return: 1},
+				{ClientId: 1, Input: leaseRevokeRequest(1), Call: 2, Output: leaseRevokeResponse(3), Return: 3},
+				{ClientId: 1, Input: createIfAbsentRequest("key", "2"), Call: 4, Output: createIfAbsentResponse(true, 4), Return: 5},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, ValidateCreateIfAbsent(tc.operations), tc.expectViolated)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// DefragmentedRead describes reads of keys with Prefix done on the same member right before and after it was
+// defragmented. Defragmentation only reclaims space of the backend, so read after it pinned to revision of the read
+// before it has to return exactly the same keys, and revision of the member must not go backwards. It holds even
+// if defragmentation failed, for example because member crashed in the middle of it.
+type DefragmentedRead struct {
+	Endpoint string
+	Prefix   string
+	// Before are keys returned by read at Revision before defragmentation.
+	Before   []*mvccpb.KeyValue
+	Revision int64
+	// DefragmentErr is error returned by defragmentation, nil if it succeeded.
+	DefragmentErr error
+	// After are keys returned by read pinned to Revision after defragmentation, done when member was at AfterRevision.
+	After         []*mvccpb.KeyValue
+	AfterRevision int64
+}
+
+// Validate returns error describing how defragmentation changed data observed by reads, nil if it didn't.
+func (r DefragmentedRead) Validate() error {
+	if r.AfterRevision < r.Revision {
+		return fmt.Errorf("member %s went back from revision %d to %d after defragmentation, defragment error: %v", r.Endpoint, r.Revision, r.AfterRevision, r.DefragmentErr)
+	}
+	before := map[string]*mvccpb.KeyValue{}
+	for _, kv := range r.Before {
+		before[string(kv.Key)] = kv
+	}
+	after := map[string]*mvccpb.KeyValue{}
+	for _, kv := range r.After {
+		after[string(kv.Key)] = kv
+	}
+	for _, kv := range r.Before {
+		got, ok := after[string(kv.Key)]
+		if !ok {
+			return fmt.Errorf("key %q read at revision %d from member %s before defragmentation was not found after it, defragment error: %v", kv.Key, r.Revision, r.Endpoint, r.DefragmentErr)
+		}
+		if diff := describeKeyValueDiff(kv, got); diff != "" {
+			return fmt.Errorf("key %q read at revision %d from member %s changed by defragmentation, %s, defragment error: %v", kv.Key, r.Revision, r.Endpoint, diff, r.DefragmentErr)
+		}
+	}
+	for _, kv := range r.After {
+		if _, ok := before[string(kv.Key)]; !ok {
+			return fmt.Errorf("key %q read at revision %d from member %s after defragmentation was not found before it, defragment error: %v", kv.Key, r.Revision, r.Endpoint, r.DefragmentErr)
+		}
+	}
+	return nil
+}
+
+// describeKeyValueDiff returns the first field that differs between two versions of the same key, empty if none does.
+func describeKeyValueDiff(want, got *mvccpb.KeyValue) string {
+	switch {
+	case string(want.Value) != string(got.Value):
+		return fmt.Sprintf("value %q became %q", want.Value, got.Value)
+	case want.ModRevision != got.ModRevision:
+		return fmt.Sprintf("mod revision %d became %d", want.ModRevision, got.ModRevision)
+	case want.CreateRevision != got.CreateRevision:
+		return fmt.Sprintf("create revision %d became %d", want.CreateRevision, got.CreateRevision)
+	case want.Version != got.Version:
+		return fmt.Sprintf("version %d became %d", want.Version, got.Version)
+	case want.Lease != got.Lease:
+		return fmt.Sprintf("lease %d became %d", want.Lease, got.Lease)
+	}
+	return ""
+}
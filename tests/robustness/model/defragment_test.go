@@ -0,0 +1,104 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestDefragmentedReadValidate(t *testing.T) {
+	kv := func(key, value string, modRevision int64) *mvccpb.KeyValue {
+		return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), CreateRevision: 2, ModRevision: modRevision, Version: modRevision - 1}
+	}
+	tcs := []struct {
+		name        string
+		read        DefragmentedRead
+		expectError bool
+	}{
+		{
+			name: "Unchanged keys",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3), kv("b", "2", 4)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "1", 3), kv("b", "2", 4)}, AfterRevision: 6,
+			},
+		},
+		{
+			name: "Unchanged keys despite failed defragmentation",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3)}, Revision: 4, DefragmentErr: errors.New("unavailable"),
+				After: []*mvccpb.KeyValue{kv("a", "1", 3)}, AfterRevision: 4,
+			},
+		},
+		{
+			name: "No keys",
+			read: DefragmentedRead{Revision: 4, AfterRevision: 4},
+		},
+		{
+			name: "Revision went backwards",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "1", 3)}, AfterRevision: 3,
+			},
+			expectError: true,
+		},
+		{
+			name: "Value changed",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "2", 3)}, AfterRevision: 4,
+			},
+			expectError: true,
+		},
+		{
+			name: "Mod revision changed",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "1", 4)}, AfterRevision: 4,
+			},
+			expectError: true,
+		},
+		{
+			name: "Key lost",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3), kv("b", "2", 4)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "1", 3)}, AfterRevision: 4,
+			},
+			expectError: true,
+		},
+		{
+			name: "Key appeared",
+			read: DefragmentedRead{
+				Before: []*mvccpb.KeyValue{kv("a", "1", 3)}, Revision: 4,
+				After: []*mvccpb.KeyValue{kv("a", "1", 3), kv("b", "2", 4)}, AfterRevision: 4,
+			},
+			expectError: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.read.Validate()
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
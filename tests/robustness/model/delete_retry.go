@@ -0,0 +1,118 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateCompareRevisionDeleteRetries checks idempotency of deletes conditioned on mod revision of the key, which
+// are retried after attempt that failed with unknown result. If the first attempt was applied, key no longer has
+// expected mod revision, as it was deleted and possibly created again, so the retry has to be a no-op. Retry that
+// deleted key is a violation if the key was written with known result after the expected revision.
+// Returns description of each violation. Retries that failed or have unknown result are skipped.
+func ValidateCompareRevisionDeleteRetries(operations []porcupine.Operation) []string {
+	writes := map[string][]porcupine.Operation{}
+	attempts := map[keyRevision][]porcupine.Operation{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn {
+			continue
+		}
+		if key, expectedRevision, ok := compareRevisionDeleteKey(request); ok {
+			attempt := keyRevision{key: key, revision: expectedRevision}
+			attempts[attempt] = append(attempts[attempt], op)
+		}
+		if resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			continue
+		}
+		for _, modification := range txnModifications(request.Txn, resp.Txn) {
+			writes[modification.Key] = append(writes[modification.Key], op)
+		}
+	}
+	deletes := make([]keyRevision, 0, len(attempts))
+	for attempt := range attempts {
+		deletes = append(deletes, attempt)
+	}
+	sort.Slice(deletes, func(i, j int) bool {
+		if deletes[i].key != deletes[j].key {
+			return deletes[i].key < deletes[j].key
+		}
+		return deletes[i].revision < deletes[j].revision
+	})
+
+	violations := []string{}
+	for _, attempt := range deletes {
+		ops := attempts[attempt]
+		sort.SliceStable(ops, func(i, j int) bool {
+			return ops[i].Call < ops[j].Call
+		})
+		var unknown *porcupine.Operation
+		for i, op := range ops {
+			resp := op.Output.(EtcdNonDeterministicResponse)
+			if resp.Err != nil || resp.ResultUnknown {
+				if unknown == nil {
+					unknown = &ops[i]
+				}
+				continue
+			}
+			if unknown == nil || resp.Txn == nil || resp.Txn.TxnResult {
+				continue
+			}
+			write, found := writtenBetween(writes[attempt.key], attempt.revision, resp.Revision)
+			if !found {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("retry of delete of key %q expecting mod revision %d deleted key written at revision %d, first attempt: client: %d, %s, write: client: %d, %s, retry: client: %d, %s",
+				attempt.key, attempt.revision, write.Output.(EtcdNonDeterministicResponse).Revision,
+				unknown.ClientId, NonDeterministicModel.DescribeOperation(unknown.Input, unknown.Output),
+				write.ClientId, NonDeterministicModel.DescribeOperation(write.Input, write.Output),
+				op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output),
+			))
+		}
+	}
+	return violations
+}
+
+// compareRevisionDeleteKey returns key deleted by request and its expected mod revision, if it's a transaction
+// deleting single key only if its mod revision is equal to non-zero expected revision.
+func compareRevisionDeleteKey(request EtcdRequest) (string, int64, bool) {
+	txn := request.Txn
+	if len(txn.Conds) != 1 || len(txn.Ops) != 1 || len(txn.OpsOnFailure) != 0 {
+		return "", 0, false
+	}
+	cond, op := txn.Conds[0], txn.Ops[0]
+	if cond.ExpectedRevision == 0 || cond.ExpectedValue != nil || op.Type != Delete || op.Key != cond.Key || op.End != "" || op.WithPrefix {
+		return "", 0, false
+	}
+	return op.Key, cond.ExpectedRevision, true
+}
+
+// writtenBetween returns write with the lowest revision in exclusive (after, before) range.
+func writtenBetween(writes []porcupine.Operation, after, before int64) (porcupine.Operation, bool) {
+	var found porcupine.Operation
+	var revision int64
+	for _, op := range writes {
+		rev := op.Output.(EtcdNonDeterministicResponse).Revision
+		if rev > after && rev < before && (revision == 0 || rev < revision) {
+			found, revision = op, rev
+		}
+	}
+	return found, revision != 0
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCompareRevisionDeleteRetries(t *testing.T) {
+	put := porcupine.Operation{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2), Call: 0, Return: 1}
+	unknownDelete := porcupine.Operation{ClientId: 2, Input: compareRevisionAndDeleteRequest("key", 2), Output: failedResponse(errors.New("timeout")), Call: 2}
+	recreate := porcupine.Operation{ClientId: 3, Input: createIfAbsentRequest("key", "2"), Output: createIfAbsentResponse(true, 4), Call: 4, Return: 5}
+	tcs := []struct {
+		name             string
+		operations       []porcupine.Operation
+		expectViolations []string
+	}{
+		{
+			name: "Retry deleted key not written since expected revision",
+			operations: []porcupine.Operation{
+				put, unknownDelete,
+				{ClientId: 4, Input: compareRevisionAndDeleteRequest("key", 2), Output: compareAndDeleteResponse(true, 1, 3), Call: 3, Return: 4},
+			},
+		},
+		{
+			name: "Retry after first attempt applied and key recreated is no-op",
+			operations: []porcupine.Operation{
+				put, unknownDelete, recreate,
+				{ClientId: 4, Input: compareRevisionAndDeleteRequest("key", 2), Output: compareAndDeleteResponse(false, 0, 4), Call: 6, Return: 7},
+			},
+		},
+		{
+			name: "Retry deleted recreated key",
+			operations: []porcupine.Operation{
+				put, unknownDelete, recreate,
+				{ClientId: 4, Input: compareRevisionAndDeleteRequest("key", 2), Output: compareAndDeleteResponse(true, 1, 5), Call: 6, Return: 7},
+			},
+			expectViolations: []string{`retry of delete of key "key" expecting mod revision 2 deleted key written at revision 4, first attempt: client: 2, if(mod_rev(key)==2).then(delete("key")) -> err: "timeout", write: client: 3, if(mod_rev(key)==0).then(put("key", "2")) -> ok, rev: 4, retry: client: 4, if(mod_rev(key)==2).then(delete("key")) -> deleted: 1, rev: 5`},
+		},
+		{
+			name: "Delete without prior unknown attempt is not a retry",
+			operations: []porcupine.Operation{
+				put, recreate,
+				{ClientId: 4, Input: compareRevisionAndDeleteRequest("key", 2), Output: compareAndDeleteResponse(true, 1, 5), Call: 6, Return: 7},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateCompareRevisionDeleteRetries(tc.operations)
+			if len(tc.expectViolations) == 0 {
+				assert.Empty(t, violations)
+			} else {
+				assert.Equal(t, tc.expectViolations, violations)
+			}
+		})
+	}
+}
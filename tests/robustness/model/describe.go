@@ -42,25 +42,64 @@ func describeEtcdResponse(request EtcdRequest, response EtcdResponse) string {
 func describeEtcdRequest(request EtcdRequest) string {
 	switch request.Type {
 	case Txn:
-		describeOperations := describeEtcdOperations(request.Txn.Ops)
-		if len(request.Txn.Conds) != 0 {
-			return fmt.Sprintf("if(%s).then(%s)", describeEtcdConditions(request.Txn.Conds), describeOperations)
-		}
-		return describeOperations
+		return describeTxnRequest(request.Txn)
 	case LeaseGrant:
 		return fmt.Sprintf("leaseGrant(%d)", request.LeaseGrant.LeaseID)
 	case LeaseRevoke:
 		return fmt.Sprintf("leaseRevoke(%d)", request.LeaseRevoke.LeaseID)
 	case Defragment:
+		if request.Defragment.Endpoint != "" {
+			return fmt.Sprintf("defragment(%q)", request.Defragment.Endpoint)
+		}
 		return fmt.Sprintf("defragment()")
+	case Compact:
+		if request.Compact.Physical {
+			return fmt.Sprintf("compact(%d, physical)", request.Compact.Revision)
+		}
+		return fmt.Sprintf("compact(%d)", request.Compact.Revision)
 	default:
 		return fmt.Sprintf("<! unknown request type: %q !>", request.Type)
 	}
 }
 
+func describeRangeOptions(op EtcdOperation) string {
+	options := ""
+	if op.Limit != 0 {
+		options += fmt.Sprintf(", limit=%d", op.Limit)
+	}
+	if op.SortTarget != "" {
+		options += fmt.Sprintf(", sort=%s-%s", op.SortTarget, op.SortOrder)
+	}
+	if op.MinModRevision != 0 {
+		options += fmt.Sprintf(", minModRev=%d", op.MinModRevision)
+	}
+	if op.MaxModRevision != 0 {
+		options += fmt.Sprintf(", maxModRev=%d", op.MaxModRevision)
+	}
+	if op.KeysOnly {
+		options += ", keysOnly"
+	}
+	return options
+}
+
+func describeTxnRequest(request *TxnRequest) string {
+	describeOperations := describeEtcdOperations(request.Ops)
+	if len(request.Conds) == 0 {
+		return describeOperations
+	}
+	if len(request.OpsOnFailure) != 0 {
+		return fmt.Sprintf("if(%s).then(%s).else(%s)", describeEtcdConditions(request.Conds), describeOperations, describeEtcdOperations(request.OpsOnFailure))
+	}
+	return fmt.Sprintf("if(%s).then(%s)", describeEtcdConditions(request.Conds), describeOperations)
+}
+
 func describeEtcdConditions(conds []EtcdCondition) string {
 	opsDescription := make([]string, len(conds))
 	for i := range conds {
+		if conds[i].ExpectedValue != nil {
+			opsDescription[i] = fmt.Sprintf("value(%s)==%s", conds[i].Key, describeValueOrHash(*conds[i].ExpectedValue))
+			continue
+		}
 		opsDescription[i] = fmt.Sprintf("mod_rev(%s)==%d", conds[i].Key, conds[i].ExpectedRevision)
 	}
 	return strings.Join(opsDescription, " && ")
@@ -75,12 +114,19 @@ func describeEtcdOperations(ops []EtcdOperation) string {
 }
 
 func describeTxnResponse(request *TxnRequest, response *TxnResponse) string {
+	ops := request.Ops
 	if response.TxnResult {
-		return fmt.Sprintf("txn failed")
+		if len(request.OpsOnFailure) == 0 {
+			return fmt.Sprintf("txn failed")
+		}
+		ops = request.OpsOnFailure
 	}
 	respDescription := make([]string, len(response.OpsResult))
 	for i := range response.OpsResult {
-		respDescription[i] = describeEtcdOperationResponse(request.Ops[i], response.OpsResult[i])
+		respDescription[i] = describeEtcdOperationResponse(ops[i], response.OpsResult[i])
+	}
+	if response.TxnResult {
+		return fmt.Sprintf("txn failed, %s", strings.Join(respDescription, ", "))
 	}
 	return strings.Join(respDescription, ", ")
 }
@@ -89,19 +135,27 @@ func describeEtcdOperation(op EtcdOperation) string {
 	switch op.Type {
 	case Range:
 		if op.WithPrefix {
-			if op.Limit != 0 {
-				return fmt.Sprintf("range(%q, limit=%d)", op.Key, op.Limit)
-			}
-			return fmt.Sprintf("range(%q)", op.Key)
+			return fmt.Sprintf("range(%q%s)", op.Key, describeRangeOptions(op))
+		}
+		if op.End != "" {
+			return fmt.Sprintf("range(%q, %q%s)", op.Key, op.End, describeRangeOptions(op))
 		}
-		return fmt.Sprintf("get(%q)", op.Key)
+		return fmt.Sprintf("get(%q%s)", op.Key, describeRangeOptions(op))
 	case Put:
 		if op.LeaseID != 0 {
 			return fmt.Sprintf("put(%q, %s, %d)", op.Key, describeValueOrHash(op.Value), op.LeaseID)
 		}
 		return fmt.Sprintf("put(%q, %s)", op.Key, describeValueOrHash(op.Value))
 	case Delete:
+		if op.WithPrefix {
+			return fmt.Sprintf("deleteRange(%q)", op.Key)
+		}
+		if op.End != "" {
+			return fmt.Sprintf("deleteRange(%q, %q)", op.Key, op.End)
+		}
 		return fmt.Sprintf("delete(%q)", op.Key)
+	case NestedTxn:
+		return fmt.Sprintf("txn(%s)", describeTxnRequest(op.Txn))
 	default:
 		return fmt.Sprintf("<! unknown op: %q !>", op.Type)
 	}
@@ -110,7 +164,7 @@ func describeEtcdOperation(op EtcdOperation) string {
 func describeEtcdOperationResponse(req EtcdOperation, resp EtcdOperationResult) string {
 	switch req.Type {
 	case Range:
-		if req.WithPrefix {
+		if req.WithPrefix || req.End != "" {
 			kvs := make([]string, len(resp.KVs))
 			for i, kv := range resp.KVs {
 				kvs[i] = describeValueOrHash(kv.Value)
@@ -127,6 +181,8 @@ func describeEtcdOperationResponse(req EtcdOperation, resp EtcdOperationResult)
 		return fmt.Sprintf("ok")
 	case Delete:
 		return fmt.Sprintf("deleted: %d", resp.Deleted)
+	case NestedTxn:
+		return fmt.Sprintf("txn(%s)", describeTxnResponse(req.Txn, resp.Txn))
 	default:
 		return fmt.Sprintf("<! unknown op: %q !>", req.Type)
 	}
@@ -79,6 +79,11 @@ func TestModelDescribe(t *testing.T) {
 			resp:           failedResponse(errors.New("failed")),
 			expectDescribe: `delete("key6") -> err: "failed"`,
 		},
+		{
+			req:            deletePrefixRequest("key6"),
+			resp:           deleteResponse(2, 6),
+			expectDescribe: `deleteRange("key6") -> deleted: 2, rev: 6`,
+		},
 		{
 			req:            compareRevisionAndPutRequest("key7", 7, "77"),
 			resp:           compareRevisionAndPutResponse(false, 7),
@@ -89,6 +94,16 @@ func TestModelDescribe(t *testing.T) {
 			resp:           compareRevisionAndPutResponse(true, 8),
 			expectDescribe: `if(mod_rev(key8)==8).then(put("key8", "88")) -> ok, rev: 8`,
 		},
+		{
+			req:            compareValueAndDeleteRequest("key8", "88"),
+			resp:           compareAndDeleteResponse(true, 1, 9),
+			expectDescribe: `if(value(key8)=="88").then(delete("key8")) -> deleted: 1, rev: 9`,
+		},
+		{
+			req:            compareMultiAndSwapRequest(map[string]string{"key8": "88", "key7": "77"}, "key9", "99"),
+			resp:           txnResponse([]EtcdOperationResult{{}}, true, 9),
+			expectDescribe: `if(value(key7)=="77" && value(key8)=="88").then(put("key9", "99")).else(get("key7"), get("key8"), get("key9")) -> ok, rev: 9`,
+		},
 		{
 			req:            compareRevisionAndPutRequest("key9", 9, "99"),
 			resp:           failedResponse(errors.New("failed")),
@@ -99,11 +114,33 @@ func TestModelDescribe(t *testing.T) {
 			resp:           txnResponse([]EtcdOperationResult{{KVs: []KeyValue{{ValueRevision: ValueRevision{Value: ValueOrHash{Value: "110"}}}}}, {}, {Deleted: 1}}, true, 10),
 			expectDescribe: `get("10"), put("11", "111"), delete("12") -> "110", ok, deleted: 1, rev: 10`,
 		},
+		{
+			req: conditionalTxnRequest([]EtcdCondition{{Key: "13", ExpectedRevision: 13}}, []EtcdOperation{
+				{Type: NestedTxn, Txn: conditionalTxnRequest([]EtcdCondition{{Key: "14", ExpectedRevision: 14}}, []EtcdOperation{{Type: Delete, Key: "14"}}, []EtcdOperation{{Type: Range, Key: "14"}}).Txn},
+			}, []EtcdOperation{{Type: Put, Key: "15", Value: ValueOrHash{Value: "15"}}}),
+			resp:           txnResponse([]EtcdOperationResult{{Txn: &TxnResponse{TxnResult: true, OpsResult: []EtcdOperationResult{{}}}}}, true, 13),
+			expectDescribe: `if(mod_rev(13)==13).then(txn(if(mod_rev(14)==14).then(delete("14")).else(get("14")))).else(put("15", "15")) -> txn(txn failed, nil), rev: 13`,
+		},
 		{
 			req:            defragmentRequest(),
 			resp:           defragmentResponse(10),
 			expectDescribe: `defragment() -> ok, rev: 10`,
 		},
+		{
+			req:            defragmentMemberRequest("http://127.0.0.1:2379"),
+			resp:           defragmentResponse(10),
+			expectDescribe: `defragment("http://127.0.0.1:2379") -> ok, rev: 10`,
+		},
+		{
+			req:            compactRequest(9, false),
+			resp:           compactResponse(10),
+			expectDescribe: `compact(9) -> ok, rev: 10`,
+		},
+		{
+			req:            compactRequest(9, true),
+			resp:           failedResponse(errors.New("etcdserver: mvcc: required revision has been compacted")),
+			expectDescribe: `compact(9, physical) -> err: "etcdserver: mvcc: required revision has been compacted"`,
+		},
 		{
 			req:            rangeRequest("key11", true, 0),
 			resp:           rangeResponse(nil, 0, 11),
@@ -124,6 +161,31 @@ func TestModelDescribe(t *testing.T) {
 			resp:           rangeResponse(nil, 0, 14),
 			expectDescribe: `range("key14", limit=14) -> [], count: 0, rev: 14`,
 		},
+		{
+			req:            sortedRangeRequest("key15", true, SortByCreateRevision, SortDescend, 15),
+			resp:           rangeResponse(nil, 0, 15),
+			expectDescribe: `range("key15", limit=15, sort=create-descend) -> [], count: 0, rev: 15`,
+		},
+		{
+			req:            modRevisionRangeRequest("key15", true, 2, 15),
+			resp:           rangeResponse(nil, 0, 15),
+			expectDescribe: `range("key15", minModRev=2, maxModRev=15) -> [], count: 0, rev: 15`,
+		},
+		{
+			req:            modRevisionRangeRequest("key16", false, 16, 16),
+			resp:           rangeResponse(nil, 1, 16),
+			expectDescribe: `get("key16", minModRev=16, maxModRev=16) -> nil, rev: 16`,
+		},
+		{
+			req:            keysOnlyRangeRequest("key17", true),
+			resp:           rangeResponse(nil, 0, 17),
+			expectDescribe: `range("key17", keysOnly) -> [], count: 0, rev: 17`,
+		},
+		{
+			req:            rangeSnapshotRequest("key15", "key16", false),
+			resp:           rangeResponse([]*mvccpb.KeyValue{{Value: []byte("15")}}, 1, 15),
+			expectDescribe: `range("key15", "key16") -> ["15"], count: 1, rev: 15`,
+		},
 	}
 	for _, tc := range tcs {
 		assert.Equal(t, tc.expectDescribe, NonDeterministicModel.DescribeOperation(tc.req, tc.resp))
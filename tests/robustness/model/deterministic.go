@@ -56,8 +56,13 @@ var DeterministicModel = porcupine.Model{
 type etcdState struct {
 	Revision  int64
 	KeyValues map[string]ValueRevision
-	KeyLeases map[string]int64
-	Leases    map[int64]EtcdLease
+	// KeyCreateRevisions tracks create revision of keys created after state was initialized.
+	// Create revision of keys from initial state is not known and treated as zero.
+	KeyCreateRevisions map[string]int64
+	KeyLeases          map[string]int64
+	Leases             map[int64]EtcdLease
+	// CompactRevision is revision of the last successful compaction, zero if none was observed.
+	CompactRevision int64
 }
 
 func (s etcdState) Step(request EtcdRequest, response EtcdResponse) (bool, etcdState) {
@@ -71,137 +76,96 @@ func (s etcdState) Step(request EtcdRequest, response EtcdResponse) (bool, etcdS
 // initState tries to create etcd state based on the first request.
 func initState(request EtcdRequest, response EtcdResponse) etcdState {
 	state := etcdState{
-		Revision:  response.Revision,
-		KeyValues: map[string]ValueRevision{},
-		KeyLeases: map[string]int64{},
-		Leases:    map[int64]EtcdLease{},
+		Revision:           response.Revision,
+		KeyValues:          map[string]ValueRevision{},
+		KeyCreateRevisions: map[string]int64{},
+		KeyLeases:          map[string]int64{},
+		Leases:             map[int64]EtcdLease{},
 	}
 	switch request.Type {
 	case Txn:
-		if response.Txn.TxnResult {
-			return state
-		}
-		if len(request.Txn.Ops) != len(response.Txn.OpsResult) {
-			panic(fmt.Sprintf("Incorrect request %s, response %+v", describeEtcdRequest(request), describeEtcdResponse(request, response)))
-		}
-		for i, op := range request.Txn.Ops {
-			opResp := response.Txn.OpsResult[i]
-			switch op.Type {
-			case Range:
-				for _, kv := range opResp.KVs {
-					state.KeyValues[kv.Key] = ValueRevision{
-						Value:       kv.Value,
-						ModRevision: kv.ModRevision,
-					}
-				}
-			case Put:
-				state.KeyValues[op.Key] = ValueRevision{
-					Value:       op.Value,
-					ModRevision: response.Revision,
-				}
-			case Delete:
-			default:
-				panic("Unknown operation")
-			}
-		}
+		initTxnState(state, request.Txn, response.Txn, response.Revision)
 	case LeaseGrant:
 		lease := EtcdLease{
 			LeaseID: request.LeaseGrant.LeaseID,
+			TTL:     request.LeaseGrant.ExpirationTTL(),
 			Keys:    map[string]struct{}{},
 		}
 		state.Leases[request.LeaseGrant.LeaseID] = lease
 	case LeaseRevoke:
 	case Defragment:
+	case Compact:
+		state.CompactRevision = request.Compact.Revision
 	default:
 		panic(fmt.Sprintf("Unknown request type: %v", request.Type))
 	}
 	return state
 }
 
+// initTxnState fills state with key values observed or written by transaction branch that was executed.
+func initTxnState(state etcdState, request *TxnRequest, response *TxnResponse, revision int64) {
+	ops := request.Ops
+	if response.TxnResult {
+		ops = request.OpsOnFailure
+	}
+	if len(ops) != len(response.OpsResult) {
+		panic(fmt.Sprintf("Incorrect request %s, response %+v", describeEtcdRequest(EtcdRequest{Type: Txn, Txn: request}), describeTxnResponse(request, response)))
+	}
+	for i, op := range ops {
+		opResp := response.OpsResult[i]
+		switch op.Type {
+		case Range:
+			for _, kv := range opResp.KVs {
+				state.KeyValues[kv.Key] = ValueRevision{
+					Value:       kv.Value,
+					ModRevision: kv.ModRevision,
+				}
+			}
+		case Put:
+			state.KeyValues[op.Key] = ValueRevision{
+				Value:       op.Value,
+				ModRevision: revision,
+			}
+		case Delete:
+		case NestedTxn:
+			initTxnState(state, op.Txn, opResp.Txn, revision)
+		default:
+			panic("Unknown operation")
+		}
+	}
+}
+
 // step handles a successful request, returning updated state and response it would generate.
 func (s etcdState) step(request EtcdRequest) (etcdState, EtcdResponse) {
+	// Transaction conditions, including nested ones, are evaluated against state from before the request.
+	readView := s.KeyValues
 	newKVs := map[string]ValueRevision{}
 	for k, v := range s.KeyValues {
 		newKVs[k] = v
 	}
 	s.KeyValues = newKVs
+	newCreateRevisions := map[string]int64{}
+	for k, v := range s.KeyCreateRevisions {
+		newCreateRevisions[k] = v
+	}
+	s.KeyCreateRevisions = newCreateRevisions
 	switch request.Type {
 	case Txn:
-		success := true
-		for _, cond := range request.Txn.Conds {
-			if val := s.KeyValues[cond.Key]; val.ModRevision != cond.ExpectedRevision {
-				success = false
-				break
-			}
-		}
-		if !success {
-			return s, EtcdResponse{Revision: s.Revision, Txn: &TxnResponse{TxnResult: true}}
-		}
-		opResp := make([]EtcdOperationResult, len(request.Txn.Ops))
-		increaseRevision := false
-		for i, op := range request.Txn.Ops {
-			switch op.Type {
-			case Range:
-				opResp[i] = EtcdOperationResult{
-					KVs: []KeyValue{},
-				}
-				if op.WithPrefix {
-					var count int64
-					for k, v := range s.KeyValues {
-						if strings.HasPrefix(k, op.Key) {
-							opResp[i].KVs = append(opResp[i].KVs, KeyValue{Key: k, ValueRevision: v})
-							count += 1
-						}
-					}
-					sort.Slice(opResp[i].KVs, func(j, k int) bool {
-						return opResp[i].KVs[j].Key < opResp[i].KVs[k].Key
-					})
-					if op.Limit != 0 && count > op.Limit {
-						opResp[i].KVs = opResp[i].KVs[:op.Limit]
-					}
-					opResp[i].Count = count
-				} else {
-					value, ok := s.KeyValues[op.Key]
-					if ok {
-						opResp[i].KVs = append(opResp[i].KVs, KeyValue{
-							Key:           op.Key,
-							ValueRevision: value,
-						})
-						opResp[i].Count = 1
-					}
-				}
-			case Put:
-				_, leaseExists := s.Leases[op.LeaseID]
-				if op.LeaseID != 0 && !leaseExists {
-					break
-				}
-				s.KeyValues[op.Key] = ValueRevision{
-					Value:       op.Value,
-					ModRevision: s.Revision + 1,
-				}
-				increaseRevision = true
-				s = detachFromOldLease(s, op.Key)
-				if leaseExists {
-					s = attachToNewLease(s, op.LeaseID, op.Key)
-				}
-			case Delete:
-				if _, ok := s.KeyValues[op.Key]; ok {
-					delete(s.KeyValues, op.Key)
-					increaseRevision = true
-					s = detachFromOldLease(s, op.Key)
-					opResp[i].Deleted = 1
-				}
-			default:
-				panic("unsupported operation")
-			}
+		if !s.txnLeasesExist(request.Txn, readView) {
+			// etcd rejects whole transaction with lease not found error, so it cannot succeed.
+			return s, EtcdResponse{Revision: s.Revision}
 		}
+		var txnResp *TxnResponse
+		var increaseRevision bool
+		s, txnResp, increaseRevision = s.stepTxn(request.Txn, readView)
 		if increaseRevision {
 			s.Revision += 1
 		}
-		return s, EtcdResponse{Txn: &TxnResponse{OpsResult: opResp}, Revision: s.Revision}
+		return s, EtcdResponse{Txn: txnResp, Revision: s.Revision}
 	case LeaseGrant:
 		lease := EtcdLease{
 			LeaseID: request.LeaseGrant.LeaseID,
+			TTL:     request.LeaseGrant.ExpirationTTL(),
 			Keys:    map[string]struct{}{},
 		}
 		s.Leases[request.LeaseGrant.LeaseID] = lease
@@ -216,6 +180,7 @@ func (s etcdState) step(request EtcdRequest) (etcdState, EtcdResponse) {
 					keyDeleted = true
 				}
 				delete(s.KeyValues, key)
+				delete(s.KeyCreateRevisions, key)
 				delete(s.KeyLeases, key)
 			}
 		}
@@ -227,11 +192,199 @@ func (s etcdState) step(request EtcdRequest) (etcdState, EtcdResponse) {
 		return s, EtcdResponse{Revision: s.Revision, LeaseRevoke: &LeaseRevokeResponse{}}
 	case Defragment:
 		return s, EtcdResponse{Defragment: &DefragmentResponse{}, Revision: s.Revision}
+	case Compact:
+		// etcd rejects compaction of future or already compacted revision, so such compaction cannot succeed.
+		if request.Compact.Revision > s.Revision || request.Compact.Revision <= s.CompactRevision {
+			return s, EtcdResponse{Revision: s.Revision}
+		}
+		s.CompactRevision = request.Compact.Revision
+		return s, EtcdResponse{Compact: &CompactResponse{}, Revision: s.Revision}
 	default:
 		panic(fmt.Sprintf("Unknown request type: %v", request.Type))
 	}
 }
 
+// txnLeasesExist returns whether all leases that puts of the branch selected by conditions, including puts of nested
+// transactions, attach keys to exist. Like etcd, only branches that would be executed are checked.
+func (s etcdState) txnLeasesExist(request *TxnRequest, readView map[string]ValueRevision) bool {
+	ops := request.Ops
+	for _, cond := range request.Conds {
+		if !cond.matches(readView) {
+			ops = request.OpsOnFailure
+			break
+		}
+	}
+	for _, op := range ops {
+		switch op.Type {
+		case Put:
+			if _, ok := s.Leases[op.LeaseID]; op.LeaseID != 0 && !ok {
+				return false
+			}
+		case NestedTxn:
+			if !s.txnLeasesExist(op.Txn, readView) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stepTxn executes branch of transaction selected by its conditions evaluated against readView.
+// Nested transactions are executed recursively, with all their writes sharing revision of the top level transaction.
+func (s etcdState) stepTxn(request *TxnRequest, readView map[string]ValueRevision) (etcdState, *TxnResponse, bool) {
+	success := true
+	for _, cond := range request.Conds {
+		if !cond.matches(readView) {
+			success = false
+			break
+		}
+	}
+	ops := request.Ops
+	if !success {
+		if len(request.OpsOnFailure) == 0 {
+			return s, &TxnResponse{TxnResult: true}, false
+		}
+		ops = request.OpsOnFailure
+	}
+	opResp := make([]EtcdOperationResult, len(ops))
+	increaseRevision := false
+	for i, op := range ops {
+		switch op.Type {
+		case Range:
+			opResp[i] = EtcdOperationResult{
+				KVs: []KeyValue{},
+			}
+			if op.WithPrefix || op.End != "" {
+				var count int64
+				for k, v := range s.KeyValues {
+					if rangeContains(op, k) {
+						// Like etcd, count all keys in range, even ones filtered out by mod revision.
+						count += 1
+						if modRevisionInWindow(op, v.ModRevision) {
+							opResp[i].KVs = append(opResp[i].KVs, KeyValue{Key: k, ValueRevision: v})
+						}
+					}
+				}
+				sort.Slice(opResp[i].KVs, func(j, k int) bool {
+					return opResp[i].KVs[j].Key < opResp[i].KVs[k].Key
+				})
+				s.sortKeyValues(opResp[i].KVs, op.SortTarget, op.SortOrder)
+				if op.Limit != 0 && int64(len(opResp[i].KVs)) > op.Limit {
+					opResp[i].KVs = opResp[i].KVs[:op.Limit]
+				}
+				opResp[i].Count = count
+			} else {
+				value, ok := s.KeyValues[op.Key]
+				if ok {
+					if modRevisionInWindow(op, value.ModRevision) {
+						opResp[i].KVs = append(opResp[i].KVs, KeyValue{
+							Key:           op.Key,
+							ValueRevision: value,
+						})
+					}
+					opResp[i].Count = 1
+				}
+			}
+			if op.KeysOnly {
+				for j := range opResp[i].KVs {
+					opResp[i].KVs[j].Value = ValueOrHash{}
+				}
+			}
+		case Put:
+			_, leaseExists := s.Leases[op.LeaseID]
+			if op.LeaseID != 0 && !leaseExists {
+				break
+			}
+			if _, ok := s.KeyValues[op.Key]; !ok {
+				s.KeyCreateRevisions[op.Key] = s.Revision + 1
+			}
+			s.KeyValues[op.Key] = ValueRevision{
+				Value:       op.Value,
+				ModRevision: s.Revision + 1,
+			}
+			increaseRevision = true
+			s = detachFromOldLease(s, op.Key)
+			if leaseExists {
+				s = attachToNewLease(s, op.LeaseID, op.Key)
+			}
+		case Delete:
+			if op.WithPrefix || op.End != "" {
+				// Range delete atomically removes all keys in range at revision it's applied.
+				for k := range s.KeyValues {
+					if rangeContains(op, k) {
+						delete(s.KeyValues, k)
+						delete(s.KeyCreateRevisions, k)
+						s = detachFromOldLease(s, k)
+						opResp[i].Deleted++
+					}
+				}
+				increaseRevision = increaseRevision || opResp[i].Deleted != 0
+			} else if _, ok := s.KeyValues[op.Key]; ok {
+				delete(s.KeyValues, op.Key)
+				delete(s.KeyCreateRevisions, op.Key)
+				increaseRevision = true
+				s = detachFromOldLease(s, op.Key)
+				opResp[i].Deleted = 1
+			}
+		case NestedTxn:
+			var nestedIncreaseRevision bool
+			s, opResp[i].Txn, nestedIncreaseRevision = s.stepTxn(op.Txn, readView)
+			increaseRevision = increaseRevision || nestedIncreaseRevision
+		default:
+			panic("unsupported operation")
+		}
+	}
+	return s, &TxnResponse{TxnResult: !success, OpsResult: opResp}, increaseRevision
+}
+
+// sortKeyValues sorts key ordered kvs by target in given order. Sort is stable, so keys with equal target stay
+// in key order, same as in etcd, which for small ranges keeps order of keys it sorts by equal field.
+func (s etcdState) sortKeyValues(kvs []KeyValue, target SortTarget, order SortOrder) {
+	var field func(kv KeyValue) int64
+	switch target {
+	case "":
+		return
+	case SortByKey:
+		if order == SortDescend {
+			sort.SliceStable(kvs, func(i, j int) bool {
+				return kvs[i].Key > kvs[j].Key
+			})
+		}
+		return
+	case SortByCreateRevision:
+		field = func(kv KeyValue) int64 { return s.KeyCreateRevisions[kv.Key] }
+	case SortByModRevision:
+		field = func(kv KeyValue) int64 { return kv.ModRevision }
+	default:
+		panic(fmt.Sprintf("Unknown sort target: %q", target))
+	}
+	sort.SliceStable(kvs, func(i, j int) bool {
+		if order == SortDescend {
+			return field(kvs[i]) > field(kvs[j])
+		}
+		return field(kvs[i]) < field(kvs[j])
+	})
+}
+
+// rangeContains returns whether key is included in range read by operation.
+// modRevisionInWindow returns whether modRevision is within inclusive window of Range operation, zero bound is not checked.
+func modRevisionInWindow(op EtcdOperation, modRevision int64) bool {
+	if op.MinModRevision != 0 && modRevision < op.MinModRevision {
+		return false
+	}
+	if op.MaxModRevision != 0 && modRevision > op.MaxModRevision {
+		return false
+	}
+	return true
+}
+
+func rangeContains(op EtcdOperation, key string) bool {
+	if op.WithPrefix {
+		return strings.HasPrefix(key, op.Key)
+	}
+	return key >= op.Key && key < op.End
+}
+
 func detachFromOldLease(s etcdState, key string) etcdState {
 	if oldLeaseId, ok := s.KeyLeases[key]; ok {
 		delete(s.Leases[oldLeaseId].Keys, key)
@@ -253,6 +406,7 @@ const (
 	LeaseGrant  RequestType = "leaseGrant"
 	LeaseRevoke RequestType = "leaseRevoke"
 	Defragment  RequestType = "defragment"
+	Compact     RequestType = "compact"
 )
 
 type EtcdRequest struct {
@@ -261,34 +415,99 @@ type EtcdRequest struct {
 	LeaseRevoke *LeaseRevokeRequest
 	Txn         *TxnRequest
 	Defragment  *DefragmentRequest
+	Compact     *CompactRequest
+	// Tag is type of traffic request that produced the operation, it's ignored by model and used only to report
+	// coverage of traffic request types.
+	Tag string `json:",omitempty"`
 }
 
 type TxnRequest struct {
-	Conds []EtcdCondition
-	Ops   []EtcdOperation
+	Conds        []EtcdCondition
+	Ops          []EtcdOperation
+	OpsOnFailure []EtcdOperation
 }
 
 type EtcdCondition struct {
 	Key              string
 	ExpectedRevision int64
+	// ExpectedValue is compared with value of the key instead of ExpectedRevision if set. Missing key never matches it.
+	ExpectedValue *ValueOrHash `json:",omitempty"`
+}
+
+// matches returns true if value of condition key in readView meets the condition.
+func (c EtcdCondition) matches(readView map[string]ValueRevision) bool {
+	val := readView[c.Key]
+	if c.ExpectedValue != nil {
+		return val.ModRevision != 0 && val.Value == *c.ExpectedValue
+	}
+	return val.ModRevision == c.ExpectedRevision
 }
 
 type EtcdOperation struct {
 	Type       OperationType
 	Key        string
+	End        string
 	WithPrefix bool
 	Limit      int64
 	Value      ValueOrHash
 	LeaseID    int64
+	// SortTarget and SortOrder are set for Range operations that sort results before applying Limit.
+	SortTarget SortTarget
+	SortOrder  SortOrder
+	// MinModRevision and MaxModRevision, if not zero, limit Range results to keys with ModRevision in the inclusive window.
+	MinModRevision int64
+	MaxModRevision int64
+	// KeysOnly is set for Range operations that return keys with their metadata, but without values.
+	KeysOnly bool
+	// Txn is set for NestedTxn operations.
+	Txn *TxnRequest
 }
 
+type SortTarget string
+
+const (
+	SortByKey            SortTarget = "key"
+	SortByCreateRevision SortTarget = "create"
+	SortByModRevision    SortTarget = "mod"
+)
+
+type SortOrder string
+
+const (
+	SortAscend  SortOrder = "ascend"
+	SortDescend SortOrder = "descend"
+)
+
 type LeaseGrantRequest struct {
 	LeaseID int64
+	// TTL is the lease TTL requested by client.
+	TTL int64
+	// GrantedTTL is the lease TTL granted by server, which enforces minimal TTL, so it can be higher than requested.
+	// Zero if not known, as request failed.
+	GrantedTTL int64
 }
+
+// ExpirationTTL returns TTL after which lease can expire if not kept alive. Granted TTL is used when known,
+// otherwise requested one, as server never grants TTL lower than requested.
+func (r LeaseGrantRequest) ExpirationTTL() int64 {
+	if r.GrantedTTL != 0 {
+		return r.GrantedTTL
+	}
+	return r.TTL
+}
+
 type LeaseRevokeRequest struct {
 	LeaseID int64
 }
-type DefragmentRequest struct{}
+type DefragmentRequest struct {
+	// Endpoint of the member that was defragmented, empty if not known.
+	Endpoint string
+}
+type CompactRequest struct {
+	Revision int64
+	// Physical is set if request waited for compacted revisions to be physically removed from the backend.
+	Physical bool
+}
 
 type EtcdResponse struct {
 	Revision    int64
@@ -296,6 +515,7 @@ type EtcdResponse struct {
 	LeaseGrant  *LeaseGrantReponse
 	LeaseRevoke *LeaseRevokeResponse
 	Defragment  *DefragmentResponse
+	Compact     *CompactResponse
 }
 
 type TxnResponse struct {
@@ -308,11 +528,14 @@ type LeaseGrantReponse struct {
 }
 type LeaseRevokeResponse struct{}
 type DefragmentResponse struct{}
+type CompactResponse struct{}
 
 type EtcdOperationResult struct {
 	KVs     []KeyValue
 	Count   int64
 	Deleted int64
+	// Txn is set for NestedTxn operations, recording which branch was executed.
+	Txn *TxnResponse
 }
 
 type KeyValue struct {
@@ -324,7 +547,9 @@ var leased = struct{}{}
 
 type EtcdLease struct {
 	LeaseID int64
-	Keys    map[string]struct{}
+	// TTL after which lease can expire if not kept alive.
+	TTL  int64
+	Keys map[string]struct{}
 }
 
 type ValueRevision struct {
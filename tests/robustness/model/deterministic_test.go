@@ -15,6 +15,7 @@
 package model
 
 import (
+	"strings"
 	"testing"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -140,6 +141,165 @@ func TestModelBase(t *testing.T) {
 				}, 3, 3).EtcdResponse, failure: true},
 			},
 		},
+		{
+			name: "Sorted range should order keys by create and mod revision before applying limit",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key2", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key1", "2"), resp: putResponse(3).EtcdResponse},
+				{req: putRequest("key2", "3"), resp: putResponse(4).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByCreateRevision, SortAscend, 1), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("3"), ModRevision: 4},
+				}, 2, 4).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByCreateRevision, SortAscend, 1), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("2"), ModRevision: 3},
+				}, 2, 4).EtcdResponse, failure: true},
+				{req: sortedRangeRequest("key", true, SortByModRevision, SortAscend, 0), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("2"), ModRevision: 3},
+					{Key: []byte("key2"), Value: []byte("3"), ModRevision: 4},
+				}, 2, 4).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByModRevision, SortDescend, 1), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("3"), ModRevision: 4},
+				}, 2, 4).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByKey, SortDescend, 0), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("3"), ModRevision: 4},
+					{Key: []byte("key1"), Value: []byte("2"), ModRevision: 3},
+				}, 2, 4).EtcdResponse},
+			},
+		},
+		{
+			name: "Sorted range should keep keys with equal revision in key order",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key2", "1"), resp: putResponse(2).EtcdResponse},
+				{req: txnRequest(nil, []EtcdOperation{{Type: Put, Key: "key3", Value: ToValueOrHash("2")}, {Type: Put, Key: "key1", Value: ToValueOrHash("2")}}), resp: txnResponse([]EtcdOperationResult{{}, {}}, true, 3).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByCreateRevision, SortDescend, 2), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("2"), ModRevision: 3},
+					{Key: []byte("key3"), Value: []byte("2"), ModRevision: 3},
+				}, 3, 3).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByModRevision, SortAscend, 2), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("1"), ModRevision: 2},
+					{Key: []byte("key3"), Value: []byte("2"), ModRevision: 3},
+				}, 3, 3).EtcdResponse, failure: true},
+				{req: sortedRangeRequest("key", true, SortByModRevision, SortAscend, 2), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("1"), ModRevision: 2},
+					{Key: []byte("key1"), Value: []byte("2"), ModRevision: 3},
+				}, 3, 3).EtcdResponse},
+			},
+		},
+		{
+			name: "Sorted range by create revision should reflect key recreation",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key1", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(3).EtcdResponse},
+				{req: deleteRequest("key1"), resp: deleteResponse(1, 4).EtcdResponse},
+				{req: putRequest("key1", "3"), resp: putResponse(5).EtcdResponse},
+				{req: putRequest("key2", "4"), resp: putResponse(6).EtcdResponse},
+				{req: sortedRangeRequest("key", true, SortByCreateRevision, SortAscend, 1), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("3"), ModRevision: 5},
+				}, 2, 6).EtcdResponse, failure: true},
+				{req: sortedRangeRequest("key", true, SortByCreateRevision, SortAscend, 1), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("4"), ModRevision: 6},
+				}, 2, 6).EtcdResponse},
+			},
+		},
+		{
+			name: "Mod revision range should return exactly keys modified within inclusive window",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key1", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(3).EtcdResponse},
+				{req: putRequest("key3", "3"), resp: putResponse(4).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 2, 3), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 2},
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 3},
+				}, 3, 4).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 3, 3), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 3},
+				}, 3, 4).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 3, 0), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 3},
+					{Key: []byte("key3"), Value: []byte("3"), ModRevision: 4},
+				}, 3, 4).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 3, 3), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 3},
+					{Key: []byte("key3"), Value: []byte("3"), ModRevision: 4},
+				}, 3, 4).EtcdResponse, failure: true},
+				{req: modRevisionRangeRequest("key", true, 3, 4), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key3"), Value: []byte("3"), ModRevision: 4},
+				}, 3, 4).EtcdResponse, failure: true},
+				{req: modRevisionRangeRequest("key1", false, 2, 2), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 2},
+				}, 1, 4).EtcdResponse},
+			},
+		},
+		{
+			name: "Keys only range should return keys in range with metadata but without values",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key1", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key2", strings.Repeat("2", 100)), resp: putResponse(3).EtcdResponse},
+				{req: keysOnlyRangeRequest("key", true), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), ModRevision: 2},
+					{Key: []byte("key2"), ModRevision: 3},
+				}, 2, 3).EtcdResponse},
+				{req: keysOnlyRangeRequest("key2", false), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), ModRevision: 3},
+				}, 1, 3).EtcdResponse},
+				{req: keysOnlyRangeRequest("key", true), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 2},
+					{Key: []byte("key2"), ModRevision: 3},
+				}, 2, 3).EtcdResponse, failure: true},
+				{req: keysOnlyRangeRequest("key", true), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), ModRevision: 2},
+				}, 2, 3).EtcdResponse, failure: true},
+				{req: keysOnlyRangeRequest("key", true), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), ModRevision: 2},
+					{Key: []byte("key2"), ModRevision: 2},
+				}, 2, 3).EtcdResponse, failure: true},
+			},
+		},
+		{
+			name: "Mod revision range with empty window should return no keys but count all keys in range",
+			operations: []testOperation{
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1).EtcdResponse},
+				{req: putRequest("key1", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(3).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 3, 2), resp: rangeResponse(nil, 2, 3).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 4, 5), resp: rangeResponse(nil, 2, 3).EtcdResponse},
+				{req: modRevisionRangeRequest("key1", false, 3, 5), resp: rangeResponse(nil, 1, 3).EtcdResponse},
+				{req: modRevisionRangeRequest("key", true, 4, 5), resp: rangeResponse(nil, 0, 3).EtcdResponse, failure: true},
+				{req: modRevisionRangeRequest("key", true, 3, 2), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 3},
+				}, 2, 3).EtcdResponse, failure: true},
+			},
+		},
+		{
+			name: "Range snapshot should return exactly keys in range",
+			operations: []testOperation{
+				{req: putRequest("key1", "1"), resp: putResponse(1).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key3", "3"), resp: putResponse(3).EtcdResponse},
+				{req: rangeSnapshotRequest("key1", "key3", false), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 1},
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 2},
+					{Key: []byte("key3"), Value: []byte("3"), ModRevision: 3},
+				}, 3, 3).EtcdResponse, failure: true},
+				{req: rangeSnapshotRequest("key1", "key3", false), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 1},
+				}, 1, 3).EtcdResponse, failure: true},
+				{req: rangeSnapshotRequest("key1", "key3", false), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 1},
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 2},
+				}, 2, 3).EtcdResponse},
+				{req: rangeSnapshotRequest("key", "", true), resp: rangeResponse([]*mvccpb.KeyValue{
+					{Key: []byte("key1"), Value: []byte("1"), ModRevision: 1},
+					{Key: []byte("key2"), Value: []byte("2"), ModRevision: 2},
+					{Key: []byte("key3"), Value: []byte("3"), ModRevision: 3},
+				}, 3, 3).EtcdResponse},
+			},
+		},
 		{
 			name: "Range response data should match large put",
 			operations: []testOperation{
@@ -179,6 +339,24 @@ func TestModelBase(t *testing.T) {
 				{req: deleteRequest("key"), resp: deleteResponse(0, 1).EtcdResponse},
 			},
 		},
+		{
+			name: "Delete prefix removes exactly keys present at its revision",
+			operations: []testOperation{
+				{req: putRequest("key1", "1"), resp: putResponse(1).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("other", "3"), resp: putResponse(3).EtcdResponse},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(3, 4).EtcdResponse, failure: true},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(1, 4).EtcdResponse, failure: true},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(2, 4).EtcdResponse},
+				{req: getRequest("key1"), resp: getResponse("key1", "1", 1, 4).EtcdResponse, failure: true},
+				{req: getRequest("key1"), resp: emptyGetResponse(4).EtcdResponse},
+				{req: getRequest("other"), resp: getResponse("other", "3", 3, 4).EtcdResponse},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(0, 5).EtcdResponse, failure: true},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(0, 4).EtcdResponse},
+				{req: putRequest("key3", "4"), resp: putResponse(5).EtcdResponse},
+				{req: deletePrefixRequest("key"), resp: deleteResponse(1, 6).EtcdResponse},
+			},
+		},
 		{
 			name: "Delete clears value",
 			operations: []testOperation{
@@ -190,6 +368,18 @@ func TestModelBase(t *testing.T) {
 				{req: getRequest("key"), resp: emptyGetResponse(2).EtcdResponse},
 			},
 		},
+		{
+			name: "Get and put returns value committed just before put",
+			operations: []testOperation{
+				{req: getRequest("key"), resp: emptyGetResponse(1).EtcdResponse},
+				{req: getAndPutRequest("key", "1"), resp: getAndPutResponse(&mvccpb.KeyValue{Key: []byte("key"), Value: []byte("0"), ModRevision: 1}, 2).EtcdResponse, failure: true},
+				{req: getAndPutRequest("key", "1"), resp: getAndPutResponse(nil, 2).EtcdResponse},
+				{req: getAndPutRequest("key", "2"), resp: getAndPutResponse(nil, 3).EtcdResponse, failure: true},
+				{req: getAndPutRequest("key", "2"), resp: getAndPutResponse(&mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 2}, 3).EtcdResponse, failure: true},
+				{req: getAndPutRequest("key", "2"), resp: getAndPutResponse(&mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}, 3).EtcdResponse},
+				{req: getRequest("key"), resp: getResponse("key", "2", 3, 3).EtcdResponse},
+			},
+		},
 		{
 			name: "Txn sets new value if value matches expected",
 			operations: []testOperation{
@@ -205,6 +395,43 @@ func TestModelBase(t *testing.T) {
 				{req: getRequest("key"), resp: getResponse("key", "2", 2, 2).EtcdResponse},
 			},
 		},
+		{
+			name: "Txn swaps key only if all compared values match, otherwise reads them",
+			operations: []testOperation{
+				{req: putRequest("a", "1"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("b", "2"), resp: putResponse(3).EtcdResponse},
+				{req: compareMultiAndSwapRequest(map[string]string{"a": "1", "b": "3"}, "c", "z"), resp: txnResponse([]EtcdOperationResult{{}}, true, 4).EtcdResponse, failure: true},
+				{req: compareMultiAndSwapRequest(map[string]string{"a": "1", "b": "3"}, "c", "z"), resp: txnResponse([]EtcdOperationResult{
+					{KVs: []KeyValue{{Key: "a", ValueRevision: ValueRevision{Value: ToValueOrHash("1"), ModRevision: 2}}}, Count: 1},
+					{KVs: []KeyValue{{Key: "b", ValueRevision: ValueRevision{Value: ToValueOrHash("2"), ModRevision: 3}}}, Count: 1},
+					{KVs: []KeyValue{}},
+				}, false, 3).EtcdResponse},
+				{req: compareMultiAndSwapRequest(map[string]string{"a": "1", "b": "2"}, "c", "z"), resp: txnResponse([]EtcdOperationResult{{}}, true, 4).EtcdResponse},
+				{req: getRequest("c"), resp: getResponse("c", "z", 4, 4).EtcdResponse},
+			},
+		},
+		{
+			name: "Txn deletes key only if value matches expected",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(2).EtcdResponse},
+				{req: compareValueAndDeleteRequest("key", "2"), resp: compareAndDeleteResponse(true, 1, 3).EtcdResponse, failure: true},
+				{req: compareValueAndDeleteRequest("key", "2"), resp: compareAndDeleteResponse(false, 0, 2).EtcdResponse},
+				{req: getRequest("key"), resp: getResponse("key", "1", 2, 2).EtcdResponse},
+				{req: compareValueAndDeleteRequest("key", "1"), resp: compareAndDeleteResponse(false, 0, 2).EtcdResponse, failure: true},
+				{req: compareValueAndDeleteRequest("key", "1"), resp: compareAndDeleteResponse(true, 1, 3).EtcdResponse},
+				{req: getRequest("key"), resp: emptyGetResponse(3).EtcdResponse},
+			},
+		},
+		{
+			name: "Txn comparing value of missing key fails",
+			operations: []testOperation{
+				{req: getRequest("key"), resp: emptyGetResponse(1).EtcdResponse},
+				{req: compareValueAndDeleteRequest("key", "1"), resp: compareAndDeleteResponse(true, 0, 2).EtcdResponse, failure: true},
+				{req: compareValueAndDeleteRequest("key", "1"), resp: compareAndDeleteResponse(false, 0, 1).EtcdResponse},
+				{req: compareValueAndDeleteRequest("key", ""), resp: compareAndDeleteResponse(true, 0, 2).EtcdResponse, failure: true},
+				{req: compareValueAndDeleteRequest("key", ""), resp: compareAndDeleteResponse(false, 0, 1).EtcdResponse},
+			},
+		},
 		{
 			name: "Txn can expect on empty key",
 			operations: []testOperation{
@@ -250,6 +477,34 @@ func TestModelBase(t *testing.T) {
 				{req: getRequest("key"), resp: emptyGetResponse(3).EtcdResponse},
 			},
 		},
+		{
+			name: "Put with missing lease fails without changing revision",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(1).EtcdResponse},
+				{req: putWithLeaseRequest("key", "2", 1), resp: putResponse(1).EtcdResponse, failure: true},
+				{req: getRequest("key"), resp: getResponse("key", "1", 1, 1).EtcdResponse},
+			},
+		},
+		{
+			name: "Transaction checks lease only in executed branch and fails whole if it doesn't exist",
+			operations: []testOperation{
+				{req: leaseGrantRequest(1), resp: leaseGrantResponse(1).EtcdResponse},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key"}}, []EtcdOperation{{Type: Put, Key: "key", Value: ToValueOrHash("2"), LeaseID: 1}, {Type: Delete, Key: "other"}}, []EtcdOperation{{Type: Put, Key: "other", Value: ToValueOrHash("3"), LeaseID: 2}}), resp: txnResponse([]EtcdOperationResult{{}, {}}, true, 2).EtcdResponse},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key"}}, []EtcdOperation{{Type: Put, Key: "key", Value: ToValueOrHash("2"), LeaseID: 1}, {Type: Delete, Key: "other"}}, []EtcdOperation{{Type: Put, Key: "other", Value: ToValueOrHash("3"), LeaseID: 2}}), resp: txnResponse([]EtcdOperationResult{{}}, false, 3).EtcdResponse, failure: true},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key"}}, []EtcdOperation{{Type: Put, Key: "key", Value: ToValueOrHash("2"), LeaseID: 1}, {Type: Delete, Key: "other"}}, []EtcdOperation{{Type: Put, Key: "other", Value: ToValueOrHash("3"), LeaseID: 2}}), resp: txnResponse([]EtcdOperationResult{{}}, false, 2).EtcdResponse, failure: true},
+				{req: getRequest("other"), resp: emptyGetResponse(2).EtcdResponse},
+				{req: leaseRevokeRequest(1), resp: leaseRevokeResponse(3).EtcdResponse},
+				{req: getRequest("key"), resp: emptyGetResponse(3).EtcdResponse},
+			},
+		},
+		{
+			name: "Nested transaction put with missing lease fails whole transaction",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(1).EtcdResponse},
+				{req: txnRequest(nil, []EtcdOperation{{Type: Delete, Key: "key"}, {Type: NestedTxn, Txn: putWithLeaseRequest("other", "2", 1).Txn}}), resp: txnResponse([]EtcdOperationResult{{Deleted: 1}, {Txn: &TxnResponse{OpsResult: []EtcdOperationResult{{}}}}}, true, 2).EtcdResponse, failure: true},
+				{req: getRequest("key"), resp: getResponse("key", "1", 1, 1).EtcdResponse},
+			},
+		},
 		{
 			name: "Revoke should increment the revision",
 			operations: []testOperation{
@@ -331,6 +586,67 @@ func TestModelBase(t *testing.T) {
 				{req: getRequest("key4"), resp: emptyGetResponse(9).EtcdResponse},
 			},
 		},
+		{
+			name: "Nested txn executes branches selected by conditions evaluated before txn",
+			operations: []testOperation{
+				{req: putRequest("key1", "1"), resp: putResponse(1).EtcdResponse},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key1", ExpectedRevision: 1}}, []EtcdOperation{
+					{Type: Delete, Key: "key1"},
+					{Type: NestedTxn, Txn: conditionalTxnRequest([]EtcdCondition{{Key: "key1", ExpectedRevision: 1}},
+						[]EtcdOperation{{Type: Put, Key: "key2", Value: ValueOrHash{Value: "2"}}},
+						[]EtcdOperation{{Type: Put, Key: "key3", Value: ValueOrHash{Value: "3"}}}).Txn},
+				}, []EtcdOperation{{Type: Put, Key: "key4", Value: ValueOrHash{Value: "4"}}}), resp: txnResponse([]EtcdOperationResult{
+					{Deleted: 1},
+					{Txn: &TxnResponse{TxnResult: true, OpsResult: []EtcdOperationResult{{}}}},
+				}, true, 2).EtcdResponse, failure: true},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key1", ExpectedRevision: 1}}, []EtcdOperation{
+					{Type: Delete, Key: "key1"},
+					{Type: NestedTxn, Txn: conditionalTxnRequest([]EtcdCondition{{Key: "key1", ExpectedRevision: 1}},
+						[]EtcdOperation{{Type: Put, Key: "key2", Value: ValueOrHash{Value: "2"}}},
+						[]EtcdOperation{{Type: Put, Key: "key3", Value: ValueOrHash{Value: "3"}}}).Txn},
+				}, []EtcdOperation{{Type: Put, Key: "key4", Value: ValueOrHash{Value: "4"}}}), resp: txnResponse([]EtcdOperationResult{
+					{Deleted: 1},
+					{Txn: &TxnResponse{OpsResult: []EtcdOperationResult{{}}}},
+				}, true, 2).EtcdResponse},
+				{req: getRequest("key2"), resp: getResponse("key2", "2", 2, 2).EtcdResponse},
+				{req: getRequest("key3"), resp: emptyGetResponse(2).EtcdResponse},
+				{req: conditionalTxnRequest([]EtcdCondition{{Key: "key1", ExpectedRevision: 1}}, []EtcdOperation{
+					{Type: Put, Key: "key1", Value: ValueOrHash{Value: "1"}},
+				}, []EtcdOperation{{Type: Put, Key: "key4", Value: ValueOrHash{Value: "4"}}}), resp: txnResponse([]EtcdOperationResult{{}}, false, 3).EtcdResponse},
+				{req: getRequest("key4"), resp: getResponse("key4", "4", 3, 3).EtcdResponse},
+			},
+		},
+		{
+			name: "Txn with conditions over multiple keys succeeds only if all of them match",
+			operations: []testOperation{
+				{req: putRequest("key1", "1"), resp: putResponse(1).EtcdResponse},
+				{req: guardedTxnRequest(), resp: txnResponse([]EtcdOperationResult{
+					getResponse("key1", "1", 1, 1).Txn.OpsResult[0],
+					emptyGetResponse(1).Txn.OpsResult[0],
+				}, false, 1).EtcdResponse, failure: true},
+				{req: guardedTxnRequest(), resp: txnResponse([]EtcdOperationResult{{}}, true, 2).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(3).EtcdResponse},
+				{req: guardedTxnRequest(), resp: txnResponse([]EtcdOperationResult{{}}, true, 4).EtcdResponse, failure: true},
+				{req: guardedTxnRequest(), resp: txnResponse([]EtcdOperationResult{
+					getResponse("key1", "1", 1, 3).Txn.OpsResult[0],
+					getResponse("key2", "2", 3, 3).Txn.OpsResult[0],
+				}, false, 3).EtcdResponse},
+				{req: getRequest("key3"), resp: getResponse("key3", "3", 2, 3).EtcdResponse},
+			},
+		},
+		{
+			name: "Put without lease detaches key from lease, so revoke deletes only keys still attached",
+			operations: []testOperation{
+				{req: leaseGrantRequest(1), resp: leaseGrantResponse(1).EtcdResponse},
+				{req: putWithLeaseRequest("key1", "1", 1), resp: putResponse(2).EtcdResponse},
+				{req: putWithLeaseRequest("key2", "2", 1), resp: putResponse(3).EtcdResponse},
+				{req: putRequest("key2", "3"), resp: putResponse(4).EtcdResponse},
+				{req: leaseRevokeRequest(1), resp: leaseRevokeResponse(5).EtcdResponse},
+				{req: getRequest("key1"), resp: emptyGetResponse(5).EtcdResponse},
+				{req: getRequest("key2"), resp: emptyGetResponse(5).EtcdResponse, failure: true},
+				{req: getRequest("key2"), resp: getResponse("key2", "3", 4, 5).EtcdResponse},
+			},
+		},
 		{
 			name: "Lease some keys then delete all of them. Revoke should not increment",
 			operations: []testOperation{
@@ -379,6 +695,34 @@ func TestModelBase(t *testing.T) {
 				{req: defragmentRequest(), resp: defragmentResponse(6).EtcdResponse},
 			},
 		},
+		{
+			name: "Compaction succeeds only for revision above last compaction and not above current revision",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(1).EtcdResponse},
+				{req: putRequest("key", "2"), resp: putResponse(2).EtcdResponse},
+				{req: compactRequest(3, false), resp: compactResponse(2).EtcdResponse, failure: true},
+				{req: compactRequest(1, true), resp: compactResponse(2).EtcdResponse},
+				{req: compactRequest(1, false), resp: compactResponse(2).EtcdResponse, failure: true},
+				{req: compactRequest(2, false), resp: compactResponse(3).EtcdResponse, failure: true},
+				{req: compactRequest(2, false), resp: compactResponse(2).EtcdResponse},
+				{req: getRequest("key"), resp: getResponse("key", "2", 2, 2).EtcdResponse},
+				{req: putRequest("key", "3"), resp: putResponse(3).EtcdResponse},
+				{req: compactRequest(3, true), resp: compactResponse(3).EtcdResponse},
+			},
+		},
+		{
+			name: "Compaction doesn't change latest reads, including keys last written at compacted revision",
+			operations: []testOperation{
+				{req: putRequest("key1", "1"), resp: putResponse(1).EtcdResponse},
+				{req: putRequest("key2", "2"), resp: putResponse(2).EtcdResponse},
+				{req: putRequest("key2", "3"), resp: putResponse(3).EtcdResponse},
+				{req: compactRequest(3, true), resp: compactResponse(3).EtcdResponse},
+				{req: getRequest("key1"), resp: emptyGetResponse(3).EtcdResponse, failure: true},
+				{req: getRequest("key1"), resp: getResponse("key1", "1", 1, 3).EtcdResponse},
+				{req: getRequest("key2"), resp: getResponse("key2", "2", 2, 3).EtcdResponse, failure: true},
+				{req: getRequest("key2"), resp: getResponse("key2", "3", 3, 3).EtcdResponse},
+			},
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
@@ -397,3 +741,54 @@ func TestModelBase(t *testing.T) {
 		})
 	}
 }
+
+func TestLeaseGrantTTL(t *testing.T) {
+	tcs := []struct {
+		name        string
+		req         EtcdRequest
+		expectTTL   int64
+		expectLease int64
+	}{
+		{
+			name:        "Granted TTL higher than requested is used",
+			req:         leaseGrantWithTTLRequest(1, 1, 5),
+			expectTTL:   5,
+			expectLease: 1,
+		},
+		{
+			name:        "Granted TTL equal to requested is used",
+			req:         leaseGrantWithTTLRequest(2, 3, 3),
+			expectTTL:   3,
+			expectLease: 2,
+		},
+		{
+			name:        "Requested TTL is used if granted is not known",
+			req:         leaseGrantWithTTLRequest(3, 3, 0),
+			expectTTL:   3,
+			expectLease: 3,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			state := initState(putRequest("key", "1"), putResponse(1).EtcdResponse)
+			state, _ = state.step(tc.req)
+			lease, found := state.Leases[tc.expectLease]
+			if !found {
+				t.Fatalf("Lease %d not found in state: %+v", tc.expectLease, state)
+			}
+			if lease.TTL != tc.expectTTL {
+				t.Errorf("Unexpected lease TTL, expected: %d, got: %d", tc.expectTTL, lease.TTL)
+			}
+		})
+	}
+}
+
+// guardedTxnRequest puts key3 only if key1 has value "1" and key2 doesn't exist, reading both keys otherwise.
+func guardedTxnRequest() EtcdRequest {
+	value := ToValueOrHash("1")
+	return conditionalTxnRequest(
+		[]EtcdCondition{{Key: "key1", ExpectedValue: &value}, {Key: "key2", ExpectedRevision: 0}},
+		[]EtcdOperation{{Type: Put, Key: "key3", Value: ToValueOrHash("3")}},
+		[]EtcdOperation{{Type: Range, Key: "key1"}, {Type: Range, Key: "key2"}},
+	)
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// DurableKeyRead describes serializable read, served by a single member, of a key put once and never changed again.
+// Put was committed, so member that applied it must keep it, also after restart. Member that restarted can still be
+// catching up, replaying entries from its WAL, so read at revision lower than put revision is allowed not to find
+// the key.
+type DurableKeyRead struct {
+	Key         string
+	Value       string
+	PutRevision int64
+	// ReadKv is the key returned by read at ReadRevision, nil if key was not found.
+	ReadKv       *mvccpb.KeyValue
+	ReadRevision int64
+}
+
+// Validate returns error describing how read didn't observe the committed put, nil if it did.
+func (r DurableKeyRead) Validate() error {
+	if r.CatchingUp() {
+		if r.ReadKv != nil {
+			return fmt.Errorf("key %q put at revision %d found by read at lower revision %d", r.Key, r.PutRevision, r.ReadRevision)
+		}
+		return nil
+	}
+	if r.ReadKv == nil {
+		return fmt.Errorf("key %q put at revision %d not found by read at revision %d", r.Key, r.PutRevision, r.ReadRevision)
+	}
+	if r.ReadKv.ModRevision != r.PutRevision {
+		return fmt.Errorf("key %q put at revision %d read with mod revision %d at revision %d", r.Key, r.PutRevision, r.ReadKv.ModRevision, r.ReadRevision)
+	}
+	if string(r.ReadKv.Value) != r.Value {
+		return fmt.Errorf("key %q put at revision %d with value %q read with value %q at revision %d", r.Key, r.PutRevision, r.Value, string(r.ReadKv.Value), r.ReadRevision)
+	}
+	return nil
+}
+
+// CatchingUp returns whether member serving the read didn't apply the put yet.
+func (r DurableKeyRead) CatchingUp() bool {
+	return r.ReadRevision < r.PutRevision
+}
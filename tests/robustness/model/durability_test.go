@@ -0,0 +1,78 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestDurableKeyRead(t *testing.T) {
+	tcs := []struct {
+		name             string
+		read             DurableKeyRead
+		expectCatchingUp bool
+		expectError      string
+	}{
+		{
+			name: "Read of committed put",
+			read: DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 5,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 3}},
+		},
+		{
+			name:             "Member catching up didn't apply put yet",
+			read:             DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 2},
+			expectCatchingUp: true,
+		},
+		{
+			name: "Key found before its put",
+			read: DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 2,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 3}},
+			expectCatchingUp: true,
+			expectError:      `key "key" put at revision 3 found by read at lower revision 2`,
+		},
+		{
+			name:        "Committed put lost",
+			read:        DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 5},
+			expectError: `key "key" put at revision 3 not found by read at revision 5`,
+		},
+		{
+			name: "Committed put read with different mod revision",
+			read: DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 5,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 4}},
+			expectError: `key "key" put at revision 3 read with mod revision 4 at revision 5`,
+		},
+		{
+			name: "Committed put read with different value",
+			read: DurableKeyRead{Key: "key", Value: "1", PutRevision: 3, ReadRevision: 5,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 3}},
+			expectError: `key "key" put at revision 3 with value "1" read with value "2" at revision 5`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectCatchingUp, tc.read.CatchingUp())
+			err := tc.read.Validate()
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -15,7 +15,9 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
@@ -24,22 +26,67 @@ import (
 
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/tests/v3/robustness/identity"
 )
 
 // ValidateOperationHistoryAndReturnVisualize return visualize as porcupine.linearizationInfo used to generate visualization is private.
-func ValidateOperationHistoryAndReturnVisualize(t *testing.T, lg *zap.Logger, operations []porcupine.Operation) (visualize func(basepath string)) {
-	linearizable, info := porcupine.CheckOperationsVerbose(NonDeterministicModel, operations, 5*time.Minute)
-	if linearizable == porcupine.Illegal {
+// Linearizability is checked in windows configured by window, visualization then covers only the last checked window.
+func ValidateOperationHistoryAndReturnVisualize(t *testing.T, lg *zap.Logger, operations []porcupine.Operation, window CheckWindow) (visualize func(basepath string)) {
+	for _, violation := range ValidateRevisions(operations) {
+		t.Errorf("Broke revision invariant: %s", violation)
+	}
+	for _, violation := range ValidateCreateIfAbsent(operations) {
+		t.Errorf("Broke create-if-absent invariant: %s", violation)
+	}
+	for _, violation := range ValidateTxnLinearizationPoints(operations) {
+		t.Errorf("Broke txn linearization point invariant: %s", violation)
+	}
+	for _, violation := range ValidateCompareRevisionDeleteRetries(operations) {
+		t.Errorf("Broke compare revision delete idempotency invariant: %s", violation)
+	}
+	for _, violation := range ValidateNoSpuriousValues(operations) {
+		t.Errorf("Broke no spurious values invariant: %s", violation)
+	}
+	if err := window.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if window.Size == 0 {
+		_, visualize = validateLinearizability(t, lg, NonDeterministicModel, operations)
+		return visualize
+	}
+	splitter := newWindowSplitter(operations, window)
+	for {
+		w, ok := splitter.next()
+		if !ok {
+			return visualize
+		}
+		lg.Info("Checking linearizability of history window", zap.Duration("start", w.Start), zap.Duration("end", w.End), zap.Int64("revision", w.Revision), zap.Int("operations", len(w.Operations)))
+		var linearizable bool
+		linearizable, visualize = validateLinearizability(t, lg, w.model(), w.Operations)
+		if !linearizable {
+			t.Errorf("Linearizability violated in %s", w)
+			return visualize
+		}
+	}
+}
+
+// validateLinearizability checks operations against the model, returns whether they were proven linearizable.
+func validateLinearizability(t *testing.T, lg *zap.Logger, model porcupine.Model, operations []porcupine.Operation) (linearizable bool, visualize func(basepath string)) {
+	result, info := porcupine.CheckOperationsVerbose(model, operations, 5*time.Minute)
+	if result == porcupine.Illegal {
 		t.Error("Model is not linearizable")
+		if prefix, ok := findLinearizablePrefix(model, operations, time.Minute); ok {
+			t.Error(prefix.String())
+		}
 	}
-	if linearizable == porcupine.Unknown {
+	if result == porcupine.Unknown {
 		t.Error("Linearization timed out")
 	}
-	return func(path string) {
+	return result == porcupine.Ok, func(path string) {
 		lg.Info("Saving visualization", zap.String("path", path))
-		err := porcupine.VisualizePath(NonDeterministicModel, info, path)
+		err := porcupine.VisualizePath(model, info, path)
 		if err != nil {
 			t.Errorf("Failed to visualize, err: %v", err)
 		}
@@ -50,6 +97,14 @@ type AppendableHistory struct {
 	// id of the next write operation. If needed a new id might be requested from idProvider.
 	id         int
 	idProvider identity.Provider
+	// spill configures spilling of successful operations to disk, zero value keeps all operations in memory.
+	spill HistorySpillConfig
+	// spillFile is file operations of this history are spilled to, empty until first spill.
+	spillFile string
+	// lastRevision is the newest revision returned by successful operation.
+	lastRevision int64
+	// tag is set as EtcdRequest.Tag of appended operations.
+	tag string
 
 	History
 }
@@ -65,18 +120,62 @@ func NewAppendableHistory(ids identity.Provider) *AppendableHistory {
 	}
 }
 
-func (h *AppendableHistory) AppendRange(key string, withPrefix bool, start, end time.Duration, resp *clientv3.GetResponse) {
+// SetTag sets tag of operations appended from now on, empty tag stops tagging.
+func (h *AppendableHistory) SetTag(tag string) {
+	h.tag = tag
+}
+
+// LastRevision returns the newest revision returned by operation recorded in history.
+func (h *AppendableHistory) LastRevision() int64 {
+	return h.lastRevision
+}
+
+func (h *AppendableHistory) AppendRange(key string, withPrefix bool, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	h.appendRange(rangeRequest(key, withPrefix, 0), start, end, resp, err)
+}
+
+// AppendRangeSnapshot records range over [key, rangeEnd) or over key prefix as a single read operation,
+// allowing model to validate that all returned keys match state at response revision.
+func (h *AppendableHistory) AppendRangeSnapshot(key, rangeEnd string, withPrefix bool, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	h.appendRange(rangeSnapshotRequest(key, rangeEnd, withPrefix), start, end, resp, err)
+}
+
+// AppendSortedRange records range sorted by target in given order and truncated to limit.
+// Keys with equal target can be returned by etcd in any order, so they are recorded in key order.
+func (h *AppendableHistory) AppendSortedRange(key string, withPrefix bool, target clientv3.SortTarget, order clientv3.SortOrder, limit int64, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	request := sortedRangeRequest(key, withPrefix, toSortTarget(target), toSortOrder(order), limit)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	kvs := make([]*mvccpb.KeyValue, len(resp.Kvs))
+	copy(kvs, resp.Kvs)
+	sortTiesByKey(kvs, target)
+	h.appendRange(request, start, end, &clientv3.GetResponse{Header: resp.Header, Kvs: kvs, Count: resp.Count}, nil)
+}
+
+// AppendModRevisionRange records range filtered to keys with mod revision in inclusive [minModRev, maxModRev] window,
+// zero bound means the window is not bounded from that side.
+func (h *AppendableHistory) AppendModRevisionRange(key string, withPrefix bool, minModRev, maxModRev int64, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	h.appendRange(modRevisionRangeRequest(key, withPrefix, minModRev, maxModRev), start, end, resp, err)
+}
+
+// AppendKeysOnlyRange records range returning keys with their metadata, but without values.
+func (h *AppendableHistory) AppendKeysOnlyRange(key string, withPrefix bool, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	h.appendRange(keysOnlyRangeRequest(key, withPrefix), start, end, resp, err)
+}
+
+// appendRange records read, failed one is recorded only to show the attempt, as it doesn't change state.
+func (h *AppendableHistory) appendRange(request EtcdRequest, start, end time.Duration, resp *clientv3.GetResponse, err error) {
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
 	var revision int64
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    rangeRequest(key, withPrefix, 0),
-		Call:     start.Nanoseconds(),
-		Output:   rangeResponse(resp.Kvs, resp.Count, revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, rangeResponse(resp.Kvs, resp.Count, revision))
 }
 
 func (h *AppendableHistory) AppendPut(key, value string, start, end time.Duration, resp *clientv3.PutResponse, err error) {
@@ -89,13 +188,7 @@ func (h *AppendableHistory) AppendPut(key, value string, start, end time.Duratio
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   putResponse(revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, putResponse(revision))
 }
 
 func (h *AppendableHistory) AppendPutWithLease(key, value string, leaseID int64, start, end time.Duration, resp *clientv3.PutResponse, err error) {
@@ -108,21 +201,16 @@ func (h *AppendableHistory) AppendPutWithLease(key, value string, leaseID int64,
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   putResponse(revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, putResponse(revision))
 }
 
-func (h *AppendableHistory) AppendLeaseGrant(start, end time.Duration, resp *clientv3.LeaseGrantResponse, err error) {
-	var leaseID int64
+func (h *AppendableHistory) AppendLeaseGrant(ttl int64, start, end time.Duration, resp *clientv3.LeaseGrantResponse, err error) {
+	var leaseID, grantedTTL int64
 	if resp != nil {
 		leaseID = int64(resp.ID)
+		grantedTTL = resp.TTL
 	}
-	request := leaseGrantRequest(leaseID)
+	request := leaseGrantWithTTLRequest(leaseID, ttl, grantedTTL)
 	if err != nil {
 		h.appendFailed(request, start, err)
 		return
@@ -131,13 +219,7 @@ func (h *AppendableHistory) AppendLeaseGrant(start, end time.Duration, resp *cli
 	if resp != nil && resp.ResponseHeader != nil {
 		revision = resp.ResponseHeader.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   leaseGrantResponse(revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, leaseGrantResponse(revision))
 }
 
 func (h *AppendableHistory) AppendLeaseRevoke(id int64, start, end time.Duration, resp *clientv3.LeaseRevokeResponse, err error) {
@@ -150,13 +232,7 @@ func (h *AppendableHistory) AppendLeaseRevoke(id int64, start, end time.Duration
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   leaseRevokeResponse(revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, leaseRevokeResponse(revision))
 }
 
 func (h *AppendableHistory) AppendDelete(key string, start, end time.Duration, resp *clientv3.DeleteResponse, err error) {
@@ -171,13 +247,23 @@ func (h *AppendableHistory) AppendDelete(key string, start, end time.Duration, r
 		revision = resp.Header.Revision
 		deleted = resp.Deleted
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   deleteResponse(deleted, revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, deleteResponse(deleted, revision))
+}
+
+// AppendDeletePrefix records delete of all keys with prefix, together with number of keys it reported as deleted.
+func (h *AppendableHistory) AppendDeletePrefix(prefix string, start, end time.Duration, resp *clientv3.DeleteResponse, err error) {
+	request := deletePrefixRequest(prefix)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	var revision int64
+	var deleted int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+		deleted = resp.Deleted
+	}
+	h.appendSuccessful(request, start, end, deleteResponse(deleted, revision))
 }
 
 func (h *AppendableHistory) AppendCompareRevisionAndDelete(key string, expectedRevision int64, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
@@ -194,15 +280,26 @@ func (h *AppendableHistory) AppendCompareRevisionAndDelete(key string, expectedR
 	if resp != nil && len(resp.Responses) > 0 {
 		deleted = resp.Responses[0].GetResponseDeleteRange().Deleted
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   compareRevisionAndDeleteResponse(resp.Succeeded, deleted, revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, compareAndDeleteResponse(resp.Succeeded, deleted, revision))
 
 }
+func (h *AppendableHistory) AppendCompareValueAndDelete(key, expectedValue string, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
+	request := compareValueAndDeleteRequest(key, expectedValue)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	var revision int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+	}
+	var deleted int64
+	if resp != nil && len(resp.Responses) > 0 {
+		deleted = resp.Responses[0].GetResponseDeleteRange().Deleted
+	}
+	h.appendSuccessful(request, start, end, compareAndDeleteResponse(resp.Succeeded, deleted, revision))
+}
+
 func (h *AppendableHistory) AppendCompareRevisionAndPut(key string, expectedRevision int64, value string, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
 	request := compareRevisionAndPutRequest(key, expectedRevision, value)
 	if err != nil {
@@ -213,25 +310,56 @@ func (h *AppendableHistory) AppendCompareRevisionAndPut(key string, expectedRevi
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   compareRevisionAndPutResponse(resp.Succeeded, revision),
-		Return:   end.Nanoseconds(),
-	})
+	h.appendSuccessful(request, start, end, compareRevisionAndPutResponse(resp.Succeeded, revision))
 }
 
-func (h *AppendableHistory) AppendTxn(cmp []clientv3.Cmp, onSuccess []clientv3.Op, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
-	conds := []EtcdCondition{}
-	for _, cmp := range cmp {
-		conds = append(conds, toEtcdCondition(cmp))
+// AppendCreateIfAbsent records transaction that puts key only if it doesn't exist, outcome tells whether key was created.
+func (h *AppendableHistory) AppendCreateIfAbsent(key, value string, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
+	request := createIfAbsentRequest(key, value)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
 	}
-	ops := []EtcdOperation{}
-	for _, op := range onSuccess {
-		ops = append(ops, toEtcdOperation(op))
+	var revision int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
 	}
-	request := txnRequest(conds, ops)
+	h.appendSuccessful(request, start, end, createIfAbsentResponse(resp.Succeeded, revision))
+}
+
+func (h *AppendableHistory) AppendGetAndPut(key, value string, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
+	request := getAndPutRequest(key, value)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	var revision int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+	}
+	h.appendSuccessful(request, start, end, EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Txn: toTxnResponse(resp.Succeeded, resp.Responses), Revision: revision}})
+}
+
+func (h *AppendableHistory) AppendCompareMultiAndSwap(expected map[string]string, key, value string, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
+	request := compareMultiAndSwapRequest(expected, key, value)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	var revision int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+	}
+	h.appendSuccessful(request, start, end, EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Txn: toTxnResponse(resp.Succeeded, resp.Responses), Revision: revision}})
+}
+
+// AppendTxn records transaction. Transaction rejected for exceeding the number of operations allowed in a single
+// transaction is not recorded, as it's rejected before being proposed, so it's known to have no effect.
+func (h *AppendableHistory) AppendTxn(cmp []clientv3.Cmp, onSuccess []clientv3.Op, onFailure []clientv3.Op, start, end time.Duration, resp *clientv3.TxnResponse, err error) {
+	if errors.Is(err, rpctypes.ErrTooManyOps) {
+		return
+	}
+	request := EtcdRequest{Type: Txn, Txn: toTxnRequest(cmp, onSuccess, onFailure)}
 	if err != nil {
 		h.appendFailed(request, start, err)
 		return
@@ -240,25 +368,48 @@ func (h *AppendableHistory) AppendTxn(cmp []clientv3.Cmp, onSuccess []clientv3.O
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
+	h.appendSuccessful(request, start, end, EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Txn: toTxnResponse(resp.Succeeded, resp.Responses), Revision: revision}})
+}
+
+func toTxnRequest(cmp []clientv3.Cmp, onSuccess []clientv3.Op, onFailure []clientv3.Op) *TxnRequest {
+	conds := []EtcdCondition{}
+	for _, cmp := range cmp {
+		conds = append(conds, toEtcdCondition(cmp))
+	}
+	ops := []EtcdOperation{}
+	for _, op := range onSuccess {
+		ops = append(ops, toEtcdOperation(op))
+	}
+	var failureOps []EtcdOperation
+	for _, op := range onFailure {
+		failureOps = append(failureOps, toEtcdOperation(op))
+	}
+	return &TxnRequest{Conds: conds, Ops: ops, OpsOnFailure: failureOps}
+}
+
+func toTxnResponse(succeeded bool, responses []*etcdserverpb.ResponseOp) *TxnResponse {
+	// Model doesn't return results for failed transactions without failure branch.
+	if !succeeded && len(responses) == 0 {
+		return &TxnResponse{TxnResult: true}
+	}
 	results := []EtcdOperationResult{}
-	for _, resp := range resp.Responses {
+	for _, resp := range responses {
 		results = append(results, toEtcdOperationResult(resp))
 	}
-	h.successful = append(h.successful, porcupine.Operation{
-		ClientId: h.id,
-		Input:    request,
-		Call:     start.Nanoseconds(),
-		Output:   txnResponse(results, resp.Succeeded, revision),
-		Return:   end.Nanoseconds(),
-	})
+	return &TxnResponse{OpsResult: results, TxnResult: !succeeded}
 }
 
 func toEtcdCondition(cmp clientv3.Cmp) (cond EtcdCondition) {
 	switch {
 	case cmp.Result == etcdserverpb.Compare_EQUAL && cmp.Target == etcdserverpb.Compare_MOD:
 		cond.Key = string(cmp.KeyBytes())
+		cond.ExpectedRevision = cmp.TargetUnion.(*etcdserverpb.Compare_ModRevision).ModRevision
 	case cmp.Result == etcdserverpb.Compare_EQUAL && cmp.Target == etcdserverpb.Compare_CREATE:
 		cond.Key = string(cmp.KeyBytes())
+	case cmp.Result == etcdserverpb.Compare_EQUAL && cmp.Target == etcdserverpb.Compare_VALUE:
+		cond.Key = string(cmp.KeyBytes())
+		value := ToValueOrHash(string(cmp.ValueBytes()))
+		cond.ExpectedValue = &value
 	default:
 		panic(fmt.Sprintf("Compare not supported, target: %q, result: %q", cmp.Target, cmp.Result))
 	}
@@ -268,6 +419,11 @@ func toEtcdCondition(cmp clientv3.Cmp) (cond EtcdCondition) {
 func toEtcdOperation(op clientv3.Op) EtcdOperation {
 	var opType OperationType
 	switch {
+	case op.IsTxn():
+		return EtcdOperation{
+			Type: NestedTxn,
+			Txn:  toTxnRequest(op.Txn()),
+		}
 	case op.IsGet():
 		opType = Range
 	case op.IsPut():
@@ -278,9 +434,10 @@ func toEtcdOperation(op clientv3.Op) EtcdOperation {
 		panic("Unsupported operation")
 	}
 	return EtcdOperation{
-		Type:  opType,
-		Key:   string(op.KeyBytes()),
-		Value: ValueOrHash{Value: string(op.ValueBytes())},
+		Type:    opType,
+		Key:     string(op.KeyBytes()),
+		Value:   ValueOrHash{Value: string(op.ValueBytes())},
+		LeaseID: int64(op.LeaseID()),
 	}
 }
 
@@ -308,13 +465,36 @@ func toEtcdOperationResult(resp *etcdserverpb.ResponseOp) EtcdOperationResult {
 		return EtcdOperationResult{
 			Deleted: resp.GetResponseDeleteRange().Deleted,
 		}
+	case resp.GetResponseTxn() != nil:
+		txnResp := resp.GetResponseTxn()
+		return EtcdOperationResult{
+			Txn: toTxnResponse(txnResp.Succeeded, txnResp.Responses),
+		}
 	default:
 		panic("Unsupported operation")
 	}
 }
 
-func (h *AppendableHistory) AppendDefragment(start, end time.Duration, resp *clientv3.DefragmentResponse, err error) {
-	request := defragmentRequest()
+func (h *AppendableHistory) AppendDefragment(endpoint string, start, end time.Duration, resp *clientv3.DefragmentResponse, err error) {
+	request := defragmentMemberRequest(endpoint)
+	if err != nil {
+		h.appendFailed(request, start, err)
+		return
+	}
+	var revision int64
+	if resp != nil && resp.Header != nil {
+		revision = resp.Header.Revision
+	}
+	h.appendSuccessful(request, start, end, defragmentResponse(revision))
+}
+
+// AppendCompact records compaction of revision rev. Compaction rejected because revision was already compacted
+// or is in the future is not recorded, as it's known to have no effect, like failed reads.
+func (h *AppendableHistory) AppendCompact(rev int64, physical bool, start, end time.Duration, resp *clientv3.CompactResponse, err error) {
+	if errors.Is(err, rpctypes.ErrCompacted) || errors.Is(err, rpctypes.ErrFutureRev) {
+		return
+	}
+	request := compactRequest(rev, physical)
 	if err != nil {
 		h.appendFailed(request, start, err)
 		return
@@ -323,16 +503,31 @@ func (h *AppendableHistory) AppendDefragment(start, end time.Duration, resp *cli
 	if resp != nil && resp.Header != nil {
 		revision = resp.Header.Revision
 	}
+	h.appendSuccessful(request, start, end, compactResponse(revision))
+}
+
+func (h *AppendableHistory) appendSuccessful(request EtcdRequest, start, end time.Duration, response EtcdNonDeterministicResponse) {
+	if response.Revision > h.lastRevision {
+		h.lastRevision = response.Revision
+	}
+	request.Tag = h.tag
 	h.successful = append(h.successful, porcupine.Operation{
 		ClientId: h.id,
 		Input:    request,
 		Call:     start.Nanoseconds(),
-		Output:   defragmentResponse(revision),
+		Output:   response,
 		Return:   end.Nanoseconds(),
 	})
+	h.spillIfFull()
 }
 
 func (h *AppendableHistory) appendFailed(request EtcdRequest, start time.Duration, err error) {
+	// Request rejected because member ran out of space quota is not applied, whether it was rejected before being
+	// proposed or when applied, so it's known to have no effect and is not recorded.
+	if errors.Is(err, rpctypes.ErrNoSpace) {
+		return
+	}
+	request.Tag = h.tag
 	h.failed = append(h.failed, porcupine.Operation{
 		ClientId: h.id,
 		Input:    request,
@@ -353,6 +548,70 @@ func rangeRequest(key string, withPrefix bool, limit int64) EtcdRequest {
 	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Range, Key: key, WithPrefix: withPrefix, Limit: limit}}}}
 }
 
+func sortedRangeRequest(key string, withPrefix bool, target SortTarget, order SortOrder, limit int64) EtcdRequest {
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Range, Key: key, WithPrefix: withPrefix, Limit: limit, SortTarget: target, SortOrder: order}}}}
+}
+
+func modRevisionRangeRequest(key string, withPrefix bool, minModRev, maxModRev int64) EtcdRequest {
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Range, Key: key, WithPrefix: withPrefix, MinModRevision: minModRev, MaxModRevision: maxModRev}}}}
+}
+
+func keysOnlyRangeRequest(key string, withPrefix bool) EtcdRequest {
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Range, Key: key, WithPrefix: withPrefix, KeysOnly: true}}}}
+}
+
+func toSortTarget(target clientv3.SortTarget) SortTarget {
+	switch target {
+	case clientv3.SortByKey:
+		return SortByKey
+	case clientv3.SortByCreateRevision:
+		return SortByCreateRevision
+	case clientv3.SortByModRevision:
+		return SortByModRevision
+	default:
+		panic(fmt.Sprintf("Unsupported sort target: %v", target))
+	}
+}
+
+// toSortOrder converts sort order, treating no order as ascending, same as etcd does when sorting by field other than key.
+func toSortOrder(order clientv3.SortOrder) SortOrder {
+	if order == clientv3.SortDescend {
+		return SortDescend
+	}
+	return SortAscend
+}
+
+// sortTiesByKey orders each run of kvs with equal sort target by key, leaving order between runs unchanged.
+func sortTiesByKey(kvs []*mvccpb.KeyValue, target clientv3.SortTarget) {
+	var field func(kv *mvccpb.KeyValue) int64
+	switch target {
+	case clientv3.SortByCreateRevision:
+		field = func(kv *mvccpb.KeyValue) int64 { return kv.CreateRevision }
+	case clientv3.SortByModRevision:
+		field = func(kv *mvccpb.KeyValue) int64 { return kv.ModRevision }
+	default:
+		return
+	}
+	for start := 0; start < len(kvs); {
+		end := start + 1
+		for end < len(kvs) && field(kvs[end]) == field(kvs[start]) {
+			end++
+		}
+		run := kvs[start:end]
+		sort.Slice(run, func(i, j int) bool {
+			return string(run[i].Key) < string(run[j].Key)
+		})
+		start = end
+	}
+}
+
+func rangeSnapshotRequest(key, end string, withPrefix bool) EtcdRequest {
+	if withPrefix {
+		end = ""
+	}
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Range, Key: key, End: end, WithPrefix: withPrefix}}}}
+}
+
 func emptyGetResponse(revision int64) EtcdNonDeterministicResponse {
 	return rangeResponse([]*mvccpb.KeyValue{}, 0, revision)
 }
@@ -362,7 +621,8 @@ func getResponse(key, value string, modRevision, revision int64) EtcdNonDetermin
 }
 
 func rangeResponse(kvs []*mvccpb.KeyValue, count int64, revision int64) EtcdNonDeterministicResponse {
-	result := EtcdOperationResult{KVs: make([]KeyValue, len(kvs))}
+	// Count is set even if no key is returned, as keys filtered out by mod revision are still counted.
+	result := EtcdOperationResult{KVs: make([]KeyValue, len(kvs)), Count: count}
 
 	for i, kv := range kvs {
 		result.KVs[i] = KeyValue{
@@ -372,7 +632,6 @@ func rangeResponse(kvs []*mvccpb.KeyValue, count int64, revision int64) EtcdNonD
 				ModRevision: kv.ModRevision,
 			},
 		}
-		result.Count = count
 	}
 	return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Txn: &TxnResponse{OpsResult: []EtcdOperationResult{result}}, Revision: revision}}
 }
@@ -397,6 +656,10 @@ func deleteRequest(key string) EtcdRequest {
 	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Delete, Key: key}}}}
 }
 
+func deletePrefixRequest(prefix string) EtcdRequest {
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Ops: []EtcdOperation{{Type: Delete, Key: prefix, WithPrefix: true}}}}
+}
+
 func deleteResponse(deleted int64, revision int64) EtcdNonDeterministicResponse {
 	return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Txn: &TxnResponse{OpsResult: []EtcdOperationResult{{Deleted: deleted}}}, Revision: revision}}
 }
@@ -405,10 +668,57 @@ func compareRevisionAndDeleteRequest(key string, expectedRevision int64) EtcdReq
 	return txnRequest([]EtcdCondition{{Key: key, ExpectedRevision: expectedRevision}}, []EtcdOperation{{Type: Delete, Key: key}})
 }
 
+func compareValueAndDeleteRequest(key, expectedValue string) EtcdRequest {
+	value := ToValueOrHash(expectedValue)
+	return txnRequest([]EtcdCondition{{Key: key, ExpectedValue: &value}}, []EtcdOperation{{Type: Delete, Key: key}})
+}
+
 func compareRevisionAndPutRequest(key string, expectedRevision int64, value string) EtcdRequest {
 	return txnRequest([]EtcdCondition{{Key: key, ExpectedRevision: expectedRevision}}, []EtcdOperation{{Type: Put, Key: key, Value: ToValueOrHash(value)}})
 }
 
+func createIfAbsentRequest(key, value string) EtcdRequest {
+	return txnRequest([]EtcdCondition{{Key: key}}, []EtcdOperation{{Type: Put, Key: key, Value: ToValueOrHash(value)}})
+}
+
+func createIfAbsentResponse(created bool, revision int64) EtcdNonDeterministicResponse {
+	return compareRevisionAndPutResponse(created, revision)
+}
+
+// compareMultiAndSwapRequest is transaction sent by clientv3util.CompareMultiAndSwap, comparing keys in sorted order
+// and reading them followed by the swapped key on failure.
+func compareMultiAndSwapRequest(expected map[string]string, key, value string) EtcdRequest {
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	conds := make([]EtcdCondition, 0, len(keys))
+	reads := make([]EtcdOperation, 0, len(keys)+1)
+	for _, k := range keys {
+		expectedValue := ToValueOrHash(expected[k])
+		conds = append(conds, EtcdCondition{Key: k, ExpectedValue: &expectedValue})
+		reads = append(reads, EtcdOperation{Type: Range, Key: k})
+	}
+	if _, ok := expected[key]; !ok {
+		reads = append(reads, EtcdOperation{Type: Range, Key: key})
+	}
+	return conditionalTxnRequest(conds, []EtcdOperation{{Type: Put, Key: key, Value: ToValueOrHash(value)}}, reads)
+}
+
+func getAndPutRequest(key, value string) EtcdRequest {
+	return txnRequest(nil, []EtcdOperation{{Type: Range, Key: key}, {Type: Put, Key: key, Value: ToValueOrHash(value)}})
+}
+
+func getAndPutResponse(prev *mvccpb.KeyValue, revision int64) EtcdNonDeterministicResponse {
+	kvs := []*mvccpb.KeyValue{}
+	if prev != nil {
+		kvs = append(kvs, prev)
+	}
+	get := rangeResponse(kvs, int64(len(kvs)), revision).Txn.OpsResult[0]
+	return txnResponse([]EtcdOperationResult{get, {}}, true, revision)
+}
+
 func compareRevisionAndPutResponse(succeeded bool, revision int64) EtcdNonDeterministicResponse {
 	var result []EtcdOperationResult
 	if succeeded {
@@ -417,7 +727,7 @@ func compareRevisionAndPutResponse(succeeded bool, revision int64) EtcdNonDeterm
 	return txnResponse(result, succeeded, revision)
 }
 
-func compareRevisionAndDeleteResponse(succeeded bool, deleted, revision int64) EtcdNonDeterministicResponse {
+func compareAndDeleteResponse(succeeded bool, deleted, revision int64) EtcdNonDeterministicResponse {
 	var result []EtcdOperationResult
 	if succeeded {
 		result = []EtcdOperationResult{{Deleted: deleted}}
@@ -426,7 +736,11 @@ func compareRevisionAndDeleteResponse(succeeded bool, deleted, revision int64) E
 }
 
 func txnRequest(conds []EtcdCondition, onSuccess []EtcdOperation) EtcdRequest {
-	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Conds: conds, Ops: onSuccess}}
+	return conditionalTxnRequest(conds, onSuccess, nil)
+}
+
+func conditionalTxnRequest(conds []EtcdCondition, onSuccess, onFailure []EtcdOperation) EtcdRequest {
+	return EtcdRequest{Type: Txn, Txn: &TxnRequest{Conds: conds, Ops: onSuccess, OpsOnFailure: onFailure}}
 }
 
 func txnResponse(result []EtcdOperationResult, succeeded bool, revision int64) EtcdNonDeterministicResponse {
@@ -441,6 +755,10 @@ func leaseGrantRequest(leaseID int64) EtcdRequest {
 	return EtcdRequest{Type: LeaseGrant, LeaseGrant: &LeaseGrantRequest{LeaseID: leaseID}}
 }
 
+func leaseGrantWithTTLRequest(leaseID, ttl, grantedTTL int64) EtcdRequest {
+	return EtcdRequest{Type: LeaseGrant, LeaseGrant: &LeaseGrantRequest{LeaseID: leaseID, TTL: ttl, GrantedTTL: grantedTTL}}
+}
+
 func leaseGrantResponse(revision int64) EtcdNonDeterministicResponse {
 	return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{LeaseGrant: &LeaseGrantReponse{}, Revision: revision}}
 }
@@ -454,36 +772,64 @@ func leaseRevokeResponse(revision int64) EtcdNonDeterministicResponse {
 }
 
 func defragmentRequest() EtcdRequest {
-	return EtcdRequest{Type: Defragment, Defragment: &DefragmentRequest{}}
+	return defragmentMemberRequest("")
+}
+
+func defragmentMemberRequest(endpoint string) EtcdRequest {
+	return EtcdRequest{Type: Defragment, Defragment: &DefragmentRequest{Endpoint: endpoint}}
 }
 
 func defragmentResponse(revision int64) EtcdNonDeterministicResponse {
 	return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Defragment: &DefragmentResponse{}, Revision: revision}}
 }
 
+func compactRequest(rev int64, physical bool) EtcdRequest {
+	return EtcdRequest{Type: Compact, Compact: &CompactRequest{Revision: rev, Physical: physical}}
+}
+
+func compactResponse(revision int64) EtcdNonDeterministicResponse {
+	return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Compact: &CompactResponse{}, Revision: revision}}
+}
+
 type History struct {
 	successful []porcupine.Operation
 	// failed requests are kept separate as we don't know return time of failed operations.
 	// Based on https://github.com/anishathalye/porcupine/issues/10
 	failed []porcupine.Operation
+	// spilled are files with successful operations that were moved out of memory.
+	spilled []string
 }
 
 func (h History) Merge(h2 History) History {
 	result := History{
 		successful: make([]porcupine.Operation, 0, len(h.successful)+len(h2.successful)),
 		failed:     make([]porcupine.Operation, 0, len(h.failed)+len(h2.failed)),
+		spilled:    make([]string, 0, len(h.spilled)+len(h2.spilled)),
 	}
 	result.successful = append(result.successful, h.successful...)
 	result.successful = append(result.successful, h2.successful...)
 	result.failed = append(result.failed, h.failed...)
 	result.failed = append(result.failed, h2.failed...)
+	result.spilled = append(result.spilled, h.spilled...)
+	result.spilled = append(result.spilled, h2.spilled...)
 	return result
 }
 
+// Operations returns all operations of the history, reading back ones that were spilled to disk,
+// as linearization checking requires whole history.
 func (h History) Operations() []porcupine.Operation {
-	operations := make([]porcupine.Operation, 0, len(h.successful)+len(h.failed))
+	successful := h.successful
+	if len(h.spilled) != 0 {
+		var err error
+		successful, err = readSpilledOperations(h.spilled)
+		if err != nil {
+			panic(err)
+		}
+		successful = append(successful, h.successful...)
+	}
+	operations := make([]porcupine.Operation, 0, len(successful)+len(h.failed))
 	var maxTime int64
-	for _, op := range h.successful {
+	for _, op := range successful {
 		operations = append(operations, op)
 		if op.Return > maxTime {
 			maxTime = op.Return
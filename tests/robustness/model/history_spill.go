@@ -0,0 +1,131 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// HistorySpillConfig configures AppendableHistory to keep only recent operations in memory,
+// allowing long runs to record more operations than would fit into memory.
+type HistorySpillConfig struct {
+	// Dir is directory where spilled operations are written.
+	Dir string
+	// MaxOperations is number of successful operations kept in memory, when exceeded they are appended
+	// to a file in Dir. Zero disables spilling.
+	MaxOperations int
+}
+
+// SpillTo configures history to spill successful operations to disk. Successful operations are settled,
+// as their return time is known. Failed operations are kept in memory as their return time is set only
+// when whole history is known, there are few of them compared to successful ones.
+func (h *AppendableHistory) SpillTo(config HistorySpillConfig) {
+	h.spill = config
+	h.spillIfFull()
+}
+
+func (h *AppendableHistory) spillIfFull() {
+	if h.spill.MaxOperations == 0 || len(h.successful) <= h.spill.MaxOperations {
+		return
+	}
+	if h.spillFile == "" {
+		f, err := os.CreateTemp(h.spill.Dir, "history-*.jsonl")
+		if err != nil {
+			panic(err)
+		}
+		f.Close()
+		h.spillFile = f.Name()
+		h.spilled = append(h.spilled, h.spillFile)
+	}
+	err := writeSpilledOperations(h.spillFile, h.successful)
+	if err != nil {
+		panic(err)
+	}
+	h.successful = []porcupine.Operation{}
+}
+
+// spilledOperation is porcupine.Operation of successful request with input and output of concrete types,
+// encoded as a single JSON line, so spilled history can be appended to and read back in a streaming way.
+type spilledOperation struct {
+	ClientId      int
+	Input         EtcdRequest
+	Call          int64
+	Output        EtcdResponse
+	ResultUnknown bool `json:",omitempty"`
+	Return        int64
+}
+
+func writeSpilledOperations(path string, operations []porcupine.Operation) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, op := range operations {
+		response := op.Output.(EtcdNonDeterministicResponse)
+		if response.Err != nil {
+			return fmt.Errorf("cannot spill failed operation, err: %v", response.Err)
+		}
+		err = encoder.Encode(spilledOperation{
+			ClientId:      op.ClientId,
+			Input:         op.Input.(EtcdRequest),
+			Call:          op.Call,
+			Output:        response.EtcdResponse,
+			ResultUnknown: response.ResultUnknown,
+			Return:        op.Return,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func readSpilledOperations(paths []string) ([]porcupine.Operation, error) {
+	operations := []porcupine.Operation{}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bufio.NewReader(f))
+		for decoder.More() {
+			var op spilledOperation
+			if err = decoder.Decode(&op); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to read spilled operations from %q, err: %w", path, err)
+			}
+			operations = append(operations, porcupine.Operation{
+				ClientId: op.ClientId,
+				Input:    op.Input,
+				Call:     op.Call,
+				Output:   EtcdNonDeterministicResponse{EtcdResponse: op.Output, ResultUnknown: op.ResultUnknown},
+				Return:   op.Return,
+			})
+		}
+		f.Close()
+	}
+	return operations, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+func TestHistorySpill(t *testing.T) {
+	record := func(h *AppendableHistory) {
+		header := &etcdserverpb.ResponseHeader{Revision: 2}
+		h.AppendPut("key", "1", 1, 2, &clientv3.PutResponse{Header: header}, nil)
+		h.AppendRange("key", false, 3, 4, &clientv3.GetResponse{Header: header, Kvs: []*mvccpb.KeyValue{{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}}, Count: 1}, nil)
+		h.AppendRange("none", true, 5, 6, &clientv3.GetResponse{Header: header}, nil)
+		h.AppendPut("key", "2", 7, 0, nil, errors.New("failed"))
+		h.AppendCompareRevisionAndPut("key", 2, "3", 8, 9, &clientv3.TxnResponse{Header: &etcdserverpb.ResponseHeader{Revision: 3}, Succeeded: true}, nil)
+		h.AppendDefragment("", 10, 11, &clientv3.DefragmentResponse{Header: header}, nil)
+		h.AppendCompact(2, true, 12, 13, &clientv3.CompactResponse{Header: header}, nil)
+		h.AppendLeaseRevoke(1, 14, 15, nil, errors.New("failed"))
+		h.AppendDelete("key", 16, 17, &clientv3.DeleteResponse{Header: &etcdserverpb.ResponseHeader{Revision: 4}, Deleted: 1}, nil)
+	}
+	inMemory := NewAppendableHistory(identity.NewIdProvider())
+	record(inMemory)
+	other := NewAppendableHistory(identity.NewIdProvider())
+	other.AppendPut("other", "1", 20, 21, &clientv3.PutResponse{Header: &etcdserverpb.ResponseHeader{Revision: 5}}, nil)
+
+	spilling := NewAppendableHistory(identity.NewIdProvider())
+	spilling.SpillTo(HistorySpillConfig{Dir: t.TempDir(), MaxOperations: 2})
+	record(spilling)
+	assert.Len(t, spilling.spilled, 1)
+	assert.LessOrEqual(t, len(spilling.successful), 2)
+
+	expect := inMemory.Merge(other.History).Operations()
+	got := spilling.Merge(other.History).Operations()
+	assert.Equal(t, expect, got)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+func TestAppendTxnRejected(t *testing.T) {
+	tcs := []struct {
+		name          string
+		err           error
+		expectRecords int
+	}{
+		{
+			name:          "Txn rejected for too many operations is not recorded, as it has no effect",
+			err:           rpctypes.ErrTooManyOps,
+			expectRecords: 0,
+		},
+		{
+			name:          "Txn rejected for exceeding space quota is not recorded, as it has no effect",
+			err:           rpctypes.ErrNoSpace,
+			expectRecords: 0,
+		},
+		{
+			name:          "Txn failed with other error is recorded, as it might have been persisted",
+			err:           errors.New("failed"),
+			expectRecords: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewAppendableHistory(identity.NewIdProvider())
+			cmp := []clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision("key"), "=", 0)}
+			h.AppendTxn(cmp, []clientv3.Op{clientv3.OpPut("key", "1")}, nil, 1, 2, nil, tc.err)
+			assert.Len(t, h.Operations(), tc.expectRecords)
+		})
+	}
+}
+
+func TestAppendPutRejectedForNoSpace(t *testing.T) {
+	h := NewAppendableHistory(identity.NewIdProvider())
+	h.AppendPut("key", "1", 1, 2, nil, rpctypes.ErrNoSpace)
+	h.AppendPut("key", "2", 3, 4, nil, errors.New("failed"))
+	operations := h.Operations()
+	assert.Len(t, operations, 1)
+	assert.Equal(t, putRequest("key", "2"), operations[0].Input)
+}
+
+func TestAppendableHistoryLastRevision(t *testing.T) {
+	h := NewAppendableHistory(identity.NewIdProvider())
+	assert.Equal(t, int64(0), h.LastRevision())
+	h.AppendPut("key", "1", 1, 2, &clientv3.PutResponse{Header: &etcdserverpb.ResponseHeader{Revision: 3}}, nil)
+	assert.Equal(t, int64(3), h.LastRevision())
+	h.AppendRange("key", false, 3, 4, &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: 2}}, nil)
+	assert.Equal(t, int64(3), h.LastRevision(), "older revision returned by other member doesn't move last revision back")
+	h.AppendPut("key", "2", 5, 6, nil, errors.New("failed"))
+	assert.Equal(t, int64(3), h.LastRevision(), "failed operation has no revision")
+}
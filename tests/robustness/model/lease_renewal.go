@@ -0,0 +1,111 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultLeaseClockTolerance is the default tolerance of lease expiry checks, one unit of lease TTL granularity.
+// Server grants TTL and reports remaining TTL in whole seconds, and measures it with its own clock, so lease can expire
+// up to a second sooner or later than client measuring gaps between renewals with its clock would expect. Lower
+// tolerance makes checks stricter, but flaky on hosts with coarse clocks.
+const DefaultLeaseClockTolerance = time.Second
+
+// LeaseRenewal records renewals of a single lease followed by a check whether it expired.
+type LeaseRenewal struct {
+	LeaseID int64
+	// TTL is the TTL granted by server.
+	TTL time.Duration
+	// Renewals are the grant followed by keepalives, in order they were sent.
+	Renewals []LeaseRenewalCall
+	// Check observed lease after renewals, either by reading its TTL or by keepalive failing as lease was not found.
+	Check LeaseCheck
+}
+
+// LeaseRenewalCall reset TTL of lease at some moment between Call and Return, unless it failed with unknown result.
+type LeaseRenewalCall struct {
+	Call   time.Time
+	Return time.Time
+	// Unknown is set if renewal failed, so TTL might or might not have been reset.
+	Unknown bool
+}
+
+// LeaseCheck observed state of lease at some moment between Call and Return.
+type LeaseCheck struct {
+	Call    time.Time
+	Return  time.Time
+	Expired bool
+}
+
+// ValidateLeaseRenewal checks that lease expired if and only if there was gap between its renewals, or between the last
+// renewal and the check, exceeding its TTL. Lease that expired must have had gap that could exceed TTL reduced
+// by clockTolerance, lease that didn't expire can't have gap that certainly exceeded TTL increased by clockTolerance
+// and revokeDelay, the time for server to revoke expired lease. So lease is reported only if it expired or stayed
+// alive outside of the tolerance window around its TTL, clockTolerance as large as TTL disables reporting expired
+// leases. Renewal with unknown result is considered to have reset TTL only when it makes the gap shorter.
+// Returns error reporting the lease and the gap on violation.
+func ValidateLeaseRenewal(r LeaseRenewal, clockTolerance, revokeDelay time.Duration) error {
+	if len(r.Renewals) == 0 {
+		return nil
+	}
+	if r.Check.Expired {
+		longest := longestPossibleGap(r)
+		if longest <= r.TTL-clockTolerance {
+			return fmt.Errorf("lease %x expired, but its longest keepalive gap %v didn't exceed TTL %v reduced by clock tolerance %v", r.LeaseID, longest, r.TTL, clockTolerance)
+		}
+		return nil
+	}
+	gap := longestCertainGap(r)
+	if gap > r.TTL+clockTolerance+revokeDelay {
+		return fmt.Errorf("lease %x didn't expire, but its keepalive gap %v exceeded TTL %v increased by clock tolerance %v and revoke delay %v", r.LeaseID, gap, r.TTL, clockTolerance, revokeDelay)
+	}
+	return nil
+}
+
+// longestPossibleGap returns the longest gap renewals of lease could have, assuming renewals with unknown result didn't happen.
+func longestPossibleGap(r LeaseRenewal) time.Duration {
+	var longest time.Duration
+	var last *LeaseRenewalCall
+	for i := range r.Renewals {
+		renewal := &r.Renewals[i]
+		if renewal.Unknown {
+			continue
+		}
+		if last != nil && renewal.Return.Sub(last.Call) > longest {
+			longest = renewal.Return.Sub(last.Call)
+		}
+		last = renewal
+	}
+	if last != nil && r.Check.Return.Sub(last.Call) > longest {
+		longest = r.Check.Return.Sub(last.Call)
+	}
+	return longest
+}
+
+// longestCertainGap returns the longest gap renewals of lease certainly had, assuming renewals with unknown result happened.
+func longestCertainGap(r LeaseRenewal) time.Duration {
+	var longest time.Duration
+	for i := 1; i < len(r.Renewals); i++ {
+		if gap := r.Renewals[i].Call.Sub(r.Renewals[i-1].Return); gap > longest {
+			longest = gap
+		}
+	}
+	if gap := r.Check.Call.Sub(r.Renewals[len(r.Renewals)-1].Return); gap > longest {
+		longest = gap
+	}
+	return longest
+}
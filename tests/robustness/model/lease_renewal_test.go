@@ -0,0 +1,166 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLeaseRenewal(t *testing.T) {
+	base := time.Now()
+	at := func(seconds float64) time.Time {
+		return base.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	renewal := func(call, ret float64) LeaseRenewalCall {
+		return LeaseRenewalCall{Call: at(call), Return: at(ret)}
+	}
+	unknown := func(call, ret float64) LeaseRenewalCall {
+		return LeaseRenewalCall{Call: at(call), Return: at(ret), Unknown: true}
+	}
+	check := func(call, ret float64, expired bool) LeaseCheck {
+		return LeaseCheck{Call: at(call), Return: at(ret), Expired: expired}
+	}
+	tcs := []struct {
+		name        string
+		renewals    []LeaseRenewalCall
+		check       LeaseCheck
+		expectError bool
+	}{
+		{
+			name:     "Lease kept alive persists",
+			renewals: []LeaseRenewalCall{renewal(0, 0.1), renewal(1, 1.1), renewal(2, 2.1), renewal(3, 3.1)},
+			check:    check(3.5, 3.6, false),
+		},
+		{
+			name:        "Lease kept alive expired",
+			renewals:    []LeaseRenewalCall{renewal(0, 0.1), renewal(1, 1.1), renewal(2, 2.1), renewal(3, 3.1)},
+			check:       check(3.5, 3.6, true),
+			expectError: true,
+		},
+		{
+			name:     "Lease not kept alive expired",
+			renewals: []LeaseRenewalCall{renewal(0, 0.1), renewal(1, 1.1)},
+			check:    check(3.5, 3.6, true),
+		},
+		{
+			name:        "Lease not kept alive persists",
+			renewals:    []LeaseRenewalCall{renewal(0, 0.1), renewal(1, 1.1)},
+			check:       check(5, 5.1, false),
+			expectError: true,
+		},
+		{
+			name:        "Lease persists despite gap between keepalives",
+			renewals:    []LeaseRenewalCall{renewal(0, 0.1), renewal(4, 4.1)},
+			check:       check(4.5, 4.6, false),
+			expectError: true,
+		},
+		{
+			name:     "Lease persists within revoke delay",
+			renewals: []LeaseRenewalCall{renewal(0, 0.1)},
+			check:    check(2.5, 2.6, false),
+		},
+		{
+			name:     "Lease expired after keepalive with unknown result",
+			renewals: []LeaseRenewalCall{renewal(0, 0.1), renewal(1, 1.1), unknown(2, 2.1)},
+			check:    check(3.2, 3.3, true),
+		},
+		{
+			name:     "Lease persists after keepalive with unknown result",
+			renewals: []LeaseRenewalCall{renewal(0, 0.1), unknown(1.5, 1.6)},
+			check:    check(3.2, 3.3, false),
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateLeaseRenewal(LeaseRenewal{LeaseID: 1, TTL: 2 * time.Second, Renewals: tc.renewals, Check: tc.check}, 100*time.Millisecond, time.Second)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateLeaseRenewalClockTolerance(t *testing.T) {
+	base := time.Now()
+	at := func(seconds float64) time.Time {
+		return base.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	tcs := []struct {
+		name        string
+		tolerance   time.Duration
+		checkAt     float64
+		expired     bool
+		expectError bool
+	}{
+		{
+			name:      "Lease expired within tolerance before TTL",
+			tolerance: DefaultLeaseClockTolerance,
+			checkAt:   1.5,
+			expired:   true,
+		},
+		{
+			name:        "Lease expired before tolerance window",
+			tolerance:   DefaultLeaseClockTolerance,
+			checkAt:     0.5,
+			expired:     true,
+			expectError: true,
+		},
+		{
+			name:        "Lease expired within tolerance of other granularity",
+			tolerance:   100 * time.Millisecond,
+			checkAt:     1.5,
+			expired:     true,
+			expectError: true,
+		},
+		{
+			name:      "Lease alive within tolerance after TTL",
+			tolerance: DefaultLeaseClockTolerance,
+			checkAt:   2.8,
+		},
+		{
+			name:        "Lease alive after tolerance window",
+			tolerance:   DefaultLeaseClockTolerance,
+			checkAt:     3.5,
+			expectError: true,
+		},
+		{
+			name:      "Tolerance as large as TTL accepts any expiry",
+			tolerance: 2 * time.Second,
+			checkAt:   0.2,
+			expired:   true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			renewal := LeaseRenewal{
+				LeaseID:  1,
+				TTL:      2 * time.Second,
+				Renewals: []LeaseRenewalCall{{Call: at(0), Return: at(0.1)}},
+				Check:    LeaseCheck{Call: at(tc.checkAt), Return: at(tc.checkAt + 0.1), Expired: tc.expired},
+			}
+			err := ValidateLeaseRenewal(renewal, tc.tolerance, 0)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
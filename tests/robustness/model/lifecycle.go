@@ -0,0 +1,68 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// KeyLifecycle is a per key state machine validating CreateRevision and Version of keys based on
+// complete sequence of events starting from empty keyspace.
+// Put of not existing key must set Version to 1 and CreateRevision to the put revision.
+// Put of existing key must increment Version and keep CreateRevision.
+// Delete removes the key, so recreating it must start from scratch instead of continuing previous lifecycle.
+type KeyLifecycle struct {
+	keys map[string]keyGeneration
+}
+
+type keyGeneration struct {
+	CreateRevision int64
+	Version        int64
+}
+
+func NewKeyLifecycle() *KeyLifecycle {
+	return &KeyLifecycle{keys: map[string]keyGeneration{}}
+}
+
+// Apply validates event against current state of its key and updates it.
+// Returned error describes the revision at which lifecycle was broken.
+func (l *KeyLifecycle) Apply(event *mvccpb.Event) error {
+	key := string(event.Kv.Key)
+	revision := event.Kv.ModRevision
+	current, exists := l.keys[key]
+	switch event.Type {
+	case mvccpb.PUT:
+		expect := keyGeneration{CreateRevision: revision, Version: 1}
+		if exists {
+			expect = keyGeneration{CreateRevision: current.CreateRevision, Version: current.Version + 1}
+		}
+		got := keyGeneration{CreateRevision: event.Kv.CreateRevision, Version: event.Kv.Version}
+		if got != expect {
+			return fmt.Errorf("key %q put at revision %d has createRevision: %d, version: %d, expected createRevision: %d, version: %d, key existed: %v",
+				key, revision, got.CreateRevision, got.Version, expect.CreateRevision, expect.Version, exists)
+		}
+		l.keys[key] = got
+	case mvccpb.DELETE:
+		if !exists {
+			return fmt.Errorf("key %q deleted at revision %d doesn't exist", key, revision)
+		}
+		delete(l.keys, key)
+	default:
+		return fmt.Errorf("key %q at revision %d has unknown event type %v", key, revision, event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestKeyLifecycle(t *testing.T) {
+	tcs := []struct {
+		name        string
+		events      []*mvccpb.Event
+		expectError string
+	}{
+		{
+			name: "Recreate resets version and create revision",
+			events: []*mvccpb.Event{
+				putEvent("key", 2, 2, 1),
+				putEvent("key", 2, 3, 2),
+				deleteEvent("key", 4),
+				putEvent("key", 5, 5, 1),
+				putEvent("key", 5, 6, 2),
+			},
+		},
+		{
+			name: "Version leaking across delete fails",
+			events: []*mvccpb.Event{
+				putEvent("key", 2, 2, 1),
+				deleteEvent("key", 3),
+				putEvent("key", 4, 4, 2),
+			},
+			expectError: `key "key" put at revision 4 has createRevision: 4, version: 2, expected createRevision: 4, version: 1, key existed: false`,
+		},
+		{
+			name: "Create revision leaking across delete fails",
+			events: []*mvccpb.Event{
+				putEvent("key", 2, 2, 1),
+				deleteEvent("key", 3),
+				putEvent("key", 2, 4, 1),
+			},
+			expectError: `key "key" put at revision 4 has createRevision: 2, version: 1, expected createRevision: 4, version: 1, key existed: false`,
+		},
+		{
+			name: "Update changing create revision fails",
+			events: []*mvccpb.Event{
+				putEvent("key", 2, 2, 1),
+				putEvent("key", 3, 3, 2),
+			},
+			expectError: `key "key" put at revision 3 has createRevision: 3, version: 2, expected createRevision: 2, version: 2, key existed: true`,
+		},
+		{
+			name: "Delete of not existing key fails",
+			events: []*mvccpb.Event{
+				putEvent("key", 2, 2, 1),
+				deleteEvent("key", 3),
+				deleteEvent("key", 4),
+			},
+			expectError: `key "key" deleted at revision 4 doesn't exist`,
+		},
+		{
+			name: "Keys are validated independently",
+			events: []*mvccpb.Event{
+				putEvent("key1", 2, 2, 1),
+				putEvent("key2", 3, 3, 1),
+				deleteEvent("key1", 4),
+				putEvent("key2", 3, 5, 2),
+				putEvent("key1", 6, 6, 1),
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewKeyLifecycle()
+			var err error
+			for _, event := range tc.events {
+				if err = l.Apply(event); err != nil {
+					break
+				}
+			}
+			if tc.expectError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func putEvent(key string, createRevision, modRevision, version int64) *mvccpb.Event {
+	return &mvccpb.Event{
+		Type: mvccpb.PUT,
+		Kv:   &mvccpb.KeyValue{Key: []byte(key), CreateRevision: createRevision, ModRevision: modRevision, Version: version},
+	}
+}
+
+func deleteEvent(key string, modRevision int64) *mvccpb.Event {
+	return &mvccpb.Event{
+		Type: mvccpb.DELETE,
+		Kv:   &mvccpb.KeyValue{Key: []byte(key), ModRevision: modRevision},
+	}
+}
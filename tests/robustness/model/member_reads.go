@@ -0,0 +1,108 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// SerializableRead describes serializable read served by a single member.
+type SerializableRead struct {
+	// MemberID is the id of member that served the read, taken from response header.
+	MemberID uint64
+	// Revision is the header revision of the read response.
+	Revision int64
+	// Time is when the read returned.
+	Time time.Time
+}
+
+// MemberReads validates that consecutive serializable reads served by a member never go back in time.
+// Member applies entries in order, so revision observed by each read cannot be older than by the previous one.
+// Member restart reloads state from disk, losing entries applied, but not yet persisted in backend, so after member
+// might have restarted, read is only required not to go below revision persisted before the restart. Entries are
+// persisted within PersistInterval after being applied, so revisions observed at least PersistInterval before
+// restart are persisted.
+type MemberReads struct {
+	MemberID        uint64
+	PersistInterval time.Duration
+	// reads keeps recent reads needed to find revision persisted before restart.
+	reads []SerializableRead
+	// mayRestartSince is the time of the first failure since the last read, zero if there was no failure.
+	mayRestartSince time.Time
+}
+
+// Failed records failed read, which means member might have restarted since previous read.
+func (m *MemberReads) Failed(t time.Time) {
+	if m.mayRestartSince.IsZero() {
+		m.mayRestartSince = t
+	}
+}
+
+// MayRestart returns whether member might have restarted since previous read.
+func (m *MemberReads) MayRestart() bool {
+	return !m.mayRestartSince.IsZero()
+}
+
+// Read validates the read against previous ones and returns error describing how it went back in time, nil if it didn't.
+func (m *MemberReads) Read(read SerializableRead) error {
+	defer func() {
+		m.mayRestartSince = time.Time{}
+		m.reads = append(m.reads, read)
+		m.prune(read.Time)
+	}()
+	if read.MemberID != m.MemberID {
+		return fmt.Errorf("read pinned to member %x served by member %x", m.MemberID, read.MemberID)
+	}
+	if len(m.reads) == 0 {
+		return nil
+	}
+	last := m.reads[len(m.reads)-1]
+	if !m.MayRestart() {
+		if read.Revision < last.Revision {
+			return fmt.Errorf("member %x read revision %d older than previous read revision %d", m.MemberID, read.Revision, last.Revision)
+		}
+		return nil
+	}
+	persisted := m.persistedRevision(m.mayRestartSince)
+	if read.Revision < persisted {
+		return fmt.Errorf("member %x read revision %d after restart older than persisted revision %d", m.MemberID, read.Revision, persisted)
+	}
+	return nil
+}
+
+// persistedRevision returns the newest revision observed at least PersistInterval before restartTime.
+func (m *MemberReads) persistedRevision(restartTime time.Time) int64 {
+	for i := len(m.reads) - 1; i >= 0; i-- {
+		if !m.reads[i].Time.After(restartTime.Add(-m.PersistInterval)) {
+			return m.reads[i].Revision
+		}
+	}
+	return 0
+}
+
+// prune drops reads that can no longer be needed to find persisted revision, keeping the newest one persisted by now.
+func (m *MemberReads) prune(now time.Time) {
+	persisted := -1
+	for i := len(m.reads) - 1; i >= 0; i-- {
+		if !m.reads[i].Time.After(now.Add(-m.PersistInterval)) {
+			persisted = i
+			break
+		}
+	}
+	if persisted > 0 {
+		m.reads = m.reads[persisted:]
+	}
+}
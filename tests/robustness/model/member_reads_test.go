@@ -0,0 +1,102 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberReads(t *testing.T) {
+	base := time.Now()
+	at := func(ms int) time.Time {
+		return base.Add(time.Duration(ms) * time.Millisecond)
+	}
+	type step struct {
+		failedAt    int
+		read        SerializableRead
+		expectError string
+	}
+	tcs := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "Reads with non-decreasing revision",
+			steps: []step{
+				{read: SerializableRead{MemberID: 1, Revision: 2, Time: at(0)}},
+				{read: SerializableRead{MemberID: 1, Revision: 2, Time: at(10)}},
+				{read: SerializableRead{MemberID: 1, Revision: 3, Time: at(20)}},
+			},
+		},
+		{
+			name: "Read going back in time fails",
+			steps: []step{
+				{read: SerializableRead{MemberID: 1, Revision: 3, Time: at(0)}},
+				{read: SerializableRead{MemberID: 1, Revision: 2, Time: at(10)}, expectError: "member 1 read revision 2 older than previous read revision 3"},
+			},
+		},
+		{
+			name: "Read served by other member fails",
+			steps: []step{
+				{read: SerializableRead{MemberID: 2, Revision: 2, Time: at(0)}, expectError: "read pinned to member 1 served by member 2"},
+			},
+		},
+		{
+			name: "Read after restart can lose revisions not yet persisted",
+			steps: []step{
+				{read: SerializableRead{MemberID: 1, Revision: 2, Time: at(0)}},
+				{read: SerializableRead{MemberID: 1, Revision: 5, Time: at(150)}},
+				{failedAt: 200, read: SerializableRead{MemberID: 1, Revision: 2, Time: at(300)}},
+				{read: SerializableRead{MemberID: 1, Revision: 3, Time: at(310)}},
+			},
+		},
+		{
+			name: "Read after restart going below persisted revision fails",
+			steps: []step{
+				{read: SerializableRead{MemberID: 1, Revision: 2, Time: at(0)}},
+				{read: SerializableRead{MemberID: 1, Revision: 4, Time: at(50)}},
+				{read: SerializableRead{MemberID: 1, Revision: 5, Time: at(150)}},
+				{failedAt: 200, read: SerializableRead{MemberID: 1, Revision: 3, Time: at(300)}, expectError: "member 1 read revision 3 after restart older than persisted revision 4"},
+			},
+		},
+		{
+			name: "Read after restart going back from previous read fails once member is back",
+			steps: []step{
+				{read: SerializableRead{MemberID: 1, Revision: 5, Time: at(0)}},
+				{failedAt: 200, read: SerializableRead{MemberID: 1, Revision: 6, Time: at(300)}},
+				{read: SerializableRead{MemberID: 1, Revision: 5, Time: at(310)}, expectError: "member 1 read revision 5 older than previous read revision 6"},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			reads := MemberReads{MemberID: 1, PersistInterval: 100 * time.Millisecond}
+			for _, s := range tc.steps {
+				if s.failedAt != 0 {
+					reads.Failed(at(s.failedAt))
+				}
+				err := reads.Read(s.read)
+				if s.expectError != "" {
+					assert.EqualError(t, err, s.expectError)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
@@ -25,9 +25,10 @@ import (
 type OperationType string
 
 const (
-	Range  OperationType = "range"
-	Put    OperationType = "put"
-	Delete OperationType = "delete"
+	Range     OperationType = "range"
+	Put       OperationType = "put"
+	Delete    OperationType = "delete"
+	NestedTxn OperationType = "txn"
 )
 
 // NonDeterministicModel extends DeterministicModel to handle requests that have unknown or error response.
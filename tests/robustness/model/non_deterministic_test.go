@@ -50,8 +50,8 @@ func TestModelNonDeterministic(t *testing.T) {
 		{
 			name: "First Range can start from non-zero revision",
 			operations: []testOperation{
-				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 1, 1)},
-				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 1, 1)},
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1)},
+				{req: rangeRequest("key", true, 0), resp: rangeResponse(nil, 0, 1)},
 			},
 		},
 		{
@@ -531,6 +531,19 @@ func TestModelNonDeterministic(t *testing.T) {
 				{req: leaseRevokeRequest(1), resp: leaseRevokeResponse(3)},
 			},
 		},
+		{
+			name: "Lease expiration with unknown result deletes only keys still attached, key overwritten without lease survives",
+			operations: []testOperation{
+				{req: leaseGrantRequest(1), resp: leaseGrantResponse(1)},
+				{req: putWithLeaseRequest("key1", "1", 1), resp: putResponse(2)},
+				{req: putWithLeaseRequest("key2", "2", 1), resp: putResponse(3)},
+				{req: putRequest("key2", "3"), resp: putResponse(4)},
+				{req: leaseRevokeRequest(1), resp: failedResponse(errors.New("lease may expire"))},
+				{req: getRequest("key1"), resp: emptyGetResponse(5)},
+				{req: getRequest("key2"), resp: emptyGetResponse(5), failure: true},
+				{req: getRequest("key2"), resp: getResponse("key2", "3", 4, 5)},
+			},
+		},
 		{
 			name: "Lease a few keys - revoke should increment revision only once",
 			operations: []testOperation{
@@ -629,6 +642,26 @@ func TestModelNonDeterministic(t *testing.T) {
 				{req: defragmentRequest(), resp: failedResponse(errors.New("failed"))},
 			},
 		},
+		{
+			name: "Failed compaction might have been persisted",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(1)},
+				{req: putRequest("key", "2"), resp: putResponse(2)},
+				{req: compactRequest(2, true), resp: failedResponse(errors.New("failed"))},
+				{req: compactRequest(1, false), resp: compactResponse(2)},
+				{req: compactRequest(2, false), resp: compactResponse(2)},
+				{req: compactRequest(2, true), resp: compactResponse(2), failure: true},
+			},
+		},
+		{
+			name: "Failed compaction of future revision is not persisted",
+			operations: []testOperation{
+				{req: putRequest("key", "1"), resp: putResponse(1)},
+				{req: compactRequest(2, true), resp: failedResponse(errors.New("failed"))},
+				{req: putRequest("key", "2"), resp: putResponse(2)},
+				{req: compactRequest(2, true), resp: compactResponse(2)},
+			},
+		},
 	}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
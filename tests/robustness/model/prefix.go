@@ -0,0 +1,116 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// LinearizablePrefix describes the longest linearizable prefix of non-linearizable history,
+// with operations ordered by their call time, and the operation that breaks linearizability when added to it.
+type LinearizablePrefix struct {
+	// Length is number of operations in the longest linearizable prefix.
+	Length int
+	// Time is call time of the last operation in the prefix.
+	Time time.Duration
+	// BreakingOperationId is index of the first operation breaking linearizability, in history ordered by call time.
+	BreakingOperationId int
+	BreakingOperation   porcupine.Operation
+	// Keys are keys involved in the breaking operation.
+	Keys []string
+}
+
+func (p LinearizablePrefix) String() string {
+	return fmt.Sprintf("longest linearizable prefix has %d operations ending at %s, operation %d breaks linearizability: client: %d, keys: %q, %s",
+		p.Length, p.Time, p.BreakingOperationId, p.BreakingOperation.ClientId, p.Keys,
+		NonDeterministicModel.DescribeOperation(p.BreakingOperation.Input, p.BreakingOperation.Output))
+}
+
+// FindLinearizablePrefix bisects non-linearizable history for its longest linearizable prefix.
+// Each prefix is checked by porcupine with the given timeout, returns false if any check timed out or history is linearizable.
+func FindLinearizablePrefix(operations []porcupine.Operation, timeout time.Duration) (LinearizablePrefix, bool) {
+	return findLinearizablePrefix(NonDeterministicModel, operations, timeout)
+}
+
+func findLinearizablePrefix(model porcupine.Model, operations []porcupine.Operation, timeout time.Duration) (LinearizablePrefix, bool) {
+	ordered := make([]porcupine.Operation, len(operations))
+	copy(ordered, operations)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Call < ordered[j].Call
+	})
+	// Invariant: first linearizable operations are linearizable, first notLinearizable operations are not.
+	linearizable, notLinearizable := 0, len(ordered)
+	switch porcupine.CheckOperationsTimeout(model, ordered, timeout) {
+	case porcupine.Ok, porcupine.Unknown:
+		return LinearizablePrefix{}, false
+	}
+	for notLinearizable-linearizable > 1 {
+		middle := (linearizable + notLinearizable) / 2
+		switch porcupine.CheckOperationsTimeout(model, ordered[:middle], timeout) {
+		case porcupine.Ok:
+			linearizable = middle
+		case porcupine.Illegal:
+			notLinearizable = middle
+		default:
+			return LinearizablePrefix{}, false
+		}
+	}
+	prefix := LinearizablePrefix{
+		Length:              linearizable,
+		BreakingOperationId: notLinearizable - 1,
+		BreakingOperation:   ordered[notLinearizable-1],
+		Keys:                requestKeys(ordered[notLinearizable-1].Input.(EtcdRequest)),
+	}
+	if linearizable > 0 {
+		prefix.Time = time.Duration(ordered[linearizable-1].Call)
+	}
+	return prefix, true
+}
+
+// requestKeys returns keys used by request conditions and operations, including nested transactions.
+func requestKeys(request EtcdRequest) []string {
+	if request.Type != Txn {
+		return nil
+	}
+	keys := []string{}
+	seen := map[string]bool{}
+	var collect func(txn *TxnRequest)
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	collect = func(txn *TxnRequest) {
+		for _, cond := range txn.Conds {
+			add(cond.Key)
+		}
+		for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+			for _, op := range ops {
+				if op.Type == NestedTxn {
+					collect(op.Txn)
+					continue
+				}
+				add(op.Key)
+			}
+		}
+	}
+	collect(request.Txn)
+	return keys
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindLinearizablePrefix(t *testing.T) {
+	operations := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("key", "3"), Call: 8, Output: putResponse(3), Return: 9},
+		{ClientId: 1, Input: getRequest("key"), Call: 0, Output: emptyGetResponse(1), Return: 1},
+		{ClientId: 1, Input: putRequest("key", "1"), Call: 2, Output: putResponse(2), Return: 3},
+		{ClientId: 2, Input: getRequest("key"), Call: 4, Output: getResponse("key", "1", 2, 2), Return: 5},
+		{ClientId: 2, Input: getRequest("key"), Call: 6, Output: getResponse("key", "2", 3, 3), Return: 7},
+	}
+	prefix, ok := FindLinearizablePrefix(operations, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, 3, prefix.Length)
+	assert.Equal(t, time.Duration(4), prefix.Time)
+	assert.Equal(t, 3, prefix.BreakingOperationId)
+	assert.Equal(t, 2, prefix.BreakingOperation.ClientId)
+	assert.Equal(t, []string{"key"}, prefix.Keys)
+
+	_, ok = FindLinearizablePrefix(operations[1:4], time.Minute)
+	assert.False(t, ok, "linearizable history has no breaking operation")
+}
+
+func TestRequestKeys(t *testing.T) {
+	request := conditionalTxnRequest([]EtcdCondition{{Key: "a", ExpectedRevision: 1}}, []EtcdOperation{
+		{Type: Put, Key: "b", Value: ValueOrHash{Value: "b"}},
+		{Type: NestedTxn, Txn: conditionalTxnRequest([]EtcdCondition{{Key: "c"}}, []EtcdOperation{{Type: Delete, Key: "a"}}, nil).Txn},
+	}, []EtcdOperation{{Type: Range, Key: "d"}})
+	assert.Equal(t, []string{"a", "b", "c", "d"}, requestKeys(request))
+	assert.Empty(t, requestKeys(leaseGrantRequest(1)))
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// ReadAfterWrite describes linearizable read of a key issued after write to the key returned.
+// Read is fenced by the write revision, so it must observe either the write or a newer one superseding it.
+// Keys are expected to never be deleted, so missing key is a violation.
+type ReadAfterWrite struct {
+	Key           string
+	Value         string
+	WriteRevision int64
+	// ReadRevision is the header revision of the read response.
+	ReadRevision int64
+	// ReadKv is the key returned by read, nil if key was not found.
+	ReadKv *mvccpb.KeyValue
+}
+
+// Superseded returns whether read observed a write newer than the fencing one.
+func (r ReadAfterWrite) Superseded() bool {
+	return r.ReadKv != nil && r.ReadKv.ModRevision > r.WriteRevision
+}
+
+// Validate returns error describing how read failed to observe the write, nil if it did.
+func (r ReadAfterWrite) Validate() error {
+	if r.ReadRevision < r.WriteRevision {
+		return fmt.Errorf("key %q read at revision %d older than write revision %d", r.Key, r.ReadRevision, r.WriteRevision)
+	}
+	if r.ReadKv == nil {
+		return fmt.Errorf("key %q written at revision %d not found by read at revision %d", r.Key, r.WriteRevision, r.ReadRevision)
+	}
+	if r.ReadKv.ModRevision < r.WriteRevision {
+		return fmt.Errorf("key %q written at revision %d read with older mod revision %d at revision %d", r.Key, r.WriteRevision, r.ReadKv.ModRevision, r.ReadRevision)
+	}
+	if r.ReadKv.ModRevision == r.WriteRevision && string(r.ReadKv.Value) != r.Value {
+		return fmt.Errorf("key %q written at revision %d with value %q read with value %q", r.Key, r.WriteRevision, r.Value, string(r.ReadKv.Value))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestReadAfterWrite(t *testing.T) {
+	tcs := []struct {
+		name             string
+		read             ReadAfterWrite
+		expectSuperseded bool
+		expectError      string
+	}{
+		{
+			name: "Read observing the write",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 2,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}},
+		},
+		{
+			name: "Read observing the write at newer revision",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 5,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}},
+		},
+		{
+			name: "Read observing newer write superseding the write",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 3,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 3}},
+			expectSuperseded: true,
+		},
+		{
+			name: "Read at revision older than write fails",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 1,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("0"), ModRevision: 1}},
+			expectError: `key "key" read at revision 1 older than write revision 2`,
+		},
+		{
+			name: "Read of value older than write fails",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 3,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("0"), ModRevision: 1}},
+			expectError: `key "key" written at revision 2 read with older mod revision 1 at revision 3`,
+		},
+		{
+			name:        "Read not finding the key fails",
+			read:        ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 3},
+			expectError: `key "key" written at revision 2 not found by read at revision 3`,
+		},
+		{
+			name: "Read of different value at write revision fails",
+			read: ReadAfterWrite{Key: "key", Value: "1", WriteRevision: 2, ReadRevision: 2,
+				ReadKv: &mvccpb.KeyValue{Key: []byte("key"), Value: []byte("2"), ModRevision: 2}},
+			expectError: `key "key" written at revision 2 with value "1" read with value "2"`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.read.Validate()
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectSuperseded, tc.read.Superseded())
+		})
+	}
+}
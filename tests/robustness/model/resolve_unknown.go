@@ -0,0 +1,94 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ResolveUnknownOperations uses final state of keys, read after traffic ended, to resolve failed transactions whose
+// result is unknown. If final value of a key was put only by a single operation in the whole history and that
+// operation failed, it must have been persisted at final mod revision of the key. Such operation is changed
+// to have unknown result with that revision, so model doesn't need to consider it lost.
+// Resolution only removes possibilities model would otherwise consider, it never allows a new one, so it cannot
+// hide a violation. Returns operations with resolved ones replaced and reasoning of each resolution.
+func ResolveUnknownOperations(operations []porcupine.Operation, finalState []KeyValue) ([]porcupine.Operation, []string) {
+	putCount := map[KeyValue]int{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		if request.Type != Txn {
+			continue
+		}
+		for kv := range txnPuts(request.Txn) {
+			putCount[kv]++
+		}
+	}
+	final := map[string]ValueRevision{}
+	for _, kv := range finalState {
+		final[kv.Key] = kv.ValueRevision
+	}
+
+	resolved := make([]porcupine.Operation, 0, len(operations))
+	reasons := []string{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if resp.Err == nil || request.Type != Txn {
+			resolved = append(resolved, op)
+			continue
+		}
+		var revision int64
+		var reason string
+		for kv := range txnPuts(request.Txn) {
+			value, found := final[kv.Key]
+			if !found || value.Value != kv.Value || putCount[kv] != 1 {
+				continue
+			}
+			revision = value.ModRevision
+			reason = fmt.Sprintf("key %q has final value %s at mod revision %d, put only by this operation", kv.Key, describeValueOrHash(kv.Value), value.ModRevision)
+			break
+		}
+		if revision == 0 {
+			resolved = append(resolved, op)
+			continue
+		}
+		before := NonDeterministicModel.DescribeOperation(op.Input, op.Output)
+		op.Output = EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Revision: revision}, ResultUnknown: true}
+		reasons = append(reasons, fmt.Sprintf("client: %d, %s resolved as persisted at revision %d, %s", op.ClientId, before, revision, reason))
+		resolved = append(resolved, op)
+	}
+	return resolved, reasons
+}
+
+// txnPuts returns keys and values put by any branch of transaction, including nested transactions.
+// Mod revision of returned values is not set.
+func txnPuts(txn *TxnRequest) map[KeyValue]struct{} {
+	puts := map[KeyValue]struct{}{}
+	for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Put:
+				puts[KeyValue{Key: op.Key, ValueRevision: ValueRevision{Value: op.Value}}] = struct{}{}
+			case NestedTxn:
+				for kv := range txnPuts(op.Txn) {
+					puts[kv] = struct{}{}
+				}
+			}
+		}
+	}
+	return puts
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveUnknownOperations(t *testing.T) {
+	failed := failedResponse(errors.New("failed"))
+	resolved := func(revision int64) EtcdNonDeterministicResponse {
+		return EtcdNonDeterministicResponse{EtcdResponse: EtcdResponse{Revision: revision}, ResultUnknown: true}
+	}
+	tcs := []struct {
+		name           string
+		operations     []porcupine.Operation
+		finalState     []KeyValue
+		expectOutputs  []EtcdNonDeterministicResponse
+		expectResolved int
+	}{
+		{
+			name: "Final value put by failed operation",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2)},
+				{ClientId: 2, Input: putRequest("key", "2"), Output: failed},
+			},
+			finalState:     []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("2"), ModRevision: 3}}},
+			expectOutputs:  []EtcdNonDeterministicResponse{putResponse(2), resolved(3)},
+			expectResolved: 1,
+		},
+		{
+			name: "Final large value put by failed operation",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "0123456789012345678901234567890123456789"), Output: failed},
+			},
+			finalState:     []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("0123456789012345678901234567890123456789"), ModRevision: 2}}},
+			expectOutputs:  []EtcdNonDeterministicResponse{resolved(2)},
+			expectResolved: 1,
+		},
+		{
+			name: "Final value put in failure branch of failed transaction",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: conditionalTxnRequest([]EtcdCondition{{Key: "key"}}, []EtcdOperation{{Type: Put, Key: "key", Value: ToValueOrHash("1")}}, []EtcdOperation{{Type: Put, Key: "other", Value: ToValueOrHash("2")}}), Output: failed},
+			},
+			finalState:     []KeyValue{{Key: "other", ValueRevision: ValueRevision{Value: ToValueOrHash("2"), ModRevision: 4}}},
+			expectOutputs:  []EtcdNonDeterministicResponse{resolved(4)},
+			expectResolved: 1,
+		},
+		{
+			name: "Final value put in nested transaction",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: txnRequest(nil, []EtcdOperation{{Type: NestedTxn, Txn: putRequest("key", "1").Txn}}), Output: failed},
+			},
+			finalState:     []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("1"), ModRevision: 5}}},
+			expectOutputs:  []EtcdNonDeterministicResponse{resolved(5)},
+			expectResolved: 1,
+		},
+		{
+			name: "Value of failed operation was overwritten",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: failed},
+				{ClientId: 2, Input: putRequest("key", "2"), Output: putResponse(3)},
+			},
+			finalState:    []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("2"), ModRevision: 3}}},
+			expectOutputs: []EtcdNonDeterministicResponse{failed, putResponse(3)},
+		},
+		{
+			name: "Final value put by multiple operations",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2)},
+				{ClientId: 2, Input: putRequest("key", "1"), Output: failed},
+			},
+			finalState:    []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("1"), ModRevision: 2}}},
+			expectOutputs: []EtcdNonDeterministicResponse{putResponse(2), failed},
+		},
+		{
+			name: "Key of failed operation was deleted",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: failed},
+				{ClientId: 2, Input: deleteRequest("key"), Output: deleteResponse(1, 3)},
+			},
+			expectOutputs: []EtcdNonDeterministicResponse{failed, deleteResponse(1, 3)},
+		},
+		{
+			name: "Failed delete is not resolved",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: deleteRequest("key"), Output: failed},
+			},
+			finalState:    []KeyValue{{Key: "key", ValueRevision: ValueRevision{Value: ToValueOrHash("1"), ModRevision: 2}}},
+			expectOutputs: []EtcdNonDeterministicResponse{failed},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			operations, reasons := ResolveUnknownOperations(tc.operations, tc.finalState)
+			outputs := []EtcdNonDeterministicResponse{}
+			for i, op := range operations {
+				assert.Equal(t, tc.operations[i].Input, op.Input)
+				outputs = append(outputs, op.Output.(EtcdNonDeterministicResponse))
+			}
+			assert.Equal(t, tc.expectOutputs, outputs)
+			assert.Len(t, reasons, tc.expectResolved)
+		})
+	}
+}
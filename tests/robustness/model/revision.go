@@ -0,0 +1,82 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateRevisions checks revision accounting invariants, which are cheap to check and don't require linearization.
+// ModRevision of each key returned cannot be higher than revision in response header,
+// and header revision cannot decrease between consecutive operations of a client.
+// Returns description of each violation. Operations that failed or have unknown result are skipped.
+func ValidateRevisions(operations []porcupine.Operation) []string {
+	clientOperations := map[int][]porcupine.Operation{}
+	for _, op := range operations {
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if resp.Err != nil || resp.ResultUnknown || resp.Revision == 0 {
+			continue
+		}
+		clientOperations[op.ClientId] = append(clientOperations[op.ClientId], op)
+	}
+	clientIds := make([]int, 0, len(clientOperations))
+	for clientId := range clientOperations {
+		clientIds = append(clientIds, clientId)
+	}
+	sort.Ints(clientIds)
+
+	violations := []string{}
+	for _, clientId := range clientIds {
+		ops := clientOperations[clientId]
+		sort.SliceStable(ops, func(i, j int) bool {
+			return ops[i].Call < ops[j].Call
+		})
+		var lastRevision int64
+		for _, op := range ops {
+			resp := op.Output.(EtcdNonDeterministicResponse)
+			describe := func() string {
+				return fmt.Sprintf("client: %d, call: %d, %s", clientId, op.Call, NonDeterministicModel.DescribeOperation(op.Input, op.Output))
+			}
+			if resp.Revision < lastRevision {
+				violations = append(violations, fmt.Sprintf("header revision %d is lower than %d returned by previous operation, %s", resp.Revision, lastRevision, describe()))
+			}
+			lastRevision = resp.Revision
+			if resp.Txn == nil {
+				continue
+			}
+			for _, kv := range txnKeyValues(resp.Txn) {
+				if kv.ModRevision > resp.Revision {
+					violations = append(violations, fmt.Sprintf("key %q mod revision %d is higher than header revision %d, %s", kv.Key, kv.ModRevision, resp.Revision, describe()))
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// txnKeyValues returns key values returned by transaction operations, including nested transactions.
+func txnKeyValues(txn *TxnResponse) []KeyValue {
+	kvs := []KeyValue{}
+	for _, result := range txn.OpsResult {
+		kvs = append(kvs, result.KVs...)
+		if result.Txn != nil {
+			kvs = append(kvs, txnKeyValues(result.Txn)...)
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRevisions(t *testing.T) {
+	tcs := []struct {
+		name           string
+		operations     []porcupine.Operation
+		expectViolated int
+	}{
+		{
+			name: "Valid history",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 0, Output: putResponse(2), Return: 1},
+				{ClientId: 1, Input: getRequest("key"), Call: 2, Output: getResponse("key", "1", 2, 2), Return: 3},
+				{ClientId: 2, Input: getRequest("key"), Call: 1, Output: emptyGetResponse(1), Return: 2},
+				{ClientId: 1, Input: putRequest("key", "2"), Call: 4, Output: failedResponse(errors.New("failed")), Return: 5},
+				{ClientId: 1, Input: getRequest("key"), Call: 6, Output: getResponse("key", "1", 2, 3), Return: 7},
+			},
+		},
+		{
+			name: "Header revision decreased",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: getRequest("key"), Call: 2, Output: emptyGetResponse(2), Return: 3},
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 0, Output: putResponse(3), Return: 1},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Mod revision higher than header revision",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: getRequest("key"), Call: 0, Output: getResponse("key", "1", 3, 2), Return: 1},
+			},
+			expectViolated: 1,
+		},
+		{
+			name: "Mod revision in nested transaction higher than header revision",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: txnRequest(nil, []EtcdOperation{{Type: NestedTxn, Txn: txnRequest(nil, []EtcdOperation{{Type: Range, Key: "key"}}).Txn}}), Call: 0, Output: txnResponse([]EtcdOperationResult{
+					{Txn: &TxnResponse{TxnResult: true, OpsResult: []EtcdOperationResult{{KVs: []KeyValue{{Key: "key", ValueRevision: ValueRevision{ModRevision: 5}}}}}}},
+				}, true, 4), Return: 1},
+			},
+			expectViolated: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, ValidateRevisions(tc.operations), tc.expectViolated)
+		})
+	}
+}
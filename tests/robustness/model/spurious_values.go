@@ -0,0 +1,80 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateNoSpuriousValues checks that each non-empty value read from a key was written to the key by operation
+// called before the read returned. Traffic values are derived from unique request ids, so value that no one wrote
+// can only come from corruption. Writes are counted regardless of their result, as failed writes could have been
+// applied. Returns description of each read of unattributable value.
+func ValidateNoSpuriousValues(operations []porcupine.Operation) []string {
+	writes := map[KeyValue]porcupine.Operation{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		if request.Type != Txn {
+			continue
+		}
+		for put := range txnPuts(request.Txn) {
+			write, found := writes[put]
+			if !found || op.Call < write.Call {
+				writes[put] = op
+			}
+		}
+	}
+	violations := []string{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn || resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			continue
+		}
+		for _, read := range txnReadValues(resp.Txn) {
+			write, found := writes[read]
+			switch {
+			case !found:
+				violations = append(violations, fmt.Sprintf("value %s of key %q was never written, read: client: %d, %s",
+					describeValueOrHash(read.Value), read.Key, op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output)))
+			case write.Call > op.Return:
+				violations = append(violations, fmt.Sprintf("value %s of key %q was written only after it was read, read: client: %d, %s, write: client: %d, %s",
+					describeValueOrHash(read.Value), read.Key,
+					op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output),
+					write.ClientId, NonDeterministicModel.DescribeOperation(write.Input, write.Output)))
+			}
+		}
+	}
+	return violations
+}
+
+// txnReadValues returns keys and non-empty values returned by transaction, including nested transactions.
+// Mod revision of returned values is not set, so they can be matched with txnPuts.
+func txnReadValues(resp *TxnResponse) []KeyValue {
+	reads := []KeyValue{}
+	for _, result := range resp.OpsResult {
+		for _, kv := range result.KVs {
+			if kv.Value != (ValueOrHash{}) {
+				reads = append(reads, KeyValue{Key: kv.Key, ValueRevision: ValueRevision{Value: kv.Value}})
+			}
+		}
+		if result.Txn != nil {
+			reads = append(reads, txnReadValues(result.Txn)...)
+		}
+	}
+	return reads
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNoSpuriousValues(t *testing.T) {
+	largeValue := strings.Repeat("a", 100)
+	tcs := []struct {
+		name             string
+		operations       []porcupine.Operation
+		expectViolations []string
+	}{
+		{
+			name: "Read values that were written",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2), Call: 0, Return: 1},
+				{ClientId: 2, Input: putRequest("key", largeValue), Output: putResponse(3), Call: 2, Return: 3},
+				{ClientId: 3, Input: getRequest("key"), Output: getResponse("key", "1", 2, 2), Call: 1, Return: 2},
+				{ClientId: 3, Input: getRequest("key"), Output: getResponse("key", largeValue, 3, 3), Call: 3, Return: 4},
+			},
+		},
+		{
+			name: "Read value of write with unknown result",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: failedResponse(errors.New("timeout")), Call: 0},
+				{ClientId: 2, Input: getRequest("key"), Output: getResponse("key", "1", 2, 2), Call: 1, Return: 2},
+			},
+		},
+		{
+			name: "Read value concurrent with its write",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2), Call: 1, Return: 3},
+				{ClientId: 2, Input: getRequest("key"), Output: getResponse("key", "1", 2, 2), Call: 0, Return: 2},
+			},
+		},
+		{
+			name: "Read value written to other key",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("other", "1"), Output: putResponse(2), Call: 0, Return: 1},
+				{ClientId: 2, Input: getRequest("key"), Output: getResponse("key", "1", 2, 2), Call: 2, Return: 3},
+			},
+			expectViolations: []string{`value "1" of key "key" was never written, read: client: 2, get("key") -> "1", rev: 2`},
+		},
+		{
+			name: "Read value before it was written",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: getRequest("key"), Output: getResponse("key", "1", 2, 2), Call: 0, Return: 1},
+				{ClientId: 2, Input: putRequest("key", "1"), Output: putResponse(2), Call: 2, Return: 3},
+			},
+			expectViolations: []string{`value "1" of key "key" was written only after it was read, read: client: 1, get("key") -> "1", rev: 2, write: client: 2, put("key", "1") -> ok, rev: 2`},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateNoSpuriousValues(tc.operations)
+			if len(tc.expectViolations) == 0 {
+				assert.Empty(t, violations)
+			} else {
+				assert.Equal(t, tc.expectViolations, violations)
+			}
+		})
+	}
+}
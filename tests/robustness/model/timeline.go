@@ -0,0 +1,105 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// KeyWatchEvent is event delivered by watch opened on Member. Time is when event was received, measured in the same
+// clock as call and return time of operations.
+type KeyWatchEvent struct {
+	Member string
+	Time   int64
+	Event  WatchedEvent
+}
+
+type timelineEntry struct {
+	time        int64
+	description string
+}
+
+// KeyTimeline describes operations and watch events of key, one line per entry ordered by time, to help triage
+// violations of a single key. Operations are ordered by call time and include ranges covering the key. Operations
+// with unknown result are marked, as they could have been applied at any time after their call.
+func KeyTimeline(key string, operations []porcupine.Operation, events []KeyWatchEvent) []string {
+	entries := []timelineEntry{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		if request.Type != Txn || !txnTouchesKey(request.Txn, key) {
+			continue
+		}
+		entries = append(entries, timelineEntry{time: op.Call, description: describeTimelineOperation(op)})
+	}
+	for _, event := range events {
+		if event.Event.Key != key {
+			continue
+		}
+		entries = append(entries, timelineEntry{
+			time:        event.Time,
+			description: fmt.Sprintf("[%s] watch on %s: %s", time.Duration(event.Time), event.Member, describeWatchedEvent(event.Event)),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time < entries[j].time
+	})
+	timeline := make([]string, len(entries))
+	for i, entry := range entries {
+		timeline[i] = entry.description
+	}
+	return timeline
+}
+
+func describeTimelineOperation(op porcupine.Operation) string {
+	request := op.Input.(EtcdRequest)
+	response := op.Output.(EtcdNonDeterministicResponse)
+	description := NonDeterministicModel.DescribeOperation(request, response)
+	if response.Err != nil {
+		return fmt.Sprintf("[%s - unknown] client %d: %s, result unknown, might have been applied any time after call",
+			time.Duration(op.Call), op.ClientId, description)
+	}
+	if response.ResultUnknown {
+		return fmt.Sprintf("[%s - unknown] client %d: %s, result unknown, return time not known",
+			time.Duration(op.Call), op.ClientId, description)
+	}
+	return fmt.Sprintf("[%s - %s] client %d: %s", time.Duration(op.Call), time.Duration(op.Return), op.ClientId, description)
+}
+
+// txnTouchesKey returns true if any condition or operation of transaction, including nested transactions, uses key.
+func txnTouchesKey(txn *TxnRequest, key string) bool {
+	for _, cond := range txn.Conds {
+		if cond.Key == key {
+			return true
+		}
+	}
+	for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+		for _, op := range ops {
+			if op.Type == NestedTxn {
+				if txnTouchesKey(op.Txn, key) {
+					return true
+				}
+				continue
+			}
+			if op.Key == key || ((op.WithPrefix || op.End != "") && rangeContains(op, key)) {
+				return true
+			}
+		}
+	}
+	return false
+}
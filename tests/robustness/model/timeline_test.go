@@ -0,0 +1,109 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyTimeline(t *testing.T) {
+	ms := time.Millisecond.Nanoseconds()
+	tcs := []struct {
+		name           string
+		operations     []porcupine.Operation
+		events         []KeyWatchEvent
+		expectTimeline []string
+	}{
+		{
+			name:           "Empty history",
+			expectTimeline: []string{},
+		},
+		{
+			name: "Operations of other keys are skipped",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("other", "1"), Call: 1 * ms, Output: putResponse(2), Return: 2 * ms},
+				{ClientId: 1, Input: getRequest("key2"), Call: 3 * ms, Output: emptyGetResponse(2), Return: 4 * ms},
+				{ClientId: 1, Input: leaseRevokeRequest(1), Call: 5 * ms, Output: leaseRevokeResponse(2), Return: 6 * ms},
+			},
+			events: []KeyWatchEvent{
+				{Member: "m0", Time: 2 * ms, Event: WatchedEvent{Key: "other", Revision: 2, Type: Put, Value: ToValueOrHash("1")}},
+			},
+			expectTimeline: []string{},
+		},
+		{
+			name: "Operations are ordered by call time",
+			operations: []porcupine.Operation{
+				{ClientId: 2, Input: getRequest("key"), Call: 3 * ms, Output: getResponse("key", "1", 2, 2), Return: 4 * ms},
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 1 * ms, Output: putResponse(2), Return: 2 * ms},
+			},
+			expectTimeline: []string{
+				`[1ms - 2ms] client 1: put("key", "1") -> ok, rev: 2`,
+				`[3ms - 4ms] client 2: get("key") -> "1", rev: 2`,
+			},
+		},
+		{
+			name: "Ranges covering key and conditions on key are included",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: rangeRequest("k", true, 0), Call: 1 * ms, Output: rangeResponse(nil, 0, 1), Return: 2 * ms},
+				{ClientId: 1, Input: deletePrefixRequest("k"), Call: 3 * ms, Output: deleteResponse(0, 1), Return: 4 * ms},
+				{ClientId: 1, Input: compareRevisionAndPutRequest("key", 0, "2"), Call: 5 * ms, Output: compareRevisionAndPutResponse(true, 2), Return: 6 * ms},
+				{ClientId: 1, Input: rangeRequest("l", true, 0), Call: 7 * ms, Output: rangeResponse(nil, 0, 2), Return: 8 * ms},
+			},
+			expectTimeline: []string{
+				`[1ms - 2ms] client 1: range("k") -> [], count: 0, rev: 1`,
+				`[3ms - 4ms] client 1: deleteRange("k") -> deleted: 0, rev: 1`,
+				`[5ms - 6ms] client 1: if(mod_rev(key)==0).then(put("key", "2")) -> ok, rev: 2`,
+			},
+		},
+		{
+			name: "Operations with unknown result are marked",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 1 * ms, Output: failedResponse(errors.New("failed")), Return: 9 * ms},
+				{ClientId: 2, Input: putRequest("key", "2"), Call: 2 * ms, Output: unknownResponse(3), Return: 9 * ms},
+			},
+			expectTimeline: []string{
+				`[1ms - unknown] client 1: put("key", "1") -> err: "failed", result unknown, might have been applied any time after call`,
+				`[2ms - unknown] client 2: put("key", "2") -> unknown, rev: 3, result unknown, return time not known`,
+			},
+		},
+		{
+			name: "Watch events are ordered with operations",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 1 * ms, Output: putResponse(2), Return: 3 * ms},
+				{ClientId: 1, Input: deleteRequest("key"), Call: 4 * ms, Output: deleteResponse(1, 3), Return: 5 * ms},
+			},
+			events: []KeyWatchEvent{
+				{Member: "m1", Time: 6 * ms, Event: WatchedEvent{Key: "key", Revision: 3, Type: Delete}},
+				{Member: "m0", Time: 2 * ms, Event: WatchedEvent{Key: "key", Revision: 2, Type: Put, Value: ToValueOrHash("1")}},
+			},
+			expectTimeline: []string{
+				`[1ms - 3ms] client 1: put("key", "1") -> ok, rev: 2`,
+				`[2ms] watch on m0: put of key "key" at revision 2 with value "1"`,
+				`[4ms - 5ms] client 1: delete("key") -> deleted: 1, rev: 3`,
+				`[6ms] watch on m1: delete of key "key" at revision 3 with value nil`,
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectTimeline, KeyTimeline("key", tc.operations, tc.events))
+		})
+	}
+}
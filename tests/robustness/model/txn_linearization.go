@@ -0,0 +1,419 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateTxnLinearizationPoints checks that each successful transaction is consistent with a single linearization
+// point, the revision it returned. State of keys at each revision is reconstructed from writes with known result
+// recorded in history. Transaction that wrote must have evaluated its conditions and reads against state from just
+// before its revision and produced exactly the writes at its revision, read-only transaction must be consistent with
+// state at its revision. Transactions touching keys that could have been changed by write with unknown result are
+// skipped. Returns description of each violation, with revision of transaction and its first inconsistent
+// sub-operation.
+func ValidateTxnLinearizationPoints(operations []porcupine.Operation) []string {
+	h := newKeyHistory(operations)
+	violations := []string{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn || resp.Err != nil || resp.ResultUnknown || resp.Revision == 0 || resp.Txn == nil {
+			continue
+		}
+		keys, ok := h.txnKeys(request.Txn)
+		if !ok {
+			continue
+		}
+		base := resp.Revision
+		if txnWrote(request.Txn, resp.Txn) {
+			base = resp.Revision - 1
+		}
+		_, expected := h.stateAt(keys, txnLeases(request.Txn), base).step(request)
+		if reflect.DeepEqual(expected, resp.EtcdResponse) {
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("txn at revision %d not consistent with state at revision %d, %s, client: %d, %s",
+			resp.Revision, base, describeTxnMismatch(request, expected, resp.EtcdResponse),
+			op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output),
+		))
+	}
+	return violations
+}
+
+// keyHistory records writes with known result applied to each key, and keys that could have been changed by writes
+// with unknown result.
+type keyHistory struct {
+	events       map[string][]keyEvent
+	rangeDeletes []keyEvent
+	leaseRevokes map[int64][]keyEvent
+	// keyLeases are leases each key was ever put with.
+	keyLeases       map[string][]int64
+	uncertainKeys   map[string]bool
+	uncertainRanges []EtcdOperation
+	uncertainLeases map[int64]bool
+	// keys are all keys known to history, sorted.
+	keys      []string
+	timelines map[string][]keyEvent
+	seq       int
+}
+
+// keyEvent is a write applied at revision. Seq orders events applied by the same transaction.
+type keyEvent struct {
+	revision int64
+	seq      int
+	// op is Put or Delete operation, zero for lease revoke.
+	op           EtcdOperation
+	revokedLease int64
+}
+
+// keyState is state of a key at some revision.
+type keyState struct {
+	exists         bool
+	value          ValueRevision
+	createRevision int64
+	leaseID        int64
+}
+
+func newKeyHistory(operations []porcupine.Operation) *keyHistory {
+	h := &keyHistory{
+		events:          map[string][]keyEvent{},
+		leaseRevokes:    map[int64][]keyEvent{},
+		keyLeases:       map[string][]int64{},
+		uncertainKeys:   map[string]bool{},
+		uncertainLeases: map[int64]bool{},
+		timelines:       map[string][]keyEvent{},
+	}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		switch request.Type {
+		case LeaseRevoke:
+			leaseID := request.LeaseRevoke.LeaseID
+			if resp.Err != nil || resp.ResultUnknown {
+				h.uncertainLeases[leaseID] = true
+				continue
+			}
+			h.seq++
+			h.leaseRevokes[leaseID] = append(h.leaseRevokes[leaseID], keyEvent{revision: resp.Revision, seq: h.seq, revokedLease: leaseID})
+		case Txn:
+			if resp.Err != nil || resp.ResultUnknown || resp.Revision == 0 || resp.Txn == nil {
+				h.addUncertainTxn(request.Txn)
+				continue
+			}
+			h.addTxn(request.Txn, resp.Txn, resp.Revision)
+		}
+	}
+	keys := map[string]struct{}{}
+	for key := range h.events {
+		keys[key] = struct{}{}
+	}
+	for key := range h.uncertainKeys {
+		keys[key] = struct{}{}
+	}
+	for key := range keys {
+		h.keys = append(h.keys, key)
+	}
+	sort.Strings(h.keys)
+	return h
+}
+
+// addTxn records writes of the executed branch of transaction, including nested transactions.
+func (h *keyHistory) addTxn(request *TxnRequest, response *TxnResponse, revision int64) {
+	ops := request.Ops
+	if response.TxnResult {
+		ops = request.OpsOnFailure
+	}
+	if len(ops) != len(response.OpsResult) {
+		h.addUncertainTxn(request)
+		return
+	}
+	for i, op := range ops {
+		opResp := response.OpsResult[i]
+		switch op.Type {
+		case Put:
+			h.seq++
+			h.events[op.Key] = append(h.events[op.Key], keyEvent{revision: revision, seq: h.seq, op: op})
+			if op.LeaseID != 0 {
+				h.keyLeases[op.Key] = append(h.keyLeases[op.Key], op.LeaseID)
+			}
+		case Delete:
+			if opResp.Deleted == 0 {
+				continue
+			}
+			h.seq++
+			event := keyEvent{revision: revision, seq: h.seq, op: op}
+			if op.WithPrefix || op.End != "" {
+				h.rangeDeletes = append(h.rangeDeletes, event)
+			} else {
+				h.events[op.Key] = append(h.events[op.Key], event)
+			}
+		case NestedTxn:
+			if opResp.Txn == nil {
+				h.addUncertainTxn(op.Txn)
+				continue
+			}
+			h.addTxn(op.Txn, opResp.Txn, revision)
+		}
+	}
+}
+
+// addUncertainTxn records keys that could have been changed by any branch of transaction with unknown result.
+func (h *keyHistory) addUncertainTxn(request *TxnRequest) {
+	for _, ops := range [][]EtcdOperation{request.Ops, request.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Put:
+				h.uncertainKeys[op.Key] = true
+			case Delete:
+				if op.WithPrefix || op.End != "" {
+					h.uncertainRanges = append(h.uncertainRanges, op)
+				} else {
+					h.uncertainKeys[op.Key] = true
+				}
+			case NestedTxn:
+				h.addUncertainTxn(op.Txn)
+			}
+		}
+	}
+}
+
+// txnKeys returns keys compared, read or written by any branch of transaction, false if any of them is uncertain.
+func (h *keyHistory) txnKeys(request *TxnRequest) (map[string]struct{}, bool) {
+	keys := map[string]struct{}{}
+	h.collectTxnKeys(request, keys)
+	for key := range keys {
+		if h.uncertain(key) {
+			return nil, false
+		}
+	}
+	return keys, true
+}
+
+func (h *keyHistory) collectTxnKeys(request *TxnRequest, keys map[string]struct{}) {
+	for _, cond := range request.Conds {
+		keys[cond.Key] = struct{}{}
+	}
+	for _, ops := range [][]EtcdOperation{request.Ops, request.OpsOnFailure} {
+		for _, op := range ops {
+			switch {
+			case op.Type == NestedTxn:
+				h.collectTxnKeys(op.Txn, keys)
+			case op.WithPrefix || op.End != "":
+				for i := sort.SearchStrings(h.keys, op.Key); i < len(h.keys) && rangeContains(op, h.keys[i]); i++ {
+					keys[h.keys[i]] = struct{}{}
+				}
+			default:
+				keys[op.Key] = struct{}{}
+			}
+		}
+	}
+}
+
+// uncertain returns whether key could have been changed by a write with unknown result.
+func (h *keyHistory) uncertain(key string) bool {
+	if h.uncertainKeys[key] {
+		return true
+	}
+	for _, op := range h.uncertainRanges {
+		if rangeContains(op, key) {
+			return true
+		}
+	}
+	for _, leaseID := range h.keyLeases[key] {
+		if h.uncertainLeases[leaseID] {
+			return true
+		}
+	}
+	return false
+}
+
+// timeline returns all events that could have changed key, in order they were applied.
+func (h *keyHistory) timeline(key string) []keyEvent {
+	if events, ok := h.timelines[key]; ok {
+		return events
+	}
+	events := append([]keyEvent{}, h.events[key]...)
+	for _, event := range h.rangeDeletes {
+		if rangeContains(event.op, key) {
+			events = append(events, event)
+		}
+	}
+	for _, leaseID := range h.keyLeases[key] {
+		events = append(events, h.leaseRevokes[leaseID]...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].revision != events[j].revision {
+			return events[i].revision < events[j].revision
+		}
+		return events[i].seq < events[j].seq
+	})
+	h.timelines[key] = events
+	return events
+}
+
+// keyStateAt replays events of key up to and including revision.
+func (h *keyHistory) keyStateAt(key string, revision int64) keyState {
+	var s keyState
+	for _, event := range h.timeline(key) {
+		if event.revision > revision {
+			break
+		}
+		switch {
+		case event.revokedLease != 0:
+			if s.exists && s.leaseID == event.revokedLease {
+				s = keyState{}
+			}
+		case event.op.Type == Put:
+			if !s.exists {
+				s.createRevision = event.revision
+			}
+			s.exists = true
+			s.value = ValueRevision{Value: event.op.Value, ModRevision: event.revision}
+			s.leaseID = event.op.LeaseID
+		case event.op.Type == Delete:
+			s = keyState{}
+		}
+	}
+	return s
+}
+
+// stateAt returns model state of keys at revision, with leases put by transaction assumed to exist.
+func (h *keyHistory) stateAt(keys map[string]struct{}, leases []int64, revision int64) etcdState {
+	s := etcdState{
+		Revision:           revision,
+		KeyValues:          map[string]ValueRevision{},
+		KeyCreateRevisions: map[string]int64{},
+		KeyLeases:          map[string]int64{},
+		Leases:             map[int64]EtcdLease{},
+	}
+	for _, leaseID := range leases {
+		s.Leases[leaseID] = EtcdLease{LeaseID: leaseID, Keys: map[string]struct{}{}}
+	}
+	for key := range keys {
+		ks := h.keyStateAt(key, revision)
+		if !ks.exists {
+			continue
+		}
+		s.KeyValues[key] = ks.value
+		s.KeyCreateRevisions[key] = ks.createRevision
+		if ks.leaseID != 0 {
+			s.KeyLeases[key] = ks.leaseID
+			if lease, ok := s.Leases[ks.leaseID]; ok {
+				lease.Keys[key] = leased
+			}
+		}
+	}
+	return s
+}
+
+// txnLeases returns leases of puts in any branch of transaction, including nested transactions.
+func txnLeases(request *TxnRequest) []int64 {
+	leases := []int64{}
+	for _, ops := range [][]EtcdOperation{request.Ops, request.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Put:
+				if op.LeaseID != 0 {
+					leases = append(leases, op.LeaseID)
+				}
+			case NestedTxn:
+				leases = append(leases, txnLeases(op.Txn)...)
+			}
+		}
+	}
+	return leases
+}
+
+// txnWrote returns whether executed branch of transaction put or deleted any key, so it created a new revision.
+func txnWrote(request *TxnRequest, response *TxnResponse) bool {
+	ops := request.Ops
+	if response.TxnResult {
+		ops = request.OpsOnFailure
+	}
+	for i, op := range ops {
+		if i >= len(response.OpsResult) {
+			break
+		}
+		switch op.Type {
+		case Put:
+			return true
+		case Delete:
+			if response.OpsResult[i].Deleted != 0 {
+				return true
+			}
+		case NestedTxn:
+			if response.OpsResult[i].Txn != nil && txnWrote(op.Txn, response.OpsResult[i].Txn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describeTxnMismatch describes the first sub-operation of transaction whose recorded result differs from expected.
+func describeTxnMismatch(request EtcdRequest, expected, got EtcdResponse) string {
+	if expected.Txn == nil {
+		return "expected txn to be rejected as lease not found"
+	}
+	if description := describeTxnResultMismatch("", request.Txn, expected.Txn, got.Txn); description != "" {
+		return description
+	}
+	return fmt.Sprintf("returned revision %d, expected %d", got.Revision, expected.Revision)
+}
+
+func describeTxnResultMismatch(path string, request *TxnRequest, expected, got *TxnResponse) string {
+	if expected.TxnResult != got.TxnResult {
+		return fmt.Sprintf("%sconditions %s evaluated to %t, expected %t", path, describeEtcdConditions(request.Conds), !got.TxnResult, !expected.TxnResult)
+	}
+	ops := request.Ops
+	if got.TxnResult {
+		ops = request.OpsOnFailure
+	}
+	if len(expected.OpsResult) != len(got.OpsResult) || len(ops) != len(got.OpsResult) {
+		return fmt.Sprintf("%sbranch returned %d results, expected %d", path, len(got.OpsResult), len(expected.OpsResult))
+	}
+	for i, op := range ops {
+		if reflect.DeepEqual(expected.OpsResult[i], got.OpsResult[i]) {
+			continue
+		}
+		opPath := fmt.Sprintf("%sops[%d] ", path, i)
+		if op.Type == NestedTxn && expected.OpsResult[i].Txn != nil && got.OpsResult[i].Txn != nil {
+			return describeTxnResultMismatch(opPath, op.Txn, expected.OpsResult[i].Txn, got.OpsResult[i].Txn)
+		}
+		return fmt.Sprintf("%s%s returned %s, expected %s", opPath, describeEtcdOperation(op),
+			describeOperationResultRevisions(op, got.OpsResult[i]), describeOperationResultRevisions(op, expected.OpsResult[i]))
+	}
+	return ""
+}
+
+// describeOperationResultRevisions describes result of operation including mod revisions of read keys,
+// as they are compared too.
+func describeOperationResultRevisions(op EtcdOperation, result EtcdOperationResult) string {
+	if op.Type != Range {
+		return describeEtcdOperationResponse(op, result)
+	}
+	kvs := make([]string, len(result.KVs))
+	for i, kv := range result.KVs {
+		kvs[i] = fmt.Sprintf("%q: %s, mod_rev: %d", kv.Key, describeValueOrHash(kv.Value), kv.ModRevision)
+	}
+	return fmt.Sprintf("[%s], count: %d", strings.Join(kvs, "; "), result.Count)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestValidateTxnLinearizationPoints(t *testing.T) {
+	putKey := porcupine.Operation{ClientId: 1, Input: putRequest("key", "1"), Output: putResponse(2)}
+	tcs := []struct {
+		name            string
+		operations      []porcupine.Operation
+		expectViolation string
+	}{
+		{
+			name: "Txn reads and writes at its revision",
+			operations: []porcupine.Operation{
+				putKey,
+				{ClientId: 2, Input: getAndPutRequest("key", "2"), Output: getAndPutResponse(&mvccpb.KeyValue{Key: []byte("key"), Value: []byte("1"), ModRevision: 2}, 3)},
+				{ClientId: 3, Input: getRequest("key"), Output: getResponse("key", "2", 3, 3)},
+			},
+		},
+		{
+			name: "Txn read stale value",
+			operations: []porcupine.Operation{
+				putKey,
+				{ClientId: 2, Input: getAndPutRequest("key", "2"), Output: getAndPutResponse(nil, 3)},
+			},
+			expectViolation: `txn at revision 3 not consistent with state at revision 2, ops[0] get("key") returned [], count: 0, expected ["key": "1", mod_rev: 2], count: 1, client: 2, get("key"), put("key", "2") -> nil, ok, rev: 3`,
+		},
+		{
+			name: "Read-only txn read value from before its revision",
+			operations: []porcupine.Operation{
+				putKey,
+				{ClientId: 2, Input: putRequest("key", "2"), Output: putResponse(3)},
+				{ClientId: 3, Input: getRequest("key"), Output: getResponse("key", "1", 2, 3)},
+			},
+			expectViolation: `txn at revision 3 not consistent with state at revision 3, ops[0] get("key") returned ["key": "1", mod_rev: 2], count: 1, expected ["key": "2", mod_rev: 3], count: 1, client: 3, get("key") -> "1", rev: 3`,
+		},
+		{
+			name: "Txn executed wrong branch",
+			operations: []porcupine.Operation{
+				putKey,
+				{ClientId: 2, Input: compareRevisionAndPutRequest("key", 2, "2"), Output: compareRevisionAndPutResponse(false, 2)},
+			},
+			expectViolation: `txn at revision 2 not consistent with state at revision 2, conditions mod_rev(key)==2 evaluated to false, expected true, client: 2, if(mod_rev(key)==2).then(put("key", "2")) -> txn failed, rev: 2`,
+		},
+		{
+			name: "Txn with key written with unknown result is skipped",
+			operations: []porcupine.Operation{
+				putKey,
+				{ClientId: 2, Input: putRequest("key", "2"), Output: failedResponse(errors.New("failed"))},
+				{ClientId: 3, Input: getRequest("key"), Output: getResponse("key", "2", 3, 3)},
+			},
+		},
+		{
+			name: "Range read after prefix delete",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("/p/a", "1"), Output: putResponse(2)},
+				{ClientId: 1, Input: putRequest("/p/b", "2"), Output: putResponse(3)},
+				{ClientId: 2, Input: rangeRequest("/p/", true, 0), Output: rangeResponse([]*mvccpb.KeyValue{{Key: []byte("/p/a"), Value: []byte("1"), ModRevision: 2}, {Key: []byte("/p/b"), Value: []byte("2"), ModRevision: 3}}, 2, 3)},
+				{ClientId: 1, Input: deletePrefixRequest("/p/"), Output: deleteResponse(2, 4)},
+				{ClientId: 2, Input: rangeRequest("/p/", true, 0), Output: emptyGetResponse(4)},
+			},
+		},
+		{
+			name: "Key read after its lease was revoked",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: leaseGrantRequest(5), Output: leaseGrantResponse(1)},
+				{ClientId: 1, Input: putWithLeaseRequest("key", "1", 5), Output: putResponse(2)},
+				{ClientId: 1, Input: leaseRevokeRequest(5), Output: leaseRevokeResponse(3)},
+				{ClientId: 2, Input: getRequest("key"), Output: getResponse("key", "1", 2, 3)},
+			},
+			expectViolation: `txn at revision 3 not consistent with state at revision 3, ops[0] get("key") returned ["key": "1", mod_rev: 2], count: 1, expected [], count: 0, client: 2, get("key") -> "1", rev: 3`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateTxnLinearizationPoints(tc.operations)
+			if tc.expectViolation == "" {
+				assert.Empty(t, violations)
+			} else {
+				assert.Equal(t, []string{tc.expectViolation}, violations)
+			}
+		})
+	}
+}
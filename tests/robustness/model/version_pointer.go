@@ -0,0 +1,115 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// PointerRead describes reader following pointer key to the versioned key it references.
+// Versioned keys are put once before pointer is updated to reference them, and never deleted,
+// so the referenced key must be found with the value it was put with.
+type PointerRead struct {
+	// Pointer is the pointer key returned by the first read at PointerRevision.
+	Pointer         *mvccpb.KeyValue
+	PointerRevision int64
+	// TargetKv is the referenced key returned by the second read at TargetRevision, nil if key was not found.
+	TargetKv       *mvccpb.KeyValue
+	TargetRevision int64
+	// TargetValue is the value the referenced key was put with.
+	TargetValue string
+}
+
+// Validate returns error describing how the reader observed a dangling pointer, nil if it didn't.
+func (r PointerRead) Validate() error {
+	target := string(r.Pointer.Value)
+	if r.TargetRevision < r.PointerRevision {
+		return fmt.Errorf("key %q referenced by pointer at revision %d read at older revision %d", target, r.PointerRevision, r.TargetRevision)
+	}
+	if r.TargetKv == nil {
+		return fmt.Errorf("key %q referenced by pointer at revision %d not found by read at revision %d", target, r.PointerRevision, r.TargetRevision)
+	}
+	if r.TargetKv.ModRevision >= r.Pointer.ModRevision {
+		return fmt.Errorf("key %q referenced by pointer modified at revision %d read with mod revision %d not older than pointer", target, r.Pointer.ModRevision, r.TargetKv.ModRevision)
+	}
+	if string(r.TargetKv.Value) != r.TargetValue {
+		return fmt.Errorf("key %q referenced by pointer put with value %q read with value %q", target, r.TargetValue, string(r.TargetKv.Value))
+	}
+	return nil
+}
+
+// ValidateVersionPointers checks that each successful read of the pointer key observed it referencing a key
+// with versionPrefix, that was put by operation with known revision older than pointer mod revision.
+// Versioned keys are expected to be put once and never deleted. Returns description of each violation.
+func ValidateVersionPointers(operations []porcupine.Operation, pointer, versionPrefix string) []string {
+	versions := map[ValueOrHash]int64{}
+	var reads []porcupine.Operation
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn || resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			continue
+		}
+		if isKeyGet(request.Txn, pointer) {
+			reads = append(reads, op)
+			continue
+		}
+		ops := request.Txn.Ops
+		if resp.Txn.TxnResult {
+			ops = request.Txn.OpsOnFailure
+		}
+		for _, put := range prefixPuts(ops, versionPrefix) {
+			// Pointer values might be hashed in history, so versions are identified in the same way.
+			version := ToValueOrHash(put.Key)
+			if rev, ok := versions[version]; !ok || resp.Revision < rev {
+				versions[version] = resp.Revision
+			}
+		}
+	}
+
+	violations := []string{}
+	for _, read := range reads {
+		resp := read.Output.(EtcdNonDeterministicResponse)
+		for _, kv := range resp.Txn.OpsResult[0].KVs {
+			if kv.Value.Hash == 0 && !strings.HasPrefix(kv.Value.Value, versionPrefix) {
+				violations = append(violations, fmt.Sprintf("pointer %q read at revision %d by client: %d references key %q without version prefix %q",
+					pointer, resp.Revision, read.ClientId, kv.Value.Value, versionPrefix))
+				continue
+			}
+			rev, ok := versions[kv.Value]
+			if !ok {
+				violations = append(violations, fmt.Sprintf("pointer %q read at revision %d by client: %d references %s, which was never put",
+					pointer, resp.Revision, read.ClientId, describeValueOrHash(kv.Value)))
+				continue
+			}
+			if rev >= kv.ModRevision {
+				violations = append(violations, fmt.Sprintf("pointer %q read at revision %d by client: %d references %s put at revision %d, not before pointer modified at revision %d",
+					pointer, resp.Revision, read.ClientId, describeValueOrHash(kv.Value), rev, kv.ModRevision))
+			}
+		}
+	}
+	return violations
+}
+
+// isKeyGet returns whether transaction is a single read of the key.
+func isKeyGet(txn *TxnRequest, key string) bool {
+	return len(txn.Conds) == 0 && len(txn.Ops) == 1 && len(txn.OpsOnFailure) == 0 &&
+		txn.Ops[0].Type == Range && txn.Ops[0].Key == key && !txn.Ops[0].WithPrefix && txn.Ops[0].End == ""
+}
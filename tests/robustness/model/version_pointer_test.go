@@ -0,0 +1,120 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func TestPointerRead(t *testing.T) {
+	pointer := &mvccpb.KeyValue{Key: []byte("/s/current"), Value: []byte("/s/v/1"), ModRevision: 3}
+	tcs := []struct {
+		name        string
+		read        PointerRead
+		expectError string
+	}{
+		{
+			name: "Read of referenced version",
+			read: PointerRead{Pointer: pointer, PointerRevision: 3, TargetRevision: 4, TargetValue: "1",
+				TargetKv: &mvccpb.KeyValue{Key: []byte("/s/v/1"), Value: []byte("1"), ModRevision: 2}},
+		},
+		{
+			name:        "Referenced version not found",
+			read:        PointerRead{Pointer: pointer, PointerRevision: 3, TargetRevision: 4, TargetValue: "1"},
+			expectError: `key "/s/v/1" referenced by pointer at revision 3 not found by read at revision 4`,
+		},
+		{
+			name: "Referenced version read at older revision",
+			read: PointerRead{Pointer: pointer, PointerRevision: 3, TargetRevision: 2, TargetValue: "1",
+				TargetKv: &mvccpb.KeyValue{Key: []byte("/s/v/1"), Value: []byte("1"), ModRevision: 2}},
+			expectError: `key "/s/v/1" referenced by pointer at revision 3 read at older revision 2`,
+		},
+		{
+			name: "Referenced version put after pointer",
+			read: PointerRead{Pointer: pointer, PointerRevision: 3, TargetRevision: 4, TargetValue: "1",
+				TargetKv: &mvccpb.KeyValue{Key: []byte("/s/v/1"), Value: []byte("1"), ModRevision: 4}},
+			expectError: `key "/s/v/1" referenced by pointer modified at revision 3 read with mod revision 4 not older than pointer`,
+		},
+		{
+			name: "Referenced version with different value",
+			read: PointerRead{Pointer: pointer, PointerRevision: 3, TargetRevision: 4, TargetValue: "1",
+				TargetKv: &mvccpb.KeyValue{Key: []byte("/s/v/1"), Value: []byte("2"), ModRevision: 2}},
+			expectError: `key "/s/v/1" referenced by pointer put with value "1" read with value "2"`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.read.Validate()
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateVersionPointers(t *testing.T) {
+	versionPut := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("/s/v/1", "1"), Output: putResponse(2)},
+		{ClientId: 1, Input: compareRevisionAndPutRequest("/s/current", 0, "/s/v/1"), Output: compareRevisionAndPutResponse(true, 3)},
+	}
+	tcs := []struct {
+		name             string
+		operations       []porcupine.Operation
+		expectViolations int
+	}{
+		{
+			name:       "Pointer references version put before it",
+			operations: append(versionPut, porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: getResponse("/s/current", "/s/v/1", 3, 3)}),
+		},
+		{
+			name:       "Pointer not set",
+			operations: append(versionPut, porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: emptyGetResponse(1)}),
+		},
+		{
+			name: "Pointer references version with name hashed in history",
+			operations: append(versionPut,
+				porcupine.Operation{ClientId: 3, Input: putRequest("/s/v/01234567890123456789", "3"), Output: putResponse(4)},
+				porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: getResponse("/s/current", "/s/v/01234567890123456789", 5, 5)},
+			),
+		},
+		{
+			name:             "Pointer references version never put",
+			operations:       append(versionPut, porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: getResponse("/s/current", "/s/v/2", 4, 4)}),
+			expectViolations: 1,
+		},
+		{
+			name:             "Pointer references version put after it",
+			operations:       append(versionPut, porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: getResponse("/s/current", "/s/v/1", 2, 3)}),
+			expectViolations: 1,
+		},
+		{
+			name:             "Pointer references key without version prefix",
+			operations:       append(versionPut, porcupine.Operation{ClientId: 2, Input: getRequest("/s/current"), Output: getResponse("/s/current", "/other", 3, 3)}),
+			expectViolations: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, ValidateVersionPointers(tc.operations, "/s/current", "/s/v/"), tc.expectViolations)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateWatchCoalescing checks that watch delivered exactly one event for every put of key with watched prefix
+// between StartRevision and EndRevision, in order of their revisions. Many puts of the same key between progress
+// notifications must not be coalesced into fewer events, nor delivered twice. Puts are taken from write history,
+// only puts with known result and revision are required to be delivered. Put event without put in history is allowed
+// only for keys with put of unknown result, as it might have been persisted.
+func ValidateWatchCoalescing(operations []porcupine.Operation, watches []WatchedRevisions) []string {
+	puts := map[keyRevision]porcupine.Operation{}
+	unknownPuts := map[string]bool{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn {
+			continue
+		}
+		if resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			for _, key := range txnPutKeys(request.Txn) {
+				unknownPuts[key] = true
+			}
+			continue
+		}
+		for _, modification := range txnModifications(request.Txn, resp.Txn) {
+			if modification.Type == Put {
+				puts[keyRevision{key: modification.Key, revision: resp.Revision}] = op
+			}
+		}
+	}
+
+	violations := []string{}
+	for _, watch := range watches {
+		delivered := map[keyRevision]int{}
+		lastRevision := map[string]int64{}
+		for _, event := range watch.Events {
+			if last, ok := lastRevision[event.Key]; ok && event.Revision <= last {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered event of key %q at revision %d after event at revision %d",
+					watch.Prefix, watch.StartRevision, event.Key, event.Revision, last))
+			}
+			lastRevision[event.Key] = event.Revision
+			if event.Type == Delete {
+				continue
+			}
+			put := keyRevision{key: event.Key, revision: event.Revision}
+			delivered[put]++
+			if _, ok := puts[put]; !ok && !unknownPuts[event.Key] && event.Type == Put {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered put event of key %q at revision %d, which is not a put in history",
+					watch.Prefix, watch.StartRevision, event.Key, event.Revision))
+			}
+		}
+		var expected []keyRevision
+		for put := range puts {
+			if put.revision < watch.StartRevision || put.revision > watch.EndRevision || !strings.HasPrefix(put.key, watch.Prefix) || watch.FilterPut {
+				continue
+			}
+			expected = append(expected, put)
+		}
+		sort.Slice(expected, func(i, j int) bool {
+			if expected[i].revision != expected[j].revision {
+				return expected[i].revision < expected[j].revision
+			}
+			return expected[i].key < expected[j].key
+		})
+		for _, put := range expected {
+			count := delivered[put]
+			if count == 1 {
+				continue
+			}
+			op := puts[put]
+			description := "no event, coalesced or dropped"
+			if count > 1 {
+				description = fmt.Sprintf("%d events", count)
+			}
+			violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d caught up with revision %d delivered %s for put of key %q at revision %d by client: %d, %s",
+				watch.Prefix, watch.StartRevision, watch.EndRevision, description, put.key, put.revision, op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output)))
+		}
+	}
+	return violations
+}
+
+// txnPutKeys returns keys put by any branch of transaction, including nested transactions.
+func txnPutKeys(txn *TxnRequest) []string {
+	keys := []string{}
+	for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Put:
+				keys = append(keys, op.Key)
+			case NestedTxn:
+				keys = append(keys, txnPutKeys(op.Txn)...)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchCoalescing(t *testing.T) {
+	operations := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("/a/key", "1"), Output: putResponse(2)},
+		{ClientId: 1, Input: putRequest("/a/key", "2"), Output: putResponse(3)},
+		{ClientId: 2, Input: putRequest("/b/key", "1"), Output: putResponse(4)},
+		{ClientId: 1, Input: putRequest("/a/key", "3"), Output: putResponse(5)},
+		{ClientId: 1, Input: putRequest("/a/key", "4"), Output: putResponse(6)},
+		{ClientId: 2, Input: putRequest("/b/key", "2"), Output: failedResponse(errors.New("failed"))},
+	}
+	tcs := []struct {
+		name             string
+		watch            WatchedRevisions
+		expectViolations int
+	}{
+		{
+			name: "Every put delivered once",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 6, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 2, Type: Put}, {Key: "/a/key", Revision: 3, Type: Put}, {Key: "/a/key", Revision: 5, Type: Put}, {Key: "/a/key", Revision: 6, Type: Put},
+			}},
+		},
+		{
+			name: "Puts before start and after end are not required",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 3, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 3, Type: Put}, {Key: "/a/key", Revision: 5, Type: Put},
+			}},
+		},
+		{
+			name: "Event of put with unknown result is allowed",
+			watch: WatchedRevisions{Prefix: "/b/key", StartRevision: 1, EndRevision: 7, Events: []WatchedEvent{
+				{Key: "/b/key", Revision: 4, Type: Put}, {Key: "/b/key", Revision: 7, Type: Put},
+			}},
+		},
+		{
+			name: "Puts coalesced into single event",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 6, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 2, Type: Put}, {Key: "/a/key", Revision: 6, Type: Put},
+			}},
+			expectViolations: 2,
+		},
+		{
+			name: "Put delivered twice",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 3, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 2, Type: Put}, {Key: "/a/key", Revision: 3, Type: Put}, {Key: "/a/key", Revision: 3, Type: Put},
+			}},
+			expectViolations: 2,
+		},
+		{
+			name: "Puts delivered out of order",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 3, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 3, Type: Put}, {Key: "/a/key", Revision: 2, Type: Put},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name: "Event without put in history",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 3, Events: []WatchedEvent{
+				{Key: "/a/key", Revision: 2, Type: Put}, {Key: "/a/key", Revision: 3, Type: Put}, {Key: "/a/key", Revision: 4, Type: Put},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name:  "Watch filtering puts is not validated",
+			watch: WatchedRevisions{Prefix: "/a/key", StartRevision: 1, EndRevision: 6, FilterPut: true},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateWatchCoalescing(operations, []WatchedRevisions{tc.watch})
+			assert.Len(t, violations, tc.expectViolations, violations)
+		})
+	}
+}
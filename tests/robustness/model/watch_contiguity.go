@@ -0,0 +1,180 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// WatchedRevisions describes events delivered by watch of keys with Prefix, from StartRevision until EndRevision,
+// the revision watch is known to have caught up with, either by delivering its event or by progress notification.
+// Watch that was compacted and resumed from compact revision is described by separate WatchedRevisions per resume.
+type WatchedRevisions struct {
+	Prefix        string
+	StartRevision int64
+	EndRevision   int64
+	// CreatedRevision is revision of store when watch was created, lower than StartRevision for watch starting
+	// in the future, which must deliver no events until store reaches StartRevision. Zero if not known.
+	CreatedRevision int64
+	// FilterPut and FilterDelete are set if watch requested server to filter out events of that type.
+	FilterPut    bool
+	FilterDelete bool
+	// Fragment is set if watch enabled fragmentation of responses larger than server request size limit.
+	Fragment bool
+	// Events are in order they were delivered.
+	Events []WatchedEvent
+	// Responses are in order they were delivered, events of each response are in Events. Empty if not recorded.
+	Responses []WatchedResponse
+}
+
+// filtered returns whether events of operation type are filtered out by watch.
+func (w WatchedRevisions) filtered(operation OperationType) bool {
+	return (operation == Put && w.FilterPut) || (operation == Delete && w.FilterDelete)
+}
+
+type WatchedEvent struct {
+	Key      string
+	Revision int64
+	// Type is either Put or Delete, event without type is not validated against watch filters.
+	Type OperationType
+	// Value is value of key written by Put event.
+	Value ValueOrHash
+}
+
+// WatchedResponse records header and compaction fields of watch response.
+type WatchedResponse struct {
+	HeaderRevision int64
+	// CompactRevision is set for compaction notification, sent when revision needed by watch was compacted.
+	CompactRevision int64
+	Canceled        bool
+	// ProgressNotify marks progress notification, guaranteeing all events up to header revision were delivered.
+	ProgressNotify bool
+	// Events is number of events delivered by the response.
+	Events int
+}
+
+type keyRevision struct {
+	key      string
+	revision int64
+}
+
+// watchedWrite is a modification of key by operation in history.
+type watchedWrite struct {
+	operation porcupine.Operation
+	writeType OperationType
+}
+
+// ValidateWatchContiguity checks that watch delivered events with increasing revisions, skipping no revision between
+// StartRevision and EndRevision that modified key with watched prefix. Modifications are taken from write history,
+// only writes with known result and revision are considered, as failed ones might not have been persisted.
+// Revisions that didn't modify any watched key, like writes to other keys, legitimately produce no events.
+// Watch filtering out events of a type must deliver none of them, while still delivering all events of other type.
+// Returns description of each violation, including the exact skipped revision.
+func ValidateWatchContiguity(operations []porcupine.Operation, watches []WatchedRevisions) []string {
+	writes := map[keyRevision]watchedWrite{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn || resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			continue
+		}
+		for _, modification := range txnModifications(request.Txn, resp.Txn) {
+			writes[keyRevision{key: modification.Key, revision: resp.Revision}] = watchedWrite{operation: op, writeType: modification.Type}
+		}
+	}
+
+	violations := []string{}
+	for _, watch := range watches {
+		delivered := map[keyRevision]bool{}
+		revisions := map[int64]bool{}
+		var lastRevision int64
+		for _, event := range watch.Events {
+			if event.Revision < lastRevision {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered event of key %q at revision %d after revision %d",
+					watch.Prefix, watch.StartRevision, event.Key, event.Revision, lastRevision))
+			}
+			if event.Revision < watch.StartRevision && watch.CreatedRevision != 0 && watch.CreatedRevision < watch.StartRevision {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from future revision %d created at revision %d delivered event of key %q at revision %d, before store reached start revision",
+					watch.Prefix, watch.StartRevision, watch.CreatedRevision, event.Key, event.Revision))
+			} else if event.Revision < watch.StartRevision {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered event of key %q at older revision %d",
+					watch.Prefix, watch.StartRevision, event.Key, event.Revision))
+			}
+			if watch.filtered(event.Type) {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered %s event of key %q at revision %d, filtered out by watch",
+					watch.Prefix, watch.StartRevision, event.Type, event.Key, event.Revision))
+			}
+			lastRevision = event.Revision
+			delivered[keyRevision{key: event.Key, revision: event.Revision}] = true
+			revisions[event.Revision] = true
+		}
+		var skipped []keyRevision
+		for write, modification := range writes {
+			if write.revision < watch.StartRevision || write.revision > watch.EndRevision || !strings.HasPrefix(write.key, watch.Prefix) || delivered[write] || watch.filtered(modification.writeType) {
+				continue
+			}
+			skipped = append(skipped, write)
+		}
+		sort.Slice(skipped, func(i, j int) bool {
+			if skipped[i].revision != skipped[j].revision {
+				return skipped[i].revision < skipped[j].revision
+			}
+			return skipped[i].key < skipped[j].key
+		})
+		for _, write := range skipped {
+			op := writes[write].operation
+			description := fmt.Sprintf("skipped revision %d", write.revision)
+			if revisions[write.revision] {
+				description = fmt.Sprintf("missed event of revision %d", write.revision)
+			}
+			violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d caught up with revision %d %s, which modified key %q by client: %d, %s",
+				watch.Prefix, watch.StartRevision, watch.EndRevision, description, write.key, op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output)))
+		}
+	}
+	return violations
+}
+
+// txnModifications returns put and delete operations executed by branch of transaction according to resp, including
+// nested transactions. Deletes are included only if they deleted a single key, range deletes don't record which keys they removed.
+func txnModifications(txn *TxnRequest, resp *TxnResponse) []EtcdOperation {
+	ops := txn.Ops
+	if resp.TxnResult {
+		ops = txn.OpsOnFailure
+	}
+	modifications := []EtcdOperation{}
+	for i, op := range ops {
+		if i >= len(resp.OpsResult) {
+			break
+		}
+		result := resp.OpsResult[i]
+		switch op.Type {
+		case Put:
+			modifications = append(modifications, op)
+		case Delete:
+			if result.Deleted > 0 && op.End == "" && !op.WithPrefix {
+				modifications = append(modifications, op)
+			}
+		case NestedTxn:
+			if result.Txn != nil {
+				modifications = append(modifications, txnModifications(op.Txn, result.Txn)...)
+			}
+		}
+	}
+	return modifications
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchContiguity(t *testing.T) {
+	operations := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("/a/1", "1"), Output: putResponse(2)},
+		{ClientId: 1, Input: putRequest("/b/1", "1"), Output: putResponse(3)},
+		{ClientId: 1, Input: deleteRequest("/a/2"), Output: deleteResponse(0, 3)},
+		{ClientId: 1, Input: txnRequest(nil, []EtcdOperation{{Type: Put, Key: "/a/1", Value: ToValueOrHash("2")}, {Type: Put, Key: "/a/2", Value: ToValueOrHash("2")}}), Output: txnResponse([]EtcdOperationResult{{}, {}}, true, 4)},
+		{ClientId: 2, Input: putRequest("/a/3", "3"), Output: failedResponse(errors.New("failed"))},
+		{ClientId: 1, Input: deleteRequest("/a/1"), Output: deleteResponse(1, 5)},
+	}
+	tcs := []struct {
+		name             string
+		watch            WatchedRevisions
+		expectViolations int
+	}{
+		{
+			name: "All revisions modifying watched keys delivered",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 5},
+			}},
+		},
+		{
+			name: "Event of failed put with unknown result is allowed",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 6, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 5}, {Key: "/a/3", Revision: 6},
+			}},
+		},
+		{
+			name: "Revisions before start and after end are not required",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 3, EndRevision: 4, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4},
+			}},
+		},
+		{
+			name: "Revision skipped",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 5},
+			}},
+			expectViolations: 2,
+		},
+		{
+			name: "Event of revision missed",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 4}, {Key: "/a/1", Revision: 5},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name: "Revision went back",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 5},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name: "Event before start revision",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 3, EndRevision: 5, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 5},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name: "Delete events filtered out",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, FilterDelete: true, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2, Type: Put}, {Key: "/a/1", Revision: 4, Type: Put}, {Key: "/a/2", Revision: 4, Type: Put},
+			}},
+		},
+		{
+			name: "Put events filtered out",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, FilterPut: true, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 5, Type: Delete},
+			}},
+		},
+		{
+			name: "Filtered out event delivered",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, FilterPut: true, Events: []WatchedEvent{
+				{Key: "/a/1", Revision: 2, Type: Put}, {Key: "/a/1", Revision: 5, Type: Delete},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Event of not filtered type missed",
+			watch:            WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 5, FilterPut: true},
+			expectViolations: 1,
+		},
+		{
+			name:  "Future watch delivers events once store reaches start revision",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 4, CreatedRevision: 1, EndRevision: 5, Events: []WatchedEvent{{Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 5}}},
+		},
+		{
+			name:             "Future watch delivered event before store reached start revision",
+			watch:            WatchedRevisions{Prefix: "/a/", StartRevision: 4, CreatedRevision: 1, EndRevision: 5, Events: []WatchedEvent{{Key: "/a/1", Revision: 2}, {Key: "/a/1", Revision: 4}, {Key: "/a/2", Revision: 4}, {Key: "/a/1", Revision: 5}}},
+			expectViolations: 1,
+		},
+		{
+			name:  "Future watch canceled before store reached start revision",
+			watch: WatchedRevisions{Prefix: "/a/", StartRevision: 100, CreatedRevision: 1, EndRevision: 99},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, ValidateWatchContiguity(operations, []WatchedRevisions{tc.watch}), tc.expectViolations)
+		})
+	}
+}
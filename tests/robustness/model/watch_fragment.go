@@ -0,0 +1,58 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "fmt"
+
+// ValidateWatchFragmentation checks that watch with fragmentation enabled delivered the same events as watch of
+// the same prefix, start revision and filters without it, so fragments of large responses are reassembled into
+// exactly the events that would be delivered in a single response. Events are compared up to revision both
+// watches caught up with, as one of them might have been broken earlier. Returns description of the first difference.
+func ValidateWatchFragmentation(fragmented, whole WatchedRevisions) error {
+	if fragmented.Prefix != whole.Prefix || fragmented.StartRevision != whole.StartRevision || fragmented.FilterPut != whole.FilterPut || fragmented.FilterDelete != whole.FilterDelete {
+		return fmt.Errorf("watches of prefix %q from revision %d and prefix %q from revision %d are not comparable", fragmented.Prefix, fragmented.StartRevision, whole.Prefix, whole.StartRevision)
+	}
+	end := fragmented.EndRevision
+	if whole.EndRevision < end {
+		end = whole.EndRevision
+	}
+	fragmentedEvents, wholeEvents := eventsUpTo(fragmented.Events, end), eventsUpTo(whole.Events, end)
+	for i := 0; i < len(fragmentedEvents) && i < len(wholeEvents); i++ {
+		if fragmentedEvents[i] != wholeEvents[i] {
+			return fmt.Errorf("watch of prefix %q from revision %d delivered %s as event %d with fragmentation, while %s without it",
+				fragmented.Prefix, fragmented.StartRevision, describeWatchedEvent(fragmentedEvents[i]), i, describeWatchedEvent(wholeEvents[i]))
+		}
+	}
+	if len(fragmentedEvents) != len(wholeEvents) {
+		return fmt.Errorf("watch of prefix %q from revision %d delivered %d events up to revision %d with fragmentation, while %d without it",
+			fragmented.Prefix, fragmented.StartRevision, len(fragmentedEvents), end, len(wholeEvents))
+	}
+	return nil
+}
+
+// eventsUpTo returns events with revision not higher than end.
+func eventsUpTo(events []WatchedEvent, end int64) []WatchedEvent {
+	var result []WatchedEvent
+	for _, event := range events {
+		if event.Revision <= end {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func describeWatchedEvent(event WatchedEvent) string {
+	return fmt.Sprintf("%s of key %q at revision %d with value %s", event.Type, event.Key, event.Revision, describeValueOrHash(event.Value))
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchFragmentation(t *testing.T) {
+	a := WatchedEvent{Key: "/a", Revision: 2, Type: Put, Value: ToValueOrHash("1")}
+	b := WatchedEvent{Key: "/b", Revision: 3, Type: Put, Value: ToValueOrHash("2")}
+	c := WatchedEvent{Key: "/a", Revision: 4, Type: Delete}
+	watch := func(fragment bool, end int64, events ...WatchedEvent) WatchedRevisions {
+		return WatchedRevisions{Prefix: "/", StartRevision: 2, EndRevision: end, Fragment: fragment, Events: events}
+	}
+	tcs := []struct {
+		name        string
+		fragmented  WatchedRevisions
+		whole       WatchedRevisions
+		expectError bool
+	}{
+		{
+			name:       "Identical events",
+			fragmented: watch(true, 4, a, b, c),
+			whole:      watch(false, 4, a, b, c),
+		},
+		{
+			name:       "Events compared up to revision both watches caught up with",
+			fragmented: watch(true, 3, a, b),
+			whole:      watch(false, 4, a, b, c),
+		},
+		{
+			name:        "Missing event",
+			fragmented:  watch(true, 4, a, c),
+			whole:       watch(false, 4, a, b, c),
+			expectError: true,
+		},
+		{
+			name:        "Different value",
+			fragmented:  watch(true, 3, a, WatchedEvent{Key: "/b", Revision: 3, Type: Put, Value: ToValueOrHash("3")}),
+			whole:       watch(false, 3, a, b),
+			expectError: true,
+		},
+		{
+			name:        "Different order",
+			fragmented:  watch(true, 3, b, a),
+			whole:       watch(false, 3, a, b),
+			expectError: true,
+		},
+		{
+			name:        "Different filters",
+			fragmented:  WatchedRevisions{Prefix: "/", StartRevision: 2, EndRevision: 3, Fragment: true, FilterPut: true},
+			whole:       watch(false, 3),
+			expectError: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateWatchFragmentation(tc.fragmented, tc.whole)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
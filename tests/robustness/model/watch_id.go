@@ -0,0 +1,99 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "fmt"
+
+// InvalidWatchId is watch ID of responses not belonging to any watch, like rejected watch creation.
+const InvalidWatchId int64 = -1
+
+// WatchStream records watches created and canceled on a single watch stream. Server assigns watch IDs per stream,
+// starting from 0, so each new stream reassigns watch ID 0. Watch ID requested explicitly by create request can be
+// reassigned on the same stream once the watch owning it is canceled.
+type WatchStream struct {
+	// Creates are keys of watch create requests, in order they were sent. Server answers them in the same order.
+	Creates []string
+	// Responses are in order they were received.
+	Responses []WatchStreamResponse
+}
+
+// WatchStreamResponse records watch ID and events of response received on watch stream.
+type WatchStreamResponse struct {
+	WatchId      int64
+	Created      bool
+	Canceled     bool
+	CancelReason string
+	Events       []WatchedEvent
+}
+
+// ValidateWatchIdReuse checks that responses on watch stream are delivered to watches that own their watch ID.
+// Each watch watches a single key, so event of other key delivered with watch ID reveals event of a previous
+// watch leaking into watch reusing its ID. Rejected watch creation must not claim any watch ID, so its response
+// carries InvalidWatchId, and watch ID can be reassigned only after watch owning it was canceled.
+// Returns description of each violation, together with the number of watches created with watch ID 0.
+func ValidateWatchIdReuse(streams []WatchStream) (violations []string, zeroIdWatches int) {
+	violations = []string{}
+	for i, stream := range streams {
+		// active maps watch ID to key of watch owning it, canceled remembers keys of watches that owned it before.
+		active := map[int64]string{}
+		canceled := map[int64][]string{}
+		nextCreate := 0
+		for _, resp := range stream.Responses {
+			if resp.Created {
+				if nextCreate >= len(stream.Creates) {
+					violations = append(violations, fmt.Sprintf("stream %d: created response of watch ID %d without create request", i, resp.WatchId))
+					continue
+				}
+				key := stream.Creates[nextCreate]
+				nextCreate++
+				if resp.Canceled {
+					if resp.WatchId != InvalidWatchId {
+						violations = append(violations, fmt.Sprintf("stream %d: rejected watch of key %q claimed watch ID %d, reason: %q", i, key, resp.WatchId, resp.CancelReason))
+					}
+					if len(resp.Events) != 0 {
+						violations = append(violations, fmt.Sprintf("stream %d: rejected watch of key %q delivered %d events", i, key, len(resp.Events)))
+					}
+					continue
+				}
+				if owner, ok := active[resp.WatchId]; ok {
+					violations = append(violations, fmt.Sprintf("stream %d: watch of key %q was assigned watch ID %d still owned by watch of key %q", i, key, resp.WatchId, owner))
+				}
+				active[resp.WatchId] = key
+				if resp.WatchId == 0 {
+					zeroIdWatches++
+				}
+			}
+			owner, ok := active[resp.WatchId]
+			for _, event := range resp.Events {
+				switch {
+				case !ok && len(canceled[resp.WatchId]) != 0:
+					violations = append(violations, fmt.Sprintf("stream %d: event of key %q at revision %d delivered with watch ID %d after its watch of key %q was canceled",
+						i, event.Key, event.Revision, resp.WatchId, canceled[resp.WatchId][len(canceled[resp.WatchId])-1]))
+				case !ok:
+					violations = append(violations, fmt.Sprintf("stream %d: event of key %q at revision %d delivered with watch ID %d not assigned to any watch",
+						i, event.Key, event.Revision, resp.WatchId))
+				case event.Key != owner:
+					violations = append(violations, fmt.Sprintf("stream %d: event of key %q at revision %d leaked into watch of key %q with watch ID %d",
+						i, event.Key, event.Revision, owner, resp.WatchId))
+				}
+			}
+			if resp.Canceled && !resp.Created && ok {
+				delete(active, resp.WatchId)
+				canceled[resp.WatchId] = append(canceled[resp.WatchId], owner)
+			}
+		}
+	}
+	return violations, zeroIdWatches
+}
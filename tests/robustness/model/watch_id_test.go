@@ -0,0 +1,107 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchIdReuse(t *testing.T) {
+	a := WatchedEvent{Key: "a", Revision: 2}
+	b := WatchedEvent{Key: "b", Revision: 3}
+	tcs := []struct {
+		name             string
+		stream           WatchStream
+		expectViolations int
+		expectZeroIds    int
+	}{
+		{
+			name: "Watch ID reused after cancel",
+			stream: WatchStream{Creates: []string{"a", "b"}, Responses: []WatchStreamResponse{
+				{WatchId: 0, Created: true},
+				{WatchId: 0, Events: []WatchedEvent{a}},
+				{WatchId: 0, Canceled: true},
+				{WatchId: 0, Created: true},
+				{WatchId: 0, Events: []WatchedEvent{b}},
+			}},
+			expectZeroIds: 2,
+		},
+		{
+			name: "Rejected watch doesn't claim watch ID",
+			stream: WatchStream{Creates: []string{"forbidden", "a"}, Responses: []WatchStreamResponse{
+				{WatchId: InvalidWatchId, Created: true, Canceled: true, CancelReason: "permission denied"},
+				{WatchId: 0, Created: true},
+				{WatchId: 0, Events: []WatchedEvent{a}},
+			}},
+			expectZeroIds: 1,
+		},
+		{
+			name: "Rejected watch claimed watch ID",
+			stream: WatchStream{Creates: []string{"forbidden", "a"}, Responses: []WatchStreamResponse{
+				{WatchId: 0, Created: true, Canceled: true, CancelReason: "permission denied"},
+				{WatchId: 0, Created: true},
+			}},
+			expectViolations: 1,
+			expectZeroIds:    1,
+		},
+		{
+			name: "Event of canceled watch leaked into watch reusing its ID",
+			stream: WatchStream{Creates: []string{"a", "b"}, Responses: []WatchStreamResponse{
+				{WatchId: 1, Created: true},
+				{WatchId: 1, Canceled: true},
+				{WatchId: 1, Created: true},
+				{WatchId: 1, Events: []WatchedEvent{a, b}},
+			}},
+			expectViolations: 1,
+		},
+		{
+			name: "Event delivered after watch was canceled",
+			stream: WatchStream{Creates: []string{"a"}, Responses: []WatchStreamResponse{
+				{WatchId: 0, Created: true},
+				{WatchId: 0, Canceled: true},
+				{WatchId: 0, Events: []WatchedEvent{a}},
+			}},
+			expectViolations: 1,
+			expectZeroIds:    1,
+		},
+		{
+			name: "Watch ID assigned while still owned",
+			stream: WatchStream{Creates: []string{"a", "b"}, Responses: []WatchStreamResponse{
+				{WatchId: 0, Created: true},
+				{WatchId: 0, Created: true},
+			}},
+			expectViolations: 1,
+			expectZeroIds:    2,
+		},
+		{
+			name: "Event with watch ID not assigned to any watch",
+			stream: WatchStream{Creates: []string{"a"}, Responses: []WatchStreamResponse{
+				{WatchId: 0, Created: true},
+				{WatchId: 1, Events: []WatchedEvent{a}},
+			}},
+			expectViolations: 1,
+			expectZeroIds:    1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, zeroIds := ValidateWatchIdReuse([]WatchStream{tc.stream})
+			assert.Len(t, violations, tc.expectViolations, "%v", violations)
+			assert.Equal(t, tc.expectZeroIds, zeroIds)
+		})
+	}
+}
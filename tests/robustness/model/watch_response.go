@@ -0,0 +1,76 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "fmt"
+
+// ValidateWatchResponses checks header and compaction fields of recorded watch responses. Events cannot be newer than
+// header revision of their response. Compaction notification has to cancel watch and can be sent only if compaction
+// removed revision needed by watch, which is the revision after the last delivered event or progress notification, or
+// start revision if neither was delivered. Canceled watch must not deliver any response. Header revision can decrease if watch was
+// resumed on other member, so it's not required to increase. Returns description of each violation.
+func ValidateWatchResponses(watches []WatchedRevisions) []string {
+	violations := []string{}
+	for _, watch := range watches {
+		next := watch.StartRevision
+		delivered := 0
+		canceled := false
+		for _, resp := range watch.Responses {
+			if canceled {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered response with header revision %d after it was canceled",
+					watch.Prefix, watch.StartRevision, resp.HeaderRevision))
+			}
+			canceled = canceled || resp.Canceled
+			needed := next
+			end := delivered + resp.Events
+			if end > len(watch.Events) {
+				end = len(watch.Events)
+			}
+			for _, event := range watch.Events[delivered:end] {
+				if resp.HeaderRevision != 0 && event.Revision > resp.HeaderRevision {
+					violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered event of key %q at revision %d in response with older header revision %d",
+						watch.Prefix, watch.StartRevision, event.Key, event.Revision, resp.HeaderRevision))
+				}
+				if event.Revision >= next {
+					next = event.Revision + 1
+				}
+			}
+			delivered = end
+			if resp.ProgressNotify && resp.HeaderRevision >= next {
+				next = resp.HeaderRevision + 1
+			}
+			if resp.CompactRevision == 0 {
+				continue
+			}
+			if !resp.Canceled {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d was not canceled by compaction notification of revision %d",
+					watch.Prefix, watch.StartRevision, resp.CompactRevision))
+			}
+			if resp.Events != 0 {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d delivered %d events with compaction notification of revision %d",
+					watch.Prefix, watch.StartRevision, resp.Events, resp.CompactRevision))
+			}
+			if resp.HeaderRevision != 0 && resp.CompactRevision > resp.HeaderRevision {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d was compacted at revision %d, newer than header revision %d",
+					watch.Prefix, watch.StartRevision, resp.CompactRevision, resp.HeaderRevision))
+			}
+			if !WatchMayBeCompacted(needed, resp.CompactRevision) {
+				violations = append(violations, fmt.Sprintf("watch of prefix %q from revision %d needing revision %d was compacted at revision %d",
+					watch.Prefix, watch.StartRevision, needed, resp.CompactRevision))
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchResponses(t *testing.T) {
+	events := []WatchedEvent{{Key: "/a/1", Revision: 2}, {Key: "/a/2", Revision: 4}}
+	tcs := []struct {
+		name             string
+		responses        []WatchedResponse
+		expectViolations int
+	}{
+		{
+			name:      "Events delivered up to header revision",
+			responses: []WatchedResponse{{HeaderRevision: 3, Events: 1}, {HeaderRevision: 5, Events: 1}},
+		},
+		{
+			name:      "Header revision decreased after watch resumed on other member",
+			responses: []WatchedResponse{{HeaderRevision: 5, Events: 1}, {HeaderRevision: 4, Events: 1}},
+		},
+		{
+			name:             "Event newer than header revision",
+			responses:        []WatchedResponse{{HeaderRevision: 3, Events: 2}},
+			expectViolations: 1,
+		},
+		{
+			name:      "Compacted revision needed by watch",
+			responses: []WatchedResponse{{HeaderRevision: 3, Events: 1}, {HeaderRevision: 6, CompactRevision: 4, Canceled: true}},
+		},
+		{
+			name:             "Compacted revision already delivered",
+			responses:        []WatchedResponse{{HeaderRevision: 5, Events: 2}, {HeaderRevision: 6, CompactRevision: 4, Canceled: true}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Compacted revision covered by progress notification",
+			responses:        []WatchedResponse{{HeaderRevision: 3, Events: 1}, {HeaderRevision: 5, ProgressNotify: true}, {HeaderRevision: 6, CompactRevision: 4, Canceled: true}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Compaction notification didn't cancel watch",
+			responses:        []WatchedResponse{{HeaderRevision: 6, CompactRevision: 4}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Compaction notification delivered events",
+			responses:        []WatchedResponse{{HeaderRevision: 6, CompactRevision: 4, Canceled: true, Events: 2}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Compact revision newer than header revision",
+			responses:        []WatchedResponse{{HeaderRevision: 3, CompactRevision: 4, Canceled: true}},
+			expectViolations: 1,
+		},
+		{
+			name:             "Response delivered after watch was canceled",
+			responses:        []WatchedResponse{{HeaderRevision: 6, CompactRevision: 4, Canceled: true}, {HeaderRevision: 6, Events: 2}},
+			expectViolations: 1,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			watch := WatchedRevisions{Prefix: "/a/", StartRevision: 1, EndRevision: 4, Events: events, Responses: tc.responses}
+			assert.Len(t, ValidateWatchResponses([]WatchedRevisions{watch}), tc.expectViolations)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// ValidateWatchedPuts checks that every committed put of key with watched prefix was observed, together with the
+// value it wrote, by at least one watch active at its revision. Watch is active from StartRevision until EndRevision,
+// the revision it's known to have caught up with. Watch compacted before catching up with put isn't active at its
+// revision, as event legitimately lost to compaction is never delivered, and watch resumed from compact revision
+// starts after it. Watches filtering out puts are not considered. Put that active watches didn't observe is reported
+// as lost event. Only puts with known result and revision are considered, as failed ones might not have been persisted.
+func ValidateWatchedPuts(operations []porcupine.Operation, watches []WatchedRevisions) []string {
+	puts := map[keyRevision]porcupine.Operation{}
+	values := map[keyRevision]ValueOrHash{}
+	for _, op := range operations {
+		request := op.Input.(EtcdRequest)
+		resp := op.Output.(EtcdNonDeterministicResponse)
+		if request.Type != Txn || resp.Err != nil || resp.ResultUnknown || resp.Txn == nil {
+			continue
+		}
+		for _, modification := range txnModifications(request.Txn, resp.Txn) {
+			if modification.Type != Put {
+				continue
+			}
+			put := keyRevision{key: modification.Key, revision: resp.Revision}
+			puts[put] = op
+			values[put] = modification.Value
+		}
+	}
+
+	var watched []keyRevision
+	for put := range puts {
+		watched = append(watched, put)
+	}
+	sort.Slice(watched, func(i, j int) bool {
+		if watched[i].revision != watched[j].revision {
+			return watched[i].revision < watched[j].revision
+		}
+		return watched[i].key < watched[j].key
+	})
+
+	violations := []string{}
+	for _, put := range watched {
+		var active int
+		var observed bool
+		var observedValues []ValueOrHash
+		for _, watch := range watches {
+			if !strings.HasPrefix(put.key, watch.Prefix) || watch.FilterPut || put.revision < watch.StartRevision || put.revision > watch.EndRevision {
+				continue
+			}
+			active++
+			for _, event := range watch.Events {
+				if event.Key != put.key || event.Revision != put.revision || event.Type == Delete {
+					continue
+				}
+				if event.Type == "" || event.Value == values[put] {
+					observed = true
+				} else {
+					observedValues = append(observedValues, event.Value)
+				}
+			}
+		}
+		if active == 0 || observed {
+			continue
+		}
+		op := puts[put]
+		description := fmt.Sprintf("none of %d watches active at revision %d observed it", active, put.revision)
+		if len(observedValues) != 0 {
+			description = fmt.Sprintf("watches active at revision %d observed it only with value %s", put.revision, describeValueOrHash(observedValues[0]))
+		}
+		violations = append(violations, fmt.Sprintf("put of key %q with value %s at revision %d by client: %d, %s, %s",
+			put.key, describeValueOrHash(values[put]), put.revision, op.ClientId, NonDeterministicModel.DescribeOperation(op.Input, op.Output), description))
+	}
+	return violations
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWatchedPuts(t *testing.T) {
+	operations := []porcupine.Operation{
+		{ClientId: 1, Input: putRequest("/a/1", "1"), Output: putResponse(2)},
+		{ClientId: 1, Input: putRequest("/b/1", "1"), Output: putResponse(3)},
+		{ClientId: 2, Input: putRequest("/a/2", "2"), Output: putResponse(4)},
+		{ClientId: 2, Input: putRequest("/a/3", "3"), Output: failedResponse(errors.New("failed"))},
+		{ClientId: 1, Input: deleteRequest("/a/1"), Output: deleteResponse(1, 5)},
+	}
+	put1 := WatchedEvent{Key: "/a/1", Revision: 2, Type: Put, Value: ToValueOrHash("1")}
+	put2 := WatchedEvent{Key: "/a/2", Revision: 4, Type: Put, Value: ToValueOrHash("2")}
+	tcs := []struct {
+		name             string
+		watches          []WatchedRevisions
+		expectViolations int
+	}{
+		{
+			name: "All puts observed",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{put1, put2}},
+			},
+		},
+		{
+			name: "Puts observed by different watches",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{put1}},
+				{Prefix: "/a/", StartRevision: 3, EndRevision: 5, Events: []WatchedEvent{put2}},
+			},
+		},
+		{
+			name: "Put not observed by any active watch",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{put1}},
+				{Prefix: "/a/", StartRevision: 3, EndRevision: 4},
+			},
+			expectViolations: 1,
+		},
+		{
+			name: "Put observed with different value",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 5, Events: []WatchedEvent{put1, {Key: "/a/2", Revision: 4, Type: Put, Value: ToValueOrHash("3")}}},
+			},
+			expectViolations: 1,
+		},
+		{
+			name: "Puts outside of watch window are not required",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 3, EndRevision: 3},
+			},
+		},
+		{
+			name: "Put after watch was compacted is not required",
+			watches: []WatchedRevisions{
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 2, Events: []WatchedEvent{put1}, Responses: []WatchedResponse{{HeaderRevision: 2, Events: 1}, {HeaderRevision: 5, CompactRevision: 5, Canceled: true}}},
+				{Prefix: "/a/", StartRevision: 5, EndRevision: 5},
+			},
+		},
+		{
+			name: "Puts of other prefix and filtered out puts are not required",
+			watches: []WatchedRevisions{
+				{Prefix: "/b/", StartRevision: 1, EndRevision: 2},
+				{Prefix: "/a/", StartRevision: 1, EndRevision: 5, FilterPut: true},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateWatchedPuts(operations, tc.watches)
+			assert.Len(t, violations, tc.expectViolations, "%v", violations)
+		})
+	}
+}
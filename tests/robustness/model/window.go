@@ -0,0 +1,317 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+)
+
+// CheckWindow configures checking linearizability of history in overlapping windows, so the time needed to check
+// long runs grows linearly with their length instead of exploding with the number of concurrent operations.
+// All operations are still recorded, but porcupine checks operations called within Size from the window start,
+// starting from the model state at the window start. That state is rebuilt by replaying operations with known
+// revision in order of revisions, which also validates their responses. Next window starts Size-Overlap after
+// the previous one.
+//
+// Checking in windows is a tradeoff, a violation that requires operations further apart than Overlap to be observed,
+// for example a stale read of revision overwritten long ago, might be missed. Overlap should be longer than
+// any request can take, so each pair of concurrent operations is checked together in at least one window.
+// Windows are merged when the model state at window start cannot be determined, so splitting never reports
+// a violation that checking whole history at once wouldn't report.
+//
+// Zero Size checks whole history at once.
+type CheckWindow struct {
+	Size    time.Duration
+	Overlap time.Duration
+}
+
+func (w CheckWindow) validate() error {
+	if w.Size < 0 || w.Overlap < 0 {
+		return fmt.Errorf("check window size and overlap cannot be negative, got size: %s, overlap: %s", w.Size, w.Overlap)
+	}
+	if w.Size != 0 && w.Overlap >= w.Size {
+		return fmt.Errorf("check window overlap %s needs to be shorter than its size %s", w.Overlap, w.Size)
+	}
+	return nil
+}
+
+// historyWindow is a part of history that is checked from a known model state.
+type historyWindow struct {
+	// Start and End are call times bounding operations of the window.
+	Start, End time.Duration
+	// Revision is revision of the model state at window start.
+	Revision int64
+	// states are possible model states at window start, empty if it should be initialized from the first operation.
+	states     nonDeterministicState
+	Operations []porcupine.Operation
+}
+
+func (w historyWindow) String() string {
+	return fmt.Sprintf("window [%s, %s) starting from revision %d with %d operations", w.Start, w.End, w.Revision, len(w.Operations))
+}
+
+// model returns NonDeterministicModel that starts from the model state at window start.
+func (w historyWindow) model() porcupine.Model {
+	data, err := json.Marshal(w.states)
+	if err != nil {
+		panic(err)
+	}
+	init := string(data)
+	m := NonDeterministicModel
+	m.Init = func() interface{} {
+		return init
+	}
+	return m
+}
+
+// windowSplitter splits history into overlapping windows one by one, so windows that were checked can be discarded.
+type windowSplitter struct {
+	window CheckWindow
+	// operations are all operations of history sorted by call time.
+	operations []porcupine.Operation
+	// replay are operations with known revision sorted by revision, used to rebuild model state at window start.
+	replay []porcupine.Operation
+	// unknown are failed write operations with unknown revision sorted by call time. They are considered both
+	// persisted and lost during replay, and included in every window after their call, as they might be applied late.
+	unknown []porcupine.Operation
+	// returned are operations with known revision sorted by return time, with maxRevision holding the highest revision
+	// returned by them up to each index.
+	returned    []porcupine.Operation
+	maxRevision []int64
+	lastCall    int64
+
+	// start, revision and states describe the next window start.
+	start    int64
+	revision int64
+	states   nonDeterministicState
+	replayed int
+	forked   int
+	done     bool
+}
+
+func newWindowSplitter(operations []porcupine.Operation, window CheckWindow) *windowSplitter {
+	s := &windowSplitter{window: window}
+	s.operations = make([]porcupine.Operation, len(operations))
+	copy(s.operations, operations)
+	sort.SliceStable(s.operations, func(i, j int) bool {
+		return s.operations[i].Call < s.operations[j].Call
+	})
+	if len(s.operations) == 0 {
+		s.done = true
+		return s
+	}
+	s.start = s.operations[0].Call
+	for _, op := range s.operations {
+		if op.Call > s.lastCall {
+			s.lastCall = op.Call
+		}
+		if _, ok := knownRevision(op); ok {
+			s.replay = append(s.replay, op)
+			s.returned = append(s.returned, op)
+			continue
+		}
+		if op.Output.(EtcdNonDeterministicResponse).Err != nil && !isReadOnly(op.Input.(EtcdRequest)) {
+			s.unknown = append(s.unknown, op)
+		}
+	}
+	sort.SliceStable(s.replay, func(i, j int) bool {
+		ri, _ := knownRevision(s.replay[i])
+		rj, _ := knownRevision(s.replay[j])
+		return ri < rj
+	})
+	sort.SliceStable(s.returned, func(i, j int) bool {
+		return s.returned[i].Return < s.returned[j].Return
+	})
+	s.maxRevision = make([]int64, len(s.returned))
+	var maxRevision int64
+	for i, op := range s.returned {
+		rev, _ := knownRevision(op)
+		if rev > maxRevision {
+			maxRevision = rev
+		}
+		s.maxRevision[i] = maxRevision
+	}
+	return s
+}
+
+// next returns the next window of history, false if whole history was already returned.
+func (s *windowSplitter) next() (historyWindow, bool) {
+	if s.done {
+		return historyWindow{}, false
+	}
+	end := s.start + int64(s.window.Size)
+	last := end > s.lastCall
+	var (
+		nextStart    int64
+		nextRevision int64
+		nextStates   nonDeterministicState
+	)
+	if !last {
+		nextStart = s.start + int64(s.window.Size-s.window.Overlap)
+		nextRevision = s.revisionAt(nextStart)
+		var ok bool
+		nextStates, ok = s.replayUntil(nextRevision, nextStart)
+		// Model state at next window start cannot be determined, check the rest of history in this window.
+		last = !ok
+	}
+	w := historyWindow{
+		Start:    time.Duration(s.start),
+		End:      time.Duration(end),
+		Revision: s.revision,
+		states:   s.states,
+	}
+	endRevision := s.revisionAt(end)
+	for _, op := range s.operations {
+		if !last && op.Call >= end {
+			break
+		}
+		if rev, ok := knownRevision(op); ok {
+			// Operations called before window start are checked only if they might have been applied within the window.
+			// Operations called after window start are always checked to validate that they observe the window state.
+			if (rev > s.revision || op.Call >= s.start) && (last || rev <= endRevision) {
+				w.Operations = append(w.Operations, op)
+			}
+			continue
+		}
+		if op.Return >= s.start {
+			w.Operations = append(w.Operations, op)
+		}
+	}
+	if last {
+		w.End = time.Duration(s.lastCall + 1)
+		s.done = true
+		return w, true
+	}
+	s.start, s.revision, s.states = nextStart, nextRevision, nextStates
+	return w, true
+}
+
+// revisionAt returns the highest revision returned by operation before the time.
+func (s *windowSplitter) revisionAt(t int64) int64 {
+	i := sort.Search(len(s.returned), func(i int) bool {
+		return s.returned[i].Return >= t
+	})
+	if i == 0 {
+		return 0
+	}
+	return s.maxRevision[i-1]
+}
+
+// replayUntil steps model states through operations up to the revision, and failed operations called before the time.
+// Returns false if operations cannot be replayed in order of revisions.
+func (s *windowSplitter) replayUntil(revision int64, call int64) (nonDeterministicState, bool) {
+	states := s.states
+	for s.replayed < len(s.replay) {
+		rev, _ := knownRevision(s.replay[s.replayed])
+		if rev > revision {
+			break
+		}
+		// Operations with the same revision were executed in unknown order, for example reads could be called
+		// before write they observe, so retry them until all succeed.
+		group := s.replay[s.replayed:]
+		size := sort.Search(len(group), func(i int) bool {
+			r, _ := knownRevision(group[i])
+			return r > rev
+		})
+		group = group[:size]
+		firstCall := group[0].Call
+		for _, op := range group {
+			if op.Call < firstCall {
+				firstCall = op.Call
+			}
+		}
+		states = s.forkUntil(states, firstCall)
+		var ok bool
+		states, ok = stepInAnyOrder(states, group)
+		if !ok {
+			return nil, false
+		}
+		s.replayed += size
+	}
+	return s.forkUntil(states, call), true
+}
+
+// forkUntil steps model states through failed operations with unknown revision called before the time.
+func (s *windowSplitter) forkUntil(states nonDeterministicState, call int64) nonDeterministicState {
+	for s.forked < len(s.unknown) && s.unknown[s.forked].Call < call {
+		op := s.unknown[s.forked]
+		_, states = states.Step(op.Input.(EtcdRequest), op.Output.(EtcdNonDeterministicResponse))
+		s.forked++
+	}
+	return states
+}
+
+func stepInAnyOrder(states nonDeterministicState, operations []porcupine.Operation) (nonDeterministicState, bool) {
+	pending := operations
+	for len(pending) > 0 {
+		var failed []porcupine.Operation
+		for _, op := range pending {
+			ok, newStates := states.Step(op.Input.(EtcdRequest), op.Output.(EtcdNonDeterministicResponse))
+			if !ok {
+				failed = append(failed, op)
+				continue
+			}
+			states = newStates
+		}
+		if len(failed) == len(pending) {
+			return nil, false
+		}
+		pending = failed
+	}
+	return states, true
+}
+
+// knownRevision returns revision of operation, false if operation failed and its revision is not known.
+func knownRevision(op porcupine.Operation) (int64, bool) {
+	response := op.Output.(EtcdNonDeterministicResponse)
+	if response.Err != nil || response.Revision == 0 {
+		return 0, false
+	}
+	return response.Revision, true
+}
+
+// isReadOnly returns whether request cannot change the model state.
+func isReadOnly(request EtcdRequest) bool {
+	switch request.Type {
+	case Txn:
+		return isReadOnlyTxn(request.Txn)
+	case Defragment:
+		return true
+	default:
+		return false
+	}
+}
+
+func isReadOnlyTxn(txn *TxnRequest) bool {
+	for _, ops := range [][]EtcdOperation{txn.Ops, txn.OpsOnFailure} {
+		for _, op := range ops {
+			switch op.Type {
+			case Range:
+			case NestedTxn:
+				if !isReadOnlyTxn(op.Txn) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckInWindows(t *testing.T) {
+	window := CheckWindow{Size: 10, Overlap: 4}
+	tcs := []struct {
+		name               string
+		operations         []porcupine.Operation
+		expectWindows      int
+		expectLinearizable bool
+	}{
+		{
+			name: "Sequential history is split into overlapping windows",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 0, Output: putResponse(2), Return: 1},
+				{ClientId: 1, Input: getRequest("key"), Call: 2, Output: getResponse("key", "1", 2, 2), Return: 3},
+				{ClientId: 1, Input: putRequest("key", "2"), Call: 4, Output: putResponse(3), Return: 5},
+				{ClientId: 1, Input: getRequest("key"), Call: 6, Output: getResponse("key", "2", 3, 3), Return: 7},
+				{ClientId: 1, Input: putRequest("key", "3"), Call: 8, Output: putResponse(4), Return: 9},
+				{ClientId: 1, Input: getRequest("key"), Call: 10, Output: getResponse("key", "3", 4, 4), Return: 11},
+				{ClientId: 1, Input: putRequest("key", "4"), Call: 12, Output: putResponse(5), Return: 13},
+				{ClientId: 1, Input: getRequest("key"), Call: 14, Output: getResponse("key", "4", 5, 5), Return: 15},
+				{ClientId: 2, Input: getRequest("key"), Call: 16, Output: getResponse("key", "4", 5, 5), Return: 17},
+			},
+			expectWindows:      3,
+			expectLinearizable: true,
+		},
+		{
+			name: "Stale read called after window start",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 0, Output: putResponse(2), Return: 1},
+				{ClientId: 1, Input: putRequest("key", "2"), Call: 4, Output: putResponse(3), Return: 5},
+				{ClientId: 1, Input: putRequest("key", "3"), Call: 8, Output: putResponse(4), Return: 9},
+				{ClientId: 1, Input: putRequest("key", "4"), Call: 12, Output: putResponse(5), Return: 13},
+				{ClientId: 2, Input: getRequest("key"), Call: 14, Output: getResponse("key", "2", 3, 3), Return: 15},
+				{ClientId: 2, Input: getRequest("key"), Call: 16, Output: getResponse("key", "4", 5, 5), Return: 17},
+			},
+			expectWindows:      3,
+			expectLinearizable: false,
+		},
+		{
+			name: "Failed put applied after window start",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("a", "1"), Call: 0, Output: putResponse(2), Return: 1},
+				{ClientId: 2, Input: putRequest("b", "late"), Call: 2, Output: failedResponse(errors.New("failed")), Return: 100},
+				{ClientId: 1, Input: getRequest("a"), Call: 3, Output: getResponse("a", "1", 2, 2), Return: 4},
+				{ClientId: 1, Input: putRequest("a", "2"), Call: 6, Output: putResponse(3), Return: 7},
+				{ClientId: 1, Input: getRequest("b"), Call: 14, Output: getResponse("b", "late", 4, 4), Return: 15},
+			},
+			expectWindows:      2,
+			expectLinearizable: true,
+		},
+		{
+			name: "Windows are merged if state at window start cannot be replayed",
+			operations: []porcupine.Operation{
+				{ClientId: 1, Input: putRequest("key", "1"), Call: 0, Output: putResponse(2), Return: 1},
+				{ClientId: 2, Input: putRequest("key", "2"), Call: 2, Output: putResponse(2), Return: 3},
+				{ClientId: 1, Input: getRequest("key"), Call: 12, Output: getResponse("key", "2", 2, 2), Return: 13},
+			},
+			expectWindows:      1,
+			expectLinearizable: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			splitter := newWindowSplitter(tc.operations, window)
+			windows := 0
+			linearizable := true
+			for {
+				w, ok := splitter.next()
+				if !ok {
+					break
+				}
+				windows++
+				if porcupine.CheckOperationsTimeout(w.model(), w.Operations, time.Minute) != porcupine.Ok {
+					linearizable = false
+				}
+			}
+			assert.Equal(t, tc.expectWindows, windows)
+			assert.Equal(t, tc.expectLinearizable, linearizable)
+			assert.Equal(t, tc.expectLinearizable, porcupine.CheckOperations(NonDeterministicModel, tc.operations))
+		})
+	}
+}
+
+func TestCheckWindowValidate(t *testing.T) {
+	assert.NoError(t, CheckWindow{}.validate())
+	assert.NoError(t, CheckWindow{Size: time.Minute, Overlap: 10 * time.Second}.validate())
+	assert.Error(t, CheckWindow{Size: time.Minute, Overlap: time.Minute}.validate())
+	assert.Error(t, CheckWindow{Size: -time.Minute}.validate())
+}
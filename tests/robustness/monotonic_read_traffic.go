@@ -0,0 +1,165 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// monotonicReadTraffic reads and writes keys while periodically reconnecting client to a random member.
+// Client is treated as a single logical session, so read after reconnect must not observe revision older
+// than the one observed before it, no matter which member serves it.
+type monotonicReadTraffic struct {
+	keyCount int
+	// reconnectPeriod is the time between forced reconnects of each client.
+	reconnectPeriod time.Duration
+	report          *monotonicReadReport
+}
+
+func newMonotonicReadTraffic(keyCount int, reconnectPeriod time.Duration) monotonicReadTraffic {
+	return monotonicReadTraffic{
+		keyCount:        keyCount,
+		reconnectPeriod: reconnectPeriod,
+	}
+}
+
+func (t monotonicReadTraffic) ForRun() Traffic {
+	t.report = &monotonicReadReport{}
+	return t
+}
+
+func (t monotonicReadTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateMonotonicReads(tt, lg, t)
+}
+
+func (t monotonicReadTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	var lastRevision int64
+	lastReconnect := time.Now()
+	reconnected := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		if time.Since(lastReconnect) >= t.reconnectPeriod {
+			lastReconnect = time.Now()
+			reconnected = t.reconnect(ctx, c)
+		}
+		key := fmt.Sprintf("%d", rand.Int()%t.keyCount)
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		_, revision, err := c.GetWithRevision(getCtx, key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			continue
+		}
+		if revision < lastRevision {
+			t.report.Regressed(fmt.Sprintf("client: %d, key: %q, revision: %d, last seen revision: %d, after reconnect: %v",
+				clientId, key, revision, lastRevision, reconnected))
+		} else {
+			lastRevision = revision
+		}
+		t.report.Read(reconnected)
+		reconnected = false
+		limiter.Wait(ctx)
+
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err = c.Put(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+	}
+}
+
+// reconnect connects client to a random cluster member, returns false if members couldn't be listed or connected.
+func (t monotonicReadTraffic) reconnect(ctx context.Context, c *recordingClient) bool {
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.MemberList(listCtx)
+	cancel()
+	if err != nil {
+		return false
+	}
+	endpoints := []string{}
+	for _, m := range resp.Members {
+		if len(m.ClientURLs) != 0 {
+			endpoints = append(endpoints, m.ClientURLs[0])
+		}
+	}
+	if len(endpoints) == 0 {
+		return false
+	}
+	if err := c.Reconnect([]string{endpoints[rand.Intn(len(endpoints))]}); err != nil {
+		return false
+	}
+	t.report.Reconnected()
+	return true
+}
+
+// monotonicReadReport collects results of monotonicReadTraffic validation from all clients.
+type monotonicReadReport struct {
+	mux                 sync.Mutex
+	reads               int
+	readsAfterReconnect int
+	reconnects          int
+	regressions         []string
+}
+
+func (r *monotonicReadReport) Read(afterReconnect bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if afterReconnect {
+		r.readsAfterReconnect++
+	}
+}
+
+func (r *monotonicReadReport) Reconnected() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reconnects++
+}
+
+func (r *monotonicReadReport) Regressed(regression string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.regressions = append(r.regressions, regression)
+}
+
+func validateMonotonicReads(t *testing.T, lg *zap.Logger, traffic monotonicReadTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Monotonic read traffic", zap.Int("reads", r.reads), zap.Int("reads-after-reconnect", r.readsAfterReconnect), zap.Int("reconnects", r.reconnects))
+	for _, regression := range r.regressions {
+		t.Errorf("Broke monotonic reads guarantee: Read observed revision older than previous read of the same client, %s", regression)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.readsAfterReconnect == 0 {
+		t.Errorf("No read was done after reconnect, reconnectPeriod: %s, reconnects: %d", traffic.reconnectPeriod, r.reconnects)
+	}
+}
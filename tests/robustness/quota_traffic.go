@@ -0,0 +1,237 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+const (
+	// quotaBackendBytes is space quota of members in quota scenario, small enough to be exceeded within seconds.
+	quotaBackendBytes = 4 * 1024 * 1024
+	// quotaRecoveryTimeout limits how long writes can be rejected after space is reclaimed and alarm disarmed.
+	quotaRecoveryTimeout = 10 * time.Second
+)
+
+// quotaTraffic fills members backend until space quota is exceeded and validates writes are rejected until space is
+// reclaimed. First client writes large values and, once rejected, recovers by compacting, defragmenting all members
+// and disarming alarms, expecting writes to resume. Other clients read and write small values to observe the rejection.
+type quotaTraffic struct {
+	prefix     string
+	keyCount   int
+	valueSize  int
+	quotaBytes int64
+	report     *quotaReport
+}
+
+func newQuotaTraffic(prefix string, keyCount, valueSize int, quotaBytes int64) quotaTraffic {
+	return quotaTraffic{
+		prefix:     prefix,
+		keyCount:   keyCount,
+		valueSize:  valueSize,
+		quotaBytes: quotaBytes,
+	}
+}
+
+func (t quotaTraffic) ForRun() Traffic {
+	t.report = &quotaReport{}
+	return t
+}
+
+func (t quotaTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateQuotaTraffic(tt, lg, t)
+}
+
+func (t quotaTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	if clientId == 0 {
+		// Alarm needs to be disarmed, so writes done after traffic finishes succeed. Failure is caught by them.
+		defer t.recover(ctx, c, ids)
+	}
+	// Without compaction every write is retained, so quota is exceeded after writing a few times its size.
+	fillLimit := int(4 * t.quotaBytes / int64(t.valueSize))
+	filled := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := fmt.Sprintf("%s%d", t.prefix, rand.Intn(t.keyCount))
+		limiter.Wait(ctx)
+		var err error
+		switch {
+		case clientId == 0:
+			err = t.put(ctx, c, key, ids.RequestId(), t.valueSize)
+		case rand.Intn(2) == 0:
+			err = t.put(ctx, c, key, ids.RequestId(), 0)
+		default:
+			getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+			_, err = c.Get(getCtx, key)
+			cancel()
+		}
+		limiter.Adapt(ctx, err)
+		if !errors.Is(err, rpctypes.ErrNoSpace) {
+			if clientId == 0 && err == nil {
+				filled++
+				if filled == fillLimit {
+					t.report.NotRejected(filled)
+				}
+			}
+			continue
+		}
+		t.report.Rejected()
+		if clientId == 0 {
+			took, err := t.recover(ctx, c, ids)
+			if ctx.Err() != nil {
+				return
+			}
+			t.report.Recovered(took, err)
+			filled = 0
+		}
+	}
+}
+
+// put writes value padded to size, so client writing large values fills the backend quickly.
+func (t quotaTraffic) put(ctx context.Context, c *recordingClient, key string, id int, size int) error {
+	value := fmt.Sprintf("%d", id)
+	if size > len(value) {
+		value += strings.Repeat("-", size-len(value))
+	}
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return c.Put(putCtx, key, value)
+}
+
+// recover reclaims space on all members and disarms alarms until write succeeds, returning how long it took and
+// error of the last write. Members can raise alarm again, for example when one was down during defragmentation,
+// so steps are repeated until quotaRecoveryTimeout.
+func (t quotaTraffic) recover(ctx context.Context, c *recordingClient, ids identity.Provider) (time.Duration, error) {
+	start := time.Now()
+	var err error
+	for time.Since(start) < quotaRecoveryTimeout && ctx.Err() == nil {
+		t.reclaimSpace(ctx, c)
+		err = t.put(ctx, c, fmt.Sprintf("%s%d", t.prefix, rand.Intn(t.keyCount)), ids.RequestId(), 0)
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return time.Since(start), err
+}
+
+// reclaimSpace compacts all revisions, defragments every member and disarms all alarms. Errors are ignored,
+// as recovery is confirmed by a successful write.
+func (t quotaTraffic) reclaimSpace(ctx context.Context, c *recordingClient) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, t.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	if err == nil {
+		compactCtx, cancel := context.WithTimeout(ctx, CompactTimeout)
+		c.Compact(compactCtx, resp.Header.Revision, true)
+		cancel()
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	members, err := c.client.MemberList(listCtx)
+	cancel()
+	if err == nil {
+		for _, member := range members.Members {
+			if len(member.ClientURLs) == 0 {
+				continue
+			}
+			defragCtx, cancel := context.WithTimeout(ctx, defragmentTimeout)
+			c.DefragmentMember(defragCtx, member.ClientURLs[0])
+			cancel()
+		}
+	}
+
+	alarmCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	alarms, err := c.client.AlarmList(alarmCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+	for _, alarm := range alarms.Alarms {
+		disarmCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		c.client.AlarmDisarm(disarmCtx, (*clientv3.AlarmMember)(alarm))
+		cancel()
+	}
+}
+
+// quotaReport collects results of quotaTraffic validation from all clients.
+type quotaReport struct {
+	mux sync.Mutex
+	// rejected counts writes rejected because space quota was exceeded.
+	rejected   int
+	recoveries int
+	// maxRecovery is the longest time it took for writes to resume.
+	maxRecovery time.Duration
+	violations  []string
+}
+
+func (r *quotaReport) Rejected() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.rejected++
+}
+
+func (r *quotaReport) Recovered(took time.Duration, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("writes still fail %s after reclaiming space, err: %v", took, err))
+		return
+	}
+	r.recoveries++
+	if took > r.maxRecovery {
+		r.maxRecovery = took
+	}
+}
+
+func (r *quotaReport) NotRejected(writes int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, fmt.Sprintf("%d large writes succeeded without exceeding space quota", writes))
+}
+
+func validateQuotaTraffic(t *testing.T, lg *zap.Logger, traffic quotaTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Quota traffic", zap.Int("rejected", r.rejected), zap.Int("recoveries", r.recoveries), zap.Duration("max-recovery", r.maxRecovery))
+	for _, violation := range r.violations {
+		t.Errorf("Broke quota guarantee: Writes are rejected once space quota is exceeded and resume after space is reclaimed and alarm is disarmed, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.recoveries == 0 {
+		t.Errorf("Space quota was never exceeded and recovered, quotaBytes: %d, valueSize: %d", traffic.quotaBytes, traffic.valueSize)
+	}
+}
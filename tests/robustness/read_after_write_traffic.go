@@ -0,0 +1,123 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// readAfterWriteTraffic puts a key and then reads it with linearizable read, fenced by the put revision.
+// Read must observe the put, or a newer put of other client superseding it. Keys are never deleted.
+type readAfterWriteTraffic struct {
+	keyCount int
+	report   *readAfterWriteReport
+}
+
+func newReadAfterWriteTraffic(keyCount int) readAfterWriteTraffic {
+	return readAfterWriteTraffic{
+		keyCount: keyCount,
+	}
+}
+
+func (t readAfterWriteTraffic) ForRun() Traffic {
+	t.report = &readAfterWriteReport{}
+	return t
+}
+
+func (t readAfterWriteTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateReadAfterWrite(tt, lg, t)
+}
+
+func (t readAfterWriteTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := fmt.Sprintf("%d", rand.Int()%t.keyCount)
+		value := fmt.Sprintf("%d", ids.RequestId())
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		writeRevision, err := c.PutWithRevision(putCtx, key, value)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			continue
+		}
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		kv, readRevision, err := c.GetWithRevision(getCtx, key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+		if err != nil {
+			continue
+		}
+		t.report.Read(clientId, model.ReadAfterWrite{
+			Key:           key,
+			Value:         value,
+			WriteRevision: writeRevision,
+			ReadRevision:  readRevision,
+			ReadKv:        kv,
+		})
+	}
+}
+
+// readAfterWriteReport collects results of readAfterWriteTraffic validation from all clients.
+type readAfterWriteReport struct {
+	mux        sync.Mutex
+	reads      int
+	superseded int
+	violations []string
+}
+
+func (r *readAfterWriteReport) Read(clientId int, read model.ReadAfterWrite) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if read.Superseded() {
+		r.superseded++
+	}
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, err))
+	}
+}
+
+func validateReadAfterWrite(t *testing.T, lg *zap.Logger, traffic readAfterWriteTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Read after write traffic", zap.Int("reads", r.reads), zap.Int("superseded", r.superseded))
+	for _, violation := range r.violations {
+		t.Errorf("Broke read after write guarantee: Linearizable read didn't observe preceding write, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.reads == 0 {
+		t.Errorf("No read was done after successful write")
+	}
+}
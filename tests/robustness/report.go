@@ -20,21 +20,44 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/anishathalye/porcupine"
 	"go.uber.org/zap"
 
 	"go.etcd.io/etcd/tests/v3/framework/e2e"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
 )
 
 type report struct {
-	lg                *zap.Logger
+	lg *zap.Logger
+	// path is the results directory, prepared by testResultsDirectory before the run.
+	path              string
 	clus              *e2e.EtcdProcessCluster
 	responses         [][]watchResponse
 	events            [][]watchEvent
 	operations        []porcupine.Operation
 	patchedOperations []porcupine.Operation
 	visualizeHistory  func(path string)
+	// resolutions explain why operations with unknown result were resolved, see model.ResolveUnknownOperations.
+	resolutions []string
+	// baseTime is when traffic started, call and return time of operations are relative to it.
+	baseTime time.Time
+	// timelineKeys are keys whose timeline is saved, see timelineKeysEnv.
+	timelineKeys []string
+}
+
+// timelineKeysEnv lists comma separated keys whose timeline of operations and watch events is saved to results
+// directory, to help triage violations of a single key.
+const timelineKeysEnv = "ROBUSTNESS_TIMELINE_KEYS"
+
+// timelineKeys returns keys listed by timelineKeysEnv.
+func timelineKeys() []string {
+	value, ok := os.LookupEnv(timelineKeysEnv)
+	if !ok || value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
 }
 
 func testResultsDirectory(t *testing.T) string {
@@ -62,7 +85,7 @@ func testResultsDirectory(t *testing.T) string {
 }
 
 func (r *report) Report(t *testing.T, force bool) {
-	path := testResultsDirectory(t)
+	path := r.path
 	if t.Failed() || force {
 		for i, member := range r.clus.Procs {
 			memberDataDir := filepath.Join(path, member.Config().Name)
@@ -80,12 +103,40 @@ func (r *report) Report(t *testing.T, force bool) {
 		if r.patchedOperations != nil {
 			persistOperationHistory(t, r.lg, filepath.Join(path, "patched-history.json"), r.patchedOperations)
 		}
+		if r.resolutions != nil {
+			persistResolutions(t, r.lg, filepath.Join(path, "resolutions.txt"), r.resolutions)
+		}
+	}
+	if r.operations != nil {
+		for _, key := range r.timelineKeys {
+			persistKeyTimeline(t, r.lg, filepath.Join(path, "timeline-"+strings.ReplaceAll(key, "/", "_")+".txt"), key, r.operations, r.keyWatchEvents())
+		}
 	}
 	if r.visualizeHistory != nil {
 		r.visualizeHistory(filepath.Join(path, "history.html"))
 	}
 }
 
+// keyWatchEvents returns watch events collected from all members, with receive time relative to baseTime.
+func (r *report) keyWatchEvents() []model.KeyWatchEvent {
+	events := []model.KeyWatchEvent{}
+	for i, memberEvents := range r.events {
+		for _, event := range memberEvents {
+			events = append(events, model.KeyWatchEvent{
+				Member: r.clus.Procs[i].Config().Name,
+				Time:   event.Time.Sub(r.baseTime).Nanoseconds(),
+				Event: model.WatchedEvent{
+					Key:      event.Op.Key,
+					Revision: event.Revision,
+					Type:     event.Op.Type,
+					Value:    event.Op.Value,
+				},
+			})
+		}
+	}
+	return events
+}
+
 func persistMemberDataDir(t *testing.T, lg *zap.Logger, member e2e.EtcdProcess, path string) {
 	lg.Info("Saving member data dir", zap.String("member", member.Config().Name), zap.String("path", path))
 	err := os.Rename(member.Config().DataDirPath, path)
@@ -128,6 +179,23 @@ func persistWatchEvents(t *testing.T, lg *zap.Logger, path string, events []watc
 	}
 }
 
+func persistResolutions(t *testing.T, lg *zap.Logger, path string, resolutions []string) {
+	lg.Info("Saving resolutions of operations with unknown result", zap.String("path", path))
+	err := os.WriteFile(path, []byte(strings.Join(resolutions, "\n")+"\n"), 0755)
+	if err != nil {
+		t.Errorf("Failed to save resolutions: %v", err)
+	}
+}
+
+func persistKeyTimeline(t *testing.T, lg *zap.Logger, path, key string, operations []porcupine.Operation, events []model.KeyWatchEvent) {
+	lg.Info("Saving key timeline", zap.String("key", key), zap.String("path", path))
+	timeline := model.KeyTimeline(key, operations, events)
+	err := os.WriteFile(path, []byte(strings.Join(timeline, "\n")+"\n"), 0755)
+	if err != nil {
+		t.Errorf("Failed to save key timeline: %v", err)
+	}
+}
+
 func persistOperationHistory(t *testing.T, lg *zap.Logger, path string, operations []porcupine.Operation) {
 	lg.Info("Saving operation history", zap.String("path", path))
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
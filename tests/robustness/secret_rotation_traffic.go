@@ -0,0 +1,189 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// secretRotationTraffic models config and secret management, that writes a new versioned key and then atomically
+// flips a pointer key to reference it. Rotating client reads the pointer, puts a new version and updates the pointer
+// only if it wasn't changed since read. Reading client follows the pointer and reads the version it references,
+// which must exist, as versions are never deleted. rotationRate is the chance each iteration rotates the secret
+// instead of reading it, configuring how fast versions churn.
+type secretRotationTraffic struct {
+	prefix       string
+	rotationRate float64
+	report       *secretRotationReport
+}
+
+func newSecretRotationTraffic(prefix string, rotationRate float64) secretRotationTraffic {
+	return secretRotationTraffic{
+		prefix:       prefix,
+		rotationRate: rotationRate,
+	}
+}
+
+func (t secretRotationTraffic) ForRun() Traffic {
+	t.report = &secretRotationReport{}
+	return t
+}
+
+func (t secretRotationTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateSecretRotation(tt, lg, t, operations)
+}
+
+// pointer returns key referencing the current version.
+func (t secretRotationTraffic) pointer() string {
+	return t.prefix + "current"
+}
+
+// versionPrefix returns prefix of versioned keys.
+func (t secretRotationTraffic) versionPrefix() string {
+	return t.prefix + "v/"
+}
+
+func (t secretRotationTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		if rand.Float64() < t.rotationRate {
+			t.rotate(ctx, c, limiter, ids)
+		} else {
+			t.follow(ctx, clientId, c, limiter)
+		}
+	}
+}
+
+// rotate puts a new version and updates pointer to reference it.
+func (t secretRotationTraffic) rotate(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	current, err := c.Get(getCtx, t.pointer())
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+	var expectedRevision int64
+	if current != nil {
+		expectedRevision = current.ModRevision
+	}
+	version := fmt.Sprintf("%d", ids.RequestId())
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err = c.Put(putCtx, t.versionPrefix()+version, version)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+	flipCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err = c.CompareRevisionAndPut(flipCtx, t.pointer(), t.versionPrefix()+version, expectedRevision)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err == nil {
+		t.report.Rotated()
+	}
+}
+
+// follow reads pointer and then the version it references.
+func (t secretRotationTraffic) follow(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	pointer, pointerRevision, err := c.GetWithRevision(getCtx, t.pointer())
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil || pointer == nil {
+		return
+	}
+	target := string(pointer.Value)
+	getCtx, cancel = context.WithTimeout(ctx, RequestTimeout)
+	targetKv, targetRevision, err := c.GetWithRevision(getCtx, target)
+	cancel()
+	limiter.Adapt(ctx, err)
+	limiter.Wait(ctx)
+	if err != nil {
+		return
+	}
+	t.report.Followed(clientId, model.PointerRead{
+		Pointer:         pointer,
+		PointerRevision: pointerRevision,
+		TargetKv:        targetKv,
+		TargetRevision:  targetRevision,
+		TargetValue:     strings.TrimPrefix(target, t.versionPrefix()),
+	})
+}
+
+// secretRotationReport collects results of secretRotationTraffic from all clients.
+type secretRotationReport struct {
+	mux        sync.Mutex
+	rotations  int
+	reads      int
+	violations []string
+}
+
+func (r *secretRotationReport) Rotated() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.rotations++
+}
+
+func (r *secretRotationReport) Followed(clientId int, read model.PointerRead) {
+	err := read.Validate()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	if err != nil {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, err))
+	}
+}
+
+func validateSecretRotation(t *testing.T, lg *zap.Logger, traffic secretRotationTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Secret rotation traffic", zap.Int("rotations", r.rotations), zap.Int("reads", r.reads))
+	for _, violation := range r.violations {
+		t.Errorf("Broke secret rotation guarantee: Reader following pointer observed dangling pointer, %s", violation)
+	}
+	for _, violation := range model.ValidateVersionPointers(operations, traffic.pointer(), traffic.versionPrefix()) {
+		t.Errorf("Broke secret rotation guarantee: Pointer references version put before it, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.rotations == 0 {
+		t.Errorf("No secret rotation was done")
+	}
+	if r.reads == 0 {
+		t.Errorf("No pointer was followed")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// serializableReadTraffic pins each client to a single member and issues serializable reads interleaved with puts.
+// Serializable reads are served locally by the member, so unlike linearizable reads, they expose member going back
+// in time. Revisions observed by consecutive reads of the client must never decrease, except after member restart
+// that can lose entries not yet persisted, see model.MemberReads.
+type serializableReadTraffic struct {
+	keyCount int
+	// persistInterval is the maximal time for member to persist applied entry, it should match backend batch interval.
+	persistInterval time.Duration
+	report          *serializableReadReport
+}
+
+func newSerializableReadTraffic(keyCount int, persistInterval time.Duration) serializableReadTraffic {
+	return serializableReadTraffic{
+		keyCount:        keyCount,
+		persistInterval: persistInterval,
+	}
+}
+
+func (t serializableReadTraffic) ForRun() Traffic {
+	t.report = &serializableReadReport{members: map[uint64]int{}}
+	return t
+}
+
+func (t serializableReadTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateSerializableReads(tt, lg, t)
+}
+
+func (t serializableReadTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	var memberID uint64
+	for memberID == 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		memberID = pinToMember(ctx, clientId, c)
+		limiter.Wait(ctx)
+	}
+	t.read(ctx, clientId, c, memberID, limiter, ids, finish)
+}
+
+// pinToMember connects client to a cluster member picked by client id, so clients are spread evenly across members.
+// Returns id of the member, or zero if members couldn't be listed or connected.
+func pinToMember(ctx context.Context, clientId int, c *recordingClient) uint64 {
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.MemberList(listCtx)
+	cancel()
+	if err != nil {
+		return 0
+	}
+	members := resp.Members[:0]
+	for _, m := range resp.Members {
+		if len(m.ClientURLs) != 0 {
+			members = append(members, m)
+		}
+	}
+	if len(members) == 0 {
+		return 0
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+	member := members[clientId%len(members)]
+	if err := c.Reconnect([]string{member.ClientURLs[0]}); err != nil {
+		return 0
+	}
+	return member.ID
+}
+
+func (t serializableReadTraffic) read(ctx context.Context, clientId int, c *recordingClient, memberID uint64, limiter *trafficLimiter, ids identity.Provider, finish <-chan struct{}) {
+	reads := model.MemberReads{MemberID: memberID, PersistInterval: t.persistInterval}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := fmt.Sprintf("%d", rand.Int()%t.keyCount)
+		getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		resp, err := c.SerializableGet(getCtx, key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			reads.Failed(time.Now())
+		} else {
+			afterRestart := reads.MayRestart()
+			err = reads.Read(model.SerializableRead{MemberID: resp.Header.MemberId, Revision: resp.Header.Revision, Time: time.Now()})
+			t.report.Read(memberID, afterRestart)
+			if err != nil {
+				t.report.Violated(fmt.Sprintf("client: %d, key: %q, %s", clientId, key, err))
+			}
+		}
+		limiter.Wait(ctx)
+
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err = c.Put(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		cancel()
+		limiter.Adapt(ctx, err)
+		limiter.Wait(ctx)
+	}
+}
+
+// serializableReadReport collects results of serializableReadTraffic validation from all clients.
+type serializableReadReport struct {
+	mux sync.Mutex
+	// members counts reads served by each member.
+	members map[uint64]int
+	reads   int
+	// readsAfterRestart counts reads done after failure, when member might have restarted.
+	readsAfterRestart int
+	violations        []string
+}
+
+func (r *serializableReadReport) Read(memberID uint64, afterRestart bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.reads++
+	r.members[memberID]++
+	if afterRestart {
+		r.readsAfterRestart++
+	}
+}
+
+func (r *serializableReadReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateSerializableReads(t *testing.T, lg *zap.Logger, traffic serializableReadTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Serializable read traffic", zap.Int("reads", r.reads), zap.Int("reads-after-restart", r.readsAfterRestart), zap.Int("members", len(r.members)))
+	for _, violation := range r.violations {
+		t.Errorf("Broke serializable reads guarantee: Member never goes back in time, also after restart below persisted state, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.reads == 0 {
+		t.Errorf("No serializable read was done")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -25,7 +26,6 @@ import (
 
 	"github.com/anishathalye/porcupine"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -41,35 +41,59 @@ var (
 	MultiOpTxnOpCount       = 4
 )
 
-func simulateTraffic(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, config trafficConfig, finish <-chan struct{}) []porcupine.Operation {
+// simulateTraffic runs traffic until finish is closed, recording call and return time of operations relative to startTime.
+func simulateTraffic(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2e.EtcdProcessCluster, config trafficConfig, startTime time.Time, finish <-chan struct{}) []porcupine.Operation {
 	mux := sync.Mutex{}
 	endpoints := clus.EndpointsGRPC()
 
 	ids := identity.NewIdProvider()
 	lm := identity.NewLeaseIdStorage()
 	h := model.History{}
-	limiter := rate.NewLimiter(rate.Limit(config.maximalQPS), 200)
+	limiter := newTrafficLimiter(lg, config.maximalQPS, config.backoff)
+	spill := model.HistorySpillConfig{MaxOperations: config.maxHistoryInMemory}
+	if spill.MaxOperations != 0 {
+		spill.Dir = t.TempDir()
+	}
+
+	traffic, err := config.clientTraffic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes *wireSizes
+	if recordWireSizes(t) {
+		sizes = newWireSizes()
+	}
 
-	startTime := time.Now()
-	cc, err := NewClient(endpoints, ids, startTime)
+	cc, err := NewClient(lg, endpoints, config.credentials(), config.clientKeepAlive(), ids, startTime, sizes.dialOptions()...)
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer cc.Close()
+	var physicalCompactions []physicalCompaction
+	var reconnections []reconnection
+	var membershipChanges []membershipChange
+	var consistentIndexes []model.ConsistentIndexObservation
 	wg := sync.WaitGroup{}
 	for i := 0; i < config.clientCount; i++ {
 		wg.Add(1)
-		c, err := NewClient([]string{endpoints[i%len(endpoints)]}, ids, startTime)
+		c, err := NewClient(lg, []string{endpoints[i%len(endpoints)]}, config.credentials(), config.clientKeepAlive(), ids, startTime, sizes.dialOptions()...)
 		if err != nil {
 			t.Fatal(err)
 		}
+		c.history.SpillTo(spill)
+		c.RetryReads(config.readRetry)
 		go func(c *recordingClient, clientId int) {
 			defer wg.Done()
 			defer c.Close()
 
-			config.traffic.Run(ctx, clientId, c, limiter, ids, lm, finish)
+			traffic.Run(ctx, clientId, c, limiter, ids, lm, finish)
 			mux.Lock()
 			h = h.Merge(c.history.History)
+			physicalCompactions = append(physicalCompactions, c.PhysicalCompactions()...)
+			reconnections = append(reconnections, c.Reconnections()...)
+			membershipChanges = append(membershipChanges, c.MembershipChanges()...)
+			consistentIndexes = append(consistentIndexes, c.ConsistentIndexes()...)
 			mux.Unlock()
 		}(c, i)
 	}
@@ -86,15 +110,118 @@ func simulateTraffic(ctx context.Context, t *testing.T, lg *zap.Logger, clus *e2
 
 	operations := h.Operations()
 	lg.Info("Recorded operations", zap.Int("count", len(operations)))
+	lg.Info("Requests rejected by backpressure", zap.Int("count", limiter.BackpressureCount()))
 
-	qps := float64(len(operations)) / float64(endTime.Sub(startTime)) * float64(time.Second)
-	lg.Info("Average traffic", zap.Float64("qps", qps))
+	qps := averageQPS(operations, config.warmUp, endTime.Sub(startTime))
+	lg.Info("Average traffic", zap.Float64("qps", qps), zap.Duration("warm-up", config.warmUp))
+	logPhysicalCompactions(lg, physicalCompactions)
+	logReconnections(lg, reconnections)
+	logMembershipChanges(lg, membershipChanges)
+	logWireSizes(lg, sizes)
+	validateConsistentIndexes(t, lg, consistentIndexes)
+	requestCounts := model.RequestTypeCounts(operations)
+	lg.Info("Request type coverage", zap.Any("operations", requestCounts))
+	for _, violation := range model.ValidateRequestTypeCounts(requestCounts, config.minimalRequestCounts) {
+		t.Errorf("Traffic didn't cover configured request types: %s", violation)
+	}
 	if qps < config.minimalQPS {
 		t.Errorf("Requiring minimal %f qps for test results to be reliable, got %f qps", config.minimalQPS, qps)
 	}
 	return operations
 }
 
+// averageQPS returns rate of operations called after warm-up. Operation call times are relative to traffic start.
+func averageQPS(operations []porcupine.Operation, warmUp, duration time.Duration) float64 {
+	if duration <= warmUp {
+		return 0
+	}
+	count := 0
+	for _, op := range operations {
+		if op.Call >= warmUp.Nanoseconds() {
+			count++
+		}
+	}
+	return float64(count) / float64(duration-warmUp) * float64(time.Second)
+}
+
+// logPhysicalCompactions logs number of physical compactions and distribution of their durations.
+// Interrupted compactions are only counted, as their duration is not known.
+func logPhysicalCompactions(lg *zap.Logger, compactions []physicalCompaction) {
+	if len(compactions) == 0 {
+		return
+	}
+	var completed, interrupted int
+	var total, min, max time.Duration
+	for _, compaction := range compactions {
+		if compaction.Interrupted {
+			interrupted++
+			continue
+		}
+		duration := compaction.Duration()
+		if completed == 0 || duration < min {
+			min = duration
+		}
+		if duration > max {
+			max = duration
+		}
+		total += duration
+		completed++
+	}
+	var avg time.Duration
+	if completed != 0 {
+		avg = total / time.Duration(completed)
+	}
+	lg.Info("Physical compactions", zap.Int("completed", completed), zap.Int("interrupted", interrupted),
+		zap.Duration("min", min), zap.Duration("avg", avg), zap.Duration("max", max))
+}
+
+// logReconnections logs number of client reconnections and time range they happened in, each reconnection
+// is logged by client at debug level.
+func logReconnections(lg *zap.Logger, reconnections []reconnection) {
+	if len(reconnections) == 0 {
+		return
+	}
+	var failed int
+	first, last := reconnections[0].Time, reconnections[0].Time
+	for _, r := range reconnections {
+		if r.Failed {
+			failed++
+		}
+		if r.Time < first {
+			first = r.Time
+		}
+		if r.Time > last {
+			last = r.Time
+		}
+	}
+	lg.Info("Client reconnections", zap.Int("count", len(reconnections)), zap.Int("failed", failed),
+		zap.Duration("first", first), zap.Duration("last", last))
+}
+
+// validateConsistentIndexes checks consistent indexes returned by members with debug consistent index trailer enabled,
+// which correlate revisions observed by clients with apply progress of members.
+func validateConsistentIndexes(t *testing.T, lg *zap.Logger, observations []model.ConsistentIndexObservation) {
+	if len(observations) == 0 {
+		return
+	}
+	lg.Info("Consistent indexes returned by members", zap.Int("count", len(observations)))
+	for _, violation := range model.ValidateConsistentIndexes(observations) {
+		t.Errorf("Broke apply guarantee: Consistent index of member never goes backwards, %s", violation)
+	}
+}
+
+// logMembershipChanges logs each membership operation done by traffic, ordered by call time, so reconfiguration
+// can be correlated with violations found in operations recorded around it.
+func logMembershipChanges(lg *zap.Logger, changes []membershipChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Start < changes[j].Start
+	})
+	for _, change := range changes {
+		lg.Info("Membership change", zap.String("type", string(change.Type)), zap.Duration("start", change.Start), zap.Duration("end", change.End),
+			zap.Uint64("member-id", change.MemberID), zap.Uint64s("members", change.Members), zap.Uint64s("learners", change.Learners), zap.Error(change.Err))
+	}
+}
+
 type trafficConfig struct {
 	name            string
 	minimalQPS      float64
@@ -102,10 +229,69 @@ type trafficConfig struct {
 	clientCount     int
 	traffic         Traffic
 	requestProgress bool // Request progress notifications while watching this traffic
+	backoff         backoffConfig
+	// readRetry configures clients to retry reads failed with retriable errors, zero value disables retries.
+	readRetry readRetryConfig
+	// warmUp is duration from traffic start, during which operations are recorded, but not counted towards minimalQPS,
+	// as connection setup and leader discovery slow down traffic. Zero counts all operations.
+	warmUp time.Duration
+	// authEnabled enables authentication before the traffic, all clients then authenticate as root user.
+	authEnabled bool
+	// maxHistoryInMemory limits number of successful operations each client keeps in memory, older ones are spilled
+	// to disk and read back only for validation, so long runs don't run out of memory. Zero keeps whole history in memory.
+	maxHistoryInMemory int
+	// checkWindow configures checking linearizability of long histories in overlapping windows. Zero value checks
+	// whole history at once.
+	checkWindow model.CheckWindow
+	// keepAlive configures keepalive pings of traffic clients, unset fields use values of DefaultKeepAlive.
+	keepAlive keepAliveConfig
+	// writeOnly makes etcdTraffic skip the read before each write, so it stresses only the write path. History then
+	// includes all failed writes, so it should be used only with cluster known to be healthy.
+	writeOnly bool
+	// minimalRequestCounts requires at least given number of operations done for each request type, catching request
+	// types that were not exercised, for example due to zero weight of their write choice.
+	minimalRequestCounts map[string]int
+}
+
+// credentials returns credentials used by clients of the traffic.
+func (c trafficConfig) credentials() clientCredentials {
+	if !c.authEnabled {
+		return clientCredentials{}
+	}
+	return rootCredentials
+}
+
+// clientKeepAlive returns keepalive used by clients of the traffic.
+func (c trafficConfig) clientKeepAlive() keepAliveConfig {
+	keepAlive := c.keepAlive
+	if keepAlive.interval == 0 {
+		keepAlive.interval = DefaultKeepAlive.interval
+	}
+	if keepAlive.timeout == 0 {
+		keepAlive.timeout = DefaultKeepAlive.timeout
+	}
+	return keepAlive
+}
+
+// clientTraffic returns traffic run by clients, with writeOnly applied to etcdTraffic.
+func (c trafficConfig) clientTraffic() (Traffic, error) {
+	if !c.writeOnly {
+		return c.traffic, nil
+	}
+	traffic, ok := c.traffic.(etcdTraffic)
+	if !ok {
+		return nil, fmt.Errorf("write-only mode is supported only by etcdTraffic, got %T", c.traffic)
+	}
+	traffic.writeOnly = true
+	return traffic, nil
 }
 
 type Traffic interface {
-	Run(ctx context.Context, clientId int, c *recordingClient, limiter *rate.Limiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{})
+	// ForRun returns traffic for a single test run, with new report shared by all its clients.
+	ForRun() Traffic
+	Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{})
+	// Validate checks operations and results reported by clients after traffic of the run finished.
+	Validate(t *testing.T, lg *zap.Logger, operations []porcupine.Operation)
 }
 
 type etcdTraffic struct {
@@ -113,6 +299,25 @@ type etcdTraffic struct {
 	writeChoices []choiceWeight
 	leaseTTL     int64
 	largePutSize int
+	// nestedTxnDepth limits how deep NestedTxn requests nest transactions.
+	nestedTxnDepth int
+	// guardCount is the number of conditions, each on a distinct key, of GuardedTxn requests.
+	guardCount int
+	// recentKeyChance is the percentage of iterations that reuse one of the keys recently written by the same client
+	// instead of picking uniformly random key. Zero keeps selection uniform.
+	recentKeyChance int
+	// recentKeyCount limits how many recently written keys each client remembers.
+	recentKeyCount int
+	// defragmentTarget selects member defragmented by Defragment requests.
+	defragmentTarget DefragmentTarget
+	// leaseTxnAttachChance is the percentage of puts in LeaseTxn requests attached to the client lease, others are
+	// put without lease, detaching the key from lease it was attached to.
+	leaseTxnAttachChance int
+	// leaseTxnRevokedChance is the percentage of LeaseTxn requests that revoke the client lease before using it,
+	// like when lease expires, so transaction executing branch that attaches key to it has to fail.
+	leaseTxnRevokedChance int
+	// writeOnly skips the read before each write, set by trafficConfig.writeOnly.
+	writeOnly bool
 }
 
 type etcdRequestType string
@@ -125,16 +330,93 @@ const (
 	PutWithLease  etcdRequestType = "putWithLease"
 	LeaseRevoke   etcdRequestType = "leaseRevoke"
 	CompareAndSet etcdRequestType = "compareAndSet"
-	Defragment    etcdRequestType = "defragment"
+	// CompareAndDelete deletes key only if it still has the value read before.
+	CompareAndDelete etcdRequestType = "compareAndDelete"
+	Defragment       etcdRequestType = "defragment"
+	NestedTxn        etcdRequestType = "nestedTxn"
+	GetAndPut        etcdRequestType = "getAndPut"
+	// SortedRange reads all keys sorted by create or mod revision and limited, like queues and pagination do.
+	SortedRange etcdRequestType = "sortedRange"
+	// ModRevisionRange reads all keys modified in a window around the last read revision, like incremental sync does.
+	ModRevisionRange etcdRequestType = "modRevisionRange"
+	// KeysOnlyRange reads all keys without values, like listing does to save bandwidth on keys with large values.
+	KeysOnlyRange etcdRequestType = "keysOnlyRange"
+	// Recreate deletes key and puts it back, exercising reset of key version and create revision.
+	Recreate etcdRequestType = "recreate"
+	// GuardedTxn puts key only if multiple other keys still have values read before, like coordination does.
+	GuardedTxn etcdRequestType = "guardedTxn"
+	// CompareMultiAndSwap puts key only if two keys still have values read before, otherwise reads all three keys.
+	CompareMultiAndSwap etcdRequestType = "compareMultiAndSwap"
+	// CreateIfAbsent puts key only if it doesn't exist, like locks and leader election do.
+	CreateIfAbsent etcdRequestType = "createIfAbsent"
+	// PaginatedRange reads all keys in small pages pinned to revision of the first one, like large consumers do.
+	PaginatedRange etcdRequestType = "paginatedRange"
+	// LeaseTxn conditionally puts keys attached to lease in both branches of transaction, like leader election does.
+	LeaseTxn etcdRequestType = "leaseTxn"
+)
+
+// DefragmentTarget selects member defragmented by etcdTraffic Defragment requests.
+type DefragmentTarget string
+
+const (
+	// DefragmentClientMember defragments member the client is connected to.
+	DefragmentClientMember DefragmentTarget = ""
+	// DefragmentRandomMember defragments random cluster member, which usually isn't the one serving the client,
+	// allowing to validate that defragmentation doesn't affect linearizability observed through other members.
+	DefragmentRandomMember DefragmentTarget = "randomMember"
 )
 
+// CompactionPolicy selects whether compactions done by traffic are physical, waiting for compacted revisions
+// to be removed from the backend before returning.
+type CompactionPolicy string
+
+const (
+	// CompactionLogical returns as soon as compaction is applied, removing revisions in the background.
+	CompactionLogical CompactionPolicy = ""
+	// CompactionPhysical waits for compaction to complete, racing removal of revisions with active watchers.
+	CompactionPhysical CompactionPolicy = "physical"
+	// CompactionRandom picks physical flag at random for each compaction.
+	CompactionRandom CompactionPolicy = "random"
+)
+
+// Physical returns physical flag for the next compaction.
+func (p CompactionPolicy) Physical() bool {
+	switch p {
+	case CompactionLogical:
+		return false
+	case CompactionPhysical:
+		return true
+	case CompactionRandom:
+		return rand.Intn(2) == 0
+	default:
+		panic(fmt.Sprintf("invalid compaction policy: %q", p))
+	}
+}
+
 type kubernetesTraffic struct {
 	averageKeyCount int
 	resource        string
 	namespace       string
 	writeChoices    []choiceWeight
+	routing         KubernetesRouting
+	// deleteRetryChance is percentage of deletes done by DeleteWithRetry.
+	deleteRetryChance int
 }
 
+// deleteAttemptTimeout is timeout of the first attempt of DeleteWithRetry, short enough to often leave its result unknown.
+const deleteAttemptTimeout = 5 * time.Millisecond
+
+// KubernetesRouting selects members that serve kubernetesTraffic reads and writes.
+type KubernetesRouting string
+
+const (
+	// SingleEndpointRouting sends all requests to the endpoint client was created with.
+	SingleEndpointRouting KubernetesRouting = ""
+	// ReadAnyWriteLeaderRouting sends each read to a random member and all writes to the current leader,
+	// like kube-apiserver instances that read from any member while writes end up on the leader.
+	ReadAnyWriteLeaderRouting KubernetesRouting = "readAnyWriteLeader"
+)
+
 type KubernetesRequestType string
 
 const (
@@ -143,7 +425,16 @@ const (
 	KubernetesDelete KubernetesRequestType = "delete"
 )
 
-func (t kubernetesTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *rate.Limiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+func (t kubernetesTraffic) ForRun() Traffic {
+	return t
+}
+
+func (t kubernetesTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+}
+
+func (t kubernetesTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	router := newEndpointRouter(t.routing, c, ids)
+	defer router.Close()
 	for {
 		select {
 		case <-ctx.Done():
@@ -152,13 +443,16 @@ func (t kubernetesTraffic) Run(ctx context.Context, clientId int, c *recordingCl
 			return
 		default:
 		}
-		objects, err := t.Range(ctx, c, "/registry/"+t.resource+"/", true)
+		objects, err := t.Range(ctx, router.Reader(ctx), "/registry/"+t.resource+"/", true)
+		limiter.Adapt(ctx, err)
 		if err != nil {
 			continue
 		}
 		limiter.Wait(ctx)
-		err = t.Write(ctx, c, ids, objects)
+		err = t.Write(ctx, router.Writer(ctx), ids, objects)
+		limiter.Adapt(ctx, err)
 		if err != nil {
+			router.WriteFailed()
 			continue
 		}
 		limiter.Wait(ctx)
@@ -166,38 +460,45 @@ func (t kubernetesTraffic) Run(ctx context.Context, clientId int, c *recordingCl
 }
 
 func (t kubernetesTraffic) Write(ctx context.Context, c *recordingClient, ids identity.Provider, objects []*mvccpb.KeyValue) (err error) {
+	op := t.pickWrite(len(objects))
+	c.Tag(string(op))
+	defer c.Tag("")
+
 	writeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
-	if len(objects) < t.averageKeyCount/2 {
-		err = t.Create(writeCtx, c, t.generateKey(), fmt.Sprintf("%d", ids.RequestId()))
-	} else {
+	switch op {
+	case KubernetesDelete:
 		randomPod := objects[rand.Intn(len(objects))]
-		if len(objects) > t.averageKeyCount*3/2 {
-			err = t.Delete(writeCtx, c, string(randomPod.Key), randomPod.ModRevision)
-		} else {
-			op := KubernetesRequestType(pickRandom(t.writeChoices))
-			switch op {
-			case KubernetesDelete:
-				err = t.Delete(writeCtx, c, string(randomPod.Key), randomPod.ModRevision)
-			case KubernetesUpdate:
-				err = t.Update(writeCtx, c, string(randomPod.Key), fmt.Sprintf("%d", ids.RequestId()), randomPod.ModRevision)
-			case KubernetesCreate:
-				err = t.Create(writeCtx, c, t.generateKey(), fmt.Sprintf("%d", ids.RequestId()))
-			default:
-				panic(fmt.Sprintf("invalid choice: %q", op))
-			}
-		}
+		err = t.delete(writeCtx, c, ids, string(randomPod.Key), randomPod.ModRevision)
+	case KubernetesUpdate:
+		randomPod := objects[rand.Intn(len(objects))]
+		err = t.Update(writeCtx, c, string(randomPod.Key), fmt.Sprintf("%d", ids.RequestId()), randomPod.ModRevision)
+	case KubernetesCreate:
+		err = t.Create(writeCtx, c, t.generateKey(), fmt.Sprintf("%d", ids.RequestId()))
+	default:
+		panic(fmt.Sprintf("invalid choice: %q", op))
 	}
 	cancel()
 	return err
 }
 
+// pickWrite returns create if there are too few objects, delete if there are too many, random write choice otherwise.
+func (t kubernetesTraffic) pickWrite(objectCount int) KubernetesRequestType {
+	if objectCount < t.averageKeyCount/2 {
+		return KubernetesCreate
+	}
+	if objectCount > t.averageKeyCount*3/2 {
+		return KubernetesDelete
+	}
+	return KubernetesRequestType(pickRandom(t.writeChoices))
+}
+
 func (t kubernetesTraffic) generateKey() string {
 	return fmt.Sprintf("/registry/%s/%s/%s", t.resource, t.namespace, stringutil.RandString(5))
 }
 
 func (t kubernetesTraffic) Range(ctx context.Context, c *recordingClient, key string, withPrefix bool) ([]*mvccpb.KeyValue, error) {
 	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
-	resp, err := c.Range(ctx, key, withPrefix)
+	resp, _, err := c.RangeSnapshot(ctx, key, clientv3.GetPrefixRangeEnd(key), withPrefix)
 	cancel()
 	return resp, err
 }
@@ -220,8 +521,47 @@ func (t kubernetesTraffic) Delete(ctx context.Context, c *recordingClient, key s
 	return err
 }
 
-func (t etcdTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *rate.Limiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+func (t kubernetesTraffic) delete(ctx context.Context, c *recordingClient, ids identity.Provider, key string, expectedRevision int64) error {
+	if rand.Intn(100) < t.deleteRetryChance {
+		return t.DeleteWithRetry(ctx, c, ids, key, expectedRevision)
+	}
+	return t.Delete(ctx, c, key, expectedRevision)
+}
+
+// DeleteWithRetry deletes key with attempt that can time out, retrying it with the same expected revision on failure.
+// Before the retry the key is created again, which succeeds only if the failed attempt was applied, in which case
+// the retry has to be a no-op, see model.ValidateCompareRevisionDeleteRetries.
+func (t kubernetesTraffic) DeleteWithRetry(ctx context.Context, c *recordingClient, ids identity.Provider, key string, expectedRevision int64) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, deleteAttemptTimeout)
+	err := c.CompareRevisionAndDelete(attemptCtx, key, expectedRevision)
+	cancel()
+	if err == nil {
+		return nil
+	}
+	if err := t.Create(ctx, c, key, fmt.Sprintf("%d", ids.RequestId())); err != nil {
+		return err
+	}
+	return t.Delete(ctx, c, key, expectedRevision)
+}
+
+// jobQueueTraffic models etcd used as a work queue. Producers put job keys under prefix,
+// consumers list pending jobs and claim one by deleting it conditionally on its mod revision.
+type jobQueueTraffic struct {
+	prefix        string
+	producerCount int
+	consumerCount int
+}
+
+func (t jobQueueTraffic) ForRun() Traffic {
+	return t
+}
+
+func (t jobQueueTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateJobClaims(tt, lg, t.prefix, operations)
+}
 
+func (t jobQueueTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	producer := clientId%(t.producerCount+t.consumerCount) < t.producerCount
 	for {
 		select {
 		case <-ctx.Done():
@@ -230,21 +570,124 @@ func (t etcdTraffic) Run(ctx context.Context, clientId int, c *recordingClient,
 			return
 		default:
 		}
-		key := fmt.Sprintf("%d", rand.Int()%t.keyCount)
-		// Execute one read per one write to avoid operation history include too many failed writes when etcd is down.
-		resp, err := t.Read(ctx, c, key)
+		var err error
+		if producer {
+			err = t.Produce(ctx, c, ids)
+		} else {
+			err = t.Claim(ctx, c, limiter)
+		}
+		limiter.Adapt(ctx, err)
 		if err != nil {
 			continue
 		}
 		limiter.Wait(ctx)
-		err = t.Write(ctx, c, limiter, key, ids, lm, clientId, resp)
+	}
+}
+
+func (t jobQueueTraffic) Produce(ctx context.Context, c *recordingClient, ids identity.Provider) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	id := ids.RequestId()
+	return c.Put(ctx, fmt.Sprintf("%s%d", t.prefix, id), fmt.Sprintf("%d", id))
+}
+
+func (t jobQueueTraffic) Claim(ctx context.Context, c *recordingClient, limiter *trafficLimiter) error {
+	listCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	jobs, _, err := c.RangeSnapshot(listCtx, t.prefix, clientv3.GetPrefixRangeEnd(t.prefix), true)
+	cancel()
+	if err != nil || len(jobs) == 0 {
+		return err
+	}
+	limiter.Wait(ctx)
+	job := jobs[rand.Intn(len(jobs))]
+	claimCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err = c.CompareRevisionAndDelete(claimCtx, string(job.Key), job.ModRevision)
+	cancel()
+	return err
+}
+
+// validateJobClaims checks that no job was claimed by more than one consumer, based on recorded history.
+// Only claims with known successful result are counted.
+func validateJobClaims(t *testing.T, lg *zap.Logger, prefix string, operations []porcupine.Operation) {
+	produced := map[string]struct{}{}
+	claims := map[string]int{}
+	for _, op := range operations {
+		request := op.Input.(model.EtcdRequest)
+		response := op.Output.(model.EtcdNonDeterministicResponse)
+		if request.Type != model.Txn || len(request.Txn.Ops) != 1 || !strings.HasPrefix(request.Txn.Ops[0].Key, prefix) {
+			continue
+		}
+		if response.Err != nil || response.ResultUnknown || response.Txn == nil || response.Txn.TxnResult {
+			continue
+		}
+		key := request.Txn.Ops[0].Key
+		switch request.Txn.Ops[0].Type {
+		case model.Put:
+			produced[key] = struct{}{}
+		case model.Delete:
+			claims[key]++
+		}
+	}
+	for key, count := range claims {
+		if count > 1 {
+			t.Errorf("Job claimed by more than one consumer, key: %q, claims: %d", key, count)
+		}
+	}
+	unclaimed := 0
+	for key := range produced {
+		if _, found := claims[key]; !found {
+			unclaimed++
+		}
+	}
+	lg.Info("Job queue summary", zap.Int("produced", len(produced)), zap.Int("claimed", len(claims)), zap.Int("unclaimed", unclaimed))
+}
+
+func (t etcdTraffic) ForRun() Traffic {
+	return t
+}
+
+func (t etcdTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {}
+
+func (t etcdTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	recent := newRecentKeys(t.recentKeyCount)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		key := t.pickKey(recent)
+		var resp *mvccpb.KeyValue
+		if !t.writeOnly {
+			// Execute one read per one write to avoid operation history include too many failed writes when etcd is down.
+			var err error
+			resp, err = t.Read(ctx, c, key)
+			limiter.Adapt(ctx, err)
+			if err != nil {
+				continue
+			}
+			limiter.Wait(ctx)
+		}
+		err := t.Write(ctx, c, limiter, key, ids, lm, clientId, resp)
+		limiter.Adapt(ctx, err)
 		if err != nil {
 			continue
 		}
+		recent.Touch(key)
 		limiter.Wait(ctx)
 	}
 }
 
+// pickKey returns one of recently written keys with recentKeyChance probability, uniformly random key otherwise.
+func (t etcdTraffic) pickKey(recent *recentKeys) string {
+	if recent.Len() > 0 && rand.Int()%100 < t.recentKeyChance {
+		return recent.Pick()
+	}
+	return fmt.Sprintf("%d", rand.Int()%t.keyCount)
+}
+
 func (t etcdTraffic) Read(ctx context.Context, c *recordingClient, key string) (*mvccpb.KeyValue, error) {
 	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
 	resp, err := c.Get(getCtx, key)
@@ -252,11 +695,15 @@ func (t etcdTraffic) Read(ctx context.Context, c *recordingClient, key string) (
 	return resp, err
 }
 
-func (t etcdTraffic) Write(ctx context.Context, c *recordingClient, limiter *rate.Limiter, key string, id identity.Provider, lm identity.LeaseIdStorage, cid int, lastValues *mvccpb.KeyValue) error {
+func (t etcdTraffic) Write(ctx context.Context, c *recordingClient, limiter *trafficLimiter, key string, id identity.Provider, lm identity.LeaseIdStorage, cid int, lastValues *mvccpb.KeyValue) error {
 	writeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
 
+	op := etcdRequestType(pickRandom(t.writeChoices))
+	c.Tag(string(op))
+	defer c.Tag("")
+
 	var err error
-	switch etcdRequestType(pickRandom(t.writeChoices)) {
+	switch op {
 	case Put:
 		err = c.Put(writeCtx, key, fmt.Sprintf("%d", id.RequestId()))
 	case LargePut:
@@ -264,23 +711,60 @@ func (t etcdTraffic) Write(ctx context.Context, c *recordingClient, limiter *rat
 	case Delete:
 		err = c.Delete(writeCtx, key)
 	case MultiOpTxn:
-		err = c.Txn(writeCtx, nil, t.pickMultiTxnOps(id))
+		err = c.Txn(writeCtx, nil, t.pickMultiTxnOps(id), nil)
+	case NestedTxn:
+		cmp, onSuccess, onFailure := t.pickNestedTxn(id, rand.Perm(t.keyCount), t.nestedTxnDepth)
+		err = c.Txn(writeCtx, cmp, onSuccess, onFailure)
 	case CompareAndSet:
 		var expectRevision int64
 		if lastValues != nil {
 			expectRevision = lastValues.ModRevision
 		}
 		err = c.CompareRevisionAndPut(writeCtx, key, fmt.Sprintf("%d", id.RequestId()), expectRevision)
-	case PutWithLease:
-		leaseId := lm.LeaseId(cid)
-		if leaseId == 0 {
-			leaseId, err = c.LeaseGrant(writeCtx, t.leaseTTL)
-			if err == nil {
-				lm.AddLeaseId(cid, leaseId)
-				limiter.Wait(ctx)
-			}
+	case CompareAndDelete:
+		err = c.CompareValueAndDelete(writeCtx, key, expectedValue(lastValues))
+	case Recreate:
+		err = c.Delete(writeCtx, key)
+		if err == nil {
+			err = c.Put(writeCtx, key, fmt.Sprintf("%d", id.RequestId()))
 		}
-		if leaseId != 0 {
+	case GetAndPut:
+		_, err = c.GetAndPut(writeCtx, key, fmt.Sprintf("%d", id.RequestId()))
+	case CreateIfAbsent:
+		_, err = c.CreateIfAbsent(writeCtx, key, fmt.Sprintf("%d", id.RequestId()))
+	case GuardedTxn:
+		err = t.guardedTxn(ctx, c, key, id, lastValues)
+	case CompareMultiAndSwap:
+		err = t.compareMultiAndSwap(ctx, c, key, id, lastValues)
+	case SortedRange:
+		target := []clientv3.SortTarget{clientv3.SortByCreateRevision, clientv3.SortByModRevision}[rand.Intn(2)]
+		order := []clientv3.SortOrder{clientv3.SortAscend, clientv3.SortDescend}[rand.Intn(2)]
+		_, err = c.SortedRange(writeCtx, "", true, target, order, int64(1+rand.Intn(3)))
+	case ModRevisionRange:
+		var modRevision int64 = 1
+		if lastValues != nil {
+			modRevision = lastValues.ModRevision
+		}
+		minModRev := modRevision - int64(rand.Intn(3))
+		if minModRev < 1 {
+			minModRev = 1
+		}
+		// Window is sometimes empty, with maximal revision below minimal one.
+		maxModRev := minModRev + int64(rand.Intn(5)) - 1
+		if maxModRev < 1 {
+			maxModRev = 1
+		}
+		_, err = c.ModRevisionRange(writeCtx, "", true, minModRev, maxModRev)
+	case KeysOnlyRange:
+		_, err = c.KeysOnlyRange(writeCtx, "", true)
+	case PaginatedRange:
+		_, _, err = c.PaginatedRange(writeCtx, "", int64(1+rand.Intn(3)))
+	case LeaseTxn:
+		err = t.leaseTxn(ctx, c, limiter, key, id, lm, cid, lastValues)
+	case PutWithLease:
+		var leaseId int64
+		leaseId, err = t.clientLease(ctx, c, limiter, lm, cid)
+		if err == nil {
 			putCtx, putCancel := context.WithTimeout(ctx, RequestTimeout)
 			err = c.PutWithLease(putCtx, key, fmt.Sprintf("%d", id.RequestId()), leaseId)
 			putCancel()
@@ -295,7 +779,11 @@ func (t etcdTraffic) Write(ctx context.Context, c *recordingClient, limiter *rat
 			}
 		}
 	case Defragment:
-		err = c.Defragment(writeCtx)
+		var endpoint string
+		endpoint, err = t.defragmentEndpoint(writeCtx, c)
+		if err == nil {
+			err = c.DefragmentMember(writeCtx, endpoint)
+		}
 	default:
 		panic("invalid choice")
 	}
@@ -303,6 +791,84 @@ func (t etcdTraffic) Write(ctx context.Context, c *recordingClient, limiter *rat
 	return err
 }
 
+// clientLease returns lease of the client, granting a new one if client doesn't have any.
+func (t etcdTraffic) clientLease(ctx context.Context, c *recordingClient, limiter *trafficLimiter, lm identity.LeaseIdStorage, cid int) (int64, error) {
+	if leaseId := lm.LeaseId(cid); leaseId != 0 {
+		return leaseId, nil
+	}
+	grantCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	leaseId, _, err := c.LeaseGrant(grantCtx, t.leaseTTL)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	lm.AddLeaseId(cid, leaseId)
+	limiter.Wait(ctx)
+	return leaseId, nil
+}
+
+// leaseTxn puts key in success branch and other key in failure branch, attaching them to the client lease with
+// leaseTxnAttachChance probability, and deletes the other key in success branch, so the model validates that only
+// keys of the executed branch are attached to lease. Success is conditioned on key still having the value read
+// before. With leaseTxnRevokedChance probability lease is revoked first, so transaction has to fail if executed
+// branch attaches key to it.
+func (t etcdTraffic) leaseTxn(ctx context.Context, c *recordingClient, limiter *trafficLimiter, key string, ids identity.Provider, lm identity.LeaseIdStorage, cid int, lastValues *mvccpb.KeyValue) error {
+	leaseId, err := t.clientLease(ctx, c, limiter, lm, cid)
+	if err != nil {
+		return err
+	}
+	if rand.Intn(100) < t.leaseTxnRevokedChance {
+		revokeCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		err = c.LeaseRevoke(revokeCtx, leaseId)
+		cancel()
+		if err != nil {
+			return err
+		}
+		lm.RemoveLeaseId(cid)
+		limiter.Wait(ctx)
+	}
+	// etcd rejects transaction that both puts and deletes the same key, so other key has to differ.
+	other := key
+	for other == key && t.keyCount > 1 {
+		other = fmt.Sprintf("%d", rand.Intn(t.keyCount))
+	}
+	cmp := []clientv3.Cmp{guardCmp(key, lastValues)}
+	onSuccess := []clientv3.Op{t.leaseTxnPut(ids, key, leaseId), clientv3.OpDelete(other)}
+	onFailure := []clientv3.Op{clientv3.OpGet(key), t.leaseTxnPut(ids, other, leaseId)}
+	txnCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return c.Txn(txnCtx, cmp, onSuccess, onFailure)
+}
+
+func (t etcdTraffic) leaseTxnPut(ids identity.Provider, key string, leaseId int64) clientv3.Op {
+	value := fmt.Sprintf("%d", ids.RequestId())
+	if rand.Intn(100) < t.leaseTxnAttachChance {
+		return clientv3.OpPut(key, value, clientv3.WithLease(clientv3.LeaseID(leaseId)))
+	}
+	return clientv3.OpPut(key, value)
+}
+
+// defragmentEndpoint returns endpoint of the member selected by defragmentTarget.
+func (t etcdTraffic) defragmentEndpoint(ctx context.Context, c *recordingClient) (string, error) {
+	if t.defragmentTarget == DefragmentClientMember {
+		return c.client.Endpoints()[0], nil
+	}
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return "", err
+	}
+	endpoints := []string{}
+	for _, m := range resp.Members {
+		if len(m.ClientURLs) != 0 {
+			endpoints = append(endpoints, m.ClientURLs[0])
+		}
+	}
+	if len(endpoints) == 0 {
+		return "", fmt.Errorf("no member client urls in member list")
+	}
+	return endpoints[rand.Intn(len(endpoints))], nil
+}
+
 func (t etcdTraffic) pickMultiTxnOps(ids identity.Provider) (ops []clientv3.Op) {
 	keys := rand.Perm(t.keyCount)
 	opTypes := make([]model.OperationType, 4)
@@ -336,6 +902,107 @@ func (t etcdTraffic) pickMultiTxnOps(ids identity.Provider) (ops []clientv3.Op)
 	return ops
 }
 
+// pickNestedTxn generates conditional transaction, nesting another one in its success branch until depth is reached.
+// Each level writes to two keys taken from keys, so writes of different branches never overlap.
+func (t etcdTraffic) pickNestedTxn(ids identity.Provider, keys []int, depth int) (cmp []clientv3.Cmp, onSuccess, onFailure []clientv3.Op) {
+	condKey := fmt.Sprintf("%d", rand.Intn(t.keyCount))
+	cmp = []clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision(condKey), "=", 0)}
+	onSuccess = []clientv3.Op{clientv3.OpGet(condKey), t.pickNestedTxnWrite(ids, keys[0])}
+	onFailure = []clientv3.Op{clientv3.OpGet(condKey), t.pickNestedTxnWrite(ids, keys[1])}
+	if depth > 1 && len(keys) >= 4 {
+		onSuccess = append(onSuccess, clientv3.OpTxn(t.pickNestedTxn(ids, keys[2:], depth-1)))
+	}
+	return cmp, onSuccess, onFailure
+}
+
+func (t etcdTraffic) pickNestedTxnWrite(ids identity.Provider, key int) clientv3.Op {
+	if rand.Int()%100 < 20 {
+		return clientv3.OpDelete(fmt.Sprintf("%d", key))
+	}
+	return clientv3.OpPut(fmt.Sprintf("%d", key), fmt.Sprintf("%d", ids.RequestId()))
+}
+
+// guardedTxn reads guard keys and puts another key only if all of them still have the values read, or are still
+// missing. Concurrent writes to any guard key make the transaction execute failure branch reading all guard keys.
+// First guard is the key already read, other guards are read by separate requests.
+func (t etcdTraffic) guardedTxn(ctx context.Context, c *recordingClient, key string, ids identity.Provider, lastValues *mvccpb.KeyValue) error {
+	keys := []string{key}
+	for _, k := range rand.Perm(t.keyCount) {
+		if len(keys) > t.guardCount {
+			break
+		}
+		if other := fmt.Sprintf("%d", k); other != key {
+			keys = append(keys, other)
+		}
+	}
+	guards, target := keys[:len(keys)-1], keys[len(keys)-1]
+
+	cmp := []clientv3.Cmp{guardCmp(key, lastValues)}
+	onFailure := []clientv3.Op{clientv3.OpGet(key)}
+	for _, guard := range guards[1:] {
+		kv, err := t.Read(ctx, c, guard)
+		if err != nil {
+			return err
+		}
+		cmp = append(cmp, guardCmp(guard, kv))
+		onFailure = append(onFailure, clientv3.OpGet(guard))
+	}
+	onSuccess := []clientv3.Op{clientv3.OpPut(target, fmt.Sprintf("%d", ids.RequestId()))}
+
+	txnCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	return c.Txn(txnCtx, cmp, onSuccess, onFailure)
+}
+
+// compareMultiAndSwap puts a random key only if key and another random key still have values read before.
+// Other key is read just before, so comparisons fail partially when keys are modified concurrently.
+func (t etcdTraffic) compareMultiAndSwap(ctx context.Context, c *recordingClient, key string, ids identity.Provider, lastValues *mvccpb.KeyValue) error {
+	keys := []string{key}
+	for _, k := range rand.Perm(t.keyCount) {
+		if len(keys) == 3 {
+			break
+		}
+		if other := fmt.Sprintf("%d", k); other != key {
+			keys = append(keys, other)
+		}
+	}
+	if len(keys) != 3 {
+		return nil
+	}
+	other, target := keys[1], keys[2]
+	otherValues, err := t.Read(ctx, c, other)
+	if err != nil {
+		return err
+	}
+	expected := map[string]string{key: expectedValue(lastValues), other: expectedValue(otherValues)}
+
+	swapCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	_, err = c.CompareMultiAndSwap(swapCtx, expected, target, fmt.Sprintf("%d", ids.RequestId()))
+	return err
+}
+
+// expectedValue returns value key is expected to still have. Missing key is compared with value that was never written,
+// exercising compare on not found key.
+func expectedValue(kv *mvccpb.KeyValue) string {
+	if kv == nil {
+		return "missing"
+	}
+	return string(kv.Value)
+}
+
+// guardCmp compares key with its value read before, randomly by value or by mod revision. Missing key is required to
+// still not exist.
+func guardCmp(key string, kv *mvccpb.KeyValue) clientv3.Cmp {
+	if kv == nil {
+		return clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+	}
+	if rand.Intn(2) == 0 {
+		return clientv3.Compare(clientv3.Value(key), "=", string(kv.Value))
+	}
+	return clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)
+}
+
 func (t etcdTraffic) pickOperationType() model.OperationType {
 	roll := rand.Int() % 100
 	if roll < 10 {
@@ -347,6 +1014,41 @@ func (t etcdTraffic) pickOperationType() model.OperationType {
 	return model.Put
 }
 
+// recentKeys is a small LRU of keys written by a single client, used to bias traffic towards overwriting them.
+type recentKeys struct {
+	size int
+	keys []string
+}
+
+func newRecentKeys(size int) *recentKeys {
+	return &recentKeys{size: size}
+}
+
+// Touch marks key as most recently written, evicting the least recently written key if over size.
+func (r *recentKeys) Touch(key string) {
+	if r.size <= 0 {
+		return
+	}
+	for i, k := range r.keys {
+		if k == key {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			break
+		}
+	}
+	r.keys = append([]string{key}, r.keys...)
+	if len(r.keys) > r.size {
+		r.keys = r.keys[:r.size]
+	}
+}
+
+func (r *recentKeys) Pick() string {
+	return r.keys[rand.Intn(len(r.keys))]
+}
+
+func (r *recentKeys) Len() int {
+	return len(r.keys)
+}
+
 func randString(size int) string {
 	data := strings.Builder{}
 	data.Grow(size)
@@ -0,0 +1,161 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+)
+
+// txnLimitTraffic validates that etcd consistently enforces limit of comparisons in a single transaction.
+// Each transaction compares cmpKeyCount keys that are never written, so all comparisons succeed, and puts a new marker key.
+// Number of comparisons is picked from limit offset by one of offsets, transactions with more comparisons than limit
+// must be rejected, others must be applied. Rejected transaction must have no effect, so its marker key is read back.
+type txnLimitTraffic struct {
+	prefix string
+	// limit is the maximal number of operations in transaction configured on etcd by --max-txn-ops.
+	limit int
+	// offsets are differences between number of comparisons in transaction and limit.
+	offsets     []int
+	cmpKeyCount int
+	report      *txnLimitReport
+}
+
+func newTxnLimitTraffic(prefix string, limit int, offsets []int, cmpKeyCount int) txnLimitTraffic {
+	return txnLimitTraffic{
+		prefix:      prefix,
+		limit:       limit,
+		offsets:     offsets,
+		cmpKeyCount: cmpKeyCount,
+	}
+}
+
+func (t txnLimitTraffic) ForRun() Traffic {
+	t.report = &txnLimitReport{limit: t.limit}
+	return t
+}
+
+func (t txnLimitTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateTxnLimit(tt, lg, t)
+}
+
+func (t txnLimitTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.txn(ctx, clientId, c, limiter, ids)
+		limiter.Wait(ctx)
+	}
+}
+
+func (t txnLimitTraffic) txn(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider) {
+	cmpCount := t.limit + t.offsets[rand.Intn(len(t.offsets))]
+	cmps := make([]clientv3.Cmp, 0, cmpCount)
+	for i := 0; i < cmpCount; i++ {
+		cmps = append(cmps, clientv3.Compare(clientv3.CreateRevision(fmt.Sprintf("%scmp/%d", t.prefix, i%t.cmpKeyCount)), "=", 0))
+	}
+	marker := fmt.Sprintf("%s%d/%d", t.prefix, clientId, ids.RequestId())
+	txnCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err := c.Txn(txnCtx, cmps, []clientv3.Op{clientv3.OpPut(marker, fmt.Sprintf("%d", cmpCount))}, nil)
+	cancel()
+	limiter.Adapt(ctx, err)
+	rejected := errors.Is(err, rpctypes.ErrTooManyOps)
+	if err != nil && !rejected {
+		return
+	}
+	t.report.Txn(cmpCount, rejected)
+	if expectRejected := cmpCount > t.limit; rejected != expectRejected {
+		t.report.Violated(fmt.Sprintf("client: %d, key: %q, comparisons: %d, limit: %d, rejected: %t", clientId, marker, cmpCount, t.limit, rejected))
+		return
+	}
+	if !rejected {
+		return
+	}
+	limiter.Wait(ctx)
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	kv, revision, err := c.GetWithRevision(getCtx, marker)
+	cancel()
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	if kv != nil {
+		t.report.Violated(fmt.Sprintf("client: %d, key: %q, comparisons: %d, limit: %d, revision: %d, rejected transaction was applied", clientId, marker, cmpCount, t.limit, revision))
+	}
+}
+
+// txnLimitReport collects results of txnLimitTraffic validation from all clients.
+type txnLimitReport struct {
+	mux sync.Mutex
+	// atLimit counts transactions with exactly limit comparisons, which must be applied.
+	atLimit    int
+	applied    int
+	rejected   int
+	violations []string
+	// limit is used to recognize transactions at limit.
+	limit int
+}
+
+func (r *txnLimitReport) Txn(cmpCount int, rejected bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if rejected {
+		r.rejected++
+	} else {
+		r.applied++
+	}
+	if cmpCount == r.limit {
+		r.atLimit++
+	}
+}
+
+func (r *txnLimitReport) Violated(violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.violations = append(r.violations, violation)
+}
+
+func validateTxnLimit(t *testing.T, lg *zap.Logger, traffic txnLimitTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Txn limit traffic", zap.Int("limit", traffic.limit), zap.Int("applied", r.applied), zap.Int("at-limit", r.atLimit), zap.Int("rejected", r.rejected))
+	for _, violation := range r.violations {
+		t.Errorf("Broke transaction guarantee: Transaction with more comparisons than limit is rejected as a whole, and one within limit is applied, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.applied == 0 || r.rejected == 0 {
+		t.Errorf("Transactions were not both applied and rejected, limit: %d, offsets: %v, applied: %d, rejected: %d", traffic.limit, traffic.offsets, r.applied, r.rejected)
+	}
+	if r.atLimit == 0 {
+		t.Errorf("No transaction with exactly limit comparisons was done, limit: %d, offsets: %v", traffic.limit, traffic.offsets)
+	}
+}
@@ -23,7 +23,6 @@ import (
 	"github.com/anishathalye/porcupine"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	"go.uber.org/zap"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -31,18 +30,13 @@ import (
 	"go.etcd.io/etcd/tests/v3/robustness/model"
 )
 
-func collectClusterWatchEvents(ctx context.Context, t *testing.T, clus *e2e.EtcdProcessCluster, maxRevisionChan <-chan int64, requestProgress bool) [][]watchResponse {
+func collectClusterWatchEvents(ctx context.Context, t *testing.T, clus *e2e.EtcdProcessCluster, maxRevisionChan <-chan int64, requestProgress bool, credentials clientCredentials) [][]watchResponse {
 	mux := sync.Mutex{}
 	var wg sync.WaitGroup
 	memberResponses := make([][]watchResponse, len(clus.Procs))
 	memberMaxRevisionChans := make([]chan int64, len(clus.Procs))
 	for i, member := range clus.Procs {
-		c, err := clientv3.New(clientv3.Config{
-			Endpoints:            member.EndpointsGRPC(),
-			Logger:               zap.NewNop(),
-			DialKeepAliveTime:    10 * time.Second,
-			DialKeepAliveTimeout: 100 * time.Millisecond,
-		})
+		c, err := newEtcdClient(member.EndpointsGRPC(), credentials, DefaultKeepAlive)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -146,6 +140,7 @@ func validateMemberWatchResponses(t *testing.T, memberId string, responses []wat
 	validateOrderedAndReliable(t, memberId, responses)
 	validateUnique(t, memberId, responses)
 	validateAtomic(t, memberId, responses)
+	validateKeyLifecycle(t, memberId, responses)
 	// Validate kubernetes usage of watch
 	validateRenewable(t, memberId, responses)
 }
@@ -224,6 +219,20 @@ func validateAtomic(t *testing.T, memberId string, responses []watchResponse) {
 	}
 }
 
+// validateKeyLifecycle validates that version and create revision of keys are reset when they are deleted and recreated.
+// Only first violation is reported, as following events of the key would also be reported as invalid.
+func validateKeyLifecycle(t *testing.T, memberId string, responses []watchResponse) {
+	lifecycle := model.NewKeyLifecycle()
+	for _, resp := range responses {
+		for _, event := range resp.Events {
+			if err := lifecycle.Apply((*mvccpb.Event)(event)); err != nil {
+				t.Errorf("Broke key lifecycle: Version and CreateRevision are reset when key is deleted and recreated, %s, member: %q", err, memberId)
+				return
+			}
+		}
+	}
+}
+
 func toWatchEvents(responses []watchResponse) (events []watchEvent) {
 	for _, resp := range responses {
 		for _, event := range resp.Events {
@@ -314,16 +323,18 @@ func lastOperationObservedInWatch(operations []porcupine.Operation, watchEvents
 }
 
 func matchWatchEvent(request *model.TxnRequest, watchEvents map[model.EtcdOperation]watchEvent) *watchEvent {
-	for _, etcdOp := range request.Ops {
-		if etcdOp.Type == model.Put {
-			// Remove LeaseID which is not exposed in watch.
-			event, ok := watchEvents[model.EtcdOperation{
-				Type:  etcdOp.Type,
-				Key:   etcdOp.Key,
-				Value: etcdOp.Value,
-			}]
-			if ok {
-				return &event
+	for _, path := range txnExecutionPaths(request) {
+		for _, etcdOp := range path {
+			if etcdOp.Type == model.Put {
+				// Remove LeaseID which is not exposed in watch.
+				event, ok := watchEvents[model.EtcdOperation{
+					Type:  etcdOp.Type,
+					Key:   etcdOp.Key,
+					Value: etcdOp.Value,
+				}]
+				if ok {
+					return &event
+				}
 			}
 		}
 	}
@@ -331,21 +342,63 @@ func matchWatchEvent(request *model.TxnRequest, watchEvents map[model.EtcdOperat
 }
 
 func hasNonUniqueWriteOperation(request *model.TxnRequest) bool {
-	for _, etcdOp := range request.Ops {
-		if etcdOp.Type == model.Put || etcdOp.Type == model.Delete {
-			return true
+	for _, path := range txnExecutionPaths(request) {
+		for _, etcdOp := range path {
+			if etcdOp.Type == model.Put || etcdOp.Type == model.Delete {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// hasUniqueWriteOperation returns true if every possible execution of transaction either includes a put or doesn't write at all.
 func hasUniqueWriteOperation(request *model.TxnRequest) bool {
-	for _, etcdOp := range request.Ops {
-		if etcdOp.Type == model.Put {
-			return true
+	for _, path := range txnExecutionPaths(request) {
+		hasPut, hasWrite := false, false
+		for _, etcdOp := range path {
+			switch etcdOp.Type {
+			case model.Put:
+				hasPut = true
+			case model.Delete:
+				hasWrite = true
+			}
+		}
+		if !hasPut && hasWrite {
+			return false
 		}
 	}
-	return false
+	return true
+}
+
+// txnExecutionPaths returns operations executed by transaction, one list for each possible combination of branches taken.
+func txnExecutionPaths(request *model.TxnRequest) [][]model.EtcdOperation {
+	paths := opsExecutionPaths(request.Ops)
+	if len(request.Conds) != 0 {
+		paths = append(paths, opsExecutionPaths(request.OpsOnFailure)...)
+	}
+	return paths
+}
+
+func opsExecutionPaths(ops []model.EtcdOperation) [][]model.EtcdOperation {
+	paths := [][]model.EtcdOperation{{}}
+	for _, op := range ops {
+		suffixes := [][]model.EtcdOperation{{op}}
+		if op.Type == model.NestedTxn {
+			suffixes = txnExecutionPaths(op.Txn)
+		}
+		newPaths := make([][]model.EtcdOperation, 0, len(paths)*len(suffixes))
+		for _, path := range paths {
+			for _, suffix := range suffixes {
+				newPath := make([]model.EtcdOperation, 0, len(path)+len(suffix))
+				newPath = append(newPath, path...)
+				newPath = append(newPath, suffix...)
+				newPaths = append(newPaths, newPath)
+			}
+		}
+		paths = newPaths
+	}
+	return paths
 }
 
 func watchEvents(responses [][]watchResponse) [][]watchEvent {
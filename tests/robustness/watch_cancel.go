@@ -0,0 +1,165 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/framework/e2e"
+)
+
+// watchCancellation records cancel request sent for a watch and the server acknowledgment of it.
+type watchCancellation struct {
+	WatchId    int64
+	CancelTime time.Time
+	// AckTime is when watch response with canceled flag was received, zero if it was never received.
+	AckTime time.Time
+	// Repeated is set when cancel was sent again after it was acknowledged, canceling already completed watch.
+	Repeated bool
+	// LateResponses counts responses received for the watch after its cancel was acknowledged.
+	LateResponses int
+}
+
+// collectClusterWatchCancellations repeatedly creates and cancels watches on each member until finish is closed.
+func collectClusterWatchCancellations(ctx context.Context, t *testing.T, clus *e2e.EtcdProcessCluster, finish <-chan struct{}, credentials clientCredentials) [][]*watchCancellation {
+	mux := sync.Mutex{}
+	var wg sync.WaitGroup
+	memberCancellations := make([][]*watchCancellation, len(clus.Procs))
+	for i, member := range clus.Procs {
+		c, err := newEtcdClient(member.EndpointsGRPC(), credentials, DefaultKeepAlive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int, c *clientv3.Client) {
+			defer wg.Done()
+			defer c.Close()
+			cancellations := cancelMemberWatches(ctx, c, finish)
+			mux.Lock()
+			memberCancellations[i] = cancellations
+			mux.Unlock()
+		}(i, c)
+	}
+	wg.Wait()
+	return memberCancellations
+}
+
+// cancelMemberWatches opens watch streams on member and records cancellations of watches created on them.
+// Stream is reopened whenever it breaks, for example due to member being killed by failpoint.
+func cancelMemberWatches(ctx context.Context, c *clientv3.Client, finish <-chan struct{}) (cancellations []*watchCancellation) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-finish:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for ctx.Err() == nil {
+		cancellations = append(cancellations, cancelStreamWatches(ctx, c)...)
+		select {
+		case <-ctx.Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return cancellations
+}
+
+// cancelStreamWatches creates watches on a single watch stream one at a time and cancels them while events are in flight.
+// Every other cancel is sent twice, to check that canceling already completed watch doesn't produce any responses.
+func cancelStreamWatches(ctx context.Context, c *clientv3.Client) (cancellations []*watchCancellation) {
+	stream, err := pb.NewWatchClient(c.ActiveConnection()).Watch(ctx)
+	if err != nil {
+		return nil
+	}
+	watches := map[int64]*watchCancellation{}
+	recv := func() (*pb.WatchResponse, error) {
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if wc, ok := watches[resp.WatchId]; ok && !wc.AckTime.IsZero() {
+			wc.LateResponses++
+		}
+		return resp, nil
+	}
+	for i := 0; ; i++ {
+		err = stream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: &pb.WatchCreateRequest{
+			Key:      []byte{0},
+			RangeEnd: []byte{0},
+		}}})
+		if err != nil {
+			return cancellations
+		}
+		var watchId int64
+		for {
+			resp, err := recv()
+			if err != nil {
+				return cancellations
+			}
+			if resp.Created {
+				watchId = resp.WatchId
+				break
+			}
+		}
+		time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
+
+		wc := &watchCancellation{WatchId: watchId, CancelTime: time.Now()}
+		watches[watchId] = wc
+		cancellations = append(cancellations, wc)
+		err = stream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CancelRequest{CancelRequest: &pb.WatchCancelRequest{WatchId: watchId}}})
+		if err != nil {
+			return cancellations
+		}
+		for wc.AckTime.IsZero() {
+			resp, err := recv()
+			if err != nil {
+				return cancellations
+			}
+			// Watch could have been canceled by server, for example due to compaction, before it received our cancel request.
+			if resp.WatchId == watchId && resp.Canceled {
+				wc.AckTime = time.Now()
+			}
+		}
+		if i%2 == 1 {
+			wc.Repeated = true
+			err = stream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CancelRequest{CancelRequest: &pb.WatchCancelRequest{WatchId: watchId}}})
+			if err != nil {
+				return cancellations
+			}
+		}
+	}
+}
+
+func validateWatchCancellations(t *testing.T, clus *e2e.EtcdProcessCluster, cancellations [][]*watchCancellation) {
+	for i, member := range clus.Procs {
+		validateMemberWatchCancellations(t, member.Config().Name, cancellations[i])
+	}
+}
+
+func validateMemberWatchCancellations(t *testing.T, memberId string, cancellations []*watchCancellation) {
+	for _, wc := range cancellations {
+		if wc.LateResponses != 0 {
+			t.Errorf("Broke watch guarantee: Cancel - no responses are sent for watch after its cancellation was acknowledged, watchId: %d, lateResponses: %d, repeatedCancel: %v, member: %q", wc.WatchId, wc.LateResponses, wc.Repeated, memberId)
+		}
+	}
+}
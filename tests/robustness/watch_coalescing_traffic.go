@@ -0,0 +1,164 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// watchCoalescingTraffic puts the same key repeatedly while watching it from revision before the first put, so many
+// puts of the key happen between progress notifications. After traffic, events are compared with write history to
+// validate every put produced exactly one event, in order, without being coalesced with other puts or dropped.
+type watchCoalescingTraffic struct {
+	prefix    string
+	batchSize int
+	report    *watchCoalescingReport
+}
+
+func newWatchCoalescingTraffic(prefix string, batchSize int) watchCoalescingTraffic {
+	return watchCoalescingTraffic{
+		prefix:    prefix,
+		batchSize: batchSize,
+	}
+}
+
+func (t watchCoalescingTraffic) ForRun() Traffic {
+	t.report = &watchCoalescingReport{}
+	return t
+}
+
+func (t watchCoalescingTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateWatchCoalescing(tt, lg, t, operations)
+}
+
+func (t watchCoalescingTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	// Key is not a prefix of keys of other clients, so watch of it as prefix observes only this key.
+	key := fmt.Sprintf("%s%d/key", t.prefix, clientId)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runBatch(ctx, clientId, c, limiter, ids, key)
+	}
+}
+
+// runBatch watches key from current revision, puts it batchSize times and waits for watch to catch up.
+func (t watchCoalescingTraffic) runBatch(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, key string) {
+	revision, err := t.currentRevision(ctx, c, key)
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := newContiguityWatch(watchCtx, c, key, revision+1)
+	var puts, failedPuts int
+	defer func() {
+		t.report.Watched(clientId, w.watched, puts, failedPuts)
+	}()
+	if !w.waitCreated() {
+		return
+	}
+
+	for i := 0; i < t.batchSize; i++ {
+		limiter.Wait(ctx)
+		putCtx, putCancel := context.WithTimeout(ctx, RequestTimeout)
+		err = c.Put(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		putCancel()
+		limiter.Adapt(ctx, err)
+		if err != nil {
+			failedPuts++
+			continue
+		}
+		puts++
+	}
+
+	revision, err = t.currentRevision(ctx, c, key)
+	if err != nil {
+		return
+	}
+	w.catchUp(watchCtx, c, revision)
+}
+
+// currentRevision returns revision of the cluster, without recording the read in history.
+func (t watchCoalescingTraffic) currentRevision(ctx context.Context, c *recordingClient, key string) (int64, error) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	resp, err := c.client.Get(getCtx, key, clientv3.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
+// watchCoalescingReport collects watched events and number of puts of watchCoalescingTraffic from all clients.
+type watchCoalescingReport struct {
+	mux     sync.Mutex
+	watched []model.WatchedRevisions
+	// puts counts acknowledged puts, failedPuts counts puts with unknown result, each may or may not produce an event.
+	puts       int
+	failedPuts int
+	events     int
+	violations []string
+}
+
+// Watched records events delivered by watch, comparing their number with puts done while it was watching.
+// Watch resumed after compaction can deliver events again, so only watch that was never resumed is compared.
+func (r *watchCoalescingReport) Watched(clientId int, watched []model.WatchedRevisions, puts, failedPuts int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var events int
+	for _, w := range watched {
+		events += len(w.Events)
+	}
+	r.watched = append(r.watched, watched...)
+	r.puts += puts
+	r.failedPuts += failedPuts
+	r.events += events
+	if len(watched) == 1 && events > puts+failedPuts {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, watch delivered %d events, more than %d puts and %d puts with unknown result", clientId, events, puts, failedPuts))
+	}
+}
+
+func validateWatchCoalescing(t *testing.T, lg *zap.Logger, traffic watchCoalescingTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Watch coalescing traffic", zap.Int("watches", len(r.watched)), zap.Int("puts", r.puts), zap.Int("failed-puts", r.failedPuts), zap.Int("events", r.events))
+	for _, violation := range r.violations {
+		t.Errorf("Broke watch guarantee: Unique - watch delivers each event at most once, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchCoalescing(operations, r.watched) {
+		t.Errorf("Broke watch guarantee: Reliable - watch delivers event of each put, without coalescing puts of the same key, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.events == 0 {
+		t.Errorf("No watch event was delivered, batchSize: %d", traffic.batchSize)
+	}
+}
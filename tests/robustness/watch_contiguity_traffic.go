@@ -0,0 +1,292 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// watchProgressInterval is how often watch catching up requests progress notification.
+const watchProgressInterval = 50 * time.Millisecond
+
+// watchContiguityTraffic watches prefix while writing and occasionally compacting keys under it, recording revisions
+// of delivered events. Watch compacted before catching up is resumed from compact revision. After traffic, recorded
+// events are compared with write history to find revisions that modified a watched key, but were skipped by watch.
+type watchContiguityTraffic struct {
+	prefix           string
+	keyCount         int
+	batchSize        int
+	compactChance    int
+	compactionPolicy CompactionPolicy
+	report           *watchContiguityReport
+}
+
+func newWatchContiguityTraffic(prefix string, keyCount, batchSize, compactChance int, policy CompactionPolicy) watchContiguityTraffic {
+	return watchContiguityTraffic{
+		prefix:           prefix,
+		keyCount:         keyCount,
+		batchSize:        batchSize,
+		compactChance:    compactChance,
+		compactionPolicy: policy,
+	}
+}
+
+func (t watchContiguityTraffic) ForRun() Traffic {
+	t.report = &watchContiguityReport{}
+	return t
+}
+
+func (t watchContiguityTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateWatchContiguity(tt, lg, t, operations)
+}
+
+func (t watchContiguityTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runBatch(ctx, clientId, c, limiter, ids, prefix)
+	}
+}
+
+// runBatch watches prefix from current revision, writes batch of keys under it and waits for watch to catch up.
+func (t watchContiguityTraffic) runBatch(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string) {
+	revision, err := t.currentRevision(ctx, c, prefix)
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := newContiguityWatch(watchCtx, c, prefix, revision+1)
+	defer func() {
+		t.report.Watched(clientId, w.watched, w.compactions, w.violation)
+	}()
+	if !w.waitCreated() {
+		return
+	}
+
+	var lastRevision int64
+	for i := 0; i < t.batchSize; i++ {
+		limiter.Wait(ctx)
+		key := fmt.Sprintf("%s%d", prefix, rand.Intn(t.keyCount))
+		opCtx, opCancel := context.WithTimeout(ctx, RequestTimeout)
+		switch n := rand.Intn(100); {
+		case n < t.compactChance && lastRevision != 0:
+			opCancel()
+			opCtx, opCancel = context.WithTimeout(ctx, CompactTimeout)
+			// Revision might have been already compacted by other client, which is not a failure of the traffic.
+			err = c.Compact(opCtx, lastRevision, t.compactionPolicy.Physical())
+		case n < 60:
+			lastRevision, err = c.PutWithRevision(opCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+		case n < 80:
+			err = c.Delete(opCtx, key)
+		default:
+			other := fmt.Sprintf("%s%d", prefix, rand.Intn(t.keyCount))
+			err = c.Txn(opCtx, nil, []clientv3.Op{
+				clientv3.OpPut(key, fmt.Sprintf("%d", ids.RequestId())),
+				clientv3.OpPut(other, fmt.Sprintf("%d", ids.RequestId())),
+			}, nil)
+		}
+		opCancel()
+		limiter.Adapt(ctx, err)
+	}
+
+	revision, err = t.currentRevision(ctx, c, prefix)
+	if err != nil {
+		return
+	}
+	w.catchUp(watchCtx, c, revision)
+}
+
+// currentRevision returns revision of the cluster, without recording the read in history.
+func (t watchContiguityTraffic) currentRevision(ctx context.Context, c *recordingClient, prefix string) (int64, error) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
+// contiguityWatch records events of watch, resuming it from compact revision if it's compacted.
+type contiguityWatch struct {
+	prefix string
+	watch  clientv3.WatchChan
+	// watched has revisions of events delivered by each watch, the last one is being watched.
+	watched     []model.WatchedRevisions
+	compactions int
+	violation   string
+}
+
+func newContiguityWatch(ctx context.Context, c *recordingClient, prefix string, revision int64) *contiguityWatch {
+	w := &contiguityWatch{prefix: prefix}
+	w.resume(ctx, c, revision)
+	return w
+}
+
+func (w *contiguityWatch) resume(ctx context.Context, c *recordingClient, revision int64) {
+	w.watch = c.client.Watch(ctx, w.prefix, clientv3.WithPrefix(), clientv3.WithRev(revision), clientv3.WithCreatedNotify())
+	// Nothing was observed yet, so watch caught up with revision before the one it starts from.
+	w.watched = append(w.watched, model.WatchedRevisions{Prefix: w.prefix, StartRevision: revision, EndRevision: revision - 1})
+}
+
+func (w *contiguityWatch) current() *model.WatchedRevisions {
+	return &w.watched[len(w.watched)-1]
+}
+
+// waitCreated returns whether watch was created.
+func (w *contiguityWatch) waitCreated() bool {
+	select {
+	case resp, ok := <-w.watch:
+		return ok && resp.Created && resp.Err() == nil
+	case <-time.After(watchBatchTimeout):
+		return false
+	}
+}
+
+// catchUp reads watch until it's known to have delivered all events up to revision, requesting progress
+// notifications to learn about revisions that didn't modify any watched key. Watch broken by failpoint or
+// timeout stops catching up, events it delivered until then are still validated.
+func (w *contiguityWatch) catchUp(ctx context.Context, c *recordingClient, revision int64) {
+	timeout := time.After(watchBatchTimeout)
+	ticker := time.NewTicker(watchProgressInterval)
+	defer ticker.Stop()
+	for w.current().EndRevision < revision {
+		var resp clientv3.WatchResponse
+		var ok bool
+		select {
+		case resp, ok = <-w.watch:
+		case <-ticker.C:
+			c.client.RequestProgress(ctx)
+			continue
+		case <-timeout:
+			return
+		}
+		if !ok {
+			return
+		}
+		w.current().Responses = append(w.current().Responses, watchedResponse(resp))
+		if resp.CompactRevision != 0 {
+			next := w.current().EndRevision + 1
+			if !model.WatchMayBeCompacted(next, resp.CompactRevision) {
+				w.violation = fmt.Sprintf("watch of prefix %q needing revision %d was compacted at revision %d", w.prefix, next, resp.CompactRevision)
+				return
+			}
+			w.compactions++
+			w.resume(ctx, c, resp.CompactRevision)
+			continue
+		}
+		if resp.Err() != nil {
+			return
+		}
+		current := w.current()
+		for _, event := range resp.Events {
+			current.Events = append(current.Events, watchedEvent(event))
+			if event.Kv.ModRevision > current.EndRevision {
+				current.EndRevision = event.Kv.ModRevision
+			}
+		}
+		// Progress notification guarantees all events up to its revision were delivered.
+		if resp.IsProgressNotify() && resp.Header.Revision > current.EndRevision {
+			current.EndRevision = resp.Header.Revision
+		}
+	}
+}
+
+// watchedEvent records key, revision, type and value of event delivered by watch.
+func watchedEvent(event *clientv3.Event) model.WatchedEvent {
+	eventType := model.Put
+	if event.Type == mvccpb.DELETE {
+		eventType = model.Delete
+	}
+	return model.WatchedEvent{Key: string(event.Kv.Key), Revision: event.Kv.ModRevision, Type: eventType, Value: model.ToValueOrHash(string(event.Kv.Value))}
+}
+
+// watchedResponse records header and compaction fields of watch response.
+func watchedResponse(resp clientv3.WatchResponse) model.WatchedResponse {
+	return model.WatchedResponse{
+		HeaderRevision:  resp.Header.Revision,
+		CompactRevision: resp.CompactRevision,
+		Canceled:        resp.Canceled,
+		ProgressNotify:  resp.IsProgressNotify(),
+		Events:          len(resp.Events),
+	}
+}
+
+// watchContiguityReport collects watched revisions of watchContiguityTraffic from all clients.
+type watchContiguityReport struct {
+	mux              sync.Mutex
+	watched          []model.WatchedRevisions
+	events           int
+	compactedWatches int
+	violations       []string
+}
+
+// Watched records revisions of events delivered by watch, together with violation of compaction if it happened.
+func (r *watchContiguityReport) Watched(clientId int, watched []model.WatchedRevisions, compactions int, violation string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, w := range watched {
+		r.events += len(w.Events)
+	}
+	r.watched = append(r.watched, watched...)
+	r.compactedWatches += compactions
+	if violation != "" {
+		r.violations = append(r.violations, fmt.Sprintf("client: %d, %s", clientId, violation))
+	}
+}
+
+func validateWatchContiguity(t *testing.T, lg *zap.Logger, traffic watchContiguityTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Watch contiguity traffic", zap.Int("watches", len(r.watched)), zap.Int("events", r.events), zap.Int("compacted-watches", r.compactedWatches))
+	for _, violation := range r.violations {
+		t.Errorf("Broke watch guarantee: Compaction - only watch starting below compaction revision can be compacted, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchContiguity(operations, r.watched) {
+		t.Errorf("Broke watch guarantee: Reliable - watch delivers event of each revision modifying watched key, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchResponses(r.watched) {
+		t.Errorf("Broke watch guarantee: Compaction - watch is canceled only by compaction of revision it needs, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchedPuts(operations, r.watched) {
+		t.Errorf("Broke watch guarantee: Reliable - put of watched key is observed by watch active at its revision, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	if r.events == 0 {
+		t.Errorf("No watch event was delivered, batchSize: %d, keyCount: %d", traffic.batchSize, traffic.keyCount)
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// reusedWatchId is requested explicitly by watch created on the stream right after canceling the previous owner of it.
+const reusedWatchId int64 = 1
+
+// watchIdTraffic churns watch creation and cancellation, so watch IDs are reassigned while events of their previous
+// watch might still be in flight. Each iteration opens new watch stream, so watch ID 0 is assigned again, and reuses
+// explicitly requested watch ID on the same stream right after canceling its previous owner. Watch stream is used
+// directly, as clientv3 doesn't expose watch IDs. With authentication enabled, watches are created by user that is
+// permitted to read only its prefix, and each stream starts with rejected watch of a forbidden key.
+type watchIdTraffic struct {
+	prefix string
+	auth   bool
+	report *watchIdReport
+}
+
+func newWatchIdTraffic(prefix string, auth bool) watchIdTraffic {
+	return watchIdTraffic{
+		prefix: prefix,
+		auth:   auth,
+	}
+}
+
+func (t watchIdTraffic) ForRun() Traffic {
+	t.report = &watchIdReport{}
+	return t
+}
+
+func (t watchIdTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateWatchIdTraffic(tt, lg, t)
+}
+
+func (t watchIdTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	wc := &c.client
+	if t.auth {
+		var err error
+		wc, err = t.setupUser(ctx, clientId, c, limiter, prefix)
+		if err != nil {
+			t.report.Failed(fmt.Sprintf("client: %d, failed to setup user: %v", clientId, err))
+			return
+		}
+		defer wc.Close()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		stream, ok := t.runStream(ctx, c, wc, limiter, ids, prefix)
+		if ok {
+			t.report.Watched(stream)
+		}
+	}
+}
+
+// setupUser creates user permitted to read and write only the prefix and returns client authenticated as the user.
+func (t watchIdTraffic) setupUser(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, prefix string) (*clientv3.Client, error) {
+	role, user := fmt.Sprintf("watch-id-role-%d", clientId), fmt.Sprintf("watch-id-user-%d", clientId)
+	steps := []func(ctx context.Context) error{
+		func(ctx context.Context) error {
+			_, err := c.client.RoleAdd(ctx, role)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.RoleGrantPermission(ctx, role, prefix, clientv3.GetPrefixRangeEnd(prefix), clientv3.PermissionType(clientv3.PermReadWrite))
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.UserAdd(ctx, user, user)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, err := c.client.UserGrantRole(ctx, user, role)
+			return err
+		},
+	}
+	deadline := time.Now().Add(grantTimeout)
+	for _, step := range steps {
+		var err error
+		// Step might fail due to failpoint, or succeed despite returning error, so it's retried until deadline
+		// and already existing role or user is accepted.
+		for {
+			limiter.Wait(ctx)
+			stepCtx, cancel := context.WithTimeout(ctx, authRequestTimeout)
+			err = step(stepCtx)
+			cancel()
+			limiter.Adapt(ctx, err)
+			if err == nil || isAlreadyExist(err) || !time.Now().Before(deadline) || ctx.Err() != nil {
+				break
+			}
+		}
+		if err != nil && !isAlreadyExist(err) {
+			return nil, err
+		}
+	}
+	return newEtcdClient(c.client.Endpoints(), clientCredentials{username: user, password: user}, c.keepAlive, c.dialOptions...)
+}
+
+// runStream opens new watch stream and churns watches on it, returning recorded watches if stream was opened.
+func (t watchIdTraffic) runStream(ctx context.Context, c *recordingClient, wc *clientv3.Client, limiter *trafficLimiter, ids identity.Provider, prefix string) (model.WatchStream, bool) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limiter.Wait(ctx)
+	grpcStream, err := etcdserverpb.NewWatchClient(wc.ActiveConnection()).Watch(streamCtx)
+	limiter.Adapt(ctx, err)
+	if err != nil {
+		return model.WatchStream{}, false
+	}
+	s := newWatchIdStream(grpcStream)
+	t.churn(ctx, c, limiter, ids, s, prefix)
+	// Responses received until stream is closed are recorded too, so they are read after receiving stopped.
+	cancel()
+	<-s.done
+	return s.watched, true
+}
+
+// churn creates watches on the stream and cancels them, putting watched keys in between. It stops at first
+// request that couldn't be sent or response that wasn't received in time, as stream might be broken by failpoint.
+func (t watchIdTraffic) churn(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, s *watchIdStream, prefix string) {
+	keyA, keyB, keyC := prefix+"a", prefix+"b", prefix+"c"
+	created := 0
+	if t.auth {
+		if !s.create(t.prefix+"forbidden", 0) {
+			return
+		}
+		created++
+	}
+	// Watch of key A is assigned watch ID 0, as it's the first watch permitted on the stream.
+	if !s.create(keyA, 0) || !s.create(keyB, reusedWatchId) {
+		return
+	}
+	created += 2
+	if !s.waitCreated(created) {
+		return
+	}
+	t.put(ctx, c, limiter, ids, keyA)
+	t.put(ctx, c, limiter, ids, keyB)
+	// Event of key B might still be in flight when its watch ID is reassigned to watch of key C.
+	if !s.cancel(reusedWatchId) || !s.create(keyC, reusedWatchId) {
+		return
+	}
+	created++
+	if !s.waitCreated(created) {
+		return
+	}
+	t.put(ctx, c, limiter, ids, keyB)
+	t.put(ctx, c, limiter, ids, keyC)
+	if !s.waitEvent(reusedWatchId, keyC) || !s.cancel(0) {
+		return
+	}
+	s.waitCanceled(0)
+}
+
+func (t watchIdTraffic) put(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, key string) {
+	limiter.Wait(ctx)
+	putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	err := c.Put(putCtx, key, fmt.Sprintf("%d", ids.RequestId()))
+	cancel()
+	limiter.Adapt(ctx, err)
+}
+
+// watchIdStream sends watch requests on watch stream and records them together with responses received on it.
+type watchIdStream struct {
+	stream etcdserverpb.Watch_WatchClient
+	done   chan struct{}
+	// received is notified after each response.
+	received chan struct{}
+
+	mux     sync.Mutex
+	watched model.WatchStream
+}
+
+func newWatchIdStream(stream etcdserverpb.Watch_WatchClient) *watchIdStream {
+	s := &watchIdStream{
+		stream:   stream,
+		done:     make(chan struct{}),
+		received: make(chan struct{}, 1),
+	}
+	go s.receive()
+	return s
+}
+
+func (s *watchIdStream) receive() {
+	defer close(s.done)
+	for {
+		resp, err := s.stream.Recv()
+		if err != nil {
+			return
+		}
+		recorded := model.WatchStreamResponse{
+			WatchId:      resp.WatchId,
+			Created:      resp.Created,
+			Canceled:     resp.Canceled,
+			CancelReason: resp.CancelReason,
+		}
+		for _, event := range resp.Events {
+			recorded.Events = append(recorded.Events, watchedEvent((*clientv3.Event)(event)))
+		}
+		s.mux.Lock()
+		s.watched.Responses = append(s.watched.Responses, recorded)
+		s.mux.Unlock()
+		select {
+		case s.received <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// create requests watch of the key, using watch ID 0 to let server assign it.
+func (s *watchIdStream) create(key string, watchId int64) bool {
+	// Create request is recorded before it's sent, as its response might be received before Send returns.
+	s.mux.Lock()
+	s.watched.Creates = append(s.watched.Creates, key)
+	s.mux.Unlock()
+	err := s.stream.Send(&etcdserverpb.WatchRequest{RequestUnion: &etcdserverpb.WatchRequest_CreateRequest{
+		CreateRequest: &etcdserverpb.WatchCreateRequest{Key: []byte(key), WatchId: watchId},
+	}})
+	return err == nil
+}
+
+func (s *watchIdStream) cancel(watchId int64) bool {
+	err := s.stream.Send(&etcdserverpb.WatchRequest{RequestUnion: &etcdserverpb.WatchRequest_CancelRequest{
+		CancelRequest: &etcdserverpb.WatchCancelRequest{WatchId: watchId},
+	}})
+	return err == nil
+}
+
+func (s *watchIdStream) waitCreated(count int) bool {
+	return s.wait(func(responses []model.WatchStreamResponse) bool {
+		created := 0
+		for _, resp := range responses {
+			if resp.Created {
+				created++
+			}
+		}
+		return created >= count
+	})
+}
+
+func (s *watchIdStream) waitEvent(watchId int64, key string) bool {
+	return s.wait(func(responses []model.WatchStreamResponse) bool {
+		for _, resp := range responses {
+			for _, event := range resp.Events {
+				if resp.WatchId == watchId && event.Key == key {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+func (s *watchIdStream) waitCanceled(watchId int64) bool {
+	return s.wait(func(responses []model.WatchStreamResponse) bool {
+		for _, resp := range responses {
+			if resp.WatchId == watchId && resp.Canceled && !resp.Created {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// wait returns whether condition on received responses was met before watchBatchTimeout or stream being broken.
+func (s *watchIdStream) wait(condition func(responses []model.WatchStreamResponse) bool) bool {
+	timeout := time.After(watchBatchTimeout)
+	for {
+		s.mux.Lock()
+		met := condition(s.watched.Responses)
+		s.mux.Unlock()
+		if met {
+			return true
+		}
+		select {
+		case <-s.received:
+		case <-s.done:
+			return false
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// isAlreadyExist returns whether request failed because role or user already exists.
+func isAlreadyExist(err error) bool {
+	return errors.Is(err, rpctypes.ErrRoleAlreadyExist) || errors.Is(err, rpctypes.ErrUserAlreadyExist)
+}
+
+// watchIdReport collects watch streams of watchIdTraffic from all clients.
+type watchIdReport struct {
+	mux      sync.Mutex
+	streams  []model.WatchStream
+	failures []string
+}
+
+func (r *watchIdReport) Watched(stream model.WatchStream) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.streams = append(r.streams, stream)
+}
+
+func (r *watchIdReport) Failed(failure string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.failures = append(r.failures, failure)
+}
+
+func validateWatchIdTraffic(t *testing.T, lg *zap.Logger, traffic watchIdTraffic) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var rejected, events int
+	for _, stream := range r.streams {
+		for _, resp := range stream.Responses {
+			if resp.Created && resp.Canceled {
+				rejected++
+			}
+			events += len(resp.Events)
+		}
+	}
+	violations, zeroIdWatches := model.ValidateWatchIdReuse(r.streams)
+	lg.Info("Watch ID traffic", zap.Int("streams", len(r.streams)), zap.Int("zero-id-watches", zeroIdWatches), zap.Int("rejected-watches", rejected), zap.Int("events", events))
+	for _, violation := range violations {
+		t.Errorf("Broke watch guarantee: Isolation - watch receives only events of its own key, %s", violation)
+	}
+	// Validate traffic is correctly configured to ensure proper testing
+	for _, failure := range r.failures {
+		t.Errorf("Watch ID traffic failed, %s", failure)
+	}
+	if zeroIdWatches == 0 {
+		t.Errorf("No watch was assigned watch ID 0, streams: %d", len(r.streams))
+	}
+	if traffic.auth && rejected == 0 {
+		t.Errorf("No watch creation was rejected, streams: %d", len(r.streams))
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anishathalye/porcupine"
+	"go.uber.org/zap"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.etcd.io/etcd/tests/v3/robustness/identity"
+	"go.etcd.io/etcd/tests/v3/robustness/model"
+)
+
+// DefaultWatchFragmentThreshold is size of watch response above which etcd with default --max-request-bytes
+// splits it into fragments, for watches that enabled fragmentation. It includes 512KiB of gRPC overhead allowed by server.
+const DefaultWatchFragmentThreshold = embed.DefaultMaxRequestBytes + 512*1024
+
+// watchBatchTimeout limits time for watch to catch up on whole batch, which requires transferring all its values.
+const watchBatchTimeout = time.Second
+
+// unreachableWatchOffset is added to start revision of future watches that are expected to be canceled before
+// store reaches their start revision.
+const unreachableWatchOffset = 1 << 20
+
+// watchTraffic writes batches of large values and watches each batch from its start revision, with fragmentation toggled
+// on and off for each next batch. Catching up on whole batch makes etcd send events in response larger than
+// fragmentThreshold, forcing it to be fragmented if watch enabled it. Batch watched from current revision is also
+// watched with the opposite fragmentation setting, to validate that fragments are reassembled into the same events
+// as delivered without fragmentation.
+// Each value is derived from its key, so watch can validate that reassembled events carry exactly the value that was put.
+// Some of the put keys are deleted in the same batch, and watch randomly asks server to filter out either put or
+// delete events. Delivered events are validated against history, to check that no event of filtered out type
+// was delivered, while all events of the other type were. Watches started at a future revision are validated to
+// deliver no events until store reaches it.
+type watchTraffic struct {
+	prefix string
+	// valueSize is size of each put value. Together with batchSize it should exceed fragmentThreshold,
+	// while being small enough for single put to fit into --max-request-bytes.
+	valueSize         int
+	batchSize         int
+	fragmentThreshold int
+	// deleteChance is percentage of keys put in batch that are deleted afterwards.
+	deleteChance int
+	// futureWatchChance is percentage of batches watched from revision in the future, created before the batch.
+	futureWatchChance int
+	report            *watchTrafficReport
+}
+
+func newWatchTraffic(prefix string, valueSize, batchSize, fragmentThreshold, deleteChance, futureWatchChance int) watchTraffic {
+	return watchTraffic{
+		prefix:            prefix,
+		valueSize:         valueSize,
+		batchSize:         batchSize,
+		fragmentThreshold: fragmentThreshold,
+		deleteChance:      deleteChance,
+		futureWatchChance: futureWatchChance,
+	}
+}
+
+func (t watchTraffic) ForRun() Traffic {
+	t.report = &watchTrafficReport{}
+	return t
+}
+
+func (t watchTraffic) Validate(tt *testing.T, lg *zap.Logger, operations []porcupine.Operation) {
+	validateWatchTraffic(tt, lg, t, operations)
+}
+
+func (t watchTraffic) Run(ctx context.Context, clientId int, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, lm identity.LeaseIdStorage, finish <-chan struct{}) {
+	prefix := fmt.Sprintf("%s%d/", t.prefix, clientId)
+	for batch := 0; ; batch++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-finish:
+			return
+		default:
+		}
+		t.runBatch(ctx, c, limiter, ids, prefix, batch%2 == 0)
+	}
+}
+
+// runBatch puts batch of values under prefix, deletes some of them, and validates that watch from revision before
+// the batch observes events of types it didn't filter out. Some watches are created before the batch, starting at
+// revision in the future, either reached during the batch or so far that the watch is canceled before store reaches it.
+func (t watchTraffic) runBatch(ctx context.Context, c *recordingClient, limiter *trafficLimiter, ids identity.Provider, prefix string, fragment bool) {
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	if err != nil {
+		return
+	}
+	startRevision := resp.Header.Revision + 1
+	future := rand.Intn(100) < t.futureWatchChance
+	unreachable := future && rand.Intn(2) == 0
+	if future {
+		startRevision += int64(rand.Intn(t.batchSize)) + 1
+	}
+	if unreachable {
+		startRevision += unreachableWatchOffset
+	}
+	watched := model.WatchedRevisions{Prefix: prefix, StartRevision: startRevision, EndRevision: startRevision - 1, Fragment: fragment}
+	switch rand.Intn(3) {
+	case 1:
+		watched.FilterPut = true
+	case 2:
+		watched.FilterDelete = true
+	}
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	var watch clientv3.WatchChan
+	if future {
+		watch = t.watch(watchCtx, c, watched, clientv3.WithCreatedNotify())
+		created, ok := <-watch
+		if !ok || created.Err() != nil || !created.Created {
+			return
+		}
+		watched.CreatedRevision = created.Header.Revision
+		t.recordResponse(&watched, created)
+	}
+	// Events delivered before watch was broken are still validated.
+	defer func() {
+		if watch != nil {
+			t.report.Watched(watched)
+		}
+	}()
+
+	// Keys with persisted put and delete that were not filtered out and happened at or after start revision,
+	// watch waits until it observes their events.
+	puts, deletes := map[string]bool{}, map[string]bool{}
+	persisted := []string{}
+	for i := 0; i < t.batchSize; i++ {
+		limiter.Wait(ctx)
+		key := fmt.Sprintf("%s%d", prefix, ids.RequestId())
+		putCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.PutWithRevision(putCtx, key, watchTrafficValue(key, t.valueSize))
+		cancel()
+		limiter.Adapt(ctx, err)
+		// Failed put might have been persisted too, its value will still be validated if watch observes it.
+		if err != nil {
+			continue
+		}
+		persisted = append(persisted, key)
+		if !watched.FilterPut && revision >= startRevision {
+			puts[key] = true
+		}
+	}
+	for _, key := range persisted {
+		if rand.Intn(100) >= t.deleteChance {
+			continue
+		}
+		limiter.Wait(ctx)
+		deleteCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		revision, err := c.DeleteWithRevision(deleteCtx, key)
+		cancel()
+		limiter.Adapt(ctx, err)
+		if err == nil && !watched.FilterDelete && revision >= startRevision {
+			deletes[key] = true
+		}
+	}
+	if unreachable {
+		t.cancelFutureWatch(ctx, c, cancelWatch, watch, &watched)
+		return
+	}
+	if len(puts)+len(deletes) == 0 {
+		return
+	}
+
+	if watch == nil {
+		watch = t.watch(watchCtx, c, watched)
+		mirrored := watched
+		mirrored.Fragment = !watched.Fragment
+		mirror := t.watch(watchCtx, c, mirrored)
+		// Runs before the watch is reported, after it caught up or was broken.
+		defer func() {
+			t.catchUpMirror(mirror, &mirrored, watched.EndRevision)
+			t.report.Mirrored(watched, mirrored)
+		}()
+	}
+	timeout := time.After(watchBatchTimeout)
+	for len(puts)+len(deletes) > 0 {
+		var resp clientv3.WatchResponse
+		var ok bool
+		select {
+		case resp, ok = <-watch:
+		case <-timeout:
+			return
+		}
+		if !ok {
+			return
+		}
+		// Record response before checking error, to validate compaction notification.
+		t.recordResponse(&watched, resp)
+		if resp.Err() != nil {
+			// Watch could have been broken by failpoint or compaction.
+			return
+		}
+		size := 0
+		for _, event := range resp.Events {
+			size += event.Kv.Size()
+		}
+		for _, event := range resp.Events {
+			key := string(event.Kv.Key)
+			if event.Type != mvccpb.PUT {
+				delete(deletes, key)
+				continue
+			}
+			delete(puts, key)
+			expect := watchTrafficValue(key, t.valueSize)
+			if string(event.Kv.Value) != expect {
+				t.report.Failed(fmt.Sprintf("key: %q, revision: %d, eventSize: %d, expectedSize: %d, responseSize: %d, fragmentThreshold: %d",
+					key, event.Kv.ModRevision, len(event.Kv.Value), len(expect), size, t.fragmentThreshold))
+			}
+		}
+		t.report.Observed(len(resp.Events), size, watched.Fragment && size > t.fragmentThreshold)
+	}
+	if watched.CreatedRevision != 0 {
+		t.report.FutureWatchCaughtUp()
+	}
+}
+
+// watch starts watch of prefix from start revision of watched, with its filters and fragmentation setting.
+func (t watchTraffic) watch(ctx context.Context, c *recordingClient, watched model.WatchedRevisions, extraOpts ...clientv3.OpOption) clientv3.WatchChan {
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithRev(watched.StartRevision)}
+	if watched.Fragment {
+		opts = append(opts, clientv3.WithFragment())
+	}
+	if watched.FilterPut {
+		opts = append(opts, clientv3.WithFilterPut())
+	}
+	if watched.FilterDelete {
+		opts = append(opts, clientv3.WithFilterDelete())
+	}
+	opts = append(opts, extraOpts...)
+	return c.client.Watch(ctx, watched.Prefix, opts...)
+}
+
+// cancelFutureWatch cancels watch whose start revision was not expected to be reached, recording events it delivered
+// anyway, and whether store indeed didn't reach the start revision before cancel.
+func (t watchTraffic) cancelFutureWatch(ctx context.Context, c *recordingClient, cancelWatch context.CancelFunc, watch clientv3.WatchChan, watched *model.WatchedRevisions) {
+	cancelWatch()
+	for resp := range watch {
+		t.recordResponse(watched, resp)
+	}
+	getCtx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	resp, err := c.client.Get(getCtx, watched.Prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	cancel()
+	if err == nil && resp.Header.Revision < watched.StartRevision {
+		t.report.FutureWatchCanceled()
+	}
+}
+
+// catchUpMirror records events of mirror watch until it catches up with end revision, or it's broken or times out.
+func (t watchTraffic) catchUpMirror(mirror clientv3.WatchChan, mirrored *model.WatchedRevisions, end int64) {
+	timeout := time.After(watchBatchTimeout)
+	for mirrored.EndRevision < end {
+		select {
+		case resp, ok := <-mirror:
+			if !ok {
+				return
+			}
+			t.recordResponse(mirrored, resp)
+			if resp.Err() != nil {
+				return
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func (t watchTraffic) recordResponse(watched *model.WatchedRevisions, resp clientv3.WatchResponse) {
+	watched.Responses = append(watched.Responses, watchedResponse(resp))
+	for _, event := range resp.Events {
+		watched.Events = append(watched.Events, watchedEvent(event))
+		if event.Kv.ModRevision > watched.EndRevision {
+			watched.EndRevision = event.Kv.ModRevision
+		}
+	}
+}
+
+// watchTrafficValue returns value of given size, deterministically generated from key.
+func watchTrafficValue(key string, size int) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	value := make([]byte, size)
+	for i := range value {
+		value[i] = byte(int('a') + r.Intn(26))
+	}
+	return string(value)
+}
+
+// watchTrafficReport collects results of watchTraffic validation from all clients.
+type watchTrafficReport struct {
+	mux                 sync.Mutex
+	events              int
+	fragmentedResponses int
+	maxResponseSize     int
+	failures            []string
+	watched             []model.WatchedRevisions
+	putFilteredWatches  int
+	deleteEvents        int
+	// futureWatches counts watches from future revision that caught up with their batch, canceledFutureWatches
+	// those canceled before store reached their start revision.
+	futureWatches         int
+	canceledFutureWatches int
+	// fragmentedWatches counts watches with fragmentation enabled, mirroredWatches batches watched with both settings.
+	fragmentedWatches     int
+	mirroredWatches       int
+	fragmentationFailures []string
+}
+
+func (r *watchTrafficReport) Observed(events, responseSize int, fragmented bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.events += events
+	if fragmented {
+		r.fragmentedResponses++
+	}
+	if responseSize > r.maxResponseSize {
+		r.maxResponseSize = responseSize
+	}
+}
+
+func (r *watchTrafficReport) Failed(failure string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.failures = append(r.failures, failure)
+}
+
+// Watched records events delivered by watch of batch, to be validated against history.
+func (r *watchTrafficReport) Watched(watched model.WatchedRevisions) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.watched = append(r.watched, watched)
+	if watched.Fragment {
+		r.fragmentedWatches++
+	}
+	if watched.FilterPut {
+		r.putFilteredWatches++
+	}
+	for _, event := range watched.Events {
+		if event.Type == model.Delete {
+			r.deleteEvents++
+		}
+	}
+}
+
+// Mirrored records events delivered by mirror of watch of batch with the opposite fragmentation setting, validating
+// that both delivered the same events.
+func (r *watchTrafficReport) Mirrored(watched, mirrored model.WatchedRevisions) {
+	fragmented, whole := watched, mirrored
+	if !fragmented.Fragment {
+		fragmented, whole = mirrored, watched
+	}
+	err := model.ValidateWatchFragmentation(fragmented, whole)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.watched = append(r.watched, mirrored)
+	if mirrored.Fragment {
+		r.fragmentedWatches++
+	}
+	r.mirroredWatches++
+	if err != nil {
+		r.fragmentationFailures = append(r.fragmentationFailures, err.Error())
+	}
+}
+
+func (r *watchTrafficReport) FutureWatchCaughtUp() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.futureWatches++
+}
+
+func (r *watchTrafficReport) FutureWatchCanceled() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.canceledFutureWatches++
+}
+
+func validateWatchTraffic(t *testing.T, lg *zap.Logger, traffic watchTraffic, operations []porcupine.Operation) {
+	r := traffic.report
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	lg.Info("Watch traffic", zap.Int("events", r.events), zap.Int("fragmented-responses", r.fragmentedResponses), zap.Int("max-response-size", r.maxResponseSize), zap.Int("fragment-threshold", traffic.fragmentThreshold),
+		zap.Int("put-filtered-watches", r.putFilteredWatches), zap.Int("delete-events", r.deleteEvents),
+		zap.Int("future-watches", r.futureWatches), zap.Int("canceled-future-watches", r.canceledFutureWatches),
+		zap.Int("fragmented-watches", r.fragmentedWatches), zap.Int("mirrored-watches", r.mirroredWatches))
+	for _, failure := range r.failures {
+		t.Errorf("Broke watch guarantee: Fragmented watch response reassembled into event with value different than put, %s", failure)
+	}
+	for _, failure := range r.fragmentationFailures {
+		t.Errorf("Broke watch guarantee: Fragmented watch delivers the same events as watch without fragmentation, %s", failure)
+	}
+	for _, violation := range model.ValidateWatchContiguity(operations, r.watched) {
+		t.Errorf("Broke watch guarantee: Filter - watch delivers all events except the filtered out type, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchResponses(r.watched) {
+		t.Errorf("Broke watch guarantee: Compaction - watch is canceled only by compaction of revision it needs, %s", violation)
+	}
+	for _, violation := range model.ValidateWatchedPuts(operations, r.watched) {
+		t.Errorf("Broke watch guarantee: Reliable - put of watched key is observed by watch active at its revision, %s", violation)
+	}
+	// Validate watch traffic is correctly configured to ensure proper testing
+	if r.fragmentedResponses == 0 {
+		t.Errorf("No watch response exceeded fragment threshold, valueSize: %d, batchSize: %d, fragmentThreshold: %d, maxResponseSize: %d", traffic.valueSize, traffic.batchSize, traffic.fragmentThreshold, r.maxResponseSize)
+	}
+	if r.fragmentedWatches == 0 || r.fragmentedWatches == len(r.watched) || r.mirroredWatches == 0 {
+		t.Errorf("Fragmentation wasn't toggled across watches, watches: %d, fragmented watches: %d, mirrored watches: %d", len(r.watched), r.fragmentedWatches, r.mirroredWatches)
+	}
+	if traffic.deleteChance > 0 && (r.putFilteredWatches == 0 || r.deleteEvents == 0) {
+		t.Errorf("No delete event was delivered to watch filtering out puts, deleteChance: %d, put-filtered watches: %d, delete events: %d", traffic.deleteChance, r.putFilteredWatches, r.deleteEvents)
+	}
+	if traffic.futureWatchChance > 0 && (r.futureWatches == 0 || r.canceledFutureWatches == 0) {
+		t.Errorf("No watch from future revision was both caught up and canceled before reaching start revision, futureWatchChance: %d, future watches: %d, canceled future watches: %d", traffic.futureWatchChance, r.futureWatches, r.canceledFutureWatches)
+	}
+}
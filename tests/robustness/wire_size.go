@@ -0,0 +1,143 @@
+// Copyright 2023 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package robustness
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// wireSizesEnv enables recording wire sizes of requests and responses of traffic clients. It's disabled by default,
+// as gRPC stats handler is called for every message sent and received.
+const wireSizesEnv = "ROBUSTNESS_WIRE_SIZES"
+
+// recordWireSizes returns whether wireSizesEnv enables recording wire sizes.
+func recordWireSizes(t *testing.T) bool {
+	value, ok := os.LookupEnv(wireSizesEnv)
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		t.Fatalf("Failed to parse %s=%q: %v", wireSizesEnv, value, err)
+	}
+	return enabled
+}
+
+// wireSizes is gRPC stats handler accumulating wire sizes of messages sent and received by clients, per gRPC method.
+// It's used to detect regressions inflating size of requests or responses on wire.
+type wireSizes struct {
+	mux     sync.Mutex
+	methods map[string]*methodWireSize
+}
+
+// methodWireSize accumulates wire sizes of messages of a single gRPC method. Streaming methods
+// count each message sent and received over the stream.
+type methodWireSize struct {
+	requests      int
+	requestBytes  int64
+	responses     int
+	responseBytes int64
+}
+
+type methodKey struct{}
+
+func newWireSizes() *wireSizes {
+	return &wireSizes{methods: map[string]*methodWireSize{}}
+}
+
+// dialOptions returns options installing stats handler on client connection, none if sizes are not recorded.
+func (w *wireSizes) dialOptions() []grpc.DialOption {
+	if w == nil {
+		return nil
+	}
+	return []grpc.DialOption{grpc.WithStatsHandler(w)}
+}
+
+func (w *wireSizes) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, methodKey{}, info.FullMethodName)
+}
+
+func (w *wireSizes) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	method, _ := ctx.Value(methodKey{}).(string)
+	switch p := s.(type) {
+	case *stats.OutPayload:
+		w.mux.Lock()
+		defer w.mux.Unlock()
+		m := w.method(method)
+		m.requests++
+		m.requestBytes += int64(p.WireLength)
+	case *stats.InPayload:
+		w.mux.Lock()
+		defer w.mux.Unlock()
+		m := w.method(method)
+		m.responses++
+		m.responseBytes += int64(p.WireLength)
+	}
+}
+
+func (w *wireSizes) method(name string) *methodWireSize {
+	m, ok := w.methods[name]
+	if !ok {
+		m = &methodWireSize{}
+		w.methods[name] = m
+	}
+	return m
+}
+
+func (w *wireSizes) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (w *wireSizes) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+// logWireSizes logs total bytes sent and received by clients, and average message sizes of each gRPC method.
+func logWireSizes(lg *zap.Logger, w *wireSizes) {
+	if w == nil {
+		return
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	names := make([]string, 0, len(w.methods))
+	var bytesOut, bytesIn int64
+	for name, m := range w.methods {
+		names = append(names, name)
+		bytesOut += m.requestBytes
+		bytesIn += m.responseBytes
+	}
+	sort.Strings(names)
+	lg.Info("Wire sizes", zap.Int64("bytes-out", bytesOut), zap.Int64("bytes-in", bytesIn))
+	for _, name := range names {
+		m := w.methods[name]
+		lg.Info("Wire sizes of method", zap.String("method", name),
+			zap.Int("requests", m.requests), zap.Int64("avg-request-bytes", averageSize(m.requestBytes, m.requests)),
+			zap.Int("responses", m.responses), zap.Int64("avg-response-bytes", averageSize(m.responseBytes, m.responses)))
+	}
+}
+
+func averageSize(total int64, count int) int64 {
+	if count == 0 {
+		return 0
+	}
+	return total / int64(count)
+}